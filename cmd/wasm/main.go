@@ -0,0 +1,57 @@
+//go:build js && wasm
+
+// Command wasm exposes the validation, defaults and rendering of go-demo to
+// JavaScript, for client-side previews:
+//
+//	GOOS=js GOARCH=wasm go build -o go-demo.wasm ./cmd/wasm
+//
+// Once instantiated with Go's wasm_exec.js, it defines the global object
+// godemo, whose functions take and return JSON strings (see package jsapi):
+//
+//	godemo.validate(schema, data)          // {"valid": ..., "errors": [...]}
+//	godemo.applyDefaults(schema, data)     // {"data": ...}
+//	godemo.render(template, context, opts) // {"output": "..."}
+//
+// Failures are returned as {"error": "...", "code": "..."}, never thrown.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"syscall/js"
+
+	"go-demo/pkg/jsapi"
+)
+
+func main() {
+	js.Global().Set("godemo", js.ValueOf(map[string]interface{}{
+		"validate":      export(2, func(args []string) string { return jsapi.Validate(args[0], args[1]) }),
+		"applyDefaults": export(2, func(args []string) string { return jsapi.ApplyDefaults(args[0], args[1]) }),
+		"render":        export(3, func(args []string) string { return jsapi.Render(args[0], args[1], args[2]) }),
+	}))
+	// Keep the exported functions callable.
+	select {}
+}
+
+// export wraps fn as a JavaScript function of n string arguments. Other
+// values are passed through JSON.stringify, so objects may be given instead
+// of their JSON; missing, null and undefined arguments are empty strings.
+func export(n int, fn func(args []string) string) js.Func {
+	return js.FuncOf(func(this js.Value, values []js.Value) (result interface{}) {
+		defer func() {
+			if r := recover(); r != nil {
+				b, _ := json.Marshal(jsapi.Error{Error: fmt.Sprint(r)})
+				result = string(b)
+			}
+		}()
+		args := make([]string, n)
+		for i := 0; i < n && i < len(values); i++ {
+			if values[i].Type() == js.TypeString {
+				args[i] = values[i].String()
+			} else if !values[i].IsNull() && !values[i].IsUndefined() {
+				args[i] = js.Global().Get("JSON").Call("stringify", values[i]).String()
+			}
+		}
+		return fn(args)
+	})
+}
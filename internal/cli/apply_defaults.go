@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/jsonutil"
+)
+
+func init() {
+	commands["apply-defaults"] = command{
+		summary: "fill in schema defaults missing from a JSON document",
+		run:     runApplyDefaults,
+		plugins: true,
+	}
+}
+
+// emptyPolicies are the values of the -empty flag.
+var emptyPolicies = map[string]schemautil.EmptyPolicy{
+	"drop":          schemautil.DropEmpty,
+	"keep":          schemautil.KeepEmpty,
+	"keep-defaults": schemautil.KeepEmptyDefaults,
+}
+
+// runApplyDefaults applies the schema's defaults to a document (or stdin for
+// "-") and writes the result to stdout or -out, or to every document matching
+// -glob, writing the results to -out-dir. It exits 1 if the defaults can't be
+// applied, e.g. to a document matching several branches of a oneOf with
+// -strict-oneof.
+func runApplyDefaults(e *env, args []string) int {
+	fs := newFlagSet(e, "apply-defaults", "FILE")
+	schemaPath := fs.String("schema", "", "JSON Schema file")
+	out := fs.String("out", "", "write the result to this file instead of stdout (- for stdout)")
+	indent := fs.Int("indent", 2, "spaces to indent the output with (0 for compact output)")
+	fillRequired := fs.Bool("fill-required", false, "fill in the defaults of required properties too")
+	strictOneOf := fs.Bool("strict-oneof", false, "fail instead of applying no branch's defaults when the document matches several branches of a oneOf")
+	empty := fs.String("empty", "drop", "empty objects and arrays created for missing properties: drop, keep, or keep-defaults (only those with a default)")
+	batch := addBatchFlags(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	emptyPolicy, ok := emptyPolicies[*empty]
+	if *schemaPath == "" || !ok || batch.enabled() == (fs.NArg() == 1) || fs.NArg() > 1 || batch.enabled() && *out != "" {
+		fs.Usage()
+		return exitError
+	}
+
+	opts := []schemautil.DefaultsOption{schemautil.WithEmptyPolicy(emptyPolicy)}
+	if *fillRequired {
+		opts = append(opts, schemautil.FillRequired())
+	}
+	if *strictOneOf {
+		opts = append(opts, schemautil.WithBranchPolicy(schemautil.StrictBranches))
+	}
+
+	schema, err := schemautil.CompileFile(*schemaPath)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	decode := func(path string) (interface{}, error) {
+		b, err := e.readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		data, err := jsonutil.DecodeBytes(b)
+		if err != nil {
+			return nil, fmt.Errorf("decode: %w", err)
+		}
+		return data, nil
+	}
+	applyDefaults := func(data interface{}) ([]byte, error) {
+		result, err := schemautil.ApplyDefaultsCtx(context.Background(), data, schema, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("apply defaults: %w", err)
+		}
+		output, err := jsonutil.Marshal(jsonutil.FormatJSON, result, *indent)
+		if err != nil {
+			return nil, fmt.Errorf("encode: %w", err)
+		}
+		return output, nil
+	}
+	if batch.enabled() {
+		return e.runBatch(batch, "", func(path string) ([]byte, error) {
+			data, err := decode(path)
+			if err != nil {
+				return nil, err
+			}
+			return applyDefaults(data)
+		})
+	}
+
+	path := fs.Arg(0)
+	data, err := decode(path)
+	if err != nil {
+		return e.errorf("%s: %v", path, err)
+	}
+	output, err := applyDefaults(data)
+	if err != nil {
+		e.fileError(path, err)
+		return exitFailure
+	}
+	if err := e.writeFile(*out, output); err != nil {
+		return e.errorf("%v", err)
+	}
+	return exitOK
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyDefaultsCommand(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"schema.json": userSchema,
+		"user.json":   `{"name": "Alice <a@example.com>", "id": 9007199254740993}`,
+	})
+	schema := filepath.Join(dir, "schema.json")
+	data := filepath.Join(dir, "user.json")
+
+	code, stdout, stderr := run(t, "", "apply-defaults", "-schema", schema, "-indent", "0", data)
+	if code != exitOK {
+		t.Fatalf("apply-defaults failed with %d: %s", code, stderr)
+	}
+	if want := `{"id":9007199254740993,"name":"Alice <a@example.com>","role":"member"}` + "\n"; stdout != want {
+		t.Errorf("expected %s, got %s", want, stdout)
+	}
+
+	out := filepath.Join(dir, "out.json")
+	code, stdout, stderr = run(t, "", "apply-defaults", "-schema", schema, "-out", out, data)
+	if code != exitOK || stdout != "" {
+		t.Fatalf("apply-defaults -out failed with %d: %s%s", code, stdout, stderr)
+	}
+	written, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if want := "{\n  \"id\": 9007199254740993,\n  \"name\": \"Alice <a@example.com>\",\n  \"role\": \"member\"\n}\n"; string(written) != want {
+		t.Errorf("expected %s, got %s", want, written)
+	}
+
+	if code, _, _ := run(t, "", "apply-defaults", data); code != exitError {
+		t.Errorf("Missing -schema should be a usage error, got %d", code)
+	}
+}
+
+func TestApplyDefaultsOptions(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"schema.json": `{
+			"type": "object",
+			"properties": {
+				"name": {"type": "string", "default": "anonymous"},
+				"tags": {"type": "array", "default": []},
+				"meta": {"type": "object", "properties": {"note": {"type": "string"}}},
+				"contact": {"oneOf": [
+					{"type": "object", "properties": {"email": {"type": "string", "default": "a@example.com"}}},
+					{"type": "object", "properties": {"phone": {"type": "string", "default": "555"}}}
+				]}
+			},
+			"required": ["name"]
+		}`,
+		"empty.json":     `{}`,
+		"ambiguous.json": `{"name": "Ada", "contact": {}}`,
+	})
+	schema := filepath.Join(dir, "schema.json")
+	applyDefaults := func(file string, flags ...string) (int, string, string) {
+		args := append([]string{"apply-defaults", "-schema", schema, "-indent", "0"}, flags...)
+		return run(t, "", append(args, filepath.Join(dir, file))...)
+	}
+
+	tests := []struct {
+		flags []string
+		want  string
+	}{
+		{nil, `{"contact":{"email":"a@example.com","phone":"555"}}`},
+		{[]string{"-fill-required"}, `{"contact":{"email":"a@example.com","phone":"555"},"name":"anonymous"}`},
+		{[]string{"-empty", "keep"}, `{"contact":{"email":"a@example.com","phone":"555"},"meta":{},"tags":[]}`},
+		{[]string{"-empty", "keep-defaults"}, `{"contact":{"email":"a@example.com","phone":"555"},"tags":[]}`},
+	}
+	for _, tt := range tests {
+		code, stdout, stderr := applyDefaults("empty.json", tt.flags...)
+		if code != exitOK || stdout != tt.want+"\n" {
+			t.Errorf("%v: expected %s, got %d: %s%s", tt.flags, tt.want, code, stdout, stderr)
+		}
+	}
+
+	// The document matches both branches of the oneOf, whose defaults are
+	// all applied unless -strict-oneof rejects it.
+	if code, stdout, stderr := applyDefaults("ambiguous.json"); code != exitOK || stdout != `{"contact":{"email":"a@example.com","phone":"555"},"name":"Ada"}`+"\n" {
+		t.Errorf("expected the defaults of both branches, got %d: %s%s", code, stdout, stderr)
+	}
+	code, stdout, stderr := applyDefaults("ambiguous.json", "-strict-oneof")
+	if code != exitFailure || stdout != "" || !strings.Contains(stderr, "ambiguous oneOf") {
+		t.Errorf("expected -strict-oneof to fail, got %d: %s%s", code, stdout, stderr)
+	}
+
+	if code, _, _ := applyDefaults("empty.json", "-empty", "all"); code != exitError {
+		t.Errorf("An unknown -empty policy should be a usage error, got %d", code)
+	}
+}
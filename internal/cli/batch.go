@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// batchFlags are the flags of commands that can process a directory of
+// documents instead of a single file.
+type batchFlags struct {
+	glob   *string
+	outDir *string
+	jobs   *int
+}
+
+func addBatchFlags(fs *flag.FlagSet) batchFlags {
+	return batchFlags{
+		glob:   fs.String("glob", "", "process every file matching this pattern (** matches any directories) instead of a single file"),
+		outDir: fs.String("out-dir", "", "with -glob, write each result here, keeping paths relative to the pattern's base directory"),
+		jobs:   fs.Int("jobs", runtime.NumCPU(), "with -glob, number of files to process in parallel"),
+	}
+}
+
+// enabled reports whether -glob was given.
+func (b batchFlags) enabled() bool {
+	return *b.glob != ""
+}
+
+// batchSummary is the -output json result of a batch.
+type batchSummary struct {
+	Files     int `json:"files"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+}
+
+// runBatch processes every file matching the -glob pattern in parallel and
+// writes each result below -out-dir, with the file extension replaced by ext
+// if ext is not empty. Failures are reported per file on stderr, followed by a
+// summary; the exit code is exitFailure if any file failed.
+func (e *env) runBatch(b batchFlags, ext string, process func(path string) ([]byte, error)) int {
+	if *b.outDir == "" {
+		return e.errorf("-glob requires -out-dir")
+	}
+	files, err := glob(*b.glob)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	if len(files) == 0 {
+		return e.errorf("no files match %q", *b.glob)
+	}
+	root, _ := splitGlob(*b.glob)
+
+	errs := make([]error, len(files))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < max(*b.jobs, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = processFile(files[i], root, *b.outDir, ext, process)
+			}
+		}()
+	}
+	for i := range files {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	failed := 0
+	for i, err := range errs {
+		if err != nil {
+			failed++
+			e.fileError(files[i], err)
+		}
+	}
+	e.setResult(batchSummary{Files: len(files), Succeeded: len(files) - failed, Failed: failed})
+	if !e.json {
+		fmt.Fprintf(e.stderr, "%d files: %d succeeded, %d failed\n", len(files), len(files)-failed, failed)
+	}
+	if failed > 0 {
+		return exitFailure
+	}
+	return exitOK
+}
+
+// processFile processes one file of a batch and writes the result.
+func processFile(path, root, outDir, ext string, process func(path string) ([]byte, error)) error {
+	output, err := process(path)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return err
+	}
+	if ext != "" {
+		rel = strings.TrimSuffix(rel, filepath.Ext(rel)) + ext
+	}
+	out := filepath.Join(outDir, rel)
+	if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(out, output, 0o644)
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBatchApplyDefaults(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"schema.json":        userSchema,
+		"data/alice.json":    `{"name": "Alice"}`,
+		"data/team/bob.json": `{"name": "Bob", "role": "admin"}`,
+		"data/broken.json":   `{"name": `,
+	})
+	outDir := filepath.Join(dir, "build")
+
+	code, _, stderr := run(t, "", "apply-defaults", "-schema", filepath.Join(dir, "schema.json"), "-indent", "0",
+		"-glob", filepath.Join(dir, "data", "**", "*.json"), "-out-dir", outDir)
+	if code != exitFailure {
+		t.Fatalf("expected exit code %d for a broken file, got %d: %s", exitFailure, code, stderr)
+	}
+	if !strings.Contains(stderr, "broken.json: decode:") || !strings.Contains(stderr, "3 files: 2 succeeded, 1 failed") {
+		t.Errorf("expected a per-file error and a summary, got:\n%s", stderr)
+	}
+	for name, want := range map[string]string{
+		"alice.json":    `{"name":"Alice","role":"member"}`,
+		"team/bob.json": `{"name":"Bob","role":"admin"}`,
+	} {
+		b, err := os.ReadFile(filepath.Join(outDir, filepath.FromSlash(name)))
+		if err != nil || strings.TrimSpace(string(b)) != want {
+			t.Errorf("%s: expected %s, got %s (%v)", name, want, b, err)
+		}
+	}
+
+	if code, _, _ := run(t, "", "apply-defaults", "-schema", filepath.Join(dir, "schema.json"), "-glob", "*.json"); code != exitError {
+		t.Errorf("-glob without -out-dir should fail, got %d", code)
+	}
+}
+
+func TestBatchRender(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"card.html.tpl":  "<p>{{ name }}</p>",
+		"users/a.yaml":   "name: Alice\n",
+		"users/b.json":   `{"name": "Bob"}`,
+		"users/skip.txt": "",
+	})
+	outDir := filepath.Join(dir, "site")
+
+	code, _, stderr := run(t, "", "render", "-template", filepath.Join(dir, "card.html.tpl"),
+		"-glob", filepath.Join(dir, "users", "*.*[lmn]"), "-out-dir", outDir, "-jobs", "2")
+	if code != exitOK || !strings.Contains(stderr, "2 files: 2 succeeded, 0 failed") {
+		t.Fatalf("render -glob failed with %d: %s", code, stderr)
+	}
+	for name, want := range map[string]string{"a.html": "<p>Alice</p>", "b.html": "<p>Bob</p>"} {
+		if b, err := os.ReadFile(filepath.Join(outDir, name)); err != nil || string(b) != want {
+			t.Errorf("%s: expected %s, got %s (%v)", name, want, b, err)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/pongo2"
+)
+
+func init() {
+	commands["bench"] = command{
+		summary: "measure ApplyDefaults, Validate and Render performance",
+		run:     runBench,
+		plugins: true,
+	}
+}
+
+// bench is an operation to measure.
+type bench struct {
+	name string
+	fn   func() error
+}
+
+// benchResult summarizes the runs of one benchmark.
+type benchResult struct {
+	name        string
+	iterations  int
+	total       time.Duration
+	p50, p99    time.Duration
+	allocsPerOp uint64
+	bytesPerOp  uint64
+}
+
+// runBench runs the benchmarks selected by the flags and prints a table of
+// throughput, latency percentiles and allocations.
+func runBench(e *env, args []string) int {
+	fs := newFlagSet(e, "bench", "")
+	schemaPath := fs.String("schema", "", "JSON Schema file for the apply-defaults and validate benchmarks")
+	dataPath := fs.String("data", "", "document to apply defaults to and validate")
+	template := fs.String("template", "", "template file for the render benchmark")
+	contextPath := fs.String("context", "", "context document for the render benchmark")
+	iterations := fs.Int("iterations", 10000, "runs per benchmark")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 || *iterations <= 0 || (*schemaPath == "") != (*dataPath == "") || *schemaPath == "" && *template == "" {
+		fs.Usage()
+		return exitError
+	}
+
+	var benches []bench
+	if *schemaPath != "" {
+		schema, err := schemautil.CompileFile(*schemaPath)
+		if err != nil {
+			return e.errorf("%v", err)
+		}
+		data, err := e.readDocument(*dataPath, "")
+		if err != nil {
+			return e.errorf("%v", err)
+		}
+		benches = append(benches,
+			bench{"apply-defaults", func() error {
+				schemautil.ApplyDefaults(data, schema)
+				return nil
+			}},
+			bench{"validate", func() error {
+				_, err := schemautil.Validate(schema, data)
+				return err
+			}},
+		)
+	}
+
+	if *template != "" {
+		ctx, err := e.readContext(*contextPath)
+		if err != nil {
+			return e.errorf("%v", err)
+		}
+		t, err := pongo2.NewRenderer(pongo2.Options{}).FromFile(*template)
+		if err != nil {
+			return e.errorf("%v", err)
+		}
+		benches = append(benches, bench{"render", func() error {
+			_, err := t.Execute(ctx)
+			return err
+		}})
+	}
+
+	results := make([]benchResult, len(benches))
+	for i, b := range benches {
+		r, err := measure(b.name, *iterations, b.fn)
+		if err != nil {
+			return e.errorf("%s: %v", b.name, err)
+		}
+		results[i] = r
+	}
+
+	w := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "benchmark\titerations\tops/s\tmean\tp50\tp99\tallocs/op\tB/op")
+	for _, r := range results {
+		mean := r.total / time.Duration(r.iterations)
+		opsPerSec := float64(r.iterations) / r.total.Seconds()
+		fmt.Fprintf(w, "%s\t%d\t%.0f\t%v\t%v\t%v\t%d\t%d\n",
+			r.name, r.iterations, opsPerSec, mean, r.p50, r.p99, r.allocsPerOp, r.bytesPerOp)
+	}
+	w.Flush()
+	return exitOK
+}
+
+// measure runs fn n times, timing each run, and stops at the first error.
+// Allocations are averaged over all runs.
+func measure(name string, n int, fn func() error) (benchResult, error) {
+	durations := make([]time.Duration, n)
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for i := range durations {
+		opStart := time.Now()
+		if err := fn(); err != nil {
+			return benchResult{}, err
+		}
+		durations[i] = time.Since(opStart)
+	}
+	total := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	return benchResult{
+		name:        name,
+		iterations:  n,
+		total:       total,
+		p50:         percentile(durations, 50),
+		p99:         percentile(durations, 99),
+		allocsPerOp: (after.Mallocs - before.Mallocs) / uint64(n),
+		bytesPerOp:  (after.TotalAlloc - before.TotalAlloc) / uint64(n),
+	}, nil
+}
+
+// percentile returns the p-th percentile of sorted durations (nearest rank).
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (len(sorted)*p + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBenchCommand(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"schema.json":  userSchema,
+		"user.json":    `{"name": "Alice"}`,
+		"hello.tpl":    "Hello {{ name }}!",
+		"context.yaml": "name: Alice\n",
+	})
+	p := func(name string) string { return filepath.Join(dir, name) }
+
+	code, stdout, stderr := run(t, "", "bench", "-iterations", "50",
+		"-schema", p("schema.json"), "-data", p("user.json"),
+		"-template", p("hello.tpl"), "-context", p("context.yaml"))
+	if code != exitOK {
+		t.Fatalf("bench failed with %d: %s", code, stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "benchmark") {
+		t.Fatalf("unexpected output:\n%s", stdout)
+	}
+	for i, name := range []string{"apply-defaults", "validate", "render"} {
+		fields := strings.Fields(lines[i+1])
+		if len(fields) != 8 || fields[0] != name || fields[1] != "50" {
+			t.Errorf("unexpected row for %s: %q", name, lines[i+1])
+		}
+	}
+
+	if code, _, _ := run(t, "", "bench", "-schema", p("schema.json")); code != exitError {
+		t.Errorf("-schema without -data should be a usage error, got %d", code)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	durations := make([]time.Duration, 100)
+	for i := range durations {
+		durations[i] = time.Duration(i+1) * time.Millisecond
+	}
+	if got := percentile(durations, 99); got != 99*time.Millisecond {
+		t.Errorf("p99 = %v, want 99ms", got)
+	}
+	if got := percentile(durations[:1], 99); got != time.Millisecond {
+		t.Errorf("p99 of one run = %v, want 1ms", got)
+	}
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/jsonutil"
+)
+
+func init() {
+	commands["bundle"] = command{
+		summary: "inline the external $refs of a schema into a single file",
+		run:     runBundle,
+	}
+}
+
+// runBundle writes the schema in -root with every external $ref inlined to
+// stdout or -out, after checking that the bundle compiles on its own.
+func runBundle(e *env, args []string) int {
+	fs := newFlagSet(e, "bundle", "")
+	root := fs.String("root", "", "root schema file")
+	out := fs.String("out", "", "write the bundle to this file instead of stdout (- for stdout)")
+	indent := fs.Int("indent", 2, "spaces to indent the output with (0 for compact output)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *root == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return exitError
+	}
+
+	bundled, err := schemautil.Bundle(*root)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	b, err := jsonutil.Marshal(jsonutil.FormatJSON, bundled, *indent)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	if _, err := schemautil.CompileString(string(b)); err != nil {
+		return e.errorf("%s: bundle doesn't compile: %v", *root, err)
+	}
+	if err := e.writeFile(*out, b); err != nil {
+		return e.errorf("%v", err)
+	}
+	return exitOK
+}
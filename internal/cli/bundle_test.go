@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/jsonutil"
+)
+
+func TestBundleCommand(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"api.schema.json":     `{"type": "object", "properties": {"address": {"$ref": "common/address.json"}}}`,
+		"common/address.json": `{"type": "object", "properties": {"zip": {"type": "string", "pattern": "^\\d{5}$"}}}`,
+		"broken.json":         `{"$ref": "common/missing.json"}`,
+	})
+	out := filepath.Join(dir, "bundled.json")
+
+	code, stdout, stderr := run(t, "", "bundle", "-root", filepath.Join(dir, "api.schema.json"), "-out", out)
+	if code != exitOK || stdout != "" {
+		t.Fatalf("bundle failed with %d: %s%s", code, stdout, stderr)
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Failed to read the bundle: %v", err)
+	}
+	if strings.Contains(string(b), "common/") {
+		t.Errorf("expected no external $refs, got %s", b)
+	}
+	// The bundle must work without the files it was built from.
+	if err := os.RemoveAll(filepath.Join(dir, "common")); err != nil {
+		t.Fatalf("Failed to remove common: %v", err)
+	}
+	schema, err := schemautil.CompileFile(out)
+	if err != nil {
+		t.Fatalf("Failed to compile the bundle: %v", err)
+	}
+	doc, _ := jsonutil.DecodeBytes([]byte(`{"address": {"zip": "abc"}}`))
+	if violations, _ := schemautil.Validate(schema, doc); len(violations) != 1 {
+		t.Errorf("expected the inlined pattern to apply, got %+v", violations)
+	}
+
+	if code, _, stderr := run(t, "", "bundle", "-root", filepath.Join(dir, "broken.json")); code != exitError || !strings.Contains(stderr, "missing.json") {
+		t.Errorf("expected an error for a missing $ref, got %d: %s", code, stderr)
+	}
+	if code, _, _ := run(t, "", "bundle"); code != exitError {
+		t.Errorf("Missing -root should be a usage error, got %d", code)
+	}
+}
@@ -0,0 +1,164 @@
+// Package cli implements the go-demo command-line tool.
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Exit codes returned by Run.
+const (
+	exitOK      = 0 // success
+	exitFailure = 1 // the input was processed but failed a check (e.g. invalid data)
+	exitError   = 2 // usage error or the command could not run
+)
+
+// env is what a command reads from and writes to.
+type env struct {
+	stdin          io.Reader
+	stdout, stderr io.Writer
+
+	// json is set by -output json: stdout is then buffered and a result
+	// envelope is printed when the command finishes.
+	json     bool
+	out      *stdoutWriter
+	envelope envelope
+}
+
+// errorf reports an error on stderr and returns exitError.
+func (e *env) errorf(format string, args ...interface{}) int {
+	if e.json {
+		e.envelope.Errors = append(e.envelope.Errors, problem{Message: fmt.Sprintf(format, args...)})
+		return exitError
+	}
+	fmt.Fprintf(e.stderr, "go-demo: "+format+"\n", args...)
+	return exitError
+}
+
+// command is a subcommand of the tool.
+type command struct {
+	summary string
+	run     func(e *env, args []string) int
+
+	// plugins is set for commands that render templates or apply schemas,
+	// which may use the filters and formats of plugins: the plugins are
+	// started before they run. Plugin commands start them anyway.
+	plugins bool
+}
+
+var commands = map[string]command{}
+
+// Run executes the command line args (without the program name) and returns
+// the process exit code. A leading "-output json" applies to the command.
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	e := &env{stdin: stdin, stderr: stderr}
+	e.out = &stdoutWriter{e: e, w: stdout}
+	e.stdout = e.out
+
+	global := flag.NewFlagSet("go-demo", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	global.Usage = func() { usage(stderr) }
+	global.Var(outputFlag{e}, "output", "output format: text or json")
+	if err := global.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitError
+	}
+	args = global.Args()
+
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		// The usage lists the commands of plugins too.
+		loadPlugins(e)
+		usage(e.stderr)
+		if len(args) == 0 {
+			return exitError
+		}
+		return exitOK
+	}
+
+	// Plugins are processes; only start them for commands that use them
+	// or may be theirs.
+	cmd, ok := commands[args[0]]
+	if !ok || cmd.plugins {
+		loadPlugins(e)
+		cmd, ok = commands[args[0]]
+	}
+	if !ok {
+		fmt.Fprintf(e.stderr, "go-demo: unknown command %q\n", args[0])
+		usage(e.stderr)
+		return exitError
+	}
+
+	start := time.Now()
+	code := cmd.run(e, args[1:])
+	if e.json {
+		e.writeEnvelope(args[0], code, start, stdout)
+	}
+	return code
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: go-demo [-output json] <command> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-16s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'go-demo <command> -h' for the flags of a command. Every command")
+	fmt.Fprintln(w, "accepts -output json to print a result envelope instead of its usual output.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "exit codes:")
+	fmt.Fprintln(w, "  0  success")
+	fmt.Fprintln(w, "  1  the input was processed but failed a check (e.g. invalid data)")
+	fmt.Fprintln(w, "  2  usage error or the command could not run")
+}
+
+// newFlagSet returns a flag set for a command that reports errors to e.stderr
+// instead of exiting.
+func newFlagSet(e *env, name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Var(outputFlag{e}, "output", "output format: text or json")
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "usage: go-demo %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses args and returns the exit code to use if parsing failed.
+// Flags may follow positional arguments ("lint-template FILE -output json");
+// everything after "--" is positional.
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if err == flag.ErrHelp {
+				return exitOK, false
+			}
+			return exitError, false
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			break
+		}
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			positional = append(positional, rest...)
+			break
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+	_ = fs.Parse(append([]string{"--"}, positional...))
+	return 0, true
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// run executes the CLI with args and returns the exit code, stdout and stderr.
+func run(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := Run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// writeFiles creates files (name -> content) in a temporary directory and
+// returns its path.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestRunUsage(t *testing.T) {
+	code, _, stderr := run(t, "")
+	if code != exitError || !strings.Contains(stderr, "validate") {
+		t.Errorf("Running without a command should print usage, got %d: %s", code, stderr)
+	}
+
+	code, _, stderr = run(t, "", "frobnicate")
+	if code != exitError || !strings.Contains(stderr, `unknown command "frobnicate"`) {
+		t.Errorf("Unknown commands should be rejected, got %d: %s", code, stderr)
+	}
+
+	code, _, _ = run(t, "", "help")
+	if code != exitOK {
+		t.Errorf("help should succeed, got %d", code)
+	}
+}
+
+func TestParseFlagsInterspersed(t *testing.T) {
+	tests := []struct {
+		args       []string
+		format     string
+		positional []string
+	}{
+		{[]string{"a", "-format", "json", "b"}, "json", []string{"a", "b"}},
+		{[]string{"-format", "json", "a"}, "json", []string{"a"}},
+		{[]string{"a", "--", "-format", "json"}, "text", []string{"a", "-format", "json"}},
+	}
+	for _, tt := range tests {
+		fs := newFlagSet(&env{stderr: io.Discard}, "test", "")
+		format := fs.String("format", "text", "")
+		if _, ok := parseFlags(fs, tt.args); !ok {
+			t.Fatalf("parseFlags(%v) failed", tt.args)
+		}
+		if *format != tt.format || !reflect.DeepEqual(fs.Args(), tt.positional) {
+			t.Errorf("parseFlags(%v): format %q, args %v; want %q, %v", tt.args, *format, fs.Args(), tt.format, tt.positional)
+		}
+	}
+}
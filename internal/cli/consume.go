@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/nats-io/nats.go"
+
+	"go-demo/pkg/pipeline"
+	"go-demo/pkg/stream"
+)
+
+func init() {
+	commands["consume"] = command{
+		summary: "transform the documents of message queue topics (Kafka or NATS)",
+		run:     runConsume,
+		plugins: true,
+	}
+}
+
+// runConsume runs a stream.Transformer configured by a stream.LoadConfig
+// file until interrupted.
+func runConsume(e *env, args []string) int {
+	fs := newFlagSet(e, "consume", "")
+	configPath := fs.String("config", "", "transformer configuration file: JSON, YAML or TOML")
+	maxMessages := fs.Int("max-messages", 0, "stop after this many messages (0 for no limit)")
+	logLevel := fs.String("log-level", "info", "log events of at least this level: debug (branches and renders), info, warn (failed messages) or error")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *configPath == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return exitError
+	}
+
+	logger, err := e.logger(*logLevel)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	cfg, err := stream.LoadConfig(*configPath)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	t, err := cfg.Transformer(pipeline.Pipeline{Logger: logger})
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	t.Logger = logger
+
+	switch cfg.Broker {
+	case "kafka":
+		k, err := stream.NewKafka(stream.KafkaConfig{Brokers: cfg.Brokers, GroupID: cfg.Group, Topics: cfg.Topics()})
+		if err != nil {
+			return e.errorf("%v", err)
+		}
+		defer k.Close()
+		t.Consumer, t.Publisher = k, k
+	case "nats":
+		url := cfg.URL
+		if url == "" {
+			url = nats.DefaultURL
+		}
+		conn, err := nats.Connect(url)
+		if err != nil {
+			return e.errorf("can't connect to %s: %v", url, err)
+		}
+		defer conn.Close()
+		n, err := stream.NewNATS(conn, cfg.Group, cfg.Topics()...)
+		if err != nil {
+			return e.errorf("%v", err)
+		}
+		defer n.Close()
+		t.Consumer, t.Publisher = n, n
+	}
+	if *maxMessages > 0 {
+		t.Consumer = &limitConsumer{Consumer: t.Consumer, left: *maxMessages}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	logger.Info("consuming", "broker", cfg.Broker, "topics", cfg.Topics())
+	if err := t.Run(ctx); err != nil {
+		return e.errorf("%v", err)
+	}
+	return exitOK
+}
+
+// limitConsumer ends the stream after a number of messages.
+type limitConsumer struct {
+	stream.Consumer
+	left int
+}
+
+func (c *limitConsumer) Fetch(ctx context.Context) (stream.Message, error) {
+	if c.left == 0 {
+		return stream.Message{}, io.EOF
+	}
+	m, err := c.Consumer.Fetch(ctx)
+	if err == nil {
+		c.left--
+	}
+	return m, err
+}
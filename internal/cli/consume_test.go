@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+
+	"go-demo/pkg/stream"
+)
+
+func TestConsumeCommand(t *testing.T) {
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatalf("Failed to create NATS server: %v", err)
+	}
+	go srv.Start()
+	defer srv.Shutdown()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS server didn't start")
+	}
+	conn, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("Failed to connect to NATS: %v", err)
+	}
+	defer conn.Close()
+	results, err := conn.SubscribeSync("cards.>")
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	dir := writeFiles(t, map[string]string{
+		"types.yaml":  "types:\n  card:\n    schema: user.json\n    template: card.txt\n",
+		"user.json":   userSchema,
+		"card.txt":    "{{ name }} ({{ role }})",
+		"stream.yaml": "broker: nats\nurl: " + srv.ClientURL() + "\ntypes: types.yaml\nroutes:\n  - {topic: users, type: card, output: cards.out, errors: cards.errors}\n",
+	})
+	subscriptions := srv.NumSubscriptions()
+	done := make(chan int)
+	var stderr string
+	go func() {
+		var code int
+		code, _, stderr = run(t, "", "consume", "-config", filepath.Join(dir, "stream.yaml"), "-max-messages", "2")
+		done <- code
+	}()
+
+	// Wait for the command to subscribe.
+	for deadline := time.Now().Add(5 * time.Second); srv.NumSubscriptions() == subscriptions; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("consume didn't subscribe")
+		}
+	}
+	conn.Publish("users", []byte(`{"name": "Alice"}`))
+	card, err := results.NextMsg(5 * time.Second)
+	if err != nil {
+		t.Fatalf("expected a card: %v", err)
+	}
+	if card.Subject != "cards.out" || string(card.Data) != "Alice (member)" {
+		t.Errorf("expected the rendered card, got %s: %q", card.Subject, card.Data)
+	}
+	conn.Publish("users", []byte(`{"age": 3}`))
+	m, err := results.NextMsg(5 * time.Second)
+	if err != nil {
+		t.Fatalf("expected an error message: %v", err)
+	}
+	var failure stream.ErrorMessage
+	if err := json.Unmarshal(m.Data, &failure); err != nil || m.Subject != "cards.errors" || failure.Code != "validation_error" || m.Header.Get(stream.HeaderErrorCode) != "validation_error" {
+		t.Errorf("unexpected error message %s: %q (%v)", m.Subject, m.Data, err)
+	}
+
+	select {
+	case code := <-done:
+		if code != exitOK {
+			t.Errorf("expected exit code 0, got %d: %s", code, stderr)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("consume didn't stop after -max-messages")
+	}
+	if !strings.Contains(stderr, "msg=consuming broker=nats topics=[users]") || !strings.Contains(stderr, `msg="message failed" topic=users`) {
+		t.Errorf("expected the consumer's log, got:\n%s", stderr)
+	}
+}
+
+func TestConsumeCommandErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"types.yaml": "types:\n  card:\n    template: card.txt\n",
+		"card.txt":   "{{ name }}",
+		"kafka.yaml": "broker: kafka\ntypes: types.yaml\nroutes: [{topic: users, type: card}]\n",
+		"other.yaml": "broker: kafka\nbrokers: [localhost:1]\ngroup: g\ntypes: types.yaml\nroutes: [{topic: users, type: invoice}]\n",
+	})
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"-config", filepath.Join(dir, "kafka.yaml")}, "kafka: no brokers"},
+		{[]string{"-config", filepath.Join(dir, "other.yaml")}, `topic users: unknown document type "invoice"`},
+		{[]string{"-config", filepath.Join(dir, "missing.yaml")}, "no such file"},
+	}
+	for _, tt := range tests {
+		code, _, stderr := run(t, "", append([]string{"consume"}, tt.args...)...)
+		if code != exitError || !strings.Contains(stderr, tt.err) {
+			t.Errorf("%v: expected %q, got %d: %s", tt.args, tt.err, code, stderr)
+		}
+	}
+	if code, _, _ := run(t, "", "consume"); code != exitError {
+		t.Errorf("missing -config should be a usage error, got %d", code)
+	}
+}
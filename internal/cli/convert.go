@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"go-demo/pkg/jsonutil"
+)
+
+func init() {
+	commands["convert"] = command{
+		summary: "convert a document between JSON, YAML and TOML",
+		run:     runConvert,
+	}
+}
+
+// runConvert decodes a document (or stdin for "-") and writes it in another
+// format to stdout or -out. Formats default to the file extensions.
+func runConvert(e *env, args []string) int {
+	fs := newFlagSet(e, "convert", "FILE")
+	from := fs.String("from", "", "input format: json, yaml or toml (default: from the file extension, json for stdin)")
+	to := fs.String("to", "", "output format: json, yaml or toml (default: from the -out extension)")
+	out := fs.String("out", "", "write the result to this file instead of stdout (- for stdout)")
+	indent := fs.Int("indent", 2, "spaces to indent the output with")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 1 || *to == "" && (*out == "" || *out == stdio) {
+		fs.Usage()
+		return exitError
+	}
+	path := fs.Arg(0)
+	var err error
+
+	var inFormat jsonutil.Format
+	if *from != "" {
+		if inFormat, err = jsonutil.ParseFormat(*from); err != nil {
+			return e.errorf("%v", err)
+		}
+	}
+	outFormat, err := formatFlag(*to, *out)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+
+	data, err := e.readDocument(path, inFormat)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	output, err := jsonutil.Marshal(outFormat, data, *indent)
+	if err != nil {
+		return e.errorf("%s: encode %s: %v", path, outFormat, err)
+	}
+	if err := e.writeFile(*out, output); err != nil {
+		return e.errorf("%v", err)
+	}
+	return exitOK
+}
+
+// formatFlag returns the format named by a flag, or else the format of path.
+func formatFlag(name, path string) (jsonutil.Format, error) {
+	if name != "" {
+		return jsonutil.ParseFormat(name)
+	}
+	return jsonutil.FormatFromPath(path)
+}
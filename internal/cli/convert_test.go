@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConvertCommand(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.yaml": "name: demo\nid: 12345678901234567890\nports: [80, 443]\n",
+	})
+	input := filepath.Join(dir, "config.yaml")
+
+	code, stdout, stderr := run(t, "", "convert", "-to", "json", "-indent", "0", input)
+	if code != exitOK {
+		t.Fatalf("convert failed with %d: %s", code, stderr)
+	}
+	if want := `{"id":12345678901234567890,"name":"demo","ports":[80,443]}` + "\n"; stdout != want {
+		t.Errorf("expected %s, got %s", want, stdout)
+	}
+
+	out := filepath.Join(dir, "config.toml")
+	code, _, stderr = run(t, "", "convert", "-from", "yaml", "-out", out, input)
+	if code != exitError {
+		t.Errorf("Converting an integer beyond int64 to TOML should fail, got %d: %s", code, stderr)
+	}
+
+	small := filepath.Join(dir, "small.yaml")
+	if err := os.WriteFile(small, []byte("name: demo\nports: [80, 443]\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	if code, _, stderr := run(t, "", "convert", "-out", out, small); code != exitOK {
+		t.Fatalf("convert -out failed with %d: %s", code, stderr)
+	}
+	written, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if want := "name = \"demo\"\nports = [80, 443]\n"; string(written) != want {
+		t.Errorf("expected %q, got %q", want, written)
+	}
+
+	if code, _, _ := run(t, "", "convert", input); code != exitError {
+		t.Errorf("Missing -to and -out should be a usage error, got %d", code)
+	}
+	if code, _, _ := run(t, "", "convert", "-to", "xml", input); code != exitError {
+		t.Errorf("Unknown format should fail, got %d", code)
+	}
+}
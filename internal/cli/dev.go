@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+
+	"go-demo/pkg/cache"
+	"go-demo/pkg/devserver"
+	"go-demo/pkg/pongo2"
+)
+
+func init() {
+	commands["dev"] = command{
+		summary: "serve a template with a sample context, reloading on save",
+		run:     runDev,
+		plugins: true,
+	}
+}
+
+// runDev serves the template rendered with the sample context until interrupted.
+func runDev(e *env, args []string) int {
+	fs := newFlagSet(e, "dev", "")
+	addr := fs.String("addr", "localhost:8000", "address to listen on")
+	template := fs.String("template", "", "template file to render")
+	sample := fs.String("context", "", "sample context file (JSON or YAML)")
+	dirs := fs.String("dirs", "", "comma-separated template directories, highest priority first")
+	trim := fs.Bool("trim-blocks", false, "remove the first newline after block tags")
+	lstrip := fs.Bool("lstrip-blocks", false, "strip whitespace before block tags")
+	logLevel := fs.String("log-level", "info", "log events of at least this level: debug (compiles and renders), info, warn (failures) or error")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *template == "" {
+		fs.Usage()
+		return exitError
+	}
+
+	logger, err := e.logger(*logLevel)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+
+	cfg := devserver.Config{
+		Template: *template,
+		Context:  *sample,
+		Options:  pongo2.Options{TrimBlocks: *trim, LStripBlocks: *lstrip, Logger: logger},
+		Cache:    cache.New(cache.Config{}),
+	}
+	if *dirs != "" {
+		cfg.Options.TemplateDirs = strings.Split(*dirs, ",")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	logger.Info("serving template", "template", *template, "url", "http://"+*addr)
+	if err := devserver.ListenAndServe(ctx, *addr, cfg); err != nil {
+		return e.errorf("%v", err)
+	}
+	return exitOK
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/jsonutil"
+)
+
+func init() {
+	commands["explain"] = command{
+		summary: "show which defaults apply-defaults would fill in, without changing anything",
+		run:     runExplain,
+		plugins: true,
+	}
+}
+
+// runExplain prints, for every data file (or stdin for "-"), the defaults
+// apply-defaults would fill in and the oneOf/anyOf branches it would take
+// them from:
+//
+//	order.json: /currency: default "EUR"
+//	order.json: /payment: oneOf branch 1 selected (#/properties/payment/oneOf/1)
+func runExplain(e *env, args []string) int {
+	fs := newFlagSet(e, "explain", "FILE...")
+	schemaPath := fs.String("schema", "", "JSON Schema file")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *schemaPath == "" || fs.NArg() == 0 {
+		fs.Usage()
+		return exitError
+	}
+
+	schema, err := schemautil.CompileFile(*schemaPath)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+
+	code := exitOK
+	results := make([]explainResult, 0, fs.NArg())
+	for _, path := range fs.Args() {
+		b, err := e.readFile(path)
+		if err != nil {
+			e.fileError(path, err)
+			code = exitError
+			continue
+		}
+		data, err := jsonutil.DecodeBytes(b)
+		if err != nil {
+			e.fileError(path, fmt.Errorf("decode: %w", err))
+			code = exitError
+			continue
+		}
+		explanation := schemautil.ExplainDefaults(data, schema)
+		results = append(results, explainResult{File: path, Explanation: explanation})
+		if !e.json {
+			e.printExplanation(path, explanation)
+		}
+	}
+	e.setResult(results)
+	return code
+}
+
+// explainResult is the outcome for one file in the -output json result.
+type explainResult struct {
+	File string `json:"file"`
+	*schemautil.Explanation
+}
+
+func (e *env) printExplanation(path string, x *schemautil.Explanation) {
+	if len(x.Defaults) == 0 && len(x.Branches) == 0 {
+		fmt.Fprintf(e.stdout, "%s: no defaults to apply\n", path)
+		return
+	}
+	for _, b := range x.Branches {
+		where := make([]string, len(b.Locations))
+		for i, loc := range b.Locations {
+			where[i] = shortLocation(loc)
+		}
+		switch {
+		case b.Keyword == "then" || b.Keyword == "else":
+			fmt.Fprintf(e.stdout, "%s: %s: %s branch of if selected (%s)\n", path, pointerOrRoot(b.Pointer), b.Keyword, strings.Join(where, ", "))
+		case b.Keyword == "dependentSchemas" || b.Keyword == "dependencies":
+			fmt.Fprintf(e.stdout, "%s: %s: dependent schema applies (%s)\n", path, pointerOrRoot(b.Pointer), strings.Join(where, ", "))
+		case b.Matched:
+			fmt.Fprintf(e.stdout, "%s: %s: %s branch %s selected (%s)\n", path, pointerOrRoot(b.Pointer), b.Keyword, joinInts(b.Selected), strings.Join(where, ", "))
+		default:
+			fmt.Fprintf(e.stdout, "%s: %s: %s has no single matching branch, defaults of all %d apply\n", path, pointerOrRoot(b.Pointer), b.Keyword, len(b.Selected))
+		}
+	}
+	for _, d := range x.Defaults {
+		v, err := jsonutil.Marshal(jsonutil.FormatJSON, d.Value, 0)
+		if err != nil {
+			v = []byte(fmt.Sprint(d.Value))
+		}
+		fmt.Fprintf(e.stdout, "%s: %s: default %s\n", path, pointerOrRoot(d.Pointer), bytes.TrimSpace(v))
+	}
+}
+
+// shortLocation drops the document URL from a schema location, keeping the
+// fragment, e.g. "#/properties/payment/oneOf/1".
+func shortLocation(loc string) string {
+	if i := strings.Index(loc, "#"); i >= 0 {
+		return loc[i:]
+	}
+	return loc
+}
+
+func joinInts(ints []int) string {
+	s := make([]string, len(ints))
+	for i, n := range ints {
+		s[i] = fmt.Sprint(n)
+	}
+	return strings.Join(s, ", ")
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExplainCommand(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"schema.json": `{
+			"type": "object",
+			"properties": {
+				"role": {"type": "string", "default": "member"},
+				"payment": {"oneOf": [
+					{"properties": {"kind": {"const": "card"}, "network": {"default": "visa"}}, "required": ["kind"]},
+					{"properties": {"kind": {"const": "bank"}, "country": {"default": "DE"}}, "required": ["kind"]}
+				]}
+			}
+		}`,
+		"order.json":    `{"payment": {"kind": "card"}}`,
+		"complete.json": `{"role": "admin", "payment": null}`,
+	})
+	schema := filepath.Join(dir, "schema.json")
+	order := filepath.Join(dir, "order.json")
+	complete := filepath.Join(dir, "complete.json")
+
+	code, stdout, stderr := run(t, "", "explain", "-schema", schema, order, complete)
+	if code != exitOK {
+		t.Fatalf("explain failed with %d: %s", code, stderr)
+	}
+	want := order + ": /payment: oneOf branch 0 selected (#/properties/payment/oneOf/0)\n" +
+		order + ": /payment/network: default \"visa\"\n" +
+		order + ": /role: default \"member\"\n" +
+		complete + ": no defaults to apply\n"
+	if stdout != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, stdout)
+	}
+
+	if b, _ := os.ReadFile(order); string(b) != `{"payment": {"kind": "card"}}` {
+		t.Errorf("explain modified the data: %s", b)
+	}
+
+	code, stdout, _ = run(t, "", "-output", "json", "explain", "-schema", schema, order)
+	if code != exitOK || !strings.Contains(stdout, `"pointer": "/role"`) || !strings.Contains(stdout, `"keyword": "oneOf"`) {
+		t.Errorf("expected defaults and branches in the JSON result, got %d: %s", code, stdout)
+	}
+
+	conditional := writeFiles(t, map[string]string{
+		"schema.json": `{"if": {"required": ["express"]}, "then": {"properties": {"days": {"default": 1}}}, "else": {"properties": {"days": {"default": 5}}}}`,
+		"order.json":  `{}`,
+	})
+	code, stdout, _ = run(t, "", "explain", "-schema", filepath.Join(conditional, "schema.json"), filepath.Join(conditional, "order.json"))
+	if code != exitOK || !strings.Contains(stdout, ": /: else branch of if selected (#/else)\n") {
+		t.Errorf("expected the else branch, got %d: %s", code, stdout)
+	}
+
+	if code, _, _ := run(t, "", "explain", order); code != exitError {
+		t.Errorf("Missing -schema should be a usage error, got %d", code)
+	}
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/jsonutil"
+)
+
+func init() {
+	commands["gen-schema"] = command{
+		summary: "generate a JSON Schema from sample documents or a Go struct",
+		run:     runGenSchema,
+	}
+}
+
+// runGenSchema writes a schema inferred from sample documents or generated
+// from a Go struct to stdout.
+func runGenSchema(e *env, args []string) int {
+	fs := newFlagSet(e, "gen-schema", "[MORE-SAMPLES...]")
+	sample := fs.String("from-sample", "", "infer the schema from this JSON, YAML or TOML document (and any further arguments); - for stdin")
+	structRef := fs.String("from-struct", "", "generate the schema for a struct in a package directory, e.g. ./pkg/types.User")
+	draftName := fs.String("draft", "draft-07", "schema draft: draft-07 or 2020-12")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if (*sample == "") == (*structRef == "") || *structRef != "" && fs.NArg() > 0 {
+		fs.Usage()
+		return exitError
+	}
+	draft, err := schemautil.ParseDraft(*draftName)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+
+	var schema map[string]interface{}
+	if *structRef != "" {
+		dot := strings.LastIndex(*structRef, ".")
+		if dot <= strings.LastIndex(*structRef, "/") {
+			return e.errorf("-from-struct: expected DIR.Type, got %q", *structRef)
+		}
+		dir, typeName := (*structRef)[:dot], (*structRef)[dot+1:]
+		if schema, err = schemautil.FromStruct(filepath.FromSlash(dir), typeName, draft); err != nil {
+			return e.errorf("%v", err)
+		}
+	} else {
+		var samples []interface{}
+		for _, path := range append([]string{*sample}, fs.Args()...) {
+			doc, err := e.readDocument(path, "")
+			if err != nil {
+				return e.errorf("%v", err)
+			}
+			samples = append(samples, doc)
+		}
+		schema = schemautil.InferSchema(draft, samples...)
+	}
+
+	b, err := jsonutil.Marshal(jsonutil.FormatJSON, schema, 2)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	if _, err := e.stdout.Write(b); err != nil {
+		return e.errorf("%v", err)
+	}
+	return exitOK
+}
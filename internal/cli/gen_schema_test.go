@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenSchemaCommand(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.json":         `{"name": "Alice", "age": 30}`,
+		"b.yaml":         "name: Bob\n",
+		"types/user.go":  "package types\n\ntype User struct {\n\tName string `json:\"name\"`\n}\n",
+		"types/other.go": "package types\n",
+	})
+
+	code, stdout, stderr := run(t, "", "gen-schema", "-from-sample", filepath.Join(dir, "a.json"), filepath.Join(dir, "b.yaml"))
+	if code != exitOK {
+		t.Fatalf("gen-schema -from-sample failed with %d: %s", code, stderr)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(stdout), &schema); err != nil {
+		t.Fatalf("Invalid JSON output: %v\n%s", err, stdout)
+	}
+	if schema["$schema"] != "http://json-schema.org/draft-07/schema#" || len(schema["required"].([]interface{})) != 1 {
+		t.Errorf("unexpected schema: %s", stdout)
+	}
+
+	code, stdout, stderr = run(t, "", "gen-schema", "-draft", "2020-12", "-from-struct", filepath.Join(dir, "types")+".User")
+	if code != exitOK {
+		t.Fatalf("gen-schema -from-struct failed with %d: %s", code, stderr)
+	}
+	want := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "properties": {
+    "name": {
+      "type": "string"
+    }
+  },
+  "required": [
+    "name"
+  ],
+  "type": "object"
+}
+`
+	if stdout != want {
+		t.Errorf("expected %s, got %s", want, stdout)
+	}
+
+	for _, args := range [][]string{
+		{"gen-schema"},
+		{"gen-schema", "-from-sample", "a.json", "-from-struct", "./types.User"},
+		{"gen-schema", "-from-struct", "./types"},
+	} {
+		if code, _, _ := run(t, "", args...); code != exitError {
+			t.Errorf("%v should fail with %d, got %d", args, exitError, code)
+		}
+	}
+}
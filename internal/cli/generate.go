@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"context"
+	"errors"
+
+	"go-demo/pkg/audit"
+	"go-demo/pkg/jsonutil"
+	"go-demo/pkg/pipeline"
+)
+
+func init() {
+	commands["generate"] = command{
+		summary: "generate a document of a registered type from its data",
+		run:     runGenerate,
+		plugins: true,
+	}
+}
+
+// runGenerate generates a document of a type from a types file (see
+// pipeline.LoadRegistry): it fills in the defaults of the type's schema,
+// validates the data and renders the type's template with it. Invalid data
+// is reported like validate does.
+func runGenerate(e *env, args []string) int {
+	fs := newFlagSet(e, "generate", "FILE")
+	typesPath := fs.String("types", "", "document types file: JSON, YAML or TOML")
+	typeName := fs.String("type", "", "document type to generate")
+	out := fs.String("out", "", "write the output to this file instead of stdout (- for stdout)")
+	auditPath := fs.String("audit", "", "append a record of how each document was generated to this JSONL file")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *typesPath == "" || *typeName == "" || fs.NArg() != 1 {
+		fs.Usage()
+		return exitError
+	}
+
+	base := pipeline.Pipeline{}
+	if *auditPath != "" {
+		log, err := audit.OpenFile(*auditPath)
+		if err != nil {
+			return e.errorf("%v", err)
+		}
+		defer log.Close()
+		base.Audit = log
+	}
+	registry, err := pipeline.LoadRegistry(*typesPath, base)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	path := fs.Arg(0)
+	doc, err := e.readDocument(path, "")
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	data, err := jsonutil.Marshal(jsonutil.FormatJSON, doc, 0)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	output, report, err := registry.Generate(audit.WithDocument(context.Background(), path), *typeName, data)
+	if errors.Is(err, pipeline.ErrInvalid) {
+		for _, v := range report.Violations {
+			e.violation(path, v)
+		}
+		return exitFailure
+	}
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	if err := e.writeFile(*out, output); err != nil {
+		return e.errorf("%v", err)
+	}
+	return exitOK
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-demo/pkg/audit"
+)
+
+func TestGenerateCommand(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"types.yaml":    "types:\n  card:\n    schema: user.json\n    template: card.txt\n",
+		"user.json":     userSchema,
+		"card.txt":      "{{ name }} ({{ role }})",
+		"alice.yaml":    "name: Alice\n",
+		"nameless.json": `{"age": 3}`,
+	})
+	types := filepath.Join(dir, "types.yaml")
+
+	code, stdout, stderr := run(t, "", "generate", "-types", types, "-type", "card", filepath.Join(dir, "alice.yaml"))
+	if code != exitOK || stdout != "Alice (member)" {
+		t.Errorf("expected the rendered card, got %d: %q %s", code, stdout, stderr)
+	}
+
+	code, stdout, _ = run(t, `{"name": "Bob", "role": "admin"}`, "generate", "-types", types, "-type", "card", "-")
+	if code != exitOK || stdout != "Bob (admin)" {
+		t.Errorf("expected the card of stdin, got %d: %q", code, stdout)
+	}
+
+	code, stdout, _ = run(t, "", "generate", "-types", types, "-type", "card", filepath.Join(dir, "nameless.json"))
+	if code != exitFailure || !strings.Contains(stdout, "nameless.json: /: missing properties: 'name'") {
+		t.Errorf("expected a violation, got %d: %s", code, stdout)
+	}
+
+	code, _, stderr = run(t, "", "generate", "-types", types, "-type", "invoice", filepath.Join(dir, "alice.yaml"))
+	if code != exitError || !strings.Contains(stderr, `unknown document type "invoice"`) {
+		t.Errorf("expected an unknown type error, got %d: %s", code, stderr)
+	}
+
+	if code, _, _ := run(t, "", "generate", "-types", types, filepath.Join(dir, "alice.yaml")); code != exitError {
+		t.Errorf("missing -type should be a usage error, got %d", code)
+	}
+}
+
+func TestGenerateAudit(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"types.yaml":    "types:\n  card:\n    schema: user.json\n    template: card.txt\n",
+		"user.json":     userSchema,
+		"card.txt":      "{{ name }} ({{ role }})",
+		"alice.yaml":    "name: Alice\n",
+		"nameless.json": `{"age": 3}`,
+	})
+	types := filepath.Join(dir, "types.yaml")
+	log := filepath.Join(dir, "audit.jsonl")
+
+	code, stdout, _ := run(t, "", "generate", "-types", types, "-type", "card", "-audit", log, filepath.Join(dir, "alice.yaml"))
+	if code != exitOK {
+		t.Fatalf("generate failed with %d", code)
+	}
+	run(t, "", "generate", "-types", types, "-type", "card", "-audit", log, filepath.Join(dir, "nameless.json"))
+
+	f, err := os.Open(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := audit.ReadJSONL(f)
+	if err != nil || len(records) != 2 {
+		t.Fatalf("expected a record per run, got %v (%v)", records, err)
+	}
+	generated, invalid := records[0], records[1]
+	if generated.Document != filepath.Join(dir, "alice.yaml") || generated.Type != "card" || generated.Output != audit.Hash([]byte(stdout)) {
+		t.Errorf("expected the file, type and output hash, got %+v", generated)
+	}
+	if generated.Schema == nil || !strings.HasPrefix(generated.Schema.Version, "sha256:") || generated.Template == nil || generated.Template.Name != "card.txt" {
+		t.Errorf("expected the schema and template versions, got %+v %+v", generated.Schema, generated.Template)
+	}
+	if len(generated.Defaults) != 1 || generated.Defaults[0].Pointer != "/role" {
+		t.Errorf("expected the role default, got %v", generated.Defaults)
+	}
+	if invalid.Outcome != audit.OutcomeInvalid || len(invalid.Violations) == 0 {
+		t.Errorf("expected the invalid document with its violations, got %+v", invalid)
+	}
+
+	if code, _, _ := run(t, "", "generate", "-types", types, "-type", "card", "-audit", filepath.Join(dir, "missing", "audit.jsonl"), filepath.Join(dir, "alice.yaml")); code != exitError {
+		t.Errorf("expected an error for an audit log in a missing directory, got %d", code)
+	}
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// expandGlobs expands file arguments containing glob patterns, for shells that
+// don't (or don't support "**", which matches any number of directories).
+// Arguments without pattern characters are kept as given, so that missing
+// files are reported by the command reading them.
+func expandGlobs(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		if !hasMeta(arg) {
+			files = append(files, arg)
+			continue
+		}
+		matches, err := glob(arg)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %q", arg)
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+func hasMeta(s string) bool {
+	return strings.ContainsAny(s, `*?[`)
+}
+
+// glob returns the files matching pattern, sorted.
+func glob(pattern string) ([]string, error) {
+	root, segments := splitGlob(pattern)
+	var matches []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if matchSegments(segments, strings.Split(filepath.ToSlash(rel), "/")) {
+			matches = append(matches, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// splitGlob splits a pattern into the longest leading directory without
+// pattern characters and the remaining pattern segments.
+func splitGlob(pattern string) (root string, segments []string) {
+	segments = strings.Split(filepath.ToSlash(pattern), "/")
+	i := 0
+	for i < len(segments)-1 && !hasMeta(segments[i]) {
+		i++
+	}
+	root = strings.Join(segments[:i], "/")
+	switch {
+	case root == "" && i > 0:
+		root = "/"
+	case root == "":
+		root = "."
+	}
+	return filepath.FromSlash(root), segments[i:]
+}
+
+// matchSegments matches path segments against pattern segments, where a "**"
+// segment matches zero or more path segments.
+func matchSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	ok, err := path.Match(pattern[0], name[0])
+	return err == nil && ok && matchSegments(pattern[1:], name[1:])
+}
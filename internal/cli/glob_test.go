@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandGlobs(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"templates/a.tpl":           "",
+		"templates/b.txt":           "",
+		"templates/mail/c.tpl":      "",
+		"templates/mail/deep/d.tpl": "",
+	})
+	p := func(rel string) string { return filepath.Join(dir, filepath.FromSlash(rel)) }
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{p("templates/*.tpl")}, []string{p("templates/a.tpl")}},
+		{[]string{p("templates/**/*.tpl")}, []string{p("templates/a.tpl"), p("templates/mail/c.tpl"), p("templates/mail/deep/d.tpl")}},
+		{[]string{p("templates/m*/*.tpl"), "literal.tpl"}, []string{p("templates/mail/c.tpl"), "literal.tpl"}},
+	}
+	for _, tt := range tests {
+		got, err := expandGlobs(tt.args)
+		if err != nil {
+			t.Fatalf("expandGlobs(%v) failed: %v", tt.args, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("expandGlobs(%v) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+
+	if _, err := expandGlobs([]string{p("templates/*.json")}); err == nil {
+		t.Error("expected an error for a pattern without matches")
+	}
+}
@@ -0,0 +1,254 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	commands["test"] = command{
+		summary: "run go test with race detection, coverage thresholds and a summary",
+		run:     runTest,
+	}
+}
+
+// runTest runs go test -json on the given packages (./... by default), prints
+// the output of failing tests and a per-package summary, and exits 1 if a test
+// fails or a package's coverage is below -min-coverage.
+func runTest(e *env, args []string) int {
+	fs := newFlagSet(e, "test", "[PACKAGES...]")
+	race := fs.Bool("race", false, "enable the race detector")
+	cover := fs.Bool("cover", false, "report statement coverage per package")
+	minCoverage := fs.Float64("min-coverage", 0, "fail if a package's coverage is below this percentage (implies -cover)")
+	runPattern := fs.String("run", "", "run only tests matching this regular expression")
+	jsonSummary := fs.Bool("json", false, "print the summary as JSON (same as -output json)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *jsonSummary {
+		e.json = true
+	}
+
+	goArgs := []string{"test", "-json"}
+	if *race {
+		goArgs = append(goArgs, "-race")
+	}
+	if *cover || *minCoverage > 0 {
+		goArgs = append(goArgs, "-cover")
+	}
+	if *runPattern != "" {
+		goArgs = append(goArgs, "-run", *runPattern)
+	}
+	packages := fs.Args()
+	if len(packages) == 0 {
+		packages = []string{"./..."}
+	}
+	goArgs = append(goArgs, packages...)
+
+	cmd := exec.Command("go", goArgs...)
+	cmd.Stderr = e.stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return e.errorf("%v", err)
+	}
+	summary, err := summarizeTests(stdout)
+	waitErr := cmd.Wait()
+	if err != nil {
+		return e.errorf("reading go test output: %v", err)
+	}
+	if len(summary.Packages) == 0 && waitErr != nil {
+		return e.errorf("go test: %v", waitErr)
+	}
+
+	code := exitOK
+	if summary.Failed > 0 || summary.hasFailedPackage() {
+		code = exitFailure
+	}
+	for _, pkg := range summary.Packages {
+		if *minCoverage > 0 && pkg.Coverage != nil && *pkg.Coverage < *minCoverage {
+			e.fileError(pkg.Package, fmt.Errorf("coverage %.1f%% is below %.1f%%", *pkg.Coverage, *minCoverage))
+			code = exitFailure
+		}
+	}
+	e.setResult(summary)
+	if !e.json {
+		summary.print(e.stdout)
+	}
+	return code
+}
+
+// testSummary is the outcome of a go test run.
+type testSummary struct {
+	Packages []*packageSummary `json:"packages"`
+
+	// Passed, Failed and Skipped count tests (including subtests).
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+
+	// Failures lists failed tests with their output.
+	Failures []testFailure `json:"failures"`
+}
+
+type packageSummary struct {
+	Package string `json:"package"`
+
+	// Status is "pass", "fail" or "skip" (no test files).
+	Status   string   `json:"status"`
+	Elapsed  float64  `json:"elapsed"`
+	Coverage *float64 `json:"coverage,omitempty"`
+
+	// Output holds the package's own output when it failed outside any test,
+	// e.g. build errors or a panic in TestMain.
+	Output string `json:"output,omitempty"`
+}
+
+type testFailure struct {
+	Package string `json:"package"`
+	Test    string `json:"test"`
+	Output  string `json:"output"`
+}
+
+// testEvent is a line of go test -json output (see go doc test2json).
+type testEvent struct {
+	Action     string
+	Package    string
+	ImportPath string // build events
+	Test       string
+	Elapsed    float64
+	Output     string
+}
+
+var reCoverage = regexp.MustCompile(`coverage: ([0-9.]+)% of statements`)
+
+// summarizeTests reads go test -json output.
+func summarizeTests(r io.Reader) (*testSummary, error) {
+	summary := &testSummary{Packages: []*packageSummary{}, Failures: []testFailure{}}
+	packages := map[string]*packageSummary{}
+	outputs := map[string]*strings.Builder{} // by package and test
+	buildOutput := map[string]*strings.Builder{}
+
+	output := func(m map[string]*strings.Builder, key string) *strings.Builder {
+		b, ok := m[key]
+		if !ok {
+			b = &strings.Builder{}
+			m[key] = b
+		}
+		return b
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var ev testEvent
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			// Not an event (e.g. output of a crashed test binary); skip it.
+			continue
+		}
+		if ev.Action == "build-output" {
+			output(buildOutput, ev.ImportPath).WriteString(ev.Output)
+			continue
+		}
+		key := ev.Package + " " + ev.Test
+		switch ev.Action {
+		case "output":
+			output(outputs, key).WriteString(ev.Output)
+			if ev.Test == "" {
+				if m := reCoverage.FindStringSubmatch(ev.Output); m != nil {
+					if pct, err := strconv.ParseFloat(m[1], 64); err == nil {
+						pkg := summary.pkg(packages, ev.Package)
+						pkg.Coverage = &pct
+					}
+				}
+			}
+		case "pass", "fail", "skip":
+			if ev.Test != "" {
+				switch ev.Action {
+				case "pass":
+					summary.Passed++
+				case "skip":
+					summary.Skipped++
+				default:
+					summary.Failed++
+					summary.Failures = append(summary.Failures, testFailure{Package: ev.Package, Test: ev.Test, Output: output(outputs, key).String()})
+				}
+				continue
+			}
+			pkg := summary.pkg(packages, ev.Package)
+			pkg.Status = ev.Action
+			pkg.Elapsed = ev.Elapsed
+			if ev.Action == "fail" {
+				pkg.Output = output(outputs, key).String()
+				for path, b := range buildOutput {
+					if path == ev.Package || strings.HasPrefix(path, ev.Package+" ") {
+						pkg.Output = b.String() + pkg.Output
+					}
+				}
+			}
+		}
+	}
+	return summary, scanner.Err()
+}
+
+func (s *testSummary) pkg(packages map[string]*packageSummary, name string) *packageSummary {
+	pkg, ok := packages[name]
+	if !ok {
+		pkg = &packageSummary{Package: name}
+		packages[name] = pkg
+		s.Packages = append(s.Packages, pkg)
+		sort.Slice(s.Packages, func(i, j int) bool { return s.Packages[i].Package < s.Packages[j].Package })
+	}
+	return pkg
+}
+
+func (s *testSummary) hasFailedPackage() bool {
+	for _, pkg := range s.Packages {
+		if pkg.Status == "fail" {
+			return true
+		}
+	}
+	return false
+}
+
+// print writes the output of failed tests, one line per package and totals.
+func (s *testSummary) print(w io.Writer) {
+	for _, f := range s.Failures {
+		fmt.Fprintf(w, "--- FAIL: %s (%s)\n%s", f.Test, f.Package, f.Output)
+	}
+	for _, pkg := range s.Packages {
+		if pkg.Status == "fail" && pkg.Output != "" && !s.failedTestsIn(pkg.Package) {
+			fmt.Fprint(w, pkg.Output)
+		}
+	}
+	for _, pkg := range s.Packages {
+		status := map[string]string{"pass": "ok  ", "fail": "FAIL", "skip": "?   "}[pkg.Status]
+		line := fmt.Sprintf("%s\t%s\t%.3fs", status, pkg.Package, pkg.Elapsed)
+		if pkg.Status == "skip" {
+			line = fmt.Sprintf("%s\t%s\t[no test files]", status, pkg.Package)
+		}
+		if pkg.Coverage != nil {
+			line += fmt.Sprintf("\tcoverage: %.1f%%", *pkg.Coverage)
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintf(w, "%d passed, %d failed, %d skipped\n", s.Passed, s.Failed, s.Skipped)
+}
+
+func (s *testSummary) failedTestsIn(pkg string) bool {
+	for _, f := range s.Failures {
+		if f.Package == pkg {
+			return true
+		}
+	}
+	return false
+}
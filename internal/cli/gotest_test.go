@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const goTestJSON = `{"Action":"start","Package":"go-demo/a"}
+{"Action":"run","Package":"go-demo/a","Test":"TestOK"}
+{"Action":"output","Package":"go-demo/a","Test":"TestOK","Output":"=== RUN   TestOK\n"}
+{"Action":"pass","Package":"go-demo/a","Test":"TestOK","Elapsed":0.01}
+{"Action":"run","Package":"go-demo/a","Test":"TestBad"}
+{"Action":"output","Package":"go-demo/a","Test":"TestBad","Output":"    a_test.go:9: expected 1, got 2\n"}
+{"Action":"fail","Package":"go-demo/a","Test":"TestBad","Elapsed":0}
+{"Action":"skip","Package":"go-demo/a","Test":"TestLater","Elapsed":0}
+{"Action":"output","Package":"go-demo/a","Output":"coverage: 71.4% of statements\n"}
+{"Action":"fail","Package":"go-demo/a","Elapsed":0.2}
+{"Action":"output","Package":"go-demo/b","Output":"ok  \tgo-demo/b\t0.1s\tcoverage: 100.0% of statements\n"}
+{"Action":"pass","Package":"go-demo/b","Elapsed":0.1}
+{"Action":"skip","Package":"go-demo/c","Elapsed":0}
+{"ImportPath":"go-demo/d","Action":"build-output","Output":"d.go:3:1: syntax error\n"}
+{"ImportPath":"go-demo/d","Action":"build-fail"}
+{"Action":"fail","Package":"go-demo/d","Elapsed":0}
+`
+
+func TestSummarizeTests(t *testing.T) {
+	summary, err := summarizeTests(strings.NewReader(goTestJSON))
+	if err != nil {
+		t.Fatalf("summarizeTests failed: %v", err)
+	}
+	if summary.Passed != 1 || summary.Failed != 1 || summary.Skipped != 1 {
+		t.Errorf("expected 1 passed, 1 failed, 1 skipped, got %+v", summary)
+	}
+	if len(summary.Failures) != 1 || summary.Failures[0].Test != "TestBad" || !strings.Contains(summary.Failures[0].Output, "expected 1, got 2") {
+		t.Errorf("unexpected failures: %+v", summary.Failures)
+	}
+
+	tests := []struct {
+		pkg      string
+		status   string
+		coverage float64 // -1 for none
+	}{
+		{"go-demo/a", "fail", 71.4},
+		{"go-demo/b", "pass", 100},
+		{"go-demo/c", "skip", -1},
+		{"go-demo/d", "fail", -1},
+	}
+	if len(summary.Packages) != len(tests) {
+		t.Fatalf("expected %d packages, got %d", len(tests), len(summary.Packages))
+	}
+	for i, tt := range tests {
+		pkg := summary.Packages[i]
+		if pkg.Package != tt.pkg || pkg.Status != tt.status {
+			t.Errorf("package %d: expected %s %s, got %s %s", i, tt.pkg, tt.status, pkg.Package, pkg.Status)
+		}
+		if tt.coverage < 0 && pkg.Coverage != nil || tt.coverage >= 0 && (pkg.Coverage == nil || *pkg.Coverage != tt.coverage) {
+			t.Errorf("%s: unexpected coverage %v", tt.pkg, pkg.Coverage)
+		}
+	}
+	if !strings.Contains(summary.Packages[3].Output, "syntax error") {
+		t.Errorf("expected the build output for go-demo/d, got %q", summary.Packages[3].Output)
+	}
+
+	var out bytes.Buffer
+	summary.print(&out)
+	for _, want := range []string{
+		"--- FAIL: TestBad (go-demo/a)\n    a_test.go:9: expected 1, got 2\n",
+		"d.go:3:1: syntax error\n",
+		"FAIL\tgo-demo/a\t0.200s\tcoverage: 71.4%\n",
+		"ok  \tgo-demo/b\t0.100s\tcoverage: 100.0%\n",
+		"?   \tgo-demo/c\t[no test files]\n",
+		"1 passed, 1 failed, 1 skipped\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"go-demo/pkg/jsonutil"
+)
+
+// stdio is the file name that stands for stdin (as an input) or stdout (as an
+// output), so commands compose in pipelines.
+const stdio = "-"
+
+// readFile reads a file, or stdin if path is "-".
+func (e *env) readFile(path string) ([]byte, error) {
+	if path == stdio {
+		return io.ReadAll(e.stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// writeFile writes b to a file, or to stdout if path is "" or "-".
+func (e *env) writeFile(path string, b []byte) error {
+	if path == "" || path == stdio {
+		_, err := e.stdout.Write(b)
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+// readDocument decodes a JSON, YAML or TOML file. The format is inferred from
+// the file extension unless given; stdin is JSON by default.
+func (e *env) readDocument(path string, format jsonutil.Format) (interface{}, error) {
+	if format == "" {
+		format = jsonutil.FormatJSON
+		if path != stdio {
+			var err error
+			if format, err = jsonutil.FormatFromPath(path); err != nil {
+				return nil, err
+			}
+		}
+	}
+	b, err := e.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := jsonutil.Unmarshal(format, b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: decode %s: %w", path, format, err)
+	}
+	return doc, nil
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStdinStdout(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"schema.json": userSchema,
+		"card.tpl":    "{{ name }} ({{ role }})",
+	})
+	schema := filepath.Join(dir, "schema.json")
+
+	// cat data.json | go-demo apply-defaults -schema s.json - | go-demo render -template card.tpl -context -
+	code, stdout, stderr := run(t, `{"name": "Alice"}`, "apply-defaults", "-schema", schema, "-out", "-", "-")
+	if code != exitOK {
+		t.Fatalf("apply-defaults from stdin failed with %d: %s", code, stderr)
+	}
+	code, stdout, stderr = run(t, stdout, "render", "-template", filepath.Join(dir, "card.tpl"), "-context", "-")
+	if code != exitOK || stdout != "Alice (member)" {
+		t.Errorf("render from the pipeline failed with %d: %s%s", code, stdout, stderr)
+	}
+
+	code, stdout, _ = run(t, `{"age": -1}`, "validate", "-schema", schema, "-")
+	if code != exitFailure || !strings.HasPrefix(stdout, "-: /: missing properties") {
+		t.Errorf("validate from stdin should report violations, got %d: %s", code, stdout)
+	}
+
+	code, stdout, stderr = run(t, "a: 1\n", "convert", "-from", "yaml", "-to", "json", "-indent", "0", "-")
+	if code != exitOK || stdout != `{"a":1}`+"\n" {
+		t.Errorf("convert from stdin failed with %d: %s%s", code, stdout, stderr)
+	}
+
+	if code, _, _ := run(t, `{}`, "convert", "-out", "-", "-"); code != exitError {
+		t.Errorf("convert to stdout without -to should be a usage error, got %d", code)
+	}
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"fmt"
+
+	"go-demo/pkg/pongo2"
+)
+
+func init() {
+	commands["lint-template"] = command{
+		summary: "check templates for errors and list the variables they use",
+		run:     runLintTemplate,
+		plugins: true,
+	}
+}
+
+// runLintTemplate lints template files, exiting 1 if any has issues. With
+// -output json, the result lists each template's variables and issues.
+func runLintTemplate(e *env, args []string) int {
+	fs := newFlagSet(e, "lint-template", "TEMPLATE...")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitError
+	}
+	files, err := expandGlobs(fs.Args())
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+
+	r := pongo2.NewRenderer(pongo2.Options{})
+	reports := make([]*pongo2.LintReport, 0, len(files))
+	code := exitOK
+	for _, file := range files {
+		report, err := r.LintFile(file)
+		if err != nil {
+			return e.errorf("%v", err)
+		}
+		if !report.OK() {
+			code = exitFailure
+		}
+		reports = append(reports, report)
+	}
+
+	e.setResult(reports)
+	for _, report := range reports {
+		for _, issue := range report.Issues {
+			switch {
+			case e.json:
+				e.envelope.Errors = append(e.envelope.Errors, problem{File: report.Template, Line: issue.Line, Column: issue.Column, Message: issue.Message})
+			case issue.Line > 0:
+				fmt.Fprintf(e.stdout, "%s:%d:%d: %s\n", report.Template, issue.Line, issue.Column, issue.Message)
+			default:
+				fmt.Fprintf(e.stdout, "%s: %s\n", report.Template, issue.Message)
+			}
+		}
+	}
+	return code
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-demo/pkg/pongo2"
+)
+
+func TestLintTemplateCommand(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"templates/user.json.tpl":   `{"name": "{{ name }}", "age": {{ age }}}`,
+		"templates/mail/body.txt":   "Hi {{ name|shout }}",
+		"templates/list.json.tpl":   `[{% for x in xs %}{{ x }},{% endfor %}]`,
+		"templates/mail/footer.txt": "Bye",
+	})
+	pattern := filepath.Join(dir, "templates", "**", "*")
+
+	code, stdout, stderr := run(t, "", "lint-template", filepath.Join(dir, "templates", "user.json.tpl"))
+	if code != exitOK || stdout != "" {
+		t.Fatalf("expected a clean template to pass, got %d: %s%s", code, stdout, stderr)
+	}
+
+	code, stdout, _ = run(t, "", "lint-template", pattern)
+	if code != exitFailure {
+		t.Fatalf("expected exit code %d, got %d", exitFailure, code)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 2 ||
+		!strings.Contains(lines[0], filepath.Join("templates", "list.json.tpl")+": JSON skeleton is invalid") ||
+		!strings.Contains(lines[1], filepath.Join("mail", "body.txt")+":1:12: ") {
+		t.Errorf("unexpected output:\n%s", stdout)
+	}
+
+	code, stdout, _ = run(t, "", "lint-template", "-output", "json", pattern)
+	if code != exitFailure {
+		t.Fatalf("expected exit code %d, got %d", exitFailure, code)
+	}
+	var result struct {
+		Errors []problem
+		Result []pongo2.LintReport
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("Invalid JSON output: %v\n%s", err, stdout)
+	}
+	if len(result.Errors) != 2 || result.Errors[1].Line != 1 || result.Errors[1].Column != 12 {
+		t.Errorf("unexpected errors: %+v", result.Errors)
+	}
+	reports := result.Result
+	if len(reports) != 4 || reports[3].Template != filepath.Join(dir, "templates", "user.json.tpl") || strings.Join(reports[3].Variables, ",") != "age,name" {
+		t.Errorf("unexpected reports: %+v", reports)
+	}
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"go-demo/pkg/jsonutil"
+)
+
+func init() {
+	commands["merge"] = command{
+		summary: "deep-merge layered JSON, YAML or TOML documents",
+		run:     runMerge,
+	}
+}
+
+// runMerge deep-merges the documents in order, each overriding the ones
+// before, and writes the result to stdout or -out:
+//
+//	go-demo merge base.yaml production.yaml local.json
+func runMerge(e *env, args []string) int {
+	fs := newFlagSet(e, "merge", "BASE OVERRIDE...")
+	strategy := fs.String("array-strategy", "replace", "how to combine arrays: replace, append, merge (by index) or by-key=KEY")
+	to := fs.String("to", "", "output format: json, yaml or toml (default: from the -out extension, json for stdout)")
+	out := fs.String("out", "", "write the result to this file instead of stdout (- for stdout)")
+	indent := fs.Int("indent", 2, "spaces to indent the output with")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return exitError
+	}
+	arrays, err := jsonutil.ParseArrayStrategy(*strategy)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	format := jsonutil.FormatJSON
+	if *to != "" || *out != "" && *out != stdio {
+		if format, err = formatFlag(*to, *out); err != nil {
+			return e.errorf("%v", err)
+		}
+	}
+
+	var merged interface{}
+	for i, path := range fs.Args() {
+		doc, err := e.readDocument(path, "")
+		if err != nil {
+			return e.errorf("%v", err)
+		}
+		if i == 0 {
+			merged = doc
+			continue
+		}
+		merged = jsonutil.Merge(merged, doc, arrays)
+	}
+
+	output, err := jsonutil.Marshal(format, merged, *indent)
+	if err != nil {
+		return e.errorf("encode %s: %v", format, err)
+	}
+	if err := e.writeFile(*out, output); err != nil {
+		return e.errorf("%v", err)
+	}
+	return exitOK
+}
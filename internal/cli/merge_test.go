@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeCommand(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.yaml":   "name: app\nreplicas: 1\nservers:\n  - {id: 1, port: 80}\n  - {id: 2, port: 81}\n",
+		"prod.json":   `{"replicas": 9007199254740993, "servers": [{"id": 2, "port": 8081}, {"id": 3, "port": 82}]}`,
+		"local.toml":  "debug = true\n",
+		"scalar.json": `"x"`,
+		"broken.json": `{`,
+	})
+	base := filepath.Join(dir, "base.yaml")
+	prod := filepath.Join(dir, "prod.json")
+	local := filepath.Join(dir, "local.toml")
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+		want string
+	}{
+		{"replace", []string{"-indent", "0", base, prod, local}, exitOK,
+			`{"debug":true,"name":"app","replicas":9007199254740993,"servers":[{"id":2,"port":8081},{"id":3,"port":82}]}` + "\n"},
+		{"append", []string{"-indent", "0", "-array-strategy", "append", base, prod}, exitOK,
+			`{"name":"app","replicas":9007199254740993,"servers":[{"id":1,"port":80},{"id":2,"port":81},{"id":2,"port":8081},{"id":3,"port":82}]}` + "\n"},
+		{"by key", []string{"-indent", "0", "-array-strategy", "by-key=id", base, prod}, exitOK,
+			`{"name":"app","replicas":9007199254740993,"servers":[{"id":1,"port":80},{"id":2,"port":8081},{"id":3,"port":82}]}` + "\n"},
+		{"yaml output", []string{"-to", "yaml", base, local}, exitOK,
+			"debug: true\nname: app\nreplicas: 1\nservers:\n  - id: 1\n    port: 80\n  - id: 2\n    port: 81\n"},
+		{"scalar override", []string{base, filepath.Join(dir, "scalar.json")}, exitOK, "\"x\"\n"},
+		{"unknown strategy", []string{"-array-strategy", "zip", base, prod}, exitError, ""},
+		{"broken override", []string{base, filepath.Join(dir, "broken.json")}, exitError, ""},
+		{"one file", []string{base}, exitError, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := run(t, "", append([]string{"merge"}, tt.args...)...)
+			if code != tt.code || stdout != tt.want {
+				t.Errorf("expected %d %q, got %d %q (%s)", tt.code, tt.want, code, stdout, stderr)
+			}
+		})
+	}
+
+	out := filepath.Join(dir, "merged.toml")
+	if code, _, stderr := run(t, "", "merge", "-out", out, base, local); code != exitOK {
+		t.Fatalf("merge -out failed with %d: %s", code, stderr)
+	}
+	if b, err := os.ReadFile(out); err != nil || len(b) == 0 || b[0] == '{' {
+		t.Errorf("expected TOML in %s, got %q (%v)", out, b, err)
+	}
+}
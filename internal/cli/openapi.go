@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"encoding/json"
+
+	"go-demo/pkg/httpapi"
+)
+
+func init() {
+	commands["openapi"] = command{
+		summary: "print the OpenAPI document of the HTTP API or generate a Go client",
+		run:     runOpenAPI,
+	}
+}
+
+// runOpenAPI writes the OpenAPI 3.1 document served by "serve" at
+// /openapi.json, and with -go-client a generated Go client for the API.
+func runOpenAPI(e *env, args []string) int {
+	fs := newFlagSet(e, "openapi", "")
+	out := fs.String("out", "", "write the OpenAPI document to this file instead of stdout")
+	client := fs.String("go-client", "", "write a generated Go client to this file (- for stdout) instead of the document")
+	pkg := fs.String("package", "client", "package name of the generated client")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 || *client != "" && *out != "" {
+		fs.Usage()
+		return exitError
+	}
+
+	if *client != "" {
+		src, err := httpapi.GenerateClient(*pkg)
+		if err != nil {
+			return e.errorf("%v", err)
+		}
+		if err := e.writeFile(*client, src); err != nil {
+			return e.errorf("%v", err)
+		}
+		return exitOK
+	}
+
+	b, err := json.MarshalIndent(httpapi.OpenAPI(), "", "  ")
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	if err := e.writeFile(*out, append(b, '\n')); err != nil {
+		return e.errorf("%v", err)
+	}
+	return exitOK
+}
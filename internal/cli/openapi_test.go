@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPICommand(t *testing.T) {
+	code, stdout, stderr := run(t, "", "openapi")
+	if code != exitOK {
+		t.Fatalf("openapi failed with %d: %s", code, stderr)
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal([]byte(stdout), &spec); err != nil || spec["openapi"] != "3.1.0" {
+		t.Fatalf("expected an OpenAPI 3.1 document, got %v: %s", err, stdout)
+	}
+
+	out := filepath.Join(t.TempDir(), "client.go")
+	if code, _, stderr := run(t, "", "openapi", "-go-client", out, "-package", "demoapi"); code != exitOK {
+		t.Fatalf("openapi -go-client failed with %d: %s", code, stderr)
+	}
+	src, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Failed to read the client: %v", err)
+	}
+	if !strings.Contains(string(src), "package demoapi\n") || !strings.Contains(string(src), "func (c *Client) Render(") {
+		t.Errorf("unexpected client source:\n%s", src)
+	}
+
+	if code, _, _ := run(t, "", "openapi", "-out", out, "-go-client", out); code != exitError {
+		t.Errorf("-out with -go-client should be a usage error, got %d", code)
+	}
+}
@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/jsonutil"
+)
+
+// Output formats selected with -output.
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// envelope is what every command prints with -output json instead of its
+// usual output:
+//
+//	{"command": "validate", "status": "failure", "exit_code": 1,
+//	 "errors": [{"file": "user.json", "pointer": "/age", "keyword": "/properties/age/minimum", "message": "must be >= 0 but found -1"}],
+//	 "warnings": [], "duration_ms": 3.2}
+type envelope struct {
+	Command string `json:"command"`
+
+	// Status is "ok", "failure" or "error" for the exit codes 0, 1 and 2.
+	Status   string `json:"status"`
+	ExitCode int    `json:"exit_code"`
+
+	Errors   []problem `json:"errors"`
+	Warnings []problem `json:"warnings"`
+
+	// Result holds a command's structured result, such as per-file outcomes.
+	Result interface{} `json:"result,omitempty"`
+
+	// Output holds what the command would have written to stdout, such as a
+	// converted or rendered document.
+	Output string `json:"output,omitempty"`
+
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// problem is an error or warning in the envelope.
+type problem struct {
+	File string `json:"file,omitempty"`
+
+	// Pointer locates the offending value in the document; "" is the root.
+	Pointer *string `json:"pointer,omitempty"`
+	Keyword string  `json:"keyword,omitempty"`
+
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+
+	Message string `json:"message"`
+}
+
+// outputFlag is the -output flag every command accepts.
+type outputFlag struct{ e *env }
+
+func (f outputFlag) String() string {
+	if f.e == nil || !f.e.json {
+		return outputText
+	}
+	return outputJSON
+}
+
+func (f outputFlag) Set(s string) error {
+	switch s {
+	case outputText:
+		f.e.json = false
+	case outputJSON:
+		f.e.json = true
+	default:
+		return fmt.Errorf("want text or json")
+	}
+	return nil
+}
+
+// stdoutWriter writes command output to stdout, or buffers it for the
+// envelope with -output json.
+type stdoutWriter struct {
+	e   *env
+	w   io.Writer
+	buf bytes.Buffer
+}
+
+func (w *stdoutWriter) Write(p []byte) (int, error) {
+	if w.e.json {
+		return w.buf.Write(p)
+	}
+	return w.w.Write(p)
+}
+
+// fileError reports an error processing one of several files.
+func (e *env) fileError(file string, err error) {
+	if e.json {
+		e.envelope.Errors = append(e.envelope.Errors, problem{File: file, Message: err.Error()})
+		return
+	}
+	fmt.Fprintf(e.stderr, "%s: %v\n", file, err)
+}
+
+// violation reports a schema violation in a document on stdout:
+//
+//	data.json: /age: must be >= 0 (at #/properties/age/minimum)
+func (e *env) violation(file string, v schemautil.Violation) {
+	if e.json {
+		pointer := v.InstanceLocation
+		e.envelope.Errors = append(e.envelope.Errors, problem{File: file, Pointer: &pointer, Keyword: v.KeywordLocation, Message: v.Message})
+		return
+	}
+	fmt.Fprintf(e.stdout, "%s: %s: %s (at #%s)\n", file, pointerOrRoot(v.InstanceLocation), v.Message, v.KeywordLocation)
+}
+
+// warnf reports a problem that doesn't fail the command.
+func (e *env) warnf(format string, args ...interface{}) {
+	if e.json {
+		e.envelope.Warnings = append(e.envelope.Warnings, problem{Message: fmt.Sprintf(format, args...)})
+		return
+	}
+	fmt.Fprintf(e.stderr, "go-demo: warning: "+format+"\n", args...)
+}
+
+// logger returns a logger writing text records of at least level (debug,
+// info, warn or error) to stderr, for long-running commands.
+func (e *env) logger(level string) (*slog.Logger, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q (want debug, info, warn or error)", level)
+	}
+	return slog.New(slog.NewTextHandler(e.stderr, &slog.HandlerOptions{Level: l})), nil
+}
+
+// setResult attaches a structured result to the envelope. It has no effect
+// without -output json.
+func (e *env) setResult(v interface{}) {
+	e.envelope.Result = v
+}
+
+// writeEnvelope completes the envelope of a finished command and writes it to stdout.
+func (e *env) writeEnvelope(name string, code int, start time.Time, stdout io.Writer) {
+	env := &e.envelope
+	env.Command = name
+	env.ExitCode = code
+	env.Status = map[int]string{exitOK: "ok", exitFailure: "failure"}[code]
+	if env.Status == "" {
+		env.Status = "error"
+	}
+	if code == exitError && len(env.Errors) == 0 {
+		env.Errors = append(env.Errors, problem{Message: fmt.Sprintf("invalid usage; see 'go-demo %s -h'", name)})
+	}
+	if env.Errors == nil {
+		env.Errors = []problem{}
+	}
+	if env.Warnings == nil {
+		env.Warnings = []problem{}
+	}
+	env.Output = e.out.buf.String()
+	env.DurationMS = float64(time.Since(start).Microseconds()) / 1000
+
+	b, err := jsonutil.Marshal(jsonutil.FormatJSON, env, 2)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "go-demo: %v\n", err)
+		return
+	}
+	stdout.Write(b)
+}
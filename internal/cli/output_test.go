@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runJSON runs the CLI and decodes the -output json envelope it prints.
+func runJSON(t *testing.T, stdin string, args ...string) (int, envelope) {
+	t.Helper()
+	code, stdout, stderr := run(t, stdin, args...)
+	var env envelope
+	if err := json.Unmarshal([]byte(stdout), &env); err != nil {
+		t.Fatalf("Invalid envelope (%v): %s%s", err, stdout, stderr)
+	}
+	return code, env
+}
+
+func TestOutputJSON(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"schema.json": userSchema,
+		"good.json":   `{"name": "Alice"}`,
+		"bad.json":    `{"age": -1}`,
+		"card.tpl":    "{{ user.name }} ({{ role }})",
+	})
+	schema := filepath.Join(dir, "schema.json")
+
+	code, env := runJSON(t, "", "-output", "json", "validate", "-schema", schema, filepath.Join(dir, "good.json"), filepath.Join(dir, "bad.json"))
+	if code != exitFailure || env.Command != "validate" || env.Status != "failure" || env.ExitCode != exitFailure {
+		t.Errorf("unexpected envelope: %+v", env)
+	}
+	if len(env.Errors) != 2 || env.Errors[0].Pointer == nil || *env.Errors[0].Pointer != "" || *env.Errors[1].Pointer != "/age" ||
+		env.Errors[1].Keyword != "/properties/age/minimum" || env.Errors[1].File != filepath.Join(dir, "bad.json") {
+		t.Errorf("unexpected errors: %+v", env.Errors)
+	}
+	if env.Output != "" || env.Warnings == nil {
+		t.Errorf("expected no output and an empty warnings list, got %+v", env)
+	}
+
+	// The flag is accepted after the command too; stdout ends up in the envelope.
+	code, env = runJSON(t, `{"role": "admin"}`, "render", "-template", filepath.Join(dir, "card.tpl"), "-context", "-", "-output", "json")
+	if code != exitOK || env.Status != "ok" || env.Output != " (admin)" {
+		t.Errorf("unexpected envelope: %+v", env)
+	}
+	if len(env.Warnings) != 1 || env.Warnings[0].Message != filepath.Join(dir, "card.tpl")+": context is missing user" {
+		t.Errorf("expected a warning about the missing variable, got %+v", env.Warnings)
+	}
+
+	code, env = runJSON(t, "", "-output", "json", "validate")
+	if code != exitError || env.Status != "error" || len(env.Errors) != 1 {
+		t.Errorf("expected a usage error envelope, got %d: %+v", code, env)
+	}
+
+	if code, _, _ := run(t, "", "-output", "xml", "validate"); code != exitError {
+		t.Errorf("unknown output formats should be rejected, got %d", code)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var stderr strings.Builder
+	e := &env{stderr: &stderr}
+	logger, err := e.logger("warn")
+	if err != nil {
+		t.Fatalf("logger failed: %v", err)
+	}
+	logger.Info("hidden")
+	logger.Warn("shown", "template", "a.txt")
+	if out := stderr.String(); strings.Contains(out, "hidden") || !strings.Contains(out, `level=WARN msg=shown template=a.txt`) {
+		t.Errorf("unexpected log output %q", out)
+	}
+	if _, err := e.logger("loud"); err == nil {
+		t.Error("an unknown level should be rejected")
+	}
+}
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/plugin"
+	tpl "go-demo/pkg/pongo2"
+)
+
+func init() {
+	commands["plugins"] = command{
+		summary: "list the plugins in $" + plugin.PathEnv + " and what they provide",
+		run:     runPlugins,
+		plugins: true,
+	}
+}
+
+// plugins holds the plugins started so far by path. Their filters, formats
+// and commands are registered globally, so each is loaded once per process.
+var plugins = map[string]*plugin.Plugin{}
+
+// loadPlugins starts the plugins in $GO_DEMO_PLUGIN_PATH that aren't running
+// yet and registers their filters, formats and commands. A plugin that fails
+// to start or clashes with an existing name is reported and skipped.
+func loadPlugins(e *env) {
+	paths, err := plugin.Discover(plugin.Dirs())
+	if err != nil {
+		fmt.Fprintf(e.stderr, "go-demo: plugins: %v\n", err)
+		return
+	}
+	for _, path := range paths {
+		if _, ok := plugins[path]; ok {
+			continue
+		}
+		p, err := plugin.Start(path)
+		if err != nil {
+			fmt.Fprintf(e.stderr, "go-demo: %v\n", err)
+			continue
+		}
+		if err := registerPlugin(p); err != nil {
+			fmt.Fprintf(e.stderr, "go-demo: plugin %s: %v\n", p.Manifest.Name, err)
+			p.Close()
+			continue
+		}
+		plugins[path] = p
+	}
+}
+
+func registerPlugin(p *plugin.Plugin) error {
+	for _, c := range p.Manifest.Commands {
+		if _, ok := commands[c.Name]; ok {
+			return fmt.Errorf("command %q is already registered", c.Name)
+		}
+	}
+	if err := tpl.RegisterFilters(p.Filters()); err != nil {
+		return err
+	}
+	if err := schemautil.RegisterFormats(p.Formats()); err != nil {
+		return err
+	}
+	for _, c := range p.Manifest.Commands {
+		name := c.Name
+		commands[name] = command{
+			summary: c.Summary + " (plugin " + p.Manifest.Name + ")",
+			run: func(e *env, args []string) int {
+				code, err := p.RunCommand(name, args, e.stdin, e.stdout, e.stderr)
+				if err != nil {
+					return e.errorf("%v", err)
+				}
+				return code
+			},
+		}
+	}
+	return nil
+}
+
+// runPlugins prints one line per plugin:
+//
+//	acme (/usr/local/lib/go-demo/acme): filters slug; formats sku; commands publish
+func runPlugins(e *env, args []string) int {
+	fs := newFlagSet(e, "plugins", "")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return exitError
+	}
+
+	paths, err := plugin.Discover(plugin.Dirs())
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	manifests := []pluginResult{}
+	for _, path := range paths {
+		p, ok := plugins[path]
+		if !ok {
+			continue
+		}
+		manifests = append(manifests, pluginResult{Path: path, Manifest: p.Manifest})
+		fmt.Fprintf(e.stdout, "%s (%s):", p.Manifest.Name, path)
+		sep := " "
+		list := func(kind string, names []string) {
+			if len(names) > 0 {
+				fmt.Fprintf(e.stdout, "%s%s %s", sep, kind, strings.Join(names, ", "))
+				sep = "; "
+			}
+		}
+		list("filters", p.Manifest.Filters)
+		list("formats", p.Manifest.Formats)
+		var names []string
+		for _, c := range p.Manifest.Commands {
+			names = append(names, c.Name)
+		}
+		list("commands", names)
+		fmt.Fprintln(e.stdout)
+	}
+	e.setResult(manifests)
+	return exitOK
+}
+
+// pluginResult is a plugin in the -output json result.
+type pluginResult struct {
+	Path string `json:"path"`
+	plugin.Manifest
+}
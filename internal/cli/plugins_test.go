@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-demo/pkg/plugin"
+)
+
+// The test binary doubles as a plugin when started with testPluginEnv set.
+const testPluginEnv = "GO_DEMO_TEST_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(testPluginEnv) == "1" {
+		os.Args = append([]string{os.Args[0]}, pluginArgs(os.Args[1:])...)
+		plugin.Serve(plugin.Handlers{
+			Name: "acme",
+			Filters: map[string]func(value, param interface{}) (interface{}, error){
+				"acme_slug": func(value, param interface{}) (interface{}, error) {
+					return strings.ReplaceAll(strings.ToLower(fmt.Sprint(value)), " ", "-"), nil
+				},
+			},
+			Formats: map[string]func(value interface{}) bool{
+				"acme-sku": func(value interface{}) bool {
+					s, ok := value.(string)
+					return !ok || strings.HasPrefix(s, "SKU-")
+				},
+			},
+			Commands: map[string]plugin.CommandHandler{
+				"acme-hello": {
+					Summary: "greet someone",
+					Run: func(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+						if len(args) != 1 {
+							return 2
+						}
+						fmt.Fprintf(stdout, "hello %s\n", args[0])
+						return 0
+					},
+				},
+			},
+		})
+	}
+	code := m.Run()
+	if testPluginDir != "" {
+		os.RemoveAll(testPluginDir)
+	}
+	os.Exit(code)
+}
+
+// testPluginDir is the plugin directory of TestPlugins. It is kept for the
+// whole process: pongo2 can't unregister the plugin's filter, so when go test
+// -count runs TestPlugins again, it reuses the plugin loaded the first time.
+var testPluginDir string
+
+// pluginArgs drops the -test.* flags the test binary may be started with.
+func pluginArgs(args []string) []string {
+	for len(args) > 0 && strings.HasPrefix(args[0], "-test.") {
+		args = args[1:]
+	}
+	return args
+}
+
+func TestPlugins(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("Failed to find the test binary: %v", err)
+	}
+	if testPluginDir == "" {
+		dir, err := os.MkdirTemp("", "go-demo-plugins")
+		if err != nil {
+			t.Fatalf("Failed to create the plugin directory: %v", err)
+		}
+		if err := os.Symlink(exe, filepath.Join(dir, "acme")); err != nil {
+			os.RemoveAll(dir)
+			t.Skipf("Can't create symlinks: %v", err)
+		}
+		testPluginDir = dir
+	}
+	pluginDir := testPluginDir
+	t.Setenv(testPluginEnv, "1")
+	t.Setenv(plugin.PathEnv, pluginDir)
+
+	dir := writeFiles(t, map[string]string{
+		"card.tpl":    `{{ title|acme_slug }}`,
+		"title.json":  `{"title": "Big News"}`,
+		"schema.json": `{"$schema": "http://json-schema.org/draft-07/schema#", "properties": {"sku": {"format": "acme-sku"}}}`,
+		"good.json":   `{"sku": "SKU-1"}`,
+		"bad.json":    `{"sku": "X-1"}`,
+	})
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	code, stdout, stderr := run(t, "", "plugins")
+	if code != exitOK || stdout != fmt.Sprintf("acme (%s): filters acme_slug; formats acme-sku; commands acme-hello\n", filepath.Join(pluginDir, "acme")) {
+		t.Fatalf("unexpected plugins output (%d): %s%s", code, stdout, stderr)
+	}
+
+	code, stdout, stderr = run(t, "", "render", "-template", path("card.tpl"), "-context", path("title.json"))
+	if code != exitOK || stdout != "big-news" {
+		t.Errorf("expected big-news, got %d %q: %s", code, stdout, stderr)
+	}
+
+	code, stdout, _ = run(t, "", "validate", "-schema", path("schema.json"), path("good.json"), path("bad.json"))
+	if code != exitFailure || !strings.Contains(stdout, "good.json: valid") || !strings.Contains(stdout, "bad.json: /sku: ") {
+		t.Errorf("expected bad.json to fail the plugin format, got %d: %s", code, stdout)
+	}
+
+	code, stdout, _ = run(t, "", "acme-hello", "world")
+	if code != exitOK || stdout != "hello world\n" {
+		t.Errorf("expected the plugin command to run, got %d %q", code, stdout)
+	}
+	if code, _, _ = run(t, "", "acme-hello"); code != 2 {
+		t.Errorf("expected the plugin command's exit code 2, got %d", code)
+	}
+	if _, _, stderr = run(t, "", "help"); !strings.Contains(stderr, "greet someone (plugin acme)") {
+		t.Errorf("expected the plugin command in the usage, got:\n%s", stderr)
+	}
+}
+
+func TestPluginsStartedOnDemand(t *testing.T) {
+	// A plugin that can't start reports an error whenever it is started.
+	pluginDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(pluginDir, "broken"), []byte("#!/bin/sh\nexit 1\n"), 0o755); err != nil {
+		t.Fatalf("Failed to write the plugin: %v", err)
+	}
+	t.Setenv(plugin.PathEnv, pluginDir)
+	dir := writeFiles(t, map[string]string{"doc.json": `{"a": 1}`})
+
+	if code, _, stderr := run(t, "", "convert", "-to", "yaml", filepath.Join(dir, "doc.json")); code != exitOK || strings.Contains(stderr, "broken") {
+		t.Errorf("expected convert to run without starting plugins, got %d: %s", code, stderr)
+	}
+	if _, _, stderr := run(t, "", "render", "-template", filepath.Join(dir, "doc.json"), "-context", filepath.Join(dir, "doc.json")); !strings.Contains(stderr, "broken") {
+		t.Errorf("expected render to start the plugins, got: %s", stderr)
+	}
+	if _, _, stderr := run(t, "", "no-such-command"); !strings.Contains(stderr, "broken") {
+		t.Errorf("expected an unknown command to be looked up in the plugins, got: %s", stderr)
+	}
+}
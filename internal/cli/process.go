@@ -0,0 +1,249 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+
+	"go-demo/pkg/audit"
+	"go-demo/pkg/batch"
+	"go-demo/pkg/jsonutil"
+	"go-demo/pkg/pipeline"
+	"go-demo/pkg/stream"
+)
+
+func init() {
+	commands["process"] = command{
+		summary: "generate the documents of a directory, NDJSON file or queue as a resumable job",
+		run:     runProcess,
+		plugins: true,
+	}
+}
+
+// runProcess generates every document of an input with a document type from
+// a types file, recording each outcome in a checkpoint (see package batch),
+// and exits with exitFailure if any document failed. An interrupted job is
+// resumed by running the command again with the same -checkpoint.
+func runProcess(e *env, args []string) int {
+	fs := newFlagSet(e, "process", "")
+	typesPath := fs.String("types", "", "document types file: JSON, YAML or TOML")
+	typeName := fs.String("type", "", "document type of the input")
+	in := fs.String("in", "", "input: a directory of JSON, YAML and TOML files, an .ndjson file, or a queue (kafka://BROKER[,BROKER]/TOPIC?group=GROUP or nats://HOST:PORT/SUBJECT?queue=GROUP)")
+	out := fs.String("out", "", "directory to write the outputs to")
+	checkpoint := fs.String("checkpoint", "", "record progress in this file, or in an SQLite database with sqlite:PATH, to resume the job after a crash")
+	job := fs.String("job", "", "name of the job in an SQLite checkpoint (default: the document type)")
+	shard := fs.String("shard", "", "process only part I of N of the input, e.g. 2/4, to split the job between processes")
+	jobs := fs.Int("jobs", runtime.NumCPU(), "number of documents to process in parallel")
+	retry := fs.Bool("retry-failed", false, "process the documents that failed in earlier runs again")
+	auditPath := fs.String("audit", "", "append a record of how each document was generated to this JSONL file")
+	logLevel := fs.String("log-level", "info", "log events of at least this level: debug, info, warn (failed documents) or error")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *typesPath == "" || *typeName == "" || *in == "" || *out == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return exitError
+	}
+
+	logger, err := e.logger(*logLevel)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	base := pipeline.Pipeline{Logger: logger}
+	if *auditPath != "" {
+		log, err := audit.OpenFile(*auditPath)
+		if err != nil {
+			return e.errorf("%v", err)
+		}
+		defer log.Close()
+		base.Audit = log
+	}
+	registry, err := pipeline.LoadRegistry(*typesPath, base)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	p, ok := registry.Lookup(*typeName)
+	if !ok {
+		return e.errorf("%v %q", pipeline.ErrUnknownType, *typeName)
+	}
+	ext := ".json"
+	if p.Template != "" {
+		ext = outputExt(p.TemplateName)
+	}
+
+	engine := &batch.Engine{Workers: *jobs, RetryFailed: *retry, Logger: logger}
+	if *shard != "" {
+		if engine.Shard, engine.Shards, err = parseShard(*shard); err != nil {
+			return e.errorf("-shard: %v", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	src, closeSource, err := openInput(*in)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	defer closeSource()
+	// Files are decoded by their extension, the others are JSON.
+	_, isDir := src.(*batch.DirSource)
+
+	if *checkpoint != "" {
+		if *job == "" {
+			*job = *typeName
+		}
+		c, err := batch.OpenCheckpoint(ctx, *checkpoint, *job)
+		if err != nil {
+			return e.errorf("%v", err)
+		}
+		defer c.Close()
+		engine.Checkpoint = c
+	}
+
+	var (
+		mu       sync.Mutex
+		failures = map[string]error{}
+	)
+	engine.Process = func(ctx context.Context, item batch.Item) error {
+		err := processItem(ctx, p, item, isDir, *out, ext)
+		if err != nil {
+			mu.Lock()
+			failures[item.ID] = err
+			mu.Unlock()
+		}
+		return err
+	}
+	summary, err := engine.Run(ctx, src)
+
+	ids := make([]string, 0, len(failures))
+	for id := range failures {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		e.fileError(id, failures[id])
+	}
+	e.setResult(summary)
+	if !e.json {
+		fmt.Fprintf(e.stderr, "%d documents: %d succeeded, %d failed (%d from earlier runs)\n", summary.Items, summary.Succeeded, summary.Failed, summary.Resumed)
+	}
+	if errors.Is(err, context.Canceled) {
+		if *checkpoint == "" {
+			return e.errorf("interrupted")
+		}
+		return e.errorf("interrupted; run the command again with -checkpoint %s to resume", *checkpoint)
+	}
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	if summary.Failed > 0 {
+		return exitFailure
+	}
+	return exitOK
+}
+
+// processItem generates the document of an item and writes it below out,
+// named after the item's ID with the extension ext.
+func processItem(ctx context.Context, p pipeline.Pipeline, item batch.Item, decode bool, out, ext string) error {
+	data := item.Data
+	if decode {
+		format, err := jsonutil.FormatFromPath(item.ID)
+		if err != nil {
+			return err
+		}
+		if format != jsonutil.FormatJSON {
+			doc, err := jsonutil.Unmarshal(format, data)
+			if err != nil {
+				return err
+			}
+			if data, err = jsonutil.Marshal(jsonutil.FormatJSON, doc, 0); err != nil {
+				return err
+			}
+		}
+	}
+	output, _, err := p.Process(audit.WithDocument(ctx, item.ID), data)
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSuffix(item.ID, path.Ext(item.ID)) + ext
+	dst := filepath.Join(out, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, output, 0o644)
+}
+
+// parseShard parses "I/N", the I-th of N parts counting from 1, into the
+// 0-based shard of batch.Engine.
+func parseShard(s string) (shard, shards int, err error) {
+	i, n, ok := strings.Cut(s, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("%q is not I/N", s)
+	}
+	shard, err1 := strconv.Atoi(i)
+	shards, err2 := strconv.Atoi(n)
+	if err1 != nil || err2 != nil || shards < 1 || shard < 1 || shard > shards {
+		return 0, 0, fmt.Errorf("%q is not I/N with 1 <= I <= N", s)
+	}
+	return shard - 1, shards, nil
+}
+
+// openInput opens the source named by in, and returns the function that
+// closes it.
+func openInput(in string) (batch.Source, func(), error) {
+	u, err := url.Parse(in)
+	if err == nil && (u.Scheme == "kafka" || u.Scheme == "nats") {
+		topic := strings.TrimPrefix(u.Path, "/")
+		if topic == "" {
+			return nil, nil, fmt.Errorf("%s: missing topic", in)
+		}
+		if u.Scheme == "kafka" {
+			group := u.Query().Get("group")
+			if group == "" {
+				return nil, nil, fmt.Errorf("%s: missing ?group=", in)
+			}
+			k, err := stream.NewKafka(stream.KafkaConfig{Brokers: strings.Split(u.Host, ","), GroupID: group, Topics: []string{topic}})
+			if err != nil {
+				return nil, nil, err
+			}
+			return batch.Queue(k), func() { k.Close() }, nil
+		}
+		conn, err := nats.Connect("nats://" + u.Host)
+		if err != nil {
+			return nil, nil, fmt.Errorf("can't connect to %s: %v", u.Host, err)
+		}
+		n, err := stream.NewNATS(conn, u.Query().Get("queue"), topic)
+		if err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+		return batch.Queue(n), func() { n.Close(); conn.Close() }, nil
+	}
+
+	info, err := os.Stat(in)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		src, err := batch.Dir(in)
+		return src, func() {}, err
+	}
+	src, err := batch.OpenNDJSON(in)
+	if err != nil {
+		return nil, nil, err
+	}
+	return src, func() { src.Close() }, nil
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-demo/pkg/batch"
+)
+
+func TestProcessCommand(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"types.yaml":       "types:\n  card:\n    schema: user.json\n    template: card.txt\n",
+		"user.json":        userSchema,
+		"card.txt":         "{{ name }} ({{ role }})",
+		"in/alice.yaml":    "name: Alice\n",
+		"in/team/bob.json": `{"name": "Bob", "role": "admin"}`,
+		"in/nameless.json": `{"age": 3}`,
+		"export.ndjson":    "{\"name\": \"Carol\"}\n{\"age\": 3}\n",
+	})
+	types := filepath.Join(dir, "types.yaml")
+	in := filepath.Join(dir, "in")
+	out := filepath.Join(dir, "out")
+	checkpoint := filepath.Join(dir, "job.ckpt")
+	args := []string{"process", "-types", types, "-type", "card", "-in", in, "-out", out, "-checkpoint", checkpoint, "-jobs", "2"}
+
+	code, _, stderr := run(t, "", args...)
+	if code != exitFailure || !strings.Contains(stderr, "nameless.json: ") || !strings.Contains(stderr, "3 documents: 2 succeeded, 1 failed (0 from earlier runs)") {
+		t.Errorf("expected one failure, got %d: %s", code, stderr)
+	}
+	for name, want := range map[string]string{"alice.txt": "Alice (member)", "team/bob.txt": "Bob (admin)"} {
+		if b, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(name))); err != nil || string(b) != want {
+			t.Errorf("%s: expected %q, got %q (%v)", name, want, b, err)
+		}
+	}
+
+	// A second run resumes: nothing is left to do, and the failure stays.
+	code, _, stderr = run(t, "", args...)
+	if code != exitFailure || !strings.Contains(stderr, "3 documents: 2 succeeded, 1 failed (3 from earlier runs)") {
+		t.Errorf("expected the job to be resumed, got %d: %s", code, stderr)
+	}
+
+	// Fixed documents are processed again with -retry-failed.
+	if err := os.WriteFile(filepath.Join(in, "nameless.json"), []byte(`{"name": "Dan"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	code, stdout, _ := run(t, "", append(args, "-retry-failed", "-output", "json")...)
+	var result struct {
+		Result batch.Summary `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); code != exitOK || err != nil || result.Result != (batch.Summary{Items: 3, Succeeded: 3, Resumed: 2}) {
+		t.Errorf("expected the failure to be retried, got %d: %s", code, stdout)
+	}
+	if b, _ := os.ReadFile(filepath.Join(out, "nameless.txt")); string(b) != "Dan (member)" {
+		t.Errorf("expected the retried output, got %q", b)
+	}
+
+	// NDJSON lines are named after their line numbers.
+	ndjsonOut := filepath.Join(dir, "ndjson-out")
+	code, _, stderr = run(t, "", "process", "-types", types, "-type", "card", "-in", filepath.Join(dir, "export.ndjson"), "-out", ndjsonOut)
+	if code != exitFailure || !strings.Contains(stderr, "2: ") {
+		t.Errorf("expected line 2 to fail, got %d: %s", code, stderr)
+	}
+	if b, _ := os.ReadFile(filepath.Join(ndjsonOut, "1.txt")); string(b) != "Carol (member)" {
+		t.Errorf("expected the output of line 1, got %q", b)
+	}
+
+	// Shards split the input without overlap.
+	shardOut := filepath.Join(dir, "shard-out")
+	items := 0
+	for _, shard := range []string{"1/2", "2/2"} {
+		code, stdout, _ := run(t, "", "process", "-types", types, "-type", "card", "-in", in, "-out", shardOut, "-shard", shard, "-output", "json")
+		result.Result = batch.Summary{}
+		if err := json.Unmarshal([]byte(stdout), &result); code != exitOK || err != nil {
+			t.Errorf("shard %s: expected success, got %d: %s", shard, code, stdout)
+		}
+		items += result.Result.Items
+	}
+	if items != 3 {
+		t.Errorf("expected the shards to process 3 documents, got %d", items)
+	}
+
+	for _, bad := range [][]string{
+		{"process", "-types", types, "-type", "card", "-in", in},
+		{"process", "-types", types, "-type", "card", "-in", in, "-out", out, "-shard", "3/2"},
+		{"process", "-types", types, "-type", "card", "-in", "kafka://localhost:9092/orders", "-out", out},
+		{"process", "-types", types, "-type", "card", "-in", filepath.Join(dir, "missing"), "-out", out},
+	} {
+		if code, _, _ := run(t, "", bad...); code != exitError {
+			t.Errorf("%v: expected exit code %d, got %d", bad, exitError, code)
+		}
+	}
+}
+
+func TestParseShard(t *testing.T) {
+	tests := []struct {
+		in            string
+		shard, shards int
+		ok            bool
+	}{
+		{"1/1", 0, 1, true},
+		{"2/4", 1, 4, true},
+		{"4/4", 3, 4, true},
+		{"0/4", 0, 0, false},
+		{"5/4", 0, 0, false},
+		{"1", 0, 0, false},
+		{"a/b", 0, 0, false},
+	}
+	for _, tt := range tests {
+		shard, shards, err := parseShard(tt.in)
+		if (err == nil) != tt.ok || shard != tt.shard || shards != tt.shards {
+			t.Errorf("parseShard(%q) = %d, %d, %v", tt.in, shard, shards, err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+
+	"go-demo/pkg/jsonutil"
+)
+
+func init() {
+	commands["query"] = command{
+		summary: "extract values from documents with JSONPath or a JSON Pointer",
+		run:     runQuery,
+	}
+}
+
+// runQuery prints the values a JSONPath expression (or -pointer) selects in
+// each JSON, YAML or TOML document (or stdin for "-"), one per match. Large
+// integers print exactly. It exits 1 if nothing matches.
+func runQuery(e *env, args []string) int {
+	fs := newFlagSet(e, "query", "[EXPR] FILE...")
+	pointer := fs.String("pointer", "", "select the value at this JSON Pointer instead of a JSONPath EXPR, e.g. /items/0/id")
+	indent := fs.Int("indent", 2, "spaces to indent the output with (0 for one value per line)")
+	raw := fs.Bool("raw", false, "print strings without quotes")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	files := fs.Args()
+	var path *jsonutil.Path
+	if *pointer == "" {
+		if len(files) < 2 {
+			fs.Usage()
+			return exitError
+		}
+		var err error
+		if path, err = jsonutil.ParsePath(files[0]); err != nil {
+			return e.errorf("%v", err)
+		}
+		files = files[1:]
+	} else if _, err := jsonutil.SplitPointer(*pointer); err != nil {
+		return e.errorf("%v", err)
+	}
+	if len(files) == 0 {
+		fs.Usage()
+		return exitError
+	}
+
+	code := exitFailure
+	results := make([]queryResult, 0, len(files))
+	for _, file := range files {
+		doc, err := e.readDocument(file, "")
+		if err != nil {
+			e.fileError(file, err)
+			return exitError
+		}
+		var matches []jsonutil.Match
+		if path != nil {
+			matches = path.Find(doc)
+		} else if v, err := jsonutil.Get(doc, *pointer); err == nil {
+			matches = []jsonutil.Match{{Pointer: *pointer, Value: v}}
+		} else {
+			e.warnf("%s: %v", file, err)
+		}
+		if matches == nil {
+			matches = []jsonutil.Match{}
+		}
+		results = append(results, queryResult{File: file, Matches: matches})
+		for _, m := range matches {
+			code = exitOK
+			if e.json {
+				continue
+			}
+			if err := e.printValue(m.Value, *indent, *raw); err != nil {
+				return e.errorf("%v", err)
+			}
+		}
+	}
+	e.setResult(results)
+	return code
+}
+
+// queryResult is the outcome for one file in the -output json result.
+type queryResult struct {
+	File    string           `json:"file"`
+	Matches []jsonutil.Match `json:"matches"`
+}
+
+// printValue writes v as JSON on its own line(s), or a string as is with raw.
+func (e *env) printValue(v interface{}, indent int, raw bool) error {
+	if s, ok := v.(string); ok && raw {
+		_, err := fmt.Fprintln(e.stdout, s)
+		return err
+	}
+	b, err := jsonutil.Marshal(jsonutil.FormatJSON, v, indent)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(e.stdout, "%s\n", bytes.TrimRight(b, "\n"))
+	return err
+}
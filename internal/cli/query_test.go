@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestQueryCommand(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"order.json": `{"items": [{"id": 9007199254740993, "sku": "A-1", "qty": 2}, {"id": 2, "sku": "B-2", "qty": 10}]}`,
+		"order.yaml": "items:\n  - id: 7\n    sku: C-3\n",
+	})
+	order := filepath.Join(dir, "order.json")
+
+	tests := []struct {
+		name  string
+		args  []string
+		stdin string
+		code  int
+		want  string
+	}{
+		{"jsonpath", []string{"$.items[*].id", order}, "", exitOK, "9007199254740993\n2\n"},
+		{"filter raw", []string{"-raw", "$.items[?(@.qty > 5)].sku", order}, "", exitOK, "B-2\n"},
+		{"compact object", []string{"-indent", "0", "$.items[0]", order}, "", exitOK, `{"id":9007199254740993,"qty":2,"sku":"A-1"}` + "\n"},
+		{"pointer", []string{"-pointer", "/items/1/sku", order}, "", exitOK, "\"B-2\"\n"},
+		{"several files", []string{"$..sku", order, filepath.Join(dir, "order.yaml")}, "", exitOK, "\"A-1\"\n\"B-2\"\n\"C-3\"\n"},
+		{"stdin", []string{"$.a", "-"}, `{"a": 1}`, exitOK, "1\n"},
+		{"no match", []string{"$.missing", order}, "", exitFailure, ""},
+		{"missing pointer", []string{"-pointer", "/items/5", order}, "", exitFailure, ""},
+		{"invalid path", []string{"items[0]", order}, "", exitError, ""},
+		{"no file", []string{"$.a"}, "", exitError, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := run(t, tt.stdin, append([]string{"query"}, tt.args...)...)
+			if code != tt.code || stdout != tt.want {
+				t.Errorf("expected %d %q, got %d %q (%s)", tt.code, tt.want, code, stdout, stderr)
+			}
+		})
+	}
+
+	code, stdout, _ := run(t, "", "-output", "json", "query", "$.items[1].id", order)
+	if code != exitOK || !strings.Contains(stdout, `"pointer": "/items/1/id"`) {
+		t.Errorf("expected the match pointers in the JSON result, got %d: %s", code, stdout)
+	}
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"strings"
+
+	"go-demo/pkg/jsonutil"
+)
+
+func init() {
+	commands["redact"] = command{
+		summary: "mask sensitive values in a document before sharing it",
+		run:     runRedact,
+	}
+}
+
+// runRedact replaces the values of members whose names match -keys with
+// "[REDACTED]" in a JSON, YAML or TOML document (or stdin for "-") and
+// writes the result to stdout or -out.
+func runRedact(e *env, args []string) int {
+	fs := newFlagSet(e, "redact", "FILE")
+	keys := fs.String("keys", "password,passwd,token,*secret*,authorization,api_key,apikey", "comma-separated member names to redact; * and ? match any characters, case-insensitively")
+	to := fs.String("to", "", "output format: json, yaml or toml (default: from the -out extension, json for stdout)")
+	out := fs.String("out", "", "write the result to this file instead of stdout (- for stdout)")
+	indent := fs.Int("indent", 2, "spaces to indent the output with")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitError
+	}
+	var patterns []string
+	for _, k := range strings.Split(*keys, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			patterns = append(patterns, k)
+		}
+	}
+	format := jsonutil.FormatJSON
+	if *to != "" || *out != "" && *out != stdio {
+		var err error
+		if format, err = formatFlag(*to, *out); err != nil {
+			return e.errorf("%v", err)
+		}
+	}
+
+	doc, err := e.readDocument(fs.Arg(0), "")
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	redacted, err := jsonutil.Redact(doc, patterns)
+	if err != nil {
+		return e.errorf("-keys: %v", err)
+	}
+	output, err := jsonutil.Marshal(format, redacted, *indent)
+	if err != nil {
+		return e.errorf("encode %s: %v", format, err)
+	}
+	if err := e.writeFile(*out, output); err != nil {
+		return e.errorf("%v", err)
+	}
+	return exitOK
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRedactCommand(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"payload.json": `{"id": 9007199254740993, "user": {"name": "alice", "password": "hunter2"}, "headers": {"Authorization": "Bearer abc"}, "db_secret_key": "k"}`,
+		"config.yaml":  "db:\n  host: localhost\n  Token: abc\n",
+	})
+	payload := filepath.Join(dir, "payload.json")
+
+	tests := []struct {
+		name  string
+		args  []string
+		stdin string
+		code  int
+		want  string
+	}{
+		{"default keys", []string{"-indent", "0", payload}, "", exitOK,
+			`{"db_secret_key":"[REDACTED]","headers":{"Authorization":"[REDACTED]"},"id":9007199254740993,"user":{"name":"alice","password":"[REDACTED]"}}` + "\n"},
+		{"custom keys", []string{"-indent", "0", "-keys", "name, *secret*", payload}, "", exitOK,
+			`{"db_secret_key":"[REDACTED]","headers":{"Authorization":"Bearer abc"},"id":9007199254740993,"user":{"name":"[REDACTED]","password":"hunter2"}}` + "\n"},
+		{"yaml", []string{"-to", "yaml", filepath.Join(dir, "config.yaml")}, "", exitOK, "db:\n  Token: '[REDACTED]'\n  host: localhost\n"},
+		{"stdin", []string{"-indent", "0", "-"}, `{"token": 1}`, exitOK, `{"token":"[REDACTED]"}` + "\n"},
+		{"bad pattern", []string{"-keys", "[x", payload}, "", exitError, ""},
+		{"no file", nil, "", exitError, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := run(t, tt.stdin, append([]string{"redact"}, tt.args...)...)
+			if code != tt.code || stdout != tt.want {
+				t.Errorf("expected %d %q, got %d %q (%s)", tt.code, tt.want, code, stdout, stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	pongo2lib "github.com/flosch/pongo2/v6"
+
+	"go-demo/pkg/pongo2"
+)
+
+func init() {
+	commands["render"] = command{
+		summary: "render a template with a context document",
+		run:     runRender,
+		plugins: true,
+	}
+}
+
+// runRender renders a template with a JSON, YAML or TOML context and writes
+// the output to stdout or -out. Either the template or the context may be read
+// from stdin with "-". With -glob, the template is rendered once per matching
+// context document into -out-dir.
+func runRender(e *env, args []string) int {
+	fs := newFlagSet(e, "render", "")
+	template := fs.String("template", "", "template file (- for stdin)")
+	contextPath := fs.String("context", "", "context document: JSON, YAML or TOML (- for JSON on stdin)")
+	out := fs.String("out", "", "write the output to this file instead of stdout (- for stdout)")
+	mode := fs.String("output-mode", "", "escape output for html, json, xml, markdown or text")
+	dirs := fs.String("dirs", "", "comma-separated template directories, highest priority first")
+	trim := fs.Bool("trim-blocks", false, "remove the first newline after block tags")
+	lstrip := fs.Bool("lstrip-blocks", false, "strip whitespace before block tags")
+	batch := addBatchFlags(fs)
+	ext := fs.String("ext", "", "with -glob, extension of the output files (default: from the template name, e.g. .html for card.html.tpl)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *template == "" || fs.NArg() > 0 || batch.enabled() && (*contextPath != "" || *out != "") {
+		fs.Usage()
+		return exitError
+	}
+	if *template == stdio && *contextPath == stdio {
+		return e.errorf("the template and the context can't both be read from stdin")
+	}
+
+	opts := pongo2.Options{TrimBlocks: *trim, LStripBlocks: *lstrip, OutputMode: pongo2.OutputMode(*mode)}
+	if *dirs != "" {
+		opts.TemplateDirs = strings.Split(*dirs, ",")
+	}
+	r := pongo2.NewRenderer(opts)
+
+	renderContext := func(ctx pongo2lib.Context) (string, error) {
+		return r.RenderFile(*template, ctx)
+	}
+	var src []byte
+	if *template == stdio {
+		var err error
+		if src, err = e.readFile(stdio); err != nil {
+			return e.errorf("%v", err)
+		}
+		renderContext = func(ctx pongo2lib.Context) (string, error) {
+			return r.RenderString(string(src), ctx)
+		}
+	} else if *dirs == "" {
+		src, _ = os.ReadFile(*template)
+	}
+
+	if batch.enabled() {
+		if *ext == "" {
+			*ext = outputExt(*template)
+		}
+		return e.runBatch(batch, *ext, func(path string) ([]byte, error) {
+			ctx, err := e.readContext(path)
+			if err != nil {
+				return nil, err
+			}
+			output, err := renderContext(ctx)
+			return []byte(output), err
+		})
+	}
+
+	ctx, err := e.readContext(*contextPath)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	// pongo2 renders missing variables as empty strings, so point them out.
+	if report, err := pongo2.ValidateContext(string(src), ctx); src != nil && err == nil {
+		for _, missing := range report.Missing {
+			e.warnf("%s: context is missing %s", *template, missing)
+		}
+	}
+	output, err := renderContext(ctx)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	if err := e.writeFile(*out, []byte(output)); err != nil {
+		return e.errorf("%v", err)
+	}
+	return exitOK
+}
+
+// outputExt returns the extension of the files a template produces: the
+// extension before a template extension such as .tpl, or ".txt".
+func outputExt(template string) string {
+	if template == stdio {
+		return ".txt"
+	}
+	name := filepath.Base(template)
+	for _, tplExt := range []string{".tpl", ".tmpl", ".j2", ".jinja", ".pongo2"} {
+		name = strings.TrimSuffix(name, tplExt)
+	}
+	if ext := filepath.Ext(name); ext != "" {
+		return ext
+	}
+	return ".txt"
+}
+
+// readContext reads a template context document, or returns an empty context
+// if path is "".
+func (e *env) readContext(path string) (pongo2lib.Context, error) {
+	if path == "" {
+		return pongo2lib.Context{}, nil
+	}
+	doc, err := e.readDocument(path, "")
+	if err != nil {
+		return nil, err
+	}
+	m, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: context must be an object", path)
+	}
+	return pongo2lib.Context(m), nil
+}
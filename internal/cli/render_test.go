@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderCommand(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"hello.tpl":    "Hello {{ name }}!",
+		"user.json":    `{"name": "<Alice>"}`,
+		"context.yaml": "name: Bob\n",
+	})
+	tpl := filepath.Join(dir, "hello.tpl")
+
+	code, stdout, stderr := run(t, "", "render", "-template", tpl, "-context", filepath.Join(dir, "context.yaml"))
+	if code != exitOK || stdout != "Hello Bob!" {
+		t.Fatalf("render failed with %d: %s%s", code, stdout, stderr)
+	}
+
+	code, stdout, stderr = run(t, `{"name": "<Alice>"}`, "render", "-template", tpl, "-context", "-", "-output-mode", "text")
+	if code != exitOK || stdout != "Hello <Alice>!" {
+		t.Errorf("render with context on stdin failed with %d: %s%s", code, stdout, stderr)
+	}
+
+	out := filepath.Join(dir, "out.txt")
+	code, _, stderr = run(t, "Hi {{ name }}", "render", "-template", "-", "-context", filepath.Join(dir, "user.json"), "-out", out)
+	if code != exitOK {
+		t.Fatalf("render with template on stdin failed with %d: %s", code, stderr)
+	}
+	if b, err := os.ReadFile(out); err != nil || string(b) != "Hi &lt;Alice&gt;" {
+		t.Errorf("unexpected output %q (%v)", b, err)
+	}
+
+	for _, args := range [][]string{
+		{"render"},
+		{"render", "-template", "-", "-context", "-"},
+		{"render", "-template", tpl, "-context", filepath.Join(dir, "missing.json")},
+	} {
+		if code, _, _ := run(t, "", args...); code != exitError {
+			t.Errorf("%v should fail with %d, got %d", args, exitError, code)
+		}
+	}
+}
+
+func TestOutputExt(t *testing.T) {
+	for template, want := range map[string]string{"card.html.tpl": ".html", "card.md": ".md", "card.tpl": ".txt", "-": ".txt"} {
+		if got := outputExt(template); got != want {
+			t.Errorf("outputExt(%q) = %q, want %q", template, got, want)
+		}
+	}
+}
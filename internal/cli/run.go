@@ -0,0 +1,315 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"gopkg.in/yaml.v3"
+
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/jsonutil"
+	"go-demo/pkg/pongo2"
+)
+
+func init() {
+	commands["run"] = command{
+		summary: "run the steps of a pipeline file on a document",
+		run:     runPipeline,
+		plugins: true,
+	}
+}
+
+// pipelineFile is a declarative document-generation workflow:
+//
+//	input: data/user.yaml
+//	steps:
+//	  - decode: {format: yaml}
+//	  - apply-defaults: {schema: schemas/user.json}
+//	  - validate: {schema: schemas/user.json}
+//	  - render: {template: templates/card.html, output-mode: html}
+//	  - write: {path: build/card.html}
+//
+// Paths are relative to the pipeline file; "-" is stdin or stdout.
+type pipelineFile struct {
+	// Input is the document to process. The command line argument overrides it.
+	Input string         `yaml:"input"`
+	Steps []pipelineStep `yaml:"steps"`
+}
+
+// pipelineStep sets exactly one of its fields.
+type pipelineStep struct {
+	// Decode parses the input in a format; without it, the input is decoded
+	// by its file extension (JSON for stdin) when a step needs the document.
+	Decode *struct {
+		Format string `yaml:"format"`
+	} `yaml:"decode"`
+
+	ApplyDefaults *struct {
+		Schema string `yaml:"schema"`
+	} `yaml:"apply-defaults"`
+
+	// Validate stops the pipeline if the document is invalid.
+	Validate *struct {
+		Schema string `yaml:"schema"`
+	} `yaml:"validate"`
+
+	// Render replaces the document by the template rendered with it as context.
+	Render *struct {
+		Template     string `yaml:"template"`
+		OutputMode   string `yaml:"output-mode"`
+		TrimBlocks   bool   `yaml:"trim-blocks"`
+		LStripBlocks bool   `yaml:"lstrip-blocks"`
+	} `yaml:"render"`
+
+	// Write writes rendered output as is, and a document encoded in Format
+	// (by default the format of the path's extension).
+	Write *struct {
+		Path   string `yaml:"path"`
+		Format string `yaml:"format"`
+		Indent *int   `yaml:"indent"`
+	} `yaml:"write"`
+}
+
+// name returns the name of the step's action, or "" unless exactly one is set.
+func (s pipelineStep) name() string {
+	var names []string
+	if s.Decode != nil {
+		names = append(names, "decode")
+	}
+	if s.ApplyDefaults != nil {
+		names = append(names, "apply-defaults")
+	}
+	if s.Validate != nil {
+		names = append(names, "validate")
+	}
+	if s.Render != nil {
+		names = append(names, "render")
+	}
+	if s.Write != nil {
+		names = append(names, "write")
+	}
+	if len(names) != 1 {
+		return ""
+	}
+	return names[0]
+}
+
+// pipelineRun is the state of a running pipeline.
+type pipelineRun struct {
+	e       *env
+	dir     string // directory of the pipeline file
+	input   string
+	schemas map[string]*jsonschema.Schema
+
+	text    []byte // the input or rendered output, if not decoded
+	doc     interface{}
+	decoded bool
+}
+
+// runPipeline runs a pipeline file. It exits 1 if a validate step fails.
+func runPipeline(e *env, args []string) int {
+	fs := newFlagSet(e, "run", "[INPUT]")
+	path := fs.String("pipeline", "", "pipeline file (YAML)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *path == "" || fs.NArg() > 1 {
+		fs.Usage()
+		return exitError
+	}
+
+	b, err := e.readFile(*path)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	var pf pipelineFile
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&pf); err != nil {
+		return e.errorf("%s: %v", *path, err)
+	}
+	for i, step := range pf.Steps {
+		if step.name() == "" {
+			return e.errorf("%s: step %d must have exactly one of decode, apply-defaults, validate, render or write", *path, i+1)
+		}
+	}
+
+	p := &pipelineRun{e: e, dir: filepath.Dir(*path), schemas: map[string]*jsonschema.Schema{}}
+	p.input = p.resolve(pf.Input)
+	if fs.NArg() == 1 {
+		p.input = fs.Arg(0)
+	}
+	if p.input == "" {
+		return e.errorf("%s: no input; set input or pass a file", *path)
+	}
+	if p.text, err = e.readFile(p.input); err != nil {
+		return e.errorf("%v", err)
+	}
+
+	for i, step := range pf.Steps {
+		code, err := p.step(step)
+		if err != nil {
+			return e.errorf("%s: step %d (%s): %v", *path, i+1, step.name(), err)
+		}
+		if code != exitOK {
+			return code
+		}
+	}
+	return exitOK
+}
+
+// resolve makes a path from the pipeline file relative to its directory.
+func (p *pipelineRun) resolve(path string) string {
+	if path == "" || path == stdio || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(p.dir, path)
+}
+
+func (p *pipelineRun) step(s pipelineStep) (int, error) {
+	switch {
+	case s.Decode != nil:
+		format, err := jsonutil.ParseFormat(s.Decode.Format)
+		if err != nil {
+			return exitError, err
+		}
+		return exitOK, p.decode(format)
+
+	case s.ApplyDefaults != nil:
+		schema, doc, err := p.schemaAndDocument(s.ApplyDefaults.Schema)
+		if err != nil {
+			return exitError, err
+		}
+		p.doc = schemautil.ApplyDefaults(doc, schema)
+		return exitOK, nil
+
+	case s.Validate != nil:
+		schema, doc, err := p.schemaAndDocument(s.Validate.Schema)
+		if err != nil {
+			return exitError, err
+		}
+		violations, err := schemautil.Validate(schema, doc)
+		if err != nil {
+			return exitError, err
+		}
+		for _, v := range violations {
+			p.e.violation(p.input, v)
+		}
+		if len(violations) > 0 {
+			return exitFailure, nil
+		}
+		return exitOK, nil
+
+	case s.Render != nil:
+		doc, err := p.document()
+		if err != nil {
+			return exitError, err
+		}
+		ctx, ok := doc.(map[string]interface{})
+		if !ok {
+			return exitError, fmt.Errorf("the document must be an object to be used as a template context")
+		}
+		r := pongo2.NewRenderer(pongo2.Options{
+			TrimBlocks:   s.Render.TrimBlocks,
+			LStripBlocks: s.Render.LStripBlocks,
+			OutputMode:   pongo2.OutputMode(s.Render.OutputMode),
+		})
+		output, err := r.RenderFile(p.resolve(s.Render.Template), ctx)
+		if err != nil {
+			return exitError, err
+		}
+		p.text, p.doc, p.decoded = []byte(output), nil, false
+		return exitOK, nil
+
+	default:
+		return exitOK, p.write(s.Write.Path, s.Write.Format, s.Write.Indent)
+	}
+}
+
+// decode parses the current text as a document.
+func (p *pipelineRun) decode(format jsonutil.Format) error {
+	doc, err := jsonutil.Unmarshal(format, p.text)
+	if err != nil {
+		return fmt.Errorf("decode %s: %w", format, err)
+	}
+	p.text, p.doc, p.decoded = nil, doc, true
+	return nil
+}
+
+// document returns the current document, decoding the input by its extension
+// if no decode step ran.
+func (p *pipelineRun) document() (interface{}, error) {
+	if !p.decoded {
+		format := jsonutil.FormatJSON
+		if p.input != stdio {
+			var err error
+			if format, err = jsonutil.FormatFromPath(p.input); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.decode(format); err != nil {
+			return nil, err
+		}
+	}
+	return p.doc, nil
+}
+
+// schemaAndDocument returns a compiled schema, compiling each file once, and
+// the current document.
+func (p *pipelineRun) schemaAndDocument(path string) (*jsonschema.Schema, interface{}, error) {
+	if path == "" {
+		return nil, nil, fmt.Errorf("missing schema")
+	}
+	path = p.resolve(path)
+	schema, ok := p.schemas[path]
+	if !ok {
+		var err error
+		if schema, err = schemautil.CompileFile(path); err != nil {
+			return nil, nil, err
+		}
+		p.schemas[path] = schema
+	}
+	doc, err := p.document()
+	return schema, doc, err
+}
+
+// write writes rendered text as is, or the document encoded in a format,
+// creating missing directories.
+func (p *pipelineRun) write(path, formatName string, indent *int) error {
+	if path == "" {
+		return fmt.Errorf("missing path")
+	}
+	path = p.resolve(path)
+	if path != stdio {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+	}
+	if !p.decoded {
+		return p.e.writeFile(path, p.text)
+	}
+
+	format := jsonutil.FormatJSON
+	var err error
+	switch {
+	case formatName != "":
+		format, err = jsonutil.ParseFormat(formatName)
+	case path != stdio:
+		format, err = jsonutil.FormatFromPath(path)
+	}
+	if err != nil {
+		return err
+	}
+	n := 2
+	if indent != nil {
+		n = *indent
+	}
+	b, err := jsonutil.Marshal(format, p.doc, n)
+	if err != nil {
+		return err
+	}
+	return p.e.writeFile(path, b)
+}
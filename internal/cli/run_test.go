@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunPipeline(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"schemas/user.json":   userSchema,
+		"templates/card.html": "<p>{{ name }} ({{ role }})</p>",
+		"data/alice.yaml":     "name: Alice & Co\n",
+		"pipeline.yaml": `input: data/alice.yaml
+steps:
+  - decode: {format: yaml}
+  - apply-defaults: {schema: schemas/user.json}
+  - validate: {schema: schemas/user.json}
+  - write: {path: build/alice.json, indent: 0}
+  - render: {template: templates/card.html, output-mode: html}
+  - write: {path: build/alice.html}
+`,
+	})
+	pipeline := filepath.Join(dir, "pipeline.yaml")
+
+	code, stdout, stderr := run(t, "", "run", "-pipeline", pipeline)
+	if code != exitOK || stdout != "" {
+		t.Fatalf("run failed with %d: %s%s", code, stdout, stderr)
+	}
+	for name, want := range map[string]string{
+		"build/alice.json": `{"name":"Alice & Co","role":"member"}` + "\n",
+		"build/alice.html": "<p>Alice &amp; Co (member)</p>",
+	} {
+		b, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil || string(b) != want {
+			t.Errorf("%s: expected %q, got %q (%v)", name, want, b, err)
+		}
+	}
+
+	// The input argument overrides the pipeline's input; JSON is read from stdin.
+	code, stdout, _ = run(t, `{"age": 3}`, "run", "-pipeline", pipeline, "-")
+	if code != exitFailure || !strings.Contains(stdout, "-: /: missing properties: 'name'") {
+		t.Errorf("expected a validation failure, got %d: %s", code, stdout)
+	}
+}
+
+func TestRunPipelineErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"data.json":    `{}`,
+		"two.yaml":     "input: data.json\nsteps:\n  - decode: {format: json}\n    validate: {schema: s.json}\n",
+		"unknown.yaml": "input: data.json\nsteps:\n  - transform: {}\n",
+		"missing.yaml": "input: data.json\nsteps:\n  - validate: {schema: missing.json}\n",
+	})
+	for name, want := range map[string]string{
+		"two.yaml":     "step 1 must have exactly one of",
+		"unknown.yaml": "field transform not found",
+		"missing.yaml": "step 1 (validate)",
+	} {
+		code, _, stderr := run(t, "", "run", "-pipeline", filepath.Join(dir, name))
+		if code != exitError || !strings.Contains(stderr, want) {
+			t.Errorf("%s: expected exit %d with %q, got %d: %s", name, exitError, want, code, stderr)
+		}
+	}
+}
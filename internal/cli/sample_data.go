@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"bytes"
+	"time"
+
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/jsonutil"
+)
+
+func init() {
+	commands["sample-data"] = command{
+		summary: "generate random documents that are valid against a schema",
+		run:     runSampleData,
+	}
+}
+
+// runSampleData writes -count documents generated from the schema, one
+// compact JSON document per line, or as an indented array with -array.
+func runSampleData(e *env, args []string) int {
+	fs := newFlagSet(e, "sample-data", "")
+	schemaPath := fs.String("schema", "", "JSON Schema file")
+	count := fs.Int("count", 1, "number of documents to generate")
+	seed := fs.Int64("seed", 0, "random seed, for repeatable output (default: time-based)")
+	array := fs.Bool("array", false, "write a JSON array instead of one document per line")
+	out := fs.String("out", "", "write the documents to this file instead of stdout (- for stdout)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *schemaPath == "" || *count < 1 || fs.NArg() > 0 {
+		fs.Usage()
+		return exitError
+	}
+
+	schema, err := schemautil.CompileFile(*schemaPath)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	sampler := schemautil.NewSampler(*seed)
+	docs := make([]interface{}, 0, *count)
+	for i := 0; i < *count; i++ {
+		doc, err := sampler.Sample(schema)
+		if err != nil {
+			return e.errorf("%s: %v", *schemaPath, err)
+		}
+		docs = append(docs, doc)
+	}
+
+	var buf bytes.Buffer
+	if *array {
+		b, err := jsonutil.Marshal(jsonutil.FormatJSON, docs, 2)
+		if err != nil {
+			return e.errorf("%v", err)
+		}
+		buf.Write(b)
+	} else {
+		for _, doc := range docs {
+			b, err := jsonutil.Marshal(jsonutil.FormatJSON, doc, 0)
+			if err != nil {
+				return e.errorf("%v", err)
+			}
+			buf.Write(bytes.TrimRight(b, "\n"))
+			buf.WriteByte('\n')
+		}
+	}
+	if err := e.writeFile(*out, buf.Bytes()); err != nil {
+		return e.errorf("%v", err)
+	}
+	return exitOK
+}
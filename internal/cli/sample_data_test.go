@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/jsonutil"
+)
+
+func TestSampleDataCommand(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"schema.json": userSchema})
+	schemaPath := filepath.Join(dir, "schema.json")
+	schema, err := schemautil.CompileFile(schemaPath)
+	if err != nil {
+		t.Fatalf("Failed to compile schema: %v", err)
+	}
+
+	code, stdout, stderr := run(t, "", "sample-data", "-schema", schemaPath, "-count", "5", "-seed", "7")
+	if code != exitOK {
+		t.Fatalf("sample-data failed with %d: %s", code, stderr)
+	}
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected 5 documents, got %d: %s", len(lines), stdout)
+	}
+	for _, line := range lines {
+		doc, err := jsonutil.DecodeBytes([]byte(line))
+		if err != nil {
+			t.Fatalf("Failed to decode %s: %v", line, err)
+		}
+		if violations, _ := schemautil.Validate(schema, doc); len(violations) > 0 {
+			t.Errorf("Document %s is invalid: %+v", line, violations)
+		}
+	}
+
+	if _, again, _ := run(t, "", "sample-data", "-schema", schemaPath, "-count", "5", "-seed", "7"); again != stdout {
+		t.Errorf("expected the same documents for the same seed:\n%s\n%s", stdout, again)
+	}
+
+	code, stdout, _ = run(t, "", "sample-data", "-schema", schemaPath, "-count", "3", "-seed", "7", "-array")
+	var docs []interface{}
+	if code != exitOK || json.Unmarshal([]byte(stdout), &docs) != nil || len(docs) != 3 {
+		t.Errorf("expected an array of 3 documents, got %d: %s", code, stdout)
+	}
+
+	if code, _, _ := run(t, "", "sample-data", "-count", "3"); code != exitError {
+		t.Errorf("Missing -schema should be a usage error, got %d", code)
+	}
+}
@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/signal"
+
+	"go-demo/pkg/auth"
+	"go-demo/pkg/cache"
+	"go-demo/pkg/grpcapi"
+	"go-demo/pkg/httpapi"
+	"go-demo/pkg/limits"
+	"go-demo/pkg/pongo2"
+	"go-demo/pkg/tenant"
+)
+
+func init() {
+	commands["serve"] = command{
+		summary: "serve validate, apply-defaults and render as an HTTP or gRPC API",
+		run:     runServe,
+		plugins: true,
+	}
+}
+
+// runServe serves the HTTP API, and with -grpc the gRPC service, until
+// interrupted.
+func runServe(e *env, args []string) int {
+	fs := newFlagSet(e, "serve", "")
+	addr := fs.String("addr", ":8080", "address to serve the HTTP API on (empty to disable)")
+	grpcAddr := fs.String("grpc", "", "address to serve the gRPC service on, e.g. :9090")
+	schemas := fs.String("schemas", "", "directory of schemas referenced by name")
+	templates := fs.String("templates", "", "directory of templates referenced by name, and the only files inline templates can include")
+	mode := fs.String("output-mode", "", "escape output for html, json, xml, markdown or text")
+	trim := fs.Bool("trim-blocks", false, "remove the first newline after block tags")
+	lstrip := fs.Bool("lstrip-blocks", false, "strip whitespace before block tags")
+	maxBody := fs.Int64("max-body-bytes", limits.DefaultMaxBodyBytes, "maximum size of a request body or gRPC message")
+	rate := fs.Float64("rate", 0, "requests per second allowed per client IP (0 for no limit)")
+	burst := fs.Int("burst", 10, "requests a client may make at once with -rate")
+	renderTimeout := fs.Duration("render-timeout", 0, "stop renders that take longer, e.g. 2s (0 for no limit)")
+	maxRenders := fs.Int("max-renders", 0, "renders running at once, including timed-out ones still stopping (0 for no limit)")
+	apiKeys := fs.String("api-keys", "", "require API keys listed in this JSON, YAML or TOML file")
+	cacheSize := fs.Int("cache-size", cache.DefaultMaxEntries, "inline schemas and templates kept compiled (0 to disable the cache)")
+	logLevel := fs.String("log-level", "info", "log events of at least this level: debug (compiles and renders), info, warn (failures) or error")
+	cacheTTL := fs.Duration("cache-ttl", 0, "compile inline schemas and templates again after this long, e.g. 1h (0 for no limit)")
+	cacheBackend := fs.String("cache-backend", "", "share compiled schemas with other instances through sqlite:FILE or redis://HOST:PORT/DB")
+	tenants := fs.String("tenants", "", "serve the tenant namespaces listed in this JSON, YAML or TOML file")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() > 0 || *addr == "" && *grpcAddr == "" {
+		fs.Usage()
+		return exitError
+	}
+
+	logger, err := e.logger(*logLevel)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+
+	cfg := httpapi.Config{
+		SchemaDir:   *schemas,
+		TemplateDir: *templates,
+		Options: pongo2.Options{
+			TrimBlocks:   *trim,
+			LStripBlocks: *lstrip,
+			OutputMode:   pongo2.OutputMode(*mode),
+			Logger:       logger,
+		},
+		Limits: limits.Config{
+			MaxBodyBytes:  *maxBody,
+			RatePerSecond: *rate,
+			Burst:         *burst,
+			RenderTimeout: *renderTimeout,
+			MaxRenders:    *maxRenders,
+		},
+	}
+
+	var backend cache.Backend
+	if *cacheBackend != "" {
+		b, err := cache.OpenBackend(context.Background(), *cacheBackend)
+		if err != nil {
+			return e.errorf("%v", err)
+		}
+		defer b.Close()
+		backend = b
+	}
+
+	if *tenants != "" {
+		set, err := tenant.Load(*tenants, cfg.Options, backend)
+		if err != nil {
+			return e.errorf("%v", err)
+		}
+		cfg.Tenants = set
+	}
+
+	if *apiKeys != "" {
+		keys, err := auth.LoadKeys(*apiKeys)
+		if err != nil {
+			return e.errorf("%v", err)
+		}
+		for _, k := range keys {
+			if _, ok := cfg.Tenants.Lookup(k.Tenant); k.Tenant != "" && !ok {
+				return e.errorf("%s: key %s: unknown tenant %q", *apiKeys, k.Name, k.Tenant)
+			}
+		}
+		cfg.Auth = keys
+	}
+
+	// One cache serves both APIs. It is sandboxed like the caches of tenants:
+	// inline schemas come from clients.
+	if *cacheSize > 0 {
+		cacheCfg := cache.Config{MaxEntries: *cacheSize, TTL: *cacheTTL, Backend: backend, Sandbox: true}
+		if *schemas != "" {
+			cacheCfg.SchemaFS = os.DirFS(*schemas)
+		}
+		cfg.Cache = cache.New(cacheCfg)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Run the servers until interrupted or until one of them fails, which
+	// stops the other.
+	var servers []func() error
+	if *addr != "" {
+		servers = append(servers, func() error {
+			logger.Info("serving API", "addr", *addr)
+			return httpapi.ListenAndServe(ctx, *addr, cfg)
+		})
+	}
+	if *grpcAddr != "" {
+		servers = append(servers, func() error {
+			logger.Info("serving gRPC", "addr", *grpcAddr)
+			return grpcapi.ListenAndServe(ctx, *grpcAddr, grpcapi.Config(cfg))
+		})
+	}
+	errs := make(chan error, len(servers))
+	for _, serve := range servers {
+		go func(serve func() error) {
+			err := serve()
+			stop()
+			errs <- err
+		}(serve)
+	}
+	code := exitOK
+	for range servers {
+		if err := <-errs; err != nil {
+			code = e.errorf("%v", err)
+		}
+	}
+	return code
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/jsonutil"
+	"go-demo/pkg/report"
+)
+
+func init() {
+	commands["validate"] = command{
+		summary: "validate JSON documents against a schema",
+		run:     runValidate,
+		plugins: true,
+	}
+}
+
+// runValidate validates every data file (or stdin for "-") against the schema
+// and prints one line per violation:
+//
+//	data.json: /age: must be >= 0 (at #/properties/age/minimum)
+func runValidate(e *env, args []string) int {
+	fs := newFlagSet(e, "validate", "FILE...")
+	schemaPath := fs.String("schema", "", "JSON Schema file")
+	reportPath := fs.String("report", "", "also write a summary report to this file: Markdown (.md) or HTML (.html)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *schemaPath == "" || fs.NArg() == 0 {
+		fs.Usage()
+		return exitError
+	}
+	var reportFormat report.Format
+	if *reportPath != "" {
+		var err error
+		if reportFormat, err = report.FormatFromPath(*reportPath); err != nil {
+			return e.errorf("%v", err)
+		}
+	}
+
+	schema, err := schemautil.CompileFile(*schemaPath)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+
+	code := exitOK
+	results := make([]validateResult, 0, fs.NArg())
+	reportResults := make([]report.Result, 0, fs.NArg())
+	for _, path := range fs.Args() {
+		violations, err := e.validateFile(schema, path)
+		reportResults = append(reportResults, report.Result{File: path, Violations: violations, Err: err})
+		if err != nil {
+			e.fileError(path, err)
+			code = exitError
+			continue
+		}
+		results = append(results, validateResult{File: path, Valid: len(violations) == 0})
+		if len(violations) == 0 {
+			if !e.json {
+				fmt.Fprintf(e.stdout, "%s: valid\n", path)
+			}
+			continue
+		}
+		for _, v := range violations {
+			e.violation(path, v)
+		}
+		if code == exitOK {
+			code = exitFailure
+		}
+	}
+	e.setResult(results)
+
+	if *reportPath != "" {
+		var b bytes.Buffer
+		err := report.Write(&b, reportFormat, reportResults, report.Options{Title: "Validation report: " + *schemaPath})
+		if err == nil {
+			err = e.writeFile(*reportPath, b.Bytes())
+		}
+		if err != nil {
+			return e.errorf("%v", err)
+		}
+	}
+	return code
+}
+
+// validateResult is the outcome for one file in the -output json result.
+type validateResult struct {
+	File  string `json:"file"`
+	Valid bool   `json:"valid"`
+}
+
+// validateFile validates one document, or stdin for "-", and returns its
+// violations.
+func (e *env) validateFile(schema *jsonschema.Schema, path string) ([]schemautil.Violation, error) {
+	b, err := e.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := jsonutil.DecodeBytes(b)
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	return schemautil.Validate(schema, data)
+}
+
+// pointerOrRoot prints the empty JSON Pointer as "/".
+func pointerOrRoot(pointer string) string {
+	if pointer == "" {
+		return "/"
+	}
+	return pointer
+}
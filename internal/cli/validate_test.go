@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const userSchema = `{
+	"$schema": "http://json-schema.org/draft-07/schema#",
+	"type": "object",
+	"properties": {
+		"name": {"type": "string"},
+		"age": {"type": "integer", "minimum": 0},
+		"role": {"type": "string", "default": "member"}
+	},
+	"required": ["name"]
+}`
+
+func TestValidateCommand(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"schema.json": userSchema,
+		"good.json":   `{"name": "Alice", "age": 30}`,
+		"bad.json":    `{"age": -1}`,
+		"broken.json": `{"name": `,
+	})
+	schema := filepath.Join(dir, "schema.json")
+
+	code, stdout, _ := run(t, "", "validate", "-schema", schema, filepath.Join(dir, "good.json"))
+	if code != exitOK || !strings.Contains(stdout, "good.json: valid") {
+		t.Errorf("Valid data should pass, got %d: %s", code, stdout)
+	}
+
+	code, stdout, _ = run(t, "", "validate", "--schema", schema, filepath.Join(dir, "good.json"), filepath.Join(dir, "bad.json"))
+	if code != exitFailure {
+		t.Errorf("Invalid data should exit with %d, got %d", exitFailure, code)
+	}
+	for _, want := range []string{"bad.json: /: missing properties: 'name'", "bad.json: /age: must be >= 0", "#/properties/age/minimum"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Output should contain %q, got:\n%s", want, stdout)
+		}
+	}
+
+	code, _, stderr := run(t, "", "validate", "-schema", schema, filepath.Join(dir, "broken.json"))
+	if code != exitError || !strings.Contains(stderr, "broken.json") {
+		t.Errorf("Unreadable data should exit with %d, got %d: %s", exitError, code, stderr)
+	}
+
+	code, _, _ = run(t, "", "validate", filepath.Join(dir, "good.json"))
+	if code != exitError {
+		t.Errorf("Missing -schema should be a usage error, got %d", code)
+	}
+}
+
+func TestValidateReport(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"schema.json": userSchema,
+		"good.json":   `{"name": "Alice", "age": 30}`,
+		"bad.json":    `{"age": -1}`,
+		"broken.json": `{"name": `,
+	})
+	files := []string{filepath.Join(dir, "good.json"), filepath.Join(dir, "bad.json"), filepath.Join(dir, "broken.json")}
+	for _, name := range []string{"report.md", "report.html"} {
+		path := filepath.Join(dir, name)
+		args := append([]string{"validate", "-schema", filepath.Join(dir, "schema.json"), "-report", path}, files...)
+		if code, _, _ := run(t, "", args...); code != exitError {
+			t.Errorf("%s: expected the exit code of the broken file, got %d", name, code)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("expected a report: %v", err)
+		}
+		for _, want := range []string{"Validation report: ", "33.3%", "/properties/age/minimum", "broken.json"} {
+			if !strings.Contains(string(b), want) {
+				t.Errorf("%s: expected %q in:\n%s", name, want, b)
+			}
+		}
+	}
+	if code, _, stderr := run(t, "", "validate", "-schema", filepath.Join(dir, "schema.json"), "-report", "r.pdf", files[0]); code != exitError || !strings.Contains(stderr, "unknown report format") {
+		t.Errorf("expected an unknown format error, got %d: %s", code, stderr)
+	}
+}
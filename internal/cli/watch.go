@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"go-demo/pkg/audit"
+	"go-demo/pkg/pipeline"
+	"go-demo/pkg/watch"
+)
+
+func init() {
+	commands["watch"] = command{
+		summary: "generate documents dropped into a directory, quarantining failures",
+		run:     runWatch,
+		plugins: true,
+	}
+}
+
+// runWatch runs a watch.Watcher with a document type from a types file until
+// interrupted, or, with -once, processes the files already in the input
+// directory and exits with exitFailure if any was quarantined.
+func runWatch(e *env, args []string) int {
+	fs := newFlagSet(e, "watch", "")
+	typesPath := fs.String("types", "", "document types file: JSON, YAML or TOML")
+	typeName := fs.String("type", "", "document type of the files")
+	in := fs.String("in", "", "directory to watch for JSON, YAML and TOML files")
+	out := fs.String("out", "", "directory to write the outputs to")
+	quarantine := fs.String("quarantine", "", "directory to move failed files and their error reports to")
+	archive := fs.String("archive", "", "directory to move processed files to (default: delete them)")
+	interval := fs.Duration("interval", time.Second, "how often to scan the input directory")
+	once := fs.Bool("once", false, "process the files in the input directory and exit")
+	auditPath := fs.String("audit", "", "append a record of how each document was generated to this JSONL file")
+	logLevel := fs.String("log-level", "info", "log events of at least this level: debug, info (processed files), warn (quarantined files) or error")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *typesPath == "" || *typeName == "" || *in == "" || *out == "" || *quarantine == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return exitError
+	}
+
+	logger, err := e.logger(*logLevel)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	base := pipeline.Pipeline{Logger: logger}
+	if *auditPath != "" {
+		log, err := audit.OpenFile(*auditPath)
+		if err != nil {
+			return e.errorf("%v", err)
+		}
+		defer log.Close()
+		base.Audit = log
+	}
+	registry, err := pipeline.LoadRegistry(*typesPath, base)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	p, ok := registry.Lookup(*typeName)
+	if !ok {
+		return e.errorf("%v %q", pipeline.ErrUnknownType, *typeName)
+	}
+	cfg := watch.Config{
+		Input:      *in,
+		Output:     *out,
+		Quarantine: *quarantine,
+		Archive:    *archive,
+		Pipeline:   p,
+		Interval:   *interval,
+		Logger:     logger,
+	}
+	if p.Template != "" {
+		cfg.Ext = outputExt(p.TemplateName)
+	}
+	w, err := watch.New(cfg)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if !*once {
+		logger.Info("watching", "dir", *in, "type", *typeName)
+		if err := w.Run(ctx); err != nil {
+			return e.errorf("%v", err)
+		}
+		return exitOK
+	}
+
+	// Files are processed once they are unchanged between two scans.
+	if _, err := w.Scan(ctx); err != nil {
+		return e.errorf("%v", err)
+	}
+	time.Sleep(*interval)
+	stats, err := w.Scan(ctx)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	e.setResult(stats)
+	if !e.json {
+		fmt.Fprintf(e.stderr, "%d processed, %d quarantined\n", stats.Processed, stats.Failed)
+	}
+	if stats.Failed > 0 {
+		return exitFailure
+	}
+	return exitOK
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWatchCommand(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"types.yaml":        "types:\n  card:\n    schema: user.json\n    template: card.md.tpl\n",
+		"user.json":         userSchema,
+		"card.md.tpl":       "# {{ name }} ({{ role }})",
+		"in/alice.yaml":     "name: Alice\n",
+		"in/nameless.json":  `{"age": 3}`,
+		"in/.incoming.json": `{"name": "Eve"}`,
+	})
+	args := []string{"watch", "-types", filepath.Join(dir, "types.yaml"), "-type", "card", "-once", "-interval", "10ms",
+		"-in", filepath.Join(dir, "in"), "-out", filepath.Join(dir, "out"), "-quarantine", filepath.Join(dir, "quarantine"), "-archive", filepath.Join(dir, "archive")}
+
+	code, _, stderr := run(t, "", args...)
+	if code != exitFailure || !strings.Contains(stderr, "1 processed, 1 quarantined") {
+		t.Fatalf("expected one processed and one quarantined file, got %d: %s", code, stderr)
+	}
+	if b, err := os.ReadFile(filepath.Join(dir, "out", "alice.md")); err != nil || string(b) != "# Alice (member)" {
+		t.Errorf("expected the rendered card, got %q (%v)", b, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "archive", "alice.yaml")); err != nil {
+		t.Errorf("expected the input to be archived: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "quarantine", "nameless.json.error.json"))
+	if err != nil || !strings.Contains(string(b), `"code": "validation_error"`) {
+		t.Errorf("expected an error report, got %s (%v)", b, err)
+	}
+	if !strings.Contains(stderr, "msg=\"file quarantined\" file=nameless.json") {
+		t.Errorf("expected the quarantine to be logged, got:\n%s", stderr)
+	}
+
+	code, _, stderr = run(t, "", args...)
+	if code != exitOK || !strings.Contains(stderr, "0 processed, 0 quarantined") {
+		t.Errorf("expected the hidden file to be ignored, got %d: %s", code, stderr)
+	}
+
+	if code, _, _ := run(t, "", "watch", "-types", "types.yaml", "-type", "card", "-in", "in"); code != exitError {
+		t.Errorf("missing -out should be a usage error, got %d", code)
+	}
+}
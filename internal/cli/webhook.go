@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go-demo/pkg/jsonutil"
+	"go-demo/pkg/pipeline"
+	"go-demo/pkg/webhook"
+)
+
+func init() {
+	commands["webhook"] = command{
+		summary: "generate a document of a registered type and POST it to a webhook",
+		run:     runWebhook,
+		plugins: true,
+	}
+}
+
+// runWebhook generates a document like generate does and delivers it with
+// webhook.Deliverer. A delivery that fails for good is written to the
+// -dead-letter directory, if any, and exits with exitFailure like invalid
+// data does.
+func runWebhook(e *env, args []string) int {
+	fs := newFlagSet(e, "webhook", "FILE")
+	typesPath := fs.String("types", "", "document types file: JSON, YAML or TOML")
+	typeName := fs.String("type", "", "document type to generate")
+	url := fs.String("url", "", "URL to POST the document to")
+	secretEnv := fs.String("secret-env", "", "sign requests with the secret in this environment variable")
+	contentType := fs.String("content-type", "", "Content-Type of the requests (detected from the document by default)")
+	attempts := fs.Int("attempts", 5, "attempts before giving up")
+	backoff := fs.Duration("backoff", 0, "delay before the first retry, doubled after each one (default 500ms)")
+	timeout := fs.Duration("timeout", 0, "timeout of each attempt (default 10s)")
+	deadLetter := fs.String("dead-letter", "", "write failed deliveries to this directory")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *typesPath == "" || *typeName == "" || *url == "" || fs.NArg() != 1 {
+		fs.Usage()
+		return exitError
+	}
+
+	cfg := webhook.Config{
+		URL:         *url,
+		ContentType: *contentType,
+		MaxAttempts: *attempts,
+		Backoff:     *backoff,
+		Timeout:     *timeout,
+	}
+	if *secretEnv != "" {
+		secret := os.Getenv(*secretEnv)
+		if secret == "" {
+			return e.errorf("environment variable %s is not set", *secretEnv)
+		}
+		cfg.Secret = []byte(secret)
+	}
+	if *deadLetter != "" {
+		cfg.DeadLetter = webhook.DeadLetterDir(*deadLetter)
+	}
+	deliverer, err := webhook.New(cfg)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	registry, err := pipeline.LoadRegistry(*typesPath, pipeline.Pipeline{})
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	p, ok := registry.Lookup(*typeName)
+	if !ok {
+		return e.errorf("%v %q", pipeline.ErrUnknownType, *typeName)
+	}
+	path := fs.Arg(0)
+	doc, err := e.readDocument(path, "")
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+	data, err := jsonutil.Marshal(jsonutil.FormatJSON, doc, 0)
+	if err != nil {
+		return e.errorf("%v", err)
+	}
+
+	result, report, err := deliverer.Generate(context.Background(), p, data)
+	var derr *webhook.Error
+	switch {
+	case errors.Is(err, pipeline.ErrInvalid):
+		for _, v := range report.Violations {
+			e.violation(path, v)
+		}
+		return exitFailure
+	case errors.As(err, &derr):
+		e.fileError(path, err)
+		return exitFailure
+	case err != nil:
+		return e.errorf("%v", err)
+	}
+	e.setResult(result)
+	fmt.Fprintf(e.out, "%s: delivered %s (%d) after %d attempt(s)\n", path, result.ID, result.Status, result.Attempts)
+	return exitOK
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-demo/pkg/webhook"
+)
+
+func TestWebhookCommand(t *testing.T) {
+	var bodies []string
+	var verified bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		verified = webhook.Verify([]byte("s3cret"), r.Header.Get(webhook.HeaderTimestamp), body, r.Header.Get(webhook.HeaderSignature))
+		if strings.Contains(string(body), "Bob") {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}
+	}))
+	defer srv.Close()
+	dir := writeFiles(t, map[string]string{
+		"types.yaml":    "types:\n  card:\n    schema: user.json\n    template: card.txt\n",
+		"user.json":     userSchema,
+		"card.txt":      "{{ name }} ({{ role }})",
+		"alice.yaml":    "name: Alice\n",
+		"bob.yaml":      "name: Bob\n",
+		"nameless.json": `{"age": 3}`,
+	})
+	t.Setenv("WEBHOOK_SECRET", "s3cret")
+	deadLetters := filepath.Join(dir, "dead")
+	webhookArgs := func(file string) []string {
+		return []string{"webhook", "-types", filepath.Join(dir, "types.yaml"), "-type", "card", "-url", srv.URL,
+			"-secret-env", "WEBHOOK_SECRET", "-dead-letter", deadLetters, filepath.Join(dir, file)}
+	}
+
+	code, stdout, stderr := run(t, "", webhookArgs("alice.yaml")...)
+	if code != exitOK || !strings.Contains(stdout, "delivered") || !strings.Contains(stdout, "(200) after 1 attempt(s)") {
+		t.Errorf("expected a delivery, got %d: %q %s", code, stdout, stderr)
+	}
+	if len(bodies) != 1 || bodies[0] != "Alice (member)" || !verified {
+		t.Errorf("expected a signed card, got %q (verified: %v)", bodies, verified)
+	}
+
+	code, _, stderr = run(t, "", webhookArgs("bob.yaml")...)
+	if code != exitFailure || !strings.Contains(stderr, "422 Unprocessable Entity") {
+		t.Errorf("expected a failed delivery, got %d: %s", code, stderr)
+	}
+	if entries, err := os.ReadDir(deadLetters); err != nil || len(entries) != 1 {
+		t.Errorf("expected a dead letter, got %v, %v", entries, err)
+	}
+
+	code, stdout, _ = run(t, "", webhookArgs("nameless.json")...)
+	if code != exitFailure || !strings.Contains(stdout, "missing properties: 'name'") || len(bodies) != 2 {
+		t.Errorf("expected a violation and no delivery, got %d: %s", code, stdout)
+	}
+
+	t.Setenv("WEBHOOK_SECRET", "")
+	if code, _, stderr := run(t, "", webhookArgs("alice.yaml")...); code != exitError || !strings.Contains(stderr, "WEBHOOK_SECRET is not set") {
+		t.Errorf("expected a missing secret error, got %d: %s", code, stderr)
+	}
+	if code, _, _ := run(t, "", "webhook", "-types", "types.yaml", "-type", "card", "x.json"); code != exitError {
+		t.Errorf("missing -url should be a usage error, got %d", code)
+	}
+}
@@ -0,0 +1,73 @@
+// Package sqlutil holds what the stores kept in a database table share:
+// checking the name of the table and writing queries in the parameter style
+// of the driver.
+package sqlutil
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var reIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Table is the table of a store.
+type Table struct {
+	// Name is the name of the table, a plain identifier, so that it can be
+	// put into queries as it is.
+	Name string
+
+	// numbered is set for drivers with numbered parameters ($1, $2, ...).
+	numbered bool
+}
+
+// NewTable returns the table name, or def if name is empty, of a driver with
+// the parameter style placeholder: "?" (or "", e.g. SQLite and MySQL) or "$"
+// for numbered parameters (e.g. PostgreSQL).
+func NewTable(name, def, placeholder string) (Table, error) {
+	t := Table{Name: name}
+	if t.Name == "" {
+		t.Name = def
+	}
+	if !reIdentifier.MatchString(t.Name) {
+		return Table{}, fmt.Errorf("invalid table name %q", t.Name)
+	}
+	switch placeholder {
+	case "", "?":
+	case "$":
+		t.numbered = true
+	default:
+		return Table{}, fmt.Errorf("unknown placeholder style %q (want ? or $)", placeholder)
+	}
+	return t, nil
+}
+
+// Create creates the table with columns, the column and key definitions of
+// a CREATE TABLE statement, unless it exists.
+func (t Table) Create(ctx context.Context, db *sql.DB, columns string) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+t.Name+` (`+columns+`)`); err != nil {
+		return fmt.Errorf("create table %s: %w", t.Name, err)
+	}
+	return nil
+}
+
+// Query replaces the "?" parameters of q with the driver's.
+func (t Table) Query(q string) string {
+	if !t.numbered {
+		return q
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range q {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
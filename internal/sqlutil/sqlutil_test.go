@@ -0,0 +1,35 @@
+package sqlutil
+
+import "testing"
+
+func TestNewTable(t *testing.T) {
+	tests := []struct {
+		name, def, placeholder string
+		want                   string
+		ok                     bool
+	}{
+		{"", "cache", "", "cache", true},
+		{"compiled", "cache", "?", "compiled", true},
+		{"compiled", "cache", "$", "compiled", true},
+		{"x; DROP TABLE cache", "cache", "", "", false},
+		{"", "cache", ":", "", false},
+	}
+	for _, tt := range tests {
+		got, err := NewTable(tt.name, tt.def, tt.placeholder)
+		if (err == nil) != tt.ok || got.Name != tt.want {
+			t.Errorf("NewTable(%q, %q, %q): expected %q (ok %v), got %q (%v)", tt.name, tt.def, tt.placeholder, tt.want, tt.ok, got.Name, err)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	q := `SELECT value FROM cache WHERE cache_key = ? AND expires > ?`
+	question, _ := NewTable("cache", "", "?")
+	if got := question.Query(q); got != q {
+		t.Errorf("expected %q, got %q", q, got)
+	}
+	dollar, _ := NewTable("cache", "", "$")
+	if got, want := dollar.Query(q), `SELECT value FROM cache WHERE cache_key = $1 AND expires > $2`; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,17 @@
+// Command go-demo validates, enriches and renders JSON documents:
+//
+//	go-demo validate -schema schema.json data.json
+//	go-demo dev -template templates/invoice.json.tpl -context sample.yaml
+//
+// Run "go-demo help" for the list of commands.
+package main
+
+import (
+	"os"
+
+	"go-demo/internal/cli"
+)
+
+func main() {
+	os.Exit(cli.Run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
@@ -0,0 +1,142 @@
+// Package assets compiles the schemas and templates shipped inside a binary,
+// typically with go:embed, so a program can load them all in one call:
+//
+//	//go:embed schemas templates
+//	var files embed.FS
+//
+//	var bundle = assets.MustLoad(files, assets.Options{})
+//
+// Everything is compiled when the bundle is loaded, so broken or dangling
+// references fail at startup rather than on first use.
+package assets
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/pongo2"
+)
+
+// ErrSchemaNotFound is returned by Bundle.Schema for unknown names.
+var ErrSchemaNotFound = errors.New("schema not found")
+
+// Options configures Load.
+type Options struct {
+	// SchemaDir is the directory of the schemas in the file system,
+	// "schemas" if empty.
+	SchemaDir string
+
+	// TemplateDir is the directory of the templates, "templates" if empty.
+	TemplateDir string
+
+	// Templates configures the templates' renderer. TemplateDirs is ignored:
+	// templates include and extend each other within TemplateDir.
+	Templates pongo2.Options
+}
+
+// Bundle holds compiled schemas and templates. It is safe for concurrent
+// use.
+type Bundle struct {
+	schemas   map[string]*jsonschema.Schema
+	templates *pongo2.Registry
+}
+
+// Load compiles every *.json schema below SchemaDir (see
+// jsonschema.CompileFS) and every template below TemplateDir (see
+// pongo2.LoadFS). Either directory may be missing, but not both.
+func Load(fsys fs.FS, opts Options) (*Bundle, error) {
+	if opts.SchemaDir == "" {
+		opts.SchemaDir = "schemas"
+	}
+	if opts.TemplateDir == "" {
+		opts.TemplateDir = "templates"
+	}
+	schemaFS, err := sub(fsys, opts.SchemaDir)
+	if err != nil {
+		return nil, err
+	}
+	templateFS, err := sub(fsys, opts.TemplateDir)
+	if err != nil {
+		return nil, err
+	}
+	if schemaFS == nil && templateFS == nil {
+		return nil, fmt.Errorf("assets: neither %s nor %s exists", opts.SchemaDir, opts.TemplateDir)
+	}
+
+	b := &Bundle{schemas: map[string]*jsonschema.Schema{}}
+	if schemaFS != nil {
+		if b.schemas, err = schemautil.CompileFS(schemaFS); err != nil {
+			return nil, err
+		}
+	}
+	if templateFS == nil {
+		templateFS = embed.FS{} // empty
+	}
+	if b.templates, err = pongo2.LoadFS(templateFS, opts.Templates); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// MustLoad is like Load but panics on errors, for package-level variables.
+func MustLoad(fsys fs.FS, opts Options) *Bundle {
+	b, err := Load(fsys, opts)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// sub returns the directory dir of fsys, or nil if it doesn't exist.
+func sub(fsys fs.FS, dir string) (fs.FS, error) {
+	info, err := fs.Stat(fsys, dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("assets: %s is not a directory", dir)
+	}
+	return fs.Sub(fsys, dir)
+}
+
+// Schema returns the schema of a file by its path relative to SchemaDir
+// without ".json", e.g. "billing/invoice".
+func (b *Bundle) Schema(name string) (*jsonschema.Schema, error) {
+	s, ok := b.schemas[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSchemaNotFound, name)
+	}
+	return s, nil
+}
+
+// Template returns a template by its path relative to TemplateDir, e.g.
+// "emails/welcome.txt", or by a version reference (see
+// pongo2.Registry.Resolve). Unknown names fail with an error matching
+// pongo2.ErrTemplateNotFound.
+func (b *Bundle) Template(name string) (*pongo2.Template, error) {
+	return b.templates.Template(name)
+}
+
+// Templates returns the registry of the templates, to render them by name.
+func (b *Bundle) Templates() *pongo2.Registry {
+	return b.templates
+}
+
+// SchemaNames returns the names of the schemas, sorted.
+func (b *Bundle) SchemaNames() []string {
+	names := make([]string, 0, len(b.schemas))
+	for name := range b.schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,90 @@
+package assets
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"go-demo/pkg/pipeline"
+	"go-demo/pkg/pongo2"
+)
+
+//go:embed testdata
+var testdata embed.FS
+
+var bundle = MustLoad(testdata, Options{SchemaDir: "testdata/schemas", TemplateDir: "testdata/templates"})
+
+func TestBundle(t *testing.T) {
+	if got := strings.Join(bundle.SchemaNames(), ","); got != "common/address,user" {
+		t.Errorf("unexpected schemas %q", got)
+	}
+	if got := strings.Join(bundle.Templates().Names(), ","); got != "emails/welcome.txt,layout.txt" {
+		t.Errorf("unexpected templates %q", got)
+	}
+
+	schema, err := bundle.Schema("user")
+	if err != nil {
+		t.Fatalf("Schema failed: %v", err)
+	}
+	tpl, err := bundle.Template("emails/welcome.txt")
+	if err != nil {
+		t.Fatalf("Template failed: %v", err)
+	}
+	p := pipeline.Pipeline{Schema: schema}
+	doc, _, err := p.Process(context.Background(), []byte(`{"name": "Ada", "address": {"city": "Berlin"}}`))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if !strings.Contains(string(doc), `"country": "DE"`) {
+		t.Errorf("expected the default of the referenced schema, got %s", doc)
+	}
+	out, err := tpl.Render(map[string]interface{}{"name": "Ada", "address": map[string]interface{}{"city": "Berlin", "country": "DE"}})
+	if err != nil || out != "<Hi Ada from Berlin, DE>" {
+		t.Errorf("unexpected output %q (%v)", out, err)
+	}
+
+	if _, err := bundle.Schema("missing"); !errors.Is(err, ErrSchemaNotFound) {
+		t.Errorf("expected ErrSchemaNotFound, got %v", err)
+	}
+	if _, err := bundle.Template("missing.txt"); !errors.Is(err, pongo2.ErrTemplateNotFound) {
+		t.Errorf("expected ErrTemplateNotFound, got %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	b, err := Load(fstest.MapFS{"schemas/a.json": {Data: []byte(`{}`)}}, Options{})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(b.SchemaNames()) != 1 || len(b.Templates().Names()) != 0 {
+		t.Errorf("expected one schema and no templates")
+	}
+
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		err  string
+	}{
+		{"empty", fstest.MapFS{"other/a.json": {Data: []byte(`{}`)}}, "neither schemas nor templates exists"},
+		{"file", fstest.MapFS{"schemas": {Data: []byte(`{}`)}}, "schemas is not a directory"},
+		{"dangling ref", fstest.MapFS{"schemas/a.json": {Data: []byte(`{"$ref": "b.json"}`)}}, "a.json"},
+		{"broken template", fstest.MapFS{"templates/a.txt": {Data: []byte(`{% if %}`)}}, "a.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(tt.fsys, Options{}); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected MustLoad to panic")
+		}
+	}()
+	MustLoad(fstest.MapFS{}, Options{})
+}
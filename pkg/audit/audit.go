@@ -0,0 +1,194 @@
+// Package audit records how every generated document was produced: the
+// schema and template revisions used, the defaults filled in, the validation
+// outcome and hashes of the input and output. Set a Sink in
+// pipeline.Pipeline and every call of Process writes a Record to it:
+//
+//	log, err := audit.OpenFile("audit.jsonl")
+//	...
+//	p := pipeline.Pipeline{Schema: schema, Template: src, Audit: log}
+//	out, _, err := p.Process(audit.WithDocument(ctx, "invoices/42.json"), data)
+//
+// Hashes let an auditor match a record to the exact bytes that went in and
+// came out without the log holding the documents themselves.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"go-demo/pkg/errcode"
+	schemautil "go-demo/pkg/jsonschema"
+)
+
+// Outcomes of a Record.
+const (
+	OutcomeGenerated = "generated" // the document was valid and its output produced
+	OutcomeInvalid   = "invalid"   // the document failed its schema
+	OutcomeFailed    = "failed"    // the document couldn't be processed, e.g. malformed or unrenderable
+)
+
+// Record describes one processed document.
+type Record struct {
+	Time time.Time `json:"time"`
+
+	// Document identifies the document, such as its file name, as set with
+	// WithDocument. It is empty if the caller didn't set one.
+	Document string `json:"document,omitempty"`
+
+	// Type is the document type of the pipeline, if it has one.
+	Type string `json:"type,omitempty"`
+
+	// Input is the hash of the document as received (see Hash).
+	Input string `json:"input"`
+
+	Schema   *Schema   `json:"schema,omitempty"`
+	Template *Template `json:"template,omitempty"`
+
+	// Defaults lists the defaults filled in, sorted by pointer.
+	Defaults []schemautil.AppliedDefault `json:"defaults"`
+
+	// Outcome is OutcomeGenerated, OutcomeInvalid or OutcomeFailed.
+	Outcome string `json:"outcome"`
+
+	// Violations lists the ways an invalid document fails its schema.
+	Violations []schemautil.Violation `json:"violations"`
+
+	// Error and Code describe why a document failed.
+	Error string       `json:"error,omitempty"`
+	Code  errcode.Code `json:"code,omitempty"`
+
+	// Output is the hash of the generated output; it is empty unless the
+	// outcome is OutcomeGenerated.
+	Output string `json:"output,omitempty"`
+}
+
+// Schema identifies the schema a document was checked against.
+type Schema struct {
+	// Location is the schema's URL.
+	Location string `json:"location"`
+	// Version identifies the schema's revision, such as the hash of its
+	// bundle including every referenced schema; it is empty if unknown.
+	Version string `json:"version,omitempty"`
+}
+
+// Template identifies the template a document was rendered with.
+type Template struct {
+	Name string `json:"name,omitempty"`
+	// Version is the hash of the template's source. Templates it includes
+	// or extends aren't covered.
+	Version string `json:"version"`
+}
+
+// Hash returns the SHA-256 of b as "sha256:<hex>".
+func Hash(b []byte) string {
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+type documentKey struct{}
+
+// WithDocument returns a copy of ctx that names the document processed with
+// it in audit records.
+func WithDocument(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, documentKey{}, id)
+}
+
+// DocumentFrom returns the document name set with WithDocument, or "".
+func DocumentFrom(ctx context.Context) string {
+	id, _ := ctx.Value(documentKey{}).(string)
+	return id
+}
+
+// Sink stores audit records. Implementations must be safe for concurrent
+// use. A document whose record can't be stored counts as failed, so that
+// no output exists without its record.
+type Sink interface {
+	Write(ctx context.Context, r *Record) error
+}
+
+// SinkFunc adapts a function to a Sink.
+type SinkFunc func(ctx context.Context, r *Record) error
+
+// Write implements Sink.
+func (f SinkFunc) Write(ctx context.Context, r *Record) error {
+	return f(ctx, r)
+}
+
+// JSONL is a Sink that writes each record to w as one line of JSON.
+type JSONL struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONL returns a Sink writing JSON lines to w.
+func NewJSONL(w io.Writer) *JSONL {
+	return &JSONL{w: w}
+}
+
+// Write implements Sink. Each record is written with a single call of the
+// writer, so records of processes appending to the same file don't mix.
+func (j *JSONL) Write(ctx context.Context, r *Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_, err = j.w.Write(append(b, '\n'))
+	return err
+}
+
+// File is a JSONL sink appending to a file.
+type File struct {
+	*JSONL
+	f *os.File
+}
+
+// OpenFile opens the file at path for appending audit records, creating it
+// if needed.
+func OpenFile(path string) (*File, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &File{JSONL: NewJSONL(f), f: f}, nil
+}
+
+// Close flushes the file to disk and closes it.
+func (f *File) Close() error {
+	err := f.f.Sync()
+	if cerr := f.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// ReadJSONL reads the records written by a JSONL sink. Numbers in default
+// values are decoded as json.Number, keeping them exact.
+func ReadJSONL(r io.Reader) ([]Record, error) {
+	var records []Record
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 64<<20)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var rec Record
+		dec := json.NewDecoder(bytes.NewReader(sc.Bytes()))
+		dec.UseNumber()
+		if err := dec.Decode(&rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+	return records, sc.Err()
+}
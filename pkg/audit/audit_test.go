@@ -0,0 +1,102 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	schemautil "go-demo/pkg/jsonschema"
+)
+
+func TestHash(t *testing.T) {
+	if got, want := Hash([]byte("abc")), "sha256:ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestWithDocument(t *testing.T) {
+	if id := DocumentFrom(context.Background()); id != "" {
+		t.Errorf("expected no document, got %q", id)
+	}
+	if id := DocumentFrom(WithDocument(context.Background(), "a.json")); id != "a.json" {
+		t.Errorf("expected a.json, got %q", id)
+	}
+}
+
+func TestJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewJSONL(&buf)
+	record := &Record{
+		Time:     time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Document: "a.json",
+		Input:    Hash([]byte("{}")),
+		Schema:   &Schema{Location: "file:///s.json"},
+		Defaults: []schemautil.AppliedDefault{{Pointer: "/id", Value: json.Number("9007199254740993"), Location: "file:///s.json#/properties/id"}},
+		Outcome:  OutcomeGenerated,
+		Output:   Hash([]byte("out")),
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := sink.Write(context.Background(), record); err != nil {
+				t.Errorf("Write failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if !strings.Contains(buf.String(), `"defaults":[{"pointer":"/id","value":9007199254740993,"location":"file:///s.json#/properties/id"}]`) {
+		t.Errorf("expected exact default values, got %s", buf.String())
+	}
+
+	records, err := ReadJSONL(&buf)
+	if err != nil {
+		t.Fatalf("ReadJSONL failed: %v", err)
+	}
+	if len(records) != 20 {
+		t.Fatalf("expected 20 records, got %d", len(records))
+	}
+	if r := records[0]; r.Document != "a.json" || !r.Time.Equal(record.Time) || r.Schema.Location != "file:///s.json" || r.Template != nil {
+		t.Errorf("expected the record back, got %+v", r)
+	}
+
+	if _, err := ReadJSONL(strings.NewReader("{}\nnot json\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected an error for line 2, got %v", err)
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	for _, doc := range []string{"a.json", "b.json"} {
+		f, err := OpenFile(path)
+		if err != nil {
+			t.Fatalf("OpenFile failed: %v", err)
+		}
+		if err := f.Write(context.Background(), &Record{Document: doc, Outcome: OutcomeInvalid}); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := ReadJSONL(bytes.NewReader(b))
+	if err != nil || len(records) != 2 || records[0].Document != "a.json" || records[1].Document != "b.json" {
+		t.Errorf("expected the records of both runs, got %+v (%v)", records, err)
+	}
+
+	if _, err := OpenFile(filepath.Join(t.TempDir(), "missing", "audit.jsonl")); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,187 @@
+// Package auth authenticates callers of the servers in pkg/httpapi and
+// pkg/grpcapi and restricts them to the operations their key allows.
+//
+// Callers send a token as "Authorization: Bearer TOKEN" or "X-API-Key: TOKEN"
+// (gRPC metadata "authorization" or "x-api-key"). An Authenticator maps the
+// token to a Principal: StaticKeys checks a fixed set of API keys, and
+// AuthenticatorFunc plugs in any other validation, such as a call to an
+// identity service.
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"go-demo/pkg/jsonutil"
+)
+
+// Operations that can be allowed per key.
+const (
+	OpValidate      = "validate"
+	OpApplyDefaults = "apply-defaults"
+	OpRender        = "render"
+)
+
+var (
+	// ErrUnauthenticated is returned for a missing or unknown token.
+	ErrUnauthenticated = errors.New("missing or invalid API key")
+
+	// ErrForbidden is returned when a principal may not use an operation.
+	ErrForbidden = errors.New("operation not allowed for this API key")
+)
+
+// Principal is an authenticated caller.
+type Principal struct {
+	// Name identifies the caller, e.g. in logs and rate limits.
+	Name string `json:"name"`
+
+	// Operations lists what the caller may do; empty allows everything.
+	Operations []string `json:"operations,omitempty"`
+
+	// Tenant binds the caller to a tenant's namespace (see pkg/tenant);
+	// empty lets it choose one.
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// Allows reports whether p may use operation op.
+func (p *Principal) Allows(op string) bool {
+	if len(p.Operations) == 0 {
+		return true
+	}
+	for _, allowed := range p.Operations {
+		if allowed == op {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator maps a token to a principal. It returns ErrUnauthenticated
+// (possibly wrapped) for tokens it rejects; other errors are server errors.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*Principal, error)
+}
+
+// AuthenticatorFunc adapts a function to the Authenticator interface.
+type AuthenticatorFunc func(ctx context.Context, token string) (*Principal, error)
+
+// Authenticate calls f.
+func (f AuthenticatorFunc) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	return f(ctx, token)
+}
+
+// Authorize authenticates token with a and checks that the principal may use
+// operation op.
+func Authorize(ctx context.Context, a Authenticator, token, op string) (*Principal, error) {
+	if token == "" {
+		return nil, ErrUnauthenticated
+	}
+	p, err := a.Authenticate(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrUnauthenticated
+	}
+	if !p.Allows(op) {
+		return nil, fmt.Errorf("%w: %s may not use %s", ErrForbidden, p.Name, op)
+	}
+	return p, nil
+}
+
+// Token extracts the token from an Authorization header value ("Bearer
+// TOKEN") or an X-API-Key value, preferring the former.
+func Token(authorization, apiKey string) string {
+	if scheme, token, ok := strings.Cut(authorization, " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return strings.TrimSpace(apiKey)
+}
+
+// Key is an API key in a key file.
+type Key struct {
+	Principal
+	Key string `json:"key"`
+}
+
+// StaticKeys authenticates a fixed set of API keys.
+type StaticKeys []Key
+
+// Authenticate implements Authenticator. Every key is compared in constant
+// time, so the time taken doesn't reveal how much of a key matched.
+func (keys StaticKeys) Authenticate(_ context.Context, token string) (*Principal, error) {
+	var found *Principal
+	for i := range keys {
+		if subtle.ConstantTimeCompare([]byte(keys[i].Key), []byte(token)) == 1 {
+			found = &keys[i].Principal
+		}
+	}
+	if found == nil {
+		return nil, ErrUnauthenticated
+	}
+	return found, nil
+}
+
+// LoadKeys reads API keys from a JSON, YAML or TOML file (by extension):
+//
+//	keys:
+//	  - name: billing
+//	    key: 9f8b...
+//	    operations: [render]
+//	  - name: acme
+//	    key: 0d7e...
+//	    tenant: acme
+//	  - name: admin
+//	    key: 41c2...
+func LoadKeys(path string) (StaticKeys, error) {
+	format, err := jsonutil.FormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := jsonutil.Unmarshal(format, b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	// Round-trip through JSON to decode into the typed structure.
+	js, err := jsonutil.Marshal(jsonutil.FormatJSON, doc, 0)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Keys StaticKeys `json:"keys"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	names := map[string]bool{}
+	for i, k := range file.Keys {
+		switch {
+		case k.Name == "":
+			return nil, fmt.Errorf("%s: key %d has no name", path, i+1)
+		case k.Key == "":
+			return nil, fmt.Errorf("%s: key %s is empty", path, k.Name)
+		case names[k.Name]:
+			return nil, fmt.Errorf("%s: duplicate key name %s", path, k.Name)
+		}
+		names[k.Name] = true
+		for _, op := range k.Operations {
+			if op != OpValidate && op != OpApplyDefaults && op != OpRender {
+				return nil, fmt.Errorf("%s: key %s: unknown operation %q", path, k.Name, op)
+			}
+		}
+	}
+	return file.Keys, nil
+}
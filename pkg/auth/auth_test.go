@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuthorize(t *testing.T) {
+	keys := StaticKeys{
+		{Principal: Principal{Name: "billing", Operations: []string{OpRender}}, Key: "billing-key"},
+		{Principal: Principal{Name: "admin"}, Key: "admin-key"},
+	}
+	hook := AuthenticatorFunc(func(_ context.Context, token string) (*Principal, error) {
+		if token == "broken" {
+			return nil, errors.New("identity service unavailable")
+		}
+		return keys.Authenticate(context.Background(), token)
+	})
+
+	tests := []struct {
+		name  string
+		token string
+		op    string
+		want  string
+		err   error
+	}{
+		{"allowed operation", "billing-key", OpRender, "billing", nil},
+		{"other operation", "billing-key", OpValidate, "", ErrForbidden},
+		{"all operations", "admin-key", OpApplyDefaults, "admin", nil},
+		{"unknown key", "guess", OpRender, "", ErrUnauthenticated},
+		{"prefix of a key", "admin", OpRender, "", ErrUnauthenticated},
+		{"no key", "", OpRender, "", ErrUnauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Authorize(context.Background(), hook, tt.token, tt.op)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if err == nil && p.Name != tt.want {
+				t.Errorf("expected principal %q, got %q", tt.want, p.Name)
+			}
+		})
+	}
+
+	_, err := Authorize(context.Background(), hook, "broken", OpRender)
+	if err == nil || errors.Is(err, ErrUnauthenticated) || errors.Is(err, ErrForbidden) {
+		t.Errorf("expected the hook's error to pass through, got %v", err)
+	}
+}
+
+func TestToken(t *testing.T) {
+	tests := []struct {
+		authorization, apiKey, want string
+	}{
+		{"Bearer abc", "", "abc"},
+		{"bearer abc", "xyz", "abc"},
+		{"Basic dXNlcg==", "xyz", "xyz"},
+		{"", " xyz ", "xyz"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		if got := Token(tt.authorization, tt.apiKey); got != tt.want {
+			t.Errorf("Token(%q, %q) = %q, want %q", tt.authorization, tt.apiKey, got, tt.want)
+		}
+	}
+}
+
+func TestLoadKeys(t *testing.T) {
+	tests := []struct {
+		name, file, content string
+		wantErr             bool
+	}{
+		{"yaml", "keys.yaml", "keys:\n  - name: billing\n    key: k1\n    operations: [render]\n    tenant: acme\n  - name: admin\n    key: k2\n", false},
+		{"json", "keys.json", `{"keys": [{"name": "billing", "key": "k1", "operations": ["render"], "tenant": "acme"}, {"name": "admin", "key": "k2"}]}`, false},
+		{"toml", "keys.toml", "[[keys]]\nname = \"billing\"\nkey = \"k1\"\noperations = [\"render\"]\ntenant = \"acme\"\n\n[[keys]]\nname = \"admin\"\nkey = \"k2\"\n", false},
+		{"unknown operation", "keys.yaml", "keys:\n  - name: a\n    key: k\n    operations: [delete]\n", true},
+		{"empty key", "keys.yaml", "keys:\n  - name: a\n    key: \"\"\n", true},
+		{"duplicate name", "keys.yaml", "keys:\n  - name: a\n    key: k1\n  - name: a\n    key: k2\n", true},
+		{"unknown field", "keys.yaml", "keys:\n  - name: a\n    key: k\n    ops: [render]\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("Failed to write %s: %v", path, err)
+			}
+			keys, err := LoadKeys(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", keys)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadKeys failed: %v", err)
+			}
+			p, err := keys.Authenticate(context.Background(), "k1")
+			if err != nil || p.Name != "billing" || p.Tenant != "acme" || !p.Allows(OpRender) || p.Allows(OpValidate) {
+				t.Errorf("unexpected principal for k1: %+v, %v", p, err)
+			}
+			if p, err := keys.Authenticate(context.Background(), "k2"); err != nil || !p.Allows(OpValidate) {
+				t.Errorf("expected k2 to allow everything: %+v, %v", p, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+// Package batch runs long jobs over many documents, such as regenerating
+// every document of a type. An Engine reads the items of a Source (a
+// directory, an NDJSON file or a message queue), processes them with a pool
+// of workers and records the outcome of each in a Checkpoint. A job that
+// crashes or is interrupted is resumed by running it again with the same
+// checkpoint: the items recorded there are skipped. Several processes, or
+// machines, share a job by each running one shard of it.
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log/slog"
+	"runtime"
+	"sync"
+)
+
+// Item is an item of a Source.
+type Item struct {
+	// ID identifies the item across runs of a job, e.g. the path of a
+	// file relative to the input directory.
+	ID   string
+	Data []byte
+
+	// ack, if set, tells the source the item is recorded.
+	ack func(ctx context.Context) error
+}
+
+// Source is the input of a job. Its IDs must be the same in every run.
+type Source interface {
+	// Next returns the next item, or io.EOF after the last one. It is only
+	// called by one goroutine at a time.
+	Next(ctx context.Context) (Item, error)
+}
+
+// Summary counts the items of a job, earlier runs included.
+type Summary struct {
+	Items     int `json:"items"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+
+	// Resumed are the items recorded by earlier runs and not processed
+	// again, whose outcomes are in Succeeded and Failed.
+	Resumed int `json:"resumed"`
+}
+
+// Engine processes the items of a source.
+type Engine struct {
+	// Process processes an item. An error fails the item, unless the
+	// context was canceled, which leaves it to the next run.
+	Process func(ctx context.Context, item Item) error
+
+	// Workers is the number of items processed in parallel,
+	// runtime.NumCPU() if 0.
+	Workers int
+
+	// Checkpoint records the outcome of each item. Nil runs the job
+	// without a way to resume it.
+	Checkpoint Checkpoint
+
+	// Shards splits the job into parts run separately; this engine only
+	// processes the items of part Shard (0 <= Shard < Shards), chosen by a
+	// hash of their IDs. 0 processes every item. Shards of a job may share
+	// a checkpoint.
+	Shard, Shards int
+
+	// RetryFailed processes the items that failed in earlier runs again
+	// instead of skipping them.
+	RetryFailed bool
+
+	// Logger, if set, logs failed items at warn level.
+	Logger *slog.Logger
+}
+
+// Run processes the items of src until its end, and returns the summary of
+// the job. It stops early if ctx is done, returning ctx.Err(), or if the
+// source or the checkpoint fail; the items being processed are finished
+// first, so that a run picks up where the last one stopped.
+func (e *Engine) Run(ctx context.Context, src Source) (Summary, error) {
+	if e.Shards < 0 || e.Shards > 0 && (e.Shard < 0 || e.Shard >= e.Shards) {
+		return Summary{}, fmt.Errorf("shard %d of %d doesn't exist", e.Shard, e.Shards)
+	}
+	recorded := map[string]Record{}
+	if e.Checkpoint != nil {
+		var err error
+		if recorded, err = e.Checkpoint.Load(ctx); err != nil {
+			return Summary{}, fmt.Errorf("load checkpoint: %w", err)
+		}
+	}
+
+	// Items are processed with a context that isn't canceled with ctx: an
+	// item that is started is finished.
+	procCtx, stopProc := context.WithCancel(context.WithoutCancel(ctx))
+	defer stopProc()
+	var (
+		mu      sync.Mutex
+		summary Summary
+		failure error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failure == nil {
+			failure = err
+			stopProc()
+		}
+	}
+
+	items := make(chan Item)
+	var wg sync.WaitGroup
+	workers := e.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range items {
+				if err := e.process(procCtx, item, &mu, &summary); err != nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+
+read:
+	for {
+		item, err := src.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				fail(fmt.Errorf("read input: %w", err))
+			}
+			break
+		}
+		if !e.inShard(item.ID) {
+			continue
+		}
+		if r, ok := recorded[item.ID]; ok && (r.Status == StatusDone || !e.RetryFailed) {
+			mu.Lock()
+			summary.Items++
+			summary.Resumed++
+			if r.Status == StatusDone {
+				summary.Succeeded++
+			} else {
+				summary.Failed++
+			}
+			mu.Unlock()
+			// A queue delivers the items of a crashed run again.
+			if item.ack != nil {
+				if err := item.ack(ctx); err != nil {
+					fail(fmt.Errorf("%s: %w", item.ID, err))
+					break
+				}
+			}
+			continue
+		}
+		select {
+		case items <- item:
+		case <-ctx.Done():
+			break read
+		case <-procCtx.Done():
+			break read
+		}
+	}
+	close(items)
+	wg.Wait()
+
+	if failure != nil {
+		return summary, failure
+	}
+	return summary, ctx.Err()
+}
+
+// process processes an item and records its outcome. It only fails if the
+// outcome can't be recorded.
+func (e *Engine) process(ctx context.Context, item Item, mu *sync.Mutex, summary *Summary) error {
+	err := e.Process(ctx, item)
+	if err != nil && ctx.Err() != nil {
+		return nil // stopped by another failure; left to the next run
+	}
+	r := Record{ID: item.ID, Status: StatusDone}
+	if err != nil {
+		r.Status, r.Error = StatusFailed, err.Error()
+		if e.Logger != nil {
+			e.Logger.Warn("item failed", "id", item.ID, "error", err)
+		}
+	}
+	if e.Checkpoint != nil {
+		if err := e.Checkpoint.Save(ctx, r); err != nil {
+			return fmt.Errorf("save checkpoint: %w", err)
+		}
+	}
+	if item.ack != nil {
+		if err := item.ack(ctx); err != nil {
+			return fmt.Errorf("%s: %w", item.ID, err)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	summary.Items++
+	if err != nil {
+		summary.Failed++
+	} else {
+		summary.Succeeded++
+	}
+	return nil
+}
+
+// inShard reports whether the item id belongs to the engine's shard.
+func (e *Engine) inShard(id string) bool {
+	if e.Shards <= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return int(h.Sum32()%uint32(e.Shards)) == e.Shard
+}
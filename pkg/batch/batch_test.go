@@ -0,0 +1,205 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// sliceSource is a Source of items with the given IDs, whose data is the ID.
+type sliceSource struct {
+	ids []string
+}
+
+func (s *sliceSource) Next(ctx context.Context) (Item, error) {
+	if err := ctx.Err(); err != nil {
+		return Item{}, err
+	}
+	if len(s.ids) == 0 {
+		return Item{}, io.EOF
+	}
+	id := s.ids[0]
+	s.ids = s.ids[1:]
+	return Item{ID: id, Data: []byte(id)}, nil
+}
+
+func itemIDs(n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("doc-%03d", i)
+	}
+	return ids
+}
+
+// processed collects the IDs of the items an engine processed.
+type processed struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+func (p *processed) add(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ids = append(p.ids, id)
+}
+
+func (p *processed) sorted() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ids := append([]string(nil), p.ids...)
+	sort.Strings(ids)
+	return ids
+}
+
+func TestEngineResumes(t *testing.T) {
+	checkpoint, err := OpenFileCheckpoint(filepath.Join(t.TempDir(), "job.ckpt"))
+	if err != nil {
+		t.Fatalf("OpenFileCheckpoint failed: %v", err)
+	}
+	defer checkpoint.Close()
+
+	// The first run is interrupted after 10 items.
+	ctx, cancel := context.WithCancel(context.Background())
+	var first processed
+	e := &Engine{Workers: 4, Checkpoint: checkpoint, Process: func(_ context.Context, item Item) error {
+		first.add(item.ID)
+		if len(first.sorted()) == 10 {
+			cancel()
+		}
+		if strings.HasSuffix(item.ID, "7") {
+			return errors.New("invalid document")
+		}
+		return nil
+	}}
+	summary, err := e.Run(ctx, &sliceSource{ids: itemIDs(50)})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the run to be canceled, got %v", err)
+	}
+	if summary.Items != len(first.sorted()) || summary.Items < 10 || summary.Items >= 50 {
+		t.Fatalf("expected the started items to be finished, got %+v for %d items", summary, len(first.sorted()))
+	}
+
+	// The second run processes the rest.
+	var second processed
+	e.Process = func(_ context.Context, item Item) error {
+		second.add(item.ID)
+		if strings.HasSuffix(item.ID, "7") {
+			return errors.New("invalid document")
+		}
+		return nil
+	}
+	summary, err = e.Run(context.Background(), &sliceSource{ids: itemIDs(50)})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	want := Summary{Items: 50, Succeeded: 45, Failed: 5, Resumed: len(first.sorted())}
+	if summary != want {
+		t.Errorf("expected %+v, got %+v", want, summary)
+	}
+	all := append(first.sorted(), second.sorted()...)
+	sort.Strings(all)
+	if strings.Join(all, ",") != strings.Join(itemIDs(50), ",") {
+		t.Errorf("expected every item to be processed once, got %v", all)
+	}
+
+	// Failed items are only retried on request.
+	var third processed
+	e.Process = func(_ context.Context, item Item) error {
+		third.add(item.ID)
+		return nil
+	}
+	e.RetryFailed = true
+	summary, err = e.Run(context.Background(), &sliceSource{ids: itemIDs(50)})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if want := []string{"doc-007", "doc-017", "doc-027", "doc-037", "doc-047"}; strings.Join(third.sorted(), ",") != strings.Join(want, ",") {
+		t.Errorf("expected the failed items to be retried, got %v", third.sorted())
+	}
+	if want := (Summary{Items: 50, Succeeded: 50, Resumed: 45}); summary != want {
+		t.Errorf("expected %+v, got %+v", want, summary)
+	}
+}
+
+func TestEngineShards(t *testing.T) {
+	var all processed
+	seen := map[string]int{}
+	for shard := 0; shard < 3; shard++ {
+		var p processed
+		e := &Engine{Shard: shard, Shards: 3, Process: func(_ context.Context, item Item) error {
+			p.add(item.ID)
+			all.add(item.ID)
+			return nil
+		}}
+		summary, err := e.Run(context.Background(), &sliceSource{ids: itemIDs(100)})
+		if err != nil {
+			t.Fatalf("shard %d: Run failed: %v", shard, err)
+		}
+		if summary.Items == 0 || summary.Items == 100 {
+			t.Errorf("shard %d: expected a part of the items, got %d", shard, summary.Items)
+		}
+		for _, id := range p.sorted() {
+			seen[id]++
+		}
+	}
+	if len(seen) != 100 {
+		t.Errorf("expected the shards to cover every item, got %d", len(seen))
+	}
+	for id, n := range seen {
+		if n != 1 {
+			t.Errorf("%s was processed by %d shards", id, n)
+		}
+	}
+
+	for _, e := range []*Engine{{Shard: 3, Shards: 3}, {Shard: -1, Shards: 2}, {Shards: -1}} {
+		if _, err := e.Run(context.Background(), &sliceSource{}); err == nil {
+			t.Errorf("shard %d of %d: expected an error", e.Shard, e.Shards)
+		}
+	}
+}
+
+// failingCheckpoint fails to save records.
+type failingCheckpoint struct{}
+
+func (failingCheckpoint) Load(context.Context) (map[string]Record, error) {
+	return map[string]Record{}, nil
+}
+
+func (failingCheckpoint) Save(context.Context, Record) error {
+	return errors.New("disk full")
+}
+
+func TestEngineStopsOnCheckpointFailure(t *testing.T) {
+	var p processed
+	e := &Engine{Workers: 2, Checkpoint: failingCheckpoint{}, Process: func(_ context.Context, item Item) error {
+		p.add(item.ID)
+		return nil
+	}}
+	summary, err := e.Run(context.Background(), &sliceSource{ids: itemIDs(100)})
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("expected the checkpoint error, got %v", err)
+	}
+	if summary.Items != 0 || len(p.sorted()) > 10 {
+		t.Errorf("expected the run to stop, got %+v after %d items", summary, len(p.sorted()))
+	}
+}
+
+func TestEngineSourceFailure(t *testing.T) {
+	e := &Engine{Process: func(context.Context, Item) error { return nil }}
+	_, err := e.Run(context.Background(), &errorSource{})
+	if err == nil || !strings.Contains(err.Error(), "read input") {
+		t.Errorf("expected the source error, got %v", err)
+	}
+}
+
+type errorSource struct{}
+
+func (errorSource) Next(context.Context) (Item, error) {
+	return Item{}, errors.New("permission denied")
+}
@@ -0,0 +1,255 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	_ "modernc.org/sqlite" // the sqlite driver of OpenCheckpoint
+
+	"go-demo/internal/sqlutil"
+)
+
+// Status is the outcome of an item.
+type Status string
+
+const (
+	StatusDone   Status = "done"
+	StatusFailed Status = "failed"
+)
+
+// Record is the outcome of an item in a checkpoint.
+type Record struct {
+	ID     string `json:"id"`
+	Status Status `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Checkpoint records the progress of a job. Implementations must be safe for
+// concurrent use.
+type Checkpoint interface {
+	// Load returns the records saved so far by ID, the last one of each.
+	Load(ctx context.Context) (map[string]Record, error)
+
+	// Save saves a record. Once it returns, the record survives a crash
+	// of the process.
+	Save(ctx context.Context, r Record) error
+}
+
+// FileCheckpoint is a Checkpoint in a file of JSON lines, one per record,
+// only ever appended to.
+type FileCheckpoint struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// OpenFileCheckpoint opens the checkpoint file path, creating it if needed.
+func OpenFileCheckpoint(path string) (*FileCheckpoint, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileCheckpoint{f: f}, nil
+}
+
+// Load implements Checkpoint. A last line cut off by a crash is ignored.
+func (c *FileCheckpoint) Load(context.Context) (map[string]Record, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	records := map[string]Record{}
+	r := bufio.NewReader(c.f)
+	for n := 1; ; n++ {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// Without its newline, the line wasn't completely written.
+			if len(bytes.TrimSpace(line)) > 0 {
+				if err := c.truncateLast(len(line)); err != nil {
+					return nil, err
+				}
+			}
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var rec Record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", c.f.Name(), n, err)
+		}
+		records[rec.ID] = rec
+	}
+}
+
+// truncateLast removes the last n bytes, so that the next record starts on a
+// line of its own.
+func (c *FileCheckpoint) truncateLast(n int) error {
+	info, err := c.f.Stat()
+	if err != nil {
+		return err
+	}
+	return c.f.Truncate(info.Size() - int64(n))
+}
+
+// Save implements Checkpoint.
+func (c *FileCheckpoint) Save(_ context.Context, r Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// One write per record: a crash can cut off the last line, but never
+	// mixes two.
+	_, err = c.f.Write(append(b, '\n'))
+	return err
+}
+
+// Close syncs and closes the file.
+func (c *FileCheckpoint) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.f.Sync(); err != nil {
+		c.f.Close()
+		return err
+	}
+	return c.f.Close()
+}
+
+// SQLConfig configures an SQL checkpoint.
+type SQLConfig struct {
+	// Table is the name of the table, "batch_checkpoints" if empty. It is
+	// created if it doesn't exist.
+	Table string
+
+	// Job names the job, so that the checkpoints of several jobs share a
+	// table. Required.
+	Job string
+
+	// Placeholder is the parameter style of the driver: "?" (the default,
+	// e.g. SQLite and MySQL) or "$" for numbered parameters ($1, $2, ...,
+	// e.g. PostgreSQL).
+	Placeholder string
+}
+
+// SQLCheckpoint is a Checkpoint in a database table with one row per item.
+// Shards of a job running on several machines can share it.
+type SQLCheckpoint struct {
+	db    *sql.DB
+	table sqlutil.Table
+	job   string
+}
+
+// NewSQLCheckpoint returns a checkpoint in a table of db, creating the table
+// if needed.
+func NewSQLCheckpoint(ctx context.Context, db *sql.DB, cfg SQLConfig) (*SQLCheckpoint, error) {
+	table, err := sqlutil.NewTable(cfg.Table, "batch_checkpoints", cfg.Placeholder)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Job == "" {
+		return nil, errors.New("checkpoint without a job name")
+	}
+	// Item IDs such as paths can be long, so the key holds their hash,
+	// which keeps it within the key size limits of MySQL and the others.
+	err = table.Create(ctx, db, `
+		job VARCHAR(255) NOT NULL,
+		item_key CHAR(64) NOT NULL,
+		item_id TEXT NOT NULL,
+		status VARCHAR(16) NOT NULL,
+		error TEXT NOT NULL,
+		PRIMARY KEY (job, item_key)
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return &SQLCheckpoint{db: db, table: table, job: cfg.Job}, nil
+}
+
+// itemKey returns the key of the row of an item: the hex SHA-256 of its ID.
+func itemKey(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
+
+// Load implements Checkpoint.
+func (c *SQLCheckpoint) Load(ctx context.Context) (map[string]Record, error) {
+	rows, err := c.db.QueryContext(ctx, c.table.Query(`SELECT item_id, status, error FROM `+c.table.Name+` WHERE job = ?`), c.job)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	records := map[string]Record{}
+	for rows.Next() {
+		var r Record
+		if err := rows.Scan(&r.ID, &r.Status, &r.Error); err != nil {
+			return nil, err
+		}
+		records[r.ID] = r
+	}
+	return records, rows.Err()
+}
+
+// Save implements Checkpoint.
+func (c *SQLCheckpoint) Save(ctx context.Context, r Record) error {
+	// Drivers disagree on upserts; replace the row in a transaction instead.
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	key := itemKey(r.ID)
+	if _, err := tx.ExecContext(ctx, c.table.Query(`DELETE FROM `+c.table.Name+` WHERE job = ? AND item_key = ?`), c.job, key); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, c.table.Query(`INSERT INTO `+c.table.Name+` (job, item_key, item_id, status, error) VALUES (?, ?, ?, ?, ?)`), c.job, key, r.ID, string(r.Status), r.Error); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CheckpointCloser is a checkpoint that holds a file or database to close.
+type CheckpointCloser interface {
+	Checkpoint
+	io.Closer
+}
+
+// OpenCheckpoint opens the checkpoint named by spec: "sqlite:PATH" for a
+// table in an SQLite database, shared by the jobs in it by their names, or
+// the path of a checkpoint file, which job is ignored for.
+func OpenCheckpoint(ctx context.Context, spec, job string) (CheckpointCloser, error) {
+	path, ok := strings.CutPrefix(spec, "sqlite:")
+	if !ok {
+		return OpenFileCheckpoint(spec)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := NewSQLCheckpoint(ctx, db, SQLConfig{Job: job})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return sqlCloser{c, db}, nil
+}
+
+// sqlCloser is an SQL checkpoint that owns its database.
+type sqlCloser struct {
+	*SQLCheckpoint
+	db *sql.DB
+}
+
+func (c sqlCloser) Close() error { return c.db.Close() }
@@ -0,0 +1,144 @@
+package batch
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testCheckpoint saves records to c and loads them back.
+func testCheckpoint(t *testing.T, c Checkpoint) {
+	t.Helper()
+	ctx := context.Background()
+	for _, r := range []Record{
+		{ID: "a.json", Status: StatusFailed, Error: "invalid"},
+		{ID: "b.json", Status: StatusDone},
+		{ID: "a.json", Status: StatusDone},
+	} {
+		if err := c.Save(ctx, r); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	records, err := c.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	want := map[string]Record{
+		"a.json": {ID: "a.json", Status: StatusDone},
+		"b.json": {ID: "b.json", Status: StatusDone},
+	}
+	if len(records) != len(want) || records["a.json"] != want["a.json"] || records["b.json"] != want["b.json"] {
+		t.Errorf("expected the last record of each item, got %v", records)
+	}
+}
+
+func TestFileCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "job.ckpt")
+	c, err := OpenFileCheckpoint(path)
+	if err != nil {
+		t.Fatalf("OpenFileCheckpoint failed: %v", err)
+	}
+	testCheckpoint(t, c)
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// A crash cut off the last record.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"id": "c.json", "sta`)
+	f.Close()
+
+	c, err = OpenFileCheckpoint(path)
+	if err != nil {
+		t.Fatalf("OpenFileCheckpoint failed: %v", err)
+	}
+	defer c.Close()
+	records, err := c.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load should ignore a cut off record: %v", err)
+	}
+	if _, ok := records["c.json"]; ok || len(records) != 2 {
+		t.Errorf("expected the complete records, got %v", records)
+	}
+	if err := c.Save(context.Background(), Record{ID: "c.json", Status: StatusDone}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if records, err := c.Load(context.Background()); err != nil || records["c.json"].Status != StatusDone {
+		t.Errorf("expected the record after the cut off one, got %v (%v)", records, err)
+	}
+
+	if err := os.WriteFile(path, []byte("not json\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Load(context.Background()); err == nil {
+		t.Error("expected an error for a corrupt checkpoint")
+	}
+}
+
+func TestSQLCheckpoint(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "jobs.db"))
+	if err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	c, err := NewSQLCheckpoint(ctx, db, SQLConfig{Job: "regenerate"})
+	if err != nil {
+		t.Fatalf("NewSQLCheckpoint failed: %v", err)
+	}
+	testCheckpoint(t, c)
+
+	// Item IDs longer than a key column can hold are keyed by their hash.
+	long := strings.Repeat("directory/", 200) + "c.json"
+	for _, status := range []Status{StatusFailed, StatusDone} {
+		if err := c.Save(ctx, Record{ID: long, Status: status}); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	if records, err := c.Load(ctx); err != nil || len(records) != 3 || records[long].Status != StatusDone {
+		t.Errorf("expected the last record of a long ID, got %v (%v)", records[long], err)
+	}
+
+	// Jobs sharing the table don't see each other's records.
+	other, err := NewSQLCheckpoint(ctx, db, SQLConfig{Job: "export"})
+	if err != nil {
+		t.Fatalf("NewSQLCheckpoint failed: %v", err)
+	}
+	if records, err := other.Load(ctx); err != nil || len(records) != 0 {
+		t.Errorf("expected no records for another job, got %v (%v)", records, err)
+	}
+
+	for name, cfg := range map[string]SQLConfig{
+		"no job":        {},
+		"invalid table": {Job: "j", Table: "x; DROP TABLE batch_checkpoints"},
+		"placeholder":   {Job: "j", Placeholder: ":"},
+	} {
+		if _, err := NewSQLCheckpoint(ctx, db, cfg); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestOpenCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	for _, spec := range []string{filepath.Join(dir, "job.ckpt"), "sqlite:" + filepath.Join(dir, "jobs.db")} {
+		c, err := OpenCheckpoint(context.Background(), spec, "regenerate")
+		if err != nil {
+			t.Fatalf("%s: OpenCheckpoint failed: %v", spec, err)
+		}
+		testCheckpoint(t, c)
+		if err := c.Close(); err != nil {
+			t.Errorf("%s: Close failed: %v", spec, err)
+		}
+	}
+	if _, err := OpenCheckpoint(context.Background(), filepath.Join(dir, "missing", "job.ckpt"), ""); err == nil {
+		t.Error("expected an error for a checkpoint in a missing directory")
+	}
+}
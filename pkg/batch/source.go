@@ -0,0 +1,149 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go-demo/pkg/stream"
+)
+
+// DirSource is a Source of the files below a directory, in lexical order.
+// Item IDs are the slash-separated paths of the files relative to the
+// directory.
+type DirSource struct {
+	dir   string
+	files []string
+}
+
+// Dir lists the files below dir, skipping hidden files and directories. The
+// files are read as the items are.
+func Dir(dir string) (*DirSource, error) {
+	s := &DirSource{dir: dir}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			s.files = append(s.files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(s.files)
+	return s, nil
+}
+
+// Next implements Source.
+func (s *DirSource) Next(context.Context) (Item, error) {
+	if len(s.files) == 0 {
+		return Item{}, io.EOF
+	}
+	id := s.files[0]
+	s.files = s.files[1:]
+	data, err := os.ReadFile(filepath.Join(s.dir, filepath.FromSlash(id)))
+	if err != nil {
+		return Item{}, err
+	}
+	return Item{ID: id, Data: data}, nil
+}
+
+// NDJSONSource is a Source of the lines of a file of JSON values, one per
+// line, such as an export of a database. Item IDs are line numbers, so the
+// file must not change between the runs of a job. Blank lines are skipped.
+type NDJSONSource struct {
+	f    *os.File
+	r    *bufio.Reader
+	line int
+}
+
+// OpenNDJSON opens an NDJSON file.
+func OpenNDJSON(path string) (*NDJSONSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &NDJSONSource{f: f, r: bufio.NewReaderSize(f, 64<<10)}, nil
+}
+
+// Next implements Source.
+func (s *NDJSONSource) Next(context.Context) (Item, error) {
+	for {
+		line, err := s.r.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			return Item{}, err
+		}
+		s.line++
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			return Item{ID: strconv.Itoa(s.line), Data: line}, nil
+		}
+		if err != nil {
+			return Item{}, err
+		}
+	}
+}
+
+// Close closes the file.
+func (s *NDJSONSource) Close() error {
+	return s.f.Close()
+}
+
+// QueueSource is a Source of the messages of a queue. A message is committed
+// once its outcome is recorded, so the messages of a crashed run are
+// delivered again; those the checkpoint has are skipped. Item IDs are the
+// topic and a hash of the key and value of the message, so identical
+// messages of a topic are processed once.
+//
+// Queues have no end: a job reading one runs until its context is done, or
+// until the consumer returns io.EOF.
+type QueueSource struct {
+	c stream.Consumer
+}
+
+// Queue returns a source of the messages of c, whose Commit must be safe to
+// call while Fetch is, as those of package stream are.
+func Queue(c stream.Consumer) *QueueSource {
+	return &QueueSource{c: c}
+}
+
+// Next implements Source.
+func (s *QueueSource) Next(ctx context.Context) (Item, error) {
+	m, err := s.c.Fetch(ctx)
+	if err != nil {
+		if ctx.Err() != nil && !errors.Is(err, io.EOF) {
+			return Item{}, ctx.Err()
+		}
+		return Item{}, err
+	}
+	h := sha256.New()
+	h.Write([]byte(strconv.Itoa(len(m.Key))))
+	h.Write([]byte{0})
+	h.Write(m.Key)
+	h.Write(m.Value)
+	id := m.Topic + "/" + hex.EncodeToString(h.Sum(nil)[:16])
+	return Item{ID: id, Data: m.Value, ack: func(ctx context.Context) error {
+		return s.c.Commit(ctx, m)
+	}}, nil
+}
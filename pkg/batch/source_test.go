@@ -0,0 +1,153 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-demo/pkg/stream"
+)
+
+// readAll returns the IDs and data of the items of src.
+func readAll(t *testing.T, src Source) ([]string, []string) {
+	t.Helper()
+	var ids, data []string
+	for {
+		item, err := src.Next(context.Background())
+		if errors.Is(err, io.EOF) {
+			return ids, data
+		}
+		if err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		ids = append(ids, item.ID)
+		data = append(data, string(item.Data))
+	}
+}
+
+func TestDir(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"b.json":         "2",
+		"a/c.yaml":       "3",
+		"a.json":         "1",
+		".hidden.json":   "x",
+		".git/HEAD":      "x",
+		"a/.skip/d.json": "x",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	src, err := Dir(dir)
+	if err != nil {
+		t.Fatalf("Dir failed: %v", err)
+	}
+	ids, data := readAll(t, src)
+	if got := strings.Join(ids, ","); got != "a.json,a/c.yaml,b.json" {
+		t.Errorf("expected the visible files in order, got %s", got)
+	}
+	if got := strings.Join(data, ","); got != "1,3,2" {
+		t.Errorf("expected the file contents, got %s", got)
+	}
+
+	if _, err := Dir(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.ndjson")
+	if err := os.WriteFile(path, []byte("{\"n\": 1}\n\n  {\"n\": 2}  \r\n{\"n\": 3}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src, err := OpenNDJSON(path)
+	if err != nil {
+		t.Fatalf("OpenNDJSON failed: %v", err)
+	}
+	defer src.Close()
+	ids, data := readAll(t, src)
+	if got := strings.Join(ids, ","); got != "1,3,4" {
+		t.Errorf("expected line numbers, got %s", got)
+	}
+	if got := strings.Join(data, "|"); got != `{"n": 1}|{"n": 2}|{"n": 3}` {
+		t.Errorf("expected the trimmed lines, got %s", got)
+	}
+}
+
+// countingConsumer counts the messages committed.
+type countingConsumer struct {
+	stream.Consumer
+	mu        sync.Mutex
+	committed int
+}
+
+func (c *countingConsumer) Commit(ctx context.Context, m stream.Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.committed++
+	return c.Consumer.Commit(ctx, m)
+}
+
+func TestQueue(t *testing.T) {
+	broker := stream.NewMemory(10, "orders")
+	ctx := context.Background()
+	for _, v := range []string{`{"n": 1}`, `{"n": 2}`, `{"n": 1}`} {
+		if err := broker.Publish(ctx, stream.Message{Topic: "orders", Value: []byte(v)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	broker.Close()
+
+	consumer := &countingConsumer{Consumer: broker}
+	var p processed
+	e := &Engine{Workers: 2, Process: func(_ context.Context, item Item) error {
+		p.add(string(item.Data))
+		return nil
+	}}
+	// The redelivered copy of a message is skipped like any recorded item.
+	checkpoint, err := OpenFileCheckpoint(filepath.Join(t.TempDir(), "queue.ckpt"))
+	if err != nil {
+		t.Fatalf("OpenFileCheckpoint failed: %v", err)
+	}
+	defer checkpoint.Close()
+	e.Checkpoint = checkpoint
+	first, _ := Queue(consumer).Next(ctx)
+	if !strings.HasPrefix(first.ID, "orders/") {
+		t.Errorf("expected the ID to start with the topic, got %s", first.ID)
+	}
+	if err := e.Checkpoint.Save(ctx, Record{ID: first.ID, Status: StatusDone}); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := e.Run(ctx, Queue(consumer))
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if want := (Summary{Items: 2, Succeeded: 2, Resumed: 1}); summary != want {
+		t.Errorf("expected %+v, got %+v", want, summary)
+	}
+	if got := p.sorted(); len(got) != 1 || got[0] != `{"n": 2}` {
+		t.Errorf("expected only the new message to be processed, got %v", got)
+	}
+	if consumer.committed != 2 {
+		t.Errorf("expected every message read to be committed, got %d", consumer.committed)
+	}
+
+	// A queue without messages runs until canceled.
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := e.Run(ctx, Queue(stream.NewMemory(1, "orders"))); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the run to end with its context, got %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+// Package bufpool reuses the byte buffers of the decode, encode and render
+// paths, which otherwise allocate and grow a buffer per document and put the
+// garbage collector under pressure under load.
+package bufpool
+
+import (
+	"bytes"
+	"sync"
+)
+
+// MaxSize is the capacity beyond which a buffer is dropped instead of
+// returned to the pool, so one huge document doesn't pin its memory.
+const MaxSize = 1 << 20
+
+var pool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+// Get returns an empty buffer from the pool.
+func Get() *bytes.Buffer {
+	return pool.Get().(*bytes.Buffer)
+}
+
+// Put returns b to the pool. b and the slices of its contents must not be
+// used afterwards.
+func Put(b *bytes.Buffer) {
+	if b == nil || b.Cap() > MaxSize {
+		return
+	}
+	b.Reset()
+	pool.Put(b)
+}
+
+// Bytes returns a copy of the contents of b that outlives Put.
+func Bytes(b *bytes.Buffer) []byte {
+	return append([]byte(nil), b.Bytes()...)
+}
@@ -0,0 +1,39 @@
+package bufpool
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPool(t *testing.T) {
+	b := Get()
+	if b.Len() != 0 {
+		t.Fatalf("Get should return an empty buffer, got %q", b.String())
+	}
+	b.WriteString("hello")
+	out := Bytes(b)
+	Put(b)
+	if string(out) != "hello" {
+		t.Errorf("Bytes() = %q, want hello", out)
+	}
+
+	b = Get()
+	b.WriteString("overwritten")
+	if string(out) != "hello" {
+		t.Errorf("Bytes should copy the contents, got %q", out)
+	}
+	if b.String() != "overwritten" {
+		t.Errorf("A reused buffer should be reset, got %q", b.String())
+	}
+	Put(b)
+
+	large := Get()
+	large.WriteString(strings.Repeat("x", MaxSize+1))
+	Put(large)
+	for i := 0; i < 10; i++ {
+		if b := Get(); b.Cap() > MaxSize {
+			t.Fatal("Buffers beyond MaxSize should not be pooled")
+		}
+	}
+	Put(nil)
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	_ "modernc.org/sqlite" // the sqlite driver of OpenBackend
+)
+
+// Backend is a store of serialized artifacts shared by the caches of several
+// processes, such as the instances of a horizontally scaled server or
+// successive CLI runs. Compiled schemas and templates are Go values that
+// can't leave their process, so a cache keeps those in memory and stores in
+// its backend what compiling them again elsewhere doesn't have to repeat:
+// for schemas, the document with every external $ref inlined (see
+// jsonschema.BundleSource), so other processes don't load the referenced
+// files or URLs again. Like the entries in memory, a bundle is keyed by its
+// source alone: a referenced document that changes is only seen again once
+// the bundle expires (Config.TTL). Templates need nothing besides their
+// source and files every process has, so they are only kept in memory.
+//
+// Implementations must be safe for concurrent use. Keys are short ASCII
+// strings, values JSON.
+type Backend interface {
+	// Get returns the value of key. ok is false if there is none, or it
+	// has expired.
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+
+	// Set stores value under key. ttl 0 keeps it until it is replaced.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// BackendCloser is a backend that holds a connection or file to close.
+type BackendCloser interface {
+	Backend
+	io.Closer
+}
+
+// backendTimeout bounds every call of a cache to its backend, so that an
+// unreachable backend delays compiling by that much at most.
+const backendTimeout = 2 * time.Second
+
+// OpenBackend opens the backend named by spec:
+//
+//	sqlite:PATH                   an SQLite database file, created if needed
+//	redis://[:PASSWORD@]HOST:PORT[/DB]
+func OpenBackend(ctx context.Context, spec string) (BackendCloser, error) {
+	switch {
+	case strings.HasPrefix(spec, "sqlite:"):
+		path := strings.TrimPrefix(spec, "sqlite:")
+		if path == "" {
+			return nil, fmt.Errorf("cache backend %q: missing database path", spec)
+		}
+		db, err := sql.Open("sqlite", path)
+		if err != nil {
+			return nil, fmt.Errorf("cache backend %q: %w", spec, err)
+		}
+		b, err := NewSQL(ctx, db, SQLConfig{})
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("cache backend %q: %w", spec, err)
+		}
+		return closer{b, db.Close}, nil
+	case strings.HasPrefix(spec, "redis://"), strings.HasPrefix(spec, "rediss://"):
+		opts, err := redis.ParseURL(spec)
+		if err != nil {
+			return nil, fmt.Errorf("cache backend %q: %w", redact(spec), err)
+		}
+		client := redis.NewClient(opts)
+		if err := client.Ping(ctx).Err(); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("cache backend %q: %w", redact(spec), err)
+		}
+		return closer{NewRedis(client, ""), client.Close}, nil
+	}
+	return nil, fmt.Errorf("unknown cache backend %q (want sqlite:PATH or redis://HOST:PORT)", spec)
+}
+
+// closer is a backend closed with close.
+type closer struct {
+	Backend
+	close func() error
+}
+
+func (c closer) Close() error { return c.close() }
+
+// redact hides the password of a backend URL.
+func redact(spec string) string {
+	u, err := url.Parse(spec)
+	if err != nil {
+		return spec
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenBackend(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.db")
+	b, err := OpenBackend(ctx, "sqlite:"+path)
+	if err != nil {
+		t.Fatalf("OpenBackend failed: %v", err)
+	}
+	if err := b.Set(ctx, "k", []byte(`{}`), 0); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// A second run finds the entries of the first.
+	b, err = OpenBackend(ctx, "sqlite:"+path)
+	if err != nil {
+		t.Fatalf("OpenBackend failed: %v", err)
+	}
+	defer b.Close()
+	if _, ok, err := b.Get(ctx, "k"); !ok || err != nil {
+		t.Errorf("expected the stored entry, got %v %v", ok, err)
+	}
+
+	for spec, want := range map[string]string{
+		"sqlite:":                         "missing database path",
+		"memcached://localhost":           "unknown cache backend",
+		"redis://:secret@localhost:1/0":   "xxxxx",
+		"redis://localhost:6379/notanint": "invalid database number",
+	} {
+		_, err := OpenBackend(ctx, spec)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected an error containing %q, got %v", spec, want, err)
+		}
+		if err != nil && strings.Contains(err.Error(), "secret") {
+			t.Errorf("%s: the error should not reveal the password: %v", spec, err)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+// Package cache keeps compiled schemas and templates so that servers,
+// pipelines and the development server compile each distinct source once.
+// Entries are keyed by a hash of their source and the options they were
+// compiled with, so a changed source is simply a new entry; old entries
+// age out by TTL or are evicted, least recently used first, when the cache
+// is full.
+//
+// With Config.Backend set, the cache also shares what it can with the caches
+// of other processes (see Backend).
+package cache
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"sync"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	schemautil "go-demo/pkg/jsonschema"
+	tpl "go-demo/pkg/pongo2"
+)
+
+// DefaultMaxEntries is the size of a cache if Config.MaxEntries is 0.
+const DefaultMaxEntries = 1000
+
+// Config configures a Cache.
+type Config struct {
+	// MaxEntries is the number of compiled artifacts kept; the least
+	// recently used ones are evicted beyond it.
+	MaxEntries int
+
+	// TTL is how long an entry is kept after it was compiled. 0 keeps
+	// entries until they are evicted. It applies to the entries stored in
+	// Backend too.
+	TTL time.Duration
+
+	// Backend, if set, is shared with the caches of other processes.
+	// Failing calls to it are counted in Stats.BackendErrors and otherwise
+	// ignored: the cache compiles as it would without it.
+	Backend Backend
+
+	// Sandbox confines the $refs of inline schemas to the schemas in
+	// SchemaFS and the common schemas (see jsonschema.CompileStringFS), for
+	// caches serving untrusted clients. Without it they can reach any file
+	// or URL the jsonschema loaders handle.
+	Sandbox  bool
+	SchemaFS fs.FS
+}
+
+// Stats counts the lookups of a cache.
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"` // entries dropped for space or age
+	Entries   int    `json:"entries"`
+
+	// BackendHits counts the misses compiled from what Config.Backend had
+	// stored, BackendErrors the failed calls to it.
+	BackendHits   uint64 `json:"backend_hits,omitempty"`
+	BackendErrors uint64 `json:"backend_errors,omitempty"`
+}
+
+// Cache holds compiled schemas and templates. It is safe for concurrent use.
+// A nil *Cache is valid and compiles on every call.
+type Cache struct {
+	cfg Config
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[key]*list.Element
+	lru     *list.List // of *entry, most recently used first
+	stats   Stats
+}
+
+// key is the hash of an artifact's kind, options and source.
+type key [sha256.Size]byte
+
+type entry struct {
+	key      key
+	value    interface{}
+	compiled time.Time
+}
+
+// New creates a cache.
+func New(cfg Config) *Cache {
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = DefaultMaxEntries
+	}
+	return &Cache{cfg: cfg, now: time.Now, entries: map[key]*list.Element{}, lru: list.New()}
+}
+
+// Schema returns the schema compiled from source, a JSON Schema document.
+// Compile errors aren't cached.
+func (c *Cache) Schema(source []byte) (*jsonschema.Schema, error) {
+	var opts string
+	if c != nil && c.cfg.Sandbox {
+		// Sandboxed schemas don't take the bundles of others, which may
+		// have inlined files they can't reach.
+		opts = "sandbox"
+	}
+	k := hash("schema", opts, string(source))
+	v, err := c.get(k, func() (interface{}, error) {
+		return c.compileSchema(k, source)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*jsonschema.Schema), nil
+}
+
+// Sandboxed reports whether the cache confines the $refs of inline schemas
+// (see Config.Sandbox). A nil cache doesn't.
+func (c *Cache) Sandboxed() bool {
+	return c != nil && c.cfg.Sandbox
+}
+
+// compileSchema compiles source, from its bundle in the backend if there is
+// one. Otherwise it stores the bundle there for other processes.
+func (c *Cache) compileSchema(k key, source []byte) (*jsonschema.Schema, error) {
+	if c == nil || c.cfg.Backend == nil {
+		return c.compileSource(source)
+	}
+	name := "schema/" + hex.EncodeToString(k[:])
+	if bundled, ok := c.load(name); ok {
+		// A bundle that doesn't compile, e.g. one stored by another
+		// version, is replaced below.
+		if s, err := c.compileSource(bundled); err == nil {
+			c.count(func(s *Stats) { s.BackendHits++ })
+			return s, nil
+		}
+	}
+	s, err := c.compileSource(source)
+	if err != nil {
+		return nil, err
+	}
+	// Schemas whose references can't be bundled, e.g. relative ones, are
+	// only kept in memory.
+	if bundled, err := schemautil.BundleSource(source); err == nil {
+		if b, err := json.Marshal(bundled); err == nil {
+			c.store(name, b)
+		}
+	}
+	return s, nil
+}
+
+// load gets key from the backend.
+func (c *Cache) load(key string) ([]byte, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	defer cancel()
+	value, ok, err := c.cfg.Backend.Get(ctx, key)
+	if err != nil {
+		c.count(func(s *Stats) { s.BackendErrors++ })
+		return nil, false
+	}
+	return value, ok
+}
+
+// store sets key in the backend.
+func (c *Cache) store(key string, value []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+	defer cancel()
+	if err := c.cfg.Backend.Set(ctx, key, value, c.cfg.TTL); err != nil {
+		c.count(func(s *Stats) { s.BackendErrors++ })
+	}
+}
+
+// count updates the stats.
+func (c *Cache) count(update func(*Stats)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	update(&c.stats)
+}
+
+// compileSource compiles an inline schema, sandboxed if c is.
+func (c *Cache) compileSource(source []byte) (*jsonschema.Schema, error) {
+	if c != nil && c.cfg.Sandbox {
+		return schemautil.CompileStringFS(string(source), c.cfg.SchemaFS)
+	}
+	return schemautil.CompileString(string(source))
+}
+
+// Template returns the template compiled from source with opts.
+func (c *Cache) Template(opts tpl.Options, source string) (*tpl.Template, error) {
+	v, err := c.get(hash("template", optionsKey(opts), source), func() (interface{}, error) {
+		return tpl.NewRenderer(opts).Compile(source)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*tpl.Template), nil
+}
+
+// InlineTemplate returns the template compiled from source with opts in
+// sandbox mode, for templates sent by clients: they can't read the
+// environment or files with ssi, and only include, extend and import the
+// templates in opts.TemplateDirs.
+func (c *Cache) InlineTemplate(opts tpl.Options, source string) (*tpl.Template, error) {
+	opts.Sandbox = true
+	return c.Template(opts, source)
+}
+
+// TemplateFile returns the template file name compiled with opts. A file
+// isn't read to look it up, so version must change whenever the file or
+// anything it includes does, e.g. be a digest of their modification times.
+func (c *Cache) TemplateFile(opts tpl.Options, name, version string) (*tpl.Template, error) {
+	v, err := c.get(hash("template-file", optionsKey(opts), name+"\x00"+version), func() (interface{}, error) {
+		return tpl.NewRenderer(opts).CompileFile(name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*tpl.Template), nil
+}
+
+// Stats returns the cache's counters.
+func (c *Cache) Stats() Stats {
+	if c == nil {
+		return Stats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.stats
+	s.Entries = c.lru.Len()
+	return s
+}
+
+// get returns the entry for k, compiling it on a miss. Concurrent misses for
+// the same key may compile twice; the last result is kept.
+func (c *Cache) get(k key, compile func() (interface{}, error)) (interface{}, error) {
+	if c == nil {
+		return compile()
+	}
+	if v, ok := c.lookup(k); ok {
+		return v, nil
+	}
+	v, err := compile()
+	if err != nil {
+		return nil, err
+	}
+	c.add(k, v)
+	return v, nil
+}
+
+func (c *Cache) lookup(k key) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[k]
+	if ok && c.expired(el.Value.(*entry)) {
+		c.remove(el)
+		ok = false
+	}
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	c.stats.Hits++
+	c.lru.MoveToFront(el)
+	return el.Value.(*entry).value, true
+}
+
+func (c *Cache) add(k key, v interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[k]; ok {
+		// Compiled concurrently by another miss.
+		*el.Value.(*entry) = entry{key: k, value: v, compiled: c.now()}
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[k] = c.lru.PushFront(&entry{key: k, value: v, compiled: c.now()})
+	for c.lru.Len() > c.cfg.MaxEntries {
+		c.remove(c.lru.Back())
+	}
+	// Expired entries are dropped as they are found, starting with the
+	// least recently used, so unused ones don't linger until eviction.
+	for el := c.lru.Back(); el != nil && c.expired(el.Value.(*entry)); el = c.lru.Back() {
+		c.remove(el)
+	}
+}
+
+func (c *Cache) expired(e *entry) bool {
+	return c.cfg.TTL > 0 && c.now().Sub(e.compiled) >= c.cfg.TTL
+}
+
+func (c *Cache) remove(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.entries, el.Value.(*entry).key)
+	c.stats.Evictions++
+}
+
+// hash derives the key of an artifact.
+func hash(kind, options, source string) key {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", kind, options)
+	h.Write([]byte(source))
+	var k key
+	h.Sum(k[:0])
+	return k
+}
+
+// optionsKey identifies renderer options. The schema, logger and metrics
+// recorder, if any, are identified by their addresses.
+func optionsKey(opts tpl.Options) string {
+	return fmt.Sprintf("%t %t %q %q %q %t %p %p %p", opts.TrimBlocks, opts.LStripBlocks, opts.TemplateDirs, opts.OutputMode, opts.EnvAllowlist, opts.Sandbox, opts.Schema, opts.Logger, opts.Metrics)
+}
@@ -0,0 +1,204 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flosch/pongo2/v6"
+
+	tpl "go-demo/pkg/pongo2"
+)
+
+func TestSchema(t *testing.T) {
+	c := New(Config{})
+	a, err := c.Schema([]byte(`{"type": "string"}`))
+	if err != nil {
+		t.Fatalf("Schema failed: %v", err)
+	}
+	b, err := c.Schema([]byte(`{"type": "string"}`))
+	if err != nil {
+		t.Fatalf("Schema failed: %v", err)
+	}
+	if a != b {
+		t.Error("the same source should return the cached schema")
+	}
+	if other, _ := c.Schema([]byte(`{"type": "integer"}`)); other == a {
+		t.Error("a different source should compile a new schema")
+	}
+	if _, err := c.Schema([]byte(`{"type": 1}`)); err == nil {
+		t.Error("an invalid schema should fail")
+	}
+	if _, err := c.Schema([]byte(`{"type": 1}`)); err == nil {
+		t.Error("an invalid schema should fail again")
+	}
+
+	want := Stats{Hits: 1, Misses: 4, Entries: 2}
+	if got := c.Stats(); got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	c := New(Config{})
+	plain, err := c.Template(tpl.Options{}, "<{{ x }}>")
+	if err != nil {
+		t.Fatalf("Template failed: %v", err)
+	}
+	again, _ := c.Template(tpl.Options{}, "<{{ x }}>")
+	escaped, _ := c.Template(tpl.Options{OutputMode: tpl.OutputJSON}, "<{{ x }}>")
+	if plain != again || plain == escaped {
+		t.Error("templates should be cached per source and options")
+	}
+	if output, err := escaped.Render(pongo2.Context{"x": `"`}); err != nil || output != `<\">` {
+		t.Errorf("unexpected output %q (%v)", output, err)
+	}
+	if _, err := c.Template(tpl.Options{}, "{% if %}"); err == nil {
+		t.Error("a syntax error should fail")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "page.txt")
+	if err := os.WriteFile(path, []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	v1, err := c.TemplateFile(tpl.Options{}, path, "1")
+	if err != nil {
+		t.Fatalf("TemplateFile failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if cached, _ := c.TemplateFile(tpl.Options{}, path, "1"); cached != v1 {
+		t.Error("the same version should return the cached template")
+	}
+	v2, err := c.TemplateFile(tpl.Options{}, path, "2")
+	if err != nil {
+		t.Fatalf("TemplateFile failed: %v", err)
+	}
+	if output, _ := v2.Render(nil); output != "v2" {
+		t.Errorf("a new version should compile the file again, got %q", output)
+	}
+
+	for _, source := range []string{`{% ssi "` + path + `" %}`, `{% include "` + path + `" %}`} {
+		if _, err := c.Template(tpl.Options{}, source); err != nil {
+			t.Fatalf("Template failed: %v", err)
+		}
+		if inline, err := c.InlineTemplate(tpl.Options{}, source); err == nil {
+			if output, err := inline.Render(nil); err == nil {
+				t.Errorf("%s: inline templates should not read files, got %q", source, output)
+			}
+		}
+	}
+}
+
+func TestEviction(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := New(Config{MaxEntries: 2, TTL: time.Minute})
+	c.now = func() time.Time { return now }
+
+	a, _ := c.Schema([]byte(`{"title": "a"}`))
+	c.Schema([]byte(`{"title": "b"}`))
+	c.Schema([]byte(`{"title": "a"}`)) // a is now the most recently used
+	c.Schema([]byte(`{"title": "c"}`)) // evicts b
+	if got, _ := c.Schema([]byte(`{"title": "a"}`)); got != a {
+		t.Error("the most recently used entry should survive")
+	}
+	if s := c.Stats(); s.Evictions != 1 || s.Entries != 2 || s.Hits != 2 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+
+	now = now.Add(time.Minute)
+	if got, _ := c.Schema([]byte(`{"title": "a"}`)); got == a {
+		t.Error("an expired entry should be compiled again")
+	}
+	if s := c.Stats(); s.Entries != 1 || s.Evictions != 3 {
+		t.Errorf("expired entries should be dropped, got %+v", s)
+	}
+}
+
+func TestNilCache(t *testing.T) {
+	var c *Cache
+	a, err := c.Schema([]byte(`{}`))
+	if err != nil {
+		t.Fatalf("Schema failed: %v", err)
+	}
+	if b, _ := c.Schema([]byte(`{}`)); a == b {
+		t.Error("a nil cache should compile every time")
+	}
+	if s := c.Stats(); s != (Stats{}) {
+		t.Errorf("a nil cache should have no stats, got %+v", s)
+	}
+}
+
+// mapBackend is a Backend in memory that fails while err is set.
+type mapBackend struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+	err     error
+}
+
+func (b *mapBackend) Get(_ context.Context, key string) ([]byte, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	v, ok := b.entries[key]
+	return v, ok, b.err
+}
+
+func (b *mapBackend) Set(_ context.Context, key string, value []byte, _ time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err == nil {
+		b.entries[key] = value
+	}
+	return b.err
+}
+
+func TestBackend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "money.json")
+	if err := os.WriteFile(path, []byte(`{"type": "integer", "minimum": 0}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ref := (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+	source := []byte(`{"properties": {"amount": {"$ref": "` + ref + `"}}}`)
+	backend := &mapBackend{entries: map[string][]byte{}}
+
+	first := New(Config{Backend: backend})
+	if _, err := first.Schema(source); err != nil {
+		t.Fatalf("Schema failed: %v", err)
+	}
+	if len(backend.entries) != 1 {
+		t.Fatalf("expected the bundle in the backend, got %v", backend.entries)
+	}
+
+	// Another process compiles the bundle without the referenced file.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	second := New(Config{Backend: backend})
+	s, err := second.Schema(source)
+	if err != nil {
+		t.Fatalf("Schema should compile from the backend: %v", err)
+	}
+	if err := s.Validate(map[string]interface{}{"amount": -1}); err == nil {
+		t.Error("the bundled schema should apply the referenced one")
+	}
+	if stats := second.Stats(); stats.BackendHits != 1 || stats.Misses != 1 {
+		t.Errorf("expected a backend hit, got %+v", stats)
+	}
+
+	// A failing backend is counted and otherwise ignored.
+	backend.err = errors.New("connection refused")
+	third := New(Config{Backend: backend})
+	if _, err := third.Schema([]byte(`{"type": "string"}`)); err != nil {
+		t.Fatalf("Schema should compile without the backend: %v", err)
+	}
+	if stats := third.Stats(); stats.BackendErrors != 2 || stats.BackendHits != 0 {
+		t.Errorf("expected two backend errors, got %+v", stats)
+	}
+}
@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRedisPrefix is the prefix of the keys of a Redis backend if none is
+// given.
+const DefaultRedisPrefix = "godemo:cache:"
+
+// Redis is a Backend in Redis, one string key per entry. Entries with a TTL
+// expire in Redis itself.
+type Redis struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedis returns a backend storing its entries in client under keys that
+// start with prefix, DefaultRedisPrefix if empty.
+func NewRedis(client redis.UniversalClient, prefix string) *Redis {
+	if prefix == "" {
+		prefix = DefaultRedisPrefix
+	}
+	return &Redis{client: client, prefix: prefix}
+}
+
+// Get implements Backend.
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := r.client.Get(ctx, r.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set implements Backend.
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, r.prefix+key, value, ttl).Err()
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TestRedis runs against the server in GODEMO_REDIS_ADDR (host:port) and is
+// skipped without it.
+func TestRedis(t *testing.T) {
+	addr := os.Getenv("GODEMO_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("GODEMO_REDIS_ADDR is not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	defer client.Close()
+	ctx := context.Background()
+	b := NewRedis(client, fmt.Sprintf("godemo-test-%d:", time.Now().UnixNano()))
+
+	if _, ok, err := b.Get(ctx, "a"); ok || err != nil {
+		t.Errorf("expected no entry, got %v %v", ok, err)
+	}
+	if err := b.Set(ctx, "a", []byte(`{"v": 1}`), time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if v, ok, err := b.Get(ctx, "a"); !ok || err != nil || string(v) != `{"v": 1}` {
+		t.Errorf("expected the value, got %s %v %v", v, ok, err)
+	}
+	if ttl := client.TTL(ctx, b.prefix+"a").Val(); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("expected the entry to expire within a minute, got %v", ttl)
+	}
+	client.Del(ctx, b.prefix+"a")
+}
+
+func TestNewRedis(t *testing.T) {
+	if b := NewRedis(redis.NewClient(&redis.Options{}), ""); b.prefix != DefaultRedisPrefix {
+		t.Errorf("expected the default prefix, got %q", b.prefix)
+	}
+}
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"go-demo/internal/sqlutil"
+)
+
+// SQLConfig configures an SQL backend.
+type SQLConfig struct {
+	// Table is the name of the table, "cache" if empty. It is created if it
+	// doesn't exist.
+	Table string
+
+	// Placeholder is the parameter style of the driver: "?" (the default,
+	// e.g. SQLite and MySQL) or "$" for numbered parameters ($1, $2, ...,
+	// e.g. PostgreSQL).
+	Placeholder string
+}
+
+// SQL is a Backend in a database table with one row per key.
+type SQL struct {
+	db    *sql.DB
+	table sqlutil.Table
+	now   func() time.Time
+}
+
+// NewSQL returns a backend in a table of db, creating the table if needed.
+func NewSQL(ctx context.Context, db *sql.DB, cfg SQLConfig) (*SQL, error) {
+	table, err := sqlutil.NewTable(cfg.Table, "cache", cfg.Placeholder)
+	if err != nil {
+		return nil, err
+	}
+	// expires is in Unix milliseconds, 0 for entries without a TTL.
+	err = table.Create(ctx, db, `
+		cache_key VARCHAR(255) NOT NULL PRIMARY KEY,
+		value TEXT NOT NULL,
+		expires BIGINT NOT NULL
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return &SQL{db: db, table: table, now: time.Now}, nil
+}
+
+// Get implements Backend.
+func (s *SQL) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	var value string
+	err := s.db.QueryRowContext(ctx,
+		s.table.Query(`SELECT value FROM `+s.table.Name+` WHERE cache_key = ? AND (expires = 0 OR expires > ?)`),
+		key, s.now().UnixMilli()).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return []byte(value), true, nil
+}
+
+// Set implements Backend. It also drops the expired rows, so that the table
+// doesn't grow with entries no process will read again.
+func (s *SQL) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	now := s.now().UnixMilli()
+	var expires int64
+	if ttl > 0 {
+		expires = now + ttl.Milliseconds()
+	}
+	// Drivers disagree on upserts; replace the row in a transaction instead.
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, s.table.Query(`DELETE FROM `+s.table.Name+` WHERE cache_key = ? OR (expires > 0 AND expires <= ?)`), key, now); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, s.table.Query(`INSERT INTO `+s.table.Name+` (cache_key, value, expires) VALUES (?, ?, ?)`), key, string(value), expires); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSQL(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	b, err := NewSQL(ctx, db, SQLConfig{Table: "compiled"})
+	if err != nil {
+		t.Fatalf("NewSQL failed: %v", err)
+	}
+	now := time.Unix(1000, 0)
+	b.now = func() time.Time { return now }
+
+	if _, ok, err := b.Get(ctx, "a"); ok || err != nil {
+		t.Errorf("expected no entry, got %v %v", ok, err)
+	}
+	if err := b.Set(ctx, "a", []byte(`{"v": 1}`), 0); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := b.Set(ctx, "a", []byte(`{"v": 2}`), 0); err != nil {
+		t.Fatalf("Set should replace an entry: %v", err)
+	}
+	if err := b.Set(ctx, "b", []byte(`{}`), time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if v, ok, err := b.Get(ctx, "a"); !ok || err != nil || string(v) != `{"v": 2}` {
+		t.Errorf("expected the new value, got %s %v %v", v, ok, err)
+	}
+	if _, ok, _ := b.Get(ctx, "b"); !ok {
+		t.Error("expected an entry within its TTL")
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok, _ := b.Get(ctx, "b"); ok {
+		t.Error("expected an expired entry to be gone")
+	}
+	if err := b.Set(ctx, "c", []byte(`{}`), 0); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	var rows int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM compiled`).Scan(&rows); err != nil || rows != 2 {
+		t.Errorf("expected Set to drop expired rows, got %d rows (%v)", rows, err)
+	}
+
+	if _, err := NewSQL(ctx, db, SQLConfig{Table: "x; DROP TABLE compiled"}); err == nil {
+		t.Error("NewSQL should reject invalid table names")
+	}
+	if _, err := NewSQL(ctx, db, SQLConfig{Placeholder: ":"}); err == nil {
+		t.Error("NewSQL should reject unknown placeholder styles")
+	}
+}
@@ -0,0 +1,133 @@
+// Package defaults fills in the zero-valued fields of Go structs from their
+// `default:"..."` struct tags, for configuration that is decoded into structs
+// rather than documents. The tags mean what the same default means in a
+// schema: jsonschema.FromType turns them into the schema's defaults, and
+// Apply fills in a struct as jsonschema.ApplyDefaults, with
+// jsonschema.FillContainerDefaults, fills in the document it encodes to.
+package defaults
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Tag is the struct tag holding a field's default.
+const Tag = "default"
+
+// ParseTag returns the default tag of a field of type t as JSON. Tags of
+// string fields are strings, so `default:"en"` and `default:"1.3"` need no
+// quotes, unless they are quoted JSON strings.
+func ParseTag(tag string, t reflect.Type) (json.RawMessage, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.String && !strings.HasPrefix(tag, `"`) {
+		return json.Marshal(tag)
+	}
+	if !json.Valid([]byte(tag)) {
+		return nil, fmt.Errorf("default %q is not JSON", tag)
+	}
+	return json.RawMessage(tag), nil
+}
+
+// Apply fills in the zero-valued fields of the struct v points to from their
+// default tags, and does the same in its nested structs, the structs its
+// slices, arrays and maps hold and the structs its pointers point to, like
+// the schema engine applies defaults to nested objects and array items. A
+// zero value is a missing value: Go can't tell it from one set to zero. Nil
+// pointers to structs are allocated only if a default fills something in
+// them, and not for a struct type already being filled in further up, so
+// recursive types end. Only exported fields are filled in.
+func Apply(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("defaults: Apply needs a non-nil pointer, got %T", v)
+	}
+	a := &applier{active: map[reflect.Type]int{}}
+	a.value(rv.Elem(), "")
+	return a.err
+}
+
+// applier fills in defaults, keeping the first bad tag in err.
+type applier struct {
+	// active counts the struct types being filled in.
+	active map[reflect.Type]int
+	err    error
+}
+
+// value fills in the defaults of v, found at path, and reports whether it
+// filled in any.
+func (a *applier) value(v reflect.Value, path string) bool {
+	switch v.Kind() {
+	case reflect.Struct:
+		return a.fields(v, path)
+	case reflect.Pointer:
+		if !v.IsNil() {
+			return a.value(v.Elem(), path)
+		}
+		t := v.Type().Elem()
+		if t.Kind() != reflect.Struct || a.active[t] > 0 {
+			return false
+		}
+		elem := reflect.New(t)
+		if a.value(elem.Elem(), path) {
+			v.Set(elem)
+			return true
+		}
+	case reflect.Slice, reflect.Array:
+		filled := false
+		for i := 0; i < v.Len(); i++ {
+			filled = a.value(v.Index(i), fmt.Sprintf("%s[%d]", path, i)) || filled
+		}
+		return filled
+	case reflect.Map:
+		filled := false
+		iter := v.MapRange()
+		for iter.Next() {
+			// Map values aren't addressable; fill in a copy and store it.
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			if a.value(elem, fmt.Sprintf("%s[%v]", path, iter.Key())) {
+				v.SetMapIndex(iter.Key(), elem)
+				filled = true
+			}
+		}
+		return filled
+	}
+	return false
+}
+
+// fields fills in the defaults of the fields of the struct v.
+func (a *applier) fields(v reflect.Value, path string) bool {
+	t := v.Type()
+	a.active[t]++
+	defer func() { a.active[t]-- }()
+
+	filled := false
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		field := v.Field(i)
+		fieldPath := strings.TrimPrefix(path+"."+f.Name, ".")
+		if tag, ok := f.Tag.Lookup(Tag); ok && field.IsZero() {
+			raw, err := ParseTag(tag, f.Type)
+			if err == nil {
+				err = json.Unmarshal(raw, field.Addr().Interface())
+			}
+			if err != nil {
+				if a.err == nil {
+					a.err = fmt.Errorf("defaults: %s: %w", fieldPath, err)
+				}
+				continue
+			}
+			filled = true
+		}
+		// A default value gets the defaults of its own fields too.
+		filled = a.value(field, fieldPath) || filled
+	}
+	return filled
+}
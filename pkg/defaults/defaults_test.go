@@ -0,0 +1,98 @@
+package defaults
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type tlsConfig struct {
+	Enabled bool   `default:"true"`
+	Version string `default:"1.3"`
+}
+
+type server struct {
+	Host    string   `default:"localhost"`
+	Port    int      `default:"8080"`
+	Ratio   *float64 `default:"0.5"`
+	Tags    []string `default:"[\"web\"]"`
+	TLS     tlsConfig
+	Proxy   *tlsConfig
+	Routes  []route
+	Backend map[string]route
+	Parent  *server
+	secret  string `default:"x"`
+}
+
+type route struct {
+	Path    string `default:"/"`
+	Timeout int    `default:"30"`
+}
+
+func TestApply(t *testing.T) {
+	s := server{
+		Port:    9090,
+		Routes:  []route{{Path: "/api"}, {}},
+		Backend: map[string]route{"a": {Timeout: 5}},
+		Parent:  &server{Host: "parent"},
+	}
+	if err := Apply(&s); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	ratio := 0.5
+	want := server{
+		Host:    "localhost",
+		Port:    9090,
+		Ratio:   &ratio,
+		Tags:    []string{"web"},
+		TLS:     tlsConfig{Enabled: true, Version: "1.3"},
+		Proxy:   &tlsConfig{Enabled: true, Version: "1.3"},
+		Routes:  []route{{Path: "/api", Timeout: 30}, {Path: "/", Timeout: 30}},
+		Backend: map[string]route{"a": {Path: "/", Timeout: 5}},
+		Parent: &server{
+			Host: "parent", Port: 8080, Ratio: &ratio, Tags: []string{"web"},
+			TLS: tlsConfig{Enabled: true, Version: "1.3"}, Proxy: &tlsConfig{Enabled: true, Version: "1.3"},
+		},
+	}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("expected %+v, got %+v", want, s)
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	var s server
+	if err := Apply(s); err == nil {
+		t.Error("Apply should fail for a non-pointer")
+	}
+
+	var bad struct {
+		Port int `default:"eighty"`
+	}
+	if err := Apply(&bad); err == nil || !strings.Contains(err.Error(), "Port") {
+		t.Errorf("expected an error naming the field, got %v", err)
+	}
+}
+
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		tag  string
+		typ  reflect.Type
+		want string
+	}{
+		{"en", reflect.TypeOf(""), `"en"`},
+		{`"en"`, reflect.TypeOf(""), `"en"`},
+		{"42", reflect.TypeOf(0), `42`},
+		{"42", reflect.TypeOf(""), `"42"`},
+		{"main", reflect.TypeOf((*string)(nil)), `"main"`},
+	}
+	for _, tt := range tests {
+		got, err := ParseTag(tt.tag, tt.typ)
+		if err != nil || string(got) != tt.want {
+			t.Errorf("ParseTag(%q, %v) = %s, %v, expected %s", tt.tag, tt.typ, got, err, tt.want)
+		}
+	}
+	if _, err := ParseTag("eighty", reflect.TypeOf(0)); err == nil {
+		t.Error("ParseTag should fail for a number field with a non-JSON tag")
+	}
+}
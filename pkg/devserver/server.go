@@ -0,0 +1,272 @@
+// Package devserver serves a template rendered with a sample context over HTTP
+// while it is being written. The page reloads itself whenever the template,
+// anything it may include, or the sample context changes on disk, and render
+// errors are shown in the browser instead of the rendered output.
+package devserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flosch/pongo2/v6"
+	"gopkg.in/yaml.v3"
+
+	"go-demo/pkg/cache"
+	tpl "go-demo/pkg/pongo2"
+)
+
+// Config describes what the development server renders.
+type Config struct {
+	// Template is the template file to render. With Options.TemplateDirs set
+	// it is a name relative to those directories.
+	Template string
+
+	// Context is an optional JSON or YAML file holding the sample context.
+	Context string
+
+	// Options configures the renderer.
+	Options tpl.Options
+
+	// Interval is how often files are checked for changes (default 500ms).
+	Interval time.Duration
+
+	// Cache keeps the template compiled until a watched file changes. Nil
+	// compiles it on every render.
+	Cache *cache.Cache
+}
+
+// Server renders Config.Template on every request. It implements http.Handler:
+//
+//	/        the rendered output (or the render error) in an auto-reloading page
+//	/raw     the rendered output alone
+//	/events  a server-sent event stream announcing file changes
+type Server struct {
+	cfg Config
+
+	mu      sync.Mutex
+	stamp   string
+	changed chan struct{} // closed and replaced whenever the files change
+}
+
+// New creates a Server for cfg.
+func New(cfg Config) *Server {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 500 * time.Millisecond
+	}
+	s := &Server{cfg: cfg, changed: make(chan struct{})}
+	s.stamp = s.snapshot()
+	return s
+}
+
+// ListenAndServe serves cfg on addr until ctx is cancelled.
+func ListenAndServe(ctx context.Context, addr string, cfg Config) error {
+	s := New(cfg)
+	srv := &http.Server{Addr: addr, Handler: s}
+	go s.Watch(ctx)
+	go func() {
+		<-ctx.Done()
+		_ = srv.Shutdown(context.Background())
+	}()
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Watch polls the watched files until ctx is cancelled, notifying connected
+// pages when anything changes.
+func (s *Server) Watch(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.check()
+		}
+	}
+}
+
+// check compares the watched files against the last snapshot.
+func (s *Server) check() {
+	stamp := s.snapshot()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stamp != s.stamp {
+		s.stamp = stamp
+		close(s.changed)
+		s.changed = make(chan struct{})
+	}
+}
+
+// snapshot lists the size and modification time of every watched file: the
+// template directories (or the template's own directory) and the context file.
+func (s *Server) snapshot() string {
+	dirs := s.cfg.Options.TemplateDirs
+	if len(dirs) == 0 {
+		dirs = []string{filepath.Dir(s.cfg.Template)}
+	}
+
+	var entries []string
+	add := func(path string, info fs.FileInfo) {
+		entries = append(entries, fmt.Sprintf("%s %d %d", path, info.Size(), info.ModTime().UnixNano()))
+	}
+	for _, dir := range dirs {
+		_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				add(path, info)
+			}
+			return nil
+		})
+	}
+	if s.cfg.Context != "" {
+		if info, err := os.Stat(s.cfg.Context); err == nil {
+			add(s.cfg.Context, info)
+		}
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, "\n")
+}
+
+// Render renders the template with a freshly loaded sample context.
+func (s *Server) Render() (string, error) {
+	ctx, err := loadContext(s.cfg.Context)
+	if err != nil {
+		return "", err
+	}
+	if s.cfg.Cache == nil {
+		return tpl.NewRenderer(s.cfg.Options).RenderFile(s.cfg.Template, ctx)
+	}
+	// The snapshot changes with any file the template may include.
+	t, err := s.cfg.Cache.TemplateFile(s.cfg.Options, s.cfg.Template, s.snapshot())
+	if err != nil {
+		return "", err
+	}
+	return t.Render(ctx)
+}
+
+// loadContext reads a JSON or YAML sample context; an empty path yields an
+// empty context.
+func loadContext(path string) (pongo2.Context, error) {
+	ctx := pongo2.Context{}
+	if path == "" {
+		return ctx, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &ctx)
+	default:
+		err = json.Unmarshal(b, &ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse context %s: %w", path, err)
+	}
+	return ctx, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/":
+		s.servePage(w)
+	case "/raw":
+		s.serveRaw(w)
+	case "/events":
+		s.serveEvents(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+var page = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .Template }}</title>
+<style>
+body { font-family: sans-serif; margin: 1.5em; }
+pre { padding: 1em; background: #f6f8fa; overflow: auto; }
+.error { background: #ffebe9; color: #82071e; }
+</style>
+</head>
+<body>
+<h3>{{ .Template }} <small>rendered {{ .Time }}</small></h3>
+{{ if .Error }}<pre class="error">{{ .Error }}</pre>{{ else }}<pre>{{ .Output }}</pre>{{ end }}
+<script>new EventSource("/events").onmessage = function() { location.reload(); };</script>
+</body>
+</html>
+`))
+
+func (s *Server) servePage(w http.ResponseWriter) {
+	output, err := s.Render()
+	data := struct {
+		Template, Time, Output, Error string
+	}{Template: s.cfg.Template, Time: time.Now().Format("15:04:05"), Output: output}
+	if err != nil {
+		data.Error = err.Error()
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = page.Execute(w, data)
+}
+
+func (s *Server) serveRaw(w http.ResponseWriter) {
+	output, err := s.Render()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	contentType := mime.TypeByExtension(filepath.Ext(strings.TrimSuffix(s.cfg.Template, filepath.Ext(s.cfg.Template))))
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(s.cfg.Template))
+	}
+	if contentType == "" {
+		contentType = "text/plain; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write([]byte(output))
+}
+
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		s.mu.Lock()
+		changed := s.changed
+		s.mu.Unlock()
+		select {
+		case <-r.Context().Done():
+			return
+		case <-changed:
+			fmt.Fprint(w, "data: reload\n\n")
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package devserver
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-demo/pkg/cache"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func get(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestServerRendersTemplate(t *testing.T) {
+	dir := t.TempDir()
+	tplPath := filepath.Join(dir, "user.json.tpl")
+	ctxPath := filepath.Join(dir, "sample.yaml")
+	writeFile(t, tplPath, `{"name": {{ name|to_json }}}`)
+	writeFile(t, ctxPath, "name: Alice\n")
+
+	ts := httptest.NewServer(New(Config{Template: tplPath, Context: ctxPath}))
+	defer ts.Close()
+
+	status, body := get(t, ts.URL+"/raw")
+	if status != http.StatusOK || body != `{"name": "Alice"}` {
+		t.Errorf("unexpected raw response %d: %s", status, body)
+	}
+
+	status, body = get(t, ts.URL+"/")
+	if status != http.StatusOK || !strings.Contains(body, "EventSource") || !strings.Contains(body, `{&#34;name&#34;: &#34;Alice&#34;}`) {
+		t.Errorf("unexpected page response %d: %s", status, body)
+	}
+}
+
+func TestServerShowsRenderErrors(t *testing.T) {
+	dir := t.TempDir()
+	tplPath := filepath.Join(dir, "broken.tpl")
+	writeFile(t, tplPath, "ok\n{% if %}")
+
+	ts := httptest.NewServer(New(Config{Template: tplPath}))
+	defer ts.Close()
+
+	status, body := get(t, ts.URL+"/")
+	if status != http.StatusOK || !strings.Contains(body, `class="error"`) || !strings.Contains(body, "broken.tpl:2") {
+		t.Errorf("Page should show the render error, got %d: %s", status, body)
+	}
+
+	status, _ = get(t, ts.URL+"/raw")
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("Raw output of a broken template should fail, got %d", status)
+	}
+}
+
+func TestServerNotifiesOnChange(t *testing.T) {
+	dir := t.TempDir()
+	tplPath := filepath.Join(dir, "page.tpl")
+	writeFile(t, tplPath, "v1")
+
+	s := New(Config{Template: tplPath})
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/events")
+	if err != nil {
+		t.Fatalf("GET /events failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Make sure the modification time differs even on coarse filesystems.
+	writeFile(t, tplPath, "version two")
+	later := time.Now().Add(time.Second)
+	if err := os.Chtimes(tplPath, later, later); err != nil {
+		t.Fatalf("Failed to touch template: %v", err)
+	}
+	s.check()
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil {
+		t.Fatalf("Failed to read event: %v", err)
+	}
+	if line != "data: reload\n" {
+		t.Errorf("expected a reload event, got %q", line)
+	}
+}
+
+func TestServerCachesTemplate(t *testing.T) {
+	dir := t.TempDir()
+	tplPath := filepath.Join(dir, "page.tpl")
+	writeFile(t, tplPath, "v1")
+
+	c := cache.New(cache.Config{})
+	s := New(Config{Template: tplPath, Cache: c})
+	for i := 0; i < 2; i++ {
+		if output, err := s.Render(); err != nil || output != "v1" {
+			t.Fatalf("expected %q, got %q (%v)", "v1", output, err)
+		}
+	}
+	if stats := c.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("expected the second render to use the cached template, got %+v", stats)
+	}
+
+	writeFile(t, tplPath, "v2!")
+	if output, err := s.Render(); err != nil || output != "v2!" {
+		t.Errorf("expected the changed template to be compiled again, got %q (%v)", output, err)
+	}
+}
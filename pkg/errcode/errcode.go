@@ -0,0 +1,63 @@
+// Package errcode classifies the errors of the other packages with stable
+// codes, so that callers can branch on the kind of failure instead of
+// matching messages:
+//
+//	switch errcode.Of(err) {
+//	case errcode.Decode, errcode.Validation:
+//		// the caller sent a bad document
+//	case errcode.TemplateCompile, errcode.Render:
+//		// the template is broken
+//	}
+//
+// Codes are errors too, so errors.Is(err, errcode.Validation) reports whether
+// err, or any error it wraps, is a validation error.
+package errcode
+
+import "errors"
+
+// Code identifies a class of failure. Codes never change once released.
+type Code string
+
+// The codes of the error types of this module.
+const (
+	// Decode is the code of *jsonutil.DecodeError: the input isn't a
+	// well-formed document.
+	Decode Code = "decode_error"
+
+	// SchemaCompile is the code of *jsonschema.SchemaCompileError: the
+	// schema can't be loaded or compiled.
+	SchemaCompile Code = "schema_compile_error"
+
+	// Validation is the code of *jsonschema.ValidationError: the document
+	// doesn't satisfy its schema.
+	Validation Code = "validation_error"
+
+	// TemplateCompile is the code of a *pongo2.RenderError for a template
+	// that failed to compile.
+	TemplateCompile Code = "template_compile_error"
+
+	// Render is the code of a *pongo2.RenderError for a template that
+	// failed to execute.
+	Render Code = "render_error"
+)
+
+// Error returns the code itself, so that codes can be errors.Is targets.
+func (c Code) Error() string {
+	return string(c)
+}
+
+// Coder is implemented by errors that have a code.
+type Coder interface {
+	error
+	Code() Code
+}
+
+// Of returns the code of the first error in err's tree that has one, or ""
+// if none has.
+func Of(err error) Code {
+	var c Coder
+	if errors.As(err, &c) {
+		return c.Code()
+	}
+	return ""
+}
@@ -0,0 +1,36 @@
+package errcode
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type testError struct{ code Code }
+
+func (e *testError) Error() string { return "failed" }
+func (e *testError) Code() Code    { return e.code }
+
+func TestOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{"coded", &testError{Render}, Render},
+		{"wrapped", fmt.Errorf("render: %w", &testError{Decode}), Decode},
+		{"joined", errors.Join(errors.New("plain"), &testError{Validation}), Validation},
+		{"plain", errors.New("plain"), ""},
+		{"nil", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Of(tt.err); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+	if !errors.Is(fmt.Errorf("x: %w", Validation), Validation) || Validation.Error() != "validation_error" {
+		t.Error("codes should be usable as errors")
+	}
+}
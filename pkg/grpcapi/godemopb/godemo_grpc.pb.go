@@ -0,0 +1,413 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             v27.3.0
+// source: godemo.proto
+
+// Validation, default application and template rendering over gRPC. The
+// messages mirror the HTTP API (see pkg/httpapi). Documents, schemas and
+// template contexts are JSON-encoded bytes, so large integers stay exact.
+
+package godemopb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	Documents_Validate_FullMethodName            = "/godemo.v1.Documents/Validate"
+	Documents_ApplyDefaults_FullMethodName       = "/godemo.v1.Documents/ApplyDefaults"
+	Documents_Render_FullMethodName              = "/godemo.v1.Documents/Render"
+	Documents_ValidateStream_FullMethodName      = "/godemo.v1.Documents/ValidateStream"
+	Documents_ApplyDefaultsStream_FullMethodName = "/godemo.v1.Documents/ApplyDefaultsStream"
+	Documents_RenderStream_FullMethodName        = "/godemo.v1.Documents/RenderStream"
+)
+
+// DocumentsClient is the client API for Documents service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DocumentsClient interface {
+	// Validate validates a document against a schema.
+	Validate(ctx context.Context, in *SchemaRequest, opts ...grpc.CallOption) (*ValidateResponse, error)
+	// ApplyDefaults fills in the schema defaults missing from a document.
+	ApplyDefaults(ctx context.Context, in *SchemaRequest, opts ...grpc.CallOption) (*ApplyDefaultsResponse, error)
+	// Render renders a named or inline template.
+	Render(ctx context.Context, in *RenderRequest, opts ...grpc.CallOption) (*RenderResponse, error)
+	// The streaming variants process batches: they answer every request in
+	// order, echoing its id, and report failures per item in the error field
+	// instead of ending the stream.
+	ValidateStream(ctx context.Context, opts ...grpc.CallOption) (Documents_ValidateStreamClient, error)
+	ApplyDefaultsStream(ctx context.Context, opts ...grpc.CallOption) (Documents_ApplyDefaultsStreamClient, error)
+	RenderStream(ctx context.Context, opts ...grpc.CallOption) (Documents_RenderStreamClient, error)
+}
+
+type documentsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDocumentsClient(cc grpc.ClientConnInterface) DocumentsClient {
+	return &documentsClient{cc}
+}
+
+func (c *documentsClient) Validate(ctx context.Context, in *SchemaRequest, opts ...grpc.CallOption) (*ValidateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ValidateResponse)
+	err := c.cc.Invoke(ctx, Documents_Validate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *documentsClient) ApplyDefaults(ctx context.Context, in *SchemaRequest, opts ...grpc.CallOption) (*ApplyDefaultsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ApplyDefaultsResponse)
+	err := c.cc.Invoke(ctx, Documents_ApplyDefaults_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *documentsClient) Render(ctx context.Context, in *RenderRequest, opts ...grpc.CallOption) (*RenderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RenderResponse)
+	err := c.cc.Invoke(ctx, Documents_Render_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *documentsClient) ValidateStream(ctx context.Context, opts ...grpc.CallOption) (Documents_ValidateStreamClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Documents_ServiceDesc.Streams[0], Documents_ValidateStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &documentsValidateStreamClient{ClientStream: stream}
+	return x, nil
+}
+
+type Documents_ValidateStreamClient interface {
+	Send(*SchemaRequest) error
+	Recv() (*ValidateResponse, error)
+	grpc.ClientStream
+}
+
+type documentsValidateStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *documentsValidateStreamClient) Send(m *SchemaRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *documentsValidateStreamClient) Recv() (*ValidateResponse, error) {
+	m := new(ValidateResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *documentsClient) ApplyDefaultsStream(ctx context.Context, opts ...grpc.CallOption) (Documents_ApplyDefaultsStreamClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Documents_ServiceDesc.Streams[1], Documents_ApplyDefaultsStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &documentsApplyDefaultsStreamClient{ClientStream: stream}
+	return x, nil
+}
+
+type Documents_ApplyDefaultsStreamClient interface {
+	Send(*SchemaRequest) error
+	Recv() (*ApplyDefaultsResponse, error)
+	grpc.ClientStream
+}
+
+type documentsApplyDefaultsStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *documentsApplyDefaultsStreamClient) Send(m *SchemaRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *documentsApplyDefaultsStreamClient) Recv() (*ApplyDefaultsResponse, error) {
+	m := new(ApplyDefaultsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *documentsClient) RenderStream(ctx context.Context, opts ...grpc.CallOption) (Documents_RenderStreamClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Documents_ServiceDesc.Streams[2], Documents_RenderStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &documentsRenderStreamClient{ClientStream: stream}
+	return x, nil
+}
+
+type Documents_RenderStreamClient interface {
+	Send(*RenderRequest) error
+	Recv() (*RenderResponse, error)
+	grpc.ClientStream
+}
+
+type documentsRenderStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *documentsRenderStreamClient) Send(m *RenderRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *documentsRenderStreamClient) Recv() (*RenderResponse, error) {
+	m := new(RenderResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DocumentsServer is the server API for Documents service.
+// All implementations must embed UnimplementedDocumentsServer
+// for forward compatibility
+type DocumentsServer interface {
+	// Validate validates a document against a schema.
+	Validate(context.Context, *SchemaRequest) (*ValidateResponse, error)
+	// ApplyDefaults fills in the schema defaults missing from a document.
+	ApplyDefaults(context.Context, *SchemaRequest) (*ApplyDefaultsResponse, error)
+	// Render renders a named or inline template.
+	Render(context.Context, *RenderRequest) (*RenderResponse, error)
+	// The streaming variants process batches: they answer every request in
+	// order, echoing its id, and report failures per item in the error field
+	// instead of ending the stream.
+	ValidateStream(Documents_ValidateStreamServer) error
+	ApplyDefaultsStream(Documents_ApplyDefaultsStreamServer) error
+	RenderStream(Documents_RenderStreamServer) error
+	mustEmbedUnimplementedDocumentsServer()
+}
+
+// UnimplementedDocumentsServer must be embedded to have forward compatible implementations.
+type UnimplementedDocumentsServer struct {
+}
+
+func (UnimplementedDocumentsServer) Validate(context.Context, *SchemaRequest) (*ValidateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Validate not implemented")
+}
+func (UnimplementedDocumentsServer) ApplyDefaults(context.Context, *SchemaRequest) (*ApplyDefaultsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApplyDefaults not implemented")
+}
+func (UnimplementedDocumentsServer) Render(context.Context, *RenderRequest) (*RenderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Render not implemented")
+}
+func (UnimplementedDocumentsServer) ValidateStream(Documents_ValidateStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method ValidateStream not implemented")
+}
+func (UnimplementedDocumentsServer) ApplyDefaultsStream(Documents_ApplyDefaultsStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method ApplyDefaultsStream not implemented")
+}
+func (UnimplementedDocumentsServer) RenderStream(Documents_RenderStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method RenderStream not implemented")
+}
+func (UnimplementedDocumentsServer) mustEmbedUnimplementedDocumentsServer() {}
+
+// UnsafeDocumentsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DocumentsServer will
+// result in compilation errors.
+type UnsafeDocumentsServer interface {
+	mustEmbedUnimplementedDocumentsServer()
+}
+
+func RegisterDocumentsServer(s grpc.ServiceRegistrar, srv DocumentsServer) {
+	s.RegisterService(&Documents_ServiceDesc, srv)
+}
+
+func _Documents_Validate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SchemaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DocumentsServer).Validate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Documents_Validate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DocumentsServer).Validate(ctx, req.(*SchemaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Documents_ApplyDefaults_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SchemaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DocumentsServer).ApplyDefaults(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Documents_ApplyDefaults_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DocumentsServer).ApplyDefaults(ctx, req.(*SchemaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Documents_Render_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DocumentsServer).Render(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Documents_Render_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DocumentsServer).Render(ctx, req.(*RenderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Documents_ValidateStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DocumentsServer).ValidateStream(&documentsValidateStreamServer{ServerStream: stream})
+}
+
+type Documents_ValidateStreamServer interface {
+	Send(*ValidateResponse) error
+	Recv() (*SchemaRequest, error)
+	grpc.ServerStream
+}
+
+type documentsValidateStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *documentsValidateStreamServer) Send(m *ValidateResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *documentsValidateStreamServer) Recv() (*SchemaRequest, error) {
+	m := new(SchemaRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Documents_ApplyDefaultsStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DocumentsServer).ApplyDefaultsStream(&documentsApplyDefaultsStreamServer{ServerStream: stream})
+}
+
+type Documents_ApplyDefaultsStreamServer interface {
+	Send(*ApplyDefaultsResponse) error
+	Recv() (*SchemaRequest, error)
+	grpc.ServerStream
+}
+
+type documentsApplyDefaultsStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *documentsApplyDefaultsStreamServer) Send(m *ApplyDefaultsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *documentsApplyDefaultsStreamServer) Recv() (*SchemaRequest, error) {
+	m := new(SchemaRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Documents_RenderStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DocumentsServer).RenderStream(&documentsRenderStreamServer{ServerStream: stream})
+}
+
+type Documents_RenderStreamServer interface {
+	Send(*RenderResponse) error
+	Recv() (*RenderRequest, error)
+	grpc.ServerStream
+}
+
+type documentsRenderStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *documentsRenderStreamServer) Send(m *RenderResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *documentsRenderStreamServer) Recv() (*RenderRequest, error) {
+	m := new(RenderRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Documents_ServiceDesc is the grpc.ServiceDesc for Documents service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Documents_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "godemo.v1.Documents",
+	HandlerType: (*DocumentsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Validate",
+			Handler:    _Documents_Validate_Handler,
+		},
+		{
+			MethodName: "ApplyDefaults",
+			Handler:    _Documents_ApplyDefaults_Handler,
+		},
+		{
+			MethodName: "Render",
+			Handler:    _Documents_Render_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ValidateStream",
+			Handler:       _Documents_ValidateStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ApplyDefaultsStream",
+			Handler:       _Documents_ApplyDefaultsStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "RenderStream",
+			Handler:       _Documents_RenderStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "godemo.proto",
+}
@@ -0,0 +1,557 @@
+// Package grpcapi exposes schema validation, default application and template
+// rendering as the gRPC service godemo.v1.Documents (see
+// godemopb/godemo.proto), with streaming variants for batch processing. It
+// serves the same schemas and templates as the HTTP API in pkg/httpapi, and
+// the standard grpc.health.v1 service for orchestrator probes.
+//
+// With Config.Auth set, calls require an API key in the "authorization"
+// ("Bearer KEY") or "x-api-key" metadata; health checks stay open. With
+// Config.Tenants set, a tenant's calls use the tenant's schemas, templates,
+// cache and limits (see pkg/tenant).
+package grpcapi
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative godemopb/godemo.proto
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/flosch/pongo2/v6"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"go-demo/pkg/auth"
+	"go-demo/pkg/cache"
+	"go-demo/pkg/grpcapi/godemopb"
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/jsonutil"
+	"go-demo/pkg/limits"
+	tpl "go-demo/pkg/pongo2"
+	"go-demo/pkg/tenant"
+)
+
+// Config configures a Server.
+type Config struct {
+	// SchemaDir holds the named schemas (*.json). Optional.
+	SchemaDir string
+
+	// TemplateDir holds the named templates, compiled at startup. Inline
+	// templates can include these, and no other files. Optional.
+	TemplateDir string
+
+	// Options configures template rendering.
+	Options tpl.Options
+
+	// Limits bounds message sizes, request rates per client, render times
+	// and concurrent renders. The zero value limits messages to 10 MB.
+	Limits limits.Config
+
+	// Auth authenticates calls. Nil leaves the service open.
+	Auth auth.Authenticator
+
+	// Cache keeps inline schemas and templates compiled, so repeated ones
+	// are compiled once. It may be shared with other servers. Nil compiles
+	// them on every request. Clients send the inline schemas, so it must be
+	// sandboxed, with the schemas of SchemaDir as its SchemaFS: New fails
+	// otherwise. Without a cache, inline schemas are compiled sandboxed too.
+	Cache *cache.Cache
+
+	// Tenants are the namespaces calls can be scoped to, by the tenant of
+	// their API key or the x-tenant metadata. Nil serves no tenants.
+	Tenants *tenant.Set
+}
+
+// Server implements the Documents service. Create it with New.
+type Server struct {
+	godemopb.UnimplementedDocumentsServer
+
+	opts      tpl.Options
+	schemas   map[string]*jsonschema.Schema
+	templates *tpl.Registry
+	health    *health.Server
+	limits    limits.Config
+	limiter   *limits.RateLimiter
+	renders   *limits.Semaphore
+	auth      auth.Authenticator
+	cache     *cache.Cache
+	tenants   *tenant.Set
+
+	// schemaFS holds the schemas inline schemas can reference.
+	schemaFS fs.FS
+
+	// templateDirs are the directories inline templates load files from.
+	templateDirs []string
+}
+
+// New loads the named schemas and templates of cfg. It fails if any of them
+// doesn't compile.
+func New(cfg Config) (*Server, error) {
+	s := &Server{
+		opts:    cfg.Options,
+		schemas: make(map[string]*jsonschema.Schema),
+		health:  health.NewServer(),
+		limits:  cfg.Limits,
+		limiter: cfg.Limits.NewRateLimiter(),
+		renders: cfg.Limits.NewSemaphore(),
+		auth:    cfg.Auth,
+		cache:   cfg.Cache,
+		tenants: cfg.Tenants,
+	}
+	if cfg.Cache != nil && !cfg.Cache.Sandboxed() {
+		return nil, errors.New("the cache of inline schemas must be sandboxed")
+	}
+	if cfg.SchemaDir != "" {
+		s.schemaFS = os.DirFS(cfg.SchemaDir)
+		schemas, err := schemautil.CompileDir(cfg.SchemaDir)
+		if err != nil {
+			return nil, fmt.Errorf("load schemas: %w", err)
+		}
+		s.schemas = schemas
+	}
+	if cfg.TemplateDir != "" {
+		reg, err := tpl.LoadDir(cfg.TemplateDir, cfg.Options)
+		if err != nil {
+			return nil, err
+		}
+		s.templates = reg
+		s.templateDirs = []string{cfg.TemplateDir}
+	}
+	return s, nil
+}
+
+// NewGRPCServer returns a gRPC server with s and the health service
+// registered. Both the server ("") and godemo.v1.Documents report SERVING.
+func (s *Server) NewGRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	opts = append([]grpc.ServerOption{
+		grpc.MaxRecvMsgSize(int(s.limits.BodyLimit())),
+		grpc.ChainUnaryInterceptor(s.guardUnary),
+		grpc.ChainStreamInterceptor(s.guardStream),
+	}, opts...)
+	g := grpc.NewServer(opts...)
+	godemopb.RegisterDocumentsServer(g, s)
+	healthpb.RegisterHealthServer(g, s.health)
+	s.health.SetServingStatus(godemopb.Documents_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	return g
+}
+
+// ListenAndServe serves the service on addr until ctx is cancelled.
+func ListenAndServe(ctx context.Context, addr string, cfg Config) error {
+	s, err := New(cfg)
+	if err != nil {
+		return err
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	g := s.NewGRPCServer()
+	go func() {
+		<-ctx.Done()
+		s.health.Shutdown()
+		g.GracefulStop()
+	}()
+	return g.Serve(lis)
+}
+
+// operations maps the methods of the Documents service to the operations
+// API keys are allowed.
+var operations = map[string]string{
+	"Validate":            auth.OpValidate,
+	"ValidateStream":      auth.OpValidate,
+	"ApplyDefaults":       auth.OpApplyDefaults,
+	"ApplyDefaultsStream": auth.OpApplyDefaults,
+	"Render":              auth.OpRender,
+	"RenderStream":        auth.OpRender,
+}
+
+// guard authenticates the call with the metadata of ctx, resolves its tenant
+// and applies the rate limits of the calling client, identified by its key
+// name or, without Config.Auth, by its IP address, and of the tenant. It
+// returns ctx with the tenant's namespace. Health checks are exempt.
+func (s *Server) guard(ctx context.Context, method string) (context.Context, error) {
+	if strings.HasPrefix(method, "/grpc.health.v1.") {
+		return ctx, nil
+	}
+	client := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		client = p.Addr.String()
+		if host, _, err := net.SplitHostPort(client); err == nil {
+			client = host
+		}
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	var p *auth.Principal
+	if s.auth != nil {
+		token := auth.Token(first(md.Get("authorization")), first(md.Get("x-api-key")))
+		op := operations[method[strings.LastIndex(method, "/")+1:]]
+		var err error
+		p, err = auth.Authorize(ctx, s.auth, token, op)
+		switch {
+		case errors.Is(err, auth.ErrUnauthenticated):
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		case errors.Is(err, auth.ErrForbidden):
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		case err != nil:
+			return nil, status.Errorf(codes.Internal, "authenticate: %v", err)
+		}
+		client = "key:" + p.Name
+	}
+	ns, err := s.tenants.Resolve(p, first(md.Get(strings.ToLower(tenant.Header))))
+	switch {
+	case errors.Is(err, auth.ErrForbidden):
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if ok, wait := s.limiter.Allow(client); !ok {
+		return nil, status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry in %v", wait.Round(time.Millisecond))
+	}
+	if ns != nil {
+		if ok, wait := ns.Allow(); !ok {
+			return nil, status.Errorf(codes.ResourceExhausted, "tenant rate limit exceeded, retry in %v", wait.Round(time.Millisecond))
+		}
+		ctx = context.WithValue(ctx, namespaceKey{}, ns)
+	}
+	return ctx, nil
+}
+
+// namespaceKey is the context key of the tenant namespace set by guard.
+type namespaceKey struct{}
+
+// scope is what a call can reach: the server's own schemas, templates, cache
+// and limits, or those of its tenant.
+type scope struct {
+	schemas      map[string]*jsonschema.Schema
+	templates    *tpl.Registry
+	templateDirs []string
+	cache        *cache.Cache
+	schemaFS     fs.FS
+	limits       limits.Config
+}
+
+// inlineSchema compiles a client's schema with the cache, which is sandboxed,
+// or without one, with its $refs confined to schemaFS and the common schemas.
+func (sc scope) inlineSchema(source []byte) (*jsonschema.Schema, error) {
+	if sc.cache == nil {
+		return schemautil.CompileStringFS(string(source), sc.schemaFS)
+	}
+	return sc.cache.Schema(source)
+}
+
+func (s *Server) scope(ctx context.Context) scope {
+	ns, ok := ctx.Value(namespaceKey{}).(*tenant.Namespace)
+	if !ok {
+		return scope{schemas: s.schemas, templates: s.templates, templateDirs: s.templateDirs, cache: s.cache, schemaFS: s.schemaFS, limits: s.limits}
+	}
+	return scope{schemas: ns.Schemas, templates: ns.Templates, templateDirs: ns.TemplateDirs, cache: ns.Cache, limits: ns.LimitsFor(s.limits)}
+}
+
+// checkSize fails for messages beyond a tenant's size limit, which may be
+// below the server's (see grpc.MaxRecvMsgSize).
+func (sc scope) checkSize(m proto.Message) error {
+	if size := int64(proto.Size(m)); size > sc.limits.BodyLimit() {
+		return errorf(codes.ResourceExhausted, "message larger than %d bytes", sc.limits.BodyLimit())
+	}
+	return nil
+}
+
+// first returns the first of values, or "".
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (s *Server) guardUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := s.guard(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// guardStream checks a stream once, when it opens.
+func (s *Server) guardStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.guard(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &guardedStream{ServerStream: ss, ctx: ctx})
+}
+
+// guardedStream is a stream with the context returned by guard.
+type guardedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *guardedStream) Context() context.Context { return s.ctx }
+
+// codeError is an error with a gRPC status code.
+type codeError struct {
+	code codes.Code
+	err  error
+}
+
+func (e *codeError) Error() string { return e.err.Error() }
+
+func errorf(code codes.Code, format string, args ...interface{}) *codeError {
+	return &codeError{code: code, err: fmt.Errorf(format, args...)}
+}
+
+// toError describes a failed stream item.
+func toError(err error) *godemopb.Error {
+	var codeErr *codeError
+	var renderErr *tpl.RenderError
+	switch {
+	case errors.As(err, &codeErr):
+		return &godemopb.Error{Code: int32(codeErr.code), Message: err.Error()}
+	case errors.Is(err, tpl.ErrTemplateNotFound):
+		return &godemopb.Error{Code: int32(codes.NotFound), Message: err.Error()}
+	case errors.Is(err, limits.ErrTimeout):
+		return &godemopb.Error{Code: int32(codes.DeadlineExceeded), Message: "render timed out"}
+	case errors.As(err, &renderErr):
+		return &godemopb.Error{
+			Code:     int32(codes.InvalidArgument),
+			Message:  renderErr.Err.Error(),
+			Template: renderErr.Template,
+			Line:     int32(renderErr.Line),
+			Column:   int32(renderErr.Column),
+		}
+	}
+	return &godemopb.Error{Code: int32(codes.Internal), Message: err.Error()}
+}
+
+// toStatus converts err into the status returned by unary calls.
+func toStatus(err error) error {
+	e := toError(err)
+	msg := e.Message
+	if e.Template != "" {
+		msg = err.Error()
+	}
+	return status.Error(codes.Code(e.Code), msg)
+}
+
+// schema returns the named or inline schema of a request.
+func (sc scope) schema(req *godemopb.SchemaRequest) (*jsonschema.Schema, error) {
+	switch ref := req.Schema.(type) {
+	case *godemopb.SchemaRequest_SchemaName:
+		schema, ok := sc.schemas[ref.SchemaName]
+		if !ok {
+			return nil, errorf(codes.NotFound, "unknown schema %q", ref.SchemaName)
+		}
+		return schema, nil
+	case *godemopb.SchemaRequest_SchemaJson:
+		schema, err := sc.inlineSchema(ref.SchemaJson)
+		if err != nil {
+			return nil, errorf(codes.InvalidArgument, "invalid schema: %v", err)
+		}
+		return schema, nil
+	}
+	return nil, errorf(codes.InvalidArgument, "missing schema")
+}
+
+// decode decodes a JSON field of a request, keeping numbers as json.Number.
+func decode(field string, b []byte) (interface{}, error) {
+	if len(b) == 0 {
+		return nil, errorf(codes.InvalidArgument, "missing %s", field)
+	}
+	v, err := jsonutil.DecodeBytes(b)
+	if err != nil {
+		return nil, errorf(codes.InvalidArgument, "invalid %s: %v", field, err)
+	}
+	return v, nil
+}
+
+func (s *Server) validate(sc scope, req *godemopb.SchemaRequest) (*godemopb.ValidateResponse, error) {
+	if err := sc.checkSize(req); err != nil {
+		return nil, err
+	}
+	schema, err := sc.schema(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := decode("data", req.Data)
+	if err != nil {
+		return nil, err
+	}
+	// The validator can't be interrupted (see schemautil.ValidateCtx), so
+	// validation has no deadline; the message size limit bounds it.
+	violations, err := schemautil.Validate(schema, data)
+	if err != nil {
+		return nil, err
+	}
+	resp := &godemopb.ValidateResponse{Id: req.Id, Valid: len(violations) == 0}
+	for _, v := range violations {
+		resp.Errors = append(resp.Errors, &godemopb.Violation{
+			InstanceLocation: v.InstanceLocation,
+			KeywordLocation:  v.KeywordLocation,
+			Message:          v.Message,
+		})
+	}
+	return resp, nil
+}
+
+func (s *Server) applyDefaults(sc scope, req *godemopb.SchemaRequest) (*godemopb.ApplyDefaultsResponse, error) {
+	if err := sc.checkSize(req); err != nil {
+		return nil, err
+	}
+	schema, err := sc.schema(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := decode("data", req.Data)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(schemautil.ApplyDefaults(data, schema))
+	if err != nil {
+		return nil, err
+	}
+	return &godemopb.ApplyDefaultsResponse{Id: req.Id, Data: b}, nil
+}
+
+func (s *Server) render(ctx context.Context, sc scope, req *godemopb.RenderRequest) (*godemopb.RenderResponse, error) {
+	if err := sc.checkSize(req); err != nil {
+		return nil, err
+	}
+	data := pongo2.Context{}
+	if len(req.Context) > 0 {
+		v, err := decode("context", req.Context)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, errorf(codes.InvalidArgument, "context must be an object")
+		}
+		data = pongo2.Context(m)
+	}
+	mode := tpl.OutputMode(req.OutputMode)
+
+	var output string
+	var err error
+	switch ref := req.Template.(type) {
+	case *godemopb.RenderRequest_TemplateSource:
+		opts := s.opts
+		opts.TemplateDirs = sc.templateDirs
+		if mode != "" {
+			opts.OutputMode = mode
+		}
+		output, err = limits.Run(ctx, sc.limits.RenderTimeout, s.renders, func(ctx context.Context) (string, error) {
+			t, err := sc.cache.InlineTemplate(opts, ref.TemplateSource)
+			if err != nil {
+				return "", err
+			}
+			return t.Render(data, tpl.WithContext(ctx))
+		})
+	case *godemopb.RenderRequest_TemplateName:
+		if sc.templates == nil {
+			return nil, errorf(codes.NotFound, "no templates configured")
+		}
+		var opts []tpl.RenderOption
+		if mode != "" {
+			opts = append(opts, tpl.WithOutputMode(mode))
+		}
+		output, err = limits.Run(ctx, sc.limits.RenderTimeout, s.renders, func(ctx context.Context) (string, error) {
+			return sc.templates.Render(ref.TemplateName, data, append(opts, tpl.WithContext(ctx))...)
+		})
+	default:
+		return nil, errorf(codes.InvalidArgument, "missing template_name or template_source")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &godemopb.RenderResponse{Id: req.Id, Output: output}, nil
+}
+
+// Validate implements godemopb.DocumentsServer.
+func (s *Server) Validate(ctx context.Context, req *godemopb.SchemaRequest) (*godemopb.ValidateResponse, error) {
+	resp, err := s.validate(s.scope(ctx), req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return resp, nil
+}
+
+// ApplyDefaults implements godemopb.DocumentsServer.
+func (s *Server) ApplyDefaults(ctx context.Context, req *godemopb.SchemaRequest) (*godemopb.ApplyDefaultsResponse, error) {
+	resp, err := s.applyDefaults(s.scope(ctx), req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return resp, nil
+}
+
+// Render implements godemopb.DocumentsServer.
+func (s *Server) Render(ctx context.Context, req *godemopb.RenderRequest) (*godemopb.RenderResponse, error) {
+	resp, err := s.render(ctx, s.scope(ctx), req)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return resp, nil
+}
+
+// ValidateStream implements godemopb.DocumentsServer.
+func (s *Server) ValidateStream(stream godemopb.Documents_ValidateStreamServer) error {
+	return serveStream(stream.Recv, stream.Send, s.scope(stream.Context()), s.validate, func(id string, err error) *godemopb.ValidateResponse {
+		return &godemopb.ValidateResponse{Id: id, Error: toError(err)}
+	})
+}
+
+// ApplyDefaultsStream implements godemopb.DocumentsServer.
+func (s *Server) ApplyDefaultsStream(stream godemopb.Documents_ApplyDefaultsStreamServer) error {
+	return serveStream(stream.Recv, stream.Send, s.scope(stream.Context()), s.applyDefaults, func(id string, err error) *godemopb.ApplyDefaultsResponse {
+		return &godemopb.ApplyDefaultsResponse{Id: id, Error: toError(err)}
+	})
+}
+
+// RenderStream implements godemopb.DocumentsServer.
+func (s *Server) RenderStream(stream godemopb.Documents_RenderStreamServer) error {
+	render := func(sc scope, req *godemopb.RenderRequest) (*godemopb.RenderResponse, error) {
+		return s.render(stream.Context(), sc, req)
+	}
+	return serveStream(stream.Recv, stream.Send, s.scope(stream.Context()), render, func(id string, err error) *godemopb.RenderResponse {
+		return &godemopb.RenderResponse{Id: id, Error: toError(err)}
+	})
+}
+
+// request is a stream request; every request message has an id.
+type request interface{ GetId() string }
+
+// serveStream answers every request of a stream in order, within scope sc,
+// until the client closes it. Failed items are answered with failed(id, err).
+func serveStream[Req request, Resp any](recv func() (Req, error), send func(Resp) error, sc scope, handle func(scope, Req) (Resp, error), failed func(id string, err error) Resp) error {
+	for {
+		req, err := recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		resp, err := handle(sc, req)
+		if err != nil {
+			resp = failed(req.GetId(), err)
+		}
+		if err := send(resp); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,523 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"go-demo/pkg/auth"
+	"go-demo/pkg/cache"
+	"go-demo/pkg/grpcapi/godemopb"
+	"go-demo/pkg/limits"
+	tpl "go-demo/pkg/pongo2"
+	"go-demo/pkg/tenant"
+)
+
+const userSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string"},
+		"role": {"type": "string", "default": "member"},
+		"age": {"type": "integer", "minimum": 0}
+	},
+	"required": ["name"]
+}`
+
+func newTestClient(t *testing.T) godemopb.DocumentsClient {
+	t.Helper()
+	return godemopb.NewDocumentsClient(newTestConn(t))
+}
+
+func newTestConn(t *testing.T) *grpc.ClientConn {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"schemas/user.json":        userSchema,
+		"templates/greeting.txt":   "Hello {{ name }}!",
+		"templates/invoice@v2.txt": "Invoice v2 for {{ name }}",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	s, err := New(Config{SchemaDir: filepath.Join(dir, "schemas"), TemplateDir: filepath.Join(dir, "templates")})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return dial(t, s)
+}
+
+// dial serves s in memory and connects to it.
+func dial(t *testing.T, s *Server) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	g := s.NewGRPCServer()
+	go g.Serve(lis)
+	t.Cleanup(g.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func schemaName(name string) *godemopb.SchemaRequest_SchemaName {
+	return &godemopb.SchemaRequest_SchemaName{SchemaName: name}
+}
+
+func TestServer(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	resp, err := client.Validate(ctx, &godemopb.SchemaRequest{Schema: schemaName("user"), Data: []byte(`{"name": "Alice", "age": -1}`)})
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if resp.Valid || len(resp.Errors) != 1 || resp.Errors[0].InstanceLocation != "/age" {
+		t.Errorf("expected one violation at /age, got %v", resp)
+	}
+
+	defaults, err := client.ApplyDefaults(ctx, &godemopb.SchemaRequest{
+		Schema: &godemopb.SchemaRequest_SchemaJson{SchemaJson: []byte(`{"properties": {"role": {"default": "member"}}}`)},
+		Data:   []byte(`{"id": 9007199254740993}`),
+	})
+	if err != nil {
+		t.Fatalf("ApplyDefaults failed: %v", err)
+	}
+	if want := `{"id":9007199254740993,"role":"member"}`; string(defaults.Data) != want {
+		t.Errorf("expected %s, got %s", want, defaults.Data)
+	}
+
+	rendered, err := client.Render(ctx, &godemopb.RenderRequest{
+		Template: &godemopb.RenderRequest_TemplateName{TemplateName: "invoice@latest.txt"},
+		Context:  []byte(`{"name": "Bob"}`),
+	})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if rendered.Output != "Invoice v2 for Bob" {
+		t.Errorf("expected %q, got %q", "Invoice v2 for Bob", rendered.Output)
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		want codes.Code
+	}{
+		{"unknown schema", func() error {
+			_, err := client.Validate(ctx, &godemopb.SchemaRequest{Schema: schemaName("nope"), Data: []byte(`{}`)})
+			return err
+		}, codes.NotFound},
+		{"missing data", func() error {
+			_, err := client.ApplyDefaults(ctx, &godemopb.SchemaRequest{Schema: schemaName("user")})
+			return err
+		}, codes.InvalidArgument},
+		{"unknown template", func() error {
+			_, err := client.Render(ctx, &godemopb.RenderRequest{Template: &godemopb.RenderRequest_TemplateName{TemplateName: "nope.txt"}})
+			return err
+		}, codes.NotFound},
+		{"render error", func() error {
+			_, err := client.Render(ctx, &godemopb.RenderRequest{Template: &godemopb.RenderRequest_TemplateSource{TemplateSource: "{{ x|nope }}"}})
+			return err
+		}, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		if code := status.Code(tt.call()); code != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, code)
+		}
+	}
+}
+
+func TestStreams(t *testing.T) {
+	client := newTestClient(t)
+
+	stream, err := client.RenderStream(context.Background())
+	if err != nil {
+		t.Fatalf("RenderStream failed: %v", err)
+	}
+	requests := []*godemopb.RenderRequest{
+		{Id: "a", Template: &godemopb.RenderRequest_TemplateName{TemplateName: "greeting.txt"}, Context: []byte(`{"name": "Ann"}`)},
+		{Id: "b", Template: &godemopb.RenderRequest_TemplateSource{TemplateSource: "line one\n{{ x|nope }}"}},
+		{Id: "c", Template: &godemopb.RenderRequest_TemplateSource{TemplateSource: "<{{ s }}>"}, Context: []byte(`{"s": "&"}`), OutputMode: "html"},
+	}
+	for _, req := range requests {
+		if err := stream.Send(req); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatalf("CloseSend failed: %v", err)
+	}
+
+	var got []*godemopb.RenderResponse
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			break
+		}
+		got = append(got, resp)
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 responses, got %v", got)
+	}
+	if got[0].Id != "a" || got[0].Output != "Hello Ann!" || got[0].Error != nil {
+		t.Errorf("unexpected response for a: %v", got[0])
+	}
+	if e := got[1].Error; got[1].Id != "b" || e == nil || codes.Code(e.Code) != codes.InvalidArgument || e.Line != 2 {
+		t.Errorf("expected a render error on line 2 for b, got %v", got[1])
+	}
+	if got[2].Id != "c" || got[2].Output != "<&amp;>" {
+		t.Errorf("unexpected response for c: %v", got[2])
+	}
+
+	validate, err := client.ValidateStream(context.Background())
+	if err != nil {
+		t.Fatalf("ValidateStream failed: %v", err)
+	}
+	for i, data := range []string{`{"name": "A"}`, `{}`, `not json`} {
+		if err := validate.Send(&godemopb.SchemaRequest{Id: fmt.Sprint(i + 1), Schema: schemaName("user"), Data: []byte(data)}); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+	validate.CloseSend()
+	var results []string
+	for {
+		resp, err := validate.Recv()
+		if err != nil {
+			break
+		}
+		result := resp.Id + ":"
+		switch {
+		case resp.Error != nil:
+			result += codes.Code(resp.Error.Code).String()
+		case resp.Valid:
+			result += "valid"
+		default:
+			result += "invalid"
+		}
+		results = append(results, result)
+	}
+	if want := "1:valid 2:invalid 3:InvalidArgument"; strings.Join(results, " ") != want {
+		t.Errorf("expected %s, got %v", want, results)
+	}
+}
+
+func TestInlineTemplateFiles(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+	secret := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(secret, []byte("top secret"), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", secret, err)
+	}
+	inline := func(source string) *godemopb.RenderRequest {
+		return &godemopb.RenderRequest{Template: &godemopb.RenderRequest_TemplateSource{TemplateSource: source}, Context: []byte(`{"name": "Ada"}`)}
+	}
+
+	resp, err := client.Render(ctx, inline(`{% include "greeting.txt" %}`))
+	if err != nil || resp.Output != "Hello Ada!" {
+		t.Errorf("expected the named template to be included, got %v, %v", resp, err)
+	}
+	sources := []string{
+		`{% ssi "` + secret + `" %}`,
+		`{% include "` + secret + `" %}`,
+		`{% include "../../` + filepath.Base(filepath.Dir(secret)) + `/secret.txt" %}`,
+		`{% import "` + secret + `" x %}`,
+	}
+	for _, source := range sources {
+		resp, err := client.Render(ctx, inline(source))
+		if status.Code(err) != codes.InvalidArgument || strings.Contains(resp.GetOutput()+err.Error(), "top secret") {
+			t.Errorf("%s: expected InvalidArgument, got %v, %v", source, resp, err)
+		}
+	}
+
+	stream, err := client.RenderStream(ctx)
+	if err != nil {
+		t.Fatalf("RenderStream failed: %v", err)
+	}
+	for _, source := range sources {
+		if err := stream.Send(inline(source)); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+	stream.CloseSend()
+	for i := range sources {
+		resp, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		if resp.Error == nil || strings.Contains(resp.Output+resp.Error.Message, "top secret") {
+			t.Errorf("%s: expected an error, got %v", sources[i], resp)
+		}
+	}
+}
+
+func TestInlineSchemaFiles(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "secret.json")
+	if err := os.WriteFile(secret, []byte(`{"properties": {"token": {"default": "s3cr3t"}}}`), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", secret, err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "user.json"), []byte(userSchema), 0o644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+	s, err := New(Config{SchemaDir: dir, Cache: cache.New(cache.Config{Sandbox: true, SchemaFS: os.DirFS(dir)})})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx := context.Background()
+	inline := func(schema string) *godemopb.SchemaRequest {
+		return &godemopb.SchemaRequest{Schema: &godemopb.SchemaRequest_SchemaJson{SchemaJson: []byte(schema)}, Data: []byte(`{"name": "Ada"}`)}
+	}
+
+	// With and without a cache, inline schemas only reach the named ones.
+	for _, client := range []godemopb.DocumentsClient{newTestClient(t), godemopb.NewDocumentsClient(dial(t, s))} {
+		resp, err := client.ApplyDefaults(ctx, inline(`{"$ref": "user.json"}`))
+		if err != nil || !strings.Contains(string(resp.Data), `"role":"member"`) {
+			t.Errorf("expected the named schema to be referenced, got %v, %v", resp, err)
+		}
+		for _, ref := range []string{"file://" + filepath.ToSlash(secret), "../" + filepath.Base(filepath.Dir(secret)) + "/secret.json"} {
+			resp, err := client.ApplyDefaults(ctx, inline(`{"$ref": "`+ref+`"}`))
+			if status.Code(err) != codes.InvalidArgument || strings.Contains(string(resp.GetData()), "s3cr3t") {
+				t.Errorf("%s: expected InvalidArgument, got %v, %v", ref, resp, err)
+			}
+		}
+	}
+
+	if _, err := New(Config{Cache: cache.New(cache.Config{})}); err == nil {
+		t.Error("expected an error for a cache that isn't sandboxed")
+	}
+}
+
+func TestHealth(t *testing.T) {
+	client := healthpb.NewHealthClient(newTestConn(t))
+	for _, service := range []string{"", "godemo.v1.Documents"} {
+		resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatalf("Check(%q) failed: %v", service, err)
+		}
+		if resp.Status != healthpb.HealthCheckResponse_SERVING {
+			t.Errorf("expected %q to be SERVING, got %v", service, resp.Status)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	s, err := New(Config{Limits: limits.Config{RatePerSecond: 0.001, Burst: 2, RenderTimeout: time.Millisecond}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	conn := dial(t, s)
+	client := godemopb.NewDocumentsClient(conn)
+	ctx := context.Background()
+
+	n := strings.TrimSuffix(strings.Repeat("1,", 1000), ",")
+	_, err = client.Render(ctx, &godemopb.RenderRequest{
+		Template: &godemopb.RenderRequest_TemplateSource{TemplateSource: "{% for a in n %}{% for b in n %}{{ a }}{% endfor %}{% endfor %}"},
+		Context:  []byte(`{"n": [` + n + `]}`),
+	})
+	if code := status.Code(err); code != codes.DeadlineExceeded {
+		t.Errorf("expected DEADLINE_EXCEEDED for a slow render, got %v", err)
+	}
+
+	if _, err := client.Render(ctx, &godemopb.RenderRequest{Template: &godemopb.RenderRequest_TemplateSource{TemplateSource: "x"}}); err != nil {
+		t.Fatalf("The second request within the burst failed: %v", err)
+	}
+	_, err = client.Render(ctx, &godemopb.RenderRequest{Template: &godemopb.RenderRequest_TemplateSource{TemplateSource: "x"}})
+	if code := status.Code(err); code != codes.ResourceExhausted {
+		t.Errorf("expected RESOURCE_EXHAUSTED beyond the burst, got %v", err)
+	}
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Errorf("Health checks should not be rate limited: %v", err)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	keys := auth.StaticKeys{
+		{Principal: auth.Principal{Name: "billing", Operations: []string{auth.OpRender}}, Key: "billing-key"},
+	}
+	s, err := New(Config{Auth: keys})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	conn := dial(t, s)
+	client := godemopb.NewDocumentsClient(conn)
+	render := &godemopb.RenderRequest{Template: &godemopb.RenderRequest_TemplateSource{TemplateSource: "x"}}
+
+	tests := []struct {
+		name string
+		md   []string
+		call func(ctx context.Context) error
+		want codes.Code
+	}{
+		{"no key", nil, func(ctx context.Context) error {
+			_, err := client.Render(ctx, render)
+			return err
+		}, codes.Unauthenticated},
+		{"unknown key", []string{"authorization", "Bearer guess"}, func(ctx context.Context) error {
+			_, err := client.Render(ctx, render)
+			return err
+		}, codes.Unauthenticated},
+		{"operation not allowed", []string{"x-api-key", "billing-key"}, func(ctx context.Context) error {
+			_, err := client.Validate(ctx, &godemopb.SchemaRequest{})
+			return err
+		}, codes.PermissionDenied},
+		{"stream not allowed", []string{"x-api-key", "billing-key"}, func(ctx context.Context) error {
+			stream, err := client.ValidateStream(ctx)
+			if err != nil {
+				return err
+			}
+			_, err = stream.Recv()
+			return err
+		}, codes.PermissionDenied},
+		{"allowed", []string{"authorization", "Bearer billing-key"}, func(ctx context.Context) error {
+			_, err := client.Render(ctx, render)
+			return err
+		}, codes.OK},
+	}
+	for _, tt := range tests {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), tt.md...)
+		if code := status.Code(tt.call(ctx)); code != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, code)
+		}
+	}
+
+	if _, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Errorf("Health checks should not require a key: %v", err)
+	}
+}
+
+func TestCache(t *testing.T) {
+	c := cache.New(cache.Config{Sandbox: true})
+	s, err := New(Config{Cache: c})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	client := godemopb.NewDocumentsClient(dial(t, s))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Validate(ctx, &godemopb.SchemaRequest{
+			Schema: &godemopb.SchemaRequest_SchemaJson{SchemaJson: []byte(userSchema)},
+			Data:   []byte(`{"name": "Alice"}`),
+		}); err != nil {
+			t.Fatalf("Validate failed: %v", err)
+		}
+		resp, err := client.Render(ctx, &godemopb.RenderRequest{
+			Template: &godemopb.RenderRequest_TemplateSource{TemplateSource: "Hi {{ name }}"},
+			Context:  []byte(fmt.Sprintf(`{"name": "u%d"}`, i)),
+		})
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		if want := fmt.Sprintf("Hi u%d", i); resp.Output != want {
+			t.Errorf("expected %q, got %q", want, resp.Output)
+		}
+	}
+	if stats := c.Stats(); stats.Hits != 2 || stats.Misses != 2 || stats.Entries != 2 {
+		t.Errorf("expected the second schema and template to come from the cache, got %+v", stats)
+	}
+}
+
+func TestTenants(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"server/user.json": userSchema,
+		"acme/user.json":   `{"properties": {"role": {"default": "customer"}}}`,
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+	acme, err := tenant.New("acme", tenant.Config{SchemaDir: filepath.Join(dir, "acme")}, tpl.Options{})
+	if err != nil {
+		t.Fatalf("tenant.New failed: %v", err)
+	}
+	small, _ := tenant.New("small", tenant.Config{Limits: limits.Config{MaxBodyBytes: 64}}, tpl.Options{})
+	set, err := tenant.NewSet(acme, small)
+	if err != nil {
+		t.Fatalf("tenant.NewSet failed: %v", err)
+	}
+	keys := auth.StaticKeys{
+		{Principal: auth.Principal{Name: "acme", Tenant: "acme"}, Key: "acme-key"},
+		{Principal: auth.Principal{Name: "ops"}, Key: "ops-key"},
+	}
+	s, err := New(Config{SchemaDir: filepath.Join(dir, "server"), Auth: keys, Tenants: set})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	client := godemopb.NewDocumentsClient(dial(t, s))
+	req := &godemopb.SchemaRequest{Schema: schemaName("user"), Data: []byte(`{"name": "Ada"}`)}
+	large := &godemopb.SchemaRequest{Schema: schemaName("user"), Data: []byte(`{"name": "` + strings.Repeat("x", 100) + `"}`)}
+
+	tests := []struct {
+		name string
+		md   []string
+		req  *godemopb.SchemaRequest
+		want codes.Code
+		role string
+	}{
+		{"no tenant", []string{"x-api-key", "ops-key"}, req, codes.OK, "member"},
+		{"tenant metadata", []string{"x-api-key", "ops-key", "x-tenant", "acme"}, req, codes.OK, "customer"},
+		{"tenant of the key", []string{"x-api-key", "acme-key"}, req, codes.OK, "customer"},
+		{"another tenant", []string{"x-api-key", "acme-key", "x-tenant", "small"}, req, codes.PermissionDenied, ""},
+		{"unknown tenant", []string{"x-api-key", "ops-key", "x-tenant", "initech"}, req, codes.NotFound, ""},
+		{"no schemas in tenant", []string{"x-api-key", "ops-key", "x-tenant", "small"}, req, codes.NotFound, ""},
+		{"tenant message limit", []string{"x-api-key", "ops-key", "x-tenant", "small"}, large, codes.ResourceExhausted, ""},
+	}
+	for _, tt := range tests {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), tt.md...)
+		resp, err := client.ApplyDefaults(ctx, tt.req)
+		if code := status.Code(err); code != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+			continue
+		}
+		if tt.role != "" && !strings.Contains(string(resp.Data), `"role":"`+tt.role+`"`) {
+			t.Errorf("%s: expected role %s, got %s", tt.name, tt.role, resp.Data)
+		}
+	}
+
+	// Streams are scoped to the tenant they were opened with.
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "acme-key")
+	stream, err := client.ApplyDefaultsStream(ctx)
+	if err != nil {
+		t.Fatalf("ApplyDefaultsStream failed: %v", err)
+	}
+	if err := stream.Send(req); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	if !strings.Contains(string(resp.Data), `"role":"customer"`) {
+		t.Errorf("Expected the tenant's defaults, got %s", resp.Data)
+	}
+	stream.CloseSend()
+}
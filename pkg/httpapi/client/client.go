@@ -0,0 +1,146 @@
+// Code generated by "go-demo openapi -go-client"; DO NOT EDIT.
+
+// Package client is a client for the go-demo HTTP API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type ApplyDefaultsResponse struct {
+	// The document with defaults applied.
+	Data json.RawMessage `json:"data"`
+}
+
+type ErrorResponse struct {
+	// Class of the failure: decode_error, schema_compile_error, template_compile_error or render_error.
+	Code     string `json:"code,omitempty"`
+	Column   int64  `json:"column,omitempty"`
+	Error    string `json:"error"`
+	Line     int64  `json:"line,omitempty"`
+	Template string `json:"template,omitempty"`
+}
+
+type RenderRequest struct {
+	Context map[string]json.RawMessage `json:"context,omitempty"`
+	// Escape output for html, json, xml, markdown or text.
+	OutputMode string `json:"output_mode,omitempty"`
+	// Name of a template in the template directory, optionally versioned as in invoice@latest.txt.
+	Template string `json:"template,omitempty"`
+	// An inline template, instead of template.
+	TemplateSource string `json:"template_source,omitempty"`
+}
+
+type RenderResponse struct {
+	Output string `json:"output"`
+}
+
+type SchemaRequest struct {
+	// The JSON document.
+	Data json.RawMessage `json:"data"`
+	// Name of a schema in the schema directory, or an inline JSON Schema.
+	Schema json.RawMessage `json:"schema"`
+}
+
+type ValidateResponse struct {
+	Errors []Violation `json:"errors"`
+	Valid  bool        `json:"valid"`
+}
+
+type Violation struct {
+	// JSON Pointer to the offending value; empty for the root.
+	InstanceLocation string `json:"instanceLocation"`
+	// JSON Pointer to the failing keyword in the schema.
+	KeywordLocation string `json:"keywordLocation"`
+	Message         string `json:"message"`
+}
+
+// Client calls the API at BaseURL.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+
+	// APIKey is sent as a bearer token if set.
+	APIKey string
+}
+
+// NewClient returns a client for the API at baseURL, e.g.
+// "http://localhost:8080".
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), HTTPClient: http.DefaultClient}
+}
+
+// Error is returned for responses with a status other than 200.
+type Error struct {
+	StatusCode int
+	ErrorResponse
+}
+
+func (e *Error) Error() string {
+	if e.Template != "" {
+		return fmt.Sprintf("%d: %s:%d:%d: %s", e.StatusCode, e.Template, e.Line, e.Column, e.ErrorResponse.Error)
+	}
+	return fmt.Sprintf("%d: %s", e.StatusCode, e.ErrorResponse.Error)
+}
+
+// ApplyDefaults calls POST /apply-defaults: Fill in the schema defaults missing from a document.
+func (c *Client) ApplyDefaults(ctx context.Context, req *SchemaRequest) (*ApplyDefaultsResponse, error) {
+	var resp ApplyDefaultsResponse
+	if err := c.post(ctx, "/apply-defaults", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Render calls POST /render: Render a named or inline template.
+func (c *Client) Render(ctx context.Context, req *RenderRequest) (*RenderResponse, error) {
+	var resp RenderResponse
+	if err := c.post(ctx, "/render", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Validate calls POST /validate: Validate a document against a schema.
+func (c *Client) Validate(ctx context.Context, req *SchemaRequest) (*ValidateResponse, error) {
+	var resp ValidateResponse
+	if err := c.post(ctx, "/validate", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) post(ctx context.Context, path string, req, resp interface{}) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	httpResp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+	dec := json.NewDecoder(httpResp.Body)
+	dec.UseNumber()
+	if httpResp.StatusCode != http.StatusOK {
+		apiErr := &Error{StatusCode: httpResp.StatusCode}
+		if err := dec.Decode(&apiErr.ErrorResponse); err != nil {
+			apiErr.ErrorResponse.Error = httpResp.Status
+		}
+		return apiErr
+	}
+	return dec.Decode(resp)
+}
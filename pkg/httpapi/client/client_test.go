@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-demo/pkg/httpapi"
+)
+
+func TestClient(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "greeting.txt"), []byte("Hello {{ name }}!"), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	s, err := httpapi.New(httpapi.Config{TemplateDir: dir})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	c := NewClient(ts.URL)
+	ctx := context.Background()
+
+	schema := json.RawMessage(`{"properties": {"n": {"type": "integer", "default": 12345678901234567890}}}`)
+	defaults, err := c.ApplyDefaults(ctx, &SchemaRequest{Schema: schema, Data: json.RawMessage(`{}`)})
+	if err != nil {
+		t.Fatalf("ApplyDefaults failed: %v", err)
+	}
+	if string(defaults.Data) != `{"n":12345678901234567890}` {
+		t.Errorf("unexpected data: %s", defaults.Data)
+	}
+
+	result, err := c.Validate(ctx, &SchemaRequest{Schema: schema, Data: json.RawMessage(`{"n": "x"}`)})
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0].InstanceLocation != "/n" {
+		t.Errorf("expected one violation at /n, got %+v", result)
+	}
+
+	rendered, err := c.Render(ctx, &RenderRequest{Template: "greeting.txt", Context: map[string]json.RawMessage{"name": json.RawMessage(`"Ann"`)}})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if rendered.Output != "Hello Ann!" {
+		t.Errorf("expected %q, got %q", "Hello Ann!", rendered.Output)
+	}
+
+	_, err = c.Render(ctx, &RenderRequest{TemplateSource: "{{ x|nope }}"})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnprocessableEntity || apiErr.Line != 1 {
+		t.Errorf("expected a 422 error on line 1, got %v", err)
+	}
+}
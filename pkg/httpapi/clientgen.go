@@ -0,0 +1,239 @@
+package httpapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// GenerateClient returns the source of a Go client for the API, generated
+// from the OpenAPI document: one struct per component schema and one method
+// per operation. Values the document leaves untyped, such as documents and
+// inline schemas, are json.RawMessage so large integers stay exact.
+func GenerateClient(pkg string) ([]byte, error) {
+	spec := OpenAPI()
+	data := clientData{Package: pkg}
+
+	schemas := spec["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	for _, name := range sortedNames(schemas) {
+		schema := schemas[name].(map[string]interface{})
+		st := clientStruct{Name: name}
+		props, _ := schema["properties"].(map[string]interface{})
+		required := map[string]bool{}
+		if list, ok := schema["required"].([]string); ok {
+			for _, p := range list {
+				required[p] = true
+			}
+		}
+		for _, prop := range sortedNames(props) {
+			ps := props[prop].(map[string]interface{})
+			desc, _ := ps["description"].(string)
+			tag := prop
+			if !required[prop] {
+				tag += ",omitempty"
+			}
+			st.Fields = append(st.Fields, clientField{Name: goName(prop), Type: goType(ps), Tag: tag, Doc: desc})
+		}
+		data.Types = append(data.Types, st)
+	}
+
+	paths := spec["paths"].(map[string]interface{})
+	for _, path := range sortedNames(paths) {
+		op := paths[path].(map[string]interface{})["post"].(map[string]interface{})
+		data.Methods = append(data.Methods, clientMethod{
+			Name:     goName(op["operationId"].(string)),
+			Path:     path,
+			Summary:  op["summary"].(string),
+			Request:  refName(op["requestBody"].(map[string]interface{})["content"]),
+			Response: refName(op["responses"].(map[string]interface{})["200"].(map[string]interface{})["content"]),
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := clientTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated client: %w", err)
+	}
+	return src, nil
+}
+
+type clientData struct {
+	Package string
+	Types   []clientStruct
+	Methods []clientMethod
+}
+
+type clientStruct struct {
+	Name   string
+	Fields []clientField
+}
+
+type clientField struct {
+	Name, Type, Tag, Doc string
+}
+
+type clientMethod struct {
+	Name, Path, Summary, Request, Response string
+}
+
+func sortedNames(m map[string]interface{}) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// goName converts a JSON name such as output_mode or instanceLocation into
+// an exported Go name.
+func goName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if r == '_' || r == '-' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// goType returns the Go type of a property schema.
+func goType(schema map[string]interface{}) string {
+	if ref, ok := schema["$ref"].(string); ok {
+		return "*" + ref[strings.LastIndex(ref, "/")+1:]
+	}
+	if allOf, ok := schema["allOf"].([]interface{}); ok && len(allOf) == 1 {
+		return goType(allOf[0].(map[string]interface{}))
+	}
+	switch schema["type"] {
+	case "string":
+		if schema["contentEncoding"] == "base64" {
+			return "[]byte"
+		}
+		return "string"
+	case "boolean":
+		return "bool"
+	case "integer":
+		return "int64"
+	case "number":
+		return "json.Number"
+	case "array":
+		return "[]" + strings.TrimPrefix(goType(schema["items"].(map[string]interface{})), "*")
+	case "object":
+		if ap, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+			return "map[string]" + goType(ap)
+		}
+	}
+	return "json.RawMessage"
+}
+
+// refName returns the schema name referenced by a content map.
+func refName(content interface{}) string {
+	schema := content.(map[string]interface{})["application/json"].(map[string]interface{})["schema"].(map[string]interface{})
+	return strings.TrimPrefix(goType(schema), "*")
+}
+
+var clientTemplate = template.Must(template.New("client").Parse(`// Code generated by "go-demo openapi -go-client"; DO NOT EDIT.
+
+// Package {{.Package}} is a client for the go-demo HTTP API.
+package {{.Package}}
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+{{range .Types}}
+type {{.Name}} struct {
+{{- range .Fields}}
+{{- if .Doc}}
+	// {{.Doc}}
+{{- end}}
+	{{.Name}} {{.Type}} ` + "`" + `json:"{{.Tag}}"` + "`" + `
+{{- end}}
+}
+{{end}}
+// Client calls the API at BaseURL.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+
+	// APIKey is sent as a bearer token if set.
+	APIKey string
+}
+
+// NewClient returns a client for the API at baseURL, e.g.
+// "http://localhost:8080".
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), HTTPClient: http.DefaultClient}
+}
+
+// Error is returned for responses with a status other than 200.
+type Error struct {
+	StatusCode int
+	ErrorResponse
+}
+
+func (e *Error) Error() string {
+	if e.Template != "" {
+		return fmt.Sprintf("%d: %s:%d:%d: %s", e.StatusCode, e.Template, e.Line, e.Column, e.ErrorResponse.Error)
+	}
+	return fmt.Sprintf("%d: %s", e.StatusCode, e.ErrorResponse.Error)
+}
+{{range .Methods}}
+// {{.Name}} calls POST {{.Path}}: {{.Summary}}.
+func (c *Client) {{.Name}}(ctx context.Context, req *{{.Request}}) (*{{.Response}}, error) {
+	var resp {{.Response}}
+	if err := c.post(ctx, "{{.Path}}", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+{{end}}
+func (c *Client) post(ctx context.Context, path string, req, resp interface{}) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	httpResp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+	dec := json.NewDecoder(httpResp.Body)
+	dec.UseNumber()
+	if httpResp.StatusCode != http.StatusOK {
+		apiErr := &Error{StatusCode: httpResp.StatusCode}
+		if err := dec.Decode(&apiErr.ErrorResponse); err != nil {
+			apiErr.ErrorResponse.Error = httpResp.Status
+		}
+		return apiErr
+	}
+	return dec.Decode(resp)
+}
+`))
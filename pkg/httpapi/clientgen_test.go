@@ -0,0 +1,35 @@
+package httpapi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGeneratedClientUpToDate(t *testing.T) {
+	src, err := GenerateClient("client")
+	if err != nil {
+		t.Fatalf("GenerateClient failed: %v", err)
+	}
+	checkedIn, err := os.ReadFile(filepath.Join("client", "client.go"))
+	if err != nil {
+		t.Fatalf("Failed to read the generated client: %v", err)
+	}
+	if string(src) != string(checkedIn) {
+		t.Error("client/client.go is out of date; run go generate ./pkg/httpapi")
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"output_mode":      "OutputMode",
+		"instanceLocation": "InstanceLocation",
+		"applyDefaults":    "ApplyDefaults",
+		"data":             "Data",
+	}
+	for in, want := range tests {
+		if got := goName(in); got != want {
+			t.Errorf("goName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
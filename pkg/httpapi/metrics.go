@@ -0,0 +1,167 @@
+package httpapi
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"go-demo/pkg/cache"
+	prom "go-demo/pkg/metrics"
+	tpl "go-demo/pkg/pongo2"
+)
+
+// metrics are the server's statistics, written by GET /metrics in the
+// Prometheus text format.
+type metrics struct {
+	mu sync.Mutex
+
+	requests         map[[2]string]uint64 // by path and status code
+	requestDurations map[string]*prom.Histogram
+	validations      map[string]uint64    // by result: valid, invalid or error
+	renders          map[[2]string]uint64 // by source (named or inline) and result
+	renderDurations  map[string]*prom.Histogram
+	inlineSchemas    map[string]uint64 // compilations by result
+	compileFailures  uint64            // templates that failed to compile
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		requests:         map[[2]string]uint64{},
+		requestDurations: map[string]*prom.Histogram{},
+		validations:      map[string]uint64{},
+		renders:          map[[2]string]uint64{},
+		renderDurations:  map[string]*prom.Histogram{},
+		inlineSchemas:    map[string]uint64{},
+	}
+}
+
+func (m *metrics) observeRequest(path string, status int, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[[2]string{path, fmt.Sprint(status)}]++
+	h, ok := m.requestDurations[path]
+	if !ok {
+		h = &prom.Histogram{}
+		m.requestDurations[path] = h
+	}
+	h.Observe(d.Seconds())
+}
+
+func (m *metrics) observeValidation(result string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.validations[result]++
+}
+
+func (m *metrics) observeInlineSchema(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inlineSchemas[resultLabel(err)]++
+}
+
+// observeRender records a render of a named or inline template.
+func (m *metrics) observeRender(source string, d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.renders[[2]string{source, resultLabel(err)}]++
+	h, ok := m.renderDurations[source]
+	if !ok {
+		h = &prom.Histogram{}
+		m.renderDurations[source] = h
+	}
+	h.Observe(d.Seconds())
+	var renderErr *tpl.RenderError
+	if errors.As(err, &renderErr) && renderErr.Compile {
+		m.compileFailures++
+	}
+}
+
+func resultLabel(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
+
+// write writes the metrics in the Prometheus text exposition format, with
+// those of the compile cache if there is one.
+func (m *metrics) write(w io.Writer, schemas, templates int, c *cache.Cache) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	gauge := func(name, help string, v int) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %d\n", name, help, name, name, v)
+	}
+	gauge("godemo_schemas_loaded", "Named schemas compiled at startup.", schemas)
+	gauge("godemo_templates_loaded", "Named templates compiled at startup.", templates)
+
+	prom.WriteCounter(w, "godemo_http_requests_total", "HTTP requests by path and status code.", []string{"path", "code"}, m.requests)
+	prom.WriteHistograms(w, "godemo_http_request_duration_seconds", "HTTP request latencies by path.", "path", m.requestDurations)
+	validations := map[[2]string]uint64{}
+	for result, n := range m.validations {
+		validations[[2]string{result}] = n
+	}
+	prom.WriteCounter(w, "godemo_validations_total", "Validated documents by result: valid, invalid or error.", []string{"result"}, validations)
+	prom.WriteCounter(w, "godemo_renders_total", "Template renders by source (named or inline) and result.", []string{"source", "result"}, m.renders)
+	prom.WriteHistograms(w, "godemo_render_duration_seconds", "Template render latencies by source.", "source", m.renderDurations)
+	inline := map[[2]string]uint64{}
+	for result, n := range m.inlineSchemas {
+		inline[[2]string{result}] = n
+	}
+	prom.WriteCounter(w, "godemo_inline_schema_compilations_total", "Compilations of inline schemas by result.", []string{"result"}, inline)
+	fmt.Fprintf(w, "# HELP godemo_template_compile_failures_total Templates that failed to compile.\n# TYPE godemo_template_compile_failures_total counter\ngodemo_template_compile_failures_total %d\n", m.compileFailures)
+
+	if c != nil {
+		stats := c.Stats()
+		counter := func(name, help string, v uint64) {
+			fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, v)
+		}
+		counter("godemo_cache_hits_total", "Inline schemas and templates found compiled in the cache.", stats.Hits)
+		counter("godemo_cache_misses_total", "Inline schemas and templates compiled because the cache didn't have them.", stats.Misses)
+		counter("godemo_cache_evictions_total", "Cache entries dropped for space or age.", stats.Evictions)
+		gauge("godemo_cache_entries", "Compiled schemas and templates in the cache.", stats.Entries)
+		counter("godemo_cache_backend_hits_total", "Cache misses compiled from what the shared cache backend had stored.", stats.BackendHits)
+		counter("godemo_cache_backend_errors_total", "Failed calls to the shared cache backend.", stats.BackendErrors)
+	}
+}
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// instrument records the status and latency of every request. Unknown paths
+// are counted as "other" to bound the number of series.
+func (s *Server) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		path := r.URL.Path
+		if _, pattern := s.mux.Handler(r); pattern == "" || pattern == "/" {
+			path = "other"
+		}
+		s.metrics.observeRequest(path, rec.status, time.Since(start))
+	})
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	templates := 0
+	if s.templates != nil {
+		templates = len(s.templates.Names())
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	s.metrics.write(w, len(s.schemas), templates, s.cache)
+}
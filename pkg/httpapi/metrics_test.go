@@ -0,0 +1,90 @@
+package httpapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-demo/pkg/cache"
+)
+
+func TestMetrics(t *testing.T) {
+	ts := newTestServer(t)
+	post(t, ts.URL+"/validate", `{"schema": "user", "data": {"name": "Alice"}}`)
+	post(t, ts.URL+"/validate", `{"schema": "user", "data": {}}`)
+	post(t, ts.URL+"/validate", `{"schema": "order", "data": {}}`)
+	post(t, ts.URL+"/validate", `{"schema": {"type": 1}, "data": {}}`)
+	post(t, ts.URL+"/render", `{"template": "greeting.txt", "context": {"name": "Alice"}}`)
+	post(t, ts.URL+"/render", `{"template_source": "{% if %}"}`)
+	post(t, ts.URL+"/nope", `{}`)
+
+	resp, err := http.Get(ts.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics failed: %v", err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read metrics: %v", err)
+	}
+	body := string(b)
+
+	for _, want := range []string{
+		"godemo_schemas_loaded 1\n",
+		"godemo_templates_loaded 2\n",
+		`godemo_http_requests_total{path="/validate",code="200"} 2` + "\n",
+		`godemo_http_requests_total{path="/validate",code="404"} 1` + "\n",
+		`godemo_http_requests_total{path="other",code="404"} 1` + "\n",
+		`godemo_http_request_duration_seconds_count{path="/validate"} 4` + "\n",
+		`godemo_http_request_duration_seconds_bucket{path="/render",le="+Inf"} 2` + "\n",
+		`godemo_validations_total{result="error"} 2` + "\n",
+		`godemo_validations_total{result="invalid"} 1` + "\n",
+		`godemo_validations_total{result="valid"} 1` + "\n",
+		`godemo_renders_total{source="inline",result="error"} 1` + "\n",
+		`godemo_renders_total{source="named",result="ok"} 1` + "\n",
+		`godemo_render_duration_seconds_count{source="named"} 1` + "\n",
+		`godemo_inline_schema_compilations_total{result="error"} 1` + "\n",
+		"godemo_template_compile_failures_total 1\n",
+		"# TYPE godemo_render_duration_seconds histogram\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected the metrics to contain %q, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestCacheMetrics(t *testing.T) {
+	s, err := New(Config{Cache: cache.New(cache.Config{Sandbox: true})})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	for i := 0; i < 3; i++ {
+		post(t, ts.URL+"/validate", `{"schema": {"type": "object"}, "data": {}}`)
+		post(t, ts.URL+"/render", `{"template_source": "Hi {{ name }}", "context": {"name": "Alice"}}`)
+	}
+
+	resp, err := http.Get(ts.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics failed: %v", err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read metrics: %v", err)
+	}
+	for _, want := range []string{
+		"godemo_cache_hits_total 4\n",
+		"godemo_cache_misses_total 2\n",
+		"godemo_cache_evictions_total 0\n",
+		"godemo_cache_entries 2\n",
+		"godemo_cache_backend_hits_total 0\n",
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("expected the metrics to contain %q, got:\n%s", want, b)
+		}
+	}
+}
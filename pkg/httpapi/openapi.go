@@ -0,0 +1,99 @@
+package httpapi
+
+//go:generate go run ../.. openapi -go-client client/client.go -package client
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/tenant"
+)
+
+// operation describes an endpoint in the OpenAPI document.
+type operation struct {
+	path, id, summary string
+	request, response interface{}
+
+	// errors lists the error statuses besides those of every operation:
+	// 400, 401, 403, 405, 413 and 429.
+	errors []int
+}
+
+var operations = []operation{
+	{"/validate", "validate", "Validate a document against a schema", schemaRequest{}, validateResponse{}, []int{http.StatusNotFound}},
+	{"/apply-defaults", "applyDefaults", "Fill in the schema defaults missing from a document", schemaRequest{}, applyDefaultsResponse{}, []int{http.StatusNotFound}},
+	{"/render", "render", "Render a named or inline template", renderRequest{}, renderResponse{}, []int{http.StatusNotFound, http.StatusUnprocessableEntity, http.StatusServiceUnavailable}},
+}
+
+// OpenAPI returns the OpenAPI 3.1 document describing the API, served at
+// GET /openapi.json. Its schemas are generated from the request and response
+// types the handlers use.
+func OpenAPI() map[string]interface{} {
+	r := &schemautil.Reflector{RefPrefix: "#/components/schemas/"}
+	content := func(t interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": r.Reflect(reflect.TypeOf(t))},
+		}
+	}
+
+	// Servers may run without authentication, hence the empty requirement.
+	security := []interface{}{
+		map[string]interface{}{},
+		map[string]interface{}{"bearer": []string{}},
+		map[string]interface{}{"apiKey": []string{}},
+	}
+	tenantHeader := map[string]interface{}{
+		"name":        tenant.Header,
+		"in":          "header",
+		"description": "Tenant whose schemas, templates and limits to use; keys bound to a tenant use theirs",
+		"schema":      map[string]interface{}{"type": "string"},
+	}
+	paths := map[string]interface{}{}
+	for _, op := range operations {
+		responses := map[string]interface{}{
+			"200": map[string]interface{}{"description": "OK", "content": content(op.response)},
+		}
+		for _, status := range append([]int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusMethodNotAllowed, http.StatusRequestEntityTooLarge, http.StatusTooManyRequests}, op.errors...) {
+			responses[strconv.Itoa(status)] = map[string]interface{}{
+				"description": http.StatusText(status),
+				"content":     content(errorResponse{}),
+			}
+		}
+		paths[op.path] = map[string]interface{}{
+			"post": map[string]interface{}{
+				"operationId": op.id,
+				"summary":     op.summary,
+				"parameters":  []interface{}{tenantHeader},
+				"requestBody": map[string]interface{}{"required": true, "content": content(op.request)},
+				"responses":   responses,
+				"security":    security,
+			},
+		}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.1.0",
+		"info": map[string]interface{}{
+			"title":   "go-demo API",
+			"version": "1.0.0",
+			"description": "Validates JSON documents against JSON Schemas, applies schema defaults and renders pongo2 templates. " +
+				"Schemas and templates are referenced by name or given inline.",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": r.Defs,
+			"securitySchemes": map[string]interface{}{
+				"bearer": map[string]interface{}{"type": "http", "scheme": "bearer", "description": "API key as a bearer token"},
+				"apiKey": map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+			},
+		},
+	}
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if allowGet(w, r) {
+		writeJSON(w, http.StatusOK, OpenAPI())
+	}
+}
@@ -0,0 +1,78 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestOpenAPI(t *testing.T) {
+	ts := newTestServer(t)
+
+	resp, err := http.Get(ts.URL + "/openapi.json")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	var spec struct {
+		OpenAPI string
+		Paths   map[string]map[string]struct {
+			OperationID string
+			Responses   map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal(b, &spec); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if spec.OpenAPI != "3.1.0" || len(spec.Paths) != 3 {
+		t.Fatalf("unexpected document: %s", b)
+	}
+	if op := spec.Paths["/render"]["post"]; op.OperationID != "render" || op.Responses["422"] == nil {
+		t.Errorf("expected the render operation with a 422 response, got %+v", op)
+	}
+
+	// The component schemas must describe what the handlers actually send.
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("openapi.json", strings.NewReader(string(b))); err != nil {
+		t.Fatalf("Failed to add document: %v", err)
+	}
+	tests := []struct {
+		schema, path, body string
+	}{
+		{"ValidateResponse", "/validate", `{"schema": "user", "data": {"age": -1}}`},
+		{"ApplyDefaultsResponse", "/apply-defaults", `{"schema": "user", "data": {}}`},
+		{"RenderResponse", "/render", `{"template": "greeting.txt", "context": {"name": "Alice"}}`},
+		{"ErrorResponse", "/render", `{"template_source": "{{ x|nope }}"}`},
+	}
+	for _, tt := range tests {
+		schema, err := compiler.Compile("openapi.json#/components/schemas/" + tt.schema)
+		if err != nil {
+			t.Fatalf("Failed to compile %s: %v", tt.schema, err)
+		}
+		_, body := post(t, ts.URL+tt.path, tt.body)
+		var v interface{}
+		if err := json.Unmarshal([]byte(body), &v); err != nil {
+			t.Fatalf("Invalid response %s: %v", body, err)
+		}
+		if err := schema.Validate(v); err != nil {
+			t.Errorf("%s doesn't match %s: %v", body, tt.schema, err)
+		}
+	}
+
+	postResp, err := http.Post(ts.URL+"/openapi.json", "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	postResp.Body.Close()
+	if postResp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for POST, got %d", postResp.StatusCode)
+	}
+}
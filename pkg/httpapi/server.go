@@ -0,0 +1,530 @@
+// Package httpapi exposes schema validation, default application and template
+// rendering as a JSON-over-HTTP API:
+//
+//	POST /validate        {"schema": "user", "data": {...}}
+//	POST /apply-defaults  {"schema": {...inline schema...}, "data": {...}}
+//	POST /render          {"template": "invoice.txt", "context": {...}}
+//	POST /render          {"template_source": "Hello {{ name }}", "context": {...}}
+//	GET  /openapi.json    the OpenAPI 3.1 document of the API
+//	GET  /healthz         liveness: 200 while the process serves requests
+//	GET  /readyz          readiness: 200 once loaded, 503 while shutting down
+//	GET  /metrics         request, validation and render statistics for Prometheus
+//
+// Schemas are referenced by name (their path below Config.SchemaDir without
+// ".json") or given inline; templates by their name in Config.TemplateDir or
+// inline as template_source.
+//
+// With Config.Auth set, the POST endpoints require an API key sent as
+// "Authorization: Bearer KEY" or "X-API-Key: KEY"; the GET endpoints stay
+// open for probes and scrapers.
+//
+// With Config.Tenants set, the POST endpoints of a tenant's requests use the
+// tenant's schemas, templates, cache and limits instead of the server's (see
+// pkg/tenant).
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/flosch/pongo2/v6"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"go-demo/pkg/auth"
+	"go-demo/pkg/cache"
+	"go-demo/pkg/errcode"
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/jsonutil"
+	"go-demo/pkg/limits"
+	tpl "go-demo/pkg/pongo2"
+	"go-demo/pkg/tenant"
+)
+
+// Config configures a Server.
+type Config struct {
+	// SchemaDir holds the named schemas (*.json). Optional.
+	SchemaDir string
+
+	// TemplateDir holds the named templates, compiled at startup. Inline
+	// templates can include these, and no other files. Optional.
+	TemplateDir string
+
+	// Options configures template rendering.
+	Options tpl.Options
+
+	// Limits bounds request sizes, request rates per client, render times
+	// and concurrent renders. The zero value limits request bodies to 10 MB.
+	Limits limits.Config
+
+	// Auth authenticates API requests. Nil leaves the API open.
+	Auth auth.Authenticator
+
+	// Cache keeps inline schemas and templates compiled, so repeated ones
+	// are compiled once. It may be shared with other servers. Nil compiles
+	// them on every request. Clients send the inline schemas, so it must be
+	// sandboxed, with the schemas of SchemaDir as its SchemaFS: New fails
+	// otherwise. Without a cache, inline schemas are compiled sandboxed too.
+	Cache *cache.Cache
+
+	// Tenants are the namespaces requests can be scoped to, by the tenant
+	// of their API key or the X-Tenant header. Nil serves no tenants.
+	Tenants *tenant.Set
+}
+
+// Server handles API requests. Create it with New.
+type Server struct {
+	opts      tpl.Options
+	schemas   map[string]*jsonschema.Schema
+	templates *tpl.Registry
+	mux       *http.ServeMux
+	handler   http.Handler
+	metrics   *metrics
+	limits    limits.Config
+	limiter   *limits.RateLimiter
+	renders   *limits.Semaphore
+	auth      auth.Authenticator
+	cache     *cache.Cache
+	tenants   *tenant.Set
+
+	// schemaFS holds the schemas inline schemas can reference.
+	schemaFS fs.FS
+
+	// templateDirs are the directories inline templates load files from.
+	templateDirs []string
+
+	// ready is cleared when the server starts shutting down.
+	ready atomic.Bool
+}
+
+// New loads the named schemas and templates of cfg. It fails if any of them
+// doesn't compile.
+func New(cfg Config) (*Server, error) {
+	s := &Server{
+		opts:    cfg.Options,
+		schemas: make(map[string]*jsonschema.Schema),
+		metrics: newMetrics(),
+		limits:  cfg.Limits,
+		limiter: cfg.Limits.NewRateLimiter(),
+		renders: cfg.Limits.NewSemaphore(),
+		auth:    cfg.Auth,
+		cache:   cfg.Cache,
+		tenants: cfg.Tenants,
+	}
+
+	if cfg.Cache != nil && !cfg.Cache.Sandboxed() {
+		return nil, errors.New("the cache of inline schemas must be sandboxed")
+	}
+	if cfg.SchemaDir != "" {
+		s.schemaFS = os.DirFS(cfg.SchemaDir)
+		schemas, err := schemautil.CompileDir(cfg.SchemaDir)
+		if err != nil {
+			return nil, fmt.Errorf("load schemas: %w", err)
+		}
+		s.schemas = schemas
+	}
+
+	if cfg.TemplateDir != "" {
+		reg, err := tpl.LoadDir(cfg.TemplateDir, cfg.Options)
+		if err != nil {
+			return nil, err
+		}
+		s.templates = reg
+		s.templateDirs = []string{cfg.TemplateDir}
+	}
+
+	s.mux = http.NewServeMux()
+	s.mux.Handle("/validate", s.guard(auth.OpValidate, s.handleValidate))
+	s.mux.Handle("/apply-defaults", s.guard(auth.OpApplyDefaults, s.handleApplyDefaults))
+	s.mux.Handle("/render", s.guard(auth.OpRender, s.handleRender))
+	s.mux.HandleFunc("/openapi.json", s.handleOpenAPI)
+	s.mux.HandleFunc("/healthz", s.handleHealthz)
+	s.mux.HandleFunc("/readyz", s.handleReadyz)
+	s.mux.HandleFunc("/metrics", s.handleMetrics)
+	s.handler = s.instrument(s.mux)
+	s.ready.Store(true)
+	return s, nil
+}
+
+// ListenAndServe serves the API on addr until ctx is cancelled.
+func ListenAndServe(ctx context.Context, addr string, cfg Config) error {
+	s, err := New(cfg)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Addr: addr, Handler: s}
+	go func() {
+		<-ctx.Done()
+		s.ready.Store(false)
+		_ = srv.Shutdown(context.Background())
+	}()
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// allowGet answers requests other than GET and HEAD with 405.
+func allowGet(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return true
+	}
+	w.Header().Set("Allow", "GET, HEAD")
+	writeError(w, errorf(http.StatusMethodNotAllowed, "method %s not allowed", r.Method))
+	return false
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if allowGet(w, r) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	}
+}
+
+// readyResponse is the body of /readyz.
+type readyResponse struct {
+	Status    string `json:"status"`
+	Schemas   int    `json:"schemas"`
+	Templates int    `json:"templates"`
+}
+
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	resp := readyResponse{Status: "ready", Schemas: len(s.schemas)}
+	if s.templates != nil {
+		resp.Templates = len(s.templates.Names())
+	}
+	status := http.StatusOK
+	if !s.ready.Load() {
+		resp.Status = "shutting down"
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, resp)
+}
+
+// apiError is an error with an HTTP status, written as {"error": "..."}.
+type apiError struct {
+	status int
+	err    error
+}
+
+func (e *apiError) Error() string { return e.err.Error() }
+
+func (e *apiError) Unwrap() error { return e.err }
+
+func errorf(status int, format string, args ...interface{}) *apiError {
+	return &apiError{status: status, err: fmt.Errorf(format, args...)}
+}
+
+// errorResponse is the body of every failed request. Code is set for the
+// failures errcode classifies, Line and Column for template errors.
+type errorResponse struct {
+	Error    string `json:"error"`
+	Code     string `json:"code,omitempty" description:"Class of the failure: decode_error, schema_compile_error, template_compile_error or render_error."`
+	Template string `json:"template,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	resp := errorResponse{Error: err.Error(), Code: string(errcode.Of(err))}
+
+	var apiErr *apiError
+	var renderErr *tpl.RenderError
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.status
+	case errors.Is(err, tpl.ErrTemplateNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, limits.ErrTimeout):
+		status = http.StatusServiceUnavailable
+		resp.Error = "render timed out"
+	case errors.As(err, &renderErr):
+		status = http.StatusUnprocessableEntity
+		resp = errorResponse{Error: renderErr.Err.Error(), Code: string(renderErr.Code()), Template: renderErr.Template, Line: renderErr.Line, Column: renderErr.Column}
+	}
+	writeJSON(w, status, resp)
+}
+
+// guard authenticates requests for operation op, resolves their tenant and
+// applies the rate limits. Unauthenticated requests are answered with 401,
+// requests for operations or tenants the key doesn't allow with 403, requests
+// for unknown tenants with 404, and requests beyond the client's or the
+// tenant's rate limit with 429. Clients are identified by their key name, or
+// without Config.Auth by their IP address.
+func (s *Server) guard(op string, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			client = r.RemoteAddr
+		}
+		var p *auth.Principal
+		if s.auth != nil {
+			token := auth.Token(r.Header.Get("Authorization"), r.Header.Get("X-API-Key"))
+			p, err = auth.Authorize(r.Context(), s.auth, token, op)
+			switch {
+			case errors.Is(err, auth.ErrUnauthenticated):
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, &apiError{status: http.StatusUnauthorized, err: err})
+				return
+			case errors.Is(err, auth.ErrForbidden):
+				writeError(w, &apiError{status: http.StatusForbidden, err: err})
+				return
+			case err != nil:
+				writeError(w, fmt.Errorf("authenticate: %w", err))
+				return
+			}
+			client = "key:" + p.Name
+		}
+		ns, err := s.tenants.Resolve(p, r.Header.Get(tenant.Header))
+		switch {
+		case errors.Is(err, auth.ErrForbidden):
+			writeError(w, &apiError{status: http.StatusForbidden, err: err})
+			return
+		case err != nil:
+			writeError(w, &apiError{status: http.StatusNotFound, err: err})
+			return
+		}
+		if ok, wait := s.limiter.Allow(client); !ok {
+			writeRateLimited(w, "rate limit", wait)
+			return
+		}
+		if ns != nil {
+			if ok, wait := ns.Allow(); !ok {
+				writeRateLimited(w, "tenant rate limit", wait)
+				return
+			}
+			r = r.WithContext(context.WithValue(r.Context(), namespaceKey{}, ns))
+		}
+		next(w, r)
+	})
+}
+
+func writeRateLimited(w http.ResponseWriter, limit string, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	writeError(w, errorf(http.StatusTooManyRequests, "%s exceeded, retry in %v", limit, wait.Round(time.Millisecond)))
+}
+
+// namespaceKey is the request context key of the tenant namespace set by
+// guard.
+type namespaceKey struct{}
+
+// scope is what a request can reach: the server's own schemas, templates,
+// cache and limits, or those of its tenant.
+type scope struct {
+	schemas      map[string]*jsonschema.Schema
+	templates    *tpl.Registry
+	templateDirs []string
+	cache        *cache.Cache
+	schemaFS     fs.FS
+	limits       limits.Config
+}
+
+// inlineSchema compiles a client's schema with the cache, which is sandboxed,
+// or without one, with its $refs confined to schemaFS and the common schemas.
+func (sc scope) inlineSchema(source []byte) (*jsonschema.Schema, error) {
+	if sc.cache == nil {
+		return schemautil.CompileStringFS(string(source), sc.schemaFS)
+	}
+	return sc.cache.Schema(source)
+}
+
+func (s *Server) scope(r *http.Request) scope {
+	ns, ok := r.Context().Value(namespaceKey{}).(*tenant.Namespace)
+	if !ok {
+		return scope{schemas: s.schemas, templates: s.templates, templateDirs: s.templateDirs, cache: s.cache, schemaFS: s.schemaFS, limits: s.limits}
+	}
+	return scope{schemas: ns.Schemas, templates: ns.Templates, templateDirs: ns.TemplateDirs, cache: ns.Cache, limits: ns.LimitsFor(s.limits)}
+}
+
+// decodeRequest decodes a POST body into v, keeping numbers as json.Number.
+// Bodies larger than the limit are answered with 413.
+func (s *Server) decodeRequest(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		return errorf(http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.scope(r).limits.BodyLimit()))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return errorf(http.StatusRequestEntityTooLarge, "request body larger than %d bytes", tooLarge.Limit)
+		}
+		return errorf(http.StatusBadRequest, "invalid request body: %w", &jsonutil.DecodeError{Format: jsonutil.FormatJSON, Err: err})
+	}
+	return nil
+}
+
+// schemaRequest is the body of /validate and /apply-defaults.
+type schemaRequest struct {
+	// Schema is a schema name or an inline schema.
+	Schema json.RawMessage `json:"schema" description:"Name of a schema in the schema directory, or an inline JSON Schema."`
+	Data   interface{}     `json:"data" description:"The JSON document."`
+}
+
+// schema returns the named or inline schema of a request.
+func (s *Server) schema(r *http.Request, raw json.RawMessage) (*jsonschema.Schema, error) {
+	if len(raw) == 0 {
+		return nil, errorf(http.StatusBadRequest, "missing schema")
+	}
+	sc := s.scope(r)
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		schema, ok := sc.schemas[strings.TrimSuffix(name, ".json")]
+		if !ok {
+			return nil, errorf(http.StatusNotFound, "unknown schema %q", name)
+		}
+		return schema, nil
+	}
+	schema, err := sc.inlineSchema(raw)
+	s.metrics.observeInlineSchema(err)
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "invalid schema: %w", err)
+	}
+	return schema, nil
+}
+
+// validateResponse is the body of a /validate response.
+type validateResponse struct {
+	Valid  bool                   `json:"valid"`
+	Errors []schemautil.Violation `json:"errors"`
+}
+
+func (s *Server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	var req schemaRequest
+	if err := s.decodeRequest(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	schema, err := s.schema(r, req.Schema)
+	if err != nil {
+		s.metrics.observeValidation("error")
+		writeError(w, err)
+		return
+	}
+	// The validator can't be interrupted (see schemautil.ValidateCtx), so
+	// validation has no deadline; the request size limit bounds it.
+	violations, err := schemautil.Validate(schema, req.Data)
+	if err != nil {
+		s.metrics.observeValidation("error")
+		writeError(w, err)
+		return
+	}
+	if len(violations) == 0 {
+		s.metrics.observeValidation("valid")
+	} else {
+		s.metrics.observeValidation("invalid")
+	}
+	if violations == nil {
+		violations = []schemautil.Violation{}
+	}
+	writeJSON(w, http.StatusOK, validateResponse{Valid: len(violations) == 0, Errors: violations})
+}
+
+func (s *Server) handleApplyDefaults(w http.ResponseWriter, r *http.Request) {
+	var req schemaRequest
+	if err := s.decodeRequest(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	schema, err := s.schema(r, req.Schema)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, applyDefaultsResponse{Data: schemautil.ApplyDefaults(req.Data, schema)})
+}
+
+// applyDefaultsResponse is the body of an /apply-defaults response.
+type applyDefaultsResponse struct {
+	Data interface{} `json:"data" description:"The document with defaults applied."`
+}
+
+// renderRequest is the body of /render.
+type renderRequest struct {
+	Template       string                 `json:"template,omitempty" description:"Name of a template in the template directory, optionally versioned as in invoice@latest.txt."`
+	TemplateSource *string                `json:"template_source,omitempty" description:"An inline template, instead of template."`
+	Context        map[string]interface{} `json:"context,omitempty"`
+	OutputMode     tpl.OutputMode         `json:"output_mode,omitempty" description:"Escape output for html, json, xml, markdown or text."`
+}
+
+// renderResponse is the body of a /render response.
+type renderResponse struct {
+	Output string `json:"output"`
+}
+
+func (s *Server) handleRender(w http.ResponseWriter, r *http.Request) {
+	var req renderRequest
+	if err := s.decodeRequest(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var opts []tpl.RenderOption
+	if req.OutputMode != "" {
+		opts = append(opts, tpl.WithOutputMode(req.OutputMode))
+	}
+
+	sc := s.scope(r)
+	var output string
+	var err error
+	switch {
+	case req.TemplateSource != nil && req.Template != "":
+		err = errorf(http.StatusBadRequest, "give either template or template_source, not both")
+	case req.TemplateSource != nil:
+		rendererOpts := s.opts
+		rendererOpts.TemplateDirs = sc.templateDirs
+		if req.OutputMode != "" {
+			rendererOpts.OutputMode = req.OutputMode
+		}
+		start := time.Now()
+		output, err = limits.Run(r.Context(), sc.limits.RenderTimeout, s.renders, func(ctx context.Context) (string, error) {
+			t, err := sc.cache.InlineTemplate(rendererOpts, *req.TemplateSource)
+			if err != nil {
+				return "", err
+			}
+			return t.Render(pongo2.Context(req.Context), tpl.WithContext(ctx))
+		})
+		s.metrics.observeRender("inline", time.Since(start), err)
+	case req.Template == "":
+		err = errorf(http.StatusBadRequest, "missing template or template_source")
+	case sc.templates == nil:
+		err = errorf(http.StatusNotFound, "no templates configured")
+	default:
+		start := time.Now()
+		output, err = limits.Run(r.Context(), sc.limits.RenderTimeout, s.renders, func(ctx context.Context) (string, error) {
+			return sc.templates.Render(req.Template, pongo2.Context(req.Context), append(opts, tpl.WithContext(ctx))...)
+		})
+		s.metrics.observeRender("named", time.Since(start), err)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, renderResponse{Output: output})
+}
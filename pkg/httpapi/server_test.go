@@ -0,0 +1,480 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-demo/pkg/auth"
+	"go-demo/pkg/cache"
+	"go-demo/pkg/limits"
+	tpl "go-demo/pkg/pongo2"
+	"go-demo/pkg/tenant"
+)
+
+const userSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string"},
+		"role": {"type": "string", "default": "member"},
+		"age": {"type": "integer", "minimum": 0}
+	},
+	"required": ["name"]
+}`
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"schemas/user.json":        userSchema,
+		"templates/greeting.txt":   "Hello {{ name }}!",
+		"templates/invoice@v2.txt": "Invoice v2 for {{ name }}",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	s, err := New(Config{SchemaDir: filepath.Join(dir, "schemas"), TemplateDir: filepath.Join(dir, "templates")})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func post(t *testing.T, url, body string) (int, string) {
+	t.Helper()
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST %s failed: %v", url, err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	return resp.StatusCode, strings.TrimSpace(string(b))
+}
+
+func TestServer(t *testing.T) {
+	ts := newTestServer(t)
+
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "validate named schema",
+			path:       "/validate",
+			body:       `{"schema": "user", "data": {"name": "Alice"}}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"valid":true,"errors":[]}`,
+		},
+		{
+			name:       "validate reports violations",
+			path:       "/validate",
+			body:       `{"schema": "user.json", "data": {"age": -1}}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"valid":false,"errors":[{"instanceLocation":"","keywordLocation":"/required","message":"missing properties: 'name'"},{"instanceLocation":"/age","keywordLocation":"/properties/age/minimum","message":"must be >= 0 but found -1"}]}`,
+		},
+		{
+			name:       "apply defaults with inline schema",
+			path:       "/apply-defaults",
+			body:       `{"schema": {"properties": {"n": {"default": 12345678901234567890}}}, "data": {}}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"data":{"n":12345678901234567890}}`,
+		},
+		{
+			name:       "apply defaults with named schema",
+			path:       "/apply-defaults",
+			body:       `{"schema": "user", "data": {"name": "Bob"}}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"data":{"name":"Bob","role":"member"}}`,
+		},
+		{
+			name:       "render named template",
+			path:       "/render",
+			body:       `{"template": "greeting.txt", "context": {"name": "Alice"}}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"output":"Hello Alice!"}`,
+		},
+		{
+			name:       "render versioned template",
+			path:       "/render",
+			body:       `{"template": "invoice@latest.txt", "context": {"name": "Bob"}}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"output":"Invoice v2 for Bob"}`,
+		},
+		{
+			name:       "render inline template with output mode",
+			path:       "/render",
+			body:       `{"template_source": "{\"name\": \"{{ name }}\"}", "context": {"name": "a\"b"}, "output_mode": "json"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"output":"{\"name\": \"a\\\"b\"}"}`,
+		},
+		{
+			name:       "unknown schema",
+			path:       "/validate",
+			body:       `{"schema": "order", "data": {}}`,
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":"unknown schema \"order\""}`,
+		},
+		{
+			name:       "invalid inline schema",
+			path:       "/validate",
+			body:       `{"schema": {"type": 1}, "data": {}}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "missing schema",
+			path:       "/apply-defaults",
+			body:       `{"data": {}}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"missing schema"}`,
+		},
+		{
+			name:       "malformed body",
+			path:       "/validate",
+			body:       `{"schema":`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"invalid request body: unexpected EOF","code":"decode_error"}`,
+		},
+		{
+			name:       "unknown template",
+			path:       "/render",
+			body:       `{"template": "missing.txt"}`,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "template and template_source",
+			path:       "/render",
+			body:       `{"template": "greeting.txt", "template_source": "x"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "render error",
+			path:       "/render",
+			body:       `{"template_source": "line 1\n{{ name|missing_filter }}"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody:   `{"error":"parser: Filter 'missing_filter' does not exist.","code":"template_compile_error","template":"<string>","line":2,"column":9}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := post(t, ts.URL+tt.path, tt.body)
+			if status != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, status, body)
+			}
+			if tt.wantBody != "" && body != tt.wantBody {
+				t.Errorf("unexpected body:\n got: %s\nwant: %s", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestServerRejectsGet(t *testing.T) {
+	ts := newTestServer(t)
+	resp, err := http.Get(ts.URL + "/validate")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed || resp.Header.Get("Allow") != http.MethodPost {
+		t.Errorf("expected 405 with Allow: POST, got %d %q", resp.StatusCode, resp.Header.Get("Allow"))
+	}
+}
+
+func TestServerInlineTemplateFiles(t *testing.T) {
+	ts := newTestServer(t)
+	secret := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(secret, []byte("top secret"), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", secret, err)
+	}
+
+	body := func(source string) string {
+		b, _ := json.Marshal(map[string]interface{}{"template_source": source, "context": map[string]string{"name": "Ada"}})
+		return string(b)
+	}
+	if status, got := post(t, ts.URL+"/render", body(`{% include "greeting.txt" %}`)); status != http.StatusOK || got != `{"output":"Hello Ada!"}` {
+		t.Errorf("expected the named template to be included, got %d: %s", status, got)
+	}
+	for _, source := range []string{
+		`{% ssi "` + secret + `" %}`,
+		`{% ssi "/etc/passwd" %}`,
+		`{% include "` + secret + `" %}`,
+		`{% include "/etc/hostname" %}`,
+		`{% include "../../` + filepath.Base(filepath.Dir(secret)) + `/secret.txt" %}`,
+		`{% import "` + secret + `" x %}`,
+	} {
+		status, got := post(t, ts.URL+"/render", body(source))
+		if status < 400 || status >= 500 || strings.Contains(got, "top secret") || strings.Contains(got, "root:") {
+			t.Errorf("%s: expected a client error, got %d: %s", source, status, got)
+		}
+	}
+}
+
+func TestServerInlineSchemaFiles(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "secret.json")
+	if err := os.WriteFile(secret, []byte(`{"properties": {"token": {"default": "s3cr3t"}}}`), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", secret, err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "user.json"), []byte(userSchema), 0o644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+	s, err := New(Config{SchemaDir: dir, Cache: cache.New(cache.Config{Sandbox: true, SchemaFS: os.DirFS(dir)})})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	cached := httptest.NewServer(s)
+	t.Cleanup(cached.Close)
+
+	// With and without a cache, inline schemas only reach the named ones.
+	for _, ts := range []*httptest.Server{newTestServer(t), cached} {
+		if status, got := post(t, ts.URL+"/apply-defaults", `{"schema": {"$ref": "user.json"}, "data": {"name": "Ada"}}`); status != http.StatusOK || got != `{"data":{"name":"Ada","role":"member"}}` {
+			t.Errorf("expected the named schema to be referenced, got %d: %s", status, got)
+		}
+		for _, ref := range []string{"file://" + filepath.ToSlash(secret), "../" + filepath.Base(filepath.Dir(secret)) + "/secret.json"} {
+			status, got := post(t, ts.URL+"/apply-defaults", `{"schema": {"$ref": "`+ref+`"}, "data": {}}`)
+			if status != http.StatusBadRequest || strings.Contains(got, "s3cr3t") {
+				t.Errorf("%s: expected 400, got %d: %s", ref, status, got)
+			}
+		}
+	}
+
+	if _, err := New(Config{Cache: cache.New(cache.Config{})}); err == nil {
+		t.Error("expected an error for a cache that isn't sandboxed")
+	}
+}
+
+func TestNewFailsOnBrokenSchema(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "bad.json"), []byte(`{"type": 1}`), 0o644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+	if _, err := New(Config{SchemaDir: dir}); err == nil {
+		t.Error("expected an error for a broken schema")
+	}
+}
+
+func TestHealthChecks(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(Config{SchemaDir: dir})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, strings.TrimSpace(string(b))
+	}
+
+	if status, body := get("/healthz"); status != http.StatusOK || body != `{"status":"ok"}` {
+		t.Errorf("unexpected /healthz: %d %s", status, body)
+	}
+	if status, body := get("/readyz"); status != http.StatusOK || body != `{"status":"ready","schemas":0,"templates":0}` {
+		t.Errorf("unexpected /readyz: %d %s", status, body)
+	}
+	s.ready.Store(false)
+	if status, _ := get("/readyz"); status != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 while shutting down, got %d", status)
+	}
+	if status, _ := get("/healthz"); status != http.StatusOK {
+		t.Errorf("expected /healthz to stay 200 while shutting down, got %d", status)
+	}
+}
+
+func TestServerLimits(t *testing.T) {
+	newServer := func(l limits.Config) *httptest.Server {
+		s, err := New(Config{Limits: l})
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		ts := httptest.NewServer(s)
+		t.Cleanup(ts.Close)
+		return ts
+	}
+
+	ts := newServer(limits.Config{MaxBodyBytes: 64})
+	if status, _ := post(t, ts.URL+"/render", `{"template_source": "`+strings.Repeat("x", 100)+`"}`); status != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 for a large body, got %d", status)
+	}
+
+	ts = newServer(limits.Config{RatePerSecond: 0.001, Burst: 2})
+	for i := 0; i < 2; i++ {
+		if status, body := post(t, ts.URL+"/render", `{"template_source": "x"}`); status != http.StatusOK {
+			t.Fatalf("request %d within the burst failed: %d %s", i+1, status, body)
+		}
+	}
+	resp, err := http.Post(ts.URL+"/render", "application/json", strings.NewReader(`{"template_source": "x"}`))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" {
+		t.Errorf("expected 429 with Retry-After, got %d %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+	if resp, err := http.Get(ts.URL + "/healthz"); err != nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("Health checks should not be rate limited: %v", err)
+	}
+
+	ts = newServer(limits.Config{RenderTimeout: time.Millisecond})
+	slow := `{"template_source": "{% for a in n %}{% for b in n %}{{ a }}{% endfor %}{% endfor %}", "context": {"n": [` +
+		strings.TrimSuffix(strings.Repeat("1,", 1000), ",") + `]}}`
+	if status, body := post(t, ts.URL+"/render", slow); status != http.StatusServiceUnavailable || body != `{"error":"render timed out"}` {
+		t.Errorf("expected 503 for a slow render, got %d %s", status, body)
+	}
+}
+
+func TestServerAuth(t *testing.T) {
+	keys := auth.StaticKeys{
+		{Principal: auth.Principal{Name: "billing", Operations: []string{auth.OpRender}}, Key: "billing-key"},
+	}
+	s, err := New(Config{Auth: keys, Limits: limits.Config{RatePerSecond: 0.001, Burst: 1}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+
+	tests := []struct {
+		name, path, header, value string
+		want                      int
+	}{
+		{"no key", "/render", "", "", http.StatusUnauthorized},
+		{"unknown key", "/render", "Authorization", "Bearer guess", http.StatusUnauthorized},
+		{"operation not allowed", "/validate", "X-API-Key", "billing-key", http.StatusForbidden},
+		{"bearer token", "/render", "Authorization", "Bearer billing-key", http.StatusOK},
+		// The rate limit applies per key, not per address.
+		{"rate limited key", "/render", "X-API-Key", "billing-key", http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+tt.path, strings.NewReader(`{"schema": {}, "data": 1, "template_source": "x"}`))
+		if tt.header != "" {
+			req.Header.Set(tt.header, tt.value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", tt.name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, resp.StatusCode)
+		}
+		if resp.StatusCode == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") != "Bearer" {
+			t.Errorf("%s: expected a WWW-Authenticate header", tt.name)
+		}
+	}
+
+	for _, path := range []string{"/healthz", "/readyz", "/metrics", "/openapi.json"} {
+		if resp, err := http.Get(ts.URL + path); err != nil || resp.StatusCode != http.StatusOK {
+			t.Errorf("GET %s should not require a key: %v", path, err)
+		}
+	}
+}
+
+func TestServerTenants(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"server/greeting.txt": "Hello {{ name }}!",
+		"acme/greeting.txt":   "Welcome to Acme, {{ name }}!",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+	acme, err := tenant.New("acme", tenant.Config{TemplateDir: filepath.Join(dir, "acme")}, tpl.Options{})
+	if err != nil {
+		t.Fatalf("tenant.New failed: %v", err)
+	}
+	small, _ := tenant.New("small", tenant.Config{Limits: limits.Config{MaxBodyBytes: 64}}, tpl.Options{})
+	slow, _ := tenant.New("slow", tenant.Config{Limits: limits.Config{RatePerSecond: 0.001, Burst: 1}}, tpl.Options{})
+	set, err := tenant.NewSet(acme, small, slow)
+	if err != nil {
+		t.Fatalf("tenant.NewSet failed: %v", err)
+	}
+	keys := auth.StaticKeys{
+		{Principal: auth.Principal{Name: "acme", Tenant: "acme"}, Key: "acme-key"},
+		{Principal: auth.Principal{Name: "ops"}, Key: "ops-key"},
+	}
+	s, err := New(Config{TemplateDir: filepath.Join(dir, "server"), Auth: keys, Tenants: set})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+
+	named := `{"template": "greeting.txt", "context": {"name": "Ada"}}`
+	include := func(name string) string {
+		b, _ := json.Marshal(map[string]interface{}{"template_source": `{% include "` + name + `" %}`, "context": map[string]string{"name": "Ada"}})
+		return string(b)
+	}
+	large := `{"template_source": "` + strings.Repeat("x", 100) + `"}`
+	tests := []struct {
+		name, key, tenant, body string
+		want                    int
+		wantBody                string
+	}{
+		{"no tenant", "ops-key", "", named, http.StatusOK, `{"output":"Hello Ada!"}`},
+		{"tenant header", "ops-key", "acme", named, http.StatusOK, `{"output":"Welcome to Acme, Ada!"}`},
+		{"tenant of the key", "acme-key", "", named, http.StatusOK, `{"output":"Welcome to Acme, Ada!"}`},
+		{"include of the tenant", "acme-key", "", include("greeting.txt"), http.StatusOK, `{"output":"Welcome to Acme, Ada!"}`},
+		{"include of another tenant", "acme-key", "", include(filepath.Join(dir, "server", "greeting.txt")), http.StatusUnprocessableEntity, ""},
+		{"relative include of another tenant", "acme-key", "", include("../server/greeting.txt"), http.StatusUnprocessableEntity, ""},
+		{"another tenant", "acme-key", "small", named, http.StatusForbidden, ""},
+		{"unknown tenant", "ops-key", "initech", named, http.StatusNotFound, ""},
+		{"no templates in tenant", "ops-key", "small", named, http.StatusNotFound, ""},
+		{"server body limit", "ops-key", "acme", large, http.StatusOK, ""},
+		{"tenant body limit", "ops-key", "small", large, http.StatusRequestEntityTooLarge, ""},
+		{"within tenant rate", "ops-key", "slow", `{"template_source": "x"}`, http.StatusOK, ""},
+		{"tenant rate limited", "ops-key", "slow", `{"template_source": "x"}`, http.StatusTooManyRequests, ""},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/render", strings.NewReader(tt.body))
+		req.Header.Set("X-API-Key", tt.key)
+		if tt.tenant != "" {
+			req.Header.Set(tenant.Header, tt.tenant)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", tt.name, err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.want, resp.StatusCode, b)
+		}
+		if tt.wantBody != "" && strings.TrimSpace(string(b)) != tt.wantBody {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.wantBody, b)
+		}
+	}
+
+}
@@ -0,0 +1,172 @@
+// Package jsapi is the JSON-string-in, JSON-string-out API of the js/wasm
+// build (cmd/wasm), so that a browser previews validation, defaults and
+// rendering with the same code as the CLI and the servers. Every function
+// takes JSON strings and returns a JSON string; failures are returned, not
+// thrown, in the shape of the HTTP API's errors:
+//
+//	{"error": "...", "code": "decode_error"}
+//
+// The package has no js/wasm dependencies and is tested on the host.
+package jsapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"go-demo/pkg/cache"
+	"go-demo/pkg/errcode"
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/jsonutil"
+	tpl "go-demo/pkg/pongo2"
+)
+
+// compiled keeps the schemas and templates of previous calls: a preview
+// usually validates and renders the same sources again on every keystroke.
+var compiled = cache.New(cache.Config{MaxEntries: 100})
+
+// ValidateResult is the result of Validate.
+type ValidateResult struct {
+	Valid  bool                   `json:"valid"`
+	Errors []schemautil.Violation `json:"errors"`
+}
+
+// ApplyDefaultsResult is the result of ApplyDefaults.
+type ApplyDefaultsResult struct {
+	Data interface{} `json:"data"`
+}
+
+// RenderResult is the result of Render.
+type RenderResult struct {
+	Output string `json:"output"`
+}
+
+// RenderOptions are the options of Render, all optional.
+type RenderOptions struct {
+	OutputMode   tpl.OutputMode `json:"output_mode,omitempty"`
+	TrimBlocks   bool           `json:"trim_blocks,omitempty"`
+	LStripBlocks bool           `json:"lstrip_blocks,omitempty"`
+}
+
+// Error is the result of a failed call. Code is set for the failures errcode
+// classifies, Line and Column for template errors.
+type Error struct {
+	Error    string `json:"error"`
+	Code     string `json:"code,omitempty"`
+	Template string `json:"template,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+}
+
+// Validate validates the JSON document data against the JSON Schema schema.
+func Validate(schema, data string) string {
+	return respond(func() (interface{}, error) {
+		s, doc, err := decode(schema, data)
+		if err != nil {
+			return nil, err
+		}
+		violations, err := schemautil.Validate(s, doc)
+		if err != nil {
+			return nil, err
+		}
+		if violations == nil {
+			violations = []schemautil.Violation{}
+		}
+		return ValidateResult{Valid: len(violations) == 0, Errors: violations}, nil
+	})
+}
+
+// ApplyDefaults fills in the defaults of schema missing from the JSON
+// document data.
+func ApplyDefaults(schema, data string) string {
+	return respond(func() (interface{}, error) {
+		s, doc, err := decode(schema, data)
+		if err != nil {
+			return nil, err
+		}
+		return ApplyDefaultsResult{Data: schemautil.ApplyDefaults(doc, s)}, nil
+	})
+}
+
+// Render renders the template source with the JSON object context. options
+// is a JSON RenderOptions object or empty. Templates are sandboxed: there is
+// no environment to read in a browser.
+func Render(source, context, options string) string {
+	return respond(func() (interface{}, error) {
+		var opts RenderOptions
+		if strings.TrimSpace(options) != "" {
+			dec := json.NewDecoder(strings.NewReader(options))
+			dec.DisallowUnknownFields()
+			if err := dec.Decode(&opts); err != nil {
+				return nil, fmt.Errorf("options: %w", err)
+			}
+		}
+		ctx := pongo2.Context{}
+		if strings.TrimSpace(context) != "" {
+			v, err := jsonutil.DecodeBytes([]byte(context))
+			if err != nil {
+				return nil, fmt.Errorf("context: %w", err)
+			}
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("context: must be a JSON object")
+			}
+			ctx = m
+		}
+		t, err := compiled.Template(tpl.Options{
+			OutputMode:   opts.OutputMode,
+			TrimBlocks:   opts.TrimBlocks,
+			LStripBlocks: opts.LStripBlocks,
+			Sandbox:      true,
+		}, source)
+		if err != nil {
+			return nil, err
+		}
+		output, err := t.Render(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return RenderResult{Output: output}, nil
+	})
+}
+
+// decode compiles schema and decodes the document data.
+func decode(schema, data string) (*jsonschema.Schema, interface{}, error) {
+	s, err := compiled.Schema([]byte(schema))
+	if err != nil {
+		return nil, nil, fmt.Errorf("schema: %w", err)
+	}
+	doc, err := jsonutil.DecodeBytes([]byte(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("data: %w", err)
+	}
+	return s, doc, nil
+}
+
+// respond encodes the result of fn, or its error as an Error.
+func respond(fn func() (interface{}, error)) string {
+	v, err := fn()
+	if err != nil {
+		v = newError(err)
+	}
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		b.Reset()
+		_ = enc.Encode(newError(err))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func newError(err error) Error {
+	var renderErr *tpl.RenderError
+	if errors.As(err, &renderErr) {
+		return Error{Error: renderErr.Err.Error(), Code: string(renderErr.Code()), Template: renderErr.Template, Line: renderErr.Line, Column: renderErr.Column}
+	}
+	return Error{Error: err.Error(), Code: string(errcode.Of(err))}
+}
@@ -0,0 +1,103 @@
+package jsapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const userSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string"},
+		"age": {"type": "integer", "minimum": 0},
+		"role": {"type": "string", "default": "member"}
+	},
+	"required": ["name"]
+}`
+
+func TestValidate(t *testing.T) {
+	var res ValidateResult
+	decodeResult(t, Validate(userSchema, `{"name": "Alice", "age": 30}`), &res)
+	if !res.Valid || res.Errors == nil || len(res.Errors) != 0 {
+		t.Errorf("Valid data should pass with an empty error list, got %+v", res)
+	}
+
+	res = ValidateResult{}
+	decodeResult(t, Validate(userSchema, `{"age": -1}`), &res)
+	if res.Valid || len(res.Errors) != 2 {
+		t.Fatalf("Expected two violations, got %+v", res)
+	}
+	if res.Errors[1].KeywordLocation != "/properties/age/minimum" {
+		t.Errorf("Unexpected violation: %+v", res.Errors[1])
+	}
+}
+
+func TestApplyDefaults(t *testing.T) {
+	got := ApplyDefaults(userSchema, `{"name": "Alice", "id": 12345678901234567890}`)
+	if want := `{"data":{"id":12345678901234567890,"name":"Alice","role":"member"}}`; got != want {
+		t.Errorf("ApplyDefaults() = %s, want %s", got, want)
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		context  string
+		options  string
+		want     string
+	}{
+		{"plain", "Hello {{ name }}!", `{"name": "<World>"}`, "", `{"output":"Hello &lt;World&gt;!"}`},
+		{"output mode", `{"n": "{{ name }}"}`, `{"name": "a\"b"}`, `{"output_mode": "json"}`, `{"output":"{\"n\": \"a\\\"b\"}"}`},
+		{"trim blocks", "{% for i in items %}\n{{ i }}\n{% endfor %}\n", `{"items": [1, 2]}`, `{"trim_blocks": true}`, `{"output":"1\n2\n"}`},
+		{"no context", "static", "", "", `{"output":"static"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Render(tt.template, tt.context, tt.options); got != tt.want {
+				t.Errorf("Render() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		result string
+		code   string
+		msg    string
+	}{
+		{"invalid schema", Validate(`{"type": `, `{}`), "schema_compile_error", "schema: "},
+		{"invalid data", ApplyDefaults(userSchema, `{"name": `), "decode_error", "data: "},
+		{"invalid context", Render("x", `[1]`, ""), "", "context: must be a JSON object"},
+		{"unknown option", Render("x", "", `{"mode": "html"}`), "", "options: "},
+		{"template syntax", Render("{% if %}", "", ""), "template_compile_error", "parser: "},
+		{"sandboxed", Render(`{% env "HOME" %}`, "", ""), "render_error", "sandbox"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var res Error
+			decodeResult(t, tt.result, &res)
+			if res.Code != tt.code || !strings.Contains(res.Error, tt.msg) {
+				t.Errorf("Expected a %q error containing %q, got %s", tt.code, tt.msg, tt.result)
+			}
+		})
+	}
+
+	var res Error
+	decodeResult(t, Render("{% if %}", "", ""), &res)
+	if res.Line != 1 || res.Column == 0 {
+		t.Errorf("Template errors should have a position, got %+v", res)
+	}
+}
+
+func decodeResult(t *testing.T, s string, v interface{}) {
+	t.Helper()
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		t.Fatalf("Unexpected result %s: %v", s, err)
+	}
+}
@@ -0,0 +1,189 @@
+// Package jsonpatch builds and applies RFC 6902 JSON Patch documents on
+// decoded JSON values (map[string]interface{}, []interface{} and scalars).
+package jsonpatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"go-demo/pkg/jsonutil"
+)
+
+// Operation is an operation of a JSON Patch.
+type Operation struct {
+	// Op is "add", "remove", "replace" or "test".
+	Op string `json:"op"`
+	// Path is the JSON Pointer of the value the operation applies to.
+	Path string `json:"path"`
+	// Value is the value to add, replace with or test for; remove has none.
+	Value interface{} `json:"value"`
+}
+
+// MarshalJSON leaves the value out of remove operations.
+func (o Operation) MarshalJSON() ([]byte, error) {
+	if o.Op == "remove" {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{o.Op, o.Path})
+	}
+	type operation Operation
+	return json.Marshal(operation(o))
+}
+
+// Diff returns a patch that turns a into b: an add for each member and
+// element b has and a doesn't, a remove for each one a has and b doesn't,
+// and a replace for the other values that differ. Members come in the order
+// of their names, and appended elements in the order of their indexes, so
+// the patch applies as it is. Equal values give an empty patch.
+func Diff(a, b interface{}) []Operation {
+	return diff([]Operation{}, "", a, b)
+}
+
+func diff(ops []Operation, pointer string, a, b interface{}) []Operation {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for _, k := range sortedKeys(av) {
+			if _, ok := bv[k]; !ok {
+				ops = append(ops, Operation{Op: "remove", Path: pointer + jsonutil.JoinPointer(k)})
+			}
+		}
+		for _, k := range sortedKeys(bv) {
+			if value, ok := av[k]; ok {
+				ops = diff(ops, pointer+jsonutil.JoinPointer(k), value, bv[k])
+			} else {
+				ops = append(ops, Operation{Op: "add", Path: pointer + jsonutil.JoinPointer(k), Value: bv[k]})
+			}
+		}
+		return ops
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(av) && i < len(bv); i++ {
+			ops = diff(ops, pointer+"/"+strconv.Itoa(i), av[i], bv[i])
+		}
+		for i := len(av); i < len(bv); i++ {
+			ops = append(ops, Operation{Op: "add", Path: pointer + "/" + strconv.Itoa(i), Value: bv[i]})
+		}
+		for i := len(av) - 1; i >= len(bv); i-- {
+			ops = append(ops, Operation{Op: "remove", Path: pointer + "/" + strconv.Itoa(i)})
+		}
+		return ops
+	}
+	if !reflect.DeepEqual(a, b) {
+		ops = append(ops, Operation{Op: "replace", Path: pointer, Value: b})
+	}
+	return ops
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Apply applies patch to doc, one operation after the other, and returns the
+// result. Doc is not modified: the objects and arrays on the paths of the
+// operations are copied. It supports add, remove, replace and test, and
+// fails at the first operation that doesn't apply, naming its index.
+func Apply(doc interface{}, patch []Operation) (interface{}, error) {
+	for i, op := range patch {
+		tokens, err := jsonutil.SplitPointer(op.Path)
+		if err == nil {
+			switch op.Op {
+			case "add", "remove", "replace":
+				doc, err = update(doc, tokens, 0, op)
+			case "test":
+				var value interface{}
+				if value, err = jsonutil.Get(doc, op.Path); err == nil && !reflect.DeepEqual(value, op.Value) {
+					err = fmt.Errorf("%s: value is %v, not %v", op.Path, value, op.Value)
+				}
+			default:
+				err = fmt.Errorf("unsupported op %q", op.Op)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+// update returns a copy of doc with op applied at the pointer tokens[i:],
+// where tokens[:i] lead to doc.
+func update(doc interface{}, tokens []string, i int, op Operation) (interface{}, error) {
+	if i == len(tokens) {
+		if op.Op == "remove" {
+			return nil, fmt.Errorf("can't remove the whole document")
+		}
+		return op.Value, nil
+	}
+	tok, last := tokens[i], i == len(tokens)-1
+	path := jsonutil.JoinPointer(tokens[:i+1]...)
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		child, ok := v[tok]
+		if !ok && !(last && op.Op == "add") {
+			return nil, fmt.Errorf("%s: no member %q", path, tok)
+		}
+		result := make(map[string]interface{}, len(v)+1)
+		for k, value := range v {
+			result[k] = value
+		}
+		switch {
+		case last && op.Op == "remove":
+			delete(result, tok)
+		case last:
+			result[tok] = op.Value
+		default:
+			value, err := update(child, tokens, i+1, op)
+			if err != nil {
+				return nil, err
+			}
+			result[tok] = value
+		}
+		return result, nil
+	case []interface{}:
+		if last && op.Op == "add" {
+			index := len(v)
+			if tok != "-" {
+				var err error
+				if index, err = strconv.Atoi(tok); err != nil || index < 0 || index > len(v) || tok != strconv.Itoa(index) {
+					return nil, fmt.Errorf("%s: no position %q in an array of %d", path, tok, len(v))
+				}
+			}
+			result := make([]interface{}, 0, len(v)+1)
+			result = append(append(append(result, v[:index]...), op.Value), v[index:]...)
+			return result, nil
+		}
+		index, err := strconv.Atoi(tok)
+		if err != nil || index < 0 || index >= len(v) || tok != strconv.Itoa(index) {
+			return nil, fmt.Errorf("%s: no element %q in an array of %d", path, tok, len(v))
+		}
+		if last && op.Op == "remove" {
+			return append(append([]interface{}{}, v[:index]...), v[index+1:]...), nil
+		}
+		result := append([]interface{}{}, v...)
+		if last {
+			result[index] = op.Value
+			return result, nil
+		}
+		if result[index], err = update(v[index], tokens, i+1, op); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("%s: parent is not an object or array", path)
+}
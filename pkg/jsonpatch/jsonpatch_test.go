@@ -0,0 +1,96 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("Failed to decode %s: %v", s, err)
+	}
+	return v
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"equal", `{"a": [1, {"b": 2}]}`, `{"a": [1, {"b": 2}]}`, `[]`},
+		{
+			"members",
+			`{"keep": 1, "gone": 2, "nested": {"x": 1}}`,
+			`{"keep": 1, "new": {"y": null}, "nested": {"x": 1, "z/w": 3}}`,
+			`[{"op":"remove","path":"/gone"},{"op":"add","path":"/nested/z~1w","value":3},{"op":"add","path":"/new","value":{"y":null}}]`,
+		},
+		{
+			"elements",
+			`{"grow": [1], "shrink": [1, 2, 3], "change": ["a"]}`,
+			`{"grow": [1, 2, 3], "shrink": [1], "change": ["b"]}`,
+			`[{"op":"replace","path":"/change/0","value":"b"},{"op":"add","path":"/grow/1","value":2},{"op":"add","path":"/grow/2","value":3},{"op":"remove","path":"/shrink/2"},{"op":"remove","path":"/shrink/1"}]`,
+		},
+		{"types", `{"a": {"b": 1}}`, `{"a": [1]}`, `[{"op":"replace","path":"/a","value":[1]}]`},
+		{"root", `1`, `"1"`, `[{"op":"replace","path":"","value":"1"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := decode(t, tt.a), decode(t, tt.b)
+			patch := Diff(a, b)
+			if got, _ := json.Marshal(patch); string(got) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+			// Applying the patch to a gives b, and leaves a alone.
+			got, err := Apply(a, patch)
+			if err != nil {
+				t.Fatalf("Apply failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, b) {
+				t.Errorf("expected %v, got %v", b, got)
+			}
+			if !reflect.DeepEqual(a, decode(t, tt.a)) {
+				t.Errorf("expected %s to be left alone, got %v", tt.a, a)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	doc := decode(t, `{"a": [1, 2], "b": {"c": 1}}`)
+	patch := []Operation{
+		{Op: "test", Path: "/b/c", Value: 1.0},
+		{Op: "add", Path: "/a/0", Value: 0.0},
+		{Op: "add", Path: "/a/-", Value: 3.0},
+		{Op: "replace", Path: "/b/c", Value: "x"},
+		{Op: "remove", Path: "/a/1"},
+	}
+	got, err := Apply(doc, patch)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if want := decode(t, `{"a": [0, 2, 3], "b": {"c": "x"}}`); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	for _, tt := range []struct {
+		op   Operation
+		want string
+	}{
+		{Operation{Op: "test", Path: "/b/c", Value: 2.0}, "operation 0 (test /b/c): /b/c: value is 1, not 2"},
+		{Operation{Op: "replace", Path: "/b/d", Value: 1.0}, `/b/d: no member "d"`},
+		{Operation{Op: "add", Path: "/a/3", Value: 1.0}, `/a/3: no position "3" in an array of 2`},
+		{Operation{Op: "remove", Path: "/a/01"}, `/a/01: no element "01"`},
+		{Operation{Op: "add", Path: "/b/c/d", Value: 1.0}, "/b/c/d: parent is not an object or array"},
+		{Operation{Op: "remove", Path: ""}, "can't remove the whole document"},
+		{Operation{Op: "move", Path: "/a"}, `unsupported op "move"`},
+	} {
+		if _, err := Apply(doc, []Operation{tt.op}); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: expected an error containing %q, got %v", tt.op, tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package jsonschema
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go-demo/pkg/jsonutil"
+)
+
+// Bundle reads the schema in path and returns it with every external $ref
+// inlined, so it can be used without access to the files (or URLs) it
+// references. Each referenced document is added once to the root's $defs
+// (definitions before draft 2019-09), named after its file, and the $refs are
+// rewritten to point there. Relative $refs are resolved against the location
+// of the file they appear in.
+func Bundle(path string) (map[string]interface{}, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	rootURL := &url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}
+	doc, err := loadSchemaDocument(rootURL.String())
+	if err != nil {
+		return nil, err
+	}
+	root, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: schema must be an object", path)
+	}
+	return bundle(root, rootURL)
+}
+
+// BundleSource is like Bundle for an inline schema document, such as one
+// compiled by CompileString. Its $refs must be absolute or local.
+func BundleSource(source []byte) (map[string]interface{}, error) {
+	doc, err := jsonutil.DecodeBytes(source)
+	if err != nil {
+		return nil, err
+	}
+	root, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("schema must be an object")
+	}
+	return bundle(root, &url.URL{Path: "/schema.json"})
+}
+
+// bundle inlines the external $refs of root, the document at rootURL.
+func bundle(root map[string]interface{}, rootURL *url.URL) (map[string]interface{}, error) {
+	b := &bundler{
+		root:    rootURL.String(),
+		defsKey: bundleDefsKey(root),
+		names:   map[string]string{},
+		defs:    map[string]interface{}{},
+	}
+	// Inlined documents must not take the names of the root's own defs.
+	if existing, ok := root[b.defsKey].(map[string]interface{}); ok {
+		for name := range existing {
+			b.defs[name] = true
+		}
+	}
+	bundled, err := b.rewrite(root, rootURL, "")
+	if err != nil {
+		return nil, err
+	}
+	result := bundled.(map[string]interface{})
+	if existing, ok := result[b.defsKey].(map[string]interface{}); ok {
+		for name, def := range existing {
+			b.defs[name] = def
+		}
+	}
+	if len(b.defs) > 0 {
+		result[b.defsKey] = b.defs
+	}
+	return result, nil
+}
+
+// bundleDefsKey returns where the drafts of root keep reusable subschemas.
+func bundleDefsKey(root map[string]interface{}) string {
+	dialect, _ := root["$schema"].(string)
+	for _, old := range []string{"draft-04", "draft-06", "draft-07"} {
+		if strings.Contains(dialect, old) {
+			return "definitions"
+		}
+	}
+	return "$defs"
+}
+
+// loadSchemaDocument loads a schema like the compilers do (see loadURL).
+func loadSchemaDocument(u string) (interface{}, error) {
+	r, err := loadURL(u)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := jsonutil.DecodeBytes(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", u, err)
+	}
+	return doc, nil
+}
+
+type bundler struct {
+	root    string
+	defsKey string
+
+	// names maps the URL of each inlined document to its name in defs.
+	names map[string]string
+	defs  map[string]interface{}
+}
+
+// annotationKeywords hold instance values, not schemas, so a "$ref" inside
+// them isn't a reference.
+var annotationKeywords = map[string]bool{"const": true, "enum": true, "default": true, "examples": true}
+
+// rewrite returns a copy of v, a part of the document at base, with its $refs
+// pointing into the bundle. prefix is the pointer of the document within the
+// bundle.
+func (b *bundler) rewrite(v interface{}, base *url.URL, prefix string) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			switch {
+			case k == "$ref":
+				ref, ok := child.(string)
+				if !ok {
+					return nil, fmt.Errorf("%s: $ref must be a string", base)
+				}
+				rewritten, err := b.ref(ref, base, prefix)
+				if err != nil {
+					return nil, err
+				}
+				out[k] = rewritten
+			case annotationKeywords[k]:
+				out[k] = child
+			default:
+				rewritten, err := b.rewrite(child, base, prefix)
+				if err != nil {
+					return nil, err
+				}
+				out[k] = rewritten
+			}
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			rewritten, err := b.rewrite(child, base, prefix)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = rewritten
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// ref rewrites one $ref found in the document at base.
+func (b *bundler) ref(ref string, base *url.URL, prefix string) (string, error) {
+	target, err := base.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("%s: invalid $ref %q: %w", base, ref, err)
+	}
+	fragment := target.Fragment
+	target.Fragment = ""
+	if target.String() == base.String() {
+		return localRef(prefix, fragment), nil
+	}
+	name, err := b.embed(target)
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		return localRef("", fragment), nil
+	}
+	return localRef(jsonutil.JoinPointer(b.defsKey, name), fragment), nil
+}
+
+// localRef returns a reference to fragment of the document at prefix. Anchor
+// fragments stay as they are.
+func localRef(prefix, fragment string) string {
+	if fragment != "" && !strings.HasPrefix(fragment, "/") {
+		prefix = ""
+	}
+	return (&url.URL{Fragment: prefix + fragment}).String()
+}
+
+// embed adds the document at u to the bundle, once, and returns its name;
+// "" is the root.
+func (b *bundler) embed(u *url.URL) (string, error) {
+	key := u.String()
+	if key == b.root {
+		return "", nil
+	}
+	if name, ok := b.names[key]; ok {
+		return name, nil
+	}
+	doc, err := loadSchemaDocument(key)
+	if err != nil {
+		return "", err
+	}
+
+	name := bundleName(u)
+	for i := 2; b.defs[name] != nil; i++ {
+		name = bundleName(u) + "-" + strconv.Itoa(i)
+	}
+	b.names[key] = name
+	b.defs[name] = true // reserve the name while the document is rewritten
+
+	// The document is no longer a resource of its own: its refs are
+	// rewritten relative to the bundle.
+	if obj, ok := doc.(map[string]interface{}); ok {
+		delete(obj, "$id")
+		delete(obj, "id")
+		delete(obj, "$schema")
+	}
+	rewritten, err := b.rewrite(doc, u, jsonutil.JoinPointer(b.defsKey, name))
+	if err != nil {
+		return "", err
+	}
+	b.defs[name] = rewritten
+	return name, nil
+}
+
+// bundleName derives a name for a document in the bundle from its file name,
+// keeping only characters that need no escaping in a $ref.
+func bundleName(u *url.URL) string {
+	base := path.Base(u.Path)
+	if base == "." || base == "/" {
+		return "schema"
+	}
+	for _, ext := range []string{".json", ".schema"} {
+		base = strings.TrimSuffix(base, ext)
+	}
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' || r == '.' {
+			return r
+		}
+		return '_'
+	}, base)
+	if name == "" {
+		return "schema"
+	}
+	return name
+}
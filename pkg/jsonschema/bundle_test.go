@@ -0,0 +1,172 @@
+package jsonschema
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-demo/pkg/jsonutil"
+)
+
+func TestBundle(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"api.schema.json": `{
+			"$schema": "http://json-schema.org/draft-07/schema#",
+			"type": "object",
+			"properties": {
+				"billing": {"$ref": "common/address.json"},
+				"shipping": {"$ref": "common/address.json"},
+				"contact": {"$ref": "common/contact.json#/definitions/email"},
+				"items": {"type": "array", "items": {"$ref": "#/definitions/item"}},
+				"note": {"default": {"$ref": "not a reference"}}
+			},
+			"definitions": {"item": {"type": "string"}}
+		}`,
+		"common/address.json": `{
+			"$id": "https://example.com/address.json",
+			"type": "object",
+			"properties": {"street": {"type": "string"}, "country": {"$ref": "#/definitions/country"}, "owner": {"$ref": "contact.json"}},
+			"required": ["street"],
+			"definitions": {"country": {"type": "string", "minLength": 2, "maxLength": 2}}
+		}`,
+		"common/contact.json": `{
+			"type": "object",
+			"properties": {"email": {"$ref": "#/definitions/email"}, "home": {"$ref": "address.json"}},
+			"definitions": {"email": {"type": "string", "format": "email"}}
+		}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	bundled, err := Bundle(filepath.Join(dir, "api.schema.json"))
+	if err != nil {
+		t.Fatalf("Bundle failed: %v", err)
+	}
+
+	refs := map[string]string{}
+	var collect func(pointer string, v interface{})
+	collect = func(pointer string, v interface{}) {
+		if m, ok := v.(map[string]interface{}); ok {
+			for k, child := range m {
+				if k == "$ref" {
+					refs[pointer] = child.(string)
+				} else {
+					collect(pointer+jsonutil.JoinPointer(k), child)
+				}
+			}
+		}
+	}
+	collect("", bundled)
+	want := map[string]string{
+		"/properties/billing":                     "#/definitions/address",
+		"/properties/shipping":                    "#/definitions/address",
+		"/properties/contact":                     "#/definitions/contact/definitions/email",
+		"/properties/items/items":                 "#/definitions/item",
+		"/properties/note/default":                "not a reference",
+		"/definitions/address/properties/country": "#/definitions/address/definitions/country",
+		"/definitions/address/properties/owner":   "#/definitions/contact",
+		"/definitions/contact/properties/email":   "#/definitions/contact/definitions/email",
+		"/definitions/contact/properties/home":    "#/definitions/address",
+	}
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("unexpected $refs:\n got %v\nwant %v", refs, want)
+	}
+	if _, ok := bundled["definitions"].(map[string]interface{})["address"].(map[string]interface{})["$id"]; ok {
+		t.Errorf("Inlined documents should lose their $id")
+	}
+
+	b, err := jsonutil.Marshal(jsonutil.FormatJSON, bundled, 0)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	schema, err := CompileString(string(b))
+	if err != nil {
+		t.Fatalf("The bundle doesn't compile on its own: %v", err)
+	}
+	tests := []struct {
+		doc   string
+		valid bool
+	}{
+		{`{"billing": {"street": "Main St", "country": "DE"}, "contact": "a@example.com"}`, true},
+		{`{"billing": {"street": "Main St", "country": "DEU"}}`, false},
+		{`{"shipping": {"owner": {"home": {}}}}`, false},
+	}
+	for _, tt := range tests {
+		doc, _ := jsonutil.DecodeBytes([]byte(tt.doc))
+		if violations, _ := Validate(schema, doc); (len(violations) == 0) != tt.valid {
+			t.Errorf("%s: expected valid=%v, got %+v", tt.doc, tt.valid, violations)
+		}
+	}
+}
+
+func TestBundleMissingRef(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "root.json")
+	if err := os.WriteFile(path, []byte(`{"$ref": "missing.json"}`), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+	if _, err := Bundle(path); err == nil || !strings.Contains(err.Error(), "missing.json") {
+		t.Errorf("expected an error naming missing.json, got %v", err)
+	}
+}
+
+func TestBundleSource(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "money.json")
+	if err := os.WriteFile(path, []byte(`{"type": "object", "properties": {"amount": {"type": "integer"}}}`), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+	ref := (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+	source := `{"properties": {"price": {"$ref": "` + ref + `"}, "tags": {"$ref": "#/$defs/tags"}}, "$defs": {"tags": {"type": "array"}}}`
+
+	bundled, err := BundleSource([]byte(source))
+	if err != nil {
+		t.Fatalf("BundleSource failed: %v", err)
+	}
+	b, err := jsonutil.Marshal(jsonutil.FormatJSON, bundled, 0)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if strings.Contains(string(b), "file:") {
+		t.Errorf("expected no external refs, got %s", b)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("Failed to remove %s: %v", path, err)
+	}
+	schema, err := CompileString(string(b))
+	if err != nil {
+		t.Fatalf("the bundle should compile without the referenced file: %v", err)
+	}
+	if err := schema.Validate(map[string]interface{}{"price": map[string]interface{}{"amount": "1"}}); err == nil {
+		t.Error("expected the inlined schema to apply")
+	}
+
+	if _, err := BundleSource([]byte(`[]`)); err == nil {
+		t.Error("expected an error for a schema that isn't an object")
+	}
+}
+
+func TestBundleName(t *testing.T) {
+	tests := map[string]string{
+		"file:///schemas/address.json":         "address",
+		"file:///schemas/api.schema.json":      "api",
+		"https://example.com/v1/user%20x.json": "user_x",
+		"https://example.com/":                 "schema",
+	}
+	for in, want := range tests {
+		u, _ := url.Parse(in)
+		if got := bundleName(u); got != want {
+			t.Errorf("bundleName(%s) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// coerce converts data to one of the types of schema if it has none of them
+// and the conversion is obvious: a string holding a number to integer or
+// number, "true" and "false" to boolean, and any other value to an array of
+// that value. Numbers become json.Number, like the documents jsonutil
+// decodes. Other data is returned unchanged.
+func coerce(data interface{}, schema *jsonschema.Schema) interface{} {
+	if len(schema.Types) == 0 {
+		return data
+	}
+	for _, typ := range schema.Types {
+		if isType(data, typ) {
+			return data
+		}
+	}
+	for _, typ := range schema.Types {
+		if v, ok := coerceTo(data, typ); ok {
+			return v
+		}
+	}
+	return data
+}
+
+// isType reports whether data is of the JSON Schema type typ.
+func isType(data interface{}, typ string) bool {
+	switch v := data.(type) {
+	case nil:
+		return typ == "null"
+	case bool:
+		return typ == "boolean"
+	case string:
+		return typ == "string"
+	case json.Number:
+		return typ == "number" || typ == "integer" && isInteger(v)
+	case float64:
+		return typ == "number" || typ == "integer" && isWhole(v)
+	case float32:
+		return typ == "number" || typ == "integer" && isWhole(float64(v))
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		// As jsonutil.UnmarshalWithInt and NormalizeNumbers decode them.
+		return typ == "number" || typ == "integer"
+	case []interface{}:
+		return typ == "array"
+	case map[string]interface{}:
+		return typ == "object"
+	}
+	return false
+}
+
+// isWhole reports whether f is a finite whole number, such as 3.0.
+func isWhole(f float64) bool {
+	return !math.IsInf(f, 0) && f == math.Trunc(f)
+}
+
+// coerceTo converts data to typ if the conversion is obvious.
+func coerceTo(data interface{}, typ string) (interface{}, bool) {
+	if typ == "array" {
+		if data == nil {
+			return nil, false
+		}
+		return []interface{}{data}, true
+	}
+	s, ok := data.(string)
+	if !ok {
+		return nil, false
+	}
+	switch typ {
+	case "integer":
+		if isNumber(s) && isInteger(json.Number(s)) {
+			return json.Number(s), true
+		}
+	case "number":
+		if isNumber(s) {
+			return json.Number(s), true
+		}
+	case "boolean":
+		switch s {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		}
+	}
+	return nil, false
+}
+
+// isNumber reports whether s is a JSON number.
+func isNumber(s string) bool {
+	if s == "" || strings.TrimLeft(s, "-+.0123456789eE") != "" {
+		return false
+	}
+	var n json.Number
+	return json.Unmarshal([]byte(s), &n) == nil
+}
@@ -0,0 +1,74 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestCoerceTo(t *testing.T) {
+	tests := []struct {
+		data interface{}
+		typ  string
+		want interface{}
+		ok   bool
+	}{
+		{"42", "integer", json.Number("42"), true},
+		{"-7", "integer", json.Number("-7"), true},
+		{"1.5", "integer", nil, false},
+		{"1e3", "number", json.Number("1e3"), true},
+		{"0x10", "number", nil, false},
+		{"Inf", "number", nil, false},
+		{" 42", "integer", nil, false},
+		{"", "number", nil, false},
+		{"true", "boolean", true, true},
+		{"yes", "boolean", nil, false},
+		{"a", "array", []interface{}{"a"}, true},
+		{json.Number("1"), "string", nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := coerceTo(tt.data, tt.typ)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("coerceTo(%#v, %q) = %#v, %v, expected %#v, %v", tt.data, tt.typ, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestIsType(t *testing.T) {
+	tests := []struct {
+		data    interface{}
+		integer bool
+		number  bool
+	}{
+		{int(3), true, true},
+		{int8(-3), true, true},
+		{int64(1) << 62, true, true},
+		{uint(3), true, true},
+		{uint64(1) << 63, true, true},
+		{float64(3), true, true},
+		{float64(1e20), true, true},
+		{float32(2.5), false, true},
+		{3.5, false, true},
+		{math.Inf(1), false, true},
+		{math.NaN(), false, true},
+		{json.Number("3"), true, true},
+		{json.Number("3.5"), false, true},
+		{"3", false, false},
+		{true, false, false},
+	}
+	for _, tt := range tests {
+		if got := isType(tt.data, "integer"); got != tt.integer {
+			t.Errorf("isType(%#v, integer) = %v, expected %v", tt.data, got, tt.integer)
+		}
+		if got := isType(tt.data, "number"); got != tt.number {
+			t.Errorf("isType(%#v, number) = %v, expected %v", tt.data, got, tt.number)
+		}
+	}
+
+	// Go integers don't need coercing.
+	schema := compileSchema(t, `{"type": ["integer", "array"]}`)
+	if got := coerce(int64(42), schema); got != int64(42) {
+		t.Errorf("expected int64 42 to be kept, got %#v", got)
+	}
+}
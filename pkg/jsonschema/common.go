@@ -0,0 +1,68 @@
+package jsonschema
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// CommonBaseURL is the base of the $ids of the common schemas. Schemas
+// reference them by absolute URL, e.g.
+//
+//	{"$ref": "https://go-demo.dev/schemas/common/money.json"}
+//
+// and every compiler of this package, as well as Bundle, loads them from the
+// program instead of the network.
+const CommonBaseURL = "https://go-demo.dev/schemas/common/"
+
+// common holds the common schemas:
+//
+//	address.json     postal address with an ISO 3166-1 alpha-2 country
+//	money.json       amount in int64 minor units with an ISO 4217 currency
+//	pagination.json  page envelope: items, page, per_page, total, next_cursor
+//	timestamp.json   RFC 3339 date-time
+//	email.json       email address
+//	phone.json       E.164 phone number
+//
+//go:embed common/*.json
+var common embed.FS
+
+// CommonSchemas returns the $ids of the common schemas, sorted.
+func CommonSchemas() []string {
+	entries, _ := fs.ReadDir(common, "common")
+	ids := make([]string, 0, len(entries))
+	for _, e := range entries {
+		ids = append(ids, CommonBaseURL+e.Name())
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// loadCommon opens the common schema with the URL u. ok is false if u isn't
+// below CommonBaseURL.
+func loadCommon(u string) (r io.ReadCloser, ok bool, err error) {
+	name, ok := strings.CutPrefix(u, CommonBaseURL)
+	if !ok {
+		return nil, false, nil
+	}
+	f, err := common.Open("common/" + name)
+	if err != nil {
+		return nil, true, fmt.Errorf("%s is not a common schema", u)
+	}
+	return f, true, nil
+}
+
+// loadURL loads the common schemas from the program and other URLs with the
+// loaders registered in the jsonschema package, which handle file URLs and
+// any others the program registered, such as http.
+func loadURL(u string) (io.ReadCloser, error) {
+	if r, ok, err := loadCommon(u); ok {
+		return r, err
+	}
+	return jsonschema.LoadURL(u)
+}
@@ -0,0 +1,149 @@
+package jsonschema
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"go-demo/pkg/jsonutil"
+)
+
+func TestCommonSchemas(t *testing.T) {
+	ids := CommonSchemas()
+	if len(ids) != 6 || ids[0] != CommonBaseURL+"address.json" {
+		t.Fatalf("Unexpected common schemas: %v", ids)
+	}
+	for _, id := range ids {
+		schema, err := CompileString(`{"$ref": "` + id + `"}`)
+		if err != nil {
+			t.Errorf("%s doesn't compile: %v", id, err)
+			continue
+		}
+		doc := readCommon(t, id)
+		if doc["$id"] != id {
+			t.Errorf("%s has the $id %v", id, doc["$id"])
+		}
+		if _, err := Validate(schema, nil); err != nil {
+			t.Errorf("%s: %v", id, err)
+		}
+	}
+
+	if _, err := CompileString(`{"$ref": "` + CommonBaseURL + `iban.json"}`); err == nil || !strings.Contains(err.Error(), "not a common schema") {
+		t.Errorf("Expected an unknown common schema to fail, got %v", err)
+	}
+}
+
+func TestCommonSchemasValidate(t *testing.T) {
+	tests := []struct {
+		schema string
+		data   string
+		valid  bool
+	}{
+		{"address.json", `{"line1": "1 Main St", "city": "Springfield", "country": "US"}`, true},
+		{"address.json", `{"line1": "1 Main St", "city": "Springfield", "country": "usa"}`, false},
+		{"address.json", `{"line1": "1 Main St", "city": "Springfield", "country": "US", "zip": "1"}`, false},
+		{"money.json", `{"amount": 1999, "currency": "EUR"}`, true},
+		{"money.json", `{"amount": 9223372036854775807, "currency": "EUR"}`, true},
+		{"money.json", `{"amount": 9223372036854775808, "currency": "EUR"}`, false},
+		{"money.json", `{"amount": 19.99, "currency": "EUR"}`, false},
+		{"money.json", `{"amount": 1999}`, false},
+		{"pagination.json", `{"items": [], "page": 2, "next_cursor": null}`, true},
+		{"pagination.json", `{"items": [], "page": 0}`, false},
+		{"pagination.json", `{"page": 1}`, false},
+		{"timestamp.json", `"2024-05-01T12:30:00Z"`, true},
+		{"timestamp.json", `"2024-05-01T12:30:00+02:00"`, true},
+		{"timestamp.json", `"2024-05-01 12:30"`, false},
+		{"email.json", `"ada@example.com"`, true},
+		{"email.json", `"ada.example.com"`, false},
+		{"phone.json", `"+14155550123"`, true},
+		{"phone.json", `"4155550123"`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.schema+" "+tt.data, func(t *testing.T) {
+			schema, err := CompileString(`{"$schema": "https://json-schema.org/draft/2020-12/schema", "$ref": "` + CommonBaseURL + tt.schema + `"}`)
+			if err != nil {
+				t.Fatalf("CompileString failed: %v", err)
+			}
+			data, err := jsonutil.DecodeBytes([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("Invalid test data: %v", err)
+			}
+			violations, err := Validate(schema, data)
+			if err != nil {
+				t.Fatalf("Validate failed: %v", err)
+			}
+			if valid := len(violations) == 0; valid != tt.valid {
+				t.Errorf("Expected valid=%v, got %v", tt.valid, violations)
+			}
+		})
+	}
+}
+
+func TestCommonSchemasDefaults(t *testing.T) {
+	schema, err := CompileString(`{
+		"allOf": [{"$ref": "` + CommonBaseURL + `pagination.json"}],
+		"properties": {"items": {"items": {"$ref": "` + CommonBaseURL + `address.json"}}}
+	}`)
+	if err != nil {
+		t.Fatalf("CompileString failed: %v", err)
+	}
+	got := ApplyDefaults(map[string]interface{}{}, schema)
+	want := map[string]interface{}{"page": 1, "per_page": 50}
+	if !reflect.DeepEqual(normalize(t, got), normalize(t, want)) {
+		t.Errorf("ApplyDefaults() = %v, want %v", got, want)
+	}
+	violations, err := Validate(schema, map[string]interface{}{"items": []interface{}{map[string]interface{}{"city": "Springfield"}}})
+	if err != nil || len(violations) == 0 {
+		t.Errorf("Expected the items to be validated as addresses, got %v, %v", violations, err)
+	}
+}
+
+func TestCommonSchemasFSAndBundle(t *testing.T) {
+	ref := `{"type": "object", "properties": {"price": {"$ref": "` + CommonBaseURL + `money.json"}}}`
+	schemas, err := CompileFS(fstest.MapFS{"order.json": {Data: []byte(ref)}})
+	if err != nil {
+		t.Fatalf("CompileFS should resolve the common schemas: %v", err)
+	}
+	if violations, _ := Validate(schemas["order"], map[string]interface{}{"price": map[string]interface{}{"amount": "1"}}); len(violations) == 0 {
+		t.Error("Expected the price to be validated as money")
+	}
+
+	path := filepath.Join(t.TempDir(), "order.json")
+	if err := os.WriteFile(path, []byte(ref), 0o644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+	bundled, err := Bundle(path)
+	if err != nil {
+		t.Fatalf("Bundle failed: %v", err)
+	}
+	defs, _ := bundled["$defs"].(map[string]interface{})
+	if _, ok := defs["money"]; !ok {
+		t.Errorf("Expected the money schema to be inlined, got %v", bundled)
+	}
+}
+
+func readCommon(t *testing.T, id string) map[string]interface{} {
+	t.Helper()
+	doc, err := loadSchemaDocument(id)
+	if err != nil {
+		t.Fatalf("Failed to load %s: %v", id, err)
+	}
+	return doc.(map[string]interface{})
+}
+
+// normalize round-trips v through JSON so numbers compare equal.
+func normalize(t *testing.T, v interface{}) interface{} {
+	t.Helper()
+	b, err := jsonutil.Marshal(jsonutil.FormatJSON, v, 0)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	out, err := jsonutil.DecodeBytes(b)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	return out
+}
@@ -0,0 +1,172 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// newCompiler returns a compiler that keeps annotations such as default,
+// which ApplyDefaults depends on, and resolves the common schemas.
+func newCompiler() *jsonschema.Compiler {
+	compiler := jsonschema.NewCompiler()
+	compiler.ExtractAnnotations = true
+	compiler.LoadURL = loadURL
+	return compiler
+}
+
+// CompileFile compiles the schema stored in a file. Relative $refs are
+// resolved against the file's location. Errors are *SchemaCompileError
+// values.
+func CompileFile(path string) (*jsonschema.Schema, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, &SchemaCompileError{Location: path, Err: err}
+	}
+	schema, err := newCompiler().Compile(abs)
+	if err != nil {
+		return nil, &SchemaCompileError{Location: path, Err: err}
+	}
+	return schema, nil
+}
+
+// CompileString compiles an inline schema document. Errors are
+// *SchemaCompileError values.
+func CompileString(schema string) (*jsonschema.Schema, error) {
+	compiler := newCompiler()
+	if err := compiler.AddResource("schema.json", strings.NewReader(schema)); err != nil {
+		return nil, &SchemaCompileError{Location: "schema.json", Err: err}
+	}
+	s, err := compiler.Compile("schema.json")
+	if err != nil {
+		return nil, &SchemaCompileError{Location: "schema.json", Err: err}
+	}
+	return s, nil
+}
+
+// CompileDir compiles every *.json file below dir and returns the schemas by
+// their slash-separated path relative to dir without ".json", e.g.
+// "billing/invoice".
+func CompileDir(dir string) (map[string]*jsonschema.Schema, error) {
+	schemas := make(map[string]*jsonschema.Schema)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".json" {
+			return err
+		}
+		schema, err := CompileFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		schemas[strings.TrimSuffix(filepath.ToSlash(rel), ".json")] = schema
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return schemas, nil
+}
+
+// fsBaseURL is the base URL of the schemas of CompileFS.
+const fsBaseURL = "fs:///"
+
+// CompileFS compiles every *.json file in fsys, such as an embed.FS, and
+// returns the schemas by their slash-separated path without ".json", like
+// CompileDir. Hidden files and directories are skipped. The schemas can
+// reference each other by relative path ("../common/address.json#/$defs/x")
+// or by the absolute $id of the referenced file; references outside fsys
+// fail, except to the common schemas. Errors are *SchemaCompileError values.
+func CompileFS(fsys fs.FS) (map[string]*jsonschema.Schema, error) {
+	compiler := newCompiler()
+	compiler.LoadURL = func(u string) (io.ReadCloser, error) {
+		if r, ok, err := loadCommon(u); ok {
+			return r, err
+		}
+		return nil, fmt.Errorf("%s is not in the file system", u)
+	}
+	var names []string
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name != "." && strings.HasPrefix(path.Base(name), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || path.Ext(name) != ".json" {
+			return nil
+		}
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		if err := compiler.AddResource(fsBaseURL+name, bytes.NewReader(b)); err != nil {
+			return &SchemaCompileError{Location: name, Err: err}
+		}
+		// Make the file reachable by its $id too.
+		var doc struct {
+			ID string `json:"$id"`
+		}
+		if json.Unmarshal(b, &doc) == nil {
+			if u, err := url.Parse(doc.ID); err == nil && u.IsAbs() && u.Fragment == "" {
+				compiler.AddResource(doc.ID, bytes.NewReader(b))
+			}
+		}
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	schemas := make(map[string]*jsonschema.Schema, len(names))
+	for _, name := range names {
+		schema, err := compiler.Compile(fsBaseURL + name)
+		if err != nil {
+			return nil, &SchemaCompileError{Location: name, Err: err}
+		}
+		schemas[strings.TrimSuffix(name, ".json")] = schema
+	}
+	return schemas, nil
+}
+
+// inlineBaseURL is the base URL of the schema of CompileStringFS.
+const inlineBaseURL = "inline:///"
+
+// CompileStringFS compiles an inline schema document from an untrusted
+// source: its $refs can reach the schemas in fsys, by paths relative to its
+// root ("billing/invoice.json"), and the common schemas, but not other files
+// or URLs. fsys may be nil to allow the common schemas only. Errors are
+// *SchemaCompileError values.
+func CompileStringFS(schema string, fsys fs.FS) (*jsonschema.Schema, error) {
+	compiler := newCompiler()
+	compiler.LoadURL = func(u string) (io.ReadCloser, error) {
+		if r, ok, err := loadCommon(u); ok {
+			return r, err
+		}
+		if name, ok := strings.CutPrefix(u, inlineBaseURL); ok && fsys != nil && fs.ValidPath(name) {
+			return fsys.Open(name)
+		}
+		return nil, fmt.Errorf("%s is not in the file system", u)
+	}
+	if err := compiler.AddResource(inlineBaseURL+"schema.json", strings.NewReader(schema)); err != nil {
+		return nil, &SchemaCompileError{Location: "schema.json", Err: err}
+	}
+	s, err := compiler.Compile(inlineBaseURL + "schema.json")
+	if err != nil {
+		return nil, &SchemaCompileError{Location: "schema.json", Err: err}
+	}
+	return s, nil
+}
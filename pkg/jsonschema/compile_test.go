@@ -0,0 +1,135 @@
+package jsonschema
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCompileFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "address.json"), []byte(`{
+		"type": "object",
+		"properties": {"city": {"type": "string", "default": "Berlin"}}
+	}`), 0o644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "user.json"), []byte(`{
+		"type": "object",
+		"properties": {"address": {"$ref": "address.json"}}
+	}`), 0o644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+
+	schema, err := CompileFile(filepath.Join(dir, "user.json"))
+	if err != nil {
+		t.Fatalf("CompileFile failed: %v", err)
+	}
+	if v, ok := DefaultAt(schema, "/address/city"); !ok || v != "Berlin" {
+		t.Errorf("Defaults should be kept across $refs, got %v", v)
+	}
+
+	if _, err := CompileString(`{"type": 5}`); err == nil {
+		t.Error("Invalid schema should fail to compile")
+	}
+}
+
+func TestCompileDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"user.json":            `{"type": "object"}`,
+		"billing/invoice.json": `{"properties": {"user": {"$ref": "../user.json"}}}`,
+		"notes.txt":            `not a schema`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	schemas, err := CompileDir(dir)
+	if err != nil {
+		t.Fatalf("CompileDir failed: %v", err)
+	}
+	if len(schemas) != 2 || schemas["user"] == nil || schemas["billing/invoice"] == nil {
+		t.Errorf("expected the schemas user and billing/invoice, got %v", schemas)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte(`{"type": 5}`), 0o644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+	if _, err := CompileDir(dir); err == nil {
+		t.Error("A broken schema should fail CompileDir")
+	}
+}
+
+func TestCompileFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"common/address.json":  {Data: []byte(`{"$id": "https://example.com/address.json", "type": "object", "properties": {"country": {"default": "DE"}}, "required": ["city"]}`)},
+		"user.json":            {Data: []byte(`{"type": "object", "properties": {"home": {"$ref": "https://example.com/address.json"}, "work": {"$ref": "common/address.json"}}}`)},
+		"billing/invoice.json": {Data: []byte(`{"properties": {"user": {"$ref": "../user.json"}}}`)},
+		".drafts/broken.json":  {Data: []byte(`{"type": 5}`)},
+		"README.md":            {Data: []byte(`not a schema`)},
+	}
+	schemas, err := CompileFS(fsys)
+	if err != nil {
+		t.Fatalf("CompileFS failed: %v", err)
+	}
+	if len(schemas) != 3 || schemas["common/address"] == nil || schemas["user"] == nil || schemas["billing/invoice"] == nil {
+		t.Fatalf("expected the three schemas, got %v", schemas)
+	}
+	violations, err := Validate(schemas["billing/invoice"], map[string]interface{}{
+		"user": map[string]interface{}{"home": map[string]interface{}{}, "work": map[string]interface{}{"city": "Berlin"}},
+	})
+	if err != nil || len(violations) != 1 || violations[0].InstanceLocation != "/user/home" {
+		t.Errorf("expected the referenced address schema to apply, got %v (%v)", violations, err)
+	}
+
+	for name, content := range map[string]string{
+		"broken.json":  `{"type": 5}`,
+		"missing.json": `{"$ref": "other.json"}`,
+		"remote.json":  `{"$ref": "https://example.com/elsewhere.json"}`,
+	} {
+		_, err := CompileFS(fstest.MapFS{name: {Data: []byte(content)}})
+		var cerr *SchemaCompileError
+		if !errors.As(err, &cerr) || cerr.Location != name {
+			t.Errorf("%s: expected a SchemaCompileError, got %v", name, err)
+		}
+	}
+}
+
+func TestCompileStringFS(t *testing.T) {
+	outside := filepath.Join(t.TempDir(), "secret.json")
+	if err := os.WriteFile(outside, []byte(`{"type": "string"}`), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", outside, err)
+	}
+	fsys := fstest.MapFS{
+		"user.json":            {Data: []byte(`{"type": "object", "required": ["name"]}`)},
+		"billing/invoice.json": {Data: []byte(`{"properties": {"user": {"$ref": "../user.json"}}}`)},
+	}
+
+	schema, err := CompileStringFS(`{"properties": {"invoice": {"$ref": "billing/invoice.json"}, "email": {"$ref": "`+CommonBaseURL+`email.json"}}}`, fsys)
+	if err != nil {
+		t.Fatalf("CompileStringFS failed: %v", err)
+	}
+	if schema.Validate(map[string]interface{}{"invoice": map[string]interface{}{"user": map[string]interface{}{}}}) == nil {
+		t.Error("expected the referenced user schema to apply")
+	}
+
+	for _, ref := range []string{"missing.json", "../" + filepath.Base(outside), "file://" + filepath.ToSlash(outside), "https://example.com/elsewhere.json"} {
+		for _, fsys := range []fs.FS{fsys, nil} {
+			_, err := CompileStringFS(`{"$ref": "`+ref+`"}`, fsys)
+			var cerr *SchemaCompileError
+			if !errors.As(err, &cerr) {
+				t.Errorf("%s: expected a SchemaCompileError, got %v", ref, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package jsonschema
+
+import (
+	"context"
+	"sort"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// DeprecationWarning reports a property of the data whose schema is marked
+// deprecated.
+type DeprecationWarning struct {
+	// Pointer is the JSON Pointer of the property.
+	Pointer string `json:"pointer"`
+	// Description is the description of its schema, which usually says what
+	// to use instead; it is empty if the schema has none.
+	Description string `json:"description"`
+	// Location is the location of the schema marked deprecated.
+	Location string `json:"location"`
+}
+
+// ApplyDefaultsWithWarnings is ApplyDefaults that also returns a warning for
+// every property present in data whose schema is marked "deprecated": true,
+// sorted by pointer, so callers can surface migration notices without a
+// second pass. Like ApplyDefaults, properties skipped for being required, or
+// by SkipReadOnly or SkipWriteOnly, aren't looked into. It returns data
+// unchanged, and no warnings, if its options make it fail.
+func ApplyDefaultsWithWarnings(data interface{}, schema *jsonschema.Schema, opts ...DefaultsOption) (interface{}, []DeprecationWarning) {
+	warnings := []DeprecationWarning{}
+	d := &defaulter{ctx: context.Background(), opts: newDefaultsOptions(opts), deprecated: &warnings}
+	result := d.apply(data, schema, "")
+	if d.err != nil {
+		return data, []DeprecationWarning{}
+	}
+	sort.SliceStable(warnings, func(i, j int) bool {
+		return warnings[i].Pointer < warnings[j].Pointer
+	})
+	return result, warnings
+}
+
+// warnDeprecated records a warning for the property at pointer if any of the
+// annotationSchemas of its schema is deprecated, with the first description
+// among them.
+func (d *defaulter) warnDeprecated(schema *jsonschema.Schema, pointer string) {
+	if d.deprecated == nil {
+		return
+	}
+	schemas := d.annotationSchemas(schema)
+	for _, s := range schemas {
+		if !s.Deprecated {
+			continue
+		}
+		// A property several schemas describe is reported once.
+		for _, w := range *d.deprecated {
+			if w.Pointer == pointer {
+				return
+			}
+		}
+		w := DeprecationWarning{Pointer: pointer, Description: s.Description, Location: s.Location}
+		for _, other := range schemas {
+			if w.Description == "" {
+				w.Description = other.Description
+			}
+		}
+		*d.deprecated = append(*d.deprecated, w)
+		return
+	}
+}
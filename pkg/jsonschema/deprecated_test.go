@@ -0,0 +1,42 @@
+package jsonschema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyDefaultsWithWarnings(t *testing.T) {
+	schema := compileSchema(t, `{
+		"$defs": {"legacyId": {"type": "string", "deprecated": true, "description": "Use id instead."}},
+		"type": "object",
+		"properties": {
+			"userId": {"$ref": "#/$defs/legacyId"},
+			"fax": {"type": "string", "deprecated": true},
+			"mode": {"type": "string", "deprecated": true, "default": "legacy"},
+			"address": {
+				"type": "object",
+				"properties": {"zip": {"type": "string", "deprecated": true, "description": "Use postalCode."}, "city": {"default": "Berlin"}}
+			}
+		},
+		"allOf": [{"properties": {"fax": {"deprecated": true, "description": "Fax is going away."}}}]
+	}`)
+
+	got, warnings := ApplyDefaultsWithWarnings(parseJSON(t, `{"userId": "42", "fax": null, "address": {"zip": "10115"}}`), schema)
+	if want := parseJSON(t, `{"userId": "42", "fax": null, "mode": "legacy", "address": {"zip": "10115", "city": "Berlin"}}`); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	// Deprecated properties filled in by defaults aren't the caller's to migrate,
+	// and one several schemas deprecate is reported once.
+	want := []DeprecationWarning{
+		{Pointer: "/address/zip", Description: "Use postalCode.", Location: schema.Location + "/properties/address/properties/zip"},
+		{Pointer: "/fax", Description: "Fax is going away.", Location: schema.Location + "/allOf/0/properties/fax"},
+		{Pointer: "/userId", Description: "Use id instead.", Location: schema.Location + "/$defs/legacyId"},
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("expected %v, got %v", want, warnings)
+	}
+
+	if _, warnings := ApplyDefaultsWithWarnings(parseJSON(t, `{}`), schema); warnings == nil || len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %#v", warnings)
+	}
+}
@@ -0,0 +1,39 @@
+package jsonschema
+
+import "fmt"
+
+// Draft is a JSON Schema dialect that generated schemas are written in.
+type Draft string
+
+// Supported drafts.
+const (
+	Draft7    Draft = "draft-07"
+	Draft2020 Draft = "2020-12"
+)
+
+// ParseDraft parses a draft name: "draft-07" (or "07", "7") or "2020-12".
+func ParseDraft(s string) (Draft, error) {
+	switch s {
+	case "draft-07", "07", "7":
+		return Draft7, nil
+	case "2020-12", "draft-2020-12":
+		return Draft2020, nil
+	}
+	return "", fmt.Errorf("unknown draft %q (want draft-07 or 2020-12)", s)
+}
+
+// URI returns the $schema URI of the draft.
+func (d Draft) URI() string {
+	if d == Draft2020 {
+		return "https://json-schema.org/draft/2020-12/schema"
+	}
+	return "http://json-schema.org/draft-07/schema#"
+}
+
+// defsKey returns the keyword holding reusable subschemas in the draft.
+func (d Draft) defsKey() string {
+	if d == Draft2020 {
+		return "$defs"
+	}
+	return "definitions"
+}
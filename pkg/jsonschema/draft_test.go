@@ -0,0 +1,14 @@
+package jsonschema
+
+import "testing"
+
+func TestParseDraft(t *testing.T) {
+	for input, want := range map[string]Draft{"draft-07": Draft7, "7": Draft7, "2020-12": Draft2020} {
+		if got, err := ParseDraft(input); err != nil || got != want {
+			t.Errorf("ParseDraft(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseDraft("2019-09"); err == nil {
+		t.Error("Unsupported drafts should be rejected")
+	}
+}
@@ -0,0 +1,42 @@
+package jsonschema
+
+import (
+	"os"
+	"regexp"
+	"slices"
+)
+
+// envRef matches ${NAME} and ${NAME:-fallback}.
+var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// expandEnv returns def with ${NAME} expanded in its strings, including
+// those in objects and arrays, for the variables in allowed. The schema's
+// own default is not modified.
+func expandEnv(def interface{}, allowed []string) interface{} {
+	switch v := def.(type) {
+	case string:
+		return envRef.ReplaceAllStringFunc(v, func(ref string) string {
+			m := envRef.FindStringSubmatch(ref)
+			if !slices.Contains(allowed, m[1]) {
+				return ref
+			}
+			if value := os.Getenv(m[1]); value != "" {
+				return value
+			}
+			return m[2]
+		})
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for name, value := range v {
+			result[name] = expandEnv(value, allowed)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = expandEnv(item, allowed)
+		}
+		return result
+	}
+	return def
+}
@@ -0,0 +1,142 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go-demo/pkg/errcode"
+)
+
+// ErrInvalid is matched by *ValidationError with errors.Is.
+var ErrInvalid = errors.New("document is invalid")
+
+// ErrMaxDepth is returned when applying defaults nests more schemas than the
+// MaxDepth of DefaultsOptions.
+var ErrMaxDepth = errors.New("maximum schema depth")
+
+// ErrRefCycle is returned when applying defaults to a schema whose $refs only
+// lead to each other.
+var ErrRefCycle = errors.New("$ref cycle")
+
+// ErrAmbiguousOneOf is matched by *AmbiguousOneOfError with errors.Is.
+var ErrAmbiguousOneOf = errors.New("ambiguous oneOf")
+
+// SchemaCompileError is returned by CompileFile, CompileString and CompileDir
+// for a schema that can't be loaded or compiled. Its message is that of the
+// underlying error.
+type SchemaCompileError struct {
+	// Location identifies the schema: its file path, or "schema.json" for
+	// inline schemas.
+	Location string
+
+	// Err is the underlying cause.
+	Err error
+}
+
+func (e *SchemaCompileError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SchemaCompileError) Unwrap() error {
+	return e.Err
+}
+
+// Code returns errcode.SchemaCompile.
+func (e *SchemaCompileError) Code() errcode.Code {
+	return errcode.SchemaCompile
+}
+
+// Is reports whether target is errcode.SchemaCompile.
+func (e *SchemaCompileError) Is(target error) bool {
+	return target == errcode.SchemaCompile
+}
+
+// MarshalJSON encodes the error as {"code", "message", "location"}.
+func (e *SchemaCompileError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Code     errcode.Code `json:"code"`
+		Message  string       `json:"message"`
+		Location string       `json:"location"`
+	}{e.Code(), e.Error(), e.Location})
+}
+
+// ValidationError reports the violations of an invalid document. It matches
+// ErrInvalid and errcode.Validation with errors.Is.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Violations) == 0 {
+		return ErrInvalid.Error()
+	}
+	v := e.Violations[0]
+	msg := fmt.Sprintf("%s: %q: %s", ErrInvalid, v.InstanceLocation, v.Message)
+	if n := len(e.Violations) - 1; n > 0 {
+		msg += fmt.Sprintf(" (and %d more)", n)
+	}
+	return msg
+}
+
+// Code returns errcode.Validation.
+func (e *ValidationError) Code() errcode.Code {
+	return errcode.Validation
+}
+
+// Is reports whether target is ErrInvalid or errcode.Validation.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalid || target == errcode.Validation
+}
+
+// MarshalJSON encodes the error as {"code", "message", "violations"}.
+func (e *ValidationError) MarshalJSON() ([]byte, error) {
+	violations := e.Violations
+	if violations == nil {
+		violations = []Violation{}
+	}
+	return json.Marshal(struct {
+		Code       errcode.Code `json:"code"`
+		Message    string       `json:"message"`
+		Violations []Violation  `json:"violations"`
+	}{e.Code(), e.Error(), violations})
+}
+
+// AmbiguousOneOfError is returned when applying defaults with StrictBranches
+// to a value that matches more than one branch of a oneOf. It matches
+// ErrAmbiguousOneOf and errcode.Validation with errors.Is, since such a value
+// fails the oneOf.
+type AmbiguousOneOfError struct {
+	// Pointer is the JSON Pointer of the value.
+	Pointer string
+	// Location is the location of the schema with the oneOf.
+	Location string
+	// Branches are the indexes of the matching branches.
+	Branches []int
+}
+
+func (e *AmbiguousOneOfError) Error() string {
+	return fmt.Sprintf("%s: %q matches branches %v", ErrAmbiguousOneOf, e.Pointer, e.Branches)
+}
+
+// Code returns errcode.Validation.
+func (e *AmbiguousOneOfError) Code() errcode.Code {
+	return errcode.Validation
+}
+
+// Is reports whether target is ErrAmbiguousOneOf or errcode.Validation.
+func (e *AmbiguousOneOfError) Is(target error) bool {
+	return target == ErrAmbiguousOneOf || target == errcode.Validation
+}
+
+// MarshalJSON encodes the error as {"code", "message", "pointer", "location",
+// "branches"}.
+func (e *AmbiguousOneOfError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Code     errcode.Code `json:"code"`
+		Message  string       `json:"message"`
+		Pointer  string       `json:"pointer"`
+		Location string       `json:"location"`
+		Branches []int        `json:"branches"`
+	}{e.Code(), e.Error(), e.Pointer, e.Location, e.Branches})
+}
@@ -0,0 +1,66 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"go-demo/pkg/errcode"
+)
+
+func TestSchemaCompileError(t *testing.T) {
+	_, err := CompileString(`{"type": 1}`)
+	var ce *SchemaCompileError
+	if !errors.As(err, &ce) || ce.Location != "schema.json" {
+		t.Fatalf("expected a *SchemaCompileError for schema.json, got %T: %v", err, err)
+	}
+	if !errors.Is(err, errcode.SchemaCompile) {
+		t.Errorf("%v should match %q", err, errcode.SchemaCompile)
+	}
+	if _, err := CompileFile("testdata/missing.json"); errcode.Of(err) != errcode.SchemaCompile {
+		t.Errorf("expected a missing file to have code %q, got %v", errcode.SchemaCompile, err)
+	}
+
+	var got map[string]string
+	b, _ := json.Marshal(err)
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if got["code"] != "schema_compile_error" || got["location"] != "schema.json" || got["message"] != err.Error() {
+		t.Errorf("unexpected JSON %s", b)
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	err := &ValidationError{Violations: []Violation{
+		{InstanceLocation: "/id", KeywordLocation: "/required", Message: "missing"},
+		{InstanceLocation: "/qty", KeywordLocation: "/properties/qty/minimum", Message: "too small"},
+	}}
+	if want := `document is invalid: "/id": missing (and 1 more)`; err.Error() != want {
+		t.Errorf("expected %q, got %q", want, err.Error())
+	}
+	if !errors.Is(err, ErrInvalid) || !errors.Is(err, errcode.Validation) || errcode.Of(err) != errcode.Validation {
+		t.Errorf("%v should match ErrInvalid and %q", err, errcode.Validation)
+	}
+
+	b, _ := json.Marshal(&ValidationError{})
+	if want := `{"code":"validation_error","message":"document is invalid","violations":[]}`; string(b) != want {
+		t.Errorf("expected %s, got %s", want, b)
+	}
+}
+
+func TestAmbiguousOneOfError(t *testing.T) {
+	err := &AmbiguousOneOfError{Pointer: "/payment", Location: "schema.json#/properties/payment", Branches: []int{0, 2}}
+	if want := `ambiguous oneOf: "/payment" matches branches [0 2]`; err.Error() != want {
+		t.Errorf("expected %q, got %q", want, err.Error())
+	}
+	if !errors.Is(err, ErrAmbiguousOneOf) || errors.Is(err, ErrInvalid) || errcode.Of(err) != errcode.Validation {
+		t.Errorf("%v should match ErrAmbiguousOneOf and %q", err, errcode.Validation)
+	}
+
+	b, _ := json.Marshal(err)
+	want := `{"code":"validation_error","message":"ambiguous oneOf: \"/payment\" matches branches [0 2]","pointer":"/payment","location":"schema.json#/properties/payment","branches":[0,2]}`
+	if string(b) != want {
+		t.Errorf("expected %s, got %s", want, b)
+	}
+}
@@ -0,0 +1,109 @@
+package jsonschema
+
+import (
+	"context"
+	"sort"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Explanation describes what ApplyDefaults does to a document.
+type Explanation struct {
+	// Defaults lists the values ApplyDefaults fills in, sorted by pointer.
+	Defaults []AppliedDefault `json:"defaults"`
+
+	// Branches lists the oneOf and anyOf branches, then and else branches
+	// and dependent schemas whose defaults apply, sorted by pointer.
+	Branches []SelectedBranches `json:"branches"`
+}
+
+// AppliedDefault is a default filled in for a missing property.
+type AppliedDefault struct {
+	// Pointer is the JSON Pointer of the property that receives the default.
+	Pointer string `json:"pointer"`
+	// Value is the default.
+	Value interface{} `json:"value"`
+	// Location is the location of the schema the default comes from, with
+	// $refs resolved, e.g. "file:///schemas/order.json#/properties/currency".
+	Location string `json:"location"`
+}
+
+// SelectedBranches records the branches of a oneOf or anyOf, the then or
+// else of an if, or the dependent schemas of present properties that supply
+// defaults for the value at Pointer.
+type SelectedBranches struct {
+	Pointer string `json:"pointer"`
+	// Keyword is oneOf, anyOf, then, else, dependentSchemas or dependencies.
+	Keyword string `json:"keyword"`
+	// Selected are the indexes of the oneOf or anyOf branches whose
+	// defaults apply; it is empty for the other keywords.
+	Selected []int `json:"selected"`
+	// Matched is false when the value matched no branch (or, for oneOf,
+	// more than one), so the defaults of all branches apply, or of none
+	// with NoBranches.
+	Matched bool `json:"matched"`
+	// Locations are the schema locations of the selected branches.
+	Locations []string `json:"locations"`
+}
+
+// ApplyDefaultsWithReport is ApplyDefaults that also returns the defaults it
+// filled in, sorted by pointer, with the schema locations they come from.
+// Like ApplyDefaults, it returns data unchanged, and no defaults, if its
+// options make it fail.
+func ApplyDefaultsWithReport(data interface{}, schema *jsonschema.Schema, opts ...DefaultsOption) (interface{}, []AppliedDefault) {
+	result, explanation, err := ApplyDefaultsExplainCtx(context.Background(), data, schema, opts...)
+	if err != nil {
+		return data, []AppliedDefault{}
+	}
+	return result, explanation.Defaults
+}
+
+// ExplainDefaults returns what ApplyDefaults(data, schema) would change
+// without changing data: the defaults it would fill in and the combination
+// branches it would take them from. It returns an empty explanation if its
+// options make it fail; ExplainDefaultsCtx returns the error.
+func ExplainDefaults(data interface{}, schema *jsonschema.Schema, opts ...DefaultsOption) *Explanation {
+	explanation, err := ExplainDefaultsCtx(context.Background(), data, schema, opts...)
+	if err != nil {
+		return &Explanation{Defaults: []AppliedDefault{}, Branches: []SelectedBranches{}}
+	}
+	return explanation
+}
+
+// ExplainDefaultsCtx is ExplainDefaults that gives up with ctx's error as
+// soon as ctx is done.
+func ExplainDefaultsCtx(ctx context.Context, data interface{}, schema *jsonschema.Schema, opts ...DefaultsOption) (*Explanation, error) {
+	_, explanation, err := ApplyDefaultsExplainCtx(ctx, data, schema, opts...)
+	return explanation, err
+}
+
+// ApplyDefaultsExplainCtx is ApplyDefaultsCtx that also returns its
+// explanation, in one pass over the document instead of the two of
+// ExplainDefaultsCtx and ApplyDefaultsCtx.
+func ApplyDefaultsExplainCtx(ctx context.Context, data interface{}, schema *jsonschema.Schema, opts ...DefaultsOption) (interface{}, *Explanation, error) {
+	d := &defaulter{ctx: ctx, opts: newDefaultsOptions(opts), explain: &Explanation{Defaults: []AppliedDefault{}, Branches: []SelectedBranches{}}}
+	result := d.apply(data, schema, "")
+	if d.err != nil {
+		return nil, nil, d.err
+	}
+	sort.SliceStable(d.explain.Defaults, func(i, j int) bool {
+		return d.explain.Defaults[i].Pointer < d.explain.Defaults[j].Pointer
+	})
+	sort.SliceStable(d.explain.Branches, func(i, j int) bool {
+		return d.explain.Branches[i].Pointer < d.explain.Branches[j].Pointer
+	})
+	return result, d.explain, nil
+}
+
+func selectedBranches(pointer, keyword string, all, selected []*jsonschema.Schema, matched bool) SelectedBranches {
+	b := SelectedBranches{Pointer: pointer, Keyword: keyword, Matched: matched}
+	for _, s := range selected {
+		for i, candidate := range all {
+			if candidate == s {
+				b.Selected = append(b.Selected, i)
+				b.Locations = append(b.Locations, s.Location)
+			}
+		}
+	}
+	return b
+}
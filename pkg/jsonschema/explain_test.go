@@ -0,0 +1,127 @@
+package jsonschema
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go-demo/pkg/jsonutil"
+)
+
+func TestExplainDefaults(t *testing.T) {
+	schema, err := CompileString(`{
+		"type": "object",
+		"properties": {
+			"currency": {"type": "string", "default": "EUR"},
+			"name": {"type": "string", "default": "unnamed"},
+			"items": {"type": "array", "items": {"type": "object", "properties": {"qty": {"type": "integer", "default": 1}}}},
+			"payment": {"oneOf": [
+				{"properties": {"kind": {"const": "card"}, "network": {"default": "visa"}}, "required": ["kind"]},
+				{"properties": {"kind": {"const": "bank"}, "country": {"default": "DE"}}, "required": ["kind"]}
+			]},
+			"contact": {"anyOf": [
+				{"properties": {"email": {"type": "string"}, "verified": {"default": false}}, "required": ["email"]},
+				{"properties": {"phone": {"type": "string"}, "sms": {"default": true}}, "required": ["phone"]}
+			]}
+		},
+		"required": ["name"]
+	}`)
+	if err != nil {
+		t.Fatalf("Failed to compile schema: %v", err)
+	}
+	data, _ := jsonutil.DecodeBytes([]byte(`{"items": [{}, {"qty": 3}], "payment": {"kind": "bank"}, "contact": {}}`))
+	before, _ := jsonutil.DecodeBytes([]byte(`{"items": [{}, {"qty": 3}], "payment": {"kind": "bank"}, "contact": {}}`))
+
+	x := ExplainDefaults(data, schema)
+
+	wantDefaults := []AppliedDefault{
+		{"/contact/sms", true, schema.Location + "/properties/contact/anyOf/1/properties/sms"},
+		{"/contact/verified", false, schema.Location + "/properties/contact/anyOf/0/properties/verified"},
+		{"/currency", "EUR", schema.Location + "/properties/currency"},
+		{"/items/0/qty", json.Number("1"), schema.Location + "/properties/items/items/properties/qty"},
+		{"/payment/country", "DE", schema.Location + "/properties/payment/oneOf/1/properties/country"},
+	}
+	if !reflect.DeepEqual(x.Defaults, wantDefaults) {
+		t.Errorf("unexpected defaults:\n got %v\nwant %v", x.Defaults, wantDefaults)
+	}
+
+	if len(x.Branches) != 2 {
+		t.Fatalf("expected 2 branch selections, got %+v", x.Branches)
+	}
+	for _, b := range x.Branches {
+		switch b.Pointer {
+		case "/payment":
+			if b.Keyword != "oneOf" || !b.Matched || !reflect.DeepEqual(b.Selected, []int{1}) {
+				t.Errorf("expected oneOf branch 1 for /payment, got %+v", b)
+			}
+		case "/contact":
+			if b.Keyword != "anyOf" || b.Matched || !reflect.DeepEqual(b.Selected, []int{0, 1}) {
+				t.Errorf("expected all anyOf branches for an unmatched /contact, got %+v", b)
+			}
+		default:
+			t.Errorf("unexpected branch selection %+v", b)
+		}
+	}
+
+	// Every explained default is what ApplyDefaults fills in.
+	applied := ApplyDefaults(data, schema)
+	for _, d := range x.Defaults {
+		tokens, _ := jsonutil.SplitPointer(d.Pointer)
+		if got, ok := lookupValue(applied, tokens); !ok || !reflect.DeepEqual(got, d.Value) {
+			t.Errorf("ApplyDefaults set %s to %v, explained %v", d.Pointer, got, d.Value)
+		}
+	}
+	if !reflect.DeepEqual(data, before) {
+		t.Errorf("ExplainDefaults modified the data: %v", data)
+	}
+
+	// Applying and explaining in one pass gives the results of both.
+	doc, both, err := ApplyDefaultsExplainCtx(context.Background(), data, schema)
+	if err != nil {
+		t.Fatalf("ApplyDefaultsExplainCtx failed: %v", err)
+	}
+	if !reflect.DeepEqual(doc, applied) || !reflect.DeepEqual(both, x) {
+		t.Errorf("ApplyDefaultsExplainCtx = %v, %+v; want %v, %+v", doc, both, applied, x)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if x, err := ExplainDefaultsCtx(ctx, data, schema); !errors.Is(err, context.Canceled) || x != nil {
+		t.Errorf("expected context.Canceled, got %v, %v", x, err)
+	}
+	if doc, x, err := ApplyDefaultsExplainCtx(ctx, data, schema); !errors.Is(err, context.Canceled) || doc != nil || x != nil {
+		t.Errorf("expected context.Canceled, got %v, %v, %v", doc, x, err)
+	}
+}
+
+func TestApplyDefaultsWithReport(t *testing.T) {
+	schema := compileSchema(t, `{
+		"type": "object",
+		"properties": {
+			"currency": {"type": "string", "default": "EUR"},
+			"billing": {"$ref": "#/$defs/address"},
+			"shipping": {"$ref": "#/$defs/address"}
+		},
+		"$defs": {
+			"address": {"type": "object", "properties": {"country": {"type": "string", "default": "DE"}}}
+		}
+	}`)
+
+	got, report := ApplyDefaultsWithReport(parseJSON(t, `{"shipping": {"country": "FR"}}`), schema)
+	if b, _ := json.Marshal(got); string(b) != `{"billing":{"country":"DE"},"currency":"EUR","shipping":{"country":"FR"}}` {
+		t.Errorf("unexpected result %s", b)
+	}
+	want := []AppliedDefault{
+		{Pointer: "/billing/country", Value: "DE", Location: schema.Location + "/$defs/address/properties/country"},
+		{Pointer: "/currency", Value: "EUR", Location: schema.Location + "/properties/currency"},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("expected %v, got %v", want, report)
+	}
+
+	if _, report := ApplyDefaultsWithReport(parseJSON(t, `{"currency": "USD", "billing": {"country": "NL"}, "shipping": {"country": "FR"}}`), schema); len(report) != 0 {
+		t.Errorf("expected an empty report, got %v", report)
+	}
+}
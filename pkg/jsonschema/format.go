@@ -0,0 +1,26 @@
+package jsonschema
+
+import (
+	"fmt"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// RegisterFormats registers validators for the "format" keyword with every
+// compiler. Like pongo2.RegisterFilters, it fails without registering anything
+// if one of the names is already taken.
+//
+// Formats are asserted for draft-07 and earlier schemas; 2019-09 and later
+// treat "format" as an annotation unless the format-assertion vocabulary is
+// enabled.
+func RegisterFormats(formats map[string]func(interface{}) bool) error {
+	for name := range formats {
+		if _, ok := jsonschema.Formats[name]; ok {
+			return fmt.Errorf("format %q is already registered", name)
+		}
+	}
+	for name, fn := range formats {
+		jsonschema.Formats[name] = fn
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package jsonschema
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// registerFormats registers formats for the duration of a test, so that it
+// can run again with go test -count.
+func registerFormats(t *testing.T, formats map[string]func(interface{}) bool) {
+	t.Helper()
+	if err := RegisterFormats(formats); err != nil {
+		t.Fatalf("RegisterFormats failed: %v", err)
+	}
+	t.Cleanup(func() {
+		for name := range formats {
+			delete(jsonschema.Formats, name)
+		}
+	})
+}
+
+func TestRegisterFormats(t *testing.T) {
+	even := func(v interface{}) bool {
+		s, ok := v.(string)
+		return !ok || len(s)%2 == 0
+	}
+
+	if err := RegisterFormats(map[string]func(interface{}) bool{"test-even": even, "email": even}); err == nil {
+		t.Fatal("Registering an existing format should fail")
+	}
+	registerFormats(t, map[string]func(interface{}) bool{"test-even": even})
+
+	schema, err := CompileString(`{"$schema": "http://json-schema.org/draft-07/schema#", "type": "string", "format": "test-even"}`)
+	if err != nil {
+		t.Fatalf("Failed to compile schema: %v", err)
+	}
+	if err := schema.Validate("ab"); err != nil {
+		t.Errorf("expected %q to be valid: %v", "ab", err)
+	}
+	if err := schema.Validate("abc"); err == nil || !strings.Contains(err.Error(), "test-even") {
+		t.Errorf("expected a format error for %q, got %v", "abc", err)
+	}
+}
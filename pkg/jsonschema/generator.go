@@ -0,0 +1,80 @@
+package jsonschema
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrUnknownGenerator is returned when applying a computed default whose
+// generator isn't registered.
+var ErrUnknownGenerator = errors.New("unknown default generator")
+
+// generatorKey is the member of a computed default naming its generator.
+const generatorKey = "$generator"
+
+var (
+	generatorsMu sync.RWMutex
+	generators   = map[string]func() interface{}{
+		"now":      func() interface{} { return time.Now().UTC().Format(time.RFC3339) },
+		"uuid":     newUUID,
+		"hostname": hostname,
+	}
+)
+
+// RegisterDefaultGenerator registers fn as the generator name of computed
+// defaults. A default of the form {"$generator": "name"} is replaced by what
+// the generator returns each time it is applied, so schemas can default to
+// values that can't be written down, such as the current time. "now" (an
+// RFC 3339 timestamp in UTC), "uuid" (a random version 4 UUID) and
+// "hostname" are built in. Like RegisterFormats, it fails if the name is
+// already taken.
+func RegisterDefaultGenerator(name string, fn func() interface{}) error {
+	generatorsMu.Lock()
+	defer generatorsMu.Unlock()
+	if _, ok := generators[name]; ok {
+		return fmt.Errorf("default generator %q is already registered", name)
+	}
+	generators[name] = fn
+	return nil
+}
+
+// generatorName returns the generator a computed default names.
+func generatorName(def interface{}) (string, bool) {
+	obj, ok := def.(map[string]interface{})
+	if !ok || len(obj) != 1 {
+		return "", false
+	}
+	name, ok := obj[generatorKey].(string)
+	return name, ok
+}
+
+// generate returns the value of the generator name.
+func generate(name string) (interface{}, error) {
+	generatorsMu.RLock()
+	fn, ok := generators[name]
+	generatorsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownGenerator, name)
+	}
+	return fn(), nil
+}
+
+func newUUID() interface{} {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func hostname() interface{} {
+	name, err := os.Hostname()
+	if err != nil {
+		return nil
+	}
+	return name
+}
@@ -0,0 +1,60 @@
+package jsonschema
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestComputedDefaults(t *testing.T) {
+	schema := compileSchema(t, `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string", "default": {"$generator": "uuid"}},
+			"createdAt": {"type": "string", "default": {"$generator": "now"}},
+			"owner": {"type": "string", "default": {"$generator": "test-owner"}},
+			"meta": {"default": {"$generator": "uuid", "other": 1}}
+		}
+	}`)
+	if err := RegisterDefaultGenerator("test-owner", func() interface{} { return "ops" }); err != nil {
+		t.Fatalf("RegisterDefaultGenerator failed: %v", err)
+	}
+	t.Cleanup(func() {
+		generatorsMu.Lock()
+		defer generatorsMu.Unlock()
+		delete(generators, "test-owner")
+	})
+	if err := RegisterDefaultGenerator("test-owner", func() interface{} { return "dev" }); err == nil {
+		t.Error("expected registering a taken name to fail")
+	}
+
+	result := ApplyDefaults(parseJSON(t, `{"id": "fixed"}`), schema).(map[string]interface{})
+	if result["id"] != "fixed" {
+		t.Errorf("expected a present value to be kept, got %v", result["id"])
+	}
+	if result["owner"] != "ops" {
+		t.Errorf("expected the registered generator's value, got %v", result["owner"])
+	}
+	if _, err := time.Parse(time.RFC3339, result["createdAt"].(string)); err != nil {
+		t.Errorf("expected an RFC 3339 timestamp, got %v", result["createdAt"])
+	}
+	// Objects with other members are ordinary defaults.
+	if meta := result["meta"].(map[string]interface{}); meta["other"] == nil {
+		t.Errorf("expected the literal object default, got %v", meta)
+	}
+
+	// Each application generates a new value.
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	first := ApplyDefaults(parseJSON(t, `{}`), schema).(map[string]interface{})["id"].(string)
+	second := ApplyDefaults(parseJSON(t, `{}`), schema).(map[string]interface{})["id"].(string)
+	if !uuid.MatchString(first) || first == second {
+		t.Errorf("expected two different UUIDs, got %q and %q", first, second)
+	}
+
+	unknown := compileSchema(t, `{"properties": {"x": {"default": {"$generator": "missing"}}}}`)
+	if _, err := ApplyDefaultsCtx(context.Background(), parseJSON(t, `{}`), unknown); !errors.Is(err, ErrUnknownGenerator) {
+		t.Errorf("expected ErrUnknownGenerator, got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// InferSchema generates a schema that accepts every sample document. Object
+// properties present in all samples are required; array items share one
+// schema inferred from every element; values seen with several types get a
+// list of types, with integers widened to numbers when mixed with floats.
+// Strings that are all RFC 3339 dates or date-times get a format.
+func InferSchema(draft Draft, samples ...interface{}) map[string]interface{} {
+	acc := &inferred{}
+	for _, sample := range samples {
+		acc.add(sample)
+	}
+	schema := acc.schema()
+	schema["$schema"] = draft.URI()
+	return schema
+}
+
+// inferred accumulates what the samples at one location look like.
+type inferred struct {
+	types map[string]bool
+
+	objects    int
+	properties map[string]*inferred
+	seen       map[string]int // number of objects that had each property
+
+	items *inferred
+
+	strings   int
+	dates     int
+	dateTimes int
+}
+
+func (in *inferred) add(v interface{}) {
+	if in.types == nil {
+		in.types = make(map[string]bool)
+	}
+	switch v := v.(type) {
+	case nil:
+		in.types["null"] = true
+	case bool:
+		in.types["boolean"] = true
+	case json.Number:
+		if _, err := v.Int64(); err == nil || isInteger(v) {
+			in.types["integer"] = true
+		} else {
+			in.types["number"] = true
+		}
+	case float64:
+		if v == float64(int64(v)) {
+			in.types["integer"] = true
+		} else {
+			in.types["number"] = true
+		}
+	case string:
+		in.types["string"] = true
+		in.strings++
+		if _, err := time.Parse(time.RFC3339, v); err == nil {
+			in.dateTimes++
+		} else if _, err := time.Parse("2006-01-02", v); err == nil {
+			in.dates++
+		}
+	case []interface{}:
+		in.types["array"] = true
+		if in.items == nil {
+			in.items = &inferred{}
+		}
+		for _, item := range v {
+			in.items.add(item)
+		}
+	case map[string]interface{}:
+		in.types["object"] = true
+		in.objects++
+		if in.properties == nil {
+			in.properties = make(map[string]*inferred)
+			in.seen = make(map[string]int)
+		}
+		for name, child := range v {
+			prop, ok := in.properties[name]
+			if !ok {
+				prop = &inferred{}
+				in.properties[name] = prop
+			}
+			prop.add(child)
+			in.seen[name]++
+		}
+	}
+}
+
+// isInteger reports whether a number too large for an int64 has no fraction
+// or exponent.
+func isInteger(n json.Number) bool {
+	for _, c := range n.String() {
+		if c == '.' || c == 'e' || c == 'E' {
+			return false
+		}
+	}
+	return true
+}
+
+func (in *inferred) schema() map[string]interface{} {
+	schema := map[string]interface{}{}
+	if in.types["integer"] && in.types["number"] {
+		delete(in.types, "integer")
+	}
+	types := make([]string, 0, len(in.types))
+	for t := range in.types {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	switch len(types) {
+	case 0:
+		return schema
+	case 1:
+		schema["type"] = types[0]
+	default:
+		schema["type"] = types
+	}
+
+	if in.objects > 0 {
+		properties := map[string]interface{}{}
+		var required []string
+		for name, prop := range in.properties {
+			properties[name] = prop.schema()
+			if in.seen[name] == in.objects {
+				required = append(required, name)
+			}
+		}
+		schema["properties"] = properties
+		if len(required) > 0 {
+			sort.Strings(required)
+			schema["required"] = required
+		}
+	}
+	if in.items != nil && in.items.types != nil {
+		schema["items"] = in.items.schema()
+	}
+	switch {
+	case in.strings == 0:
+	case in.dateTimes == in.strings:
+		schema["format"] = "date-time"
+	case in.dates == in.strings:
+		schema["format"] = "date"
+	}
+	return schema
+}
@@ -0,0 +1,50 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"go-demo/pkg/jsonutil"
+)
+
+func TestInferSchema(t *testing.T) {
+	var samples []interface{}
+	for _, doc := range []string{
+		`{"id": 1, "name": "Alice", "score": 1.5, "joined": "2024-05-01T10:00:00Z", "tags": ["a"], "address": {"city": "Berlin"}}`,
+		`{"id": 12345678901234567890, "name": null, "score": 2, "joined": "2024-06-01T09:30:00+02:00", "tags": [], "birthday": "1990-01-02"}`,
+	} {
+		v, err := jsonutil.DecodeBytes([]byte(doc))
+		if err != nil {
+			t.Fatalf("Failed to decode sample: %v", err)
+		}
+		samples = append(samples, v)
+	}
+
+	schema := InferSchema(Draft2020, samples...)
+	got, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want := `{"$schema":"https://json-schema.org/draft/2020-12/schema","properties":{` +
+		`"address":{"properties":{"city":{"type":"string"}},"required":["city"],"type":"object"},` +
+		`"birthday":{"format":"date","type":"string"},` +
+		`"id":{"type":"integer"},` +
+		`"joined":{"format":"date-time","type":"string"},` +
+		`"name":{"type":["null","string"]},` +
+		`"score":{"type":"number"},` +
+		`"tags":{"items":{"type":"string"},"type":"array"}},` +
+		`"required":["id","joined","name","score","tags"],"type":"object"}`
+	if string(got) != want {
+		t.Errorf("unexpected schema:\n got: %s\nwant: %s", got, want)
+	}
+
+	compiled, err := CompileString(string(got))
+	if err != nil {
+		t.Fatalf("Inferred schema doesn't compile: %v", err)
+	}
+	for i, sample := range samples {
+		if err := compiled.Validate(sample); err != nil {
+			t.Errorf("Sample %d should be valid: %v", i, err)
+		}
+	}
+}
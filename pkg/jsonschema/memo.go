@@ -0,0 +1,64 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// validKey identifies a validation of a value against a schema. Objects and
+// arrays are identified by their address and length: the defaulter copies
+// the values it changes instead of changing them in place, so a value at the
+// same address is the same value for the length of a call. Scalars are
+// identified by themselves.
+type validKey struct {
+	schema *jsonschema.Schema
+	addr   uintptr
+	len    int
+	scalar interface{}
+}
+
+// validation is the memoized result of a validation. It holds the value it
+// validated, so that its address isn't reused while the result is kept.
+type validation struct {
+	data  interface{}
+	valid bool
+}
+
+// valid reports whether data is valid against schema, validating each value
+// against each schema only once per call, however many nested combinations
+// and conditions ask.
+func (d *defaulter) valid(schema *jsonschema.Schema, data interface{}) bool {
+	key, ok := validKeyOf(schema, data)
+	if !ok {
+		return schema.Validate(data) == nil
+	}
+	if v, ok := d.validated[key]; ok {
+		return v.valid
+	}
+	valid := schema.Validate(data) == nil
+	if d.validated == nil {
+		d.validated = map[validKey]validation{}
+	}
+	d.validated[key] = validation{data: data, valid: valid}
+	return valid
+}
+
+// validKeyOf returns the key of the validation of data against schema, or
+// false for values it can't identify, such as structs, which are validated
+// every time.
+func validKeyOf(schema *jsonschema.Schema, data interface{}) (validKey, bool) {
+	key := validKey{schema: schema}
+	switch v := data.(type) {
+	case map[string]interface{}:
+		key.addr, key.len = reflect.ValueOf(v).Pointer(), len(v)
+	case []interface{}:
+		key.addr, key.len = reflect.ValueOf(v).Pointer(), len(v)
+	case nil, string, bool, float64, int, int64, json.Number:
+		key.scalar = v
+	default:
+		return key, false
+	}
+	return key, true
+}
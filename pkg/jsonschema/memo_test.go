@@ -0,0 +1,46 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDefaulterValidMemoizes(t *testing.T) {
+	calls := 0
+	registerFormats(t, map[string]func(interface{}) bool{"test-counted": func(interface{}) bool {
+		calls++
+		return true
+	}})
+	schema := compileSchema(t, `{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"properties": {"name": {"format": "test-counted"}}
+	}`)
+
+	d := &defaulter{}
+	data := map[string]interface{}{"name": "a"}
+	for i := 0; i < 3; i++ {
+		if !d.valid(schema, data) {
+			t.Fatal("expected the data to be valid")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected one validation of the same object, got %d", calls)
+	}
+
+	// Other values, even equal ones, and other schemas are validated anew.
+	d.valid(schema, map[string]interface{}{"name": "a"})
+	d.valid(schema.Properties["name"], "a")
+	d.valid(schema.Properties["name"], "a")
+	d.valid(schema.Properties["name"], json.Number("1"))
+	if calls != 4 {
+		t.Errorf("expected 4 validations, got %d", calls)
+	}
+
+	// Values it can't identify are validated every time.
+	type point struct{ X int }
+	d.valid(schema.Properties["name"], point{})
+	d.valid(schema.Properties["name"], point{})
+	if calls != 6 {
+		t.Errorf("expected 6 validations, got %d", calls)
+	}
+}
@@ -0,0 +1,244 @@
+package jsonschema
+
+// DefaultsOptions configures how ApplyDefaults fills in defaults. The zero
+// value is the behavior of ApplyDefaults without options.
+type DefaultsOptions struct {
+	// FillRequired fills in the defaults of required properties too. By
+	// default they are skipped, since the data must provide them.
+	FillRequired bool
+
+	// FillConst fills in the const of a schema without a default as if it
+	// were its default. By default only explicit defaults are filled in.
+	FillConst bool
+
+	// FillExamples fills in the first of the examples of a schema without a
+	// default, or a const under FillConst, as if it were its default, for
+	// schemas that only ship examples.
+	FillExamples bool
+
+	// FillContainerDefaults starts a missing object or array whose schema
+	// has an object or array default from a copy of that default, and then
+	// applies the defaults of its own properties and items to it, as struct
+	// tag defaults (see FromType) fill in a struct field. By default such a
+	// property starts empty and gets only the defaults inside it.
+	FillContainerDefaults bool
+
+	// NormalizeNumbers fills in numbers as int64, or float64 if they aren't
+	// integers that fit one, as jsonutil.UnmarshalWithInt decodes them. By
+	// default they are json.Number, as the compiled schema holds them.
+	NormalizeNumbers bool
+
+	// Coerce converts values that don't have the type of their schema but
+	// obviously stand for one before applying defaults: "42" to 42, "true"
+	// to true and a single value to an array of it, as in data from
+	// environment variables and query strings.
+	Coerce bool
+
+	// PadArrays appends items to arrays shorter than their minItems, so
+	// partial input becomes a valid document. Each item is the default of
+	// its items schema, with the defaults of its own properties, or else
+	// what GenerateSkeleton makes of the items schema.
+	PadArrays bool
+
+	// LegacyAdditionalItems gives the items beyond a draft-07 tuple (an
+	// items array) the defaults of its last schema, as earlier versions
+	// did, unless additionalItems has a schema for them. By default they
+	// get the defaults of the additionalItems schema, and none if it is
+	// missing or a boolean.
+	LegacyAdditionalItems bool
+
+	// SkipReadOnly applies no defaults to readOnly properties, nor inside
+	// them, as in client input, where OpenAPI expects them to be left out.
+	SkipReadOnly bool
+
+	// SkipWriteOnly applies no defaults to writeOnly properties, nor inside
+	// them, as in server output, where OpenAPI expects them to be left out.
+	SkipWriteOnly bool
+
+	// ExpandEnv names the environment variables that ${NAME} in string
+	// defaults is expanded from, for configuration that defaults to its
+	// environment. ${NAME:-fallback} stands for fallback when NAME is unset
+	// or empty. References to variables not named here are left as they
+	// are, so a schema can't read the whole environment.
+	ExpandEnv []string
+
+	// Empty decides what happens to the empty objects and arrays created
+	// for missing properties that end up without any defaults.
+	Empty EmptyPolicy
+
+	// Branches decides which branches of a oneOf or anyOf supply defaults
+	// when the data doesn't select any.
+	Branches BranchPolicy
+
+	// MaxDepth is the number of schemas, counting each $ref and
+	// combination, that may be nested while applying defaults before
+	// ApplyDefaultsCtx fails with ErrMaxDepth. Zero means DefaultMaxDepth.
+	MaxDepth int
+}
+
+// DefaultMaxDepth is the MaxDepth of DefaultsOptions that don't set one. It
+// allows far deeper documents than any schema written by hand.
+const DefaultMaxDepth = 1000
+
+func (o DefaultsOptions) maxDepth() int {
+	if o.MaxDepth > 0 {
+		return o.MaxDepth
+	}
+	return DefaultMaxDepth
+}
+
+// EmptyPolicy decides whether ApplyDefaults adds missing properties whose
+// value would be an empty object or array.
+type EmptyPolicy int
+
+const (
+	// DropEmpty leaves such properties out, so that filling in defaults
+	// doesn't add {} and [] for every optional object and array.
+	DropEmpty EmptyPolicy = iota
+	// KeepEmpty adds them, including schema defaults of {} and [].
+	KeepEmpty
+	// KeepEmptyDefaults adds them only if their schema has a default, so
+	// that a default of {} or [] is filled in as written while optional
+	// objects and arrays without one are still left out.
+	KeepEmptyDefaults
+)
+
+// BranchPolicy decides which branches of a oneOf or anyOf supply defaults
+// when the data matches none of them, or, for oneOf, more than one.
+type BranchPolicy int
+
+const (
+	// AllBranches applies the defaults of every branch, in order.
+	AllBranches BranchPolicy = iota
+	// NoBranches applies the defaults of none of them.
+	NoBranches
+	// StrictBranches applies the defaults of none of them either, but fails
+	// with an *AmbiguousOneOfError when the data matches more than one
+	// branch of a oneOf instead of guessing.
+	StrictBranches
+)
+
+// DefaultsOption adjusts the DefaultsOptions of a call.
+type DefaultsOption func(*DefaultsOptions)
+
+// WithDefaultsOptions replaces the options of a call with opts; later
+// options adjust them further.
+func WithDefaultsOptions(opts DefaultsOptions) DefaultsOption {
+	return func(o *DefaultsOptions) {
+		*o = opts
+	}
+}
+
+// FillRequired fills in the defaults of required properties too.
+func FillRequired() DefaultsOption {
+	return func(o *DefaultsOptions) {
+		o.FillRequired = true
+	}
+}
+
+// FillConst fills in consts as implicit defaults.
+func FillConst() DefaultsOption {
+	return func(o *DefaultsOptions) {
+		o.FillConst = true
+	}
+}
+
+// FillExamples fills in first examples as implicit defaults.
+func FillExamples() DefaultsOption {
+	return func(o *DefaultsOptions) {
+		o.FillExamples = true
+	}
+}
+
+// FillContainerDefaults starts missing objects and arrays from their own
+// defaults.
+func FillContainerDefaults() DefaultsOption {
+	return func(o *DefaultsOptions) {
+		o.FillContainerDefaults = true
+	}
+}
+
+// NormalizeNumbers fills in numbers as int64 and float64 instead of
+// json.Number.
+func NormalizeNumbers() DefaultsOption {
+	return func(o *DefaultsOptions) {
+		o.NormalizeNumbers = true
+	}
+}
+
+// CoerceTypes converts values to the type of their schema where obvious.
+func CoerceTypes() DefaultsOption {
+	return func(o *DefaultsOptions) {
+		o.Coerce = true
+	}
+}
+
+// PadArrays pads arrays up to their minItems.
+func PadArrays() DefaultsOption {
+	return func(o *DefaultsOptions) {
+		o.PadArrays = true
+	}
+}
+
+// LegacyAdditionalItems applies the last tuple schema to the items beyond
+// it.
+func LegacyAdditionalItems() DefaultsOption {
+	return func(o *DefaultsOptions) {
+		o.LegacyAdditionalItems = true
+	}
+}
+
+// SkipReadOnly leaves readOnly properties without defaults, for requests.
+func SkipReadOnly() DefaultsOption {
+	return func(o *DefaultsOptions) {
+		o.SkipReadOnly = true
+	}
+}
+
+// SkipWriteOnly leaves writeOnly properties without defaults, for
+// responses.
+func SkipWriteOnly() DefaultsOption {
+	return func(o *DefaultsOptions) {
+		o.SkipWriteOnly = true
+	}
+}
+
+// ExpandEnv expands ${NAME} in string defaults from the environment
+// variables names.
+func ExpandEnv(names ...string) DefaultsOption {
+	return func(o *DefaultsOptions) {
+		o.ExpandEnv = append(o.ExpandEnv, names...)
+	}
+}
+
+// WithEmptyPolicy sets what happens to empty objects and arrays created for
+// missing properties.
+func WithEmptyPolicy(p EmptyPolicy) DefaultsOption {
+	return func(o *DefaultsOptions) {
+		o.Empty = p
+	}
+}
+
+// WithBranchPolicy sets which oneOf and anyOf branches supply defaults when
+// the data doesn't select any.
+func WithBranchPolicy(p BranchPolicy) DefaultsOption {
+	return func(o *DefaultsOptions) {
+		o.Branches = p
+	}
+}
+
+// WithMaxDepth sets how deeply schemas may nest while applying defaults.
+func WithMaxDepth(n int) DefaultsOption {
+	return func(o *DefaultsOptions) {
+		o.MaxDepth = n
+	}
+}
+
+// newDefaultsOptions applies opts to the zero options.
+func newDefaultsOptions(opts []DefaultsOption) DefaultsOptions {
+	var o DefaultsOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
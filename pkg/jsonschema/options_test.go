@@ -0,0 +1,422 @@
+package jsonschema
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApplyDefaultsOptions(t *testing.T) {
+	schema := compileSchema(t, `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string", "default": "new"},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"meta": {"type": "object", "properties": {"owner": {"type": "string"}}},
+			"labels": {"type": "object", "default": {}},
+			"payment": {
+				"oneOf": [
+					{"type": "object", "properties": {"kind": {"const": "card"}, "network": {"default": "visa"}}, "required": ["kind"]},
+					{"type": "object", "properties": {"kind": {"const": "iban"}, "country": {"default": "DE"}}, "required": ["kind"]}
+				]
+			}
+		},
+		"required": ["id"]
+	}`)
+	data := `{"payment": {}}`
+
+	tests := []struct {
+		name string
+		opts []DefaultsOption
+		want string
+	}{
+		{"zero config", nil, `{"payment": {"network": "visa", "country": "DE"}}`},
+		{"fill required", []DefaultsOption{FillRequired()}, `{"id": "new", "payment": {"network": "visa", "country": "DE"}}`},
+		{"keep empty", []DefaultsOption{WithEmptyPolicy(KeepEmpty)}, `{"tags": [], "meta": {}, "labels": {}, "payment": {"network": "visa", "country": "DE"}}`},
+		{"keep empty defaults", []DefaultsOption{WithEmptyPolicy(KeepEmptyDefaults)}, `{"labels": {}, "payment": {"network": "visa", "country": "DE"}}`},
+		{"no branches", []DefaultsOption{WithBranchPolicy(NoBranches)}, `{"payment": {}}`},
+		{
+			"struct",
+			[]DefaultsOption{WithDefaultsOptions(DefaultsOptions{FillRequired: true, Branches: NoBranches})},
+			`{"id": "new", "payment": {}}`,
+		},
+		{
+			"later options adjust the struct",
+			[]DefaultsOption{WithDefaultsOptions(DefaultsOptions{FillRequired: true}), WithBranchPolicy(NoBranches)},
+			`{"id": "new", "payment": {}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ApplyDefaults(parseJSON(t, data), schema, tt.opts...)
+			if want := parseJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("expected %v, got %v", want, got)
+			}
+		})
+	}
+
+	// Matching branches are selected whatever the policy.
+	got := ApplyDefaults(parseJSON(t, `{"payment": {"kind": "iban"}}`), schema, WithBranchPolicy(NoBranches))
+	if want := parseJSON(t, `{"payment": {"kind": "iban", "country": "DE"}}`); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the matching branch's defaults, got %v", got)
+	}
+}
+
+func TestExplainDefaultsOptions(t *testing.T) {
+	schema := compileSchema(t, `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string", "default": "new"},
+			"meta": {"type": "object", "properties": {"owner": {"type": "string"}}},
+			"labels": {"type": "object", "default": {}}
+		},
+		"required": ["id"]
+	}`)
+	explanation := ExplainDefaults(parseJSON(t, `{}`), schema, FillRequired(), WithEmptyPolicy(KeepEmpty))
+	want := []AppliedDefault{
+		{Pointer: "/id", Value: "new", Location: schema.Location + "/properties/id"},
+		{Pointer: "/labels", Value: map[string]interface{}{}, Location: schema.Location + "/properties/labels"},
+		{Pointer: "/meta", Value: map[string]interface{}{}, Location: schema.Location + "/properties/meta"},
+	}
+	if !reflect.DeepEqual(explanation.Defaults, want) {
+		t.Errorf("expected %v, got %v", want, explanation.Defaults)
+	}
+}
+
+func TestApplyDefaultsStrictBranches(t *testing.T) {
+	schema := compileSchema(t, `{
+		"type": "object",
+		"properties": {
+			"currency": {"default": "EUR"},
+			"payment": {
+				"oneOf": [
+					{"type": "object", "properties": {"network": {"default": "visa"}}, "required": ["number"]},
+					{"type": "object", "properties": {"country": {"default": "DE"}}, "required": ["iban"]},
+					{"type": "object", "properties": {"wallet": {"default": "paypal"}}, "required": ["email"]}
+				]
+			}
+		}
+	}`)
+
+	data := parseJSON(t, `{"payment": {"number": "4111", "email": "a@example.com"}}`)
+	_, err := ApplyDefaultsCtx(context.Background(), data, schema, WithBranchPolicy(StrictBranches))
+	var ambiguous *AmbiguousOneOfError
+	if !errors.As(err, &ambiguous) || !errors.Is(err, ErrAmbiguousOneOf) {
+		t.Fatalf("expected an *AmbiguousOneOfError, got %v", err)
+	}
+	if ambiguous.Pointer != "/payment" || !reflect.DeepEqual(ambiguous.Branches, []int{0, 2}) || ambiguous.Location != schema.Location+"/properties/payment" {
+		t.Errorf("expected the pointer, location and matching branches, got %+v", ambiguous)
+	}
+	if got := ApplyDefaults(data, schema, WithBranchPolicy(StrictBranches)); !reflect.DeepEqual(got, data) {
+		t.Errorf("expected ApplyDefaults to leave the data unchanged, got %v", got)
+	}
+	if _, _, err := ApplyDefaultsExplainCtx(context.Background(), data, schema, WithBranchPolicy(StrictBranches)); !errors.Is(err, ErrAmbiguousOneOf) {
+		t.Errorf("expected ApplyDefaultsExplainCtx to fail too, got %v", err)
+	}
+
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"unique match", `{"payment": {"iban": "DE89"}}`, `{"currency": "EUR", "payment": {"iban": "DE89", "country": "DE"}}`},
+		{"no match", `{"payment": {}}`, `{"currency": "EUR", "payment": {}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyDefaultsCtx(context.Background(), parseJSON(t, tt.data), schema, WithBranchPolicy(StrictBranches))
+			if err != nil {
+				t.Fatalf("ApplyDefaultsCtx failed: %v", err)
+			}
+			if want := parseJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("expected %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestApplyDefaultsMaxDepth(t *testing.T) {
+	schema := compileSchema(t, `{
+		"$defs": {"node": {"type": "object", "properties": {"name": {"default": "leaf"}, "child": {"$ref": "#/$defs/node"}}}},
+		"$ref": "#/$defs/node"
+	}`)
+	data := parseJSON(t, `{"child": {"child": {"child": {}}}}`)
+
+	_, err := ApplyDefaultsCtx(context.Background(), data, schema, WithMaxDepth(3))
+	if !errors.Is(err, ErrMaxDepth) {
+		t.Fatalf("expected ErrMaxDepth, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `"/child/child/`) {
+		t.Errorf("expected the error to name the pointer, got %v", err)
+	}
+
+	got, err := ApplyDefaultsCtx(context.Background(), data, schema, WithDefaultsOptions(DefaultsOptions{MaxDepth: 5}))
+	if err != nil {
+		t.Fatalf("ApplyDefaultsCtx failed: %v", err)
+	}
+	want := parseJSON(t, `{"name": "leaf", "child": {"name": "leaf", "child": {"name": "leaf", "child": {"name": "leaf"}}}}`)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestApplyDefaultsFillConst(t *testing.T) {
+	schema := compileSchema(t, `{
+		"type": "object",
+		"properties": {
+			"version": {"const": 2},
+			"kind": {"const": "order", "default": "draft"},
+			"source": {"$ref": "#/$defs/source"}
+		},
+		"$defs": {"source": {"const": "api"}}
+	}`)
+
+	if got, want := ApplyDefaults(parseJSON(t, `{}`), schema), parseJSON(t, `{"kind": "draft"}`); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected only explicit defaults without FillConst, got %v", got)
+	}
+
+	got, explanation, err := ApplyDefaultsExplainCtx(context.Background(), parseJSON(t, `{"version": 1}`), schema, FillConst())
+	if err != nil {
+		t.Fatalf("ApplyDefaultsExplainCtx failed: %v", err)
+	}
+	if want := parseJSON(t, `{"version": 1, "kind": "draft", "source": "api"}`); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	want := []AppliedDefault{
+		{Pointer: "/kind", Value: "draft", Location: schema.Location + "/properties/kind"},
+		{Pointer: "/source", Value: "api", Location: schema.Location + "/$defs/source"},
+	}
+	if !reflect.DeepEqual(explanation.Defaults, want) {
+		t.Errorf("expected %v, got %v", want, explanation.Defaults)
+	}
+}
+
+func TestApplyDefaultsFillExamples(t *testing.T) {
+	schema := compileSchema(t, `{
+		"type": "object",
+		"properties": {
+			"host": {"type": "string", "examples": ["db.example.com", "localhost"]},
+			"port": {"type": "integer", "default": 5432, "examples": [6432]},
+			"version": {"const": 2, "examples": [1]},
+			"pool": {"$ref": "#/$defs/pool"}
+		},
+		"$defs": {"pool": {"type": "object", "properties": {"size": {"type": "integer", "examples": [10]}}}}
+	}`)
+
+	tests := []struct {
+		name string
+		opts []DefaultsOption
+		want string
+	}{
+		{"without the option", nil, `{"port":5432}`},
+		{"first examples", []DefaultsOption{FillExamples()}, `{"host":"db.example.com","pool":{"size":10},"port":5432,"version":1}`},
+		{"consts first", []DefaultsOption{FillExamples(), FillConst()}, `{"host":"db.example.com","pool":{"size":10},"port":5432,"version":2}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := json.Marshal(ApplyDefaults(parseJSON(t, `{}`), schema, tt.opts...))
+			if string(got) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestApplyDefaultsCoerce(t *testing.T) {
+	schema := compileSchema(t, `{
+		"type": "object",
+		"properties": {
+			"port": {"type": "integer", "default": 80},
+			"ratio": {"type": "number"},
+			"debug": {"type": "boolean"},
+			"name": {"type": "string"},
+			"id": {"type": ["string", "integer"]},
+			"hosts": {"type": "array", "items": {"type": "object", "properties": {"port": {"type": "integer", "default": 443}}}},
+			"tags": {"type": "array", "items": {"type": "integer"}},
+			"level": {"type": "integer"}
+		}
+	}`)
+	data := `{"ratio": "0.5", "debug": "true", "name": "42", "id": "7", "hosts": {"name": "a"}, "tags": ["1", "2"], "level": "high"}`
+
+	got, err := ApplyDefaultsCtx(context.Background(), parseJSON(t, data), schema, CoerceTypes())
+	if err != nil {
+		t.Fatalf("ApplyDefaultsCtx failed: %v", err)
+	}
+	want := map[string]interface{}{
+		"port":  json.Number("80"),
+		"ratio": json.Number("0.5"),
+		"debug": true,
+		"name":  "42",
+		"id":    "7",
+		"hosts": []interface{}{map[string]interface{}{"name": "a", "port": json.Number("443")}},
+		"tags":  []interface{}{json.Number("1"), json.Number("2")},
+		"level": "high",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	if got := ApplyDefaults(parseJSON(t, `{"ratio": "0.5"}`), schema).(map[string]interface{}); got["ratio"] != "0.5" {
+		t.Errorf("expected no coercion without the option, got %v", got)
+	}
+}
+
+func TestApplyDefaultsNormalizeNumbers(t *testing.T) {
+	schema := compileSchema(t, `{
+		"type": "object",
+		"properties": {
+			"port": {"type": "integer", "default": 80},
+			"ratio": {"default": 0.5},
+			"limits": {"default": {"max": 10, "steps": [1, 2.5]}},
+			"version": {"const": 2}
+		}
+	}`)
+
+	got := ApplyDefaults(parseJSON(t, `{}`), schema, NormalizeNumbers(), FillConst())
+	want := map[string]interface{}{
+		"port":    int64(80),
+		"ratio":   0.5,
+		"limits":  map[string]interface{}{"max": int64(10), "steps": []interface{}{int64(1), 2.5}},
+		"version": int64(2),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got := ApplyDefaults(parseJSON(t, `{}`), schema).(map[string]interface{}); got["port"] != json.Number("80") {
+		t.Errorf("expected json.Number without the option, got %#v", got["port"])
+	}
+	if port := schema.Properties["port"].Default; port != json.Number("80") {
+		t.Errorf("expected the schema default to be left unchanged, got %#v", port)
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("APP_HOST", "db.internal")
+	t.Setenv("APP_EMPTY", "")
+	t.Setenv("SECRET", "hunter2")
+	schema := compileSchema(t, `{
+		"type": "object",
+		"properties": {
+			"host": {"type": "string", "default": "${APP_HOST}:5432"},
+			"user": {"type": "string", "default": "${APP_USER:-postgres}"},
+			"mode": {"type": "string", "default": "${APP_EMPTY:-safe}"},
+			"secret": {"type": "string", "default": "${SECRET}"},
+			"paths": {"default": ["/srv/${APP_HOST}"]}
+		}
+	}`)
+
+	got := ApplyDefaults(parseJSON(t, `{}`), schema, ExpandEnv("APP_HOST", "APP_USER", "APP_EMPTY"))
+	want := parseJSON(t, `{"host": "db.internal:5432", "user": "postgres", "mode": "safe", "secret": "${SECRET}", "paths": ["/srv/db.internal"]}`)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// Without the option, and in the schema itself, nothing is expanded.
+	got = ApplyDefaults(parseJSON(t, `{}`), schema)
+	if host := got.(map[string]interface{})["host"]; host != "${APP_HOST}:5432" {
+		t.Errorf("expected the default as written, got %v", host)
+	}
+	if paths := schema.Properties["paths"].Default.([]interface{}); paths[0] != "/srv/${APP_HOST}" {
+		t.Errorf("expected the schema's default to be unchanged, got %v", paths)
+	}
+}
+
+func TestApplyDefaultsReadWriteOnly(t *testing.T) {
+	schema := compileSchema(t, `{
+		"$defs": {"timestamp": {"type": "string", "readOnly": true, "default": "1970-01-01T00:00:00Z"}},
+		"type": "object",
+		"properties": {
+			"id": {"type": "string", "readOnly": true, "default": "generated"},
+			"createdAt": {"$ref": "#/$defs/timestamp"},
+			"password": {"type": "string", "writeOnly": true, "default": "changeme"},
+			"audit": {"type": "object", "readOnly": true, "properties": {"by": {"default": "system"}}},
+			"name": {"type": "string", "default": "anonymous"}
+		}
+	}`)
+
+	tests := []struct {
+		name string
+		opts []DefaultsOption
+		data string
+		want string
+	}{
+		{
+			"no options",
+			nil,
+			`{}`,
+			`{"id": "generated", "createdAt": "1970-01-01T00:00:00Z", "password": "changeme", "audit": {"by": "system"}, "name": "anonymous"}`,
+		},
+		{"request", []DefaultsOption{SkipReadOnly()}, `{}`, `{"password": "changeme", "name": "anonymous"}`},
+		{
+			"response",
+			[]DefaultsOption{SkipWriteOnly()},
+			`{}`,
+			`{"id": "generated", "createdAt": "1970-01-01T00:00:00Z", "audit": {"by": "system"}, "name": "anonymous"}`,
+		},
+		{"present values are kept as they are", []DefaultsOption{SkipReadOnly()}, `{"audit": {}}`, `{"audit": {}, "password": "changeme", "name": "anonymous"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ApplyDefaults(parseJSON(t, tt.data), schema, tt.opts...)
+			if want := parseJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("expected %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestApplyDefaultsPadArrays(t *testing.T) {
+	schema := compileSchema(t, `{
+		"type": "object",
+		"properties": {
+			"replicas": {"type": "array", "items": {"type": "object", "properties": {"zone": {"default": "a"}}}, "minItems": 2},
+			"ports": {"type": "array", "items": {"type": "integer", "default": 80}, "minItems": 3},
+			"names": {"type": "array", "items": {"type": "string"}, "minItems": 1},
+			"point": {"type": "array", "prefixItems": [{"type": "number"}, {"type": "string", "default": "m"}], "minItems": 2},
+			"free": {"type": "array", "items": {}, "minItems": 1}
+		}
+	}`)
+
+	tests := []struct {
+		name string
+		data string
+		opts []DefaultsOption
+		want string
+	}{
+		{"without the option", `{"ports": [443]}`, nil, `{"ports":[443]}`},
+		{
+			"present arrays",
+			`{"replicas": [{"zone": "b"}], "ports": [443], "names": [], "point": [1], "free": []}`,
+			[]DefaultsOption{PadArrays()},
+			`{"free":[],"names":[""],"point":[1,"m"],"ports":[443,80,80],"replicas":[{"zone":"b"},{"zone":"a"}]}`,
+		},
+		{
+			"missing arrays",
+			`{}`,
+			[]DefaultsOption{PadArrays()},
+			`{"names":[""],"point":[0,"m"],"ports":[80,80,80],"replicas":[{"zone":"a"},{"zone":"a"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := json.Marshal(ApplyDefaults(parseJSON(t, tt.data), schema, tt.opts...))
+			if string(got) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	explanation := ExplainDefaults(parseJSON(t, `{"names": []}`), schema, PadArrays())
+	found := map[string]bool{}
+	for _, d := range explanation.Defaults {
+		found[d.Pointer] = true
+	}
+	for _, pointer := range []string{"/names/0", "/ports/2", "/replicas/1/zone"} {
+		if !found[pointer] {
+			t.Errorf("expected %s to be explained, got %v", pointer, explanation.Defaults)
+		}
+	}
+}
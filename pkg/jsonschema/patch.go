@@ -0,0 +1,24 @@
+package jsonschema
+
+import (
+	"context"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"go-demo/pkg/jsonpatch"
+)
+
+// DefaultsPatch returns the RFC 6902 JSON Patch that turns data into what
+// ApplyDefaults(data, schema, opts...) returns, so clients can preview the
+// defaults or apply only some of them. Each missing property gets an add of
+// its whole value, in the order of the property names, and so do the items
+// PadArrays appends; values CoerceTypes converts get a replace. Data is not
+// modified. The errors of the options are returned as ApplyDefaultsCtx
+// returns them.
+func DefaultsPatch(data interface{}, schema *jsonschema.Schema, opts ...DefaultsOption) ([]jsonpatch.Operation, error) {
+	result, err := ApplyDefaultsCtx(context.Background(), data, schema, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return jsonpatch.Diff(data, result), nil
+}
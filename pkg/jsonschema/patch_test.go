@@ -0,0 +1,60 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go-demo/pkg/jsonpatch"
+)
+
+func TestDefaultsPatch(t *testing.T) {
+	schema := compileSchema(t, `{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string", "default": "anonymous"},
+			"port": {"type": "integer"},
+			"server": {"type": "object", "properties": {"host": {"default": "localhost"}, "tls": {"type": "object", "properties": {"version": {"default": "1.3"}}}}},
+			"hosts": {"type": "array", "items": {"type": "object", "properties": {"port": {"default": 80}}}, "minItems": 2},
+			"payment": {"oneOf": [{"properties": {"kind": {"type": "string"}}}, {"properties": {"kind": {"minLength": 1}}}]}
+		}
+	}`)
+	data := parseJSON(t, `{"port": "8080", "server": {"host": "example.com"}, "hosts": [{}]}`)
+
+	patch, err := DefaultsPatch(data, schema, CoerceTypes(), PadArrays())
+	if err != nil {
+		t.Fatalf("DefaultsPatch failed: %v", err)
+	}
+	got, _ := json.Marshal(patch)
+	want := `[{"op":"add","path":"/hosts/0/port","value":80},{"op":"add","path":"/hosts/1","value":{"port":80}},` +
+		`{"op":"add","path":"/name","value":"anonymous"},{"op":"replace","path":"/port","value":8080},` +
+		`{"op":"add","path":"/server/tls","value":{"version":"1.3"}}]`
+	if string(got) != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	if !reflect.DeepEqual(data, parseJSON(t, `{"port": "8080", "server": {"host": "example.com"}, "hosts": [{}]}`)) {
+		t.Errorf("expected data to be left alone, got %v", data)
+	}
+
+	// Applying the patch gives what ApplyDefaults does, also one
+	// operation at a time.
+	patched, err := jsonpatch.Apply(data, patch)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if want := ApplyDefaults(data, schema, CoerceTypes(), PadArrays()); !reflect.DeepEqual(patched, want) {
+		t.Errorf("expected %v, got %v", want, patched)
+	}
+	if _, err := jsonpatch.Apply(data, patch[2:3]); err != nil {
+		t.Errorf("expected a single operation to apply, got %v", err)
+	}
+
+	if patch, err := DefaultsPatch(parseJSON(t, `{"name": "x", "server": {"host": "h", "tls": {"version": "1.2"}}, "hosts": []}`), schema); err != nil || len(patch) != 0 {
+		t.Errorf("expected an empty patch for complete data, got %v, %v", patch, err)
+	}
+	var ambiguous *AmbiguousOneOfError
+	if _, err := DefaultsPatch(parseJSON(t, `{"payment": {"kind": "a"}}`), schema, WithBranchPolicy(StrictBranches)); !errors.As(err, &ambiguous) {
+		t.Errorf("expected an *AmbiguousOneOfError, got %v", err)
+	}
+}
@@ -0,0 +1,382 @@
+package jsonschema
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"go-demo/pkg/jsonutil"
+)
+
+// DefaultsPlan is the default structure of a schema compiled by
+// CompileDefaults, to be applied to any number of documents, concurrently
+// too, without walking the schema again.
+type DefaultsPlan struct {
+	root *planNode
+	opts DefaultsOptions
+}
+
+// planNode is the plan of a schema applied at some position of a document:
+// its properties, or its items, each with its own plan and the value a
+// missing one gets, worked out once. A schema whose defaults depend on the
+// data, through combinations, conditions, dependent or unevaluated schemas
+// or dynamic references, or that recurs further down, falls back to the
+// defaulter at run time, with the state it would have reached there.
+type planNode struct {
+	schema   *jsonschema.Schema
+	resolved *jsonschema.Schema
+	fallback bool
+	// pointers is set if the plan, or one further down, falls back or
+	// computes values at run time, which needs the pointers of the values;
+	// otherwise they aren't built.
+	pointers bool
+
+	// ancestors are the resolved schemas of the nodes above and scope
+	// their dynamic scope, as the defaulter holds them in active and scope;
+	// depth is their number. chain is the reference chain of schema.
+	ancestors []*jsonschema.Schema
+	scope     []*jsonschema.Schema
+	depth     int
+	chain     []*jsonschema.Schema
+
+	props []planProp
+	// items are the plans of the items at their positions, and rest of the
+	// items after them; pads are what PadArrays appends at each position up
+	// to minItems.
+	items []*planNode
+	rest  *planNode
+	pads  []planValue
+}
+
+// planProp is the plan of a property.
+type planProp struct {
+	name    string
+	token   string
+	node    *planNode
+	missing planValue
+}
+
+// planValue is the value a missing property or padded item gets. A value
+// the defaulter can't work out ahead of time, because it is computed or
+// fails, is worked out at run time by compute.
+type planValue struct {
+	value   interface{}
+	add     bool
+	compute func(d *defaulter, pointer string) (interface{}, bool)
+}
+
+// CompileDefaults compiles the defaults of schema under opts into a plan.
+// plan.Apply(data) returns what ApplyDefaults(data, schema, opts...) does,
+// but the parts of the schema whose defaults don't depend on the data, such
+// as the properties of objects and the items of arrays, are walked only
+// once, here: only the data is walked on each Apply. The schema must not be
+// modified after its plan is compiled. Apply fills in copies of the defaults,
+// so the documents don't share them.
+func CompileDefaults(schema *jsonschema.Schema, opts ...DefaultsOption) *DefaultsPlan {
+	p := &DefaultsPlan{opts: newDefaultsOptions(opts)}
+	if schema != nil {
+		p.root = p.node(schema, nil, nil, 0, "")
+	}
+	return p
+}
+
+// Apply applies the defaults of the plan to data, as ApplyDefaults does. It
+// returns data unchanged if the plan's options make it fail.
+func (p *DefaultsPlan) Apply(data interface{}) interface{} {
+	result, err := p.ApplyCtx(context.Background(), data)
+	if err != nil {
+		return data
+	}
+	return result
+}
+
+// ApplyCtx is Apply that returns the errors of ApplyDefaultsCtx, giving up
+// with ctx's error as soon as ctx is done.
+func (p *DefaultsPlan) ApplyCtx(ctx context.Context, data interface{}) (interface{}, error) {
+	if p.root == nil {
+		return data, nil
+	}
+	r := &planRun{plan: p, ctx: ctx}
+	result := r.apply(p.root, data, "")
+	if r.err != nil {
+		return nil, r.err
+	}
+	return result, nil
+}
+
+// node compiles the plan of schema, applied at pointer below ancestors.
+func (p *DefaultsPlan) node(schema *jsonschema.Schema, ancestors, scope []*jsonschema.Schema, depth int, pointer string) *planNode {
+	n := &planNode{schema: schema, ancestors: ancestors, scope: scope, depth: depth}
+	d := n.defaulter(p.opts, nil)
+	n.chain = d.resolveChain(schema)
+	n.resolved = n.chain[len(n.chain)-1]
+	if !plannable(n.chain) || depth >= p.opts.maxDepth() || containsSchema(ancestors, n.resolved) {
+		n.fallback = true
+		n.pointers = true
+		return n
+	}
+
+	ancestors = append(append([]*jsonschema.Schema{}, ancestors...), n.resolved)
+	scope = append(append([]*jsonschema.Schema{}, scope...), n.chain...)
+	inner := func() *defaulter { return n.inner(p.opts, nil) }
+
+	if n.resolved.Properties != nil {
+		for name, propSchema := range n.resolved.Properties {
+			if propSchema == nil || (!p.opts.FillRequired && isRequired(name, n.resolved.Required)) || inner().skipsAccess(propSchema) {
+				continue
+			}
+			propSchema := propSchema
+			token := jsonutil.JoinPointer(name)
+			n.props = append(n.props, planProp{
+				name:  name,
+				token: token,
+				node:  p.node(propSchema, ancestors, scope, depth+1, pointer+token),
+				missing: planValueOf(inner(), pointer+token, func(d *defaulter, pointer string) (interface{}, bool) {
+					value := d.applyForProperty(nil, propSchema, pointer)
+					return value, d.shouldAdd(value, propSchema)
+				}),
+			})
+		}
+	} else if isArraySchema(n.resolved) {
+		positions := len(n.resolved.PrefixItems)
+		if items, ok := n.resolved.Items.([]*jsonschema.Schema); ok && positions == 0 {
+			positions = len(items)
+		}
+		for i := 0; i <= positions; i++ {
+			var item *planNode
+			if s := d.itemsSchema(n.resolved, i); s != nil {
+				item = p.node(s, ancestors, scope, depth+1, pointer+"/"+strconv.Itoa(i))
+			}
+			if i < positions {
+				n.items = append(n.items, item)
+			} else {
+				n.rest = item
+			}
+		}
+		if p.opts.PadArrays {
+			for i := 0; i < n.resolved.MinItems; i++ {
+				i := i
+				n.pads = append(n.pads, planValueOf(inner(), pointer+"/"+strconv.Itoa(i), func(d *defaulter, pointer string) (interface{}, bool) {
+					item := d.padItem(d.itemsSchema(n.resolved, i), pointer)
+					return item, item != nil
+				}))
+			}
+		}
+	}
+	n.pointers = n.runsLate()
+	return n
+}
+
+// runsLate reports whether the plans of the properties and items of n fall
+// back or compute values at run time.
+func (n *planNode) runsLate() bool {
+	for _, prop := range n.props {
+		if prop.node.pointers || prop.missing.compute != nil {
+			return true
+		}
+	}
+	for _, item := range n.items {
+		if item != nil && item.pointers {
+			return true
+		}
+	}
+	if n.rest != nil && n.rest.pointers {
+		return true
+	}
+	for _, pad := range n.pads {
+		if pad.compute != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// plannable reports whether the defaults of a schema with the reference
+// chain chain are the same for all data, apart from its properties and
+// items. Only the schema at the end of the chain may have those.
+func plannable(chain []*jsonschema.Schema) bool {
+	last := chain[len(chain)-1]
+	if hasRef(last) {
+		return false
+	}
+	for i, s := range chain {
+		if s.DynamicRef != nil || s.RecursiveRef != nil ||
+			len(s.AllOf) > 0 || len(s.AnyOf) > 0 || len(s.OneOf) > 0 || s.If != nil ||
+			len(s.DependentSchemas) > 0 || len(s.Dependencies) > 0 ||
+			s.UnevaluatedProperties != nil || s.UnevaluatedItems != nil {
+			return false
+		}
+		if i < len(chain)-1 && (s.Properties != nil || isArraySchema(s)) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsSchema(schemas []*jsonschema.Schema, schema *jsonschema.Schema) bool {
+	for _, s := range schemas {
+		if s == schema {
+			return true
+		}
+	}
+	return false
+}
+
+// planValueOf works out a value with fn, or leaves it to run time if it
+// can't be worked out ahead of time.
+func planValueOf(d *defaulter, pointer string, fn func(d *defaulter, pointer string) (interface{}, bool)) planValue {
+	value, add := fn(d, pointer)
+	if d.err != nil || d.computed {
+		return planValue{compute: fn}
+	}
+	return planValue{value: value, add: add}
+}
+
+// defaulter returns a defaulter in the state it is in when it applies the
+// schema of n.
+func (n *planNode) defaulter(opts DefaultsOptions, ctx context.Context) *defaulter {
+	d := &defaulter{opts: opts, ctx: ctx, depth: n.depth, active: make(map[*jsonschema.Schema]int, len(n.ancestors))}
+	for _, s := range n.ancestors {
+		d.active[s]++
+	}
+	d.scope = append([]*jsonschema.Schema{}, n.scope...)
+	return d
+}
+
+// inner returns a defaulter in the state it is in inside the schema of n,
+// when it applies the schemas of its properties and items.
+func (n *planNode) inner(opts DefaultsOptions, ctx context.Context) *defaulter {
+	d := n.defaulter(opts, ctx)
+	d.depth++
+	d.active[n.resolved]++
+	d.scope = append(d.scope, n.chain...)
+	return d
+}
+
+// item returns the plan of the item at index i, or nil if no schema applies
+// to it.
+func (n *planNode) item(i int) *planNode {
+	if i < len(n.items) {
+		return n.items[i]
+	}
+	return n.rest
+}
+
+// itemPointer returns the pointer of the item at index i of the array at
+// pointer, if the plan needs it.
+func (n *planNode) itemPointer(pointer string, i int) string {
+	if !n.pointers {
+		return ""
+	}
+	return pointer + "/" + strconv.Itoa(i)
+}
+
+// planRun applies a plan to one document, keeping the first error in err.
+type planRun struct {
+	plan *DefaultsPlan
+	ctx  context.Context
+	err  error
+}
+
+// apply applies the plan n to data, found at pointer.
+func (r *planRun) apply(n *planNode, data interface{}, pointer string) interface{} {
+	if data == nil || r.err != nil {
+		return data
+	}
+	if err := r.ctx.Err(); err != nil {
+		r.err = err
+		return data
+	}
+	if n.fallback {
+		d := n.defaulter(r.plan.opts, r.ctx)
+		result := d.apply(data, n.schema, pointer)
+		r.err = d.err
+		return result
+	}
+	if r.plan.opts.Coerce {
+		data = coerce(data, n.resolved)
+	}
+
+	switch v := data.(type) {
+	case map[string]interface{}:
+		if n.props == nil {
+			return data
+		}
+		result := make(map[string]interface{}, len(v)+len(n.props))
+		for k, value := range v {
+			result[k] = value
+		}
+		for _, prop := range n.props {
+			value, exists := v[prop.name]
+			var propPointer string
+			if n.pointers {
+				propPointer = pointer + prop.token
+			}
+			if !exists {
+				if value, add := r.value(n, prop.missing, propPointer); add {
+					result[prop.name] = value
+				}
+			} else if value != nil {
+				result[prop.name] = r.apply(prop.node, value, propPointer)
+			}
+		}
+		return result
+	case []interface{}:
+		if n.resolved.Properties != nil || !isArraySchema(n.resolved) {
+			return data
+		}
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			if itemNode := n.item(i); itemNode != nil {
+				result[i] = r.apply(itemNode, item, n.itemPointer(pointer, i))
+			} else {
+				result[i] = item
+			}
+		}
+		for i := len(result); i < len(n.pads); i++ {
+			item, add := r.value(n, n.pads[i], n.itemPointer(pointer, i))
+			if !add {
+				break
+			}
+			result = append(result, item)
+		}
+		return result
+	}
+	return data
+}
+
+// value returns the value v stands for inside n, at pointer, and whether to
+// add it.
+func (r *planRun) value(n *planNode, v planValue, pointer string) (interface{}, bool) {
+	if v.compute == nil {
+		return copyValue(v.value), v.add
+	}
+	d := n.inner(r.plan.opts, r.ctx)
+	value, add := v.compute(d, pointer)
+	if d.err != nil {
+		r.err = d.err
+		return nil, false
+	}
+	return value, add
+}
+
+// copyValue returns a deep copy of the objects and arrays of v, so that the
+// documents a plan fills in don't share its values.
+func copyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			result[k] = copyValue(value)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, value := range v {
+			result[i] = copyValue(value)
+		}
+		return result
+	}
+	return v
+}
@@ -0,0 +1,173 @@
+package jsonschema
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestCompileDefaults(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		opts   []DefaultsOption
+		data   []string
+	}{
+		{"objects", `{
+			"type": "object",
+			"properties": {
+				"name": {"type": "string", "default": "anonymous"},
+				"id": {"type": "string", "default": "new"},
+				"labels": {"type": "object", "default": {"team": "core"}, "properties": {"tier": {"default": 1}}},
+				"server": {"$ref": "#/$defs/server"}
+			},
+			"required": ["id"],
+			"$defs": {"server": {"type": "object", "properties": {"host": {"default": "localhost"}, "tls": {"type": "object", "properties": {"version": {"default": "1.3"}}}}}}
+		}`, nil, []string{`{}`, `{"name": null, "server": {"tls": {}}}`, `{"labels": {"team": "ops"}, "server": "x"}`, `[]`, `null`}},
+		{"arrays", `{
+			"$schema": "http://json-schema.org/draft-07/schema#",
+			"type": "object",
+			"properties": {
+				"hosts": {"type": "array", "items": {"properties": {"port": {"default": 80}}}, "minItems": 2},
+				"tuple": {"type": "array", "items": [{"properties": {"a": {"default": "A"}}}], "additionalItems": {"properties": {"b": {"default": "B"}}}}
+			}
+		}`, []DefaultsOption{PadArrays()}, []string{`{}`, `{"hosts": [{}, {"port": 443}, {}], "tuple": [{}, {}, null]}`}},
+		{"conditional subschemas", `{
+			"type": "object",
+			"properties": {
+				"payment": {
+					"oneOf": [
+						{"properties": {"kind": {"const": "card"}, "network": {"default": "visa"}}, "required": ["kind"]},
+						{"properties": {"kind": {"const": "iban"}, "country": {"default": "DE"}}, "required": ["kind"]}
+					]
+				},
+				"mode": {"default": "fast"}
+			},
+			"if": {"properties": {"mode": {"const": "fast"}}},
+			"then": {"properties": {"retries": {"default": 0}}}
+		}`, nil, []string{`{}`, `{"payment": {"kind": "iban"}, "mode": "safe"}`}},
+		{"recursive", `{
+			"$defs": {"node": {"type": "object", "properties": {"name": {"default": "leaf"}, "children": {"type": "array", "items": {"$ref": "#/$defs/node"}}, "parent": {"$ref": "#/$defs/node"}}}},
+			"$ref": "#/$defs/node"
+		}`, nil, []string{`{}`, `{"children": [{}, {"children": [{}]}], "parent": {"parent": {}}}`}},
+		{"options", `{
+			"type": "object",
+			"properties": {
+				"port": {"type": "integer", "default": 80},
+				"id": {"type": "string", "readOnly": true, "default": "generated"},
+				"version": {"const": 2},
+				"meta": {"type": "object", "default": {}},
+				"ratio": {"type": "number"}
+			},
+			"required": ["port"]
+		}`, []DefaultsOption{FillRequired(), FillConst(), SkipReadOnly(), CoerceTypes(), WithEmptyPolicy(KeepEmptyDefaults)}, []string{`{}`, `{"port": "8080", "ratio": "0.5"}`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := compileSchema(t, tt.schema)
+			plan := CompileDefaults(schema, tt.opts...)
+			for _, data := range tt.data {
+				want, _ := json.Marshal(ApplyDefaults(parseJSON(t, data), schema, tt.opts...))
+				got, _ := json.Marshal(plan.Apply(parseJSON(t, data)))
+				if string(got) != string(want) {
+					t.Errorf("%s: expected %s, got %s", data, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCompileDefaultsCopies(t *testing.T) {
+	schema := compileSchema(t, `{"type": "object", "properties": {"tags": {"type": "array", "default": ["a"]}}}`)
+	plan := CompileDefaults(schema, FillContainerDefaults())
+	first := plan.Apply(map[string]interface{}{}).(map[string]interface{})
+	first["tags"].([]interface{})[0] = "changed"
+	second := plan.Apply(map[string]interface{}{}).(map[string]interface{})
+	if want := []interface{}{"a"}; !reflect.DeepEqual(second["tags"], want) {
+		t.Errorf("expected %v, got %v", want, second["tags"])
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			plan.Apply(map[string]interface{}{}).(map[string]interface{})["tags"].([]interface{})[0] = "changed"
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCompileDefaultsComputed(t *testing.T) {
+	schema := compileSchema(t, `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string", "default": {"$generator": "uuid"}},
+			"bad": {"default": {"$generator": "missing"}}
+		},
+		"required": ["bad"]
+	}`)
+	plan := CompileDefaults(schema)
+	first := plan.Apply(map[string]interface{}{}).(map[string]interface{})["id"]
+	second := plan.Apply(map[string]interface{}{}).(map[string]interface{})["id"]
+	if first == nil || first == second {
+		t.Errorf("expected a new value for each document, got %v and %v", first, second)
+	}
+
+	// Errors come up when the plan is applied, as with ApplyDefaultsCtx.
+	plan = CompileDefaults(schema, FillRequired())
+	if _, err := plan.ApplyCtx(context.Background(), map[string]interface{}{}); !errors.Is(err, ErrUnknownGenerator) {
+		t.Errorf("expected ErrUnknownGenerator, got %v", err)
+	}
+	data := map[string]interface{}{"bad": 1}
+	if got := plan.Apply(data); !reflect.DeepEqual(got, map[string]interface{}{"bad": 1, "id": got.(map[string]interface{})["id"]}) {
+		t.Errorf("expected defaults for present properties, got %v", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := CompileDefaults(schema).ApplyCtx(ctx, map[string]interface{}{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func BenchmarkApplyDefaults(b *testing.B) {
+	schema, err := CompileString(`{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string"},
+			"currency": {"type": "string", "default": "EUR"},
+			"customer": {"$ref": "#/$defs/customer"},
+			"items": {"type": "array", "items": {"$ref": "#/$defs/item"}}
+		},
+		"$defs": {
+			"customer": {"type": "object", "properties": {"country": {"default": "DE"}, "tier": {"default": "standard"}}},
+			"item": {"type": "object", "properties": {"quantity": {"type": "integer", "default": 1}, "gift": {"type": "boolean", "default": false}}}
+		}
+	}`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	items := make([]interface{}, 20)
+	for i := range items {
+		items[i] = map[string]interface{}{"sku": i}
+	}
+	data := map[string]interface{}{"id": "o-1", "customer": map[string]interface{}{}, "items": items}
+
+	b.Run("schema", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ApplyDefaults(data, schema)
+		}
+	})
+	b.Run("plan", func(b *testing.B) {
+		plan := CompileDefaults(schema)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			plan.Apply(data)
+		}
+	})
+}
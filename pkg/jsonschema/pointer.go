@@ -0,0 +1,102 @@
+package jsonschema
+
+import (
+	"strconv"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"go-demo/pkg/jsonutil"
+)
+
+// DefaultAt returns the schema default for the value addressed by a JSON Pointer
+// into a document described by schema, e.g. "/address/city" or "/items/0/name".
+// If the addressed schema has no default of its own, the nearest ancestor's
+// default is searched instead (a default of {"city": "Berlin"} on "address"
+// also provides "/address/city").
+// The second result reports whether a default was found.
+func DefaultAt(schema *jsonschema.Schema, pointer string) (interface{}, bool) {
+	tokens, err := jsonutil.SplitPointer(pointer)
+	if err != nil || schema == nil {
+		return nil, false
+	}
+
+	var ancestorDefault interface{}
+	var ancestorRest []string
+	current := resolveRef(schema)
+	for i, tok := range tokens {
+		if current.Default != nil {
+			ancestorDefault, ancestorRest = current.Default, tokens[i:]
+		}
+		current = subschemaFor(current, tok)
+		if current == nil {
+			break
+		}
+	}
+
+	if current != nil && current.Default != nil {
+		return current.Default, true
+	}
+	if ancestorDefault != nil {
+		return lookupValue(ancestorDefault, ancestorRest)
+	}
+	return nil, false
+}
+
+// subschemaFor returns the (ref-resolved) schema describing the child tok of a
+// value described by schema, looking through allOf/anyOf/oneOf branches.
+func subschemaFor(schema *jsonschema.Schema, tok string) *jsonschema.Schema {
+	schema = resolveRef(schema)
+	if schema == nil {
+		return nil
+	}
+
+	if s, ok := schema.Properties[tok]; ok {
+		return resolveRef(s)
+	}
+	if index, err := strconv.Atoi(tok); err == nil && index >= 0 {
+		if s := getItemsSchemaForIndex(schema, index); s != nil {
+			return resolveRef(s)
+		}
+	}
+
+	for _, branches := range [][]*jsonschema.Schema{schema.AllOf, schema.AnyOf, schema.OneOf} {
+		for _, branch := range branches {
+			if s := subschemaFor(branch, tok); s != nil {
+				return s
+			}
+		}
+	}
+
+	for re, s := range schema.PatternProperties {
+		if re.MatchString(tok) {
+			return resolveRef(s)
+		}
+	}
+	if s, ok := schema.AdditionalProperties.(*jsonschema.Schema); ok {
+		return resolveRef(s)
+	}
+	return nil
+}
+
+// lookupValue walks decoded JSON along the given reference tokens.
+func lookupValue(value interface{}, tokens []string) (interface{}, bool) {
+	for _, tok := range tokens {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			child, ok := v[tok]
+			if !ok {
+				return nil, false
+			}
+			value = child
+		case []interface{}:
+			index, err := strconv.Atoi(tok)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			value = v[index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
@@ -0,0 +1,59 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDefaultAt(t *testing.T) {
+	schemaStr := `{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"definitions": {
+			"address": {
+				"type": "object",
+				"properties": {
+					"city": {"type": "string", "default": "Unknown"},
+					"zip": {"type": "string"}
+				},
+				"default": {"zip": "00000"}
+			}
+		},
+		"type": "object",
+		"properties": {
+			"name": {"type": "string", "default": "John"},
+			"address": {"$ref": "#/definitions/address"},
+			"users": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"properties": {"role": {"type": "string", "default": "member"}}
+				}
+			}
+		},
+		"allOf": [
+			{"properties": {"version": {"type": "integer", "default": 2}}}
+		]
+	}`
+	schema := compileSchema(t, schemaStr)
+
+	tests := []struct {
+		pointer string
+		want    interface{}
+		found   bool
+	}{
+		{"/name", "John", true},
+		{"/address/city", "Unknown", true},
+		{"/address/zip", "00000", true},
+		{"/users/3/role", "member", true},
+		{"/version", json.Number("2"), true},
+		{"/missing", nil, false},
+		{"/name/nested", nil, false},
+		{"not-a-pointer", nil, false},
+	}
+	for _, tt := range tests {
+		got, found := DefaultAt(schema, tt.pointer)
+		if found != tt.found || got != tt.want {
+			t.Errorf("DefaultAt(%q): expected (%v, %v), got (%v, %v)", tt.pointer, tt.want, tt.found, got, found)
+		}
+	}
+}
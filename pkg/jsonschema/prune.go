@@ -0,0 +1,147 @@
+package jsonschema
+
+import (
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Prune returns data without the object members schema doesn't define, so
+// that only fields the schema knows about remain. A member is defined if
+// properties, patternProperties or an additionalProperties other than false
+// cover it, or unevaluatedProperties does, in the schema of the object or in
+// any schema that applies to it in place: $refs, allOf, every branch of
+// anyOf and oneOf, if/then/else and dependent schemas. Objects whose schemas
+// say nothing about their members, such as {}, are kept whole. Data is not
+// modified.
+func Prune(data interface{}, schema *jsonschema.Schema) interface{} {
+	if schema == nil {
+		return data
+	}
+	return prune(data, inPlaceSchemas(schema, nil, map[*jsonschema.Schema]bool{}))
+}
+
+// prune prunes data against all of schemas.
+func prune(data interface{}, schemas []*jsonschema.Schema) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		if !describesMembers(schemas) {
+			return data
+		}
+		result := make(map[string]interface{}, len(v))
+		for name, value := range v {
+			children, ok := memberSchemas(schemas, name)
+			if !ok {
+				continue
+			}
+			result[name] = prune(value, children)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			seen := map[*jsonschema.Schema]bool{}
+			var children []*jsonschema.Schema
+			for _, s := range schemas {
+				if item := getItemsSchemaForIndex(s, i); item != nil {
+					children = inPlaceSchemas(item, children, seen)
+				}
+			}
+			result[i] = prune(item, children)
+		}
+		return result
+	}
+	return data
+}
+
+// inPlaceSchemas appends schema and the schemas that apply in place with it
+// to schemas, skipping those in seen.
+func inPlaceSchemas(schema *jsonschema.Schema, schemas []*jsonschema.Schema, seen map[*jsonschema.Schema]bool) []*jsonschema.Schema {
+	if schema == nil || seen[schema] {
+		return schemas
+	}
+	seen[schema] = true
+	schemas = append(schemas, schema)
+
+	subschemas := []*jsonschema.Schema{schema.Ref, schema.DynamicRef, schema.RecursiveRef, schema.If, schema.Then, schema.Else}
+	subschemas = append(subschemas, schema.AllOf...)
+	subschemas = append(subschemas, schema.AnyOf...)
+	subschemas = append(subschemas, schema.OneOf...)
+	for _, s := range schema.DependentSchemas {
+		subschemas = append(subschemas, s)
+	}
+	for _, s := range schema.Dependencies {
+		if s, ok := s.(*jsonschema.Schema); ok {
+			subschemas = append(subschemas, s)
+		}
+	}
+	for _, s := range subschemas {
+		schemas = inPlaceSchemas(s, schemas, seen)
+	}
+	return schemas
+}
+
+// describesMembers reports whether any of schemas says which members an
+// object may have.
+func describesMembers(schemas []*jsonschema.Schema) bool {
+	for _, s := range schemas {
+		if s.Properties != nil || s.PatternProperties != nil || s.AdditionalProperties != nil || s.UnevaluatedProperties != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// memberSchemas returns the schemas that apply to the member name of an
+// object described by schemas, and whether any of them defines it.
+func memberSchemas(schemas []*jsonschema.Schema, name string) ([]*jsonschema.Schema, bool) {
+	seen := map[*jsonschema.Schema]bool{}
+	var children []*jsonschema.Schema
+	defined := false
+	for _, s := range schemas {
+		// A member named by properties or patternProperties is never
+		// additional, even if their schema is false.
+		named := false
+		if prop, ok := s.Properties[name]; ok {
+			named = true
+			if allowsAny(prop) {
+				children = inPlaceSchemas(prop, children, seen)
+				defined = true
+			}
+		}
+		for re, pattern := range s.PatternProperties {
+			if re.MatchString(name) {
+				named = true
+				if allowsAny(pattern) {
+					children = inPlaceSchemas(pattern, children, seen)
+					defined = true
+				}
+			}
+		}
+		if named {
+			continue
+		}
+		switch additional := s.AdditionalProperties.(type) {
+		case *jsonschema.Schema:
+			if allowsAny(additional) {
+				children = inPlaceSchemas(additional, children, seen)
+				defined = true
+			}
+		case bool:
+			defined = defined || additional
+		}
+	}
+	if !defined {
+		for _, s := range schemas {
+			if s.UnevaluatedProperties != nil && allowsAny(s.UnevaluatedProperties) {
+				children = inPlaceSchemas(s.UnevaluatedProperties, children, seen)
+				defined = true
+			}
+		}
+	}
+	return children, defined
+}
+
+// allowsAny reports whether schema isn't the false schema, which no value
+// satisfies.
+func allowsAny(schema *jsonschema.Schema) bool {
+	return schema.Always == nil || *schema.Always
+}
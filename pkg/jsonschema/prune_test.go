@@ -0,0 +1,71 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPrune(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		data   string
+		want   string
+	}{
+		{
+			"properties",
+			`{"type": "object", "properties": {"name": {"type": "string"}, "address": {"properties": {"city": {}}}}}`,
+			`{"name": "Alice", "age": 3, "address": {"city": "Oslo", "zip": "0150"}}`,
+			`{"address":{"city":"Oslo"},"name":"Alice"}`,
+		},
+		{
+			"patterns and additional properties",
+			`{"properties": {"id": {}, "secret": false}, "patternProperties": {"^x-": {}}, "additionalProperties": true}`,
+			`{"id": 1, "x-trace": "abc", "secret": "s", "other": true}`,
+			`{"id":1,"other":true,"x-trace":"abc"}`,
+		},
+		{
+			"additional schema",
+			`{"properties": {"id": {}}, "additionalProperties": {"properties": {"enabled": {}}}}`,
+			`{"id": 1, "plugin": {"enabled": true, "debug": true}}`,
+			`{"id":1,"plugin":{"enabled":true}}`,
+		},
+		{
+			"combinations and refs",
+			`{
+				"allOf": [{"$ref": "#/$defs/named"}],
+				"oneOf": [{"properties": {"card": {}}}, {"properties": {"iban": {}}}],
+				"if": {"properties": {"kind": {"const": "card"}}},
+				"then": {"properties": {"network": {}}},
+				"$defs": {"named": {"properties": {"name": {}}}}
+			}`,
+			`{"name": "a", "card": "4111", "iban": "DE89", "kind": "card", "network": "visa", "other": 1}`,
+			`{"card":"4111","iban":"DE89","kind":"card","name":"a","network":"visa"}`,
+		},
+		{
+			"arrays",
+			`{"properties": {"items": {"type": "array", "items": {"properties": {"sku": {}}}}, "point": {"prefixItems": [{"properties": {"x": {}}}]}}}`,
+			`{"items": [{"sku": "a", "qty": 1}, "loose"], "point": [{"x": 1, "y": 2}, {"z": 3}]}`,
+			`{"items":[{"sku":"a"},"loose"],"point":[{"x":1},{"z":3}]}`,
+		},
+		{
+			"free-form and unevaluated",
+			`{"properties": {"meta": {}, "extra": {"properties": {"a": {}}, "unevaluatedProperties": {"type": "string"}}, "closed": {"properties": {"a": {}}, "unevaluatedProperties": false}}}`,
+			`{"meta": {"anything": 1}, "extra": {"a": 1, "b": "2"}, "closed": {"a": 1, "b": 2}}`,
+			`{"closed":{"a":1},"extra":{"a":1,"b":"2"},"meta":{"anything":1}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := parseJSON(t, tt.data)
+			got, _ := json.Marshal(Prune(data, compileSchema(t, tt.schema)))
+			if string(got) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+			if !reflect.DeepEqual(data, parseJSON(t, tt.data)) {
+				t.Errorf("expected the data to be left unchanged, got %v", data)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"go-demo/pkg/defaults"
+	"go-demo/pkg/jsonutil"
+)
+
+// Reflector generates schemas for Go types at run time, following the same
+// encoding/json conventions as FromStruct. Named struct types become
+// definitions in Defs, referenced as RefPrefix followed by the type name with
+// its first letter upper-cased. A `description:"..."` struct tag becomes the
+// property's description, and a `default:"..."` tag, as package defaults
+// reads it, its default.
+type Reflector struct {
+	// RefPrefix defaults to "#/$defs/".
+	RefPrefix string
+
+	// Defs collects the definitions of the reflected types.
+	Defs map[string]interface{}
+
+	root reflect.Type
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	numberType     = reflect.TypeOf(json.Number(""))
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// FromType generates a standalone schema for the type of v. References to
+// the root type itself use "#".
+func FromType(v interface{}, draft Draft) map[string]interface{} {
+	r := &Reflector{RefPrefix: "#/" + draft.defsKey() + "/"}
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var schema map[string]interface{}
+	if t != nil && t.Kind() == reflect.Struct {
+		r.root = t
+		schema = r.structSchema(t)
+	} else {
+		schema = r.Reflect(t)
+	}
+	schema["$schema"] = draft.URI()
+	if len(r.Defs) > 0 {
+		schema[draft.defsKey()] = r.Defs
+	}
+	return schema
+}
+
+// Reflect returns the schema of t.
+func (r *Reflector) Reflect(t reflect.Type) map[string]interface{} {
+	if r.RefPrefix == "" {
+		r.RefPrefix = "#/$defs/"
+	}
+	if r.Defs == nil {
+		r.Defs = map[string]interface{}{}
+	}
+	if t == nil {
+		return map[string]interface{}{}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case numberType:
+		return map[string]interface{}{"type": "number"}
+	case rawMessageType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": r.Reflect(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": r.Reflect(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t)
+		}
+		if t == r.root {
+			return map[string]interface{}{"$ref": "#"}
+		}
+		name := defName(t)
+		ref := map[string]interface{}{"$ref": r.RefPrefix + name}
+		if _, done := r.Defs[name]; !done {
+			// Reserve the name first so recursive types refer to themselves.
+			r.Defs[name] = nil
+			r.Defs[name] = r.structSchema(t)
+		}
+		return ref
+	}
+	return map[string]interface{}{}
+}
+
+// defName returns the definition name of a named type.
+func defName(t reflect.Type) string {
+	name := t.Name()
+	first, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToUpper(first)) + name[size:]
+}
+
+// structSchema returns the object schema of a struct's JSON fields.
+func (r *Reflector) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+	r.addFields(t, properties, &required)
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (r *Reflector) addFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		jsonName, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if jsonName == "-" && opts == "" {
+			continue
+		}
+		if f.Anonymous && jsonName == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				// Embedded structs promote their fields, even unexported ones.
+				r.addFields(ft, properties, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		if jsonName != "" {
+			name = jsonName
+		}
+		prop := r.Reflect(f.Type)
+		annotations := map[string]interface{}{}
+		if desc := f.Tag.Get("description"); desc != "" {
+			annotations["description"] = desc
+		}
+		if tag, ok := f.Tag.Lookup(defaults.Tag); ok {
+			if raw, err := defaults.ParseTag(tag, f.Type); err == nil {
+				if def, err := jsonutil.DecodeBytes(raw); err == nil {
+					annotations["default"] = def
+				}
+			}
+		}
+		if len(annotations) > 0 {
+			if _, isRef := prop["$ref"]; isRef {
+				// Siblings of $ref are ignored before 2019-09; wrap it.
+				prop = map[string]interface{}{"allOf": []interface{}{prop}}
+			}
+			for k, v := range annotations {
+				prop[k] = v
+			}
+		}
+		properties[name] = prop
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"go-demo/pkg/defaults"
+)
+
+type reflectBase struct {
+	ID      int64     `json:"id"`
+	Created time.Time `json:"created"`
+}
+
+type reflectAddress struct {
+	City string `json:"city" description:"City name."`
+	Zip  string
+}
+
+type reflectUser struct {
+	reflectBase
+	Name     string            `json:"name" description:"Display name."`
+	Email    string            `json:"email,omitempty"`
+	Age      *int              `json:"age,omitempty"`
+	Address  reflectAddress    `json:"address" description:"Postal address."`
+	Friends  []*reflectUser    `json:"friends,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Avatar   []byte            `json:"avatar,omitempty"`
+	Balance  json.Number       `json:"balance"`
+	Extra    json.RawMessage   `json:"extra,omitempty"`
+	Password string            `json:"-"`
+	internal int
+}
+
+func TestFromType(t *testing.T) {
+	got, err := json.Marshal(FromType(&reflectUser{}, Draft2020))
+	if err != nil {
+		t.Fatalf("Failed to marshal schema: %v", err)
+	}
+	want := `{"$defs":{"ReflectAddress":{"properties":{"Zip":{"type":"string"},"city":{"description":"City name.","type":"string"}},"required":["Zip","city"],"type":"object"}},` +
+		`"$schema":"https://json-schema.org/draft/2020-12/schema",` +
+		`"properties":{"address":{"allOf":[{"$ref":"#/$defs/ReflectAddress"}],"description":"Postal address."},` +
+		`"age":{"type":"integer"},"avatar":{"contentEncoding":"base64","type":"string"},"balance":{"type":"number"},` +
+		`"created":{"format":"date-time","type":"string"},"email":{"type":"string"},"extra":{},` +
+		`"friends":{"items":{"$ref":"#"},"type":"array"},"id":{"type":"integer"},` +
+		`"labels":{"additionalProperties":{"type":"string"},"type":"object"},"name":{"description":"Display name.","type":"string"}},` +
+		`"required":["address","balance","created","id","name"],"type":"object"}`
+	if string(got) != want {
+		t.Errorf("unexpected schema:\n got: %s\nwant: %s", got, want)
+	}
+
+	schema, err := CompileString(string(got))
+	if err != nil {
+		t.Fatalf("The generated schema doesn't compile: %v", err)
+	}
+	var doc interface{}
+	if err := json.Unmarshal([]byte(`{"id": 1, "created": "2024-01-01T00:00:00Z", "name": "A", "balance": 1.5,
+		"address": {"city": "Berlin", "Zip": "10115"}, "friends": [{"id": 2, "created": "2024-01-01T00:00:00Z", "name": "B", "balance": 0, "address": {"city": "x", "Zip": "y"}}]}`), &doc); err != nil {
+		t.Fatalf("Failed to decode document: %v", err)
+	}
+	if err := schema.Validate(doc); err != nil {
+		t.Errorf("A document matching the type should be valid: %v", err)
+	}
+}
+
+func TestReflector(t *testing.T) {
+	r := &Reflector{RefPrefix: "#/components/schemas/"}
+	ref := r.Reflect(reflect.TypeOf([]reflectAddress{}))
+	if items := ref["items"].(map[string]interface{}); items["$ref"] != "#/components/schemas/ReflectAddress" {
+		t.Errorf("expected a reference to ReflectAddress, got %v", ref)
+	}
+	if _, ok := r.Defs["ReflectAddress"]; !ok || len(r.Defs) != 1 {
+		t.Errorf("expected ReflectAddress in the definitions, got %v", r.Defs)
+	}
+}
+
+type reflectServer struct {
+	Host   string         `json:"host,omitempty" default:"localhost"`
+	Port   int            `json:"port,omitempty" default:"8080"`
+	Tags   []string       `json:"tags,omitempty" default:"[\"web\"]"`
+	TLS    *reflectTLS    `json:"tls,omitempty"`
+	Routes []reflectRoute `json:"routes,omitempty"`
+}
+
+type reflectTLS struct {
+	Version string `json:"version,omitempty" default:"1.3" description:"Minimum version."`
+}
+
+type reflectRoute struct {
+	Timeout int `json:"timeout,omitempty" default:"30"`
+}
+
+func TestFromTypeDefaults(t *testing.T) {
+	b, err := json.Marshal(FromType(reflectServer{}, Draft2020))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	schema := compileSchema(t, string(b))
+	if def := schema.Properties["port"].Default; def != json.Number("8080") {
+		t.Errorf("expected the default tag in the schema, got %#v", def)
+	}
+
+	// The schema fills in a document like package defaults fills in the
+	// struct it decodes to.
+	doc := ApplyDefaults(parseJSON(t, `{"routes": [{}]}`), schema, FillContainerDefaults())
+	out, _ := json.Marshal(doc)
+	var fromSchema reflectServer
+	if err := json.Unmarshal(out, &fromSchema); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	fromTags := reflectServer{Routes: []reflectRoute{{}}}
+	if err := defaults.Apply(&fromTags); err != nil {
+		t.Fatalf("defaults.Apply failed: %v", err)
+	}
+	if !reflect.DeepEqual(fromSchema, fromTags) {
+		t.Errorf("expected the same defaults, got %+v from the schema and %+v from the tags", fromSchema, fromTags)
+	}
+}
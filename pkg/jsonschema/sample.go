@@ -0,0 +1,481 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"go-demo/pkg/jsonutil"
+)
+
+// sampleAttempts is how many documents Sample generates before giving up on
+// finding a valid one.
+const sampleAttempts = 100
+
+// Sampler generates random documents that are valid against a schema, for
+// demos, load tests and template previews. Numbers are json.Number, like
+// the documents jsonutil decodes. Create it with NewSampler.
+type Sampler struct {
+	rand *rand.Rand
+
+	// MaxDepth is the nesting depth below which optional properties and
+	// array items beyond minItems are left out, so recursive schemas end.
+	MaxDepth int
+}
+
+// NewSampler returns a sampler seeded with seed; the same seed and schema
+// produce the same documents.
+func NewSampler(seed int64) *Sampler {
+	return &Sampler{rand: rand.New(rand.NewSource(seed)), MaxDepth: 4}
+}
+
+// Sample returns a random document valid against schema. Values are drawn
+// from enum, const, examples, formats, patterns and numeric and length
+// bounds; keywords the generator doesn't model, such as not or if/then/else,
+// are satisfied by retrying. It fails if no valid document turns up.
+func (s *Sampler) Sample(schema *jsonschema.Schema) (interface{}, error) {
+	var last Violation
+	for i := 0; i < sampleAttempts; i++ {
+		doc := s.value(schema, "", 0)
+		violations, err := Validate(schema, doc)
+		if err != nil {
+			return nil, err
+		}
+		if len(violations) == 0 {
+			return doc, nil
+		}
+		last = violations[0]
+	}
+	return nil, fmt.Errorf("no valid document after %d attempts, e.g. %q: %s", sampleAttempts, last.InstanceLocation, last.Message)
+}
+
+// value generates a value for schema; name is the property it is for, if any,
+// and hints at the kind of string to generate.
+func (s *Sampler) value(schema *jsonschema.Schema, name string, depth int) interface{} {
+	schema = resolveRef(schema)
+	if schema == nil || depth > 8*s.MaxDepth {
+		return nil
+	}
+	if schema.Always != nil {
+		return s.anyValue(name)
+	}
+	if len(schema.Constant) > 0 {
+		return schema.Constant[0]
+	}
+	if len(schema.Enum) > 0 {
+		return schema.Enum[s.rand.Intn(len(schema.Enum))]
+	}
+	if len(schema.Examples) > 0 && s.rand.Intn(2) == 0 {
+		return schema.Examples[s.rand.Intn(len(schema.Examples))]
+	}
+
+	var v interface{}
+	if types := sampleTypes(schema); len(types) > 0 {
+		v = s.typed(schema, types[s.rand.Intn(len(types))], name, depth)
+	}
+	// Combinations contribute their own values, merged into the object the
+	// schema itself describes.
+	var branches []*jsonschema.Schema
+	branches = append(branches, schema.AllOf...)
+	if len(schema.OneOf) > 0 {
+		branches = append(branches, schema.OneOf[s.rand.Intn(len(schema.OneOf))])
+	}
+	if len(schema.AnyOf) > 0 {
+		branches = append(branches, schema.AnyOf[s.rand.Intn(len(schema.AnyOf))])
+	}
+	for _, branch := range branches {
+		bv := s.value(branch, name, depth)
+		if obj, ok := v.(map[string]interface{}); ok {
+			if bobj, ok := bv.(map[string]interface{}); ok {
+				v = jsonutil.Merge(obj, bobj, jsonutil.ArrayReplace)
+			}
+			continue
+		}
+		if bv != nil || v == nil {
+			v = bv
+		}
+	}
+	if v == nil && len(branches) == 0 && len(sampleTypes(schema)) == 0 {
+		return s.anyValue(name)
+	}
+	return v
+}
+
+// sampleTypes returns the types schema allows, inferred from its keywords if
+// it has no type. Null is only chosen when nothing else is allowed.
+func sampleTypes(schema *jsonschema.Schema) []string {
+	var types []string
+	for _, t := range schema.Types {
+		if t != "null" {
+			types = append(types, t)
+		}
+	}
+	switch {
+	case len(types) > 0:
+		return types
+	case len(schema.Types) > 0:
+		return schema.Types
+	case schema.Properties != nil || len(schema.Required) > 0 || schema.PatternProperties != nil:
+		return []string{"object"}
+	case schema.Items != nil || schema.Items2020 != nil || schema.PrefixItems != nil:
+		return []string{"array"}
+	case schema.Pattern != nil || schema.Format != "" || schema.MinLength > 0 || schema.MaxLength >= 0:
+		return []string{"string"}
+	case schema.Minimum != nil || schema.Maximum != nil || schema.ExclusiveMinimum != nil || schema.ExclusiveMaximum != nil || schema.MultipleOf != nil:
+		return []string{"number"}
+	}
+	return nil
+}
+
+// anyValue generates a value for a schema without constraints.
+func (s *Sampler) anyValue(name string) interface{} {
+	switch s.rand.Intn(3) {
+	case 0:
+		return json.Number(strconv.Itoa(s.rand.Intn(100)))
+	case 1:
+		return s.rand.Intn(2) == 0
+	}
+	return s.text(name, -1, -1)
+}
+
+func (s *Sampler) typed(schema *jsonschema.Schema, typ, name string, depth int) interface{} {
+	switch typ {
+	case "object":
+		return s.object(schema, depth)
+	case "array":
+		return s.array(schema, name, depth)
+	case "string":
+		return s.string(schema, name)
+	case "integer":
+		return s.number(schema, true)
+	case "number":
+		return s.number(schema, false)
+	case "boolean":
+		return s.rand.Intn(2) == 0
+	}
+	return nil
+}
+
+func (s *Sampler) object(schema *jsonschema.Schema, depth int) map[string]interface{} {
+	obj := map[string]interface{}{}
+	add := func(name string) {
+		if _, ok := obj[name]; ok {
+			return
+		}
+		prop, ok := schema.Properties[name]
+		if !ok {
+			prop, _ = schema.AdditionalProperties.(*jsonschema.Schema)
+		}
+		obj[name] = s.value(prop, name, depth+1)
+		for _, dep := range schema.DependentRequired[name] {
+			if _, ok := obj[dep]; !ok {
+				obj[dep] = s.value(schema.Properties[dep], dep, depth+1)
+			}
+		}
+	}
+
+	for _, name := range schema.Required {
+		add(name)
+	}
+	// Sorted, so a seed always produces the same document.
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if depth < s.MaxDepth && s.rand.Intn(3) > 0 {
+			add(name)
+		}
+	}
+	for _, name := range names {
+		if len(obj) >= schema.MinProperties {
+			break
+		}
+		add(name)
+	}
+	if schema.AdditionalProperties != false {
+		for i := 1; len(obj) < schema.MinProperties; i++ {
+			add("extra" + strconv.Itoa(i))
+		}
+	}
+	for schema.MaxProperties >= 0 && len(obj) > schema.MaxProperties && len(names) > 0 {
+		name := names[len(names)-1]
+		names = names[:len(names)-1]
+		if !isRequired(name, schema.Required) {
+			delete(obj, name)
+		}
+	}
+	return obj
+}
+
+func (s *Sampler) array(schema *jsonschema.Schema, name string, depth int) []interface{} {
+	// Draft 2019-09 and earlier give tuples as an items array.
+	prefix := schema.PrefixItems
+	items := schema.Items2020
+	switch it := schema.Items.(type) {
+	case *jsonschema.Schema:
+		items = it
+	case []*jsonschema.Schema:
+		prefix = it
+		items, _ = schema.AdditionalItems.(*jsonschema.Schema)
+	}
+
+	lo := schema.MinItems
+	if lo < 0 {
+		lo = 0
+	}
+	if schema.Contains != nil && lo < schema.MinContains {
+		lo = schema.MinContains
+	}
+	hi := schema.MaxItems
+	if hi < 0 {
+		hi = lo + 3
+	}
+	n := lo
+	if depth < s.MaxDepth && hi > lo {
+		n += s.rand.Intn(hi - lo + 1)
+	}
+	if schema.AdditionalItems == false && len(prefix) < n {
+		n = len(prefix)
+	}
+
+	arr := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		itemSchema := items
+		if i < len(prefix) {
+			itemSchema = prefix[i]
+		} else if schema.Contains != nil && i-len(prefix) < schema.MinContains {
+			itemSchema = schema.Contains
+		}
+		item := s.value(itemSchema, name, depth+1)
+		// Unique items get a few chances to differ from the ones before.
+		for try := 0; schema.UniqueItems && try < 10 && containsValue(arr, item); try++ {
+			item = s.value(itemSchema, name, depth+1)
+		}
+		arr = append(arr, item)
+	}
+	return arr
+}
+
+func containsValue(arr []interface{}, v interface{}) bool {
+	for _, item := range arr {
+		if reflect.DeepEqual(item, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Sampler) string(schema *jsonschema.Schema, name string) string {
+	if schema.Pattern != nil {
+		if re, err := syntax.Parse(schema.Pattern.String(), syntax.Perl); err == nil {
+			var b strings.Builder
+			s.regexp(&b, re.Simplify())
+			return b.String()
+		}
+	}
+	if v, ok := s.format(schema.Format); ok {
+		return v
+	}
+	return s.text(name, schema.MinLength, schema.MaxLength)
+}
+
+// format generates a string in one of the common formats.
+func (s *Sampler) format(format string) (string, bool) {
+	t := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(s.rand.Int63n(int64(5 * 365 * 24 * time.Hour))))
+	switch format {
+	case "date-time":
+		return t.Format(time.RFC3339), true
+	case "date":
+		return t.Format("2006-01-02"), true
+	case "time":
+		return t.Format("15:04:05Z"), true
+	case "email", "idn-email":
+		return s.pick(firstNames) + "." + s.pick(lastNames) + "@example.com", true
+	case "hostname", "idn-hostname":
+		return s.pick(words) + ".example.com", true
+	case "uri", "iri", "uri-reference", "iri-reference", "url":
+		return "https://example.com/" + s.pick(words), true
+	case "uuid":
+		b := make([]byte, 16)
+		s.rand.Read(b)
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), true
+	case "ipv4":
+		return fmt.Sprintf("10.%d.%d.%d", s.rand.Intn(256), s.rand.Intn(256), 1+s.rand.Intn(254)), true
+	case "ipv6":
+		return fmt.Sprintf("fd00::%x", s.rand.Intn(0xffff)), true
+	}
+	return "", false
+}
+
+var (
+	firstNames = []string{"alice", "bob", "carol", "dave", "erin", "frank", "grace", "heidi"}
+	lastNames  = []string{"smith", "jones", "garcia", "chen", "mueller", "rossi", "kim", "novak"}
+	cities     = []string{"Berlin", "Lisbon", "Osaka", "Toronto", "Nairobi", "Lima", "Oslo", "Perth"}
+	words      = []string{"alpha", "bravo", "delta", "echo", "lima", "nova", "orbit", "pixel", "quartz", "sierra"}
+)
+
+func (s *Sampler) pick(list []string) string {
+	return list[s.rand.Intn(len(list))]
+}
+
+func capitalize(word string) string {
+	return strings.ToUpper(word[:1]) + word[1:]
+}
+
+// text generates a string fitting the length bounds (-1 for none), shaped
+// after the property name where it suggests what the value holds.
+func (s *Sampler) text(name string, minLen, maxLen int) string {
+	var v string
+	switch lower := strings.ToLower(name); {
+	case strings.Contains(lower, "email"):
+		v, _ = s.format("email")
+	case strings.Contains(lower, "city"):
+		v = s.pick(cities)
+	case strings.Contains(lower, "name"):
+		v = capitalize(s.pick(firstNames)) + " " + capitalize(s.pick(lastNames))
+	case strings.HasSuffix(lower, "id"):
+		v = strconv.Itoa(1000 + s.rand.Intn(9000))
+	default:
+		v = s.pick(words)
+		for s.rand.Intn(3) == 0 {
+			v += " " + s.pick(words)
+		}
+	}
+	runes := []rune(v)
+	for len(runes) < minLen {
+		runes = append(runes, rune('a'+s.rand.Intn(26)))
+	}
+	if maxLen >= 0 && len(runes) > maxLen {
+		runes = runes[:maxLen]
+	}
+	return string(runes)
+}
+
+// regexp writes a random string matching re. Unbounded repetitions repeat at
+// most three extra times.
+func (s *Sampler) regexp(b *strings.Builder, re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		// Rune holds inclusive ranges; prefer printable ASCII ones.
+		var ranges [][2]rune
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			lo, hi := re.Rune[i], re.Rune[i+1]
+			if lo < ' ' {
+				lo = ' '
+			}
+			if hi > '~' {
+				hi = '~'
+			}
+			if lo <= hi {
+				ranges = append(ranges, [2]rune{lo, hi})
+			}
+		}
+		if len(ranges) == 0 && len(re.Rune) >= 2 {
+			ranges = append(ranges, [2]rune{re.Rune[0], re.Rune[0]})
+		}
+		if len(ranges) > 0 {
+			r := ranges[s.rand.Intn(len(ranges))]
+			b.WriteRune(r[0] + rune(s.rand.Intn(int(r[1]-r[0])+1)))
+		}
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteRune(rune('a' + s.rand.Intn(26)))
+	case syntax.OpCapture:
+		s.regexp(b, re.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			s.regexp(b, sub)
+		}
+	case syntax.OpAlternate:
+		s.regexp(b, re.Sub[s.rand.Intn(len(re.Sub))])
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		lo, hi := 0, 3
+		switch re.Op {
+		case syntax.OpPlus:
+			lo, hi = 1, 4
+		case syntax.OpQuest:
+			hi = 1
+		case syntax.OpRepeat:
+			lo, hi = re.Min, re.Max
+			if hi < 0 {
+				hi = lo + 3
+			}
+		}
+		for n := lo + s.rand.Intn(hi-lo+1); n > 0; n-- {
+			s.regexp(b, re.Sub[0])
+		}
+	}
+}
+
+// number generates a number within the bounds of schema, a multiple of
+// multipleOf if set.
+func (s *Sampler) number(schema *jsonschema.Schema, integer bool) json.Number {
+	lo, hi := math.Inf(-1), math.Inf(1)
+	if schema.Minimum != nil {
+		lo, _ = schema.Minimum.Float64()
+	}
+	if schema.ExclusiveMinimum != nil {
+		f, _ := schema.ExclusiveMinimum.Float64()
+		lo = math.Max(lo, math.Nextafter(f, math.Inf(1)))
+	}
+	if schema.Maximum != nil {
+		hi, _ = schema.Maximum.Float64()
+	}
+	if schema.ExclusiveMaximum != nil {
+		f, _ := schema.ExclusiveMaximum.Float64()
+		hi = math.Min(hi, math.Nextafter(f, math.Inf(-1)))
+	}
+	switch {
+	case math.IsInf(lo, -1) && math.IsInf(hi, 1):
+		lo, hi = 0, 1000
+	case math.IsInf(lo, -1):
+		lo = hi - 1000
+	case math.IsInf(hi, 1):
+		hi = lo + 1000
+	}
+
+	step := 0.0
+	if schema.MultipleOf != nil {
+		step, _ = schema.MultipleOf.Float64()
+	}
+	if integer {
+		// An integer is a multiple of p/q (in lowest terms) iff it is a
+		// multiple of p.
+		step = 1
+		if schema.MultipleOf != nil {
+			step, _ = new(big.Rat).SetInt(schema.MultipleOf.Num()).Float64()
+		}
+	}
+	if step > 0 {
+		k := math.Ceil(lo / step)
+		n := math.Floor(hi/step) - k
+		if n > 0 {
+			k += float64(s.rand.Int63n(int64(math.Min(n, 1<<53)) + 1))
+		}
+		if integer {
+			return json.Number(strconv.FormatFloat(k*step, 'f', 0, 64))
+		}
+		return json.Number(strconv.FormatFloat(k*step, 'f', -1, 64))
+	}
+	// Two decimals look like prices and measurements, and stay in bounds.
+	v := math.Round((lo+s.rand.Float64()*(hi-lo))*100) / 100
+	if v < lo || v > hi {
+		v = lo
+	}
+	return json.Number(strconv.FormatFloat(v, 'f', -1, 64))
+}
@@ -0,0 +1,106 @@
+package jsonschema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSample(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+	}{
+		{"object", `{
+			"type": "object",
+			"properties": {
+				"name": {"type": "string"},
+				"email": {"type": "string", "format": "email"},
+				"age": {"type": "integer", "minimum": 18, "maximum": 99},
+				"score": {"type": "number", "exclusiveMinimum": 0, "exclusiveMaximum": 1},
+				"tags": {"type": "array", "items": {"type": "string", "enum": ["a", "b", "c"]}, "uniqueItems": true, "maxItems": 3}
+			},
+			"required": ["name", "email", "age"],
+			"additionalProperties": false
+		}`},
+		{"formats and patterns", `{
+			"type": "object",
+			"properties": {
+				"id": {"type": "string", "format": "uuid"},
+				"sku": {"type": "string", "pattern": "^[A-Z]{3}-\\d{4}$"},
+				"created": {"type": "string", "format": "date-time"},
+				"code": {"type": "string", "minLength": 12, "maxLength": 12}
+			},
+			"required": ["id", "sku", "created", "code"]
+		}`},
+		{"multiples", `{"$schema": "http://json-schema.org/draft-07/schema#", "type": "array", "items": [{"type": "integer", "multipleOf": 1.5}, {"type": "number", "multipleOf": 0.25, "minimum": 10}], "minItems": 2, "additionalItems": false}`},
+		{"combinations", `{
+			"allOf": [
+				{"type": "object", "properties": {"id": {"type": "integer"}}, "required": ["id"]},
+				{"oneOf": [
+					{"properties": {"kind": {"const": "card"}, "last4": {"type": "string", "pattern": "^\\d{4}$"}}, "required": ["kind", "last4"]},
+					{"properties": {"kind": {"const": "bank"}, "iban": {"type": "string", "minLength": 15}}, "required": ["kind", "iban"]}
+				]}
+			]
+		}`},
+		{"recursive", `{
+			"$ref": "#/definitions/node",
+			"definitions": {"node": {"type": "object", "properties": {"value": {"type": "integer"}, "children": {"type": "array", "items": {"$ref": "#/definitions/node"}}}, "required": ["value"]}}
+		}`},
+		{"not", `{"type": "integer", "minimum": 0, "maximum": 5, "not": {"const": 3}}`},
+		{"untyped", `{"properties": {"any": {}}, "required": ["any"], "minProperties": 3}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := CompileString(tt.schema)
+			if err != nil {
+				t.Fatalf("Failed to compile schema: %v", err)
+			}
+			s := NewSampler(7)
+			for i := 0; i < 20; i++ {
+				doc, err := s.Sample(schema)
+				if err != nil {
+					t.Fatalf("Sample failed: %v", err)
+				}
+				if violations, _ := Validate(schema, doc); len(violations) > 0 {
+					t.Fatalf("Sample returned an invalid document %v: %+v", doc, violations)
+				}
+			}
+		})
+	}
+}
+
+func TestSampleSeed(t *testing.T) {
+	schema, err := CompileString(`{"type": "object", "properties": {"name": {"type": "string"}, "n": {"type": "number"}, "tags": {"type": "array", "items": {"type": "string"}}}}`)
+	if err != nil {
+		t.Fatalf("Failed to compile schema: %v", err)
+	}
+	sample := func(seed int64) []interface{} {
+		s := NewSampler(seed)
+		var docs []interface{}
+		for i := 0; i < 5; i++ {
+			doc, err := s.Sample(schema)
+			if err != nil {
+				t.Fatalf("Sample failed: %v", err)
+			}
+			docs = append(docs, doc)
+		}
+		return docs
+	}
+	if a, b := sample(1), sample(1); !reflect.DeepEqual(a, b) {
+		t.Errorf("expected the same documents for the same seed:\n%v\n%v", a, b)
+	}
+	if a, b := sample(1), sample(2); reflect.DeepEqual(a, b) {
+		t.Errorf("expected different documents for different seeds, got %v", a)
+	}
+}
+
+func TestSampleImpossible(t *testing.T) {
+	schema, err := CompileString(`{"type": "string", "minLength": 3, "not": {"type": "string"}}`)
+	if err != nil {
+		t.Fatalf("Failed to compile schema: %v", err)
+	}
+	if _, err := NewSampler(1).Sample(schema); err == nil || !strings.Contains(err.Error(), "no valid document") {
+		t.Errorf("expected an error for an unsatisfiable schema, got %v", err)
+	}
+}
@@ -1,7 +1,15 @@
 package jsonschema
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
 	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"go-demo/pkg/jsonutil"
 )
 
 // Package jsonschema provides JSON schema default value application utilities.
@@ -11,10 +19,158 @@ import (
 //   - data == nil is treated as JSON null and is preserved as-is (no defaults applied)
 //   - Defaults are only applied to *missing* object properties (non-required properties only)
 //   - Required properties never receive defaults (they must be explicitly provided)
+//   - Missing objects and arrays that end up empty are left out
+//   - A oneOf or anyOf the data doesn't select applies the defaults of all its branches
 //   - Explicit null values are preserved and do not receive defaults
 //   - Defaults are recursively applied to nested objects and arrays
-func ApplyDefaults(data interface{}, schema *jsonschema.Schema) interface{} {
-	if schema == nil {
+//   - A missing object or array starts empty, even if its schema has a
+//     default, unless FillContainerDefaults is set
+//   - An if/then/else applies the defaults of then if the data, with the other
+//     defaults applied, matches if, and of else otherwise
+//   - dependentSchemas (and the schema form of draft-07 dependencies) apply
+//     their defaults when their property is present
+//   - unevaluatedProperties and unevaluatedItems apply their defaults to the
+//     values no other keyword evaluates, as the validator decides
+//   - $dynamicRef and $recursiveRef resolve against the schemas being
+//     applied, and from draft 2019-09 on the keywords next to a reference
+//     apply along with the schema it leads to
+//   - A recursive schema isn't expanded into missing values: a missing
+//     property whose schema is already being applied further up stays missing
+//   - Schemas nested deeper than the maximum depth fail with ErrMaxDepth, and
+//     $refs that only lead to each other with ErrRefCycle
+//
+// Options change these rules; see DefaultsOptions. ApplyDefaults returns data
+// unchanged if they make it fail, as StrictBranches does for an ambiguous
+// oneOf; ApplyDefaultsCtx returns the error.
+func ApplyDefaults(data interface{}, schema *jsonschema.Schema, opts ...DefaultsOption) interface{} {
+	d := &defaulter{opts: newDefaultsOptions(opts)}
+	result := d.apply(data, schema, "")
+	if d.err != nil {
+		return data
+	}
+	return result
+}
+
+// ApplyDefaultsCtx is ApplyDefaults that gives up with ctx's error as soon as
+// ctx is done, checking it at every value of the document. It also returns
+// the *AmbiguousOneOfError of StrictBranches.
+func ApplyDefaultsCtx(ctx context.Context, data interface{}, schema *jsonschema.Schema, opts ...DefaultsOption) (interface{}, error) {
+	d := &defaulter{ctx: ctx, opts: newDefaultsOptions(opts)}
+	result := d.apply(data, schema, "")
+	if d.err != nil {
+		return nil, d.err
+	}
+	return result, nil
+}
+
+// ApplyDefaultsInto decodes the JSON document data, applies the defaults of
+// schema to it and stores the result in dst, which must be a pointer, as
+// json.Unmarshal does. Decoding errors are *jsonutil.DecodeError values, and
+// the errors of the options are returned as ApplyDefaultsCtx returns them.
+func ApplyDefaultsInto(data []byte, schema *jsonschema.Schema, dst interface{}, opts ...DefaultsOption) error {
+	doc, err := jsonutil.DecodeBytes(data)
+	if err != nil {
+		return err
+	}
+	doc, err = ApplyDefaultsCtx(context.Background(), doc, schema, opts...)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, dst); err != nil {
+		return fmt.Errorf("decode into %T: %w", dst, err)
+	}
+	return nil
+}
+
+// ApplyDefaultsBytes compiles the schema document schemaStr, applies its
+// defaults to the JSON document data and returns the result as a line of
+// compact JSON, for callers that only hold bytes, such as CLI tools and HTTP
+// handlers. Numbers are decoded as json.Number, so large integers come out as they went
+// in. Errors are *SchemaCompileError and *jsonutil.DecodeError values, or the
+// errors of the options as ApplyDefaultsCtx returns them.
+func ApplyDefaultsBytes(data []byte, schemaStr string, opts ...DefaultsOption) ([]byte, error) {
+	schema, err := CompileString(schemaStr)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := jsonutil.DecodeBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	doc, err = ApplyDefaultsCtx(context.Background(), doc, schema, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return jsonutil.Marshal(jsonutil.FormatJSON, doc, 0)
+}
+
+// ApplyDefaultsOrRootDefault is ApplyDefaults for data that may be absent,
+// such as an empty request body or a configuration file that doesn't exist.
+// Nil data is taken as an absent document rather than JSON null: it becomes
+// the root default, with the defaults applied to it, or what a missing
+// property with schema would get, such as an object of the defaults of its
+// properties. It returns nil if there is nothing to fill in, or if the
+// options make it fail.
+func ApplyDefaultsOrRootDefault(data interface{}, schema *jsonschema.Schema, opts ...DefaultsOption) interface{} {
+	if data != nil {
+		return ApplyDefaults(data, schema, opts...)
+	}
+	d := &defaulter{opts: newDefaultsOptions(opts)}
+	var result interface{}
+	if def := d.defaultOf(d.resolve(schema)); def != nil {
+		result = d.apply(copyValue(def), schema, "")
+	} else {
+		result = d.applyForProperty(nil, schema, "")
+	}
+	if d.err != nil || !d.shouldAdd(result, schema) {
+		return nil
+	}
+	return result
+}
+
+// defaulter applies defaults, recording what it does in explain and the
+// deprecated properties of the data in deprecated if set. With a ctx, it
+// stops at the first value it reaches after ctx is done and keeps ctx's
+// error in err, as it does with the errors of its options.
+type defaulter struct {
+	opts       DefaultsOptions
+	explain    *Explanation
+	deprecated *[]DeprecationWarning
+	ctx        context.Context
+	err        error
+
+	// depth is the number of schemas being applied and active counts them
+	// by schema, with references resolved. scope is the dynamic scope: the
+	// same schemas, outermost first, with those their references pass
+	// through.
+	scope  []*jsonschema.Schema
+	depth  int
+	active map[*jsonschema.Schema]int
+
+	// validated memoizes the validations of data against the branches and
+	// conditions the defaulter selects by.
+	validated map[validKey]validation
+
+	// computed is set once a default is generated or expanded from the
+	// environment, which a DefaultsPlan can't do ahead of time.
+	computed bool
+}
+
+// done reports whether the defaulter has been canceled.
+func (d *defaulter) done() bool {
+	if d.err == nil && d.ctx != nil {
+		d.err = d.ctx.Err()
+	}
+	return d.err != nil
+}
+
+// apply applies the defaults of schema to data, found at pointer.
+func (d *defaulter) apply(data interface{}, schema *jsonschema.Schema, pointer string) interface{} {
+	if schema == nil || d.done() {
 		return data
 	}
 
@@ -23,36 +179,94 @@ func ApplyDefaults(data interface{}, schema *jsonschema.Schema) interface{} {
 		return nil
 	}
 
-	// Handle $ref: resolve reference first
-	schema = resolveRef(schema)
+	// Handle $ref, $dynamicRef and $recursiveRef: resolve references first
+	chain := d.resolveChain(schema)
+	schema = chain[len(chain)-1]
+	if hasRef(schema) {
+		d.err = fmt.Errorf("%w at %s", ErrRefCycle, schema.Location)
+		return data
+	}
+	if d.depth >= d.opts.maxDepth() {
+		d.err = fmt.Errorf("%w of %d exceeded at %q (%s)", ErrMaxDepth, d.opts.maxDepth(), pointer, schema.Location)
+		return data
+	}
+	if d.active == nil {
+		d.active = map[*jsonschema.Schema]int{}
+	}
+	d.depth++
+	d.active[schema]++
+	d.scope = append(d.scope, chain...)
+	defer func() {
+		d.depth--
+		d.active[schema]--
+		d.scope = d.scope[:len(d.scope)-len(chain)]
+	}()
+
+	if d.opts.Coerce {
+		data = coerce(data, schema)
+	}
+
+	// From draft 2019-09 on, the keywords next to a reference apply too,
+	// after those of the schema it leads to.
+	result := data
+	for i := len(chain) - 1; i >= 0; i-- {
+		result = d.applySchema(result, chain[i], pointer)
+	}
+	return result
+}
+
+// applySchema applies the defaults of the keywords of schema, ignoring its
+// references, to data, found at pointer.
+func (d *defaulter) applySchema(data interface{}, schema *jsonschema.Schema, pointer string) interface{} {
+	result := d.applyKeywords(data, schema, pointer)
+	if len(schema.DependentSchemas) > 0 || len(schema.Dependencies) > 0 {
+		result = d.applyDependents(result, schema, pointer)
+	}
+	if schema.If != nil {
+		result = d.applyConditional(result, schema, pointer)
+	}
+	if schema.UnevaluatedProperties != nil || schema.UnevaluatedItems != nil {
+		result = d.applyUnevaluated(result, schema, pointer)
+	}
+	return result
+}
 
+// applyKeywords applies the defaults of the combinations, properties and items
+// of schema to data, found at pointer.
+func (d *defaulter) applyKeywords(data interface{}, schema *jsonschema.Schema, pointer string) interface{} {
 	// Handle combination schemas: allOf, oneOf, anyOf
 	if len(schema.AllOf) > 0 {
-		return applyDefaultsWithCombination(data, schema.AllOf, schema, "allOf")
+		return d.applyWithCombination(data, schema.AllOf, schema, "allOf", pointer)
 	}
 	if len(schema.OneOf) > 0 {
-		return applyDefaultsWithCombination(data, schema.OneOf, schema, "oneOf")
+		return d.applyWithCombination(data, schema.OneOf, schema, "oneOf", pointer)
 	}
 	if len(schema.AnyOf) > 0 {
-		return applyDefaultsWithCombination(data, schema.AnyOf, schema, "anyOf")
+		return d.applyWithCombination(data, schema.AnyOf, schema, "anyOf", pointer)
 	}
 
 	// Check if it's an object schema (has properties, even without explicit type)
 	if schema.Properties != nil {
 		if obj, ok := data.(map[string]interface{}); ok {
-			return applyDefaultsToObject(obj, schema)
+			return d.applyToObject(obj, schema, pointer)
 		}
 		// Type mismatch: return original data
 		return data
 	}
 
-	if hasType(schema, "array") {
-		return applyDefaultsToArray(data, schema)
+	if isArraySchema(schema) {
+		return d.applyToArray(data, schema, pointer)
 	}
 
 	return data
 }
 
+// isArraySchema reports whether schema describes arrays: it has the array
+// type or any of the item keywords.
+func isArraySchema(schema *jsonschema.Schema) bool {
+	return hasType(schema, "array") || schema.Items != nil || schema.Items2020 != nil || len(schema.PrefixItems) > 0
+}
+
 // hasType checks if schema has the specified type
 func hasType(schema *jsonschema.Schema, typ string) bool {
 	if schema == nil {
@@ -79,10 +293,10 @@ func isRequired(propName string, required []string) bool {
 	return false
 }
 
-// applyDefaultsToObject applies default values to an object.
+// applyToObject applies default values to an object.
 // Only non-required properties that are missing will receive defaults.
 // Required properties are skipped and must be explicitly provided.
-func applyDefaultsToObject(data interface{}, schema *jsonschema.Schema) interface{} {
+func (d *defaulter) applyToObject(data interface{}, schema *jsonschema.Schema, pointer string) interface{} {
 	dataMap, ok := data.(map[string]interface{})
 	if !ok || schema.Properties == nil {
 		return data
@@ -94,51 +308,105 @@ func applyDefaultsToObject(data interface{}, schema *jsonschema.Schema) interfac
 	}
 
 	for propName, propSchema := range schema.Properties {
+		if _, exists := dataMap[propName]; exists && propSchema != nil {
+			d.warnDeprecated(propSchema, pointer+jsonutil.JoinPointer(propName))
+		}
 		// Skip required properties - they must be explicitly provided, no defaults applied
-		if propSchema == nil || isRequired(propName, schema.Required) {
+		if propSchema == nil || (!d.opts.FillRequired && isRequired(propName, schema.Required)) {
+			continue
+		}
+		// Skip properties the options leave to the other side of an API
+		if d.skipsAccess(propSchema) {
 			continue
 		}
 
 		existingValue, exists := result[propName]
 		if !exists {
 			// Property doesn't exist (non-required): apply default or recursively process
-			// ApplyDefaults handles $ref internally, so we can use it directly
-			if value := applyDefaultsForProperty(nil, propSchema); shouldAddValue(value) {
+			// apply handles $ref internally, so we can use it directly
+			propPointer := pointer + jsonutil.JoinPointer(propName)
+			if value := d.applyForProperty(nil, propSchema, propPointer); d.shouldAdd(value, propSchema) {
 				result[propName] = value
+				if d.explain != nil && !shouldAddValue(value) {
+					// Kept empty containers have no defaults inside to explain them.
+					d.explain.Defaults = append(d.explain.Defaults, AppliedDefault{Pointer: propPointer, Value: value, Location: d.resolve(propSchema).Location})
+				}
 			}
 		} else if existingValue != nil {
 			// Property exists and is not nil: recursively apply defaults to nested structures
 			// Preserve nil values as-is (user explicitly provided null)
-			result[propName] = applyDefaultsForProperty(existingValue, propSchema)
+			result[propName] = d.applyForProperty(existingValue, propSchema, pointer+jsonutil.JoinPointer(propName))
 		}
 	}
 
 	return result
 }
 
-// applyDefaultsForProperty applies defaults to a property value based on its schema
-func applyDefaultsForProperty(value interface{}, propSchema *jsonschema.Schema) interface{} {
+// skipsAccess reports whether the options skip a property with schema for
+// being readOnly or writeOnly, in any of its annotationSchemas.
+func (d *defaulter) skipsAccess(schema *jsonschema.Schema) bool {
+	if !d.opts.SkipReadOnly && !d.opts.SkipWriteOnly {
+		return false
+	}
+	for _, s := range d.annotationSchemas(schema) {
+		if (d.opts.SkipReadOnly && s.ReadOnly) || (d.opts.SkipWriteOnly && s.WriteOnly) {
+			return true
+		}
+	}
+	return false
+}
+
+// annotationSchemas returns the schemas whose annotations, such as readOnly
+// and deprecated, apply to a property with schema: schema, the schemas its
+// references lead to and the allOf of each.
+func (d *defaulter) annotationSchemas(schema *jsonschema.Schema) []*jsonschema.Schema {
+	var schemas []*jsonschema.Schema
+	for _, s := range d.resolveChain(schema) {
+		schemas = append(append(schemas, s), s.AllOf...)
+	}
+	return schemas
+}
+
+// applyForProperty applies defaults to a property value based on its schema
+func (d *defaulter) applyForProperty(value interface{}, propSchema *jsonschema.Schema, pointer string) interface{} {
 	if propSchema == nil {
 		return value
 	}
 
 	// For nil values (property missing), try to infer type from schema to create empty structure
 	if value == nil {
-		// ApplyDefaults will handle $ref and combination keywords.
+		// apply will handle $ref and combination keywords.
 		// Here we just need a hint whether we should start from an empty object/array.
-		resolvedSchema := resolveRef(propSchema)
+		resolvedSchema := d.resolve(propSchema)
+
+		if resolvedSchema != nil && d.active[resolvedSchema] > 0 {
+			// Expanding a recursive schema would never end; its default,
+			// if any, is all a missing value gets.
+			if def := d.defaultOf(resolvedSchema); def != nil {
+				return d.explainDefault(pointer, def, resolvedSchema)
+			}
+			return nil
+		}
 
 		if resolvedSchema != nil {
+			def := d.defaultOf(resolvedSchema)
+			_, objectDefault := def.(map[string]interface{})
+			_, arrayDefault := def.([]interface{})
 			// Direct object/array hints from this schema
-			if resolvedSchema.Properties != nil || hasType(resolvedSchema, "object") {
+			if objectDefault && d.opts.FillContainerDefaults && (resolvedSchema.Properties != nil || hasType(resolvedSchema, "object")) {
+				value = d.explainDefault(pointer, def, resolvedSchema)
+			} else if arrayDefault && d.opts.FillContainerDefaults && hasType(resolvedSchema, "array") {
+				value = d.explainDefault(pointer, def, resolvedSchema)
+			} else if resolvedSchema.Properties != nil || hasType(resolvedSchema, "object") {
 				value = map[string]interface{}{}
 			} else if hasType(resolvedSchema, "array") {
 				value = []interface{}{}
 			} else {
-				// If it's a combination schema, try to infer from its children
+				// If it's a combination or conditional schema, try to infer from its children
 				children := append(append(resolvedSchema.AllOf, resolvedSchema.AnyOf...), resolvedSchema.OneOf...)
+				children = append(children, resolvedSchema.Then, resolvedSchema.Else)
 				for _, child := range children {
-					child = resolveRef(child)
+					child = d.resolve(child)
 					if child == nil {
 						continue
 					}
@@ -153,8 +421,8 @@ func applyDefaultsForProperty(value interface{}, propSchema *jsonschema.Schema)
 				}
 
 				// If we still don't know the structure, but schema has a default, use it directly
-				if value == nil && resolvedSchema.Default != nil {
-					return resolvedSchema.Default
+				if value == nil && def != nil {
+					return d.explainDefault(pointer, def, resolvedSchema)
 				}
 			}
 		}
@@ -165,20 +433,169 @@ func applyDefaultsForProperty(value interface{}, propSchema *jsonschema.Schema)
 		}
 	}
 
-	return ApplyDefaults(value, propSchema)
+	return d.apply(value, propSchema, pointer)
 }
 
-// resolveRef resolves $ref recursively
+// resolveRef resolves $ref recursively. If the $refs lead back to a schema
+// it has already passed, it stops there, returning a schema whose Ref is
+// still set. The compiler rejects such cycles, but schemas built by hand may
+// have them.
 func resolveRef(schema *jsonschema.Schema) *jsonschema.Schema {
 	if schema == nil {
 		return nil
 	}
+	var seen map[*jsonschema.Schema]bool
 	for schema.Ref != nil {
+		if schema.Ref.Ref == nil {
+			return schema.Ref
+		}
+		if seen == nil {
+			seen = map[*jsonschema.Schema]bool{}
+		}
+		if seen[schema] {
+			return schema
+		}
+		seen[schema] = true
 		schema = schema.Ref
 	}
 	return schema
 }
 
+// explainDefault records that def, the default of schema, is filled in at
+// pointer, and returns a copy of it, so that the result never shares maps or
+// slices with the schema.
+func (d *defaulter) explainDefault(pointer string, def interface{}, schema *jsonschema.Schema) interface{} {
+	def = copyValue(def)
+	if d.explain != nil && shouldAddValue(def) {
+		d.explain.Defaults = append(d.explain.Defaults, AppliedDefault{Pointer: pointer, Value: def, Location: schema.Location})
+	}
+	return def
+}
+
+// declaredDefault returns the default of schema as written, or if it has
+// none its const with FillConst or else its first example with FillExamples.
+func (d *defaulter) declaredDefault(schema *jsonschema.Schema) interface{} {
+	switch {
+	case schema.Default != nil:
+		return schema.Default
+	case d.opts.FillConst && len(schema.Constant) > 0:
+		return schema.Constant[0]
+	case d.opts.FillExamples && len(schema.Examples) > 0:
+		return schema.Examples[0]
+	}
+	return nil
+}
+
+// defaultOf returns the default of schema, or if it has none its const with
+// FillConst or else its first example with FillExamples, with its numbers
+// normalized under NormalizeNumbers. A computed default is generated anew.
+func (d *defaulter) defaultOf(schema *jsonschema.Schema) interface{} {
+	if schema == nil {
+		return nil
+	}
+	def := d.declaredDefault(schema)
+	if name, ok := generatorName(def); ok {
+		d.computed = true
+		var err error
+		if def, err = generate(name); err != nil {
+			if d.err == nil {
+				d.err = fmt.Errorf("%w at %s", err, schema.Location)
+			}
+			return nil
+		}
+	}
+	if len(d.opts.ExpandEnv) > 0 && def != nil {
+		d.computed = true
+		def = expandEnv(def, d.opts.ExpandEnv)
+	}
+	if def != nil && d.opts.NormalizeNumbers {
+		return jsonutil.NormalizeNumbers(def)
+	}
+	return def
+}
+
+// resolve resolves the references of schema like resolveRef, also following
+// $dynamicRef and $recursiveRef.
+func (d *defaulter) resolve(schema *jsonschema.Schema) *jsonschema.Schema {
+	if schema == nil {
+		return nil
+	}
+	chain := d.resolveChain(schema)
+	return chain[len(chain)-1]
+}
+
+// resolveChain returns schema followed by the schemas its $ref, $dynamicRef
+// and $recursiveRef lead to, one after the other. Like resolveRef, it stops
+// at a schema whose references lead back into the chain.
+func (d *defaulter) resolveChain(schema *jsonschema.Schema) []*jsonschema.Schema {
+	chain := []*jsonschema.Schema{schema}
+	for {
+		next := d.refTarget(schema)
+		if next == nil {
+			return chain
+		}
+		for _, s := range chain {
+			if s == next {
+				return chain
+			}
+		}
+		chain = append(chain, next)
+		schema = next
+	}
+}
+
+// refTarget returns the schema the reference of schema leads to, or nil if it
+// has none. A $dynamicRef to a $dynamicAnchor, or a $recursiveRef to a
+// schema with $recursiveAnchor, leads to the outermost schema of the dynamic
+// scope with the same anchor, so that an extending schema replaces the one it
+// extends at every level of a recursive structure.
+func (d *defaulter) refTarget(schema *jsonschema.Schema) *jsonschema.Schema {
+	switch {
+	case schema.Ref != nil:
+		return schema.Ref
+	case schema.DynamicRef != nil:
+		target := schema.DynamicRef
+		if target.DynamicAnchor != "" {
+			for _, s := range d.scope {
+				if s.DynamicAnchor == target.DynamicAnchor {
+					return s
+				}
+			}
+		}
+		return target
+	case schema.RecursiveRef != nil:
+		target := schema.RecursiveRef
+		if target.RecursiveAnchor {
+			for _, s := range d.scope {
+				if s.RecursiveAnchor {
+					return s
+				}
+			}
+		}
+		return target
+	}
+	return nil
+}
+
+// hasRef reports whether schema has a $ref, $dynamicRef or $recursiveRef.
+func hasRef(schema *jsonschema.Schema) bool {
+	return schema.Ref != nil || schema.DynamicRef != nil || schema.RecursiveRef != nil
+}
+
+// shouldAdd reports whether a value created for a missing property with
+// schema is added under the empty policy.
+func (d *defaulter) shouldAdd(value interface{}, schema *jsonschema.Schema) bool {
+	switch d.opts.Empty {
+	case KeepEmpty:
+		return value != nil
+	case KeepEmptyDefaults:
+		if value != nil && d.declaredDefault(d.resolve(schema)) != nil {
+			return true
+		}
+	}
+	return shouldAddValue(value)
+}
+
 // shouldAddValue checks if a value should be added to the result
 // Returns false for nil values and empty objects/arrays
 func shouldAddValue(value interface{}) bool {
@@ -196,8 +613,8 @@ func shouldAddValue(value interface{}) bool {
 	return true
 }
 
-// applyDefaultsToArray applies default values to array items
-func applyDefaultsToArray(data interface{}, schema *jsonschema.Schema) interface{} {
+// applyToArray applies default values to array items
+func (d *defaulter) applyToArray(data interface{}, schema *jsonschema.Schema, pointer string) interface{} {
 	arr, ok := data.([]interface{})
 	if !ok {
 		return data
@@ -206,31 +623,69 @@ func applyDefaultsToArray(data interface{}, schema *jsonschema.Schema) interface
 	result := make([]interface{}, len(arr))
 	for i, item := range arr {
 		// Get schema for this specific position (handles tuple validation)
-		itemsSchema := getItemsSchemaForIndex(schema, i)
+		itemsSchema := d.itemsSchema(schema, i)
 		if itemsSchema != nil {
 			// Apply defaults to array item
 			// Note: We preserve the processed value even if it becomes empty/nil, as this is user-provided data
-			result[i] = ApplyDefaults(item, itemsSchema)
+			result[i] = d.apply(item, itemsSchema, pointer+"/"+strconv.Itoa(i))
 		} else {
 			// No schema for this item, keep original value
 			result[i] = item
 		}
 	}
 
+	if d.opts.PadArrays {
+		for i := len(result); i < schema.MinItems; i++ {
+			item := d.padItem(d.itemsSchema(schema, i), pointer+"/"+strconv.Itoa(i))
+			if item == nil {
+				break
+			}
+			result = append(result, item)
+		}
+	}
+
 	return result
 }
 
+// padItem returns the item PadArrays appends at pointer for the items schema
+// schema, or nil if there is none to make.
+func (d *defaulter) padItem(schema *jsonschema.Schema, pointer string) interface{} {
+	if schema == nil {
+		return nil
+	}
+	if item := d.applyForProperty(nil, schema, pointer); item != nil {
+		if d.explain != nil && !shouldAddValue(item) {
+			// Empty items have no defaults inside to explain them.
+			d.explain.Defaults = append(d.explain.Defaults, AppliedDefault{Pointer: pointer, Value: item, Location: d.resolve(schema).Location})
+		}
+		return item
+	}
+	item := GenerateSkeleton(schema)
+	if item == nil {
+		return nil
+	}
+	item = d.apply(item, schema, pointer)
+	if d.explain != nil {
+		d.explain.Defaults = append(d.explain.Defaults, AppliedDefault{Pointer: pointer, Value: item, Location: d.resolve(schema).Location})
+	}
+	return item
+}
+
 // getItemsSchemaForIndex extracts the items schema for a specific array index
 // Handles both list validation (single schema) and tuple validation (array of schemas)
-// For tuple validation, returns the schema at the given index, or the last schema if index exceeds
+// For tuple validation, returns the schema at the given index, or the additionalItems schema if index exceeds
 // For list validation, returns the single schema for all indices
+// For draft 2020-12, returns the prefixItems schema at the given index, or items beyond them
 func getItemsSchemaForIndex(schema *jsonschema.Schema, index int) *jsonschema.Schema {
 	if schema == nil {
 		return nil
 	}
 
-	// Handle Items2020 (draft 2020-12)
-	if schema.Items2020 != nil {
+	// Handle prefixItems and Items2020 (draft 2020-12)
+	if index < len(schema.PrefixItems) {
+		return schema.PrefixItems[index]
+	}
+	if schema.Items2020 != nil || len(schema.PrefixItems) > 0 {
 		return schema.Items2020
 	}
 
@@ -252,17 +707,31 @@ func getItemsSchemaForIndex(schema *jsonschema.Schema, index int) *jsonschema.Sc
 		if index < len(items) {
 			return items[index]
 		}
-		// If index exceeds tuple length, use the last schema (additionalItems behavior)
-		// This follows JSON Schema spec: additional items beyond tuple length use the last schema
-		return items[len(items)-1]
+		// If index exceeds tuple length, use the additionalItems schema. The
+		// boolean forms have no schema: true allows anything, false nothing.
+		additional, _ := schema.AdditionalItems.(*jsonschema.Schema)
+		return additional
 	default:
 		return nil
 	}
 }
 
-// applyDefaultsWithCombination applies defaults from combination schemas (allOf/oneOf/anyOf)
-func applyDefaultsWithCombination(data interface{}, subschemas []*jsonschema.Schema, baseSchema *jsonschema.Schema, mode string) interface{} {
+// itemsSchema returns the schema of the item of an array with schema at
+// index, or under LegacyAdditionalItems the last schema of a tuple for items
+// beyond it that additionalItems doesn't describe with a schema.
+func (d *defaulter) itemsSchema(schema *jsonschema.Schema, index int) *jsonschema.Schema {
+	if items, ok := schema.Items.([]*jsonschema.Schema); ok && d.opts.LegacyAdditionalItems && len(items) > 0 && index >= len(items) {
+		if _, ok := schema.AdditionalItems.(*jsonschema.Schema); !ok {
+			return items[len(items)-1]
+		}
+	}
+	return getItemsSchemaForIndex(schema, index)
+}
+
+// applyWithCombination applies defaults from combination schemas (allOf/oneOf/anyOf)
+func (d *defaulter) applyWithCombination(data interface{}, subschemas []*jsonschema.Schema, baseSchema *jsonschema.Schema, mode string, pointer string) interface{} {
 	var schemasToApply []*jsonschema.Schema
+	matched := true
 
 	switch mode {
 	case "allOf":
@@ -272,21 +741,31 @@ func applyDefaultsWithCombination(data interface{}, subschemas []*jsonschema.Sch
 		// oneOf: find exactly one matching schema
 		var matching []*jsonschema.Schema
 		for _, s := range subschemas {
-			if s.Validate(data) == nil {
+			if d.done() {
+				return data
+			}
+			if d.valid(s, data) {
 				matching = append(matching, s)
 			}
 		}
 		if len(matching) == 1 {
 			schemasToApply = matching
+		} else if len(matching) > 1 && d.opts.Branches == StrictBranches {
+			d.err = &AmbiguousOneOfError{Pointer: pointer, Location: baseSchema.Location, Branches: branchIndexes(subschemas, matching)}
+			return data
 		} else {
 			// Graceful degradation: apply all if no unique match
-			schemasToApply = subschemas
+			schemasToApply = d.unmatched(subschemas)
+			matched = false
 		}
 	case "anyOf":
 		// anyOf: find matching schemas
 		var matching []*jsonschema.Schema
 		for _, s := range subschemas {
-			if s.Validate(data) == nil {
+			if d.done() {
+				return data
+			}
+			if d.valid(s, data) {
 				matching = append(matching, s)
 			}
 		}
@@ -294,31 +773,123 @@ func applyDefaultsWithCombination(data interface{}, subschemas []*jsonschema.Sch
 			schemasToApply = matching
 		} else {
 			// Graceful degradation: apply all if none match
-			schemasToApply = subschemas
+			schemasToApply = d.unmatched(subschemas)
+			matched = false
 		}
 	}
+	if d.explain != nil && mode != "allOf" {
+		d.explain.Branches = append(d.explain.Branches, selectedBranches(pointer, mode, subschemas, schemasToApply, matched))
+	}
 
 	// Apply defaults from selected schemas sequentially
 	result := data
 	for _, s := range schemasToApply {
-		result = ApplyDefaults(result, s)
+		result = d.apply(result, s, pointer)
 	}
 
-	return applyDefaultsToBaseSchema(result, baseSchema)
+	return d.applyToBaseSchema(result, baseSchema, pointer)
+}
+
+// unmatched returns the branches that supply defaults when the data selects
+// none of subschemas, under the branch policy.
+func (d *defaulter) unmatched(subschemas []*jsonschema.Schema) []*jsonschema.Schema {
+	if d.opts.Branches != AllBranches {
+		return nil
+	}
+	return subschemas
+}
+
+// branchIndexes returns the indexes of selected in subschemas.
+func branchIndexes(subschemas, selected []*jsonschema.Schema) []int {
+	var indexes []int
+	for i, s := range subschemas {
+		for _, t := range selected {
+			if s == t {
+				indexes = append(indexes, i)
+				break
+			}
+		}
+	}
+	return indexes
+}
+
+// applyDependents applies the defaults of the dependentSchemas, and of the
+// schema forms of dependencies, whose property is present in data, in the
+// order of their properties. Presence is checked with the defaults applied so
+// far, like the validator would.
+func (d *defaulter) applyDependents(data interface{}, schema *jsonschema.Schema, pointer string) interface{} {
+	dependents := map[string][]*jsonschema.Schema{}
+	keywords := map[*jsonschema.Schema]string{}
+	for name, s := range schema.Dependencies {
+		if s, ok := s.(*jsonschema.Schema); ok {
+			dependents[name] = append(dependents[name], s)
+			keywords[s] = "dependencies"
+		}
+	}
+	for name, s := range schema.DependentSchemas {
+		dependents[name] = append(dependents[name], s)
+		keywords[s] = "dependentSchemas"
+	}
+	names := make([]string, 0, len(dependents))
+	for name := range dependents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		obj, ok := data.(map[string]interface{})
+		if !ok || d.done() {
+			return data
+		}
+		if _, present := obj[name]; !present {
+			continue
+		}
+		for _, s := range dependents[name] {
+			if d.explain != nil {
+				d.explain.Branches = append(d.explain.Branches, SelectedBranches{
+					Pointer: pointer, Keyword: keywords[s], Matched: true, Locations: []string{s.Location},
+				})
+			}
+			data = d.apply(data, s, pointer)
+		}
+	}
+	return data
+}
+
+// applyConditional applies the defaults of schema's then if data matches its
+// if, and of its else otherwise. Data is checked with the defaults applied so
+// far, so that a defaulted discriminator selects its branch.
+func (d *defaulter) applyConditional(data interface{}, schema *jsonschema.Schema, pointer string) interface{} {
+	if d.done() {
+		return data
+	}
+	branch, keyword := schema.Else, "else"
+	if d.valid(schema.If, data) {
+		branch, keyword = schema.Then, "then"
+	}
+	if branch == nil {
+		return data
+	}
+	if d.explain != nil {
+		d.explain.Branches = append(d.explain.Branches, SelectedBranches{
+			Pointer: pointer, Keyword: keyword, Matched: true, Locations: []string{branch.Location},
+		})
+	}
+	return d.apply(data, branch, pointer)
 }
 
-// applyDefaultsToBaseSchema applies defaults from the base schema (properties, etc.)
+// applyToBaseSchema applies defaults from the base schema (properties, etc.)
 // This is used after applying defaults from combination schemas (allOf/anyOf/oneOf)
-func applyDefaultsToBaseSchema(data interface{}, schema *jsonschema.Schema) interface{} {
+func (d *defaulter) applyToBaseSchema(data interface{}, schema *jsonschema.Schema, pointer string) interface{} {
 	if schema.Properties != nil {
 		if obj, ok := data.(map[string]interface{}); ok {
-			return applyDefaultsToObject(obj, schema)
+			return d.applyToObject(obj, schema, pointer)
 		}
 		return data
 	}
 
-	if hasType(schema, "array") {
-		return applyDefaultsToArray(data, schema)
+	if isArraySchema(schema) {
+		return d.applyToArray(data, schema, pointer)
 	}
 
 	return data
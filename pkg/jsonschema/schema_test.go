@@ -2,10 +2,16 @@ package jsonschema
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
 
 	jsonschemaLib "github.com/santhosh-tekuri/jsonschema/v5"
+
+	"go-demo/pkg/jsonutil"
 )
 
 func compileSchema(t *testing.T, schemaStr string) *jsonschemaLib.Schema {
@@ -91,6 +97,141 @@ func TestApplyDefaults_Basic(t *testing.T) {
 	}
 }
 
+func TestApplyDefaultsBytes(t *testing.T) {
+	schema := `{"type": "object", "properties": {"port": {"default": 8080}, "id": {"type": "integer"}}}`
+
+	got, err := ApplyDefaultsBytes([]byte(`{"id": 9007199254740993}`), schema)
+	if err != nil {
+		t.Fatalf("ApplyDefaultsBytes failed: %v", err)
+	}
+	if want := "{\"id\":9007199254740993,\"port\":8080}\n"; string(got) != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+
+	var compileErr *SchemaCompileError
+	if _, err := ApplyDefaultsBytes([]byte(`{}`), `{"type": 1}`); !errors.As(err, &compileErr) {
+		t.Errorf("expected a *SchemaCompileError, got %v", err)
+	}
+	var decodeErr *jsonutil.DecodeError
+	if _, err := ApplyDefaultsBytes([]byte(`{"id":`), schema); !errors.As(err, &decodeErr) {
+		t.Errorf("expected a *jsonutil.DecodeError, got %v", err)
+	}
+}
+
+func TestApplyDefaultsOrRootDefault(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		data   string
+		want   string
+	}{
+		{"root default", `{"type": "object", "default": {"name": "root"}, "properties": {"port": {"default": 80}}}`, "", `{"name":"root","port":80}`},
+		{"property defaults", `{"type": "object", "properties": {"port": {"default": 80}}}`, "", `{"port":80}`},
+		{"scalar default", `{"$ref": "#/$defs/mode", "$defs": {"mode": {"type": "string", "default": "fast"}}}`, "", `"fast"`},
+		{"nothing to fill in", `{"type": "object", "properties": {"port": {"type": "integer"}}}`, "", `null`},
+		{"present data", `{"type": "object", "default": {"name": "root"}, "properties": {"port": {"default": 80}}}`, `{}`, `{"port":80}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data interface{}
+			if tt.data != "" {
+				data = parseJSON(t, tt.data)
+			}
+			got, _ := json.Marshal(ApplyDefaultsOrRootDefault(data, compileSchema(t, tt.schema)))
+			if string(got) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	// ApplyDefaults keeps taking nil for an explicit null.
+	if got := ApplyDefaults(nil, compileSchema(t, `{"default": {"name": "root"}}`)); got != nil {
+		t.Errorf("expected ApplyDefaults to keep null, got %v", got)
+	}
+}
+
+func TestApplyDefaultsInto(t *testing.T) {
+	schema := compileSchema(t, `{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string"},
+			"port": {"type": "integer", "default": 8080},
+			"tls": {"type": "object", "properties": {"enabled": {"type": "boolean", "default": true}}}
+		}
+	}`)
+	type config struct {
+		Name string `json:"name"`
+		Port int    `json:"port"`
+		TLS  struct {
+			Enabled bool `json:"enabled"`
+		} `json:"tls"`
+	}
+
+	var cfg config
+	if err := ApplyDefaultsInto([]byte(`{"name": "api", "tls": {}}`), schema, &cfg); err != nil {
+		t.Fatalf("ApplyDefaultsInto failed: %v", err)
+	}
+	if cfg.Name != "api" || cfg.Port != 8080 || !cfg.TLS.Enabled {
+		t.Errorf("expected the document with its defaults, got %+v", cfg)
+	}
+
+	var decodeErr *jsonutil.DecodeError
+	if err := ApplyDefaultsInto([]byte(`{"name":`), schema, &cfg); !errors.As(err, &decodeErr) {
+		t.Errorf("expected a *jsonutil.DecodeError, got %v", err)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if err := ApplyDefaultsInto([]byte(`{"name": 1}`), schema, &cfg); !errors.As(err, &typeErr) {
+		t.Errorf("expected a *json.UnmarshalTypeError, got %v", err)
+	}
+}
+
+func TestApplyDefaults_ContainerDefault(t *testing.T) {
+	schema := compileSchema(t, `{
+		"type": "object",
+		"properties": {
+			"tags": {"type": "array", "default": ["web"]},
+			"tls": {"type": "object", "default": {"version": "1.2"}, "properties": {"version": {}, "enabled": {"default": true}}}
+		}
+	}`)
+
+	// By default missing containers start empty.
+	got, _ := json.Marshal(ApplyDefaults(parseJSON(t, `{}`), schema))
+	if want := `{"tls":{"enabled":true}}`; string(got) != want {
+		t.Errorf("expected %s without the option, got %s", want, got)
+	}
+
+	result := ApplyDefaults(parseJSON(t, `{}`), schema, FillContainerDefaults())
+	got, _ = json.Marshal(result)
+	if want := `{"tags":["web"],"tls":{"enabled":true,"version":"1.2"}}`; string(got) != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	got, _ = json.Marshal(ApplyDefaults(parseJSON(t, `{"tags": [], "tls": {}}`), schema, FillContainerDefaults()))
+	if want := `{"tags":[],"tls":{"enabled":true}}`; string(got) != want {
+		t.Errorf("expected present values to keep theirs, got %s", got)
+	}
+
+	// The result doesn't share the defaults of the schema.
+	result.(map[string]interface{})["tags"].([]interface{})[0] = "changed"
+	result.(map[string]interface{})["tls"].(map[string]interface{})["version"] = "changed"
+	explanation := ExplainDefaults(parseJSON(t, `{}`), schema, FillContainerDefaults())
+	for _, d := range explanation.Defaults {
+		if tags, ok := d.Value.([]interface{}); ok {
+			tags[0] = "changed"
+		}
+	}
+	if def, _ := json.Marshal(schema.Properties["tls"].Default); string(def) != `{"version":"1.2"}` {
+		t.Errorf("expected the schema default to be left unchanged, got %s", def)
+	}
+	if def, _ := json.Marshal(schema.Properties["tags"].Default); string(def) != `["web"]` {
+		t.Errorf("expected the schema default to be left unchanged, got %s", def)
+	}
+	rootSchema := compileSchema(t, `{"type": "object", "default": {"tags": ["web"]}}`)
+	ApplyDefaultsOrRootDefault(nil, rootSchema).(map[string]interface{})["tags"].([]interface{})[0] = "changed"
+	if def, _ := json.Marshal(rootSchema.Default); string(def) != `{"tags":["web"]}` {
+		t.Errorf("expected the root default to be left unchanged, got %s", def)
+	}
+}
+
 func TestApplyDefaults_TupleItems(t *testing.T) {
 	schemaStr := `{
 		"$schema": "http://json-schema.org/draft-07/schema#",
@@ -117,7 +258,7 @@ func TestApplyDefaults_TupleItems(t *testing.T) {
 	}`
 	schema := compileSchema(t, schemaStr)
 
-	// First element uses first item schema, second and later use last schema
+	// Each element uses the schema at its position; later ones have none
 	data := parseJSON(t, `{"tuple": [{}, {}, {}]}`)
 	result := ApplyDefaults(data, schema)
 	m := result.(map[string]interface{})
@@ -132,11 +273,143 @@ func TestApplyDefaults_TupleItems(t *testing.T) {
 		t.Errorf("Second tuple element should get default b=B, got %#v", second)
 	}
 	third := tuple[2].(map[string]interface{})
+	if len(third) != 0 {
+		t.Errorf("Third tuple element has no schema without additionalItems, got %#v", third)
+	}
+
+	// The legacy behavior reuses the last schema
+	result = ApplyDefaults(parseJSON(t, `{"tuple": [{}, {}, {}]}`), schema, LegacyAdditionalItems())
+	third = result.(map[string]interface{})["tuple"].([]interface{})[2].(map[string]interface{})
 	if third["b"] != "B" {
 		t.Errorf("Third tuple element should reuse last item schema and get b=B, got %#v", third)
 	}
 }
 
+func TestApplyDefaults_AdditionalItems(t *testing.T) {
+	tests := []struct {
+		name       string
+		additional string
+		opts       []DefaultsOption
+		want       string
+	}{
+		{"schema", `{"properties": {"c": {"default": "C"}}}`, nil, `[{"a":"A"},{"c":"C"},{"c":"C"}]`},
+		{"schema with the legacy option", `{"properties": {"c": {"default": "C"}}}`, []DefaultsOption{LegacyAdditionalItems()}, `[{"a":"A"},{"c":"C"},{"c":"C"}]`},
+		{"true", `true`, nil, `[{"a":"A"},{},{}]`},
+		{"false", `false`, nil, `[{"a":"A"},{},{}]`},
+		{"false with the legacy option", `false`, []DefaultsOption{LegacyAdditionalItems()}, `[{"a":"A"},{"a":"A"},{"a":"A"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := compileSchema(t, `{
+				"$schema": "http://json-schema.org/draft-07/schema#",
+				"type": "array",
+				"items": [{"properties": {"a": {"default": "A"}}}],
+				"additionalItems": `+tt.additional+`
+			}`)
+			got, _ := json.Marshal(ApplyDefaults(parseJSON(t, `[{}, {}, {}]`), schema, tt.opts...))
+			if string(got) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestApplyDefaults_PrefixItems(t *testing.T) {
+	schema := compileSchema(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"point": {
+				"type": "array",
+				"prefixItems": [
+					{"type": "object", "properties": {"axis": {"default": "x"}}},
+					{"type": "object", "properties": {"axis": {"default": "y"}}}
+				],
+				"items": {"type": "object", "properties": {"extra": {"default": true}}}
+			},
+			"pair": {
+				"prefixItems": [{"properties": {"first": {"default": 1}}}]
+			}
+		}
+	}`)
+
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"positional and items", `{"point": [{}, {}, {}, {}]}`, `{"point":[{"axis":"x"},{"axis":"y"},{"extra":true},{"extra":true}]}`},
+		{"shorter than the prefix", `{"point": [{"axis": "z"}]}`, `{"point":[{"axis":"z"}]}`},
+		{"no items beyond the prefix", `{"pair": [{}, {}]}`, `{"pair":[{"first":1},{}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := json.Marshal(ApplyDefaults(parseJSON(t, tt.data), schema))
+			if string(got) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestApplyDefaults_Unevaluated(t *testing.T) {
+	schema := compileSchema(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"allOf": [{"properties": {"name": {"type": "object", "properties": {"lang": {"default": "en"}}}}}],
+		"properties": {"id": {"type": "object"}},
+		"patternProperties": {"^x-": {"type": "object"}},
+		"if": {"properties": {"kind": {"const": "card"}}, "required": ["kind"]},
+		"then": {"properties": {"card": {"type": "object"}}},
+		"unevaluatedProperties": {"type": "object", "properties": {"enabled": {"default": true}}}
+	}`)
+
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			"object",
+			`{"id": {}, "name": {}, "x-tag": {}, "plugin": {}, "other": null}`,
+			`{"id":{},"name":{"lang":"en"},"other":null,"plugin":{"enabled":true},"x-tag":{}}`,
+		},
+		{"then evaluates", `{"kind": "card", "card": {}, "name": {}}`, `{"card":{},"kind":"card","name":{"lang":"en"}}`},
+		{"else doesn't", `{"kind": "iban", "card": {}, "name": {}}`, `{"card":{"enabled":true},"kind":"iban","name":{"lang":"en"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := json.Marshal(ApplyDefaults(parseJSON(t, tt.data), schema))
+			if string(got) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	items := compileSchema(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "array",
+		"prefixItems": [{"type": "object"}],
+		"contains": {"type": "object", "required": ["fixed"]},
+		"unevaluatedItems": {"type": "object", "properties": {"enabled": {"default": false}}}
+	}`)
+	got, _ := json.Marshal(ApplyDefaults(parseJSON(t, `[{}, {"fixed": 1}, {}]`), items))
+	if want := `[{},{"fixed":1},{"enabled":false}]`; string(got) != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+
+	// Properties evaluated by additionalProperties are never unevaluated.
+	closed := compileSchema(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"additionalProperties": {"type": "object"},
+		"unevaluatedProperties": {"properties": {"enabled": {"default": true}}}
+	}`)
+	got, _ = json.Marshal(ApplyDefaults(parseJSON(t, `{"a": {}}`), closed))
+	if want := `{"a":{}}`; string(got) != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
 func TestApplyDefaults_RequiredProperties(t *testing.T) {
 	schemaStr := `{
 		"$schema": "http://json-schema.org/draft-07/schema#",
@@ -194,6 +467,106 @@ func TestApplyDefaults_Ref(t *testing.T) {
 	}
 }
 
+func TestApplyDefaults_RecursiveRef(t *testing.T) {
+	schema := compileSchema(t, `{
+		"$defs": {
+			"node": {
+				"type": "object",
+				"properties": {
+					"name": {"default": "leaf"},
+					"child": {"$ref": "#/$defs/node"},
+					"children": {"type": "array", "items": {"$ref": "#/$defs/node"}}
+				}
+			}
+		},
+		"$ref": "#/$defs/node"
+	}`)
+
+	got, err := ApplyDefaultsCtx(context.Background(), parseJSON(t, `{"child": {"child": {}}, "children": [{}]}`), schema)
+	if err != nil {
+		t.Fatalf("ApplyDefaultsCtx failed: %v", err)
+	}
+	want := parseJSON(t, `{
+		"name": "leaf",
+		"child": {"name": "leaf", "child": {"name": "leaf"}},
+		"children": [{"name": "leaf"}]
+	}`)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// Schemas built by hand may have $refs the compiler would reject.
+	a, b := &jsonschemaLib.Schema{Location: "a"}, &jsonschemaLib.Schema{Location: "b"}
+	a.Ref, b.Ref = b, a
+	data := parseJSON(t, `{}`)
+	if _, err := ApplyDefaultsCtx(context.Background(), data, a); !errors.Is(err, ErrRefCycle) {
+		t.Errorf("expected ErrRefCycle, got %v", err)
+	}
+	if got := ApplyDefaults(data, a); !reflect.DeepEqual(got, data) {
+		t.Errorf("expected ApplyDefaults to leave the data unchanged, got %v", got)
+	}
+}
+
+func TestApplyDefaults_DynamicRef(t *testing.T) {
+	compiler := jsonschemaLib.NewCompiler()
+	compiler.ExtractAnnotations = true
+	resources := map[string]string{
+		"tree.json": `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"$dynamicAnchor": "node",
+			"type": "object",
+			"properties": {
+				"name": {"default": "node"},
+				"children": {"type": "array", "items": {"$dynamicRef": "#node"}}
+			}
+		}`,
+		"labeled.json": `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"$dynamicAnchor": "node",
+			"$ref": "tree.json",
+			"properties": {"label": {"default": "none"}}
+		}`,
+		"recursive.json": `{
+			"$schema": "https://json-schema.org/draft/2019-09/schema",
+			"$recursiveAnchor": true,
+			"type": "object",
+			"properties": {
+				"name": {"default": "node"},
+				"children": {"type": "array", "items": {"$recursiveRef": "#"}}
+			}
+		}`,
+	}
+	for name, schema := range resources {
+		if err := compiler.AddResource(name, strings.NewReader(schema)); err != nil {
+			t.Fatalf("Failed to add schema resource: %v", err)
+		}
+	}
+
+	tests := []struct {
+		schema string
+		want   string
+	}{
+		{"tree.json", `{"name": "node", "children": [{"name": "node", "children": [{"name": "node"}]}]}`},
+		{"recursive.json", `{"name": "node", "children": [{"name": "node", "children": [{"name": "node"}]}]}`},
+		{"labeled.json", `{"name": "node", "label": "none", "children": [{"name": "node", "label": "none", "children": [{"name": "node", "label": "none"}]}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.schema, func(t *testing.T) {
+			schema, err := compiler.Compile(tt.schema)
+			if err != nil {
+				t.Fatalf("Failed to compile schema: %v", err)
+			}
+			got, err := ApplyDefaultsCtx(context.Background(), parseJSON(t, `{"children": [{"children": [{}]}]}`), schema)
+			if err != nil {
+				t.Fatalf("ApplyDefaultsCtx failed: %v", err)
+			}
+			if want := parseJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("expected %v, got %v", want, got)
+			}
+		})
+	}
+}
+
 func TestApplyDefaults_AllOf(t *testing.T) {
 	schemaStr := `{
 		"$schema": "http://json-schema.org/draft-07/schema#",
@@ -287,3 +660,152 @@ func TestApplyDefaults_Combined(t *testing.T) {
 		t.Error("Combined schemas should merge defaults correctly")
 	}
 }
+
+func TestApplyDefaults_IfThenElse(t *testing.T) {
+	schema := compileSchema(t, `{
+		"type": "object",
+		"properties": {
+			"kind": {"type": "string", "default": "card"},
+			"shipping": {
+				"type": "object",
+				"properties": {"method": {"type": "string"}},
+				"if": {"properties": {"method": {"const": "express"}}, "required": ["method"]},
+				"then": {"properties": {"days": {"default": 1}}},
+				"else": {"properties": {"days": {"default": 5}}}
+			}
+		},
+		"if": {"properties": {"kind": {"const": "card"}}},
+		"then": {
+			"properties": {"network": {"default": "visa"}},
+			"if": {"properties": {"network": {"const": "visa"}}},
+			"then": {"properties": {"cvcRequired": {"default": true}}}
+		},
+		"else": {"properties": {"country": {"default": "DE"}}}
+	}`)
+
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"then", `{"kind": "card", "shipping": {}}`, `{"kind": "card", "network": "visa", "cvcRequired": true, "shipping": {"days": 5}}`},
+		{"else", `{"kind": "iban", "shipping": {}}`, `{"kind": "iban", "country": "DE", "shipping": {"days": 5}}`},
+		{"defaulted discriminator", `{"shipping": {}}`, `{"kind": "card", "network": "visa", "cvcRequired": true, "shipping": {"days": 5}}`},
+		{"nested if without else", `{"network": "amex", "shipping": {}}`, `{"kind": "card", "network": "amex", "shipping": {"days": 5}}`},
+		{"missing property", `{"kind": "iban"}`, `{"kind": "iban", "country": "DE", "shipping": {"days": 5}}`},
+		{"property then", `{"kind": "iban", "shipping": {"method": "express"}}`, `{"kind": "iban", "country": "DE", "shipping": {"method": "express", "days": 1}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := json.Marshal(ApplyDefaults(parseJSON(t, tt.data), schema))
+			if want, _ := json.Marshal(parseJSON(t, tt.want)); string(got) != string(want) {
+				t.Errorf("expected %s, got %s", want, got)
+			}
+		})
+	}
+
+	// A missing property with a conditional schema gets the defaults of its branch.
+	missing := compileSchema(t, `{
+		"type": "object",
+		"properties": {"limits": {"if": false, "else": {"type": "object", "properties": {"max": {"default": 10}}}}}
+	}`)
+	if got, _ := json.Marshal(ApplyDefaults(parseJSON(t, `{}`), missing)); string(got) != `{"limits":{"max":10}}` {
+		t.Errorf("expected the else defaults, got %s", got)
+	}
+
+	explanation := ExplainDefaults(parseJSON(t, `{"kind": "iban", "shipping": {}}`), schema)
+	var keywords []string
+	for _, b := range explanation.Branches {
+		keywords = append(keywords, b.Pointer+" "+b.Keyword)
+	}
+	if want := []string{" else", "/shipping else"}; !reflect.DeepEqual(keywords, want) {
+		t.Errorf("expected the else branches to be explained, got %v", keywords)
+	}
+}
+
+func TestApplyDefaults_Dependencies(t *testing.T) {
+	dependentSchemas := compileSchema(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {"plan": {"type": "string"}, "trial": {"type": "boolean", "default": true}},
+		"dependentSchemas": {
+			"card": {"properties": {"network": {"default": "visa"}}},
+			"trial": {"properties": {"trialDays": {"default": 14}}},
+			"network": {"properties": {"cvcRequired": {"default": true}}}
+		}
+	}`)
+	dependencies := compileSchema(t, `{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"type": "object",
+		"dependencies": {
+			"card": {"properties": {"network": {"default": "visa"}}},
+			"iban": ["bic"]
+		}
+	}`)
+
+	tests := []struct {
+		name   string
+		schema *jsonschemaLib.Schema
+		data   string
+		want   string
+	}{
+		{"present", dependentSchemas, `{"card": "4242", "trial": false}`, `{"card":"4242","cvcRequired":true,"network":"visa","trial":false,"trialDays":14}`},
+		{"absent", dependentSchemas, `{"plan": "pro", "trial": false}`, `{"plan":"pro","trial":false,"trialDays":14}`},
+		{"defaulted trigger", dependentSchemas, `{}`, `{"trial":true,"trialDays":14}`},
+		{"draft-07 dependencies", dependencies, `{"card": "4242"}`, `{"card":"4242","network":"visa"}`},
+		{"property dependencies", dependencies, `{"iban": "DE00"}`, `{"iban":"DE00"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := json.Marshal(ApplyDefaults(parseJSON(t, tt.data), tt.schema))
+			if string(got) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	explanation := ExplainDefaults(parseJSON(t, `{"card": "4242"}`), dependencies)
+	if len(explanation.Branches) != 1 || explanation.Branches[0].Keyword != "dependencies" || !strings.HasSuffix(explanation.Branches[0].Locations[0], "#/dependencies/card") {
+		t.Errorf("expected the dependent schema to be explained, got %+v", explanation.Branches)
+	}
+}
+
+// expiringContext is a context that is done from the nth call to Err on.
+type expiringContext struct {
+	context.Context
+	n int
+}
+
+func (c *expiringContext) Err() error {
+	if c.n--; c.n <= 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestApplyDefaultsCtx(t *testing.T) {
+	schema := compileSchema(t, `{
+		"type": "array",
+		"items": {"type": "object", "properties": {"qty": {"type": "integer", "default": 1}}}
+	}`)
+	data := parseJSON(t, `[{}, {}, {}, {}]`)
+
+	result, err := ApplyDefaultsCtx(context.Background(), data, schema)
+	if err != nil {
+		t.Fatalf("ApplyDefaultsCtx failed: %v", err)
+	}
+	if want := ApplyDefaults(data, schema); !reflect.DeepEqual(result, want) {
+		t.Errorf("expected %v, got %v", want, result)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ApplyDefaultsCtx(canceled, data, schema); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	// Canceled while walking the array.
+	ctx := &expiringContext{Context: context.Background(), n: 3}
+	if result, err := ApplyDefaultsCtx(ctx, data, schema); !errors.Is(err, context.Canceled) || result != nil {
+		t.Errorf("expected context.Canceled partway through, got %v, %v", result, err)
+	}
+}
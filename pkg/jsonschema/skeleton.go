@@ -0,0 +1,104 @@
+package jsonschema
+
+import (
+	"encoding/json"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"go-demo/pkg/jsonutil"
+)
+
+// GenerateSkeleton returns a complete document for schema alone, for starter
+// configuration files and API examples. Unlike Sample it is deterministic
+// and doesn't try to be valid: every property is present, with its default,
+// its const, its first example, its first enum entry or the zero value of
+// its first type, and arrays have their minItems items. The first branch of
+// a oneOf or anyOf stands for the others. Numbers are json.Number. Values
+// taken from the schema are copies, so the document can be changed freely. A
+// property whose schema is already being generated further up, as in a
+// recursive schema, is left out.
+func GenerateSkeleton(schema *jsonschema.Schema) interface{} {
+	return skeleton(schema, map[*jsonschema.Schema]bool{})
+}
+
+// skeleton generates the value of schema; active holds the schemas being
+// generated.
+func skeleton(schema *jsonschema.Schema, active map[*jsonschema.Schema]bool) interface{} {
+	schema = resolveRef(schema)
+	if schema == nil {
+		return nil
+	}
+	if schema.Default != nil {
+		return copyValue(schema.Default)
+	}
+	if len(schema.Constant) > 0 {
+		return copyValue(schema.Constant[0])
+	}
+	if len(schema.Examples) > 0 {
+		return copyValue(schema.Examples[0])
+	}
+	if len(schema.Enum) > 0 {
+		return copyValue(schema.Enum[0])
+	}
+	active[schema] = true
+	defer delete(active, schema)
+
+	var v interface{}
+	if types := sampleTypes(schema); len(types) > 0 {
+		v = skeletonTyped(schema, types[0], active)
+	}
+	// Combinations contribute their own values, merged into the object the
+	// schema itself describes, as in Sample.
+	var branches []*jsonschema.Schema
+	branches = append(branches, schema.AllOf...)
+	if len(schema.OneOf) > 0 {
+		branches = append(branches, schema.OneOf[0])
+	}
+	if len(schema.AnyOf) > 0 {
+		branches = append(branches, schema.AnyOf[0])
+	}
+	for _, branch := range branches {
+		bv := skeleton(branch, active)
+		if obj, ok := v.(map[string]interface{}); ok {
+			if bobj, ok := bv.(map[string]interface{}); ok {
+				v = jsonutil.Merge(obj, bobj, jsonutil.ArrayReplace)
+			}
+			continue
+		}
+		if bv != nil || v == nil {
+			v = bv
+		}
+	}
+	return v
+}
+
+func skeletonTyped(schema *jsonschema.Schema, typ string, active map[*jsonschema.Schema]bool) interface{} {
+	switch typ {
+	case "object":
+		obj := map[string]interface{}{}
+		for name, prop := range schema.Properties {
+			if resolved := resolveRef(prop); resolved != nil && active[resolved] {
+				continue
+			}
+			obj[name] = skeleton(prop, active)
+		}
+		return obj
+	case "array":
+		arr := []interface{}{}
+		for i := 0; i < schema.MinItems; i++ {
+			item := getItemsSchemaForIndex(schema, i)
+			if resolved := resolveRef(item); resolved != nil && active[resolved] {
+				break
+			}
+			arr = append(arr, skeleton(item, active))
+		}
+		return arr
+	case "string":
+		return ""
+	case "integer", "number":
+		return json.Number("0")
+	case "boolean":
+		return false
+	}
+	return nil
+}
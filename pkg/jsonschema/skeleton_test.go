@@ -0,0 +1,83 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGenerateSkeleton(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   string
+	}{
+		{"object", `{
+			"type": "object",
+			"properties": {
+				"name": {"type": "string"},
+				"port": {"type": "integer", "default": 8080},
+				"ratio": {"type": ["null", "number"]},
+				"debug": {"type": "boolean"},
+				"mode": {"enum": ["fast", "safe"]},
+				"version": {"const": 2},
+				"owner": {"type": "object", "properties": {"email": {"type": "string", "format": "email"}}},
+				"anything": {}
+			}
+		}`, `{"anything":null,"debug":false,"mode":"fast","name":"","owner":{"email":""},"port":8080,"ratio":0,"version":2}`},
+		{"arrays", `{
+			"properties": {
+				"tags": {"type": "array", "items": {"type": "string"}},
+				"hosts": {"type": "array", "items": {"properties": {"port": {"default": 80}}}, "minItems": 2},
+				"point": {"prefixItems": [{"type": "number"}, {"type": "string"}], "minItems": 2}
+			}
+		}`, `{"hosts":[{"port":80},{"port":80}],"point":[0,""],"tags":[]}`},
+		{"combinations", `{
+			"allOf": [{"properties": {"id": {"type": "integer"}}}],
+			"oneOf": [{"properties": {"card": {"type": "string"}}}, {"properties": {"iban": {"type": "string"}}}],
+			"properties": {"ref": {"$ref": "#/$defs/ref"}},
+			"$defs": {"ref": {"type": "string", "default": "main"}}
+		}`, `{"card":"","id":0,"ref":"main"}`},
+		{"examples", `{
+			"properties": {
+				"host": {"type": "string", "examples": ["db.example.com", "localhost"]},
+				"mode": {"enum": ["fast", "safe"], "examples": ["safe"]},
+				"port": {"type": "integer", "default": 5432, "examples": [6432]}
+			}
+		}`, `{"host":"db.example.com","mode":"safe","port":5432}`},
+		{"recursive", `{
+			"$defs": {"node": {"properties": {"name": {"type": "string"}, "children": {"type": "array", "items": {"$ref": "#/$defs/node"}, "minItems": 1}, "parent": {"$ref": "#/$defs/node"}}}},
+			"$ref": "#/$defs/node"
+		}`, `{"children":[],"name":""}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := json.Marshal(GenerateSkeleton(compileSchema(t, tt.schema)))
+			if string(got) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	if got := GenerateSkeleton(nil); got != nil {
+		t.Errorf("expected nil for no schema, got %v", got)
+	}
+}
+
+func TestGenerateSkeletonCopies(t *testing.T) {
+	schema := compileSchema(t, `{
+		"properties": {
+			"tls": {"default": {"version": "1.2"}},
+			"tags": {"const": ["web"]},
+			"hosts": {"examples": [["a"]]},
+			"modes": {"enum": [["fast"]]}
+		}
+	}`)
+	doc := GenerateSkeleton(schema).(map[string]interface{})
+	doc["tls"].(map[string]interface{})["version"] = "changed"
+	for _, name := range []string{"tags", "hosts", "modes"} {
+		doc[name].([]interface{})[0] = "changed"
+	}
+	if got, _ := json.Marshal(GenerateSkeleton(schema)); string(got) != `{"hosts":["a"],"modes":["fast"],"tags":["web"],"tls":{"version":"1.2"}}` {
+		t.Errorf("expected the schema to be left unchanged, got %s", got)
+	}
+}
@@ -0,0 +1,60 @@
+package jsonschema
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"go-demo/pkg/jsonutil"
+)
+
+// ApplyDefaultsStream applies the defaults of schema to each record of the
+// newline-delimited JSON stream r and writes the results to w, one compact
+// line per record, reading and writing one record at a time so the stream
+// never has to fit in memory. Blank lines are skipped. It stops at the first
+// record that fails, with an error naming its line, after writing the ones
+// before it.
+func ApplyDefaultsStream(r io.Reader, w io.Writer, schema *jsonschema.Schema, opts ...DefaultsOption) error {
+	br := bufio.NewReaderSize(r, 64<<10)
+	bw := bufio.NewWriterSize(w, 64<<10)
+	for n := 1; ; n++ {
+		line, readErr := br.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			bw.Flush()
+			return readErr
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			if err := applyDefaultsLine(bw, line, schema, opts); err != nil {
+				bw.Flush()
+				return fmt.Errorf("line %d: %w", n, err)
+			}
+		}
+		if readErr != nil {
+			return bw.Flush()
+		}
+	}
+}
+
+// applyDefaultsLine applies the defaults of schema to the JSON record line
+// and writes the result to w.
+func applyDefaultsLine(w io.Writer, line []byte, schema *jsonschema.Schema, opts []DefaultsOption) error {
+	doc, err := jsonutil.DecodeBytes(line)
+	if err != nil {
+		return err
+	}
+	doc, err = ApplyDefaultsCtx(context.Background(), doc, schema, opts...)
+	if err != nil {
+		return err
+	}
+	out, err := jsonutil.Marshal(jsonutil.FormatJSON, doc, 0)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
@@ -0,0 +1,34 @@
+package jsonschema
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"go-demo/pkg/jsonutil"
+)
+
+func TestApplyDefaultsStream(t *testing.T) {
+	schema := compileSchema(t, `{"type": "object", "properties": {"port": {"default": 80}}}`)
+
+	var out bytes.Buffer
+	in := "{\"id\": 1}\n\n  {\"id\": 2, \"port\": 443}\r\n{\"id\": 9007199254740993}"
+	if err := ApplyDefaultsStream(strings.NewReader(in), &out, schema); err != nil {
+		t.Fatalf("ApplyDefaultsStream failed: %v", err)
+	}
+	want := "{\"id\":1,\"port\":80}\n{\"id\":2,\"port\":443}\n{\"id\":9007199254740993,\"port\":80}\n"
+	if out.String() != want {
+		t.Errorf("expected %q, got %q", want, out.String())
+	}
+
+	out.Reset()
+	err := ApplyDefaultsStream(strings.NewReader("{}\n{\"id\":\n{}\n"), &out, schema)
+	var decodeErr *jsonutil.DecodeError
+	if !errors.As(err, &decodeErr) || !strings.HasPrefix(err.Error(), "line 2: ") {
+		t.Errorf("expected a decode error on line 2, got %v", err)
+	}
+	if want := "{\"port\":80}\n"; out.String() != want {
+		t.Errorf("expected the records before the error, got %q", out.String())
+	}
+}
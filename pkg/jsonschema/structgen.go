@@ -0,0 +1,258 @@
+package jsonschema
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FromStruct generates a schema for the Go struct type typeName declared in
+// the package source directory dir, following encoding/json conventions:
+// unexported fields and fields tagged `json:"-"` are skipped, tag names rename
+// properties, and fields without omitempty are required. Embedded structs
+// contribute their fields; other struct types of the package become
+// definitions referenced with $ref. Doc comments become descriptions.
+//
+// The source is parsed, not type-checked, so types from other packages are
+// only understood for time.Time, json.Number and json.RawMessage; others
+// accept any value.
+func FromStruct(dir, typeName string, draft Draft) (map[string]interface{}, error) {
+	types, err := parseTypes(dir)
+	if err != nil {
+		return nil, err
+	}
+	spec, ok := types[typeName]
+	if !ok {
+		return nil, fmt.Errorf("type %s not found in %s", typeName, dir)
+	}
+	if _, ok := spec.Type.(*ast.StructType); !ok {
+		return nil, fmt.Errorf("type %s is not a struct", typeName)
+	}
+
+	g := &structGenerator{types: types, draft: draft, root: typeName, defs: map[string]interface{}{}, inProgress: map[string]bool{}}
+	schema := g.typeSchema(spec.Type)
+	schema["$schema"] = draft.URI()
+	if doc := docText(spec.Doc); doc != "" {
+		schema["description"] = doc
+	}
+	if len(g.defs) > 0 {
+		schema[draft.defsKey()] = g.defs
+	}
+	return schema, nil
+}
+
+// parseTypes returns the type declarations of the non-test Go files in dir.
+func parseTypes(dir string) (map[string]*ast.TypeSpec, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	types := map[string]*ast.TypeSpec{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, s := range gen.Specs {
+				spec := s.(*ast.TypeSpec)
+				if spec.Doc == nil && len(gen.Specs) == 1 {
+					spec.Doc = gen.Doc
+				}
+				types[spec.Name.Name] = spec
+			}
+		}
+	}
+	return types, nil
+}
+
+type structGenerator struct {
+	types      map[string]*ast.TypeSpec
+	draft      Draft
+	root       string
+	defs       map[string]interface{}
+	inProgress map[string]bool
+}
+
+func (g *structGenerator) typeSchema(expr ast.Expr) map[string]interface{} {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return g.identSchema(t.Name)
+	case *ast.StarExpr:
+		return g.typeSchema(t.X)
+	case *ast.ParenExpr:
+		return g.typeSchema(t.X)
+	case *ast.ArrayType:
+		if ident, ok := t.Elt.(*ast.Ident); ok && (ident.Name == "byte" || ident.Name == "uint8") && t.Len == nil {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.typeSchema(t.Elt)}
+	case *ast.MapType:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.typeSchema(t.Value)}
+	case *ast.StructType:
+		return g.structSchema(t)
+	case *ast.SelectorExpr:
+		if pkg, ok := t.X.(*ast.Ident); ok {
+			switch pkg.Name + "." + t.Sel.Name {
+			case "time.Time":
+				return map[string]interface{}{"type": "string", "format": "date-time"}
+			case "json.Number":
+				return map[string]interface{}{"type": "number"}
+			}
+		}
+	}
+	return map[string]interface{}{}
+}
+
+// identSchema returns the schema of a predeclared or package-level type.
+func (g *structGenerator) identSchema(name string) map[string]interface{} {
+	switch name {
+	case "string":
+		return map[string]interface{}{"type": "string"}
+	case "bool":
+		return map[string]interface{}{"type": "boolean"}
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte", "rune":
+		return map[string]interface{}{"type": "integer"}
+	case "float32", "float64":
+		return map[string]interface{}{"type": "number"}
+	}
+
+	spec, ok := g.types[name]
+	if !ok {
+		return map[string]interface{}{}
+	}
+	if _, isStruct := spec.Type.(*ast.StructType); !isStruct {
+		return g.typeSchema(spec.Type)
+	}
+
+	if name == g.root {
+		return map[string]interface{}{"$ref": "#"}
+	}
+	ref := map[string]interface{}{"$ref": "#/" + g.draft.defsKey() + "/" + name}
+	if _, done := g.defs[name]; done || g.inProgress[name] {
+		return ref
+	}
+	g.inProgress[name] = true
+	def := g.typeSchema(spec.Type)
+	if doc := docText(spec.Doc); doc != "" {
+		def["description"] = doc
+	}
+	g.defs[name] = def
+	delete(g.inProgress, name)
+	return ref
+}
+
+// structSchema returns the object schema of a struct's JSON fields.
+func (g *structGenerator) structSchema(st *ast.StructType) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+	g.addFields(st, properties, &required)
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (g *structGenerator) addFields(st *ast.StructType, properties map[string]interface{}, required *[]string) {
+	for _, f := range st.Fields.List {
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			if s, err := strconv.Unquote(f.Tag.Value); err == nil {
+				tag = reflect.StructTag(s)
+			}
+		}
+		jsonName, opts, _ := strings.Cut(tag.Get("json"), ",")
+		if jsonName == "-" && opts == "" {
+			continue
+		}
+
+		if len(f.Names) == 0 {
+			// Embedded fields without a tag name promote their fields.
+			if embedded := g.embeddedStruct(f.Type); embedded != nil && jsonName == "" {
+				g.addFields(embedded, properties, required)
+				continue
+			}
+		}
+
+		names := f.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{embeddedName(f.Type)}
+		}
+		for _, ident := range names {
+			if ident == nil || !ident.IsExported() {
+				continue
+			}
+			name := ident.Name
+			if jsonName != "" {
+				name = jsonName
+			}
+			prop := g.typeSchema(f.Type)
+			if doc := docText(f.Doc); doc != "" {
+				prop["description"] = doc
+			} else if doc := docText(f.Comment); doc != "" {
+				prop["description"] = doc
+			}
+			properties[name] = prop
+			if !strings.Contains(","+opts+",", ",omitempty,") {
+				*required = append(*required, name)
+			}
+		}
+	}
+}
+
+// embeddedStruct returns the struct type of an embedded field declared in the
+// package, or nil.
+func (g *structGenerator) embeddedStruct(expr ast.Expr) *ast.StructType {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	spec, ok := g.types[ident.Name]
+	if !ok {
+		return nil
+	}
+	st, _ := spec.Type.(*ast.StructType)
+	return st
+}
+
+// embeddedName returns the field name of an embedded field.
+func embeddedName(expr ast.Expr) *ast.Ident {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.Ident:
+		return t
+	case *ast.SelectorExpr:
+		return t.Sel
+	}
+	return nil
+}
+
+func docText(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	return strings.TrimSpace(doc.Text())
+}
@@ -0,0 +1,94 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const typesSource = `package types
+
+import "time"
+
+// User is an account holder.
+type User struct {
+	Base
+	// Name is the display name.
+	Name     string            ` + "`json:\"name\"`" + `
+	Email    string            ` + "`json:\"email,omitempty\"`" + `
+	Age      *int              ` + "`json:\"age,omitempty\"`" + `
+	Roles    []Role            ` + "`json:\"roles\"`" + `
+	Address  Address           ` + "`json:\"address\"`" + `
+	Friends  []*User           ` + "`json:\"friends,omitempty\"`" + `
+	Labels   map[string]string ` + "`json:\"labels,omitempty\"`" + `
+	Avatar   []byte            ` + "`json:\"avatar,omitempty\"`" + `
+	Password string            ` + "`json:\"-\"`" + `
+	internal int
+}
+
+// Base holds fields shared by all records.
+type Base struct {
+	ID      int64     ` + "`json:\"id\"`" + `
+	Created time.Time ` + "`json:\"created\"`" + `
+}
+
+// Address is a postal address.
+type Address struct {
+	City string ` + "`json:\"city\"`" + ` // City name.
+	Zip  string
+}
+
+// Role names a permission set.
+type Role string
+`
+
+func TestFromStruct(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "types.go"), []byte(typesSource), 0o644); err != nil {
+		t.Fatalf("Failed to write source: %v", err)
+	}
+
+	schema, err := FromStruct(dir, "User", Draft7)
+	if err != nil {
+		t.Fatalf("FromStruct failed: %v", err)
+	}
+	got, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want := `{"$schema":"http://json-schema.org/draft-07/schema#",` +
+		`"definitions":{"Address":{"description":"Address is a postal address.","properties":{"Zip":{"type":"string"},"city":{"description":"City name.","type":"string"}},"required":["Zip","city"],"type":"object"}},` +
+		`"description":"User is an account holder.","properties":{` +
+		`"address":{"$ref":"#/definitions/Address"},` +
+		`"age":{"type":"integer"},` +
+		`"avatar":{"contentEncoding":"base64","type":"string"},` +
+		`"created":{"format":"date-time","type":"string"},` +
+		`"email":{"type":"string"},` +
+		`"friends":{"items":{"$ref":"#"},"type":"array"},` +
+		`"id":{"type":"integer"},` +
+		`"labels":{"additionalProperties":{"type":"string"},"type":"object"},` +
+		`"name":{"description":"Name is the display name.","type":"string"},` +
+		`"roles":{"items":{"type":"string"},"type":"array"}},` +
+		`"required":["address","created","id","name","roles"],"type":"object"}`
+	if string(got) != want {
+		t.Errorf("unexpected schema:\n got: %s\nwant: %s", got, want)
+	}
+	if _, err := CompileString(string(got)); err != nil {
+		t.Errorf("Generated schema doesn't compile: %v", err)
+	}
+
+	schema, err = FromStruct(dir, "User", Draft2020)
+	if err != nil {
+		t.Fatalf("FromStruct failed: %v", err)
+	}
+	if _, ok := schema["$defs"]; !ok {
+		t.Errorf("2020-12 schemas should use $defs, got %v", schema)
+	}
+
+	for _, name := range []string{"Missing", "Role"} {
+		if _, err := FromStruct(dir, name, Draft7); err == nil {
+			t.Errorf("FromStruct(%q) should fail", name)
+		}
+	}
+}
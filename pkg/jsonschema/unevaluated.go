@@ -0,0 +1,153 @@
+package jsonschema
+
+import (
+	"strconv"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"go-demo/pkg/jsonutil"
+)
+
+// applyUnevaluated applies schema's unevaluatedProperties to the members of
+// data that no other keyword evaluates, and its unevaluatedItems to such
+// elements. As for the validator, a member or element is evaluated by the
+// keywords next to them and by the in-place applicators that apply to data:
+// $ref, allOf, the anyOf and oneOf branches data matches, the if with its then
+// or else, and the dependentSchemas of present properties.
+func (d *defaulter) applyUnevaluated(data interface{}, schema *jsonschema.Schema, pointer string) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		if schema.UnevaluatedProperties == nil {
+			return data
+		}
+		seen := map[string]bool{}
+		if d.evaluatedProperties(schema, v, seen, false) {
+			return data
+		}
+		result := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			if !seen[k] && value != nil {
+				value = d.apply(value, schema.UnevaluatedProperties, pointer+jsonutil.JoinPointer(k))
+			}
+			result[k] = value
+		}
+		return result
+	case []interface{}:
+		if schema.UnevaluatedItems == nil {
+			return data
+		}
+		seen := make([]bool, len(v))
+		if d.evaluatedItems(schema, v, seen, false) {
+			return data
+		}
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			if !seen[i] {
+				item = d.apply(item, schema.UnevaluatedItems, pointer+"/"+strconv.Itoa(i))
+			}
+			result[i] = item
+		}
+		return result
+	}
+	return data
+}
+
+// evaluatedProperties marks the members of obj that schema evaluates in seen
+// and reports whether it evaluates all of them. The unevaluatedProperties of
+// schema itself only count if nested is true, i.e. for subschemas.
+func (d *defaulter) evaluatedProperties(schema *jsonschema.Schema, obj map[string]interface{}, seen map[string]bool, nested bool) bool {
+	if schema == nil {
+		return false
+	}
+	if schema.AdditionalProperties != nil || (nested && schema.UnevaluatedProperties != nil) {
+		return true
+	}
+	for k := range obj {
+		if _, ok := schema.Properties[k]; ok {
+			seen[k] = true
+			continue
+		}
+		for re := range schema.PatternProperties {
+			if re.MatchString(k) {
+				seen[k] = true
+				break
+			}
+		}
+	}
+	for name, s := range schema.DependentSchemas {
+		if _, present := obj[name]; present && d.evaluatedProperties(s, obj, seen, true) {
+			return true
+		}
+	}
+	for _, s := range d.inPlace(schema, obj) {
+		if d.evaluatedProperties(s, obj, seen, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluatedItems marks the elements of arr that schema evaluates in seen and
+// reports whether it evaluates all of them. The unevaluatedItems of schema
+// itself only count if nested is true, i.e. for subschemas.
+func (d *defaulter) evaluatedItems(schema *jsonschema.Schema, arr []interface{}, seen []bool, nested bool) bool {
+	if schema == nil {
+		return false
+	}
+	if schema.Items2020 != nil || (nested && schema.UnevaluatedItems != nil) {
+		return true
+	}
+	switch items := schema.Items.(type) {
+	case *jsonschema.Schema:
+		return true
+	case []*jsonschema.Schema:
+		if schema.AdditionalItems != nil {
+			return true
+		}
+		for i := 0; i < len(items) && i < len(arr); i++ {
+			seen[i] = true
+		}
+	}
+	for i := 0; i < len(schema.PrefixItems) && i < len(arr); i++ {
+		seen[i] = true
+	}
+	if schema.Contains != nil {
+		for i, item := range arr {
+			if d.valid(schema.Contains, item) {
+				seen[i] = true
+			}
+		}
+	}
+	for _, s := range d.inPlace(schema, arr) {
+		if d.evaluatedItems(s, arr, seen, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// inPlace returns the subschemas of schema that apply to data in place,
+// except dependentSchemas: its $ref, its allOf, the anyOf and oneOf
+// branches data matches, and its if with then, or its else.
+func (d *defaulter) inPlace(schema *jsonschema.Schema, data interface{}) []*jsonschema.Schema {
+	var subschemas []*jsonschema.Schema
+	if schema.Ref != nil {
+		subschemas = append(subschemas, schema.Ref)
+	}
+	subschemas = append(subschemas, schema.AllOf...)
+	for _, branches := range [][]*jsonschema.Schema{schema.AnyOf, schema.OneOf} {
+		for _, s := range branches {
+			if d.valid(s, data) {
+				subschemas = append(subschemas, s)
+			}
+		}
+	}
+	if schema.If != nil {
+		if d.valid(schema.If, data) {
+			subschemas = append(subschemas, schema.If, schema.Then)
+		} else {
+			subschemas = append(subschemas, schema.Else)
+		}
+	}
+	return subschemas
+}
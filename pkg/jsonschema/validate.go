@@ -0,0 +1,93 @@
+package jsonschema
+
+import (
+	"context"
+	"errors"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Violation is a single way in which a document fails its schema.
+type Violation struct {
+	// InstanceLocation is a JSON Pointer to the offending value ("" for the root).
+	InstanceLocation string `json:"instanceLocation" description:"JSON Pointer to the offending value; empty for the root."`
+	// KeywordLocation is a JSON Pointer to the failing keyword in the schema.
+	KeywordLocation string `json:"keywordLocation" description:"JSON Pointer to the failing keyword in the schema."`
+	// Message describes the violation.
+	Message string `json:"message"`
+}
+
+// Validate validates data against schema and returns its violations, or nil
+// if data is valid. The error reports failures other than invalid data.
+func Validate(schema *jsonschema.Schema, data interface{}) ([]Violation, error) {
+	err := schema.Validate(data)
+	if err == nil {
+		return nil, nil
+	}
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return nil, err
+	}
+	return leafViolations(ve), nil
+}
+
+// ValidateCtx is Validate that returns ctx's error if ctx is done before or
+// after validating. The deadline is only checked before and after the whole
+// validation: the validator can't be interrupted, so a validation that has
+// started runs to its end, however long past the deadline that is. It runs in
+// the caller's goroutine rather than on in the background after ValidateCtx
+// has returned; callers with untrusted schemas bound the work by limiting the
+// size of schemas and documents.
+func ValidateCtx(ctx context.Context, schema *jsonschema.Schema, data interface{}) ([]Violation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	violations, err := Validate(schema, data)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	return violations, err
+}
+
+// ApplyDefaultsAndValidate applies the defaults of schema to data, like
+// ApplyDefaultsCtx, and validates the result. It returns the result if it is
+// valid and a *ValidationError with its violations otherwise, so that
+// defaults can satisfy the schema but never hide a violation.
+func ApplyDefaultsAndValidate(data interface{}, schema *jsonschema.Schema, opts ...DefaultsOption) (interface{}, error) {
+	return ApplyDefaultsAndValidateCtx(context.Background(), data, schema, opts...)
+}
+
+// ApplyDefaultsAndValidateCtx is ApplyDefaultsAndValidate that gives up with
+// ctx's error as soon as ctx is done while applying defaults, and checks ctx
+// only before and after validating, as ValidateCtx does.
+func ApplyDefaultsAndValidateCtx(ctx context.Context, data interface{}, schema *jsonschema.Schema, opts ...DefaultsOption) (interface{}, error) {
+	result, err := ApplyDefaultsCtx(ctx, data, schema, opts...)
+	if err != nil {
+		return nil, err
+	}
+	violations, err := ValidateCtx(ctx, schema, result)
+	if err != nil {
+		return nil, err
+	}
+	if len(violations) > 0 {
+		return nil, &ValidationError{Violations: violations}
+	}
+	return result, nil
+}
+
+// leafViolations flattens a validation error tree into its leaves; the inner
+// nodes only summarise their causes.
+func leafViolations(ve *jsonschema.ValidationError) []Violation {
+	if len(ve.Causes) == 0 {
+		return []Violation{{
+			InstanceLocation: ve.InstanceLocation,
+			KeywordLocation:  ve.KeywordLocation,
+			Message:          ve.Message,
+		}}
+	}
+	var leaves []Violation
+	for _, cause := range ve.Causes {
+		leaves = append(leaves, leafViolations(cause)...)
+	}
+	return leaves
+}
@@ -0,0 +1,121 @@
+package jsonschema
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	schema := compileSchema(t, `{
+		"type": "object",
+		"properties": {
+			"age": {"type": "integer", "minimum": 0},
+			"tags": {"type": "array", "items": {"type": "string"}}
+		},
+		"required": ["name"]
+	}`)
+
+	violations, err := Validate(schema, parseJSON(t, `{"name": "Alice", "age": 3}`))
+	if err != nil || violations != nil {
+		t.Fatalf("Valid data should have no violations, got %v, %v", violations, err)
+	}
+
+	violations, err = Validate(schema, parseJSON(t, `{"age": -1, "tags": ["a", 2]}`))
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	found := map[string]string{}
+	for _, v := range violations {
+		found[v.InstanceLocation] = v.KeywordLocation
+	}
+	expected := map[string]string{
+		"":        "/required",
+		"/age":    "/properties/age/minimum",
+		"/tags/1": "/properties/tags/items/type",
+	}
+	for instance, keyword := range expected {
+		if found[instance] != keyword {
+			t.Errorf("expected violation at %q from %q, got %v", instance, keyword, violations)
+		}
+	}
+	if len(violations) != len(expected) {
+		t.Errorf("expected %d violations, got %d: %v", len(expected), len(violations), violations)
+	}
+}
+
+func TestValidateCtx(t *testing.T) {
+	schema := compileSchema(t, `{"type": "object", "required": ["name"]}`)
+	data := parseJSON(t, `{}`)
+
+	violations, err := ValidateCtx(context.Background(), schema, data)
+	if err != nil {
+		t.Fatalf("ValidateCtx failed: %v", err)
+	}
+	if want, _ := Validate(schema, data); !reflect.DeepEqual(violations, want) {
+		t.Errorf("expected %v, got %v", want, violations)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ValidateCtx(ctx, schema, data); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	// Validation doesn't go on in the background after ValidateCtx returns.
+	var running atomic.Bool
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	registerFormats(t, map[string]func(interface{}) bool{"test-slow": func(interface{}) bool {
+		running.Store(true)
+		cancel()
+		time.Sleep(20 * time.Millisecond)
+		running.Store(false)
+		return true
+	}})
+	schema = compileSchema(t, `{"$schema": "http://json-schema.org/draft-07/schema#", "format": "test-slow"}`)
+	if _, err := ValidateCtx(ctx, schema, "x"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if running.Load() {
+		t.Error("expected validation to have finished when ValidateCtx returned")
+	}
+}
+
+func TestApplyDefaultsAndValidate(t *testing.T) {
+	schema := compileSchema(t, `{
+		"type": "object",
+		"properties": {
+			"mode": {"enum": ["fast", "safe"], "default": "fast"},
+			"port": {"type": "integer"}
+		},
+		"minProperties": 1
+	}`)
+
+	// The defaults are applied before validating, so they count.
+	got, err := ApplyDefaultsAndValidate(parseJSON(t, `{}`), schema)
+	if err != nil {
+		t.Fatalf("ApplyDefaultsAndValidate failed: %v", err)
+	}
+	if want := parseJSON(t, `{"mode": "fast"}`); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	got, err = ApplyDefaultsAndValidate(parseJSON(t, `{"port": "80"}`), schema)
+	var ve *ValidationError
+	if !errors.As(err, &ve) || !errors.Is(err, ErrInvalid) || got != nil {
+		t.Fatalf("expected a *ValidationError, got %v, %v", got, err)
+	}
+	if len(ve.Violations) != 1 || ve.Violations[0].InstanceLocation != "/port" {
+		t.Errorf("expected the violation of /port, got %v", ve.Violations)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ApplyDefaultsAndValidateCtx(ctx, parseJSON(t, `{}`), schema); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
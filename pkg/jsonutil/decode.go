@@ -0,0 +1,70 @@
+package jsonutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Decode reads exactly one JSON document from r. Numbers are kept as
+// json.Number, so large integers survive a decode/encode round trip. Errors
+// are *DecodeError values.
+func Decode(r io.Reader) (interface{}, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, decodeError(FormatJSON, err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		offset := dec.InputOffset()
+		return nil, &DecodeError{Format: FormatJSON, Offset: offset, Err: fmt.Errorf("unexpected data after JSON document at offset %d", offset)}
+	}
+	return v, nil
+}
+
+// DecodeBytes is Decode for a byte slice.
+func DecodeBytes(b []byte) (interface{}, error) {
+	return Decode(bytes.NewReader(b))
+}
+
+// UnmarshalWithInt is DecodeBytes that returns Go numbers instead of
+// json.Number: int64 for integers that fit one and float64 for the others.
+func UnmarshalWithInt(b []byte) (interface{}, error) {
+	v, err := DecodeBytes(b)
+	if err != nil {
+		return nil, err
+	}
+	return NormalizeNumbers(v), nil
+}
+
+// NormalizeNumbers returns v with its json.Number values converted as
+// UnmarshalWithInt converts them. Objects and arrays are copied, so v is not
+// modified.
+func NormalizeNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v
+	case map[string]interface{}:
+		obj := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			obj[k] = NormalizeNumbers(child)
+		}
+		return obj
+	case []interface{}:
+		arr := make([]interface{}, len(v))
+		for i, child := range v {
+			arr[i] = NormalizeNumbers(child)
+		}
+		return arr
+	}
+	return v
+}
@@ -0,0 +1,54 @@
+package jsonutil
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	v, err := Decode(strings.NewReader(`{"id": 9007199254740993, "price": 1.5}`))
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	obj := v.(map[string]interface{})
+	if obj["id"] != json.Number("9007199254740993") {
+		t.Errorf("Large integers should be preserved, got %v", obj["id"])
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(out) != `{"id":9007199254740993,"price":1.5}` {
+		t.Errorf("unexpected round trip: %s", out)
+	}
+
+	for _, input := range []string{``, `{"a": 1} {"b": 2}`, `{"a":`} {
+		if _, err := DecodeBytes([]byte(input)); err == nil {
+			t.Errorf("Decode(%q) should fail", input)
+		}
+	}
+}
+
+func TestUnmarshalWithInt(t *testing.T) {
+	v, err := UnmarshalWithInt([]byte(`{"id": 42, "price": 1.5, "big": 1e400, "huge": 9223372036854775808, "tags": [1, "a"]}`))
+	if err != nil {
+		t.Fatalf("UnmarshalWithInt failed: %v", err)
+	}
+	want := map[string]interface{}{
+		"id":    int64(42),
+		"price": 1.5,
+		"big":   json.Number("1e400"),
+		"huge":  9223372036854775808.0,
+		"tags":  []interface{}{int64(1), "a"},
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("expected %v, got %v", want, v)
+	}
+
+	if _, err := UnmarshalWithInt([]byte(`{"a":`)); err == nil {
+		t.Error("UnmarshalWithInt should fail for malformed JSON")
+	}
+}
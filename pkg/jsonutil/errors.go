@@ -0,0 +1,67 @@
+package jsonutil
+
+import (
+	"encoding/json"
+	"errors"
+
+	"go-demo/pkg/errcode"
+)
+
+// DecodeError is returned by Decode and Unmarshal for input that isn't a
+// well-formed document. Its message is that of the underlying error.
+type DecodeError struct {
+	// Format is the format the input was decoded as.
+	Format Format
+
+	// Offset is the byte offset in the input at which decoding failed, or 0
+	// if unknown. Only JSON errors have one.
+	Offset int64
+
+	// Err is the underlying cause.
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Code returns errcode.Decode.
+func (e *DecodeError) Code() errcode.Code {
+	return errcode.Decode
+}
+
+// Is reports whether target is errcode.Decode.
+func (e *DecodeError) Is(target error) bool {
+	return target == errcode.Decode
+}
+
+// MarshalJSON encodes the error as {"code", "message", "format", "offset"}.
+func (e *DecodeError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Code    errcode.Code `json:"code"`
+		Message string       `json:"message"`
+		Format  Format       `json:"format"`
+		Offset  int64        `json:"offset,omitempty"`
+	}{e.Code(), e.Error(), e.Format, e.Offset})
+}
+
+// decodeError wraps err, if not nil, in a *DecodeError for format f.
+func decodeError(f Format, err error) error {
+	if err == nil {
+		return nil
+	}
+	var de *DecodeError
+	if errors.As(err, &de) {
+		return err
+	}
+	de = &DecodeError{Format: f, Err: err}
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		de.Offset = syntaxErr.Offset
+	}
+	return de
+}
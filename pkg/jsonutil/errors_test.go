@@ -0,0 +1,45 @@
+package jsonutil
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"go-demo/pkg/errcode"
+)
+
+func TestDecodeError(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		input  string
+		offset int64
+	}{
+		{"syntax", FormatJSON, `{"a": 1,}`, 9},
+		{"trailing data", FormatJSON, `{} {}`, 4},
+		{"truncated", FormatJSON, `{"a": `, 0},
+		{"yaml", FormatYAML, "a: [1", 0},
+		{"toml", FormatTOML, "a = ", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Unmarshal(tt.format, []byte(tt.input))
+			var de *DecodeError
+			if !errors.As(err, &de) {
+				t.Fatalf("expected a *DecodeError, got %T: %v", err, err)
+			}
+			if de.Format != tt.format || de.Offset != tt.offset {
+				t.Errorf("expected format %s at offset %d, got %s at %d", tt.format, tt.offset, de.Format, de.Offset)
+			}
+			if !errors.Is(err, errcode.Decode) || errcode.Of(err) != errcode.Decode {
+				t.Errorf("%v should have code %q", err, errcode.Decode)
+			}
+		})
+	}
+
+	_, err := DecodeBytes([]byte(`[1,]`))
+	b, _ := json.Marshal(err)
+	if want := `{"code":"decode_error","message":"invalid character ']' looking for beginning of value","format":"json","offset":4}`; string(b) != want {
+		t.Errorf("expected %s, got %s", want, b)
+	}
+}
@@ -0,0 +1,438 @@
+package jsonutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"go-demo/pkg/bufpool"
+)
+
+// Format is a document format that converts to and from decoded JSON values.
+type Format string
+
+// Supported formats.
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+	FormatTOML Format = "toml"
+)
+
+// ParseFormat parses a format name; "yml" is accepted for YAML.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "json":
+		return FormatJSON, nil
+	case "yaml", "yml":
+		return FormatYAML, nil
+	case "toml":
+		return FormatTOML, nil
+	}
+	return "", fmt.Errorf("unknown format %q (want json, yaml or toml)", s)
+}
+
+// FormatFromPath returns the format of a file by its extension.
+func FormatFromPath(path string) (Format, error) {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	if ext == "" {
+		return "", fmt.Errorf("%s: no file extension to infer the format from", path)
+	}
+	return ParseFormat(ext)
+}
+
+// Unmarshal decodes a document in format f into the values Decode produces:
+// maps, slices, strings, booleans, nil and json.Number. Integers of any size
+// are kept exact, and YAML and TOML timestamps become RFC 3339 strings.
+// Malformed documents fail with a *DecodeError.
+func Unmarshal(f Format, b []byte) (interface{}, error) {
+	switch f {
+	case FormatJSON:
+		return DecodeBytes(b)
+	case FormatYAML:
+		v, err := unmarshalYAML(b)
+		return v, decodeError(f, err)
+	case FormatTOML:
+		v, err := unmarshalTOML(b)
+		return v, decodeError(f, err)
+	}
+	return nil, fmt.Errorf("unknown format %q", f)
+}
+
+// Marshal encodes a decoded JSON value in format f, indenting nested
+// structures by indent spaces. JSON is compact if indent is 0; YAML always
+// uses at least 2. TOML documents must be objects without nulls, and their
+// integers must fit in an int64.
+func Marshal(f Format, v interface{}, indent int) ([]byte, error) {
+	switch f {
+	case FormatJSON:
+		e := jsonEncoders.Get().(*jsonEncoder)
+		defer e.release()
+		e.enc.SetIndent("", spaces(indent))
+		if err := e.enc.Encode(v); err != nil {
+			return nil, err
+		}
+		return bufpool.Bytes(&e.buf), nil
+	case FormatYAML:
+		return marshalYAML(v, indent)
+	case FormatTOML:
+		return marshalTOML(v, indent)
+	}
+	return nil, fmt.Errorf("unknown format %q", f)
+}
+
+// jsonEncoder is a JSON encoder with the buffer it writes to. The encoder
+// keeps its indentation buffer between calls, so pooling both spares Marshal
+// two allocations the size of the document.
+type jsonEncoder struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+var jsonEncoders = sync.Pool{New: func() interface{} {
+	e := &jsonEncoder{}
+	e.enc = json.NewEncoder(&e.buf)
+	e.enc.SetEscapeHTML(false)
+	return e
+}}
+
+// release returns e to the pool unless its buffer grew beyond
+// bufpool.MaxSize.
+func (e *jsonEncoder) release() {
+	if e.buf.Cap() > bufpool.MaxSize {
+		return
+	}
+	e.buf.Reset()
+	jsonEncoders.Put(e)
+}
+
+// spaces returns n spaces.
+func spaces(n int) string {
+	const s = "                "
+	if n <= len(s) {
+		return s[:n]
+	}
+	return strings.Repeat(" ", n)
+}
+
+// unmarshalYAML decodes a single YAML document through its node tree, so that
+// integers beyond 64 bits aren't turned into floats.
+func unmarshalYAML(b []byte) (interface{}, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	var doc yaml.Node
+	if err := dec.Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var extra yaml.Node
+	if err := dec.Decode(&extra); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("unexpected second YAML document")
+	}
+	return fromYAMLNode(&doc)
+}
+
+func fromYAMLNode(n *yaml.Node) (interface{}, error) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil, nil
+		}
+		return fromYAMLNode(n.Content[0])
+	case yaml.AliasNode:
+		return fromYAMLNode(n.Alias)
+	case yaml.SequenceNode:
+		items := make([]interface{}, len(n.Content))
+		for i, c := range n.Content {
+			v, err := fromYAMLNode(c)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = v
+		}
+		return items, nil
+	case yaml.MappingNode:
+		m := make(map[string]interface{}, len(n.Content)/2)
+		var merged []map[string]interface{}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			v, err := fromYAMLNode(value)
+			if err != nil {
+				return nil, err
+			}
+			if key.Tag == "!!merge" {
+				switch mv := v.(type) {
+				case map[string]interface{}:
+					merged = append(merged, mv)
+				case []interface{}:
+					for _, item := range mv {
+						if im, ok := item.(map[string]interface{}); ok {
+							merged = append(merged, im)
+						}
+					}
+				}
+				continue
+			}
+			if key.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("line %d: mapping key must be a scalar", key.Line)
+			}
+			m[key.Value] = v
+		}
+		// Explicit keys win over merged ones, and earlier merges over later ones.
+		for _, mm := range merged {
+			for k, v := range mm {
+				if _, ok := m[k]; !ok {
+					m[k] = v
+				}
+			}
+		}
+		return m, nil
+	}
+	return fromYAMLScalar(n)
+}
+
+// jsonNumber matches the number syntax of JSON.
+var jsonNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+func fromYAMLScalar(n *yaml.Node) (interface{}, error) {
+	switch n.ShortTag() {
+	case "!!null":
+		return nil, nil
+	case "!!bool":
+		var b bool
+		if err := n.Decode(&b); err != nil {
+			return nil, err
+		}
+		return b, nil
+	case "!!int":
+		i, ok := new(big.Int).SetString(n.Value, 0)
+		if !ok {
+			return nil, fmt.Errorf("line %d: invalid integer %q", n.Line, n.Value)
+		}
+		return json.Number(i.String()), nil
+	case "!!float":
+		if jsonNumber.MatchString(n.Value) {
+			return json.Number(n.Value), nil
+		}
+		var f float64
+		if err := n.Decode(&f); err != nil {
+			return nil, err
+		}
+		return floatNumber(f)
+	}
+	return n.Value, nil
+}
+
+// floatNumber converts a float to a json.Number, rejecting NaN and infinities,
+// which JSON can't represent.
+func floatNumber(f float64) (json.Number, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("%v can't be represented in JSON", f)
+	}
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), nil
+}
+
+func marshalYAML(v interface{}, indent int) ([]byte, error) {
+	n, err := toYAMLNode(v)
+	if err != nil {
+		return nil, err
+	}
+	buf := bufpool.Get()
+	defer bufpool.Put(buf)
+	enc := yaml.NewEncoder(buf)
+	if indent < 2 {
+		indent = 2
+	}
+	enc.SetIndent(indent)
+	if err := enc.Encode(n); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return bufpool.Bytes(buf), nil
+}
+
+// toYAMLNode builds a node tree for v with map keys sorted, writing
+// json.Number values as plain numbers rather than strings.
+func toYAMLNode(v interface{}) (*yaml.Node, error) {
+	switch v := v.(type) {
+	case json.Number:
+		tag := "!!int"
+		if _, ok := new(big.Int).SetString(v.String(), 10); !ok {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String()}, nil
+	case map[string]interface{}:
+		n := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, k := range sortedKeys(v) {
+			value, err := toYAMLNode(v[k])
+			if err != nil {
+				return nil, err
+			}
+			n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}, value)
+		}
+		return n, nil
+	case []interface{}:
+		n := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range v {
+			c, err := toYAMLNode(item)
+			if err != nil {
+				return nil, err
+			}
+			n.Content = append(n.Content, c)
+		}
+		return n, nil
+	}
+	n := &yaml.Node{}
+	if err := n.Encode(v); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func unmarshalTOML(b []byte) (interface{}, error) {
+	var m map[string]interface{}
+	if err := toml.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return fromTOML(m)
+}
+
+// fromTOML converts the values decoded by the toml package (int64, float64,
+// time.Time and their containers) into decoded JSON values.
+func fromTOML(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			c, err := fromTOML(child)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			m[k] = c
+		}
+		return m, nil
+	case []map[string]interface{}:
+		items := make([]interface{}, len(v))
+		for i, child := range v {
+			c, err := fromTOML(child)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = c
+		}
+		return items, nil
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, child := range v {
+			c, err := fromTOML(child)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = c
+		}
+		return items, nil
+	case int64:
+		return json.Number(strconv.FormatInt(v, 10)), nil
+	case float64:
+		return floatNumber(v)
+	case time.Time:
+		return tomlTime(v), nil
+	}
+	return v, nil
+}
+
+// tomlTime formats a TOML date-time, local date-time, local date or local time.
+// The toml package marks the local kinds with zones of these names.
+func tomlTime(t time.Time) string {
+	switch t.Location().String() {
+	case "datetime-local":
+		return t.Format("2006-01-02T15:04:05.999999999")
+	case "date-local":
+		return t.Format("2006-01-02")
+	case "time-local":
+		return t.Format("15:04:05.999999999")
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+func marshalTOML(v interface{}, indent int) ([]byte, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("TOML documents must be objects, got %T", v)
+	}
+	tv, err := toTOML(m)
+	if err != nil {
+		return nil, err
+	}
+	buf := bufpool.Get()
+	defer bufpool.Put(buf)
+	enc := toml.NewEncoder(buf)
+	enc.Indent = strings.Repeat(" ", indent)
+	if err := enc.Encode(tv); err != nil {
+		return nil, err
+	}
+	return bufpool.Bytes(buf), nil
+}
+
+// toTOML converts json.Number values into int64 or float64 for the toml
+// encoder, which has no null.
+func toTOML(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, errors.New("TOML has no null value")
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		if _, ok := new(big.Int).SetString(v.String(), 10); ok {
+			return nil, fmt.Errorf("integer %s overflows TOML's 64-bit integers", v)
+		}
+		return v.Float64()
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			c, err := toTOML(child)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			m[k] = c
+		}
+		return m, nil
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, child := range v {
+			c, err := toTOML(child)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = c
+		}
+		return items, nil
+	}
+	return v, nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,162 @@
+package jsonutil
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestUnmarshalFormats(t *testing.T) {
+	want := map[string]interface{}{
+		"id":    json.Number("12345678901234567890"),
+		"price": json.Number("1.5"),
+		"tags":  []interface{}{"a", "b"},
+		"owner": map[string]interface{}{"name": "Alice", "active": true},
+	}
+
+	tests := []struct {
+		format Format
+		input  string
+	}{
+		{FormatJSON, `{"id": 12345678901234567890, "price": 1.5, "tags": ["a", "b"], "owner": {"name": "Alice", "active": true}}`},
+		{FormatYAML, "id: 12345678901234567890\nprice: 1.5\ntags: [a, b]\nowner:\n  name: Alice\n  active: true\n"},
+	}
+	for _, tt := range tests {
+		got, err := Unmarshal(tt.format, []byte(tt.input))
+		if err != nil {
+			t.Fatalf("Unmarshal(%s) failed: %v", tt.format, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Unmarshal(%s) = %#v, want %#v", tt.format, got, want)
+		}
+	}
+
+	got, err := Unmarshal(FormatTOML, []byte("id = 9007199254740993\nwhen = 2024-05-01\n[owner]\nname = \"Alice\"\n"))
+	if err != nil {
+		t.Fatalf("Unmarshal(toml) failed: %v", err)
+	}
+	wantTOML := map[string]interface{}{
+		"id":    json.Number("9007199254740993"),
+		"when":  "2024-05-01",
+		"owner": map[string]interface{}{"name": "Alice"},
+	}
+	if !reflect.DeepEqual(got, wantTOML) {
+		t.Errorf("Unmarshal(toml) = %#v, want %#v", got, wantTOML)
+	}
+}
+
+func TestUnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		input string
+		want  interface{}
+	}{
+		{"0x1F", json.Number("31")},
+		{"1_000", json.Number("1000")},
+		{"1e3", json.Number("1e3")},
+		{"", nil},
+		{"~", nil},
+		{"2024-05-01", "2024-05-01"},
+		{"base: &b {a: 1, b: 2}\nderived:\n  <<: *b\n  b: 3\n", map[string]interface{}{
+			"base":    map[string]interface{}{"a": json.Number("1"), "b": json.Number("2")},
+			"derived": map[string]interface{}{"a": json.Number("1"), "b": json.Number("3")},
+		}},
+	}
+	for _, tt := range tests {
+		got, err := Unmarshal(FormatYAML, []byte(tt.input))
+		if err != nil {
+			t.Fatalf("Unmarshal(%q) failed: %v", tt.input, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Unmarshal(%q) = %#v, want %#v", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{".inf", "a: 1\n---\nb: 2\n", "[1, 2]: x"} {
+		if _, err := Unmarshal(FormatYAML, []byte(input)); err == nil {
+			t.Errorf("Unmarshal(%q) should fail", input)
+		}
+	}
+}
+
+func TestMarshalFormats(t *testing.T) {
+	v := map[string]interface{}{
+		"id":    json.Number("9007199254740993"),
+		"price": json.Number("1.5"),
+		"tags":  []interface{}{"a", "b"},
+		"owner": map[string]interface{}{"name": "Alice"},
+	}
+
+	tests := []struct {
+		format Format
+		indent int
+		want   string
+	}{
+		{FormatJSON, 0, `{"id":9007199254740993,"owner":{"name":"Alice"},"price":1.5,"tags":["a","b"]}` + "\n"},
+		{FormatYAML, 2, "id: 9007199254740993\nowner:\n  name: Alice\nprice: 1.5\ntags:\n  - a\n  - b\n"},
+		{FormatTOML, 0, "id = 9007199254740993\nprice = 1.5\ntags = [\"a\", \"b\"]\n\n[owner]\nname = \"Alice\"\n"},
+	}
+	for _, tt := range tests {
+		got, err := Marshal(tt.format, v, tt.indent)
+		if err != nil {
+			t.Fatalf("Marshal(%s) failed: %v", tt.format, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("Marshal(%s) =\n%s\nwant\n%s", tt.format, got, tt.want)
+		}
+
+		back, err := Unmarshal(tt.format, got)
+		if err != nil {
+			t.Fatalf("Unmarshal(%s) of marshalled output failed: %v", tt.format, err)
+		}
+		if !reflect.DeepEqual(back, v) {
+			t.Errorf("%s round trip = %#v, want %#v", tt.format, back, v)
+		}
+	}
+
+	for _, bad := range []interface{}{
+		[]interface{}{1},
+		map[string]interface{}{"a": nil},
+		map[string]interface{}{"a": json.Number("12345678901234567890")},
+	} {
+		if _, err := Marshal(FormatTOML, bad, 0); err == nil {
+			t.Errorf("Marshal(toml, %v) should fail", bad)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for path, want := range map[string]Format{"a.json": FormatJSON, "a.yml": FormatYAML, "a.YAML": FormatYAML, "a.toml": FormatTOML} {
+		if got, err := FormatFromPath(path); err != nil || got != want {
+			t.Errorf("FormatFromPath(%q) = %q, %v; want %q", path, got, err, want)
+		}
+	}
+	for _, path := range []string{"a", "a.txt"} {
+		if _, err := FormatFromPath(path); err == nil {
+			t.Errorf("FormatFromPath(%q) should fail", path)
+		}
+	}
+}
+
+// benchDocument returns a decoded document with n line items.
+func benchDocument(n int) interface{} {
+	items := make([]interface{}, n)
+	for i := range items {
+		items[i] = map[string]interface{}{"sku": "SKU-" + strconv.Itoa(i), "qty": json.Number("2"), "price": json.Number("19.99")}
+	}
+	return map[string]interface{}{"id": json.Number("12345678901234567890"), "customer": "Alice", "items": items}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	doc := benchDocument(100)
+	for _, f := range []Format{FormatJSON, FormatYAML} {
+		b.Run(string(f), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Marshal(f, doc, 2); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
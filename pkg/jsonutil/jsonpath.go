@@ -0,0 +1,634 @@
+package jsonutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Path is a compiled JSONPath expression, such as $.items[*].id. It supports
+// member names (.name, ['name']), wildcards, recursive descent (..), array
+// indexes (negative ones count from the end), unions ([0,2], ['a','b']),
+// slices ([start:end:step]) and filters comparing values relative to the
+// current node with literals ([?(@.price < 10 && @.tags)]).
+type Path struct {
+	expr  string
+	steps []pathStep
+}
+
+// Match is a value selected by a Path.
+type Match struct {
+	// Pointer is the JSON Pointer of the value in the document.
+	Pointer string      `json:"pointer"`
+	Value   interface{} `json:"value"`
+}
+
+// ParsePath compiles a JSONPath expression.
+func ParsePath(expr string) (*Path, error) {
+	p := &pathParser{s: expr}
+	if !p.consume("$") {
+		return nil, fmt.Errorf("invalid JSONPath %q: must start with $", expr)
+	}
+	steps, err := p.steps(false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSONPath %q: %w", expr, err)
+	}
+	if p.pos < len(p.s) {
+		return nil, fmt.Errorf("invalid JSONPath %q: unexpected %q at offset %d", expr, p.s[p.pos:], p.pos)
+	}
+	return &Path{expr: expr, steps: steps}, nil
+}
+
+func (p *Path) String() string { return p.expr }
+
+// Find returns the values p selects in doc, in document order. Object
+// members are visited in key order.
+func (p *Path) Find(doc interface{}) []Match {
+	matches := []Match{{Pointer: "", Value: doc}}
+	for _, step := range p.steps {
+		var next []Match
+		for _, m := range matches {
+			step.apply(m, func(found Match) { next = append(next, found) })
+		}
+		matches = next
+	}
+	return matches
+}
+
+// pathStep applies a selector to a node, or with recursive set, to the node
+// and all of its descendants.
+type pathStep struct {
+	recursive bool
+	sel       selector
+}
+
+func (s pathStep) apply(m Match, emit func(Match)) {
+	s.sel.apply(m, emit)
+	if s.recursive {
+		children(m, func(child Match) { s.apply(child, emit) })
+	}
+}
+
+// children calls fn with the members or elements of m's value.
+func children(m Match, fn func(Match)) {
+	switch v := m.Value.(type) {
+	case map[string]interface{}:
+		for _, k := range sortedKeys(v) {
+			fn(Match{Pointer: m.Pointer + JoinPointer(k), Value: v[k]})
+		}
+	case []interface{}:
+		for i, item := range v {
+			fn(Match{Pointer: m.Pointer + "/" + strconv.Itoa(i), Value: item})
+		}
+	}
+}
+
+type selector interface {
+	apply(m Match, emit func(Match))
+}
+
+type wildcardSelector struct{}
+
+func (wildcardSelector) apply(m Match, emit func(Match)) { children(m, emit) }
+
+type nameSelector []string
+
+func (names nameSelector) apply(m Match, emit func(Match)) {
+	obj, ok := m.Value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for _, name := range names {
+		if v, ok := obj[name]; ok {
+			emit(Match{Pointer: m.Pointer + JoinPointer(name), Value: v})
+		}
+	}
+}
+
+type indexSelector []int
+
+func (indexes indexSelector) apply(m Match, emit func(Match)) {
+	arr, ok := m.Value.([]interface{})
+	if !ok {
+		return
+	}
+	for _, i := range indexes {
+		if i < 0 {
+			i += len(arr)
+		}
+		if i >= 0 && i < len(arr) {
+			emit(Match{Pointer: m.Pointer + "/" + strconv.Itoa(i), Value: arr[i]})
+		}
+	}
+}
+
+// sliceSelector selects [start:end:step] like Python slices; nil bounds
+// default to the whole array in the direction of step.
+type sliceSelector struct {
+	start, end *int
+	step       int
+}
+
+func (s sliceSelector) apply(m Match, emit func(Match)) {
+	arr, ok := m.Value.([]interface{})
+	if !ok || s.step == 0 {
+		return
+	}
+	n := len(arr)
+	bound := func(b *int, def int) int {
+		if b == nil {
+			return def
+		}
+		i := *b
+		if i < 0 {
+			i += n
+		}
+		if i < -1 {
+			i = -1
+		}
+		if i > n {
+			i = n
+		}
+		return i
+	}
+	if s.step > 0 {
+		start, end := bound(s.start, 0), bound(s.end, n)
+		if start < 0 {
+			start = 0
+		}
+		for i := start; i < end; i += s.step {
+			emit(Match{Pointer: m.Pointer + "/" + strconv.Itoa(i), Value: arr[i]})
+		}
+		return
+	}
+	start, end := bound(s.start, n-1), bound(s.end, -1)
+	if start >= n {
+		start = n - 1
+	}
+	for i := start; i > end; i += s.step {
+		emit(Match{Pointer: m.Pointer + "/" + strconv.Itoa(i), Value: arr[i]})
+	}
+}
+
+// filterSelector selects the members or elements for which expr holds.
+type filterSelector struct {
+	expr filterExpr
+}
+
+func (f filterSelector) apply(m Match, emit func(Match)) {
+	children(m, func(child Match) {
+		if f.expr.eval(child.Value) {
+			emit(child)
+		}
+	})
+}
+
+// filterExpr is a boolean expression over the current node (@).
+type filterExpr interface {
+	eval(node interface{}) bool
+}
+
+type orExpr []filterExpr
+
+func (e orExpr) eval(node interface{}) bool {
+	for _, sub := range e {
+		if sub.eval(node) {
+			return true
+		}
+	}
+	return false
+}
+
+type andExpr []filterExpr
+
+func (e andExpr) eval(node interface{}) bool {
+	for _, sub := range e {
+		if !sub.eval(node) {
+			return false
+		}
+	}
+	return true
+}
+
+type notExpr struct{ sub filterExpr }
+
+func (e notExpr) eval(node interface{}) bool { return !e.sub.eval(node) }
+
+// operand is a literal or a path relative to the current node.
+type operand struct {
+	path    []pathStep // nil for a literal
+	literal interface{}
+}
+
+// value returns the operand's value for node; ok is false for a path that
+// selects nothing.
+func (o operand) value(node interface{}) (interface{}, bool) {
+	if o.path == nil {
+		return o.literal, true
+	}
+	matches := (&Path{steps: o.path}).Find(node)
+	if len(matches) == 0 {
+		return nil, false
+	}
+	return matches[0].Value, true
+}
+
+// existsExpr holds if a relative path selects something.
+type existsExpr struct{ operand operand }
+
+func (e existsExpr) eval(node interface{}) bool {
+	_, ok := e.operand.value(node)
+	return ok
+}
+
+type compareExpr struct {
+	left, right operand
+	op          string
+}
+
+func (e compareExpr) eval(node interface{}) bool {
+	l, lok := e.left.value(node)
+	r, rok := e.right.value(node)
+	if !lok || !rok {
+		return e.op == "!=" && lok != rok
+	}
+	if e.op == "==" || e.op == "!=" {
+		return equalValues(l, r) == (e.op == "==")
+	}
+	c, ok := compareValues(l, r)
+	if !ok {
+		return false
+	}
+	switch e.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	}
+	return c >= 0
+}
+
+// numberRat returns v as an exact rational if it is a number.
+func numberRat(v interface{}) (*big.Rat, bool) {
+	var s string
+	switch n := v.(type) {
+	case json.Number:
+		s = n.String()
+	case float64:
+		return new(big.Rat).SetFloat64(n), true
+	case int64:
+		return new(big.Rat).SetInt64(n), true
+	case int:
+		return new(big.Rat).SetInt64(int64(n)), true
+	default:
+		return nil, false
+	}
+	return new(big.Rat).SetString(s)
+}
+
+// compareValues orders two numbers or two strings.
+func compareValues(a, b interface{}) (int, bool) {
+	if ra, ok := numberRat(a); ok {
+		if rb, ok := numberRat(b); ok {
+			return ra.Cmp(rb), true
+		}
+		return 0, false
+	}
+	sa, ok1 := a.(string)
+	sb, ok2 := b.(string)
+	if ok1 && ok2 {
+		return strings.Compare(sa, sb), true
+	}
+	return 0, false
+}
+
+func equalValues(a, b interface{}) bool {
+	if c, ok := compareValues(a, b); ok {
+		return c == 0
+	}
+	switch a := a.(type) {
+	case bool:
+		b, ok := b.(bool)
+		return ok && a == b
+	case nil:
+		return b == nil
+	}
+	return false
+}
+
+// pathParser parses JSONPath expressions.
+type pathParser struct {
+	s   string
+	pos int
+}
+
+func (p *pathParser) skipSpace() {
+	for p.pos < len(p.s) && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *pathParser) consume(tok string) bool {
+	if strings.HasPrefix(p.s[p.pos:], tok) {
+		p.pos += len(tok)
+		return true
+	}
+	return false
+}
+
+// steps parses selectors until the end of the expression or, in a filter,
+// until something that isn't a selector.
+func (p *pathParser) steps(inFilter bool) ([]pathStep, error) {
+	steps := []pathStep{}
+	for p.pos < len(p.s) {
+		recursive := false
+		switch {
+		case p.consume(".."):
+			recursive = true
+			if p.consume("[") {
+				sel, err := p.bracket()
+				if err != nil {
+					return nil, err
+				}
+				steps = append(steps, pathStep{recursive: true, sel: sel})
+				continue
+			}
+		case p.consume("."):
+		case p.consume("["):
+			sel, err := p.bracket()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, pathStep{sel: sel})
+			continue
+		default:
+			if inFilter {
+				return steps, nil
+			}
+			return nil, fmt.Errorf("unexpected %q at offset %d", p.s[p.pos:], p.pos)
+		}
+		if p.consume("*") {
+			steps = append(steps, pathStep{recursive: recursive, sel: wildcardSelector{}})
+			continue
+		}
+		name := p.name()
+		if name == "" {
+			return nil, fmt.Errorf("expected a member name at offset %d", p.pos)
+		}
+		steps = append(steps, pathStep{recursive: recursive, sel: nameSelector{name}})
+	}
+	return steps, nil
+}
+
+// name parses a dot-notation member name.
+func (p *pathParser) name() string {
+	start := p.pos
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if c == '.' || c == '[' || c == ' ' || c == ')' || c == '=' || c == '!' || c == '<' || c == '>' || c == '&' || c == '|' {
+			break
+		}
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+// bracket parses the selector after "[" up to and including "]".
+func (p *pathParser) bracket() (selector, error) {
+	p.skipSpace()
+	var sel selector
+	switch {
+	case p.consume("*"):
+		sel = wildcardSelector{}
+	case p.consume("?("):
+		expr, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if !p.consume(")") {
+			return nil, fmt.Errorf("expected ) at offset %d", p.pos)
+		}
+		sel = filterSelector{expr}
+	case p.pos < len(p.s) && (p.s[p.pos] == '\'' || p.s[p.pos] == '"'):
+		var names nameSelector
+		for {
+			name, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, name)
+			p.skipSpace()
+			if !p.consume(",") {
+				break
+			}
+			p.skipSpace()
+		}
+		sel = names
+	default:
+		var err error
+		if sel, err = p.indexes(); err != nil {
+			return nil, err
+		}
+	}
+	p.skipSpace()
+	if !p.consume("]") {
+		return nil, fmt.Errorf("expected ] at offset %d", p.pos)
+	}
+	return sel, nil
+}
+
+// indexes parses an index union or a slice.
+func (p *pathParser) indexes() (selector, error) {
+	var parts []*int
+	colons := 0
+	for {
+		p.skipSpace()
+		n, ok, err := p.integer()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			parts = append(parts, &n)
+		} else {
+			parts = append(parts, nil)
+		}
+		p.skipSpace()
+		if p.consume(":") {
+			colons++
+			continue
+		}
+		if colons == 0 && p.consume(",") {
+			continue
+		}
+		break
+	}
+	if colons == 0 {
+		var indexes indexSelector
+		for _, part := range parts {
+			if part == nil {
+				return nil, fmt.Errorf("expected an index at offset %d", p.pos)
+			}
+			indexes = append(indexes, *part)
+		}
+		return indexes, nil
+	}
+	if colons > 2 {
+		return nil, fmt.Errorf("too many colons in slice at offset %d", p.pos)
+	}
+	s := sliceSelector{start: parts[0], end: parts[1], step: 1}
+	if colons == 2 && parts[2] != nil {
+		s.step = *parts[2]
+		if s.step == 0 {
+			return nil, fmt.Errorf("slice step must not be 0")
+		}
+	}
+	return s, nil
+}
+
+func (p *pathParser) integer() (int, bool, error) {
+	start := p.pos
+	if p.pos < len(p.s) && p.s[p.pos] == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.s) && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' {
+		p.pos++
+	}
+	if p.pos == start {
+		return 0, false, nil
+	}
+	n, err := strconv.Atoi(p.s[start:p.pos])
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid index %q", p.s[start:p.pos])
+	}
+	return n, true, nil
+}
+
+// quoted parses a single- or double-quoted string with backslash escapes.
+func (p *pathParser) quoted() (string, error) {
+	quote := p.s[p.pos]
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		p.pos++
+		switch {
+		case c == quote:
+			return b.String(), nil
+		case c == '\\' && p.pos < len(p.s):
+			b.WriteByte(p.s[p.pos])
+			p.pos++
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+func (p *pathParser) or() (filterExpr, error) {
+	var terms orExpr
+	for {
+		term, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+		p.skipSpace()
+		if !p.consume("||") {
+			break
+		}
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return terms, nil
+}
+
+func (p *pathParser) and() (filterExpr, error) {
+	var terms andExpr
+	for {
+		term, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+		p.skipSpace()
+		if !p.consume("&&") {
+			break
+		}
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return terms, nil
+}
+
+func (p *pathParser) comparison() (filterExpr, error) {
+	p.skipSpace()
+	if p.consume("!") {
+		sub, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{sub}, nil
+	}
+	if p.consume("(") {
+		expr, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if !p.consume(")") {
+			return nil, fmt.Errorf("expected ) at offset %d", p.pos)
+		}
+		return expr, nil
+	}
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consume(op) {
+			p.skipSpace()
+			right, err := p.operand()
+			if err != nil {
+				return nil, err
+			}
+			return compareExpr{left: left, right: right, op: op}, nil
+		}
+	}
+	if left.path == nil {
+		return nil, fmt.Errorf("expected a comparison at offset %d", p.pos)
+	}
+	return existsExpr{left}, nil
+}
+
+func (p *pathParser) operand() (operand, error) {
+	switch {
+	case p.consume("@"):
+		steps, err := p.steps(true)
+		return operand{path: steps}, err
+	case p.consume("true"):
+		return operand{literal: true}, nil
+	case p.consume("false"):
+		return operand{literal: false}, nil
+	case p.consume("null"):
+		return operand{literal: nil}, nil
+	case p.pos < len(p.s) && (p.s[p.pos] == '\'' || p.s[p.pos] == '"'):
+		s, err := p.quoted()
+		return operand{literal: s}, err
+	}
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte("+-.0123456789eE", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+	if _, err := strconv.ParseFloat(p.s[start:p.pos], 64); err != nil || start == p.pos {
+		return operand{}, fmt.Errorf("expected @, a number, a string, true, false or null at offset %d", start)
+	}
+	return operand{literal: json.Number(p.s[start:p.pos])}, nil
+}
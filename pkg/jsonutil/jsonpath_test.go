@@ -0,0 +1,75 @@
+package jsonutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+const storeDoc = `{
+	"store": {
+		"books": [
+			{"id": 9007199254740993, "title": "Go", "price": 30, "tags": ["dev"]},
+			{"id": 2, "title": "Rust", "price": 45.5},
+			{"id": 3, "title": "Zig", "price": 12, "tags": []},
+			{"id": 4, "title": "C", "price": 12.0, "author": null}
+		],
+		"bike": {"color": "red", "price": 199}
+	}
+}`
+
+func TestPathFind(t *testing.T) {
+	doc, err := DecodeBytes([]byte(storeDoc))
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	tests := []struct {
+		expr     string
+		pointers []string
+	}{
+		{"$", []string{""}},
+		{"$.store.bike.color", []string{"/store/bike/color"}},
+		{"$['store']['bike']['color', 'price']", []string{"/store/bike/color", "/store/bike/price"}},
+		{"$.store.books[*].id", []string{"/store/books/0/id", "/store/books/1/id", "/store/books/2/id", "/store/books/3/id"}},
+		{"$.store.books[0,-1].title", []string{"/store/books/0/title", "/store/books/3/title"}},
+		{"$.store.books[1:3].id", []string{"/store/books/1/id", "/store/books/2/id"}},
+		{"$.store.books[::-2].id", []string{"/store/books/3/id", "/store/books/1/id"}},
+		{"$.store.books[-2:].id", []string{"/store/books/2/id", "/store/books/3/id"}},
+		{"$..price", []string{"/store/bike/price", "/store/books/0/price", "/store/books/1/price", "/store/books/2/price", "/store/books/3/price"}},
+		{"$.store.*", []string{"/store/bike", "/store/books"}},
+		{"$.store.books[?(@.price < 20)].title", []string{"/store/books/2/title", "/store/books/3/title"}},
+		{"$.store.books[?(@.price == 12 && @.tags)].id", []string{"/store/books/2/id"}},
+		{"$.store.books[?(@.title == 'Go' || @.price >= 45.5)].id", []string{"/store/books/0/id", "/store/books/1/id"}},
+		{"$.store.books[?(@.id == 9007199254740993)].title", []string{"/store/books/0/title"}},
+		{"$.store.books[?(@.author == null)].id", []string{"/store/books/3/id"}},
+		{"$.store.books[?(!@.tags)].id", []string{"/store/books/1/id", "/store/books/3/id"}},
+		{"$..books[?(@.tags[0] == \"dev\")].title", []string{"/store/books/0/title"}},
+		{"$.store.missing", nil},
+		{"$.store.books[10]", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			p, err := ParsePath(tt.expr)
+			if err != nil {
+				t.Fatalf("ParsePath failed: %v", err)
+			}
+			var pointers []string
+			for _, m := range p.Find(doc) {
+				pointers = append(pointers, m.Pointer)
+				if got, err := Get(doc, m.Pointer); err != nil || !reflect.DeepEqual(got, m.Value) {
+					t.Errorf("Match %s has value %v, the document %v", m.Pointer, m.Value, got)
+				}
+			}
+			if !reflect.DeepEqual(pointers, tt.pointers) {
+				t.Errorf("expected %v, got %v", tt.pointers, pointers)
+			}
+		})
+	}
+}
+
+func TestParsePathErrors(t *testing.T) {
+	for _, expr := range []string{"", "store.books", "$.", "$[", "$[1", "$['a", "$[1:2:0]", "$[?(@.a <)]", "$[?(@.a == 1]", "$ x"} {
+		if _, err := ParsePath(expr); err == nil {
+			t.Errorf("expected an error for %q", expr)
+		}
+	}
+}
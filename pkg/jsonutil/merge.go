@@ -0,0 +1,146 @@
+package jsonutil
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ArrayStrategy controls how Merge combines two arrays. The zero value is
+// ArrayReplace.
+type ArrayStrategy struct {
+	mode arrayMode
+	key  string
+}
+
+type arrayMode int
+
+const (
+	arrayReplace arrayMode = iota
+	arrayAppend
+	arrayMergeByIndex
+	arrayMergeByKey
+)
+
+var (
+	// ArrayReplace uses the override array as is.
+	ArrayReplace = ArrayStrategy{mode: arrayReplace}
+	// ArrayAppend appends the override elements to the base elements.
+	ArrayAppend = ArrayStrategy{mode: arrayAppend}
+	// ArrayMergeByIndex deep-merges elements at the same index; extra
+	// elements of either array are kept.
+	ArrayMergeByIndex = ArrayStrategy{mode: arrayMergeByIndex}
+)
+
+// ArrayMergeByKey deep-merges each override object into the base object with
+// the same value for key, in place; other override elements are appended.
+// It suits lists of records such as [{"id": 1, ...}, {"id": 2, ...}].
+func ArrayMergeByKey(key string) ArrayStrategy {
+	return ArrayStrategy{mode: arrayMergeByKey, key: key}
+}
+
+// ParseArrayStrategy parses "replace", "append", "merge" or "by-key=KEY"
+// ("" means replace).
+func ParseArrayStrategy(s string) (ArrayStrategy, error) {
+	switch s {
+	case "", "replace":
+		return ArrayReplace, nil
+	case "append":
+		return ArrayAppend, nil
+	case "merge":
+		return ArrayMergeByIndex, nil
+	}
+	if key, ok := strings.CutPrefix(s, "by-key="); ok && key != "" {
+		return ArrayMergeByKey(key), nil
+	}
+	return ArrayStrategy{}, fmt.Errorf("unknown array strategy %q (want replace, append, merge or by-key=KEY)", s)
+}
+
+func (s ArrayStrategy) String() string {
+	switch s.mode {
+	case arrayAppend:
+		return "append"
+	case arrayMergeByIndex:
+		return "merge"
+	case arrayMergeByKey:
+		return "by-key=" + s.key
+	}
+	return "replace"
+}
+
+// Merge deep-merges override into base and returns the result. Objects are
+// merged key by key, arrays according to arrays, and any other override value
+// (including null) replaces the base value. Neither input is modified.
+func Merge(base, override interface{}, arrays ArrayStrategy) interface{} {
+	switch o := override.(type) {
+	case map[string]interface{}:
+		b, _ := base.(map[string]interface{})
+		merged := make(map[string]interface{}, len(b)+len(o))
+		for k, v := range b {
+			merged[k] = v
+		}
+		for k, v := range o {
+			merged[k] = Merge(merged[k], v, arrays)
+		}
+		return merged
+	case []interface{}:
+		b, _ := base.([]interface{})
+		switch arrays.mode {
+		case arrayAppend:
+			merged := make([]interface{}, 0, len(b)+len(o))
+			return append(append(merged, b...), o...)
+		case arrayMergeByIndex:
+			merged := make([]interface{}, len(b))
+			copy(merged, b)
+			for i, v := range o {
+				if i < len(merged) {
+					merged[i] = Merge(merged[i], v, arrays)
+				} else {
+					merged = append(merged, v)
+				}
+			}
+			return merged
+		case arrayMergeByKey:
+			return mergeByKey(b, o, arrays)
+		}
+		return append([]interface{}(nil), o...)
+	}
+	return override
+}
+
+// mergeByKey merges the override elements into the base elements with the
+// same key value.
+func mergeByKey(base, override []interface{}, arrays ArrayStrategy) []interface{} {
+	merged := make([]interface{}, len(base), len(base)+len(override))
+	copy(merged, base)
+	for _, v := range override {
+		i := indexByKey(merged, v, arrays.key)
+		if i < 0 {
+			merged = append(merged, v)
+			continue
+		}
+		merged[i] = Merge(merged[i], v, arrays)
+	}
+	return merged
+}
+
+// indexByKey returns the index of the object in arr whose key equals that of
+// v, or -1.
+func indexByKey(arr []interface{}, v interface{}, key string) int {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return -1
+	}
+	want, ok := obj[key]
+	if !ok {
+		return -1
+	}
+	for i, item := range arr {
+		if m, ok := item.(map[string]interface{}); ok {
+			if got, ok := m[key]; ok && reflect.DeepEqual(got, want) {
+				return i
+			}
+		}
+	}
+	return -1
+}
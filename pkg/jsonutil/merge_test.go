@@ -0,0 +1,66 @@
+package jsonutil
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("Failed to decode %s: %v", s, err)
+	}
+	return v
+}
+
+func TestMerge(t *testing.T) {
+	base := `{"name": "base", "tags": ["a", "b"], "items": [{"id": 1, "qty": 1}], "meta": {"x": 1, "y": 2}}`
+	override := `{"name": "override", "tags": ["c"], "items": [{"qty": 5}, {"id": 2}], "meta": {"y": null, "z": 3}}`
+
+	tests := []struct {
+		strategy string
+		expected string
+	}{
+		{"replace", `{"name": "override", "tags": ["c"], "items": [{"qty": 5}, {"id": 2}], "meta": {"x": 1, "y": null, "z": 3}}`},
+		{"append", `{"name": "override", "tags": ["a", "b", "c"], "items": [{"id": 1, "qty": 1}, {"qty": 5}, {"id": 2}], "meta": {"x": 1, "y": null, "z": 3}}`},
+		{"merge", `{"name": "override", "tags": ["c", "b"], "items": [{"id": 1, "qty": 5}, {"id": 2}], "meta": {"x": 1, "y": null, "z": 3}}`},
+		{"by-key=id", `{"name": "override", "tags": ["a", "b", "c"], "items": [{"id": 1, "qty": 1}, {"qty": 5}, {"id": 2}], "meta": {"x": 1, "y": null, "z": 3}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			strategy, err := ParseArrayStrategy(tt.strategy)
+			if err != nil {
+				t.Fatalf("ParseArrayStrategy failed: %v", err)
+			}
+			if strategy.String() != tt.strategy {
+				t.Errorf("expected %s to round-trip, got %s", tt.strategy, strategy)
+			}
+			b, o := decode(t, base), decode(t, override)
+			got := Merge(b, o, strategy)
+			if want := decode(t, tt.expected); !reflect.DeepEqual(got, want) {
+				t.Errorf("expected %v, got %v", want, got)
+			}
+			if !reflect.DeepEqual(b, decode(t, base)) || !reflect.DeepEqual(o, decode(t, override)) {
+				t.Error("Merge should not modify its inputs")
+			}
+		})
+	}
+
+	for _, s := range []string{"concat", "by-key=", "by-key"} {
+		if _, err := ParseArrayStrategy(s); err == nil {
+			t.Errorf("Unknown array strategy %q should be rejected", s)
+		}
+	}
+}
+
+func TestMergeByKey(t *testing.T) {
+	base := decode(t, `{"servers": [{"name": "a", "port": 80, "tls": {"on": false}}, {"name": "b", "port": 81}, "x"]}`)
+	override := decode(t, `{"servers": [{"name": "b", "port": 8081}, {"name": "c"}, {"name": "a", "tls": {"on": true}}, {"port": 1}, "x"]}`)
+	want := decode(t, `{"servers": [{"name": "a", "port": 80, "tls": {"on": true}}, {"name": "b", "port": 8081}, "x", {"name": "c"}, {"port": 1}, "x"]}`)
+	if got := Merge(base, override, ArrayMergeByKey("name")); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
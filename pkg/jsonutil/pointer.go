@@ -0,0 +1,62 @@
+// Package jsonutil provides helpers for working with decoded JSON values
+// (map[string]interface{}, []interface{} and scalars).
+package jsonutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SplitPointer splits an RFC 6901 JSON Pointer into its unescaped reference tokens.
+// The empty pointer "" refers to the whole document and yields no tokens.
+func SplitPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q: must start with '/'", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, tok := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(tok, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// JoinPointer builds an RFC 6901 JSON Pointer from reference tokens.
+func JoinPointer(tokens ...string) string {
+	var b strings.Builder
+	for _, tok := range tokens {
+		b.WriteByte('/')
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(tok, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+// Get returns the value at pointer in doc.
+func Get(doc interface{}, pointer string) (interface{}, error) {
+	tokens, err := SplitPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	for i, tok := range tokens {
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			child, ok := v[tok]
+			if !ok {
+				return nil, fmt.Errorf("%s: no member %q", JoinPointer(tokens[:i+1]...), tok)
+			}
+			doc = child
+		case []interface{}:
+			index, err := strconv.Atoi(tok)
+			if err != nil || index < 0 || index >= len(v) || tok != strconv.Itoa(index) {
+				return nil, fmt.Errorf("%s: no element %q in an array of %d", JoinPointer(tokens[:i+1]...), tok, len(v))
+			}
+			doc = v[index]
+		default:
+			return nil, fmt.Errorf("%s: parent is not an object or array", JoinPointer(tokens[:i+1]...))
+		}
+	}
+	return doc, nil
+}
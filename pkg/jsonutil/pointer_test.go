@@ -0,0 +1,68 @@
+package jsonutil
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSplitPointer(t *testing.T) {
+	tests := []struct {
+		pointer string
+		want    []string
+	}{
+		{"", nil},
+		{"/", []string{""}},
+		{"/a/b/0", []string{"a", "b", "0"}},
+		{"/a~1b/m~0n", []string{"a/b", "m~n"}},
+	}
+	for _, tt := range tests {
+		got, err := SplitPointer(tt.pointer)
+		if err != nil {
+			t.Fatalf("SplitPointer(%q) failed: %v", tt.pointer, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitPointer(%q): expected %#v, got %#v", tt.pointer, tt.want, got)
+		}
+		if tt.pointer != "" && JoinPointer(got...) != tt.pointer {
+			t.Errorf("JoinPointer should round-trip %q, got %q", tt.pointer, JoinPointer(got...))
+		}
+	}
+
+	if _, err := SplitPointer("a/b"); err == nil {
+		t.Error("Pointer without leading '/' should be rejected")
+	}
+}
+
+func TestGet(t *testing.T) {
+	doc, err := DecodeBytes([]byte(`{"a": {"b/c": [10, {"id": 9007199254740993}]}, "n": null}`))
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	tests := []struct {
+		pointer string
+		want    string
+		wantErr bool
+	}{
+		{"/a/b~1c/0", "10", false},
+		{"/a/b~1c/1/id", "9007199254740993", false},
+		{"/n", "<nil>", false},
+		{"/a/missing", "", true},
+		{"/a/b~1c/2", "", true},
+		{"/a/b~1c/01", "", true},
+		{"/a/b~1c/0/x", "", true},
+		{"a", "", true},
+	}
+	for _, tt := range tests {
+		got, err := Get(doc, tt.pointer)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Get(%q): expected an error, got %v", tt.pointer, got)
+			}
+			continue
+		}
+		if err != nil || fmt.Sprint(got) != tt.want {
+			t.Errorf("Get(%q) = %v, %v; want %s", tt.pointer, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package jsonutil
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Redacted is what Redact replaces sensitive values with.
+const Redacted = "[REDACTED]"
+
+// Redact returns a copy of doc in which the value of every object member
+// whose name matches one of the glob patterns (such as "password" or
+// "*secret*") is replaced with Redacted, at any depth. Names are matched
+// case-insensitively. doc isn't modified.
+func Redact(doc interface{}, patterns []string) (interface{}, error) {
+	lower := make([]string, len(patterns))
+	for i, p := range patterns {
+		lower[i] = strings.ToLower(p)
+		if _, err := path.Match(lower[i], ""); err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q", p)
+		}
+	}
+	return redact(doc, lower), nil
+}
+
+func redact(v interface{}, patterns []string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			if matchesAny(strings.ToLower(k), patterns) {
+				out[k] = Redacted
+			} else {
+				out[k] = redact(child, patterns)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			out[i] = redact(child, patterns)
+		}
+		return out
+	}
+	return v
+}
+
+func matchesAny(name string, patterns []string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
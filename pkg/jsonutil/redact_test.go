@@ -0,0 +1,45 @@
+package jsonutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	doc := decode(t, `{
+		"user": "alice",
+		"Password": "hunter2",
+		"auth": {"token": "abc", "refresh_token": "def", "expires": 3600},
+		"clients": [{"name": "ci", "client_secret": "s1"}, {"name": "web", "apiSecretKey": {"v": "s2"}}],
+		"notes": ["password", "token"]
+	}`)
+	before := decode(t, `{
+		"user": "alice",
+		"Password": "hunter2",
+		"auth": {"token": "abc", "refresh_token": "def", "expires": 3600},
+		"clients": [{"name": "ci", "client_secret": "s1"}, {"name": "web", "apiSecretKey": {"v": "s2"}}],
+		"notes": ["password", "token"]
+	}`)
+
+	got, err := Redact(doc, []string{"password", "token", "*secret*"})
+	if err != nil {
+		t.Fatalf("Redact failed: %v", err)
+	}
+	want := decode(t, `{
+		"user": "alice",
+		"Password": "[REDACTED]",
+		"auth": {"token": "[REDACTED]", "refresh_token": "def", "expires": 3600},
+		"clients": [{"name": "ci", "client_secret": "[REDACTED]"}, {"name": "web", "apiSecretKey": "[REDACTED]"}],
+		"notes": ["password", "token"]
+	}`)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if !reflect.DeepEqual(doc, before) {
+		t.Errorf("Redact modified its input: %v", doc)
+	}
+
+	if _, err := Redact(doc, []string{"[abc"}); err == nil {
+		t.Error("A malformed pattern should be rejected")
+	}
+}
@@ -0,0 +1,201 @@
+// Package limits protects the servers in pkg/httpapi and pkg/grpcapi, which
+// accept arbitrary schemas and templates, against abuse: request size limits,
+// per-client rate limits, render timeouts and a cap on concurrent renders.
+package limits
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// DefaultMaxBodyBytes is the request size limit if Config.MaxBodyBytes is 0.
+const DefaultMaxBodyBytes = 10 << 20
+
+// Config configures the limits of a server. The zero value only limits the
+// request size, to DefaultMaxBodyBytes.
+type Config struct {
+	// MaxBodyBytes limits the size of a request body or message.
+	MaxBodyBytes int64
+
+	// RatePerSecond is the sustained number of requests a client may make per
+	// second, and Burst the number it may make at once (at least 1). A rate
+	// of 0 disables rate limiting.
+	RatePerSecond float64
+	Burst         int
+
+	// RenderTimeout bounds the time a template may render. 0 means no limit.
+	RenderTimeout time.Duration
+
+	// MaxRenders caps the renders running at once, counting those that
+	// timed out but haven't stopped yet; further renders wait for a slot
+	// within their timeout. 0 means no limit.
+	MaxRenders int
+}
+
+// BodyLimit returns MaxBodyBytes or its default.
+func (c Config) BodyLimit() int64 {
+	if c.MaxBodyBytes <= 0 {
+		return DefaultMaxBodyBytes
+	}
+	return c.MaxBodyBytes
+}
+
+// NewRateLimiter returns the rate limiter of c, or nil if rate limiting is
+// disabled.
+func (c Config) NewRateLimiter() *RateLimiter {
+	if c.RatePerSecond <= 0 {
+		return nil
+	}
+	burst := c.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{rate: c.RatePerSecond, burst: float64(burst), clients: map[string]*bucket{}, now: time.Now}
+}
+
+// RateLimiter is a token bucket per client. A nil *RateLimiter allows
+// everything.
+type RateLimiter struct {
+	rate, burst float64
+	now         func() time.Time
+
+	mu        sync.Mutex
+	clients   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// sweepInterval is how often buckets of idle clients are dropped.
+const sweepInterval = time.Minute
+
+// Allow takes a token from client's bucket. If the bucket is empty, it
+// returns false and how long the client should wait for the next token.
+func (l *RateLimiter) Allow(client string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now)
+	}
+	b, ok := l.clients[client]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.clients[client] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep drops the buckets that have refilled completely; those clients start
+// over with a full bucket anyway.
+func (l *RateLimiter) sweep(now time.Time) {
+	for client, b := range l.clients {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.clients, client)
+		}
+	}
+	l.lastSweep = now
+}
+
+// ErrTimeout is returned by Run when the function doesn't finish in time.
+var ErrTimeout = errors.New("timed out")
+
+// Semaphore caps the renders running at once. A nil *Semaphore doesn't.
+type Semaphore struct {
+	slots chan struct{}
+}
+
+// NewSemaphore returns the semaphore of c, or nil if MaxRenders is 0.
+func (c Config) NewSemaphore() *Semaphore {
+	if c.MaxRenders <= 0 {
+		return nil
+	}
+	return &Semaphore{slots: make(chan struct{}, c.MaxRenders)}
+}
+
+// acquire takes a slot, waiting until one is free or ctx is done.
+func (s *Semaphore) acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a slot taken by acquire.
+func (s *Semaphore) release() {
+	if s != nil {
+		<-s.slots
+	}
+}
+
+// Run calls fn with a context that is done when ctx is or after timeout (0
+// means no limit), in one of the slots of sem, and returns its result. If
+// the timeout passes first, or passes while waiting for a slot, it returns
+// ErrTimeout; if ctx is done first, ctx's error. fn must stop soon after
+// its context is done, e.g. by rendering with pongo2.WithContext: Run
+// doesn't wait for it, but it keeps its slot until it returns, so renders
+// that didn't stop in time can't pile up beyond the slots of sem.
+func Run[T any](ctx context.Context, timeout time.Duration, sem *Semaphore, fn func(context.Context) (T, error)) (T, error) {
+	var zero T
+	parent := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	timedOut := func(err error) error {
+		if ctx.Err() != nil && parent.Err() == nil {
+			return ErrTimeout
+		}
+		return err
+	}
+	if err := sem.acquire(ctx); err != nil {
+		return zero, timedOut(err)
+	}
+	if timeout <= 0 {
+		defer sem.release()
+		return fn(ctx)
+	}
+
+	type result struct {
+		v   T
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer sem.release()
+		v, err := fn(ctx)
+		done <- result{v, err}
+	}()
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return r.v, timedOut(r.err)
+		}
+		return r.v, nil
+	case <-ctx.Done():
+		return zero, timedOut(ctx.Err())
+	}
+}
@@ -0,0 +1,124 @@
+package limits
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := Config{RatePerSecond: 2, Burst: 3}.NewRateLimiter()
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("request %d within the burst was refused", i+1)
+		}
+	}
+	ok, wait := l.Allow("a")
+	if ok || wait != 500*time.Millisecond {
+		t.Errorf("expected a refusal with a 500ms wait, got %v %v", ok, wait)
+	}
+	if ok, _ := l.Allow("b"); !ok {
+		t.Error("Clients should have separate buckets")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.Allow("a"); !ok {
+		t.Error("A token should have been refilled after 500ms")
+	}
+	if ok, _ := l.Allow("a"); ok {
+		t.Error("Only one token should have been refilled")
+	}
+
+	now = now.Add(time.Hour)
+	l.Allow("c")
+	if _, ok := l.clients["b"]; ok || len(l.clients) != 1 {
+		t.Errorf("Idle clients should be swept, got %d buckets", len(l.clients))
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	l := Config{}.NewRateLimiter()
+	if l != nil {
+		t.Fatal("A zero rate should disable rate limiting")
+	}
+	for i := 0; i < 100; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatal("A nil limiter should allow everything")
+		}
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	if got := (Config{}).BodyLimit(); got != DefaultMaxBodyBytes {
+		t.Errorf("expected the default limit, got %d", got)
+	}
+	if got := (Config{MaxBodyBytes: 100}).BodyLimit(); got != 100 {
+		t.Errorf("expected 100, got %d", got)
+	}
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	v, err := Run(ctx, time.Second, nil, func(context.Context) (string, error) { return "done", nil })
+	if err != nil || v != "done" {
+		t.Errorf("expected done, got %q %v", v, err)
+	}
+
+	// The function's context is done when the timeout passes, so it can stop.
+	stopped := make(chan struct{})
+	_, err = Run(ctx, 10*time.Millisecond, nil, func(ctx context.Context) (string, error) {
+		defer close(stopped)
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected ErrTimeout, got %v", err)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Error("expected the function's context to be cancelled")
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := Run(canceled, time.Second, nil, func(ctx context.Context) (int, error) { return 0, ctx.Err() }); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	boom := errors.New("boom")
+	if _, err := Run(ctx, 0, nil, func(context.Context) (int, error) { return 0, boom }); err != boom {
+		t.Errorf("expected the function's error without a timeout, got %v", err)
+	}
+}
+
+func TestRunSemaphore(t *testing.T) {
+	ctx := context.Background()
+	sem := Config{MaxRenders: 1}.NewSemaphore()
+	if (Config{}).NewSemaphore() != nil {
+		t.Error("expected no semaphore without MaxRenders")
+	}
+
+	// A function that ignores its context keeps its slot after timing out.
+	release := make(chan struct{})
+	_, err := Run(ctx, 10*time.Millisecond, sem, func(context.Context) (int, error) {
+		<-release
+		return 0, nil
+	})
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected ErrTimeout, got %v", err)
+	}
+	ran := false
+	if _, err := Run(ctx, 10*time.Millisecond, sem, func(context.Context) (int, error) { ran = true; return 0, nil }); !errors.Is(err, ErrTimeout) || ran {
+		t.Errorf("expected ErrTimeout without running while the slot is taken, got %v (ran %v)", err, ran)
+	}
+
+	close(release)
+	if v, err := Run(ctx, time.Second, sem, func(context.Context) (int, error) { return 1, nil }); err != nil || v != 1 {
+		t.Errorf("expected the slot to be free again, got %d %v", v, err)
+	}
+}
@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// DurationBuckets are the upper bounds, in seconds, of latency histograms;
+// they match the Prometheus client defaults.
+var DurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// SizeBuckets are the upper bounds, in bytes, of size histograms.
+var SizeBuckets = []float64{256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20}
+
+// Histogram is a Prometheus histogram. It isn't safe for concurrent use;
+// its owner guards it. The zero value uses DurationBuckets.
+type Histogram struct {
+	Buckets []float64
+
+	counts []uint64 // per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// Observe records a value.
+func (h *Histogram) Observe(v float64) {
+	if h.Buckets == nil {
+		h.Buckets = DurationBuckets
+	}
+	if h.counts == nil {
+		h.counts = make([]uint64, len(h.Buckets))
+	}
+	for i, le := range h.Buckets {
+		if v <= le {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// WriteCounter writes a counter with up to two labels in the Prometheus text
+// exposition format, sorted by label values. Unused label values are "".
+func WriteCounter(w io.Writer, name, help string, labels []string, values map[[2]string]uint64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	keys := make([][2]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i][0] < keys[j][0] || keys[i][0] == keys[j][0] && keys[i][1] < keys[j][1]
+	})
+	for _, k := range keys {
+		var pairs []string
+		for i, label := range labels {
+			pairs = append(pairs, fmt.Sprintf("%s=%q", label, k[i]))
+		}
+		fmt.Fprintf(w, "%s{%s} %d\n", name, strings.Join(pairs, ","), values[k])
+	}
+}
+
+// WriteHistograms writes one histogram per value of label, or, with an empty
+// label, the histogram under "".
+func WriteHistograms(w io.Writer, name, help, label string, hs map[string]*Histogram) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	values := make([]string, 0, len(hs))
+	for v := range hs {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	for _, v := range values {
+		h := hs[v]
+		pair := ""
+		if label != "" {
+			pair = fmt.Sprintf("%s=%q", label, v)
+		}
+		with := func(extra string) string {
+			if pair == "" || extra == "" {
+				return "{" + pair + extra + "}"
+			}
+			return "{" + pair + "," + extra + "}"
+		}
+		var cumulative uint64
+		for i, le := range h.Buckets {
+			if i < len(h.counts) {
+				cumulative += h.counts[i]
+			}
+			fmt.Fprintf(w, "%s_bucket%s %d\n", name, with(fmt.Sprintf("le=\"%g\"", le)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", name, with(`le="+Inf"`), h.count)
+		sumLabels, countLabels := with(""), with("")
+		if pair == "" {
+			sumLabels, countLabels = "", ""
+		}
+		fmt.Fprintf(w, "%s_sum%s %g\n", name, sumLabels, h.sum)
+		fmt.Fprintf(w, "%s_count%s %d\n", name, countLabels, h.count)
+	}
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHistogram(t *testing.T) {
+	var h Histogram
+	for _, seconds := range []float64{0.001, 0.005, 0.3, 20} {
+		h.Observe(seconds)
+	}
+	var b strings.Builder
+	WriteHistograms(&b, "x", "help", "l", map[string]*Histogram{"v": &h})
+	WriteHistograms(&b, "y", "help", "", map[string]*Histogram{"": {Buckets: SizeBuckets}})
+	for _, want := range []string{
+		`x_bucket{l="v",le="0.005"} 2`,
+		`x_bucket{l="v",le="0.25"} 2`,
+		`x_bucket{l="v",le="0.5"} 3`,
+		`x_bucket{l="v",le="10"} 3`,
+		`x_bucket{l="v",le="+Inf"} 4`,
+		`x_sum{l="v"} 20.306`,
+		`x_count{l="v"} 4`,
+		`y_bucket{le="256"} 0`,
+		`y_bucket{le="+Inf"} 0`,
+		`y_sum 0`,
+		`y_count 0`,
+	} {
+		if !strings.Contains(b.String(), want+"\n") {
+			t.Errorf("expected %q in:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestWriteCounter(t *testing.T) {
+	var b strings.Builder
+	WriteCounter(&b, "c", "Things.", []string{"a", "b"}, map[[2]string]uint64{
+		{"y", "1"}: 3,
+		{"x", "2"}: 1,
+		{"x", "1"}: 2,
+	})
+	want := "# HELP c Things.\n# TYPE c counter\n" +
+		"c{a=\"x\",b=\"1\"} 2\nc{a=\"x\",b=\"2\"} 1\nc{a=\"y\",b=\"1\"} 3\n"
+	if b.String() != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, b.String())
+	}
+}
@@ -0,0 +1,110 @@
+// Package metrics lets programs that embed the renderer and pipeline observe
+// them: set a Recorder in pongo2.Options or pipeline.Pipeline and every
+// validation and render is reported to it. Prometheus is a Recorder that
+// serves the figures in the Prometheus text format; the helpers it is built
+// from also serve the HTTP API's own metrics.
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Validation results.
+const (
+	ResultValid   = "valid"
+	ResultInvalid = "invalid"
+	ResultError   = "error" // the document couldn't be validated
+)
+
+// Recorder receives measurements. Implementations must be safe for
+// concurrent use.
+type Recorder interface {
+	// ObserveValidation records a validation with its result (ResultValid,
+	// ResultInvalid or ResultError) and duration.
+	ObserveValidation(result string, d time.Duration)
+
+	// ObserveRender records a render of a compiled template: its duration,
+	// the size of its output in bytes and its error, if any.
+	ObserveRender(d time.Duration, outputBytes int, err error)
+}
+
+// Prometheus is a Recorder that keeps counters and histograms and writes
+// them in the Prometheus text exposition format. Create it with
+// NewPrometheus and serve it as an http.Handler, e.g. on /metrics.
+type Prometheus struct {
+	namespace string
+
+	mu                  sync.Mutex
+	validations         map[[2]string]uint64 // by result
+	validationDurations *Histogram
+	renders             map[[2]string]uint64 // by result: ok or error
+	renderDurations     *Histogram
+	outputSizes         *Histogram
+	errors              map[[2]string]uint64 // by operation: validate or render
+}
+
+// NewPrometheus returns a Prometheus recorder whose metric names start with
+// namespace ("godemo" if empty), e.g. godemo_renders_total.
+func NewPrometheus(namespace string) *Prometheus {
+	if namespace == "" {
+		namespace = "godemo"
+	}
+	return &Prometheus{
+		namespace:           namespace,
+		validations:         map[[2]string]uint64{},
+		validationDurations: &Histogram{Buckets: DurationBuckets},
+		renders:             map[[2]string]uint64{},
+		renderDurations:     &Histogram{Buckets: DurationBuckets},
+		outputSizes:         &Histogram{Buckets: SizeBuckets},
+		errors:              map[[2]string]uint64{},
+	}
+}
+
+// ObserveValidation implements Recorder.
+func (p *Prometheus) ObserveValidation(result string, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.validations[[2]string{result}]++
+	p.validationDurations.Observe(d.Seconds())
+	if result == ResultError {
+		p.errors[[2]string{"validate"}]++
+	}
+}
+
+// ObserveRender implements Recorder.
+func (p *Prometheus) ObserveRender(d time.Duration, outputBytes int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result := "ok"
+	if err != nil {
+		result = "error"
+		p.errors[[2]string{"render"}]++
+	}
+	p.renders[[2]string{result}]++
+	p.renderDurations.Observe(d.Seconds())
+	if err == nil {
+		p.outputSizes.Observe(float64(outputBytes))
+	}
+}
+
+// Write writes the metrics in the Prometheus text exposition format.
+func (p *Prometheus) Write(w io.Writer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	name := func(s string) string { return p.namespace + "_" + s }
+	WriteCounter(w, name("validations_total"), "Validated documents by result: valid, invalid or error.", []string{"result"}, p.validations)
+	WriteHistograms(w, name("validation_duration_seconds"), "Validation latencies.", "", map[string]*Histogram{"": p.validationDurations})
+	WriteCounter(w, name("renders_total"), "Template renders by result.", []string{"result"}, p.renders)
+	WriteHistograms(w, name("render_duration_seconds"), "Template render latencies.", "", map[string]*Histogram{"": p.renderDurations})
+	WriteHistograms(w, name("render_output_bytes"), "Sizes of rendered output.", "", map[string]*Histogram{"": p.outputSizes})
+	WriteCounter(w, name("errors_total"), "Failed validations and renders by operation.", []string{"operation"}, p.errors)
+}
+
+// ServeHTTP serves the metrics.
+func (p *Prometheus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	p.Write(w)
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheus(t *testing.T) {
+	p := NewPrometheus("")
+	p.ObserveValidation(ResultValid, 2*time.Millisecond)
+	p.ObserveValidation(ResultInvalid, 3*time.Millisecond)
+	p.ObserveValidation(ResultError, time.Millisecond)
+	p.ObserveRender(20*time.Millisecond, 300, nil)
+	p.ObserveRender(time.Millisecond, 0, errors.New("boom"))
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("expected a text content type, got %q", ct)
+	}
+	body, _ := io.ReadAll(rec.Body)
+	for _, want := range []string{
+		`godemo_validations_total{result="error"} 1`,
+		`godemo_validations_total{result="invalid"} 1`,
+		`godemo_validations_total{result="valid"} 1`,
+		`godemo_validation_duration_seconds_count 3`,
+		`godemo_renders_total{result="error"} 1`,
+		`godemo_renders_total{result="ok"} 1`,
+		`godemo_render_duration_seconds_bucket{le="0.025"} 2`,
+		`godemo_render_output_bytes_bucket{le="256"} 0`,
+		`godemo_render_output_bytes_bucket{le="1024"} 1`,
+		`godemo_render_output_bytes_sum 300`,
+		`godemo_errors_total{operation="render"} 1`,
+		`godemo_errors_total{operation="validate"} 1`,
+	} {
+		if !strings.Contains(string(body), want+"\n") {
+			t.Errorf("expected %q in:\n%s", want, body)
+		}
+	}
+
+	var b strings.Builder
+	NewPrometheus("app").Write(&b)
+	if !strings.Contains(b.String(), "# TYPE app_renders_total counter\n") {
+		t.Errorf("expected the namespace to prefix the names, got:\n%s", b.String())
+	}
+}
@@ -0,0 +1,40 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go-demo/pkg/audit"
+	"go-demo/pkg/errcode"
+)
+
+// audit writes the record of a call of Process to the Audit sink. The record
+// is written even if ctx is done, so that canceled documents are accounted
+// for too.
+func (p Pipeline) audit(ctx context.Context, data, out []byte, report Report, err error) error {
+	r := &audit.Record{
+		Time:       time.Now().UTC(),
+		Document:   audit.DocumentFrom(ctx),
+		Type:       p.Name,
+		Input:      audit.Hash(data),
+		Defaults:   report.Defaults,
+		Outcome:    audit.OutcomeGenerated,
+		Violations: report.Violations,
+	}
+	if p.Schema != nil {
+		r.Schema = &audit.Schema{Location: p.Schema.Location, Version: p.SchemaVersion}
+	}
+	if p.Template != "" {
+		r.Template = &audit.Template{Name: p.TemplateName, Version: audit.Hash([]byte(p.Template))}
+	}
+	switch {
+	case errors.Is(err, ErrInvalid):
+		r.Outcome = audit.OutcomeInvalid
+	case err != nil:
+		r.Outcome, r.Error, r.Code = audit.OutcomeFailed, err.Error(), errcode.Of(err)
+	default:
+		r.Output = audit.Hash(out)
+	}
+	return p.Audit.Write(context.WithoutCancel(ctx), r)
+}
@@ -0,0 +1,114 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"go-demo/pkg/audit"
+	"go-demo/pkg/errcode"
+	schemautil "go-demo/pkg/jsonschema"
+)
+
+// recorder is an audit sink that keeps the records in memory.
+type recorder struct {
+	mu      sync.Mutex
+	records []audit.Record
+}
+
+func (r *recorder) Write(_ context.Context, rec *audit.Record) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, *rec)
+	return nil
+}
+
+func TestProcessAudit(t *testing.T) {
+	schema, err := schemautil.CompileString(orderSchema)
+	if err != nil {
+		t.Fatalf("Failed to compile schema: %v", err)
+	}
+	var sink recorder
+	p := Pipeline{Schema: schema, SchemaVersion: "v3", Template: "{{ id }} {{ currency }}", TemplateName: "order.txt", Name: "order", Audit: &sink}
+
+	ctx := audit.WithDocument(context.Background(), "orders/1.json")
+	out, _, err := p.Process(ctx, []byte(`{"id": 1}`))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	p.Process(context.Background(), []byte(`{"currency": "USD"}`))
+	p.Process(context.Background(), []byte(`{"id": `))
+
+	if len(sink.records) != 3 {
+		t.Fatalf("expected a record per call, got %d", len(sink.records))
+	}
+	generated, invalid, failed := sink.records[0], sink.records[1], sink.records[2]
+	if generated.Document != "orders/1.json" || generated.Type != "order" || generated.Outcome != audit.OutcomeGenerated {
+		t.Errorf("expected the document, type and outcome, got %+v", generated)
+	}
+	if generated.Input != audit.Hash([]byte(`{"id": 1}`)) || generated.Output != audit.Hash(out) || generated.Time.IsZero() {
+		t.Errorf("expected the input and output hashes, got %+v", generated)
+	}
+	if *generated.Schema != (audit.Schema{Location: schema.Location, Version: "v3"}) {
+		t.Errorf("expected the schema and its version, got %+v", generated.Schema)
+	}
+	if *generated.Template != (audit.Template{Name: "order.txt", Version: audit.Hash([]byte(p.Template))}) {
+		t.Errorf("expected the template and its hash, got %+v", generated.Template)
+	}
+	if len(generated.Defaults) != 1 || generated.Defaults[0] != (schemautil.AppliedDefault{Pointer: "/currency", Value: "EUR", Location: schema.Location + "/properties/currency"}) {
+		t.Errorf("expected the applied default, got %v", generated.Defaults)
+	}
+	if invalid.Outcome != audit.OutcomeInvalid || len(invalid.Violations) != 1 || invalid.Output != "" || invalid.Document != "" {
+		t.Errorf("expected an invalid record with its violation, got %+v", invalid)
+	}
+	if failed.Outcome != audit.OutcomeFailed || failed.Code != errcode.Decode || failed.Error == "" || failed.Output != "" {
+		t.Errorf("expected a failed record with its error code, got %+v", failed)
+	}
+
+	// Without its record, a document isn't generated.
+	p.Audit = audit.SinkFunc(func(context.Context, *audit.Record) error { return errors.New("disk full") })
+	if out, _, err := p.Process(context.Background(), []byte(`{"id": 1}`)); err == nil || !strings.Contains(err.Error(), "audit: disk full") || out != nil {
+		t.Errorf("expected the audit error, got %q, %v", out, err)
+	}
+}
+
+func TestLoadRegistryAudit(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"types.yaml":   "types:\n  invoice:\n    schema: invoice.json\n",
+		"invoice.json": `{"type": "object", "properties": {"address": {"$ref": "address.json"}}}`,
+		"address.json": `{"type": "string"}`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	load := func() Pipeline {
+		t.Helper()
+		r, err := LoadRegistry(filepath.Join(dir, "types.yaml"), Pipeline{Audit: &recorder{}})
+		if err != nil {
+			t.Fatalf("LoadRegistry failed: %v", err)
+		}
+		p, _ := r.Lookup("invoice")
+		return p
+	}
+
+	p := load()
+	if p.Name != "invoice" || !strings.HasPrefix(p.SchemaVersion, "sha256:") {
+		t.Fatalf("expected the type name and a schema version, got %q, %q", p.Name, p.SchemaVersion)
+	}
+	if again := load(); again.SchemaVersion != p.SchemaVersion {
+		t.Errorf("expected the same version for the same schema, got %s and %s", p.SchemaVersion, again.SchemaVersion)
+	}
+	// The version covers referenced schemas.
+	if err := os.WriteFile(filepath.Join(dir, "address.json"), []byte(`{"type": "object"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if changed := load(); changed.SchemaVersion == p.SchemaVersion {
+		t.Error("expected a new version after a referenced schema changed")
+	}
+}
@@ -0,0 +1,273 @@
+// Package pipeline turns a JSON document into output in one call: it decodes
+// the document, applies the defaults of a schema, validates it and renders a
+// template with it, reporting what each step did.
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"go-demo/pkg/audit"
+	"go-demo/pkg/cache"
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/jsonutil"
+	"go-demo/pkg/metrics"
+	"go-demo/pkg/pongo2"
+)
+
+// Span attributes.
+const (
+	attrDocumentSize    = "document.size"
+	attrOutputSize      = "output.size"
+	attrSchemaID        = "schema.id"
+	attrTemplateName    = "template.name"
+	attrDefaultsCount   = "defaults.count"
+	attrViolationsCount = "violations.count"
+)
+
+// ErrInvalid is matched by the error of Process when the document fails its
+// schema; the report lists the violations.
+var ErrInvalid = schemautil.ErrInvalid
+
+// Pipeline processes documents against a schema and a template. The zero
+// value decodes and re-encodes documents.
+type Pipeline struct {
+	// Schema supplies the defaults and validates the document. Nil skips
+	// both steps.
+	Schema *jsonschema.Schema
+
+	// Defaults are the options the defaults of Schema are applied with,
+	// such as FillRequired() of go-demo/pkg/jsonschema. Nil applies them as
+	// ApplyDefaults does without options.
+	Defaults []schemautil.DefaultsOption
+
+	// Template is the source of the template rendered with the document as
+	// context. When empty, the output is the document, encoded as JSON.
+	Template string
+
+	// Options configures the renderer.
+	Options pongo2.Options
+
+	// Cache keeps the template compiled between calls. Nil compiles it on
+	// every call.
+	Cache *cache.Cache
+
+	// TemplateName names the template in traces.
+	TemplateName string
+
+	// TracerProvider creates the spans of Process. Nil uses the global
+	// provider, otel.GetTracerProvider().
+	TracerProvider trace.TracerProvider
+
+	// Logger receives the branches defaults were taken from and the number
+	// of defaults applied at debug level, and invalid documents at info
+	// level. It is also the renderer's logger unless Options has one. Nil
+	// disables logging.
+	Logger *slog.Logger
+
+	// Metrics receives the result and duration of every validation. It is
+	// also the renderer's recorder unless Options has one. Nil disables it.
+	Metrics metrics.Recorder
+
+	// Audit receives a record of every document processed (see package
+	// audit). Nil disables auditing.
+	Audit audit.Sink
+
+	// Name is the document type in audit records. Registry.Register sets it.
+	Name string
+
+	// SchemaVersion identifies the revision of Schema in audit records.
+	// LoadRegistry sets it to the hash of the schema's bundle when base has
+	// an Audit sink.
+	SchemaVersion string
+}
+
+// Report describes what Process did to a document.
+type Report struct {
+	// Defaults lists the defaults filled in, sorted by pointer.
+	Defaults []schemautil.AppliedDefault `json:"defaults"`
+
+	// Violations lists the ways the document, with its defaults, fails the
+	// schema.
+	Violations []schemautil.Violation `json:"violations"`
+}
+
+// Valid reports whether the document passed validation.
+func (r Report) Valid() bool {
+	return len(r.Violations) == 0
+}
+
+// Process decodes data as JSON, keeping numbers exact, applies the schema's
+// defaults, validates the result and renders the template with it. It
+// returns a *ValidationError, which matches ErrInvalid, with the violations
+// also in the report, if the document fails validation, and ctx's error as
+// soon as ctx is done, even partway through applying defaults or rendering.
+// Validation can't be interrupted: ctx is only checked before and after it
+// (see schemautil.ValidateCtx).
+//
+// Each call is traced as a pipeline.Process span with a child span per step,
+// so a trace shows where the time goes. With an Audit sink, each call is also
+// recorded there; if the record can't be written, Process fails.
+func (p Pipeline) Process(ctx context.Context, data []byte) ([]byte, Report, error) {
+	out, report, err := p.process(ctx, data)
+	if p.Audit != nil {
+		if auditErr := p.audit(ctx, data, out, report, err); auditErr != nil && err == nil {
+			return nil, report, fmt.Errorf("audit: %w", auditErr)
+		}
+	}
+	return out, report, err
+}
+
+func (p Pipeline) process(ctx context.Context, data []byte) (_ []byte, report Report, err error) {
+	start := time.Now()
+	report = Report{Defaults: []schemautil.AppliedDefault{}, Violations: []schemautil.Violation{}}
+	tracer := p.tracer()
+	ctx, span := tracer.Start(ctx, "pipeline.Process", trace.WithAttributes(attribute.Int(attrDocumentSize, len(data))))
+	defer func() { endSpan(span, err) }()
+
+	_, decodeSpan := tracer.Start(ctx, "pipeline.decode", trace.WithAttributes(attribute.Int(attrDocumentSize, len(data))))
+	doc, err := jsonutil.DecodeBytes(data)
+	endSpan(decodeSpan, err)
+	if err != nil {
+		return nil, report, fmt.Errorf("decode: %w", err)
+	}
+
+	if p.Schema != nil {
+		schemaID := attribute.String(attrSchemaID, p.Schema.Location)
+		_, defaultsSpan := tracer.Start(ctx, "pipeline.apply_defaults", trace.WithAttributes(schemaID))
+		var explanation *schemautil.Explanation
+		doc, explanation, err = schemautil.ApplyDefaultsExplainCtx(ctx, doc, p.Schema, p.Defaults...)
+		if err != nil {
+			endSpan(defaultsSpan, err)
+			return nil, report, err
+		}
+		for _, b := range explanation.Branches {
+			p.log(ctx, slog.LevelDebug, "branch selected",
+				"pointer", b.Pointer, "keyword", b.Keyword, "selected", b.Selected, "matched", b.Matched)
+		}
+		report.Defaults = explanation.Defaults
+		defaultsSpan.SetAttributes(attribute.Int(attrDefaultsCount, len(report.Defaults)))
+		endSpan(defaultsSpan, nil)
+		p.log(ctx, slog.LevelDebug, "defaults applied", "schema", p.Schema.Location, "count", len(report.Defaults))
+
+		_, validateSpan := tracer.Start(ctx, "pipeline.validate", trace.WithAttributes(schemaID))
+		validateStart := time.Now()
+		// This runs to its end even if ctx is done meanwhile.
+		violations, err := schemautil.ValidateCtx(ctx, p.Schema, doc)
+		validateSpan.SetAttributes(attribute.Int(attrViolationsCount, len(violations)))
+		endSpan(validateSpan, err)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, report, ctxErr
+		}
+		p.observeValidation(validateStart, len(violations), err)
+		if err != nil {
+			return nil, report, fmt.Errorf("validate: %w", err)
+		}
+		if len(violations) > 0 {
+			report.Violations = violations
+			p.log(ctx, slog.LevelInfo, "document invalid", "schema", p.Schema.Location,
+				"violations", len(violations), "first", violations[0].InstanceLocation+": "+violations[0].Message)
+			return nil, report, &ValidationError{Violations: violations}
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, report, err
+	}
+	var out []byte
+	if p.Template == "" {
+		if out, err = jsonutil.Marshal(jsonutil.FormatJSON, doc, 2); err != nil {
+			return nil, report, fmt.Errorf("encode: %w", err)
+		}
+	} else {
+		rendered, err := p.render(ctx, doc)
+		if err != nil {
+			return nil, report, err
+		}
+		out = []byte(rendered)
+	}
+	span.SetAttributes(attribute.Int(attrOutputSize, len(out)))
+	p.log(ctx, slog.LevelDebug, "document processed", "bytes", len(data), "duration", time.Since(start))
+	return out, report, nil
+}
+
+func (p Pipeline) log(ctx context.Context, level slog.Level, msg string, args ...interface{}) {
+	if p.Logger != nil {
+		p.Logger.Log(ctx, level, msg, args...)
+	}
+}
+
+func (p Pipeline) observeValidation(start time.Time, violations int, err error) {
+	if p.Metrics == nil {
+		return
+	}
+	result := metrics.ResultValid
+	switch {
+	case err != nil:
+		result = metrics.ResultError
+	case violations > 0:
+		result = metrics.ResultInvalid
+	}
+	p.Metrics.ObserveValidation(result, time.Since(start))
+}
+
+// render renders the template with doc, giving up when ctx is done.
+func (p Pipeline) render(ctx context.Context, doc interface{}) (output string, err error) {
+	name := p.TemplateName
+	if name == "" {
+		name = "<string>"
+	}
+	_, span := p.tracer().Start(ctx, "pipeline.render", trace.WithAttributes(attribute.String(attrTemplateName, name)))
+	defer func() {
+		span.SetAttributes(attribute.Int(attrOutputSize, len(output)))
+		endSpan(span, err)
+	}()
+
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("render: the document must be an object to be used as a template context")
+	}
+	opts := p.Options
+	if opts.Logger == nil {
+		opts.Logger = p.Logger
+	}
+	if opts.Metrics == nil {
+		opts.Metrics = p.Metrics
+	}
+	t, err := p.Cache.Template(opts, p.Template)
+	if err == nil {
+		output, err = t.RenderContext(ctx, obj)
+	}
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+		return "", fmt.Errorf("render: %w", err)
+	}
+	return output, nil
+}
+
+func (p Pipeline) tracer() trace.Tracer {
+	provider := p.TracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer("go-demo/pkg/pipeline")
+}
+
+// endSpan ends span, marking it failed with err if not nil.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
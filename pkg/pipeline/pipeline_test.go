@@ -0,0 +1,333 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"go-demo/pkg/cache"
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/metrics"
+)
+
+const orderSchema = `{
+	"type": "object",
+	"properties": {
+		"id": {"type": "integer"},
+		"currency": {"type": "string", "default": "EUR"},
+		"items": {"type": "array", "items": {"type": "object", "properties": {"qty": {"type": "integer", "minimum": 1, "default": 1}}}}
+	},
+	"required": ["id"]
+}`
+
+func TestProcess(t *testing.T) {
+	schema, err := schemautil.CompileString(orderSchema)
+	if err != nil {
+		t.Fatalf("Failed to compile schema: %v", err)
+	}
+	p := Pipeline{
+		Schema:   schema,
+		Template: "{{ id }} {{ currency }}{% for item in items %} {{ item.qty }}{% endfor %}",
+	}
+
+	tests := []struct {
+		name       string
+		data       string
+		output     string
+		defaults   []schemautil.AppliedDefault
+		violations int
+		err        error
+	}{
+		{
+			name:   "defaults",
+			data:   `{"id": 9007199254740993, "items": [{}, {"qty": 2}]}`,
+			output: "9007199254740993 EUR 1 2",
+			defaults: []schemautil.AppliedDefault{
+				{Pointer: "/currency", Value: "EUR", Location: schema.Location + "/properties/currency"},
+				{Pointer: "/items/0/qty", Value: json.Number("1"), Location: schema.Location + "/properties/items/items/properties/qty"},
+			},
+		},
+		{
+			name:     "no defaults",
+			data:     `{"id": 1, "currency": "USD", "items": []}`,
+			output:   "1 USD",
+			defaults: []schemautil.AppliedDefault{},
+		},
+		{
+			name:       "invalid",
+			data:       `{"items": [{"qty": 0}]}`,
+			defaults:   []schemautil.AppliedDefault{{Pointer: "/currency", Value: "EUR", Location: schema.Location + "/properties/currency"}},
+			violations: 2,
+			err:        ErrInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, report, err := p.Process(context.Background(), []byte(tt.data))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+			if string(out) != tt.output {
+				t.Errorf("expected output %q, got %q", tt.output, out)
+			}
+			if !reflect.DeepEqual(report.Defaults, tt.defaults) {
+				t.Errorf("expected defaults %v, got %v", tt.defaults, report.Defaults)
+			}
+			if len(report.Violations) != tt.violations || report.Valid() != (tt.violations == 0) {
+				t.Errorf("expected %d violations, got %v", tt.violations, report.Violations)
+			}
+		})
+	}
+}
+
+func TestProcessWithoutTemplate(t *testing.T) {
+	schema, err := schemautil.CompileString(orderSchema)
+	if err != nil {
+		t.Fatalf("Failed to compile schema: %v", err)
+	}
+	out, _, err := Pipeline{Schema: schema}.Process(context.Background(), []byte(`{"id": 1}`))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if want := "{\n  \"currency\": \"EUR\",\n  \"id\": 1\n}\n"; string(out) != want {
+		t.Errorf("expected %q, got %q", want, out)
+	}
+}
+
+func TestProcessDefaultsOptions(t *testing.T) {
+	schema, err := schemautil.CompileString(orderSchema)
+	if err != nil {
+		t.Fatalf("Failed to compile schema: %v", err)
+	}
+	p := Pipeline{Schema: schema, Defaults: []schemautil.DefaultsOption{schemautil.WithEmptyPolicy(schemautil.KeepEmpty)}}
+	out, report, err := p.Process(context.Background(), []byte(`{"id": 1}`))
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if want := "{\n  \"currency\": \"EUR\",\n  \"id\": 1,\n  \"items\": []\n}\n"; string(out) != want {
+		t.Errorf("expected %q, got %q", want, out)
+	}
+	if len(report.Defaults) != 2 {
+		t.Errorf("expected the options in the explanation too, got %v", report.Defaults)
+	}
+
+	got, err := Defaults(schema, schemautil.WithEmptyPolicy(schemautil.KeepEmpty)).Transform(context.Background(), decode(t, `{"id": 1}`))
+	if want := decode(t, `{"id": 1, "currency": "EUR", "items": []}`); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v (%v)", want, got, err)
+	}
+}
+
+func TestProcessCache(t *testing.T) {
+	c := cache.New(cache.Config{})
+	p := Pipeline{Template: "Hi {{ name }}", Cache: c}
+	for _, name := range []string{"Ada", "Bob"} {
+		out, _, err := p.Process(context.Background(), []byte(`{"name": "`+name+`"}`))
+		if err != nil {
+			t.Fatalf("Process failed: %v", err)
+		}
+		if string(out) != "Hi "+name {
+			t.Errorf("expected %q, got %q", "Hi "+name, out)
+		}
+	}
+	if stats := c.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("expected the template to be compiled once, got %+v", stats)
+	}
+}
+
+func TestProcessLogging(t *testing.T) {
+	schema, err := schemautil.CompileString(`{
+		"type": "object",
+		"properties": {"payment": {"oneOf": [
+			{"properties": {"kind": {"const": "card"}, "network": {"default": "visa"}}, "required": ["kind"]},
+			{"properties": {"kind": {"const": "bank"}, "country": {"default": "DE"}}, "required": ["kind"]}
+		]}}
+	}`)
+	if err != nil {
+		t.Fatalf("Failed to compile schema: %v", err)
+	}
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	p := Pipeline{Schema: schema, Template: "{{ payment.country }}", Logger: logger}
+
+	if _, _, err := p.Process(context.Background(), []byte(`{"payment": {"kind": "bank"}}`)); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if _, _, err := p.Process(context.Background(), []byte(`{"payment": {"kind": "cash"}}`)); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected ErrInvalid, got %v", err)
+	}
+	for _, want := range []string{
+		`level=DEBUG msg="branch selected" pointer=/payment keyword=oneOf selected=[1] matched=true`,
+		`level=DEBUG msg="defaults applied" schema=`,
+		` count=1`,
+		`level=DEBUG msg="template rendered" template=<string>`,
+		`level=DEBUG msg="document processed" bytes=29`,
+		`level=INFO msg="document invalid" schema=`,
+		` violations=`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected the log to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestProcessMetrics(t *testing.T) {
+	schema, err := schemautil.CompileString(orderSchema)
+	if err != nil {
+		t.Fatalf("Failed to compile schema: %v", err)
+	}
+	m := metrics.NewPrometheus("")
+	p := Pipeline{Schema: schema, Template: "{{ id }}", Metrics: m}
+	if _, _, err := p.Process(context.Background(), []byte(`{"id": 1}`)); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if _, _, err := p.Process(context.Background(), []byte(`{}`)); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected ErrInvalid, got %v", err)
+	}
+
+	var b strings.Builder
+	m.Write(&b)
+	for _, want := range []string{
+		`godemo_validations_total{result="invalid"} 1`,
+		`godemo_validations_total{result="valid"} 1`,
+		`godemo_renders_total{result="ok"} 1`,
+		`godemo_render_output_bytes_sum 1`,
+	} {
+		if !strings.Contains(b.String(), want+"\n") {
+			t.Errorf("expected %q in:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestProcessErrors(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		p    Pipeline
+		ctx  context.Context
+		data string
+	}{
+		{"malformed", Pipeline{}, context.Background(), `{"id": `},
+		{"not an object", Pipeline{Template: "{{ id }}"}, context.Background(), `[1]`},
+		{"template error", Pipeline{Template: "{% if %}"}, context.Background(), `{}`},
+		{"canceled", Pipeline{Template: "{{ id }}"}, canceled, `{"id": 1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, _, err := tt.p.Process(tt.ctx, []byte(tt.data))
+			if err == nil || errors.Is(err, ErrInvalid) {
+				t.Fatalf("expected a processing error, got %v", err)
+			}
+			if out != nil {
+				t.Errorf("expected no output, got %q", out)
+			}
+		})
+	}
+	if _, _, err := (Pipeline{Template: "x"}).Process(canceled, []byte(`{}`)); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestProcessTracing(t *testing.T) {
+	schema, err := schemautil.CompileString(orderSchema)
+	if err != nil {
+		t.Fatalf("Failed to compile schema: %v", err)
+	}
+	recorder := tracetest.NewSpanRecorder()
+	p := Pipeline{
+		Schema:         schema,
+		Template:       "{{ id }}",
+		TemplateName:   "order.txt",
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)),
+	}
+
+	if _, _, err := p.Process(context.Background(), []byte(`{"id": 1}`)); err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	spans := recorder.Ended()
+	var names []string
+	for _, s := range spans {
+		names = append(names, s.Name())
+	}
+	want := []string{"pipeline.decode", "pipeline.apply_defaults", "pipeline.validate", "pipeline.render", "pipeline.Process"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("expected spans %v, got %v", want, names)
+	}
+	root := spans[4]
+	for _, s := range spans[:4] {
+		if s.Parent().SpanID() != root.SpanContext().SpanID() {
+			t.Errorf("%s should be a child of pipeline.Process", s.Name())
+		}
+	}
+	attrs := func(s sdktrace.ReadOnlySpan) map[string]string {
+		m := map[string]string{}
+		for _, kv := range s.Attributes() {
+			m[string(kv.Key)] = kv.Value.Emit()
+		}
+		return m
+	}
+	checks := []struct {
+		span      sdktrace.ReadOnlySpan
+		key, want string
+	}{
+		{root, "document.size", "9"},
+		{root, "output.size", "1"},
+		{spans[1], "schema.id", schema.Location},
+		{spans[1], "defaults.count", "1"},
+		{spans[2], "violations.count", "0"},
+		{spans[3], "template.name", "order.txt"},
+	}
+	for _, c := range checks {
+		if got := attrs(c.span)[c.key]; got != c.want {
+			t.Errorf("%s: expected %s=%q, got %q", c.span.Name(), c.key, c.want, got)
+		}
+	}
+
+	recorder = tracetest.NewSpanRecorder()
+	p.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	if _, _, err := p.Process(context.Background(), []byte(`{}`)); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected ErrInvalid, got %v", err)
+	}
+	spans = recorder.Ended()
+	root = spans[len(spans)-1]
+	if root.Status().Code != codes.Error || attrs(spans[len(spans)-2])["violations.count"] != "1" {
+		t.Errorf("expected a failed root span and a violation count, got %v and %v", root.Status(), attrs(spans[len(spans)-2]))
+	}
+}
+
+func BenchmarkProcess(b *testing.B) {
+	schema, err := schemautil.CompileString(orderSchema)
+	if err != nil {
+		b.Fatal(err)
+	}
+	var data bytes.Buffer
+	data.WriteString(`{"id": 1, "items": [`)
+	for i := 0; i < 100; i++ {
+		if i > 0 {
+			data.WriteString(",")
+		}
+		data.WriteString(`{"sku": "SKU-1"}`)
+	}
+	data.WriteString(`]}`)
+	p := Pipeline{
+		Schema:   schema,
+		Template: "{{ id }} {{ currency }}{% for item in items %} {{ item.sku }}x{{ item.qty }}{% endfor %}",
+		Cache:    cache.New(cache.Config{}),
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := p.Process(context.Background(), data.Bytes()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
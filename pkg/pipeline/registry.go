@@ -0,0 +1,195 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"go-demo/pkg/audit"
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/jsonutil"
+	"go-demo/pkg/pongo2"
+)
+
+// ErrUnknownType is matched by the error of Generate for a document type that
+// isn't registered.
+var ErrUnknownType = errors.New("unknown document type")
+
+// Registry binds document type names, such as "invoice", to the pipelines
+// that produce them: a schema, a template and their options. It is safe for
+// concurrent use.
+type Registry struct {
+	mu    sync.RWMutex
+	types map[string]Pipeline
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{types: map[string]Pipeline{}}
+}
+
+// Register adds a document type. Names must be unique. The pipeline's Name
+// is set to name unless it has one.
+func (r *Registry) Register(name string, p Pipeline) error {
+	if name == "" {
+		return fmt.Errorf("document type without a name")
+	}
+	if p.Name == "" {
+		p.Name = name
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.types[name]; ok {
+		return fmt.Errorf("document type %s is already registered", name)
+	}
+	r.types[name] = p
+	return nil
+}
+
+// Lookup returns the pipeline of a document type.
+func (r *Registry) Lookup(name string) (Pipeline, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.types[name]
+	return p, ok
+}
+
+// Names returns the registered document types, sorted.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.types))
+	for name := range r.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Generate processes a JSON document as the document type name: it applies
+// the type's defaults, validates the document and renders the type's
+// template with it (see Pipeline.Process).
+func (r *Registry) Generate(ctx context.Context, name string, data []byte) ([]byte, Report, error) {
+	p, ok := r.Lookup(name)
+	if !ok {
+		return nil, Report{}, fmt.Errorf("%w %q", ErrUnknownType, name)
+	}
+	return p.Process(ctx, data)
+}
+
+// typeFile is the file format of LoadRegistry.
+type typeFile struct {
+	Types map[string]struct {
+		Schema       string   `json:"schema"`
+		Template     string   `json:"template"`
+		OutputMode   string   `json:"output-mode"`
+		TrimBlocks   bool     `json:"trim-blocks"`
+		LStripBlocks bool     `json:"lstrip-blocks"`
+		TemplateDirs []string `json:"template-dirs"`
+	} `json:"types"`
+}
+
+// LoadRegistry reads document types from a JSON, YAML or TOML file (by
+// extension). Paths are relative to the file; both the schema and the
+// template are optional:
+//
+//	types:
+//	  invoice:
+//	    schema: schemas/invoice.json
+//	    template: templates/invoice.html
+//	    output-mode: html
+//	    trim-blocks: true
+//
+// Templates can include and extend templates in their own directory and in
+// template-dirs. The pipelines start from base, which supplies the fields the
+// file doesn't set, such as the cache, logger, metrics recorder and audit
+// sink.
+func LoadRegistry(path string, base Pipeline) (*Registry, error) {
+	format, err := jsonutil.FormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := jsonutil.Unmarshal(format, b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	// Round-trip through JSON to decode into the typed structure.
+	js, err := jsonutil.Marshal(jsonutil.FormatJSON, doc, 0)
+	if err != nil {
+		return nil, err
+	}
+	var file typeFile
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+	r := NewRegistry()
+	for name, t := range file.Types {
+		p := base
+		p.Options.OutputMode = pongo2.OutputMode(t.OutputMode)
+		p.Options.TrimBlocks = t.TrimBlocks
+		p.Options.LStripBlocks = t.LStripBlocks
+		if t.Schema != "" {
+			if p.Schema, err = schemautil.CompileFile(resolve(t.Schema)); err != nil {
+				return nil, fmt.Errorf("%s: type %s: %w", path, name, err)
+			}
+			if base.Audit != nil {
+				if p.SchemaVersion, err = schemaVersion(resolve(t.Schema)); err != nil {
+					return nil, fmt.Errorf("%s: type %s: %w", path, name, err)
+				}
+			}
+		}
+		var dirs []string
+		if t.Template != "" {
+			src, err := os.ReadFile(resolve(t.Template))
+			if err != nil {
+				return nil, fmt.Errorf("%s: type %s: %w", path, name, err)
+			}
+			p.Template, p.TemplateName = string(src), t.Template
+			dirs = append(dirs, filepath.Dir(resolve(t.Template)))
+		}
+		for _, d := range t.TemplateDirs {
+			dirs = append(dirs, resolve(d))
+		}
+		if len(dirs) > 0 {
+			p.Options.TemplateDirs = append(dirs, base.Options.TemplateDirs...)
+		}
+		if err := r.Register(name, p); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return r, nil
+}
+
+// schemaVersion returns the hash of the bundle of the schema in path, which
+// changes with the schema and with any schema it references.
+func schemaVersion(path string) (string, error) {
+	bundle, err := schemautil.Bundle(path)
+	if err != nil {
+		return "", err
+	}
+	b, err := jsonutil.Marshal(jsonutil.FormatJSON, bundle, 0)
+	if err != nil {
+		return "", err
+	}
+	return audit.Hash(b), nil
+}
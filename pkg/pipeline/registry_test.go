@@ -0,0 +1,100 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-demo/pkg/cache"
+	schemautil "go-demo/pkg/jsonschema"
+)
+
+func TestRegistry(t *testing.T) {
+	schema, err := schemautil.CompileString(orderSchema)
+	if err != nil {
+		t.Fatalf("Failed to compile schema: %v", err)
+	}
+	r := NewRegistry()
+	if err := r.Register("order", Pipeline{Schema: schema, Template: "{{ id }} {{ currency }}"}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := r.Register("echo", Pipeline{}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := r.Register("order", Pipeline{}); err == nil {
+		t.Error("Register should reject a duplicate name")
+	}
+	if names := r.Names(); !reflect.DeepEqual(names, []string{"echo", "order"}) {
+		t.Errorf("unexpected names %v", names)
+	}
+
+	out, _, err := r.Generate(context.Background(), "order", []byte(`{"id": 7}`))
+	if err != nil || string(out) != "7 EUR" {
+		t.Errorf("expected %q, got %q, %v", "7 EUR", out, err)
+	}
+	if _, report, err := r.Generate(context.Background(), "order", []byte(`{}`)); !errors.Is(err, ErrInvalid) || report.Valid() {
+		t.Errorf("expected ErrInvalid with violations, got %v", err)
+	}
+	if _, _, err := r.Generate(context.Background(), "invoice", []byte(`{}`)); !errors.Is(err, ErrUnknownType) {
+		t.Errorf("expected ErrUnknownType, got %v", err)
+	}
+}
+
+func TestLoadRegistry(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"types.yaml": `types:
+  invoice:
+    schema: schemas/invoice.json
+    template: templates/invoice.html
+    output-mode: html
+    trim-blocks: true
+  raw:
+    schema: schemas/invoice.json
+`,
+		"schemas/invoice.json":    `{"type": "object", "properties": {"customer": {"type": "string"}, "currency": {"default": "EUR"}}, "required": ["customer"]}`,
+		"templates/invoice.html":  "{% include \"header.html\" %}\n{{ customer }} {{ currency }}",
+		"templates/header.html":   "<h1>Invoice</h1>",
+		"bad/unknown-field.yaml":  "types:\n  x:\n    schema: a.json\n    colour: red\n",
+		"bad/missing-schema.yaml": "types:\n  x:\n    schema: missing.json\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := cache.New(cache.Config{})
+	r, err := LoadRegistry(filepath.Join(dir, "types.yaml"), Pipeline{Cache: c})
+	if err != nil {
+		t.Fatalf("LoadRegistry failed: %v", err)
+	}
+	out, _, err := r.Generate(context.Background(), "invoice", []byte(`{"customer": "<Ada>"}`))
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if want := "<h1>Invoice</h1>&lt;Ada&gt; EUR"; string(out) != want {
+		t.Errorf("expected %q, got %q", want, out)
+	}
+	out, _, err = r.Generate(context.Background(), "raw", []byte(`{"customer": "Ada"}`))
+	if err != nil || !strings.Contains(string(out), `"currency": "EUR"`) {
+		t.Errorf("expected the document with its defaults, got %q, %v", out, err)
+	}
+	if p, _ := r.Lookup("invoice"); p.Cache != c || p.TemplateName != "templates/invoice.html" {
+		t.Errorf("expected the base cache and the template name, got %+v", p)
+	}
+
+	for _, name := range []string{"bad/unknown-field.yaml", "bad/missing-schema.yaml", "missing.yaml"} {
+		if _, err := LoadRegistry(filepath.Join(dir, name), Pipeline{}); err == nil {
+			t.Errorf("LoadRegistry(%s) should fail", name)
+		}
+	}
+}
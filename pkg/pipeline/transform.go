@@ -0,0 +1,178 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/jsonutil"
+)
+
+// Transform is one step of document processing, taking and returning decoded
+// documents (map[string]interface{}, []interface{} and scalars). Transforms
+// shouldn't modify the document they are given, so the caller still has it
+// if a later step fails.
+type Transform interface {
+	Transform(ctx context.Context, doc interface{}) (interface{}, error)
+}
+
+// TransformFunc adapts a function to the Transform interface.
+type TransformFunc func(ctx context.Context, doc interface{}) (interface{}, error)
+
+// Transform calls f.
+func (f TransformFunc) Transform(ctx context.Context, doc interface{}) (interface{}, error) {
+	return f(ctx, doc)
+}
+
+// Chain returns a transform that runs transforms in order, each on the
+// result of the one before. It stops at the first error, or when ctx is done.
+func Chain(transforms ...Transform) Transform {
+	return TransformFunc(func(ctx context.Context, doc interface{}) (interface{}, error) {
+		for _, t := range transforms {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			var err error
+			if doc, err = t.Transform(ctx, doc); err != nil {
+				return nil, err
+			}
+		}
+		return doc, nil
+	})
+}
+
+// ValidationError is returned by the Validate transform and Process for an
+// invalid document. It matches ErrInvalid with errors.Is.
+type ValidationError = schemautil.ValidationError
+
+// Defaults returns a transform that applies the defaults of schema with opts.
+func Defaults(schema *jsonschema.Schema, opts ...schemautil.DefaultsOption) Transform {
+	return TransformFunc(func(ctx context.Context, doc interface{}) (interface{}, error) {
+		return schemautil.ApplyDefaultsCtx(ctx, doc, schema, opts...)
+	})
+}
+
+// Validate returns a transform that passes valid documents through and fails
+// with a *ValidationError otherwise. It checks ctx only before and after
+// validating (see schemautil.ValidateCtx).
+func Validate(schema *jsonschema.Schema) Transform {
+	return TransformFunc(func(ctx context.Context, doc interface{}) (interface{}, error) {
+		violations, err := schemautil.ValidateCtx(ctx, schema, doc)
+		if err != nil {
+			return nil, err
+		}
+		if len(violations) > 0 {
+			return nil, &ValidationError{Violations: violations}
+		}
+		return doc, nil
+	})
+}
+
+// Redact returns a transform that masks the members whose names match the
+// glob patterns (see jsonutil.Redact).
+func Redact(patterns ...string) Transform {
+	return TransformFunc(func(ctx context.Context, doc interface{}) (interface{}, error) {
+		return jsonutil.Redact(doc, patterns)
+	})
+}
+
+// Merge returns a transform that deep-merges override into the document
+// (see jsonutil.Merge), e.g. to enforce environment-specific settings.
+func Merge(override interface{}, arrays jsonutil.ArrayStrategy) Transform {
+	return TransformFunc(func(ctx context.Context, doc interface{}) (interface{}, error) {
+		return jsonutil.Merge(doc, override, arrays), nil
+	})
+}
+
+// Rename returns a transform that moves the value at the JSON Pointer from to
+// the pointer to, e.g. Rename("/user/mail", "/user/email"). The parent of to
+// must exist; documents without a value at from are passed through. The
+// document isn't modified: the objects and arrays on both paths are copied.
+func Rename(from, to string) Transform {
+	return TransformFunc(func(ctx context.Context, doc interface{}) (interface{}, error) {
+		fromTokens, err := jsonutil.SplitPointer(from)
+		if err != nil {
+			return nil, err
+		}
+		toTokens, err := jsonutil.SplitPointer(to)
+		if err != nil {
+			return nil, err
+		}
+		if len(fromTokens) == 0 || len(toTokens) == 0 {
+			return nil, fmt.Errorf("rename: can't move the whole document")
+		}
+		if strings.HasPrefix(to+"/", from+"/") {
+			return nil, fmt.Errorf("rename: can't move %s into itself", from)
+		}
+		value, err := jsonutil.Get(doc, from)
+		if err != nil {
+			return doc, nil
+		}
+		doc, err = update(doc, fromTokens, nil, true)
+		if err != nil {
+			return nil, err
+		}
+		return update(doc, toTokens, value, false)
+	})
+}
+
+// update returns a copy of doc with the value at tokens set to value, or
+// removed. Only the containers on the path are copied.
+func update(doc interface{}, tokens []string, value interface{}, remove bool) (interface{}, error) {
+	tok := tokens[0]
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v)+1)
+		for k, child := range v {
+			out[k] = child
+		}
+		switch {
+		case len(tokens) > 1:
+			child, ok := v[tok]
+			if !ok {
+				return nil, fmt.Errorf("rename: no member %q", tok)
+			}
+			updated, err := update(child, tokens[1:], value, remove)
+			if err != nil {
+				return nil, err
+			}
+			out[tok] = updated
+		case remove:
+			delete(out, tok)
+		default:
+			out[tok] = value
+		}
+		return out, nil
+	case []interface{}:
+		index, err := strconv.Atoi(tok)
+		if err != nil || index < 0 || index > len(v) || tok != strconv.Itoa(index) {
+			return nil, fmt.Errorf("rename: no element %q in an array of %d", tok, len(v))
+		}
+		if len(tokens) == 1 && !remove {
+			// Like a JSON Patch add: insert before index.
+			out := make([]interface{}, 0, len(v)+1)
+			out = append(append(append(out, v[:index]...), value), v[index:]...)
+			return out, nil
+		}
+		if index == len(v) {
+			return nil, fmt.Errorf("rename: no element %q in an array of %d", tok, len(v))
+		}
+		if len(tokens) == 1 {
+			out := make([]interface{}, 0, len(v)-1)
+			return append(append(out, v[:index]...), v[index+1:]...), nil
+		}
+		out := make([]interface{}, len(v))
+		copy(out, v)
+		updated, err := update(v[index], tokens[1:], value, remove)
+		if err != nil {
+			return nil, err
+		}
+		out[index] = updated
+		return out, nil
+	}
+	return nil, fmt.Errorf("rename: parent of %q is not an object or array", tok)
+}
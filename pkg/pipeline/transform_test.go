@@ -0,0 +1,119 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/jsonutil"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	doc, err := jsonutil.DecodeBytes([]byte(s))
+	if err != nil {
+		t.Fatalf("Failed to decode %s: %v", s, err)
+	}
+	return doc
+}
+
+func TestChain(t *testing.T) {
+	schema, err := schemautil.CompileString(orderSchema)
+	if err != nil {
+		t.Fatalf("Failed to compile schema: %v", err)
+	}
+	var calls []string
+	trace := func(name string) Transform {
+		return TransformFunc(func(ctx context.Context, doc interface{}) (interface{}, error) {
+			calls = append(calls, name)
+			return doc, nil
+		})
+	}
+	chain := Chain(
+		trace("first"),
+		Rename("/order_id", "/id"),
+		Merge(decode(t, `{"currency": "USD", "items": [{"sku": "x"}]}`), jsonutil.ArrayMergeByIndex),
+		Defaults(schema),
+		Validate(schema),
+		Redact("*token*"),
+		trace("last"),
+	)
+
+	input := decode(t, `{"order_id": 1, "items": [{}], "auth_token": "s3cr3t"}`)
+	got, err := chain.Transform(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Transform failed: %v", err)
+	}
+	want := decode(t, `{"id": 1, "currency": "USD", "items": [{"sku": "x", "qty": 1}], "auth_token": "[REDACTED]"}`)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if !reflect.DeepEqual(input, decode(t, `{"order_id": 1, "items": [{}], "auth_token": "s3cr3t"}`)) {
+		t.Errorf("the chain modified its input: %v", input)
+	}
+	if !reflect.DeepEqual(calls, []string{"first", "last"}) {
+		t.Errorf("unexpected calls %v", calls)
+	}
+
+	calls = nil
+	_, err = chain.Transform(context.Background(), decode(t, `{"items": [{"qty": 0}]}`))
+	var ve *ValidationError
+	if !errors.As(err, &ve) || !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+	if len(ve.Violations) != 2 || !strings.Contains(err.Error(), "(and 1 more)") {
+		t.Errorf("unexpected violations %v (%v)", ve.Violations, err)
+	}
+	if !reflect.DeepEqual(calls, []string{"first"}) {
+		t.Errorf("the chain should stop at the failing transform, got calls %v", calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := chain.Transform(ctx, decode(t, `{}`)); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestRename(t *testing.T) {
+	tests := []struct {
+		from, to string
+		doc      string
+		expected string
+		err      bool
+	}{
+		{"/mail", "/email", `{"mail": "a@b.c"}`, `{"email": "a@b.c"}`, false},
+		{"/user/mail", "/contact", `{"user": {"mail": "a@b.c", "id": 1}}`, `{"user": {"id": 1}, "contact": "a@b.c"}`, false},
+		{"/tags/0", "/tags/1", `{"tags": ["a", "b", "c"]}`, `{"tags": ["b", "a", "c"]}`, false},
+		{"/list/1", "/first", `{"list": [1, 2]}`, `{"list": [1], "first": 2}`, false},
+		{"/missing", "/other", `{"a": 1}`, `{"a": 1}`, false},
+		{"/a", "/b/c", `{"a": 1}`, "", true},
+		{"/a", "/a/b", `{"a": {}}`, "", true},
+		{"", "/a", `{}`, "", true},
+		{"a", "/b", `{}`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			doc := decode(t, tt.doc)
+			got, err := Rename(tt.from, tt.to).Transform(context.Background(), doc)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Rename failed: %v", err)
+			}
+			if want := decode(t, tt.expected); !reflect.DeepEqual(got, want) {
+				t.Errorf("expected %v, got %v", want, got)
+			}
+			if !reflect.DeepEqual(doc, decode(t, tt.doc)) {
+				t.Errorf("Rename modified its input: %v", doc)
+			}
+		})
+	}
+}
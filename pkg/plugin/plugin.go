@@ -0,0 +1,246 @@
+// Package plugin extends go-demo with pongo2 filters, schema format
+// validators and CLI subcommands implemented by separate executables, so
+// teams can add their own without recompiling the tool.
+//
+// A plugin is any executable. The host starts it with the argument "serve"
+// and exchanges one JSON object per line over its stdin and stdout:
+//
+//	-> {"id": 1, "method": "describe"}
+//	<- {"id": 1, "result": {"name": "acme", "filters": ["slug"], "formats": ["sku"], "commands": [{"name": "publish", "summary": "..."}]}}
+//	-> {"id": 2, "method": "filter", "name": "slug", "value": "Hello World", "param": null}
+//	<- {"id": 2, "result": "hello-world"}
+//	-> {"id": 3, "method": "format", "name": "sku", "value": "AB-12"}
+//	<- {"id": 3, "result": true}
+//	<- {"id": 4, "error": "..."}
+//
+// Subcommands run in a new process, "PLUGIN command NAME ARGS...", connected
+// to the tool's stdin, stdout and stderr. Plugins written in Go can use Serve
+// to implement the protocol.
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// PathEnv is the environment variable listing the plugin directories,
+// separated like PATH.
+const PathEnv = "GO_DEMO_PLUGIN_PATH"
+
+// Manifest describes what a plugin provides.
+type Manifest struct {
+	Name     string    `json:"name"`
+	Filters  []string  `json:"filters,omitempty"`
+	Formats  []string  `json:"formats,omitempty"`
+	Commands []Command `json:"commands,omitempty"`
+}
+
+// Command is a CLI subcommand provided by a plugin.
+type Command struct {
+	Name    string `json:"name"`
+	Summary string `json:"summary"`
+}
+
+type request struct {
+	ID     int         `json:"id"`
+	Method string      `json:"method"`
+	Name   string      `json:"name,omitempty"`
+	Value  interface{} `json:"value"`
+	Param  interface{} `json:"param"`
+}
+
+type response struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// Plugin is a running plugin process. It is safe for concurrent use; calls
+// are serialized.
+type Plugin struct {
+	Path     string
+	Manifest Manifest
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	nextID int
+}
+
+// Start runs the plugin executable at path and asks it to describe itself.
+// The manifest name defaults to the file name without extension.
+func Start(path string) (*Plugin, error) {
+	cmd := exec.Command(path, "serve")
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", path, err)
+	}
+	p := &Plugin{Path: path, cmd: cmd, stdin: stdin, stdout: bufio.NewReader(stdout)}
+	if err := p.call(request{Method: "describe"}, &p.Manifest); err != nil {
+		p.Close()
+		return nil, err
+	}
+	if p.Manifest.Name == "" {
+		p.Manifest.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return p, nil
+}
+
+// Close stops the plugin process.
+func (p *Plugin) Close() error {
+	p.stdin.Close()
+	return p.cmd.Wait()
+}
+
+// call sends one request and decodes the result into v.
+func (p *Plugin) call(req request, v interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.nextID++
+	req.ID = p.nextID
+	b, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("plugin %s: %w", p.Path, err)
+	}
+	if _, err := p.stdin.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("plugin %s: %w", p.Path, err)
+	}
+	line, err := p.stdout.ReadBytes('\n')
+	if err != nil {
+		if err == io.EOF {
+			err = errors.New("plugin exited")
+		}
+		return fmt.Errorf("plugin %s: %w", p.Path, err)
+	}
+	var resp response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return fmt.Errorf("plugin %s: invalid response: %w", p.Path, err)
+	}
+	if resp.ID != req.ID {
+		return fmt.Errorf("plugin %s: response %d for request %d", p.Path, resp.ID, req.ID)
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	if v == nil || resp.Result == nil {
+		return nil
+	}
+	return json.Unmarshal(resp.Result, v)
+}
+
+// Filter applies the plugin filter name to a value.
+func (p *Plugin) Filter(name string, value, param interface{}) (interface{}, error) {
+	var result interface{}
+	err := p.call(request{Method: "filter", Name: name, Value: value, Param: param}, &result)
+	return result, err
+}
+
+// Format reports whether a value is valid for the plugin format name.
+func (p *Plugin) Format(name string, value interface{}) (bool, error) {
+	var valid bool
+	err := p.call(request{Method: "format", Name: name, Value: value}, &valid)
+	return valid, err
+}
+
+// Filters returns the plugin's filters as pongo2 filter functions, for
+// pongo2.RegisterFilters.
+func (p *Plugin) Filters() map[string]pongo2.FilterFunction {
+	filters := make(map[string]pongo2.FilterFunction, len(p.Manifest.Filters))
+	for _, name := range p.Manifest.Filters {
+		name := name
+		filters[name] = func(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+			result, err := p.Filter(name, in.Interface(), param.Interface())
+			if err != nil {
+				return nil, &pongo2.Error{Sender: "filter:" + name, OrigError: err}
+			}
+			return pongo2.AsValue(result), nil
+		}
+	}
+	return filters
+}
+
+// Formats returns the plugin's format validators, for
+// jsonschema.RegisterFormats. A value is invalid if the plugin fails.
+func (p *Plugin) Formats() map[string]func(interface{}) bool {
+	formats := make(map[string]func(interface{}) bool, len(p.Manifest.Formats))
+	for _, name := range p.Manifest.Formats {
+		name := name
+		formats[name] = func(v interface{}) bool {
+			valid, err := p.Format(name, v)
+			return err == nil && valid
+		}
+	}
+	return formats
+}
+
+// RunCommand runs the plugin subcommand name and returns its exit code.
+func (p *Plugin) RunCommand(name string, args []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	cmd := exec.Command(p.Path, append([]string{"command", name}, args...)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = stdin, stdout, stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("plugin %s: %w", p.Path, err)
+	}
+	return 0, nil
+}
+
+// Discover returns the executables in the given directories, sorted by name
+// within each directory. Missing directories are skipped.
+func Discover(dirs []string) ([]string, error) {
+	var paths []string
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, entry := range entries {
+			// Stat rather than entry.Info so that symlinked plugins count.
+			info, err := os.Stat(filepath.Join(dir, entry.Name()))
+			if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+				continue
+			}
+			names = append(names, entry.Name())
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+	}
+	return paths, nil
+}
+
+// Dirs returns the plugin directories listed in $GO_DEMO_PLUGIN_PATH.
+func Dirs() []string {
+	return filepath.SplitList(os.Getenv(PathEnv))
+}
@@ -0,0 +1,160 @@
+package plugin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// The test binary doubles as a plugin when started with testPluginEnv set.
+const testPluginEnv = "GO_DEMO_TEST_PLUGIN"
+
+var testHandlers = Handlers{
+	Name: "acme",
+	Filters: map[string]func(value, param interface{}) (interface{}, error){
+		"slug": func(value, param interface{}) (interface{}, error) {
+			return strings.ReplaceAll(strings.ToLower(fmt.Sprint(value)), " ", "-"), nil
+		},
+		"fail": func(value, param interface{}) (interface{}, error) {
+			return nil, errors.New("always fails")
+		},
+	},
+	Formats: map[string]func(value interface{}) bool{
+		"sku": func(value interface{}) bool {
+			s, ok := value.(string)
+			return ok && strings.HasPrefix(s, "SKU-")
+		},
+	},
+	Commands: map[string]CommandHandler{
+		"shout": {
+			Summary: "print the arguments in upper case",
+			Run: func(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+				fmt.Fprintln(stdout, strings.ToUpper(strings.Join(args, " ")))
+				if len(args) == 0 {
+					return 3
+				}
+				return 0
+			},
+		},
+	},
+}
+
+func TestMain(m *testing.M) {
+	if os.Getenv(testPluginEnv) == "1" {
+		args := os.Args[1:]
+		for len(args) > 0 && strings.HasPrefix(args[0], "-test.") {
+			args = args[1:]
+		}
+		os.Exit(serve(testHandlers, args, os.Stdin, os.Stdout, os.Stderr))
+	}
+	os.Exit(m.Run())
+}
+
+// startTestPlugin starts the test binary as a plugin.
+func startTestPlugin(t *testing.T) *Plugin {
+	t.Helper()
+	t.Setenv(testPluginEnv, "1")
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("Failed to find the test binary: %v", err)
+	}
+	p, err := Start(exe)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+// pluginRuns counts the runs of TestPlugin. pongo2 can't unregister filters,
+// so each run registers a new name for go test -count.
+var pluginRuns int
+
+func TestPlugin(t *testing.T) {
+	p := startTestPlugin(t)
+	pluginRuns++
+	name := fmt.Sprintf("test_plugin_slug%d", pluginRuns)
+
+	m := p.Manifest
+	if m.Name != "acme" || strings.Join(m.Filters, ",") != "fail,slug" || strings.Join(m.Formats, ",") != "sku" ||
+		len(m.Commands) != 1 || m.Commands[0].Name != "shout" || m.Commands[0].Summary == "" {
+		t.Errorf("unexpected manifest: %+v", m)
+	}
+
+	if result, err := p.Filter("slug", "Hello World", nil); err != nil || result != "hello-world" {
+		t.Errorf("expected hello-world, got %v (%v)", result, err)
+	}
+	if _, err := p.Filter("fail", "x", nil); err == nil || err.Error() != "always fails" {
+		t.Errorf("expected the plugin's error, got %v", err)
+	}
+	if _, err := p.Filter("missing", "x", nil); err == nil {
+		t.Error("Calling an unknown filter should fail")
+	}
+
+	formats := p.Formats()
+	if !formats["sku"]("SKU-1") || formats["sku"]("X-1") || formats["sku"](false) {
+		t.Error("unexpected results from the sku format")
+	}
+
+	if err := pongo2.RegisterFilter(name, p.Filters()["slug"]); err != nil {
+		t.Fatalf("Failed to register filter: %v", err)
+	}
+	tpl, err := pongo2.FromString(`{{ title|` + name + ` }}`)
+	if err != nil {
+		t.Fatalf("Failed to compile template: %v", err)
+	}
+	if output, err := tpl.Execute(pongo2.Context{"title": "Big News"}); err != nil || output != "big-news" {
+		t.Errorf("expected big-news, got %q (%v)", output, err)
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	p := startTestPlugin(t)
+
+	tests := []struct {
+		args     []string
+		wantCode int
+		wantOut  string
+	}{
+		{[]string{"hello", "world"}, 0, "HELLO WORLD\n"},
+		{nil, 3, "\n"},
+	}
+	for _, tt := range tests {
+		var stdout bytes.Buffer
+		code, err := p.RunCommand("shout", tt.args, nil, &stdout, io.Discard)
+		if err != nil {
+			t.Fatalf("RunCommand failed: %v", err)
+		}
+		if code != tt.wantCode || stdout.String() != tt.wantOut {
+			t.Errorf("%v: expected %d %q, got %d %q", tt.args, tt.wantCode, tt.wantOut, code, stdout.String())
+		}
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	for name, mode := range map[string]os.FileMode{"b-plugin": 0o755, "a-plugin": 0o755, "README": 0o644} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, mode); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	paths, err := Discover([]string{dir, filepath.Join(dir, "missing"), ""})
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	want := []string{filepath.Join(dir, "a-plugin"), filepath.Join(dir, "b-plugin")}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, paths)
+	}
+}
@@ -0,0 +1,111 @@
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Handlers implements a plugin in Go.
+type Handlers struct {
+	// Name defaults to the executable's file name.
+	Name string
+
+	Filters  map[string]func(value, param interface{}) (interface{}, error)
+	Formats  map[string]func(value interface{}) bool
+	Commands map[string]CommandHandler
+}
+
+// CommandHandler implements a plugin subcommand. It returns the exit code.
+type CommandHandler struct {
+	Summary string
+	Run     func(args []string, stdin io.Reader, stdout, stderr io.Writer) int
+}
+
+// Serve runs a plugin: it answers requests from the host on stdin when
+// started with "serve", and runs a subcommand when started with
+// "command NAME ARGS...". It exits the process when done.
+func Serve(h Handlers) {
+	os.Exit(serve(h, os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func serve(h Handlers, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	switch {
+	case len(args) == 1 && args[0] == "serve":
+		if err := h.serveRequests(stdin, stdout); err != nil {
+			fmt.Fprintf(stderr, "plugin: %v\n", err)
+			return 1
+		}
+		return 0
+	case len(args) >= 2 && args[0] == "command":
+		cmd, ok := h.Commands[args[1]]
+		if !ok {
+			fmt.Fprintf(stderr, "plugin: unknown command %q\n", args[1])
+			return 2
+		}
+		return cmd.Run(args[2:], stdin, stdout, stderr)
+	}
+	fmt.Fprintln(stderr, "plugin: this program is a go-demo plugin; add its directory to $"+PathEnv)
+	return 2
+}
+
+// serveRequests answers requests until stdin is closed.
+func (h Handlers) serveRequests(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	enc := json.NewEncoder(w)
+	for scanner.Scan() {
+		var req request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			return fmt.Errorf("invalid request: %w", err)
+		}
+		result, err := h.handle(req)
+		resp := response{ID: req.ID}
+		if err == nil {
+			resp.Result, err = json.Marshal(result)
+		}
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (h Handlers) handle(req request) (interface{}, error) {
+	switch req.Method {
+	case "describe":
+		m := Manifest{Name: h.Name}
+		for name := range h.Filters {
+			m.Filters = append(m.Filters, name)
+		}
+		for name := range h.Formats {
+			m.Formats = append(m.Formats, name)
+		}
+		for name, cmd := range h.Commands {
+			m.Commands = append(m.Commands, Command{Name: name, Summary: cmd.Summary})
+		}
+		sort.Strings(m.Filters)
+		sort.Strings(m.Formats)
+		sort.Slice(m.Commands, func(i, j int) bool { return m.Commands[i].Name < m.Commands[j].Name })
+		return m, nil
+	case "filter":
+		filter, ok := h.Filters[req.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown filter %q", req.Name)
+		}
+		return filter(req.Value, req.Param)
+	case "format":
+		format, ok := h.Formats[req.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown format %q", req.Name)
+		}
+		return format(req.Value), nil
+	}
+	return nil, fmt.Errorf("unknown method %q", req.Method)
+}
@@ -0,0 +1,51 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestServeRequests(t *testing.T) {
+	requests := strings.Join([]string{
+		`{"id": 1, "method": "filter", "name": "slug", "value": "A B"}`,
+		`{"id": 2, "method": "format", "name": "sku", "value": "SKU-9"}`,
+		`{"id": 3, "method": "format", "name": "nope", "value": "x"}`,
+		`{"id": 4, "method": "reboot"}`,
+	}, "\n")
+	var stdout, stderr bytes.Buffer
+	if code := serve(testHandlers, []string{"serve"}, strings.NewReader(requests), &stdout, &stderr); code != 0 {
+		t.Fatalf("serve failed with %d: %s", code, stderr.String())
+	}
+
+	want := []response{
+		{ID: 1, Result: json.RawMessage(`"a-b"`)},
+		{ID: 2, Result: json.RawMessage(`true`)},
+		{ID: 3, Error: `unknown format "nope"`},
+		{ID: 4, Error: `unknown method "reboot"`},
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d responses, got:\n%s", len(want), stdout.String())
+	}
+	for i, line := range lines {
+		var got response
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("Invalid response %q: %v", line, err)
+		}
+		if got.ID != want[i].ID || string(got.Result) != string(want[i].Result) || got.Error != want[i].Error {
+			t.Errorf("expected %+v, got %s", want[i], line)
+		}
+	}
+}
+
+func TestServeUsage(t *testing.T) {
+	var stderr bytes.Buffer
+	if code := serve(testHandlers, nil, nil, nil, &stderr); code != 2 || !strings.Contains(stderr.String(), PathEnv) {
+		t.Errorf("expected a usage error, got %d: %s", code, stderr.String())
+	}
+	if code := serve(testHandlers, []string{"command", "nope"}, nil, nil, &stderr); code != 2 {
+		t.Errorf("expected an unknown command error, got %d", code)
+	}
+}
@@ -0,0 +1,291 @@
+package pongo2
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// filterSortByKey sorts a slice of maps (or structs) by a key, comparing
+// integers, floats and strings by value. Prefix the key with "-" for
+// descending order. Items missing the key sort last.
+//
+//	{% for user in users|sort_by_key:"-age" %}
+func filterSortByKey(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	items, err := toSlice(in)
+	if err != nil {
+		return nil, &pongo2.Error{Sender: "filter:sort_by_key", OrigError: err}
+	}
+	key := param.String()
+	descending := strings.HasPrefix(key, "-")
+	key = strings.TrimPrefix(key, "-")
+	if key == "" {
+		return nil, &pongo2.Error{Sender: "filter:sort_by_key", OrigError: fmt.Errorf("missing key parameter")}
+	}
+
+	sorted := make([]interface{}, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, aok := field(sorted[i], key)
+		b, bok := field(sorted[j], key)
+		if !aok || !bok {
+			return aok && !bok
+		}
+		c := compareValues(a, b)
+		if descending {
+			return c > 0
+		}
+		return c < 0
+	})
+	return pongo2.AsValue(sorted), nil
+}
+
+// filterGroupBy groups a slice of maps (or structs) by the string form of a
+// field, producing a map of slices that keeps the input order within groups.
+// Items missing the field are grouped under "".
+//
+//	{% for role, members in users|group_by:"role" sorted %}
+func filterGroupBy(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	items, err := toSlice(in)
+	if err != nil {
+		return nil, &pongo2.Error{Sender: "filter:group_by", OrigError: err}
+	}
+	key := param.String()
+	if key == "" {
+		return nil, &pongo2.Error{Sender: "filter:group_by", OrigError: fmt.Errorf("missing key parameter")}
+	}
+
+	groups := make(map[string]interface{})
+	for _, item := range items {
+		group := ""
+		if v, ok := field(item, key); ok && v != nil {
+			group = fmt.Sprint(v)
+		}
+		members, _ := groups[group].([]interface{})
+		groups[group] = append(members, item)
+	}
+	return pongo2.AsValue(groups), nil
+}
+
+// filterMap extracts a field from every item of a slice of maps (or structs).
+// Items missing the field contribute nil.
+//
+//	{{ users|map:"name"|join:", " }}
+func filterMap(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	items, err := toSlice(in)
+	if err != nil {
+		return nil, &pongo2.Error{Sender: "filter:map", OrigError: err}
+	}
+	key := param.String()
+	if key == "" {
+		return nil, &pongo2.Error{Sender: "filter:map", OrigError: fmt.Errorf("missing key parameter")}
+	}
+
+	values := make([]interface{}, len(items))
+	for i, item := range items {
+		values[i], _ = field(item, key)
+	}
+	return pongo2.AsValue(values), nil
+}
+
+// filterWhere keeps the items of a slice whose field equals a value, compared
+// by string form. Without a value it keeps items whose field is truthy.
+//
+//	{% for order in orders|where:"status,active" %}
+//	{% for user in users|where:"verified" %}
+func filterWhere(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	items, err := toSlice(in)
+	if err != nil {
+		return nil, &pongo2.Error{Sender: "filter:where", OrigError: err}
+	}
+	key, want, hasValue := strings.Cut(param.String(), ",")
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil, &pongo2.Error{Sender: "filter:where", OrigError: fmt.Errorf("missing key parameter")}
+	}
+
+	matches := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		v, ok := field(item, key)
+		if !ok {
+			continue
+		}
+		if hasValue && fmt.Sprint(v) == want || !hasValue && pongo2.AsValue(v).IsTrue() {
+			matches = append(matches, item)
+		}
+	}
+	return pongo2.AsValue(matches), nil
+}
+
+// filterSum adds up a field of every item, or the items themselves without a
+// parameter. Integers are summed exactly and the result stays an int64 unless
+// a float is involved; nil and missing values are skipped.
+//
+//	Total: {{ lines|sum:"amount"|currency:"USD" }}
+func filterSum(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	items, err := toSlice(in)
+	if err != nil {
+		return nil, &pongo2.Error{Sender: "filter:sum", OrigError: err}
+	}
+	key := param.String()
+
+	total := new(big.Rat)
+	isFloat := false
+	for _, item := range items {
+		v := item
+		if key != "" {
+			v, _ = field(item, key)
+		}
+		switch n := v.(type) {
+		case nil:
+			continue
+		case float32, float64:
+			isFloat = true
+		case json.Number:
+			if _, err := n.Int64(); err != nil {
+				isFloat = true
+			}
+		}
+		if valueRank(v) != rankNumber {
+			return nil, &pongo2.Error{Sender: "filter:sum", OrigError: fmt.Errorf("cannot sum %T", v)}
+		}
+		total.Add(total, toRat(v))
+	}
+
+	if isFloat {
+		f, _ := total.Float64()
+		return pongo2.AsValue(f), nil
+	}
+	if !total.Num().IsInt64() {
+		return nil, &pongo2.Error{Sender: "filter:sum", OrigError: fmt.Errorf("sum overflows int64")}
+	}
+	return pongo2.AsValue(total.Num().Int64()), nil
+}
+
+// toSlice converts a slice or array value into []interface{}.
+func toSlice(in *pongo2.Value) ([]interface{}, error) {
+	if in.IsNil() {
+		return nil, nil
+	}
+	v := reflect.ValueOf(in.Interface())
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected a list, got %T", in.Interface())
+	}
+	items := make([]interface{}, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+	return items, nil
+}
+
+// field returns the named field of a map or struct item.
+func field(item interface{}, name string) (interface{}, bool) {
+	v := reflect.ValueOf(item)
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, false
+	}
+	child, ok := attribute(v, name)
+	if !ok || (child.Kind() == reflect.Func) {
+		return nil, false
+	}
+	return child.Interface(), true
+}
+
+// compareValues orders two values: numbers by numeric value (integers compared
+// exactly), strings lexically, booleans false before true. Values of different
+// kinds order as nil < numbers < strings < booleans < anything else.
+func compareValues(a, b interface{}) int {
+	ra, rb := valueRank(a), valueRank(b)
+	if ra != rb {
+		return ra - rb
+	}
+	switch ra {
+	case rankNumber:
+		return toRat(a).Cmp(toRat(b))
+	case rankString:
+		return strings.Compare(a.(string), b.(string))
+	case rankBool:
+		switch {
+		case a.(bool) == b.(bool):
+			return 0
+		case !a.(bool):
+			return -1
+		default:
+			return 1
+		}
+	}
+	return 0
+}
+
+const (
+	rankNil = iota
+	rankNumber
+	rankString
+	rankBool
+	rankOther
+)
+
+func valueRank(v interface{}) int {
+	switch v.(type) {
+	case nil:
+		return rankNil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, json.Number:
+		return rankNumber
+	case string:
+		return rankString
+	case bool:
+		return rankBool
+	}
+	return rankOther
+}
+
+// toRat converts a number to an exact rational, so int64 values beyond 2^53
+// still compare correctly.
+func toRat(v interface{}) *big.Rat {
+	r := new(big.Rat)
+	switch n := v.(type) {
+	case json.Number:
+		if _, ok := r.SetString(n.String()); !ok {
+			return r
+		}
+	case float32:
+		setFloat(r, float64(n))
+	case float64:
+		setFloat(r, n)
+	default:
+		rv := reflect.ValueOf(v)
+		if rv.CanInt() {
+			r.SetInt64(rv.Int())
+		} else {
+			r.SetInt(new(big.Int).SetUint64(rv.Uint()))
+		}
+	}
+	return r
+}
+
+// setFloat sets r to f. Infinities become ±1e400 (beyond any float64) and NaN
+// sorts below every other number.
+func setFloat(r *big.Rat, f float64) {
+	switch {
+	case math.IsInf(f, 1):
+		r.SetString("1e400")
+	case math.IsInf(f, -1):
+		r.SetString("-1e400")
+	case math.IsNaN(f):
+		r.SetString("-1e401")
+	default:
+		r.SetFloat64(f)
+	}
+}
@@ -0,0 +1,119 @@
+package pongo2
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+func executeString(t *testing.T, templateString string, ctx pongo2.Context) string {
+	t.Helper()
+	tpl, err := pongo2.FromString(templateString)
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	output, err := tpl.Execute(ctx)
+	if err != nil {
+		t.Fatalf("Failed to execute template: %v", err)
+	}
+	return output
+}
+
+func TestSortByKeyFilter(t *testing.T) {
+	users := []map[string]interface{}{
+		{"name": "Carol", "age": int64(9007199254740993)},
+		{"name": "Alice", "age": json.Number("30")},
+		{"name": "Dave"},
+		{"name": "Bob", "age": 25.5},
+		{"name": "Eve", "age": int64(9007199254740992)},
+	}
+	ctx := pongo2.Context{"users": users}
+
+	output := executeString(t, `{% for u in users|sort_by_key:"age" %}{{ u.name }} {% endfor %}`, ctx)
+	if want := "Bob Alice Eve Carol Dave "; output != want {
+		t.Errorf("ascending: expected %q, got %q", want, output)
+	}
+
+	output = executeString(t, `{% for u in users|sort_by_key:"-age" %}{{ u.name }} {% endfor %}`, ctx)
+	if want := "Carol Eve Alice Bob Dave "; output != want {
+		t.Errorf("descending: expected %q, got %q", want, output)
+	}
+
+	output = executeString(t, `{% for u in users|sort_by_key:"name" %}{{ u.name }} {% endfor %}`, ctx)
+	if want := "Alice Bob Carol Dave Eve "; output != want {
+		t.Errorf("by name: expected %q, got %q", want, output)
+	}
+}
+
+func TestGroupByFilter(t *testing.T) {
+	type User struct {
+		Name string
+		Role string
+	}
+	ctx := pongo2.Context{"users": []User{
+		{"Alice", "admin"}, {"Bob", "member"}, {"Carol", "admin"},
+	}}
+
+	output := executeString(t, `{% for role, members in users|group_by:"Role" sorted %}{{ role }}:{% for m in members %}{{ m.Name }}{% if not forloop.Last %},{% endif %}{% endfor %};{% endfor %}`, ctx)
+	if want := "admin:Alice,Carol;member:Bob;"; output != want {
+		t.Errorf("expected %q, got %q", want, output)
+	}
+}
+
+func TestCollectionFilterErrors(t *testing.T) {
+	tpl, err := pongo2.FromString(`{{ value|sort_by_key:"a" }}`)
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	if _, err := tpl.Execute(pongo2.Context{"value": "not a list"}); err == nil || !strings.Contains(err.Error(), "expected a list") {
+		t.Errorf("Expected a list error, got %v", err)
+	}
+}
+
+func TestMapWhereSumFilters(t *testing.T) {
+	orders := []map[string]interface{}{
+		{"id": "A", "status": "active", "amount": int64(9007199254740993)},
+		{"id": "B", "status": "closed", "amount": int64(5)},
+		{"id": "C", "status": "active", "amount": json.Number("2"), "paid": true},
+		{"id": "D", "status": "active"},
+	}
+	ctx := pongo2.Context{"orders": orders}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"map", `{{ orders|map:"id"|join:"," }}`, "A,B,C,D"},
+		{"where value", `{{ orders|where:"status,active"|map:"id"|join:"," }}`, "A,C,D"},
+		{"where truthy", `{{ orders|where:"paid"|map:"id"|join:"," }}`, "C"},
+		{"sum int64", `{{ orders|where:"status,active"|sum:"amount" }}`, "9007199254740995"},
+		{"sum floats", `{{ prices|sum }}`, "3.750000"},
+	}
+	ctx["prices"] = []interface{}{1.5, 2, json.Number("0.25")}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := executeString(t, tt.template, ctx)
+			if output != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestSumFilterErrors(t *testing.T) {
+	tpl, err := pongo2.FromString(`{{ items|sum }}`)
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	if _, err := tpl.Execute(pongo2.Context{"items": []interface{}{1, "two"}}); err == nil {
+		t.Error("Expected an error when summing a string")
+	}
+	if _, err := tpl.Execute(pongo2.Context{"items": []int64{math.MaxInt64, 1}}); err == nil || !strings.Contains(err.Error(), "overflows") {
+		t.Errorf("Expected an overflow error, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package pongo2
+
+import (
+	"context"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// Template is a template compiled by a Renderer, to be rendered any number of
+// times, concurrently too, without compiling it again.
+type Template struct {
+	renderer *Renderer
+	template *pongo2.Template
+	name     string
+	source   string
+}
+
+// Compile compiles a template string.
+func (r *Renderer) Compile(tpl string) (*Template, error) {
+	t, err := r.FromString(tpl)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{renderer: r, template: t, name: stringTemplateName, source: tpl}, nil
+}
+
+// CompileFile compiles a template file.
+func (r *Renderer) CompileFile(name string) (*Template, error) {
+	t, err := r.FromFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{renderer: r, template: t, name: name}, nil
+}
+
+// Render executes the template. Errors are *RenderError values, like those of
+// RenderString and RenderFile.
+func (t *Template) Render(ctx pongo2.Context, opts ...RenderOption) (string, error) {
+	output, err := t.renderer.execute(t.name, t.template, ctx, opts)
+	if err != nil {
+		source := t.source
+		if t.name != stringTemplateName {
+			source = t.renderer.source(t.name)
+		}
+		return "", t.renderer.wrapError(err, t.name, source)
+	}
+	return output, nil
+}
+
+// RenderContext is Render that returns ctx's error as soon as ctx is done.
+// The render is stopped too, like with WithContext, at the next output it
+// writes; until then it runs on in the background, so the context must not be
+// modified meanwhile.
+func (t *Template) RenderContext(ctx context.Context, data pongo2.Context, opts ...RenderOption) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	type result struct {
+		output string
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		output, err := t.Render(data, append(opts[:len(opts):len(opts)], WithContext(ctx))...)
+		done <- result{output, err}
+	}()
+	select {
+	case r := <-done:
+		return r.output, r.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
@@ -0,0 +1,147 @@
+package pongo2
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+func TestCompile(t *testing.T) {
+	r := NewRenderer(Options{TrimBlocks: true, OutputMode: OutputJSON})
+	tmpl, err := r.Compile("{% for n in names %}\n{{ n }};{% endfor %}")
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			output, err := tmpl.Render(pongo2.Context{"names": []string{`a"b`, "c"}})
+			if err != nil {
+				t.Errorf("Render failed: %v", err)
+				return
+			}
+			if want := `a\"b;c;`; output != want {
+				t.Errorf("expected %q, got %q", want, output)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if _, err := r.Compile("{% if %}"); err == nil {
+		t.Error("Compile should fail on a syntax error")
+	}
+	tmpl, err = r.Compile("{{ n|sort_by_key:1 }}")
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	_, err = tmpl.Render(pongo2.Context{"n": 1})
+	var renderErr *RenderError
+	if !errors.As(err, &renderErr) || renderErr.Compile {
+		t.Errorf("expected a runtime *RenderError, got %v", err)
+	}
+}
+
+func TestCompileFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hello.txt")
+	if err := os.WriteFile(path, []byte("Hello {{ name }}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := NewRenderer(Options{}).CompileFile(path)
+	if err != nil {
+		t.Fatalf("CompileFile failed: %v", err)
+	}
+	if output, err := tmpl.Render(pongo2.Context{"name": "Ada"}); err != nil || output != "Hello Ada" {
+		t.Errorf("expected %q, got %q (%v)", "Hello Ada", output, err)
+	}
+	if _, err := NewRenderer(Options{}).CompileFile(filepath.Join(dir, "missing.txt")); err == nil {
+		t.Error("CompileFile should fail for a missing file")
+	}
+}
+
+func TestRenderContext(t *testing.T) {
+	tmpl, err := NewRenderer(Options{}).Compile("{{ wait() }}done")
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	release := make(chan struct{})
+	defer close(release)
+	data := pongo2.Context{"wait": func() string { <-release; return "" }}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := tmpl.RenderContext(ctx, data); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	output, err := tmpl.RenderContext(context.Background(), pongo2.Context{"wait": func() string { return "" }})
+	if err != nil || output != "done" {
+		t.Errorf("expected %q, got %q, %v", "done", output, err)
+	}
+
+	// The render in the background stops at its next output.
+	var calls atomic.Int32
+	tmpl, err = NewRenderer(Options{}).Compile("{% for i in items %}{{ tick() }}{% endfor %}")
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	data = pongo2.Context{
+		"items": make([]int, 1000),
+		"tick": func() int32 {
+			n := calls.Add(1)
+			if n == 3 {
+				cancel()
+			}
+			return n
+		},
+	}
+	if _, err := tmpl.RenderContext(ctx, data); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := calls.Load(); n != 3 {
+		t.Errorf("expected the render to stop after 3 calls, got %d", n)
+	}
+}
+
+func BenchmarkRender(b *testing.B) {
+	items := make([]interface{}, 100)
+	for i := range items {
+		items[i] = map[string]interface{}{"sku": i, "name": `Widget "deluxe" & more`}
+	}
+	ctx := pongo2.Context{"customer": "Alice", "items": items}
+	source := "Order for {{ customer }}\n{% for item in items %}{{ item.sku }}: {{ item.name }}\n{% endfor %}"
+	for _, mode := range []OutputMode{OutputHTML, OutputJSON} {
+		tmpl, err := NewRenderer(Options{OutputMode: mode}).Compile(source)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(string(mode), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := tmpl.Render(ctx); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(string(mode)+"-per-render", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := tmpl.Render(ctx, WithOutputMode(OutputText)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
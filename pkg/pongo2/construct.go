@@ -0,0 +1,43 @@
+package pongo2
+
+import (
+	"fmt"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// constructors are functions available to every template rendered by a Renderer
+// for building intermediate structures in-template:
+//
+//	{% set d = dict("name", user.name, "tags", list("a", "b")) %}
+//	{{ d|to_json }}
+//
+// pongo2 expressions don't support keyword arguments, so dict takes
+// alternating key/value pairs instead.
+var constructors = pongo2.Context{
+	"dict": dict,
+	"list": list,
+}
+
+// dict builds a map from alternating key/value arguments.
+func dict(pairs ...interface{}) (map[string]interface{}, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("dict expects key/value pairs, got %d arguments", len(pairs))
+	}
+	d := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict key at position %d must be a string, got %T", i, pairs[i])
+		}
+		d[key] = pairs[i+1]
+	}
+	return d, nil
+}
+
+// list builds a slice from its arguments.
+func list(items ...interface{}) []interface{} {
+	l := make([]interface{}, len(items))
+	copy(l, items)
+	return l
+}
@@ -0,0 +1,50 @@
+package pongo2
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+func TestDictAndListConstructors(t *testing.T) {
+	templateString := `{% set d = dict("name", name, "n", 1, "tags", list("a", "b")) %}{{ d|to_json }}`
+
+	renderer := NewRenderer(Options{})
+	output, err := renderer.RenderString(templateString, pongo2.Context{"name": `John "Doe"`})
+	if err != nil {
+		t.Fatalf("Failed to execute template: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+		t.Fatalf("Rendered output should be valid JSON: %v\nOutput: %s", err, output)
+	}
+	want := map[string]interface{}{
+		"name": `John "Doe"`,
+		"n":    float64(1),
+		"tags": []interface{}{"a", "b"},
+	}
+	if !reflect.DeepEqual(decoded, want) {
+		t.Errorf("expected %#v, got %#v", want, decoded)
+	}
+}
+
+func TestDictAttributeAccess(t *testing.T) {
+	renderer := NewRenderer(Options{})
+	output, err := renderer.RenderString(`{% set d = dict("city", "Berlin") %}{{ d.city }}`, nil)
+	if err != nil {
+		t.Fatalf("Failed to execute template: %v", err)
+	}
+	if output != "Berlin" {
+		t.Errorf("expected %q, got %q", "Berlin", output)
+	}
+}
+
+func TestDictOddArguments(t *testing.T) {
+	renderer := NewRenderer(Options{})
+	if _, err := renderer.RenderString(`{% set d = dict("key") %}{{ d }}`, nil); err == nil {
+		t.Error("Expected an error for an odd number of dict arguments")
+	}
+}
@@ -0,0 +1,51 @@
+package pongo2
+
+import (
+	"sort"
+)
+
+// ContextSchema generates a draft-07 JSON Schema describing the context a
+// template expects (see Introspect): referenced variables become properties,
+// unconditionally used ones are required, and variables iterated by for-loops
+// are arrays. The result can be marshalled and compiled with the jsonschema
+// package to validate template inputs.
+func ContextSchema(tpl string) (map[string]interface{}, error) {
+	shape, err := Introspect(tpl)
+	if err != nil {
+		return nil, err
+	}
+	schema := shape.JSONSchema()
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	return schema, nil
+}
+
+// JSONSchema converts a shape into a JSON Schema object. Values of unknown
+// type produce an empty (accept anything) schema.
+func (s *Shape) JSONSchema() map[string]interface{} {
+	schema := map[string]interface{}{}
+	switch s.Type {
+	case "object":
+		schema["type"] = "object"
+		properties := map[string]interface{}{}
+		var required []string
+		for name, prop := range s.Properties {
+			properties[name] = prop.JSONSchema()
+			if prop.Required {
+				required = append(required, name)
+			}
+		}
+		if len(properties) > 0 {
+			schema["properties"] = properties
+		}
+		if len(required) > 0 {
+			sort.Strings(required)
+			schema["required"] = required
+		}
+	case "array":
+		schema["type"] = "array"
+		if s.Items != nil {
+			schema["items"] = s.Items.JSONSchema()
+		}
+	}
+	return schema
+}
@@ -0,0 +1,35 @@
+package pongo2
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestContextSchema(t *testing.T) {
+	templateString := `{{ name }}{% for tag in tags %}{{ tag }}{% endfor %}{% if bio %}{{ bio }}{% endif %}`
+
+	schema, err := ContextSchema(templateString)
+	if err != nil {
+		t.Fatalf("Failed to generate schema: %v", err)
+	}
+	b, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("Failed to marshal schema: %v", err)
+	}
+	compiled := compileSchema(t, string(b))
+
+	valid := map[string]interface{}{"name": "John", "tags": []interface{}{"a", "b"}}
+	if err := compiled.Validate(valid); err != nil {
+		t.Errorf("Valid context should pass: %v", err)
+	}
+
+	missing := map[string]interface{}{"tags": []interface{}{}}
+	if err := compiled.Validate(missing); err == nil {
+		t.Error("Context without required 'name' should fail")
+	}
+
+	wrongType := map[string]interface{}{"name": "John", "tags": "not-an-array"}
+	if err := compiled.Validate(wrongType); err == nil {
+		t.Error("Context with non-array 'tags' should fail")
+	}
+}
@@ -0,0 +1,232 @@
+package pongo2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// currencyInfo holds the display symbol and number of minor-unit digits of a currency.
+type currencyInfo struct {
+	symbol string
+	digits int
+}
+
+var currencies = map[string]currencyInfo{
+	"USD": {"$", 2},
+	"EUR": {"€", 2},
+	"GBP": {"£", 2},
+	"JPY": {"¥", 0},
+	"CNY": {"¥", 2},
+	"CHF": {"CHF", 2},
+	"CAD": {"CA$", 2},
+	"AUD": {"A$", 2},
+	"SEK": {"kr", 2},
+	"NOK": {"kr", 2},
+	"DKK": {"kr.", 2},
+	"PLN": {"zł", 2},
+	"CZK": {"Kč", 2},
+	"RUB": {"₽", 2},
+	"INR": {"₹", 2},
+	"KRW": {"₩", 0},
+	"BRL": {"R$", 2},
+	"KWD": {"KD", 3},
+	"BHD": {"BD", 3},
+}
+
+// numberFormat describes how a locale writes monetary amounts.
+type numberFormat struct {
+	decimal      string
+	group        string
+	symbolSuffix bool   // symbol after the amount ("1.234,56 €") instead of before
+	symbolSpace  string // separator between symbol and amount
+}
+
+const nbsp = "\u00a0"
+
+var numberFormats = map[string]numberFormat{
+	"en":    {decimal: ".", group: ","},
+	"ja":    {decimal: ".", group: ","},
+	"zh":    {decimal: ".", group: ","},
+	"ko":    {decimal: ".", group: ","},
+	"de":    {decimal: ",", group: ".", symbolSuffix: true, symbolSpace: nbsp},
+	"es":    {decimal: ",", group: ".", symbolSuffix: true, symbolSpace: nbsp},
+	"it":    {decimal: ",", group: ".", symbolSuffix: true, symbolSpace: nbsp},
+	"nl":    {decimal: ",", group: ".", symbolSpace: nbsp},
+	"pt":    {decimal: ",", group: ".", symbolSuffix: true, symbolSpace: nbsp},
+	"pt-br": {decimal: ",", group: ".", symbolSpace: nbsp},
+	"fr":    {decimal: ",", group: "\u202f", symbolSuffix: true, symbolSpace: nbsp},
+	"ru":    {decimal: ",", group: nbsp, symbolSuffix: true, symbolSpace: nbsp},
+	"pl":    {decimal: ",", group: nbsp, symbolSuffix: true, symbolSpace: nbsp},
+	"cs":    {decimal: ",", group: nbsp, symbolSuffix: true, symbolSpace: nbsp},
+	"sv":    {decimal: ",", group: nbsp, symbolSuffix: true, symbolSpace: nbsp},
+	"de-ch": {decimal: ".", group: "’", symbolSpace: nbsp},
+}
+
+// numberFormatFor returns the number format for a locale such as "de-DE",
+// falling back to the base language and then to English.
+func numberFormatFor(locale string) numberFormat {
+	locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	if f, ok := numberFormats[locale]; ok {
+		return f
+	}
+	lang, _, _ := strings.Cut(locale, "-")
+	if f, ok := numberFormats[lang]; ok {
+		return f
+	}
+	return numberFormats["en"]
+}
+
+// filterCurrency formats a monetary amount:
+//
+//	{{ total|currency:"EUR,de-DE" }}  -> 1.234,56 €
+//	{{ total|currency:"USD" }}        -> $1,234.56
+//
+// Integers are minor units (cents), and so are json.Number values, the numbers
+// of decoded JSON documents such as common/money.json amounts, however they are
+// written: 1299 and 1299.0 are both 12.99 dollars, and 12.5 is an error. Floats
+// and decimal strings are major units. Amounts that don't fit an int64 of
+// minor units are errors. The locale defaults to "en-US".
+func filterCurrency(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	code, locale, _ := strings.Cut(param.String(), ",")
+	code = strings.ToUpper(strings.TrimSpace(code))
+	locale = strings.TrimSpace(locale)
+	if locale == "" {
+		locale = "en-US"
+	}
+
+	info, ok := currencies[code]
+	if !ok {
+		return nil, &pongo2.Error{
+			Sender:    "filter:currency",
+			OrigError: fmt.Errorf("unknown currency %q", code),
+		}
+	}
+
+	minor, err := minorUnits(in.Interface(), info.digits)
+	if err != nil {
+		return nil, &pongo2.Error{Sender: "filter:currency", OrigError: err}
+	}
+	return pongo2.AsValue(formatMoney(minor, info, numberFormatFor(locale))), nil
+}
+
+// minorUnits converts an amount into an integer number of minor units.
+func minorUnits(v interface{}, digits int) (int64, error) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), nil
+	case int8:
+		return int64(n), nil
+	case int16:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case uint:
+		return uintToMinor(uint64(n))
+	case uint8:
+		return int64(n), nil
+	case uint16:
+		return int64(n), nil
+	case uint32:
+		return int64(n), nil
+	case uint64:
+		return uintToMinor(n)
+	case float32:
+		return decimalToMinor(strconv.FormatFloat(float64(n), 'f', -1, 32), digits)
+	case float64:
+		return decimalToMinor(strconv.FormatFloat(n, 'f', -1, 64), digits)
+	case json.Number:
+		return numberToMinor(n)
+	case string:
+		return decimalToMinor(strings.TrimSpace(n), digits)
+	default:
+		return 0, fmt.Errorf("cannot format %T as currency", v)
+	}
+}
+
+// uintToMinor returns n minor units, if an int64 can hold them.
+func uintToMinor(n uint64) (int64, error) {
+	if n > math.MaxInt64 {
+		return 0, errors.New("amount out of range")
+	}
+	return int64(n), nil
+}
+
+// numberToMinor returns the minor units of a JSON number, which must be whole.
+func numberToMinor(n json.Number) (int64, error) {
+	r, ok := new(big.Rat).SetString(n.String())
+	if !ok {
+		return 0, fmt.Errorf("invalid amount %q", n)
+	}
+	if !r.IsInt() {
+		return 0, fmt.Errorf("amount %s is not a whole number of minor units", n)
+	}
+	if !r.Num().IsInt64() {
+		return 0, errors.New("amount out of range")
+	}
+	return r.Num().Int64(), nil
+}
+
+// decimalToMinor parses a decimal string in major units and rounds it half away
+// from zero to the currency's minor units, without going through float64.
+func decimalToMinor(s string, digits int) (int64, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return 0, fmt.Errorf("invalid decimal amount %q", s)
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	r.Mul(r, new(big.Rat).SetInt(scale))
+
+	num, den := r.Num(), r.Denom()
+	q, m := new(big.Int).QuoRem(num, den, new(big.Int))
+	if m.Sign() != 0 && new(big.Int).Mul(new(big.Int).Abs(m), big.NewInt(2)).Cmp(den) >= 0 {
+		if num.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	if !q.IsInt64() {
+		return 0, errors.New("amount out of range")
+	}
+	return q.Int64(), nil
+}
+
+// formatMoney renders minor units with the currency symbol in the locale's format.
+func formatMoney(minor int64, info currencyInfo, f numberFormat) string {
+	sign := ""
+	// Work on the decimal string so math.MinInt64 doesn't overflow on negation.
+	digits := strconv.FormatInt(minor, 10)
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	if len(digits) <= info.digits {
+		digits = strings.Repeat("0", info.digits-len(digits)+1) + digits
+	}
+
+	intPart, fracPart := digits[:len(digits)-info.digits], digits[len(digits)-info.digits:]
+	var b strings.Builder
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteString(f.group)
+		}
+		b.WriteRune(c)
+	}
+	amount := b.String()
+	if fracPart != "" {
+		amount += f.decimal + fracPart
+	}
+
+	if f.symbolSuffix {
+		return sign + amount + f.symbolSpace + info.symbol
+	}
+	return sign + info.symbol + f.symbolSpace + amount
+}
@@ -0,0 +1,66 @@
+package pongo2
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+func TestCurrencyFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		param  string
+		amount interface{}
+		want   string
+	}{
+		{"USD minor units", "USD", int64(123456), "$1,234.56"},
+		{"USD default locale with int", "USD", 5, "$0.05"},
+		{"EUR German", "EUR,de-DE", int64(123456), "1.234,56\u00a0€"},
+		{"EUR French", "EUR,fr-FR", int64(123456789), "1\u202f234\u202f567,89\u00a0€"},
+		{"EUR Dutch prefix", "EUR,nl-NL", int64(1050), "€\u00a010,50"},
+		{"Float major units", "EUR,de-DE", 19.99, "19,99\u00a0€"},
+		{"Float rounding half away from zero", "USD", 0.125, "$0.13"},
+		{"Decimal string", "USD", "1234.5", "$1,234.50"},
+		{"JSON number integer", "USD", json.Number("250"), "$2.50"},
+		{"JSON number with zero fraction", "USD", json.Number("250.00"), "$2.50"},
+		{"JSON number exponent", "USD", json.Number("2.5e2"), "$2.50"},
+		{"Largest uint64 amount", "USD", uint64(math.MaxInt64), "$92,233,720,368,547,758.07"},
+		{"Zero-digit currency", "JPY,ja-JP", int64(1234567), "¥1,234,567"},
+		{"Three-digit currency", "KWD", int64(1234), "KD1.234"},
+		{"Negative amount", "USD", int64(-9900), "-$99.00"},
+		{"Unknown locale falls back to English", "GBP,xx-YY", int64(100), "£1.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tpl, err := pongo2.FromString(`{{ amount|currency:param }}`)
+			if err != nil {
+				t.Fatalf("Failed to parse template: %v", err)
+			}
+			got, err := tpl.Execute(pongo2.Context{"amount": tt.amount, "param": tt.param})
+			if err != nil {
+				t.Fatalf("Failed to execute template: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCurrencyFilterErrors(t *testing.T) {
+	tpl, err := pongo2.FromString(`{{ amount|currency:param }}`)
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	if _, err := tpl.Execute(pongo2.Context{"amount": 1, "param": "XYZ"}); err == nil {
+		t.Error("Expected an error for an unknown currency")
+	}
+	for _, amount := range []interface{}{"abc", uint64(math.MaxInt64) + 1, uint(math.MaxUint64), json.Number("2.5"), json.Number("1e30")} {
+		if _, err := tpl.Execute(pongo2.Context{"amount": amount, "param": "USD"}); err == nil {
+			t.Errorf("Expected an error for %T %v", amount, amount)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package pongo2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+
+	"go-demo/pkg/errcode"
+)
+
+// stringTemplateName identifies templates compiled from strings.
+const stringTemplateName = "<string>"
+
+// RenderError describes a template that failed to compile or execute, with
+// enough context for the template author to locate the problem.
+type RenderError struct {
+	// Template identifies the failing template: a file name, or "<string>"
+	// for templates compiled from strings.
+	Template string
+
+	// Line and Column give the 1-based position of the failure, or 0 if unknown.
+	Line   int
+	Column int
+
+	// Snippet is the offending template line followed by a line with a caret
+	// under the failing column. It is empty if the source isn't available.
+	Snippet string
+
+	// Err is the underlying cause.
+	Err error
+
+	// Compile is set if the template failed to compile (a lexer or parser
+	// error) rather than to execute.
+	Compile bool
+}
+
+func (e *RenderError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Template)
+	if e.Line > 0 {
+		fmt.Fprintf(&b, ":%d:%d", e.Line, e.Column)
+	}
+	b.WriteString(": ")
+	b.WriteString(e.Err.Error())
+	if e.Snippet != "" {
+		b.WriteString("\n")
+		b.WriteString(e.Snippet)
+	}
+	return b.String()
+}
+
+func (e *RenderError) Unwrap() error {
+	return e.Err
+}
+
+// Code returns errcode.TemplateCompile for compile errors and errcode.Render
+// otherwise.
+func (e *RenderError) Code() errcode.Code {
+	if e.Compile {
+		return errcode.TemplateCompile
+	}
+	return errcode.Render
+}
+
+// Is reports whether target is the error's code.
+func (e *RenderError) Is(target error) bool {
+	return target == e.Code()
+}
+
+// MarshalJSON encodes the error as {"code", "message", "template", "line",
+// "column", "snippet"}, the message being that of the underlying error.
+func (e *RenderError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Code     errcode.Code `json:"code"`
+		Message  string       `json:"message"`
+		Template string       `json:"template"`
+		Line     int          `json:"line,omitempty"`
+		Column   int          `json:"column,omitempty"`
+		Snippet  string       `json:"snippet,omitempty"`
+	}{e.Code(), e.Err.Error(), e.Template, e.Line, e.Column, e.Snippet})
+}
+
+// wrapError converts a pongo2 error into a *RenderError. name and source identify
+// the template that was being compiled or executed; errors raised in other
+// templates (includes, parents) are located through the Renderer's loaders.
+func (r *Renderer) wrapError(err error, name, source string) error {
+	if err == nil {
+		return nil
+	}
+	var renderErr *RenderError
+	if errors.As(err, &renderErr) {
+		return err
+	}
+
+	var pe *pongo2.Error
+	if !errors.As(err, &pe) {
+		return &RenderError{Template: name, Err: err}
+	}
+
+	renderErr = &RenderError{
+		Template: name,
+		Line:     pe.Line,
+		Column:   pe.Column,
+		Err:      pe.OrigError,
+		Compile:  pe.Sender == "lexer" || pe.Sender == "parser",
+	}
+	if renderErr.Err == nil {
+		renderErr.Err = errors.New(pe.Sender)
+	} else if pe.Sender != "" && pe.Sender != "execution" {
+		renderErr.Err = fmt.Errorf("%s: %w", pe.Sender, pe.OrigError)
+	}
+	if pe.Filename != "" && pe.Filename != stringTemplateName && pe.Filename != name {
+		renderErr.Template = pe.Filename
+		source = r.source(pe.Filename)
+	}
+	renderErr.Snippet = snippet(source, pe.Line, pe.Column)
+	return renderErr
+}
+
+// source returns the content of a template file, or "" if it can't be loaded.
+func (r *Renderer) source(name string) string {
+	src, _ := r.readSource(name)
+	return src
+}
+
+// readSource loads the content of a template file through the Renderer's loaders.
+func (r *Renderer) readSource(name string) (string, error) {
+	var err error
+	for _, loader := range r.loaders {
+		var rd io.Reader
+		rd, err = loader.Get(loader.Abs("", name))
+		if err != nil {
+			continue
+		}
+		var b []byte
+		if b, err = io.ReadAll(rd); err != nil {
+			continue
+		}
+		return string(b), nil
+	}
+	return "", err
+}
+
+// snippet returns the given line of source followed by a caret under column.
+func snippet(source string, line, column int) string {
+	if source == "" || line <= 0 {
+		return ""
+	}
+	lines := strings.Split(source, "\n")
+	if line > len(lines) {
+		return ""
+	}
+	text := strings.TrimRight(lines[line-1], "\r")
+
+	// Keep tabs in the caret line so it lines up with the source line.
+	var caret strings.Builder
+	for i, c := range []rune(text) {
+		if i >= column-1 {
+			break
+		}
+		if c == '\t' {
+			caret.WriteRune('\t')
+		} else {
+			caret.WriteRune(' ')
+		}
+	}
+	caret.WriteRune('^')
+	return text + "\n" + caret.String()
+}
@@ -0,0 +1,104 @@
+package pongo2
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/flosch/pongo2/v6"
+
+	"go-demo/pkg/errcode"
+)
+
+func TestRenderErrorExecution(t *testing.T) {
+	renderer := NewRenderer(Options{})
+	_, err := renderer.RenderString("line one\n  {{ name|pluralize }}\n", pongo2.Context{"name": "John"})
+	if err == nil {
+		t.Fatal("Expected an execution error")
+	}
+
+	var renderErr *RenderError
+	if !errors.As(err, &renderErr) {
+		t.Fatalf("Expected a *RenderError, got %T: %v", err, err)
+	}
+	if renderErr.Template != "<string>" || renderErr.Line != 2 || renderErr.Column != 11 {
+		t.Errorf("unexpected position %s:%d:%d", renderErr.Template, renderErr.Line, renderErr.Column)
+	}
+	wantSnippet := "  {{ name|pluralize }}\n          ^"
+	if renderErr.Snippet != wantSnippet {
+		t.Errorf("expected snippet:\n%s\ngot:\n%s", wantSnippet, renderErr.Snippet)
+	}
+	if !strings.Contains(renderErr.Error(), "does only work on numbers") {
+		t.Errorf("Error message should contain the cause, got %q", renderErr.Error())
+	}
+	if renderErr.Compile {
+		t.Error("An execution error should not be marked as a compile error")
+	}
+}
+
+func TestRenderErrorParse(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "broken.tpl")
+	if err := os.WriteFile(path, []byte("ok\n{% for x in %}\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	renderer := NewRenderer(Options{})
+	_, err := renderer.RenderFile(path, nil)
+
+	var renderErr *RenderError
+	if !errors.As(err, &renderErr) {
+		t.Fatalf("Expected a *RenderError, got %T: %v", err, err)
+	}
+	if renderErr.Line != 2 || !strings.HasPrefix(renderErr.Snippet, "{% for x in %}\n") {
+		t.Errorf("unexpected error location: %v", renderErr)
+	}
+	if !renderErr.Compile {
+		t.Error("A parse error should be marked as a compile error")
+	}
+
+	var pongoErr *pongo2.Error
+	if errors.As(err, &pongoErr) {
+		t.Error("RenderError should unwrap to the cause, not the raw pongo2 error")
+	}
+}
+
+func TestRenderErrorCode(t *testing.T) {
+	renderer := NewRenderer(Options{})
+	_, compileErr := renderer.RenderString("{% if %}", nil)
+	_, execErr := renderer.RenderString("{{ n|sort_by_key:1 }}", pongo2.Context{"n": 1})
+
+	tests := []struct {
+		err  error
+		code errcode.Code
+	}{
+		{compileErr, errcode.TemplateCompile},
+		{execErr, errcode.Render},
+	}
+	for _, tt := range tests {
+		if got := errcode.Of(tt.err); got != tt.code {
+			t.Errorf("expected code %q, got %q", tt.code, got)
+		}
+		if !errors.Is(tt.err, tt.code) {
+			t.Errorf("%v should match %q", tt.err, tt.code)
+		}
+	}
+	if errors.Is(compileErr, errcode.Render) {
+		t.Error("a compile error shouldn't match errcode.Render")
+	}
+
+	b, err := json.Marshal(compileErr)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if got["code"] != "template_compile_error" || got["template"] != "<string>" || got["line"] != float64(1) || got["message"] == "" {
+		t.Errorf("unexpected JSON %s", b)
+	}
+}
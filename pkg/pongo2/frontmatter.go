@@ -0,0 +1,107 @@
+package pongo2
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+	"gopkg.in/yaml.v3"
+)
+
+// Front matter formats recognised by ParseDocument.
+const (
+	FrontMatterNone = ""
+	FrontMatterYAML = "yaml"
+	FrontMatterJSON = "json"
+)
+
+// Document is rendered output split into front matter and body, as used by
+// static-site generators and documentation pipelines.
+type Document struct {
+	// Format is the front matter format (FrontMatterYAML, FrontMatterJSON or
+	// FrontMatterNone if the output had no front matter).
+	Format string
+
+	// FrontMatter holds the decoded front matter, or nil if there was none.
+	FrontMatter map[string]interface{}
+
+	// Body is everything after the front matter.
+	Body string
+}
+
+// RenderDocument renders a template string and splits the output into front
+// matter and body (see ParseDocument).
+func (r *Renderer) RenderDocument(tpl string, ctx pongo2.Context) (*Document, error) {
+	output, err := r.RenderString(tpl, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDocument(output)
+}
+
+// ParseDocument splits text into front matter and body. YAML front matter is
+// enclosed in "---" lines and must be a YAML mapping; text that starts with a
+// "---" line but has no closing one, or whose block isn't a mapping (such as a
+// Markdown horizontal rule), has no front matter. JSON front matter is a JSON
+// object at the very start of the text. Text without front matter is returned
+// as the body.
+func ParseDocument(text string) (*Document, error) {
+	switch {
+	case strings.HasPrefix(text, "---\n") || strings.HasPrefix(text, "---\r\n"):
+		if doc, ok := parseYAMLFrontMatter(text); ok {
+			return doc, nil
+		}
+		return &Document{Format: FrontMatterNone, Body: text}, nil
+	case strings.HasPrefix(text, "{"):
+		return parseJSONFrontMatter(text)
+	default:
+		return &Document{Format: FrontMatterNone, Body: text}, nil
+	}
+}
+
+// parseYAMLFrontMatter reports false if text has no closing "---" line or the
+// block before it doesn't parse as a YAML mapping.
+func parseYAMLFrontMatter(text string) (*Document, bool) {
+	rest := trimLeadingNewline(strings.TrimPrefix(text, "---"))
+
+	offset := 0
+	for _, line := range strings.SplitAfter(rest, "\n") {
+		if strings.TrimRight(line, "\r\n") == "---" {
+			frontMatter := map[string]interface{}{}
+			if err := yaml.Unmarshal([]byte(rest[:offset]), &frontMatter); err != nil {
+				return nil, false
+			}
+			return &Document{
+				Format:      FrontMatterYAML,
+				FrontMatter: frontMatter,
+				Body:        rest[offset+len(line):],
+			}, true
+		}
+		offset += len(line)
+	}
+	return nil, false
+}
+
+func parseJSONFrontMatter(text string) (*Document, error) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+
+	var frontMatter map[string]interface{}
+	if err := decoder.Decode(&frontMatter); err != nil {
+		return nil, fmt.Errorf("front matter: %w", err)
+	}
+	return &Document{
+		Format:      FrontMatterJSON,
+		FrontMatter: frontMatter,
+		Body:        trimLeadingNewline(text[decoder.InputOffset():]),
+	}, nil
+}
+
+// trimLeadingNewline removes a single leading "\n" or "\r\n".
+func trimLeadingNewline(s string) string {
+	if strings.HasPrefix(s, "\r\n") {
+		return s[2:]
+	}
+	return strings.TrimPrefix(s, "\n")
+}
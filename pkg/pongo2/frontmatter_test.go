@@ -0,0 +1,85 @@
+package pongo2
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+func TestRenderDocumentYAML(t *testing.T) {
+	templateString := `---
+title: {{ title }}
+tags:
+{% for tag in tags %}  - {{ tag }}
+{% endfor %}---
+# {{ title }}
+
+Body text.
+`
+	renderer := NewRenderer(Options{})
+	doc, err := renderer.RenderDocument(templateString, pongo2.Context{
+		"title": "Release Notes",
+		"tags":  []string{"go", "docs"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to render document: %v", err)
+	}
+
+	if doc.Format != FrontMatterYAML {
+		t.Errorf("expected format %q, got %q", FrontMatterYAML, doc.Format)
+	}
+	if doc.FrontMatter["title"] != "Release Notes" {
+		t.Errorf("expected title in front matter, got %#v", doc.FrontMatter)
+	}
+	if tags, ok := doc.FrontMatter["tags"].([]interface{}); !ok || len(tags) != 2 {
+		t.Errorf("expected 2 tags in front matter, got %#v", doc.FrontMatter["tags"])
+	}
+	if doc.Body != "# Release Notes\n\nBody text.\n" {
+		t.Errorf("unexpected body %q", doc.Body)
+	}
+}
+
+func TestRenderDocumentJSON(t *testing.T) {
+	renderer := NewRenderer(Options{})
+	doc, err := renderer.RenderDocument("{\"title\": {{ title|to_json }}, \"weight\": 3}\nBody", pongo2.Context{"title": "Intro"})
+	if err != nil {
+		t.Fatalf("Failed to render document: %v", err)
+	}
+
+	if doc.Format != FrontMatterJSON {
+		t.Errorf("expected format %q, got %q", FrontMatterJSON, doc.Format)
+	}
+	if doc.FrontMatter["title"] != "Intro" || doc.FrontMatter["weight"] != json.Number("3") {
+		t.Errorf("unexpected front matter %#v", doc.FrontMatter)
+	}
+	if doc.Body != "Body" {
+		t.Errorf("unexpected body %q", doc.Body)
+	}
+}
+
+func TestParseDocumentWithoutFrontMatter(t *testing.T) {
+	doc, err := ParseDocument("# Just a body\n")
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	if doc.Format != FrontMatterNone || doc.FrontMatter != nil || doc.Body != "# Just a body\n" {
+		t.Errorf("unexpected document %#v", doc)
+	}
+
+	// A leading "---" is only front matter if it is closed and holds a mapping.
+	for _, text := range []string{
+		"---\ntitle: x\n",
+		"---\nA paragraph after a rule.\n\n---\nMore text.\n",
+		"---\n- a list\n---\n",
+		"---\ntitle: [unclosed\n---\nBody\n",
+	} {
+		doc, err := ParseDocument(text)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", text, err)
+		}
+		if doc.Format != FrontMatterNone || doc.FrontMatter != nil || doc.Body != text {
+			t.Errorf("expected %q to have no front matter, got %#v", text, doc)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package pongo2
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+
+	"go-demo/pkg/jsonutil"
+)
+
+// filterGet resolves a deep path in a map, struct or slice value. The path is
+// either a JSON Pointer ("/items/0/name") or a dotted path ("items.0.name").
+// A missing intermediate key yields nil instead of an error, or the fallback
+// given after "|":
+//
+//	{{ order|get:"/customer/address/city" }}
+//	{{ order|get:"customer.address.city|unknown" }}
+func filterGet(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	path, fallback, hasFallback := strings.Cut(param.String(), "|")
+
+	var tokens []string
+	if strings.HasPrefix(path, "/") || path == "" {
+		var err error
+		if tokens, err = jsonutil.SplitPointer(path); err != nil {
+			return nil, &pongo2.Error{Sender: "filter:get", OrigError: err}
+		}
+	} else {
+		tokens = strings.Split(path, ".")
+	}
+
+	if v, ok := lookupPath(in.Interface(), tokens); ok && v != nil {
+		return pongo2.AsValue(v), nil
+	}
+	if hasFallback {
+		return pongo2.AsValue(fallback), nil
+	}
+	return pongo2.AsValue(nil), nil
+}
+
+// lookupPath walks tokens through nested maps, structs and slices.
+func lookupPath(value interface{}, tokens []string) (interface{}, bool) {
+	v := reflect.ValueOf(value)
+	for _, tok := range tokens {
+		for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) {
+			if v.IsNil() {
+				return nil, false
+			}
+			v = v.Elem()
+		}
+		if !v.IsValid() {
+			return nil, false
+		}
+
+		switch v.Kind() {
+		case reflect.Slice, reflect.Array:
+			index, err := strconv.Atoi(tok)
+			if err != nil || index < 0 || index >= v.Len() {
+				return nil, false
+			}
+			v = v.Index(index)
+		default:
+			child, ok := attribute(v, tok)
+			if !ok || child.Kind() == reflect.Func {
+				return nil, false
+			}
+			v = child
+		}
+	}
+	if !v.IsValid() {
+		return nil, false
+	}
+	return v.Interface(), true
+}
@@ -0,0 +1,51 @@
+package pongo2
+
+import (
+	"testing"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+func TestGetFilter(t *testing.T) {
+	type Address struct {
+		City string
+	}
+	ctx := pongo2.Context{
+		"order": map[string]interface{}{
+			"customer": map[string]interface{}{
+				"name":    "Alice",
+				"address": &Address{City: "Berlin"},
+			},
+			"items": []interface{}{
+				map[string]interface{}{"sku": "A-1", "tags": []string{"new"}},
+			},
+			"a/b": "slash",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"pointer", `{{ order|get:"/customer/name" }}`, "Alice"},
+		{"pointer into struct", `{{ order|get:"/customer/address/City" }}`, "Berlin"},
+		{"pointer index", `{{ order|get:"/items/0/tags/0" }}`, "new"},
+		{"pointer escape", `{{ order|get:"/a~1b" }}`, "slash"},
+		{"dotted path", `{{ order|get:"items.0.sku" }}`, "A-1"},
+		{"missing", `{{ order|get:"/customer/phone/number" }}`, ""},
+		{"missing is none", `{% if order|get:"/missing/key" == nil %}none{% endif %}`, "none"},
+		{"index out of range", `{{ order|get:"items.5.sku|-" }}`, "-"},
+		{"fallback", `{{ order|get:"customer.address.Zip|unknown" }}`, "unknown"},
+		{"nil input", `{{ nothing|get:"a.b|fallback" }}`, "fallback"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := executeString(t, tt.template, ctx)
+			if output != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, output)
+			}
+		})
+	}
+}
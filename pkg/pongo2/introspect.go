@@ -0,0 +1,549 @@
+package pongo2
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// Shape describes the structure of a context value as far as it can be
+// inferred from how a template uses it.
+type Shape struct {
+	// Type is "object", "array", or "" when the template doesn't reveal the type.
+	Type string
+
+	// Required is true when the template references the value unconditionally,
+	// i.e. outside if-blocks and loops and without a default filter.
+	Required bool
+
+	// Properties holds the shapes of attributes accessed on an object.
+	Properties map[string]*Shape
+
+	// Items holds the shape of the elements of an array.
+	Items *Shape
+}
+
+// Variables returns the sorted names of the properties of an object shape.
+// For the root shape returned by Introspect these are the context variables.
+func (s *Shape) Variables() []string {
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// property returns the shape of the named attribute, turning s into an object.
+func (s *Shape) property(name string) *Shape {
+	if s.Type == "" {
+		s.Type = "object"
+	}
+	if s.Type != "object" {
+		return &Shape{}
+	}
+	if s.Properties == nil {
+		s.Properties = make(map[string]*Shape)
+	}
+	child, ok := s.Properties[name]
+	if !ok {
+		child = &Shape{}
+		s.Properties[name] = child
+	}
+	return child
+}
+
+// items returns the shape of the elements, turning s into an array.
+func (s *Shape) items() *Shape {
+	if s.Type == "" {
+		s.Type = "array"
+	}
+	if s.Type != "array" {
+		return &Shape{}
+	}
+	if s.Items == nil {
+		s.Items = &Shape{}
+	}
+	return s.Items
+}
+
+// Introspect parses a template and returns the shape of the context it expects:
+// the variables it references, attributes accessed on them, and arrays iterated
+// by for-loops. Loop variables, {% set %}/{% with %} variables, macro arguments
+// and renderer globals (dict, list) are not part of the context.
+//
+// Only the given template is inspected; templates pulled in through
+// {% extends %} or {% include %} are not followed.
+func Introspect(tpl string) (*Shape, error) {
+	if _, err := pongo2.FromString(tpl); err != nil {
+		return nil, err
+	}
+	return introspect(tpl), nil
+}
+
+// introspect is Introspect for a template known to compile.
+func introspect(tpl string) *Shape {
+	in := &introspector{root: &Shape{Type: "object"}}
+	in.push(frameRoot, "")
+	for _, tok := range lexTemplate(tpl) {
+		switch tok.kind {
+		case templateVariable:
+			if in.top().kind != frameSkip {
+				in.expression(lexExpr(tok.content), false)
+			}
+		case templateTag:
+			in.tag(tok.content)
+		}
+	}
+	return in.root
+}
+
+// Template tokens as seen by the introspection lexer.
+const (
+	templateText = iota
+	templateVariable
+	templateTag
+	templateComment
+)
+
+type templateToken struct {
+	kind    int
+	content string // trimmed content between the delimiters
+}
+
+// lexTemplate splits template source into text, {{ variable }}, {% tag %} and
+// {# comment #} tokens. String literals inside delimiters may contain closing
+// delimiters.
+func lexTemplate(src string) []templateToken {
+	var tokens []templateToken
+	for len(src) > 0 {
+		start := strings.Index(src, "{")
+		for start >= 0 && start+1 < len(src) && !strings.ContainsRune("{%#", rune(src[start+1])) {
+			next := strings.Index(src[start+1:], "{")
+			if next < 0 {
+				start = -1
+				break
+			}
+			start += next + 1
+		}
+		if start < 0 || start+1 >= len(src) {
+			tokens = append(tokens, templateToken{kind: templateText, content: src})
+			break
+		}
+		if start > 0 {
+			tokens = append(tokens, templateToken{kind: templateText, content: src[:start]})
+		}
+
+		var kind int
+		var closing string
+		switch src[start+1] {
+		case '{':
+			kind, closing = templateVariable, "}}"
+		case '%':
+			kind, closing = templateTag, "%}"
+		default:
+			kind, closing = templateComment, "#}"
+		}
+
+		end := findClosing(src, start+2, closing)
+		if end < 0 {
+			tokens = append(tokens, templateToken{kind: templateText, content: src[start:]})
+			break
+		}
+		content := strings.TrimSpace(src[start+2 : end])
+		content = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(content, "-"), "-"))
+		tokens = append(tokens, templateToken{kind: kind, content: content})
+		src = src[end+len(closing):]
+	}
+	return tokens
+}
+
+// findClosing returns the index of the closing delimiter at or after from,
+// skipping over quoted strings.
+func findClosing(src string, from int, closing string) int {
+	var quote byte
+	for i := from; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case strings.HasPrefix(src[i:], closing):
+			return i
+		}
+	}
+	return -1
+}
+
+// exprToken is a token of a pongo2 expression.
+type exprToken struct {
+	kind byte // 'i' identifier, 'n' number, 's' string, 'p' punctuation
+	val  string
+}
+
+var exprKeywords = map[string]bool{
+	"in": true, "and": true, "or": true, "not": true, "is": true, "as": true, "export": true,
+	"true": true, "false": true, "True": true, "False": true, "nil": true, "none": true, "None": true,
+}
+
+var exprOperators = map[string]bool{
+	"==": true, "!=": true, "<=": true, ">=": true, "&&": true, "||": true, "<>": true,
+}
+
+// lexExpr splits a pongo2 expression (or tag arguments) into tokens.
+func lexExpr(s string) []exprToken {
+	var tokens []exprToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(s) && s[j] != c {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			end := j
+			if end > len(s) {
+				end = len(s)
+			}
+			tokens = append(tokens, exprToken{'s', s[i+1 : end]})
+			i = j + 1
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(s) && (isIdentStart(s[j]) || isDigit(s[j])) {
+				j++
+			}
+			tokens = append(tokens, exprToken{'i', s[i:j]})
+			i = j
+		case isDigit(c):
+			j := i + 1
+			for j < len(s) && isDigit(s[j]) {
+				j++
+			}
+			if j+1 < len(s) && s[j] == '.' && isDigit(s[j+1]) && (len(tokens) == 0 || tokens[len(tokens)-1].val != ".") {
+				j++
+				for j < len(s) && isDigit(s[j]) {
+					j++
+				}
+			}
+			tokens = append(tokens, exprToken{'n', s[i:j]})
+			i = j
+		default:
+			if i+1 < len(s) && exprOperators[s[i:i+2]] {
+				tokens = append(tokens, exprToken{'p', s[i : i+2]})
+				i += 2
+			} else {
+				tokens = append(tokens, exprToken{'p', s[i : i+1]})
+				i++
+			}
+		}
+	}
+	return tokens
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// Kinds of introspection scopes.
+const (
+	frameRoot        = iota
+	frameBlock       // body that always executes (block, filter, with, ...)
+	frameConditional // body that may not execute (if, ifchanged, macro, ...)
+	frameLoop        // for-loop body
+	frameSkip        // content that isn't template code (comment, verbatim)
+)
+
+type introspectFrame struct {
+	kind     int
+	endTag   string
+	bindings map[string]*Shape
+}
+
+type introspector struct {
+	root   *Shape
+	frames []*introspectFrame
+}
+
+// bodyTags maps tags with a body to the kind of scope their body opens.
+var bodyTags = map[string]int{
+	"if":         frameConditional,
+	"ifequal":    frameConditional,
+	"ifnotequal": frameConditional,
+	"ifchanged":  frameConditional,
+	"macro":      frameConditional,
+	"for":        frameLoop,
+	"with":       frameBlock,
+	"block":      frameBlock,
+	"filter":     frameBlock,
+	"spaceless":  frameBlock,
+	"autoescape": frameBlock,
+	"comment":    frameSkip,
+	"verbatim":   frameSkip,
+}
+
+func (in *introspector) push(kind int, endTag string) *introspectFrame {
+	f := &introspectFrame{kind: kind, endTag: endTag, bindings: make(map[string]*Shape)}
+	in.frames = append(in.frames, f)
+	return f
+}
+
+func (in *introspector) top() *introspectFrame {
+	return in.frames[len(in.frames)-1]
+}
+
+// bind declares a template-local variable in the innermost scope.
+func (in *introspector) bind(name string, shape *Shape) {
+	if shape == nil {
+		shape = &Shape{}
+	}
+	in.top().bindings[name] = shape
+}
+
+// lookup resolves a variable name to its shape and reports whether the
+// reference is conditional relative to where the variable is bound.
+func (in *introspector) lookup(name string) (shape *Shape, conditional bool) {
+	for i := len(in.frames) - 1; i >= 0; i-- {
+		f := in.frames[i]
+		if s, ok := f.bindings[name]; ok {
+			return s, conditional
+		}
+		if f.kind == frameConditional || f.kind == frameLoop {
+			conditional = true
+		}
+	}
+	if name == "forloop" || name == "pongo2" || name == rendererKey || constructors[name] != nil {
+		return nil, conditional
+	}
+	return in.root.property(name), conditional
+}
+
+// tag processes the content of a {% tag %}.
+func (in *introspector) tag(content string) {
+	name, args, _ := strings.Cut(content, " ")
+	toks := lexExpr(args)
+
+	if in.top().kind == frameSkip {
+		if name == in.top().endTag {
+			in.frames = in.frames[:len(in.frames)-1]
+		}
+		return
+	}
+	if len(in.frames) > 1 && name == in.top().endTag {
+		in.frames = in.frames[:len(in.frames)-1]
+		return
+	}
+
+	switch name {
+	case "if", "ifequal", "ifnotequal", "ifchanged":
+		in.push(frameConditional, "end"+name)
+		in.expression(toks, true)
+	case "elif", "firstof":
+		in.expression(toks, true)
+	case "cycle":
+		for i := range toks {
+			if toks[i].val == "as" && i+1 < len(toks) {
+				in.expression(toks[:i], true)
+				in.bind(toks[i+1].val, nil)
+				return
+			}
+		}
+		in.expression(toks, true)
+	case "for":
+		in.forTag(toks)
+	case "set":
+		if len(toks) >= 2 && toks[0].kind == 'i' && toks[1].val == "=" {
+			in.expression(toks[2:], false)
+			in.bind(toks[0].val, nil)
+		}
+	case "set_default":
+		if len(toks) >= 1 && toks[0].kind == 'i' {
+			in.chain(toks, 0, true)
+			in.expression(toks[1:], false)
+		}
+	case "with":
+		in.push(frameBlock, "endwith")
+		in.withTag(toks)
+	case "include":
+		for i := range toks {
+			if toks[i].kind == 'i' && toks[i].val == "with" {
+				in.assignments(toks[i+1:], false)
+				break
+			}
+		}
+	case "macro":
+		f := in.push(frameConditional, "endmacro")
+		for i, tok := range toks {
+			if tok.kind == 'i' && i > 0 && (toks[i-1].val == "(" || toks[i-1].val == ",") {
+				f.bindings[tok.val] = &Shape{}
+			}
+		}
+		if len(toks) > 0 {
+			in.frames[len(in.frames)-2].bindings[toks[0].val] = &Shape{}
+		}
+	case "import":
+		for i, tok := range toks {
+			if tok.kind == 'i' && !exprKeywords[tok.val] && (i+1 == len(toks) || toks[i+1].val != "as") {
+				in.bind(tok.val, nil)
+			}
+		}
+	case "widthratio":
+		in.expression(toks, false)
+	default:
+		if kind, ok := bodyTags[name]; ok {
+			in.push(kind, "end"+name)
+		}
+	}
+}
+
+// forTag handles {% for x in expr %} and {% for k, v in expr %}.
+func (in *introspector) forTag(toks []exprToken) {
+	keyed := len(toks) > 2 && toks[1].val == ","
+	start := 2
+	if keyed {
+		start = 4
+	}
+	if len(toks) <= start || toks[start-1].val != "in" {
+		in.push(frameLoop, "endfor")
+		return
+	}
+
+	var iterated *Shape
+	if toks[start].kind == 'i' && !exprKeywords[toks[start].val] {
+		var next int
+		iterated, next = in.chain(toks, start, false)
+		in.expression(toks[next:], false)
+	} else {
+		in.expression(toks[start:], false)
+	}
+
+	f := in.push(frameLoop, "endfor")
+	f.bindings["forloop"] = &Shape{}
+	switch {
+	case iterated == nil:
+		f.bindings[toks[0].val] = &Shape{}
+		if keyed {
+			f.bindings[toks[2].val] = &Shape{}
+		}
+	case keyed:
+		if iterated.Type == "" {
+			iterated.Type = "object"
+		}
+		f.bindings[toks[0].val] = &Shape{}
+		f.bindings[toks[2].val] = &Shape{}
+	default:
+		f.bindings[toks[0].val] = iterated.items()
+	}
+}
+
+// withTag handles {% with a=expr b=expr %} and {% with expr as name %}.
+func (in *introspector) withTag(toks []exprToken) {
+	for i := range toks {
+		if toks[i].kind == 'i' && toks[i].val == "as" && i+1 < len(toks) {
+			in.expression(toks[:i], false)
+			in.bind(toks[i+1].val, nil)
+			return
+		}
+	}
+	in.assignments(toks, true)
+}
+
+// assignments handles "a=expr b=expr" argument lists, optionally binding the names.
+func (in *introspector) assignments(toks []exprToken, bind bool) {
+	for i := 0; i < len(toks); {
+		if i+1 < len(toks) && toks[i].kind == 'i' && toks[i+1].val == "=" {
+			end := i + 2
+			for end < len(toks) && !(end+1 < len(toks) && toks[end].kind == 'i' && toks[end+1].val == "=") {
+				end++
+			}
+			in.expression(toks[i+2:end], false)
+			if bind {
+				in.bind(toks[i].val, nil)
+			}
+			i = end
+			continue
+		}
+		i++
+	}
+}
+
+// expression records every variable referenced by an expression. References are
+// optional when the expression is a condition.
+func (in *introspector) expression(toks []exprToken, optional bool) {
+	for i := 0; i < len(toks); {
+		tok := toks[i]
+		if tok.kind != 'i' || exprKeywords[tok.val] || (i > 0 && toks[i-1].val == "|") {
+			i++
+			continue
+		}
+		_, next := in.chain(toks, i, optional)
+		i = next
+	}
+}
+
+// chain records the attribute chain starting at toks[start] (name.attr[0]["key"])
+// and returns the shape it refers to and the index after the chain.
+func (in *introspector) chain(toks []exprToken, start int, optional bool) (*Shape, int) {
+	shape, conditional := in.lookup(toks[start].val)
+	path := []*Shape{shape}
+
+	i := start + 1
+walk:
+	for i < len(toks) && shape != nil {
+		switch {
+		case toks[i].val == "." && i+1 < len(toks) && toks[i+1].kind == 'i':
+			shape = shape.property(toks[i+1].val)
+			i += 2
+		case toks[i].val == "." && i+1 < len(toks) && toks[i+1].kind == 'n':
+			shape = shape.items()
+			i += 2
+		case toks[i].val == "[" && i+2 < len(toks) && toks[i+2].val == "]" && toks[i+1].kind == 's':
+			shape = shape.property(toks[i+1].val)
+			i += 3
+		case toks[i].val == "[" && i+2 < len(toks) && toks[i+2].val == "]" && toks[i+1].kind == 'n':
+			shape = shape.items()
+			i += 3
+		default:
+			break walk
+		}
+		path = append(path, shape)
+	}
+	if shape == nil {
+		return nil, i
+	}
+
+	// Function calls and default filters make the value optional for the template.
+	if i < len(toks) && toks[i].val == "(" {
+		optional = true
+	}
+	for j := i; j+1 < len(toks) && toks[j].val == "|"; {
+		if filter := toks[j+1].val; filter == "default" || filter == "default_if_none" {
+			optional = true
+		}
+		j += 2
+		for j < len(toks) && toks[j].val != "|" && !(toks[j].kind == 'i' && exprKeywords[toks[j].val]) {
+			j++
+		}
+	}
+
+	if !optional && !conditional {
+		for _, s := range path {
+			s.Required = true
+		}
+	}
+	return shape, i
+}
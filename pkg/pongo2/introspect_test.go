@@ -0,0 +1,57 @@
+package pongo2
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIntrospect(t *testing.T) {
+	templateString := `{# {{ commented }} #}
+{% set greeting = "Hello" %}
+{{ greeting }} {{ user.name }} <{{ user.email|default:"none" }}>
+{% for tag in tags %}{{ tag.label }}{% if not forloop.Last %}, {% endif %}{% endfor %}
+{% for key, value in metadata %}{{ key }}={{ value }}{% endfor %}
+{% if show_footer %}{{ footer.text }}{% endif %}
+{{ items.0.id }} {{ settings["theme"] }}`
+
+	shape, err := Introspect(templateString)
+	if err != nil {
+		t.Fatalf("Failed to introspect template: %v", err)
+	}
+
+	wantVars := []string{"footer", "items", "metadata", "settings", "show_footer", "tags", "user"}
+	if got := shape.Variables(); !reflect.DeepEqual(got, wantVars) {
+		t.Fatalf("expected variables %v, got %v", wantVars, got)
+	}
+
+	user := shape.Properties["user"]
+	if user.Type != "object" || !user.Required {
+		t.Errorf("user should be a required object, got %+v", user)
+	}
+	if !user.Properties["name"].Required || user.Properties["email"].Required {
+		t.Errorf("user.name should be required and user.email optional (default filter)")
+	}
+
+	tags := shape.Properties["tags"]
+	if tags.Type != "array" || !tags.Required || tags.Items == nil || tags.Items.Properties["label"] == nil {
+		t.Errorf("tags should be a required array of objects with label, got %+v", tags)
+	}
+	if shape.Properties["metadata"].Type != "object" {
+		t.Errorf("metadata iterated as key/value should be an object")
+	}
+	if shape.Properties["show_footer"].Required || shape.Properties["footer"].Required {
+		t.Errorf("variables used in conditions and if-blocks should be optional")
+	}
+	if shape.Properties["items"].Type != "array" || shape.Properties["items"].Items.Properties["id"] == nil {
+		t.Errorf("items should be an array with id, got %+v", shape.Properties["items"])
+	}
+	if shape.Properties["settings"].Properties["theme"] == nil {
+		t.Errorf("settings[\"theme\"] should be recorded as a property")
+	}
+}
+
+func TestIntrospectInvalidTemplate(t *testing.T) {
+	if _, err := Introspect(`{% for x in %}`); err == nil {
+		t.Error("Expected an error for an invalid template")
+	}
+}
@@ -0,0 +1,61 @@
+package pongo2
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// layeredLoader is a pongo2.TemplateLoader that looks a template name up in a
+// list of file systems, highest priority first. Names are relative to the layer
+// roots rather than to the including template, so an {% extends %} or
+// {% include %} in a base template still picks up an override of its target.
+type layeredLoader struct {
+	layers []fs.FS
+	names  []string // layer descriptions for error messages
+}
+
+// newDirLoader returns a layeredLoader over directories on disk.
+func newDirLoader(dirs []string) *layeredLoader {
+	l := &layeredLoader{names: dirs}
+	for _, dir := range dirs {
+		l.layers = append(l.layers, os.DirFS(dir))
+	}
+	return l
+}
+
+// Abs returns the layer-relative name of a template. Names are not resolved
+// against the including template, and absolute names are left for Get to
+// reject.
+func (l *layeredLoader) Abs(base, name string) string {
+	return path.Clean(filepath.ToSlash(name))
+}
+
+// Get reads the template from the first layer that contains it. Absolute
+// names and names leading out of the layers with ".." are rejected, so
+// templates can't reach files beside them on the OS file system.
+func (l *layeredLoader) Get(name string) (io.Reader, error) {
+	name = path.Clean(filepath.ToSlash(name))
+	if filepath.IsAbs(name) || !fs.ValidPath(name) {
+		return nil, fmt.Errorf("template %q is outside the template directories", name)
+	}
+	if len(l.layers) == 0 {
+		return nil, fmt.Errorf("template %q can't be loaded without template directories", name)
+	}
+	for _, layer := range l.layers {
+		b, err := fs.ReadFile(layer, name)
+		if err == nil {
+			return bytes.NewReader(b), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("template %q not found in %s", name, strings.Join(l.names, ", "))
+}
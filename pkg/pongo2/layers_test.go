@@ -0,0 +1,126 @@
+package pongo2
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+func writeTemplates(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write template: %v", err)
+		}
+	}
+}
+
+func TestRendererTemplateDirs(t *testing.T) {
+	base, tenant := t.TempDir(), t.TempDir()
+	writeTemplates(t, base, map[string]string{
+		"layout.html":          `[{% include "partials/header.html" %}|{% block body %}base body{% endblock %}]`,
+		"partials/header.html": `base header`,
+		"invoice.html":         `{% extends "layout.html" %}{% block body %}invoice {{ number }}{% endblock %}`,
+	})
+	writeTemplates(t, tenant, map[string]string{
+		"partials/header.html": `ACME header`,
+	})
+
+	tests := []struct {
+		name     string
+		dirs     []string
+		expected string
+	}{
+		{"base only", []string{base}, "[base header|invoice 42]"},
+		{"tenant override", []string{tenant, base}, "[ACME header|invoice 42]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer := NewRenderer(Options{TemplateDirs: tt.dirs})
+			output, err := renderer.RenderFile("invoice.html", pongo2.Context{"number": 42})
+			if err != nil {
+				t.Fatalf("Failed to render template: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestRendererTemplateDirsErrors(t *testing.T) {
+	base := t.TempDir()
+	writeTemplates(t, base, map[string]string{
+		"broken.html": "line one\n{% include \"missing.html\" %}",
+	})
+	renderer := NewRenderer(Options{TemplateDirs: []string{base}})
+
+	if _, err := renderer.RenderFile("../secret.html", nil); err == nil {
+		t.Error("Templates outside the template directories should be rejected")
+	}
+
+	secret := filepath.Join(t.TempDir(), "secret.html")
+	writeTemplates(t, filepath.Dir(secret), map[string]string{"secret.html": "top secret"})
+	for _, tpl := range []string{`{% include "` + secret + `" %}`, `{% include "../` + filepath.Base(filepath.Dir(secret)) + `/secret.html" %}`} {
+		if output, err := renderer.RenderString(tpl, nil); err == nil || strings.Contains(output, "top secret") {
+			t.Errorf("%s: expected an error, got %q", tpl, output)
+		}
+	}
+	if _, err := renderer.RenderFile(secret, nil); err == nil {
+		t.Error("Absolute template names should be rejected")
+	}
+
+	_, err := renderer.RenderFile("broken.html", nil)
+	if err == nil {
+		t.Fatal("Expected an error for a missing include")
+	}
+	if !strings.Contains(err.Error(), "missing.html") {
+		t.Errorf("Error should name the missing template, got: %v", err)
+	}
+}
+
+func TestRendererSandboxFiles(t *testing.T) {
+	dir, outside := t.TempDir(), t.TempDir()
+	writeTemplates(t, dir, map[string]string{"partial.html": "partial"})
+	writeTemplates(t, outside, map[string]string{"secret.txt": "top secret"})
+	secret := filepath.Join(outside, "secret.txt")
+
+	tests := []struct {
+		name     string
+		dirs     []string
+		tpl      string
+		expected string
+	}{
+		{"include", []string{dir}, `{% include "partial.html" %}`, "partial"},
+		{"absolute include", []string{dir}, `{% include "` + secret + `" %}`, ""},
+		{"relative include", []string{dir}, `{% include "../` + filepath.Base(outside) + `/secret.txt" %}`, ""},
+		{"include without dirs", nil, `{% include "partial.html" %}`, ""},
+		{"ssi", []string{dir}, `{% ssi "` + secret + `" %}`, ""},
+		{"import", nil, `{% import "` + secret + `" x %}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := NewRenderer(Options{TemplateDirs: tt.dirs, Sandbox: true}).RenderString(tt.tpl, nil)
+			if tt.expected == "" {
+				if err == nil || strings.Contains(output, "top secret") {
+					t.Errorf("expected an error, got %q", output)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to render template: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, output)
+			}
+		})
+	}
+}
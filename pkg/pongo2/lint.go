@@ -0,0 +1,197 @@
+package pongo2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// LintIssue is a problem found by LintFile.
+type LintIssue struct {
+	// Line and Column give the 1-based position of the problem, or 0 if it
+	// can't be attributed to a position in the template.
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// LintReport is the result of linting one template.
+type LintReport struct {
+	Template string `json:"template"`
+
+	// Variables lists the context variables the template references (see
+	// Introspect). It is empty if the template doesn't compile.
+	Variables []string `json:"variables"`
+
+	Issues []LintIssue `json:"issues"`
+}
+
+// OK reports whether no issues were found.
+func (r *LintReport) OK() bool {
+	return len(r.Issues) == 0
+}
+
+// jsonTemplateSuffixes are the template file extensions that may follow
+// ".json" in the name of a template producing JSON, e.g. "user.json.tpl".
+var jsonTemplateSuffixes = []string{"", ".tpl", ".tmpl", ".j2", ".jinja", ".pongo2"}
+
+// isJSONTemplate reports whether a template name says it produces JSON.
+func isJSONTemplate(name string) bool {
+	for _, suffix := range jsonTemplateSuffixes {
+		if strings.HasSuffix(name, ".json"+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// LintFile checks that a template file compiles, which catches syntax errors,
+// unknown tags and undefined filters, and lists the variables it references.
+//
+// Templates named *.json (optionally followed by a template extension such as
+// .tpl) are also rendered as a skeleton: every {{ ... }} becomes a placeholder
+// (x inside JSON strings, 0 elsewhere) and the template runs against a sample
+// context with one element per array, so literal JSON broken by misplaced
+// quotes, braces or loop commas is reported. Included and parent templates
+// are rendered as skeletons too.
+//
+// An error is returned only if the template can't be read.
+func (r *Renderer) LintFile(name string) (*LintReport, error) {
+	src, err := r.readSource(name)
+	if err != nil {
+		return nil, err
+	}
+	report := &LintReport{Template: name, Variables: []string{}, Issues: []LintIssue{}}
+
+	if _, err := r.FromFile(name); err != nil {
+		report.Issues = append(report.Issues, lintIssue(err))
+		return report, nil
+	}
+	shape := introspect(src)
+	report.Variables = shape.Variables()
+
+	if isJSONTemplate(name) {
+		if issue, ok := r.lintJSONSkeleton(name, shape); !ok {
+			report.Issues = append(report.Issues, issue)
+		}
+	}
+	return report, nil
+}
+
+// lintIssue converts a compile error into an issue.
+func lintIssue(err error) LintIssue {
+	var renderErr *RenderError
+	if errors.As(err, &renderErr) {
+		return LintIssue{Line: renderErr.Line, Column: renderErr.Column, Message: renderErr.Err.Error()}
+	}
+	return LintIssue{Message: err.Error()}
+}
+
+// lintJSONSkeleton renders the skeleton of a JSON template and checks that it
+// is valid JSON.
+func (r *Renderer) lintJSONSkeleton(name string, shape *Shape) (LintIssue, bool) {
+	opts := r.opts
+	opts.OutputMode = ""
+	skeleton := newRenderer(opts, skeletonLoader{r.loaders[0]})
+
+	output, err := skeleton.RenderFile(name, sampleContext(shape))
+	if err != nil {
+		issue := lintIssue(err)
+		issue.Message = "rendering JSON skeleton: " + issue.Message
+		return issue, false
+	}
+
+	var v interface{}
+	if err := json.Unmarshal([]byte(output), &v); err != nil {
+		offset := len(output)
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			offset = int(syntaxErr.Offset)
+		}
+		return LintIssue{Message: fmt.Sprintf("JSON skeleton is invalid: %v near %q", err, excerpt(output, offset))}, false
+	}
+	return LintIssue{}, true
+}
+
+// excerpt returns up to 20 bytes of s before offset.
+func excerpt(s string, offset int) string {
+	if offset > len(s) {
+		offset = len(s)
+	}
+	start := offset - 20
+	if start < 0 {
+		start = 0
+	}
+	return s[start:offset]
+}
+
+// sampleContext builds a context matching a template's shape: objects become
+// maps, arrays hold one sample element and everything else is "x".
+func sampleContext(shape *Shape) map[string]interface{} {
+	ctx := make(map[string]interface{}, len(shape.Properties))
+	for name, prop := range shape.Properties {
+		ctx[name] = sampleValue(prop)
+	}
+	return ctx
+}
+
+func sampleValue(shape *Shape) interface{} {
+	switch shape.Type {
+	case "object":
+		return sampleContext(shape)
+	case "array":
+		items := shape.Items
+		if items == nil {
+			items = &Shape{}
+		}
+		return []interface{}{sampleValue(items)}
+	}
+	return "x"
+}
+
+// skeletonSource replaces every {{ ... }} of a JSON template with a
+// placeholder that is valid where it appears: x inside a JSON string literal
+// and 0 elsewhere.
+func skeletonSource(src string) string {
+	inString, escaped := false, false
+	text := func(s string) {
+		for i := 0; i < len(s); i++ {
+			switch {
+			case escaped:
+				escaped = false
+			case inString && s[i] == '\\':
+				escaped = true
+			case s[i] == '"':
+				inString = !inString
+			}
+		}
+	}
+	variable := func(string) string {
+		if inString {
+			return "x"
+		}
+		return "0"
+	}
+	return rewriteVariables(src, text, variable)
+}
+
+// skeletonLoader applies skeletonSource to the templates it loads.
+type skeletonLoader struct {
+	pongo2.TemplateLoader
+}
+
+func (l skeletonLoader) Get(path string) (io.Reader, error) {
+	rd, err := l.TemplateLoader.Get(path)
+	if err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, err
+	}
+	return strings.NewReader(skeletonSource(string(b))), nil
+}
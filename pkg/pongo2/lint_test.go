@@ -0,0 +1,80 @@
+package pongo2
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLintFile(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplates(t, dir, map[string]string{
+		"user.json.tpl": `{
+  "name": "{{ user.name }}",
+  "age": {{ user.age }},
+  "tags": [{% for tag in tags %}"{{ tag }}"{% if not forloop.Last %}, {% endif %}{% endfor %}]
+}`,
+		"trailing_comma.json.tpl": `{"tags": [{% for tag in tags %}"{{ tag }}",{% endfor %}]}`,
+		"unquoted.json":           `{"name": {{ name }}, "note": "say \"hi\" {{ greeting }}"}`,
+		"broken_quote.json.tpl":   `{"name": "{{ name }}}`,
+		"filter.txt":              "line 1\n{{ name|shout }}",
+		"page.html":               `<p>{{ title }}</p>`,
+	})
+	r := NewRenderer(Options{TemplateDirs: []string{dir}})
+
+	tests := []struct {
+		name      string
+		variables []string
+		issue     string
+		line      int
+	}{
+		{name: "user.json.tpl", variables: []string{"tags", "user"}},
+		{name: "unquoted.json", variables: []string{"greeting", "name"}},
+		{name: "page.html", variables: []string{"title"}},
+		{name: "trailing_comma.json.tpl", variables: []string{"tags"}, issue: "JSON skeleton is invalid"},
+		{name: "broken_quote.json.tpl", variables: []string{"name"}, issue: "JSON skeleton is invalid"},
+		{name: "filter.txt", variables: []string{}, issue: "Filter 'shout' does not exist", line: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := r.LintFile(tt.name)
+			if err != nil {
+				t.Fatalf("LintFile failed: %v", err)
+			}
+			if !reflect.DeepEqual(report.Variables, tt.variables) {
+				t.Errorf("expected variables %v, got %v", tt.variables, report.Variables)
+			}
+			if tt.issue == "" {
+				if !report.OK() {
+					t.Errorf("expected no issues, got %+v", report.Issues)
+				}
+				return
+			}
+			if len(report.Issues) != 1 || !strings.Contains(report.Issues[0].Message, tt.issue) || report.Issues[0].Line != tt.line {
+				t.Errorf("expected one issue %q at line %d, got %+v", tt.issue, tt.line, report.Issues)
+			}
+		})
+	}
+
+	if _, err := r.LintFile("missing.json"); err == nil {
+		t.Error("expected an error for a missing template")
+	}
+}
+
+func TestLintFileFollowsIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplates(t, dir, map[string]string{
+		"order.json.tpl": `{"items": [{% for item in items %}{% include "item.json.tpl" %}{% if not forloop.Last %},{% endif %}{% endfor %}]}`,
+		"item.json.tpl":  `{"sku": "{{ item.sku }}", "qty": {{ item.qty }}`,
+	})
+	r := NewRenderer(Options{})
+
+	report, err := r.LintFile(filepath.Join(dir, "order.json.tpl"))
+	if err != nil {
+		t.Fatalf("LintFile failed: %v", err)
+	}
+	if report.OK() {
+		t.Error("expected the unclosed object in the included template to be reported")
+	}
+}
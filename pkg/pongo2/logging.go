@@ -0,0 +1,39 @@
+package pongo2
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// logCompile logs the compilation of the template name that started at
+// start and failed with *err, if not nil.
+func (r *Renderer) logCompile(name string, start time.Time, err *error) {
+	r.log("template compiled", "template compile failed", name, start, *err)
+}
+
+// logRender logs a render of the template name.
+func (r *Renderer) logRender(name string, start time.Time, err *error) {
+	r.log("template rendered", "template render failed", name, start, *err)
+}
+
+// observeRender reports a render that started at start to the metrics
+// recorder, if there is one.
+func (r *Renderer) observeRender(start time.Time, output *string, err *error) {
+	if r.opts.Metrics != nil {
+		r.opts.Metrics.ObserveRender(time.Since(start), len(*output), *err)
+	}
+}
+
+func (r *Renderer) log(okMsg, failMsg, name string, start time.Time, err error) {
+	logger := r.opts.Logger
+	if logger == nil {
+		return
+	}
+	attrs := []slog.Attr{slog.String("template", name), slog.Duration("duration", time.Since(start))}
+	if err != nil {
+		logger.LogAttrs(context.Background(), slog.LevelWarn, failMsg, append(attrs, slog.Any("error", err))...)
+		return
+	}
+	logger.LogAttrs(context.Background(), slog.LevelDebug, okMsg, attrs...)
+}
@@ -0,0 +1,91 @@
+package pongo2
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+func TestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "duration" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	r := NewRenderer(Options{Logger: logger})
+
+	if _, err := r.RenderString("Hi {{ name }}", pongo2.Context{"name": "Ada"}); err != nil {
+		t.Fatalf("RenderString failed: %v", err)
+	}
+	if _, err := r.RenderString("{% if %}", nil); err == nil {
+		t.Fatal("RenderString should fail on a syntax error")
+	}
+	if _, err := r.RenderString("{{ n|sort_by_key:1 }}", pongo2.Context{"n": 1}); err == nil {
+		t.Fatal("RenderString should fail on a filter error")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		`level=DEBUG msg="template compiled" template=<string>`,
+		`level=DEBUG msg="template rendered" template=<string>`,
+		`level=WARN msg="template compile failed" template=<string> error=`,
+		`level=DEBUG msg="template compiled" template=<string>`,
+		`level=WARN msg="template render failed" template=<string> error=`,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d log lines, got:\n%s", len(want), buf.String())
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("line %d: expected prefix %q, got %q", i+1, prefix, lines[i])
+		}
+	}
+
+	buf.Reset()
+	quiet := NewRenderer(Options{Logger: slog.New(slog.NewTextHandler(&buf, nil))})
+	if _, err := quiet.RenderString("Hi", nil); err != nil {
+		t.Fatalf("RenderString failed: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("debug events should be filtered at info level, got %s", buf.String())
+	}
+}
+
+// renderRecorder is a metrics.Recorder that keeps the renders it observes.
+type renderRecorder struct {
+	sizes []int
+	errs  []error
+}
+
+func (r *renderRecorder) ObserveValidation(string, time.Duration) {}
+
+func (r *renderRecorder) ObserveRender(_ time.Duration, outputBytes int, err error) {
+	r.sizes = append(r.sizes, outputBytes)
+	r.errs = append(r.errs, err)
+}
+
+func TestRenderMetrics(t *testing.T) {
+	rec := &renderRecorder{}
+	r := NewRenderer(Options{Metrics: rec})
+	if _, err := r.RenderString("Hi {{ name }}", pongo2.Context{"name": "Ada"}); err != nil {
+		t.Fatalf("RenderString failed: %v", err)
+	}
+	if _, err := r.RenderString("{% if %}", nil); err == nil {
+		t.Fatal("RenderString should fail on a syntax error")
+	}
+	if _, err := r.RenderString("{{ n|sort_by_key:1 }}", pongo2.Context{"n": 1}); err == nil {
+		t.Fatal("RenderString should fail on a filter error")
+	}
+	if len(rec.sizes) != 2 || rec.sizes[0] != 6 || rec.errs[0] != nil || rec.errs[1] == nil {
+		t.Errorf("expected a successful 6-byte render and a failed one, got sizes %v and errors %v", rec.sizes, rec.errs)
+	}
+}
@@ -0,0 +1,78 @@
+package pongo2
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/flosch/pongo2/v6"
+
+	"go-demo/pkg/jsonutil"
+)
+
+// filterMerge deep-merges a map parameter into the input map. Nested maps are
+// merged key by key; arrays are replaced unless a strategy ("append",
+// "merge", which merges elements by index, or "by-key=KEY", which merges
+// objects with the same KEY) is passed alongside the override:
+//
+//	{{ base|merge:overrides|to_json }}
+//	{{ base|merge:list(overrides, "append")|to_json }}
+//	{{ base|merge:list(overrides, "by-key=id")|to_json }}
+func filterMerge(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	override, strategy := param.Interface(), "replace"
+	if args, ok := toGeneric(override).([]interface{}); ok {
+		if len(args) != 2 {
+			return nil, &pongo2.Error{
+				Sender:    "filter:merge",
+				OrigError: fmt.Errorf("expected a map or list(map, strategy), got %d arguments", len(args)),
+			}
+		}
+		override, strategy = args[0], fmt.Sprint(args[1])
+	}
+	arrays, err := jsonutil.ParseArrayStrategy(strategy)
+	if err != nil {
+		return nil, &pongo2.Error{Sender: "filter:merge", OrigError: err}
+	}
+
+	base, overrides := toGeneric(in.Interface()), toGeneric(override)
+	for _, v := range []interface{}{base, overrides} {
+		if _, ok := v.(map[string]interface{}); !ok && v != nil {
+			return nil, &pongo2.Error{Sender: "filter:merge", OrigError: fmt.Errorf("cannot merge %T", v)}
+		}
+	}
+	return pongo2.AsValue(jsonutil.Merge(base, overrides, arrays)), nil
+}
+
+// toGeneric converts maps with string keys and slices (at any depth) into
+// map[string]interface{} and []interface{}, the shapes jsonutil works on.
+// Other values are returned unchanged.
+func toGeneric(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return value
+		}
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = toGeneric(iter.Value().Interface())
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return value
+		}
+		s := make([]interface{}, v.Len())
+		for i := range s {
+			s[i] = toGeneric(v.Index(i).Interface())
+		}
+		return s
+	}
+	return value
+}
@@ -0,0 +1,56 @@
+package pongo2
+
+import (
+	"testing"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+func TestMergeFilter(t *testing.T) {
+	ctx := pongo2.Context{
+		"base": map[string]interface{}{
+			"title": "Invoice",
+			"style": map[string]string{"font": "serif", "size": "12pt"},
+			"tags":  []string{"finance"},
+		},
+		"override": pongo2.Context{
+			"style": map[string]interface{}{"size": "10pt"},
+			"tags":  []string{"draft"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"deep merge", `{{ base|merge:override|to_json }}`, `{"style":{"font":"serif","size":"10pt"},"tags":["draft"],"title":"Invoice"}`},
+		{"append arrays", `{{ base|merge:list(override, "append")|get:"tags"|join:"," }}`, "finance,draft"},
+		{"dict override", `{{ base|merge:dict("title", "Quote")|get:"title" }}`, "Quote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := NewRenderer(Options{}).RenderString(tt.template, ctx)
+			if err != nil {
+				t.Fatalf("Failed to execute template: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestMergeFilterErrors(t *testing.T) {
+	renderer := NewRenderer(Options{})
+	for _, tpl := range []string{
+		`{{ base|merge:list(base, "concat") }}`,
+		`{{ base|merge:"text" }}`,
+		`{{ "text"|merge:base }}`,
+	} {
+		if _, err := renderer.RenderString(tpl, pongo2.Context{"base": map[string]interface{}{}}); err == nil {
+			t.Errorf("%s: expected an error", tpl)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package pongo2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+
+	"go-demo/pkg/bufpool"
+)
+
+// OutputMode selects how interpolated variables ({{ ... }}) are escaped.
+type OutputMode string
+
+const (
+	// OutputHTML escapes strings for HTML, like pongo2's autoescaping.
+	OutputHTML OutputMode = "html"
+	// OutputJSON escapes strings for use inside a JSON string literal
+	// ("name": "{{ name }}"); lists and maps are written as JSON.
+	OutputJSON OutputMode = "json"
+	// OutputXML escapes the XML special characters & < > " and '.
+	OutputXML OutputMode = "xml"
+	// OutputMarkdown backslash-escapes Markdown punctuation such as * _ [ ] and #.
+	OutputMarkdown OutputMode = "markdown"
+	// OutputText writes values unescaped.
+	OutputText OutputMode = "text"
+)
+
+// escapeKey is the context function every {{ ... }} is routed through when
+// a Renderer has an output mode (see rewriteOutputs).
+const escapeKey = "_escape"
+
+var (
+	xmlEscaper      = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+	markdownEscaper = strings.NewReplacer(
+		`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+		"<", `\<`, ">", `\>`, "|", `\|`, "#", `\#`,
+	)
+)
+
+// escaperFor returns the function that escapes interpolated values for mode.
+// Values marked safe (by |safe or filters such as to_json) are written as is.
+func escaperFor(mode OutputMode) (func(*pongo2.Value) *pongo2.Value, error) {
+	var escape func(*pongo2.Value) string
+	switch mode {
+	case OutputHTML:
+		escape = func(v *pongo2.Value) string {
+			if !v.IsString() {
+				return v.String()
+			}
+			escaped, _ := pongo2.ApplyFilter("escape", v, nil)
+			return escaped.String()
+		}
+	case OutputJSON:
+		escape = escapeJSON
+	case OutputXML:
+		escape = func(v *pongo2.Value) string { return xmlEscaper.Replace(v.String()) }
+	case OutputMarkdown:
+		escape = func(v *pongo2.Value) string { return markdownEscaper.Replace(v.String()) }
+	case OutputText:
+		escape = func(v *pongo2.Value) string { return v.String() }
+	default:
+		return nil, fmt.Errorf("unknown output mode %q", mode)
+	}
+	return func(v *pongo2.Value) *pongo2.Value {
+		if isSafe(v) {
+			return v
+		}
+		return pongo2.AsSafeValue(escape(v))
+	}, nil
+}
+
+// escapeJSON writes strings as the content of a JSON string literal, booleans
+// as true/false and lists, maps and structs as JSON.
+func escapeJSON(v *pongo2.Value) string {
+	var data interface{}
+	switch {
+	case v.IsNil():
+		return ""
+	case v.IsString():
+		data = v.String()
+	case v.IsBool():
+		return fmt.Sprint(v.Bool())
+	case v.IsNumber():
+		return v.String()
+	default:
+		data = v.Interface()
+	}
+
+	buf := bufpool.Get()
+	defer bufpool.Put(buf)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(data); err != nil {
+		return v.String()
+	}
+	out := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	if v.IsString() {
+		out = out[1 : len(out)-1]
+	}
+	return string(out)
+}
+
+// escapeOutput escapes a value a tag writes directly, using the output mode
+// of the current render if there is one.
+func escapeOutput(ctx *pongo2.ExecutionContext, v *pongo2.Value) *pongo2.Value {
+	if escape, ok := ctx.Public[escapeKey].(func(*pongo2.Value) *pongo2.Value); ok {
+		return escape(v)
+	}
+	return v
+}
+
+// isSafe reports whether a value was marked safe. pongo2 doesn't export the
+// flag, but reflection may read it.
+func isSafe(v *pongo2.Value) bool {
+	f := reflect.ValueOf(v).Elem().FieldByName("safe")
+	return f.IsValid() && f.Bool()
+}
+
+var reEndVerbatim = regexp.MustCompile(`\{%-?\s*endverbatim\s*-?%\}`)
+
+// rewriteOutputs routes every {{ expr }} of a template through the escape
+// function: {{ _escape(expr) }}, except for expressions using |safe. pongo2 can't apply filters to arbitrary
+// expressions, but it can call functions on them, and the function is looked
+// up at render time, so the output mode can change per render.
+// Comments and {% verbatim %} blocks are left alone.
+func rewriteOutputs(src string) string {
+	return rewriteVariables(src, nil, wrapOutput)
+}
+
+// rewriteVariables rebuilds template source with the inside of every {{ ... }}
+// tag replaced by variable(inner). Block tags, comments and {% verbatim %}
+// blocks are kept as written. If text is not nil, it sees the literal text
+// between tags in order, interleaved with the calls to variable.
+func rewriteVariables(src string, text func(string), variable func(inner string) string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(src, "{")
+		for start >= 0 && (start+1 >= len(src) || !strings.ContainsRune("{%#", rune(src[start+1]))) {
+			next := strings.Index(src[start+1:], "{")
+			if next < 0 {
+				start = -1
+				break
+			}
+			start += next + 1
+		}
+		if start < 0 {
+			if text != nil {
+				text(src)
+			}
+			b.WriteString(src)
+			return b.String()
+		}
+		if text != nil {
+			text(src[:start])
+		}
+		b.WriteString(src[:start])
+		src = src[start:]
+
+		closing := map[byte]string{'{': "}}", '%': "%}", '#': "#}"}[src[1]]
+		end := findClosing(src, 2, closing)
+		if end < 0 {
+			b.WriteString(src)
+			return b.String()
+		}
+		end += len(closing)
+
+		switch src[1] {
+		case '{':
+			b.WriteString(variable(src[2 : end-2]))
+		case '%':
+			tag := strings.Fields(strings.Trim(src[2:end-2], "- \t\r\n"))
+			if len(tag) > 0 && tag[0] == "verbatim" {
+				if loc := reEndVerbatim.FindStringIndex(src[end:]); loc != nil {
+					end += loc[1]
+				}
+			}
+			b.WriteString(src[:end])
+		default:
+			b.WriteString(src[:end])
+		}
+		src = src[end:]
+	}
+}
+
+// wrapOutput rewrites the inside of a {{ ... }} tag, keeping "-" trim markers.
+func wrapOutput(inner string) string {
+	expr := inner
+	lead, trail := "", ""
+	if strings.HasPrefix(expr, "-") {
+		lead, expr = "-", expr[1:]
+	}
+	if strings.HasSuffix(expr, "-") {
+		trail, expr = "-", expr[:len(expr)-1]
+	}
+	expr = strings.TrimSpace(expr)
+	if expr == "" || appliesSafe(expr) {
+		return "{{" + inner + "}}"
+	}
+	return "{{" + lead + " " + escapeKey + "(" + expr + ") " + trail + "}}"
+}
+
+// appliesSafe reports whether an expression uses the safe filter. pongo2
+// honours |safe by inspecting the variable tag rather than marking the value,
+// so such tags must stay as written.
+func appliesSafe(expr string) bool {
+	toks := lexExpr(expr)
+	for i := 1; i < len(toks); i++ {
+		if toks[i-1].val == "|" && toks[i].kind == 'i' && toks[i].val == "safe" {
+			return true
+		}
+	}
+	return false
+}
+
+// escapingLoader applies rewriteOutputs to every template it loads, so
+// included and parent templates are escaped too.
+type escapingLoader struct {
+	pongo2.TemplateLoader
+}
+
+func (l escapingLoader) Get(path string) (io.Reader, error) {
+	rd, err := l.TemplateLoader.Get(path)
+	if err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, err
+	}
+	return strings.NewReader(rewriteOutputs(string(b))), nil
+}
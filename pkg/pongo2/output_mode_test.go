@@ -0,0 +1,128 @@
+package pongo2
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+func TestOutputModes(t *testing.T) {
+	ctx := pongo2.Context{
+		"name": `Tom & "Jerry" <*cats*>`,
+		"tags": []string{"a", "b"},
+		"ok":   true,
+	}
+
+	tests := []struct {
+		mode     OutputMode
+		template string
+		expected string
+	}{
+		{OutputHTML, `<p>{{ name }}</p>`, `<p>Tom &amp; &quot;Jerry&quot; &lt;*cats*&gt;</p>`},
+		{OutputJSON, `{"name": "{{ name }}", "tags": {{ tags }}, "ok": {{ ok }}}`, `{"name": "Tom & \"Jerry\" <*cats*>", "tags": ["a","b"], "ok": true}`},
+		{OutputXML, `<name>{{ name }}</name>`, `<name>Tom &amp; &quot;Jerry&quot; &lt;*cats*&gt;</name>`},
+		{OutputMarkdown, `# {{ name }}`, `# Tom & "Jerry" \<\*cats\*\>`},
+		{OutputText, `{{ name }}`, `Tom & "Jerry" <*cats*>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			output, err := NewRenderer(Options{OutputMode: tt.mode}).RenderString(tt.template, ctx)
+			if err != nil {
+				t.Fatalf("Failed to render template: %v", err)
+			}
+			if output != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestOutputModeJSONDocument(t *testing.T) {
+	templateString := `{
+  "title": "{{ title|upper }}",
+  "raw": {{ title|to_json }},
+  {# {{ not rewritten }} #}
+  "sum": "{{ a + b }}",
+  "trimmed": "{{- title -}}"
+}`
+	renderer := NewRenderer(Options{OutputMode: OutputJSON})
+	output, err := renderer.RenderString(templateString, pongo2.Context{"title": "a\\b \"c\"\n", "a": 1, "b": 2})
+	if err != nil {
+		t.Fatalf("Failed to render template: %v", err)
+	}
+
+	var decoded map[string]string
+	if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+		t.Fatalf("Rendered output should be valid JSON: %v\nOutput: %s", err, output)
+	}
+	if decoded["title"] != "A\\B \"C\"\n" || decoded["raw"] != "a\\b \"c\"\n" || decoded["sum"] != "3" {
+		t.Errorf("unexpected values: %#v", decoded)
+	}
+}
+
+func TestOutputModePerRender(t *testing.T) {
+	renderer := NewRenderer(Options{OutputMode: OutputHTML})
+	tpl := `{{ v }}|{{ v|safe }}`
+	ctx := pongo2.Context{"v": "<b>"}
+
+	output, err := renderer.RenderString(tpl, ctx)
+	if err != nil {
+		t.Fatalf("Failed to render template: %v", err)
+	}
+	if output != "&lt;b&gt;|<b>" {
+		t.Errorf("html: got %q", output)
+	}
+
+	output, err = renderer.RenderString(tpl, ctx, WithOutputMode(OutputText))
+	if err != nil {
+		t.Fatalf("Failed to render template: %v", err)
+	}
+	if output != "<b>|<b>" {
+		t.Errorf("text: got %q", output)
+	}
+	if _, ok := ctx[escapeKey]; ok {
+		t.Error("Per-render options should not modify the caller's context")
+	}
+
+	if _, err := NewRenderer(Options{}).RenderString(tpl, ctx, WithOutputMode(OutputText)); err == nil {
+		t.Error("Output modes should require a Renderer with Options.OutputMode")
+	}
+	if _, err := NewRenderer(Options{OutputMode: "yaml"}).RenderString(tpl, ctx); err == nil || !strings.Contains(err.Error(), "unknown output mode") {
+		t.Errorf("Expected an unknown output mode error, got %v", err)
+	}
+}
+
+func TestOutputModeErrorPositions(t *testing.T) {
+	tpl := "{{ a }}\n{{ b }} {% if %}"
+	_, want := NewRenderer(Options{}).RenderString(tpl, nil)
+	_, err := NewRenderer(Options{OutputMode: OutputJSON}).RenderString(tpl, nil)
+	if err == nil || want == nil {
+		t.Fatal("Expected a syntax error")
+	}
+	if err.Error() != want.Error() {
+		t.Errorf("Error should point at the original source:\n%v\nwant:\n%v", err, want)
+	}
+}
+
+func TestRewriteOutputs(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{`a {{ x }} b`, `a {{ _escape(x) }} b`},
+		{`{{- x|upper -}}`, `{{- _escape(x|upper) -}}`},
+		{`{{ "}}" }}`, `{{ _escape("}}") }}`},
+		{`{{ x|safe }}`, `{{ x|safe }}`},
+		{`{# {{ x }} #}{% if y %}{{ y }}{% endif %}`, `{# {{ x }} #}{% if y %}{{ _escape(y) }}{% endif %}`},
+		{`{% verbatim %}{{ x }}{% endverbatim %}{{ x }}`, `{% verbatim %}{{ x }}{% endverbatim %}{{ _escape(x) }}`},
+		{`{ {x} }`, `{ {x} }`},
+	}
+	for _, tt := range tests {
+		if got := rewriteOutputs(tt.src); got != tt.expected {
+			t.Errorf("rewriteOutputs(%q): expected %q, got %q", tt.src, tt.expected, got)
+		}
+	}
+}
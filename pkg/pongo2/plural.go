@@ -0,0 +1,187 @@
+package pongo2
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// pluralRule describes how many plural forms a language uses and which one
+// applies to a given integer n. Forms are listed in CLDR category order (one, few, many, other),
+// skipping categories the language doesn't use for integers.
+type pluralRule struct {
+	forms int
+	index func(n int64) int
+
+	// intPart is set if fractions take the form of their integer part, as in
+	// French where 1.5 is singular; otherwise they take the last form.
+	intPart bool
+}
+
+var (
+	// one, other: English, German, Spanish, Italian, ...
+	pluralOneOther = pluralRule{forms: 2, index: func(n int64) int {
+		if n == 1 {
+			return 0
+		}
+		return 1
+	}}
+
+	// one (0 up to but not including 2, such as 1.5), other: French, Brazilian Portuguese
+	pluralZeroOneOther = pluralRule{forms: 2, intPart: true, index: func(n int64) int {
+		if n == 0 || n == 1 {
+			return 0
+		}
+		return 1
+	}}
+
+	// other only: Japanese, Chinese, Korean, ...
+	pluralOther = pluralRule{forms: 1, index: func(n int64) int {
+		return 0
+	}}
+
+	// one, few, many: Russian, Ukrainian, Belarusian, Serbian, Croatian
+	pluralEastSlavic = pluralRule{forms: 3, index: func(n int64) int {
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return 0
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return 1
+		default:
+			return 2
+		}
+	}}
+
+	// one, few, many: Polish
+	pluralPolish = pluralRule{forms: 3, index: func(n int64) int {
+		switch {
+		case n == 1:
+			return 0
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return 1
+		default:
+			return 2
+		}
+	}}
+
+	// one, few, other: Czech, Slovak
+	pluralWestSlavic = pluralRule{forms: 3, index: func(n int64) int {
+		switch {
+		case n == 1:
+			return 0
+		case n >= 2 && n <= 4:
+			return 1
+		default:
+			return 2
+		}
+	}}
+)
+
+// pluralRules maps base language codes to their plural rule.
+// Languages not listed here use pluralOneOther.
+var pluralRules = map[string]pluralRule{
+	"fr": pluralZeroOneOther,
+	"ja": pluralOther,
+	"zh": pluralOther,
+	"ko": pluralOther,
+	"vi": pluralOther,
+	"th": pluralOther,
+	"id": pluralOther,
+	"ru": pluralEastSlavic,
+	"uk": pluralEastSlavic,
+	"be": pluralEastSlavic,
+	"sr": pluralEastSlavic,
+	"hr": pluralEastSlavic,
+	"bs": pluralEastSlavic,
+	"pl": pluralPolish,
+	"cs": pluralWestSlavic,
+	"sk": pluralWestSlavic,
+}
+
+// pluralRuleFor returns the plural rule for a locale such as "de-DE" or "pt_BR".
+func pluralRuleFor(locale string) pluralRule {
+	locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	if locale == "pt-br" {
+		return pluralZeroOneOther
+	}
+	lang, _, _ := strings.Cut(locale, "-")
+	if rule, ok := pluralRules[lang]; ok {
+		return rule
+	}
+	return pluralOneOther
+}
+
+var reLocalePrefix = regexp.MustCompile(`^[a-zA-Z]{2,3}([-_][a-zA-Z0-9]+)*$`)
+
+// filterPluralize selects a plural form for a number.
+//
+// With explicit forms separated by "|" (or a locale prefix), the form is chosen
+// by the plural rules of that locale (English by default):
+//
+//	{{ n }} {{ n|pluralize:"item|items" }}
+//	{{ n }} {{ n|pluralize:"ru:файл|файла|файлов" }}
+//
+// Without either, the filter keeps pongo2's built-in suffix behaviour
+// ("s" by default, "es", or "y,ies").
+func filterPluralize(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	if !in.IsNumber() {
+		return nil, &pongo2.Error{
+			Sender:    "filter:pluralize",
+			OrigError: errors.New("filter 'pluralize' does only work on numbers"),
+		}
+	}
+
+	arg := param.String()
+	locale := "en"
+	if prefix, rest, ok := strings.Cut(arg, ":"); ok && reLocalePrefix.MatchString(prefix) {
+		locale, arg = prefix, rest
+	} else if !strings.Contains(arg, "|") {
+		return pluralizeSuffix(in, arg)
+	}
+
+	forms := strings.Split(arg, "|")
+	rule := pluralRuleFor(locale)
+	if len(forms) != rule.forms {
+		return nil, &pongo2.Error{
+			Sender:    "filter:pluralize",
+			OrigError: fmt.Errorf("locale %q expects %d plural forms, got %d", locale, rule.forms, len(forms)),
+		}
+	}
+
+	// Fractional numbers take the last ("other") form, unless the locale
+	// counts them by their integer part.
+	if in.IsFloat() && in.Float() != float64(int64(in.Float())) && !rule.intPart {
+		return pongo2.AsValue(forms[len(forms)-1]), nil
+	}
+	n := int64(in.Integer())
+	if n < 0 {
+		n = -n
+	}
+	return pongo2.AsValue(forms[rule.index(n)]), nil
+}
+
+// pluralizeSuffix implements pongo2's built-in "pluralize" suffix semantics.
+func pluralizeSuffix(in *pongo2.Value, arg string) (*pongo2.Value, *pongo2.Error) {
+	singular, plural := "", "s"
+	if arg != "" {
+		endings := strings.Split(arg, ",")
+		switch len(endings) {
+		case 1:
+			plural = endings[0]
+		case 2:
+			singular, plural = endings[0], endings[1]
+		default:
+			return nil, &pongo2.Error{
+				Sender:    "filter:pluralize",
+				OrigError: errors.New("you cannot pass more than 2 arguments to filter 'pluralize'"),
+			}
+		}
+	}
+	if in.Integer() == 1 {
+		return pongo2.AsValue(singular), nil
+	}
+	return pongo2.AsValue(plural), nil
+}
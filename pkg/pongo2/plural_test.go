@@ -0,0 +1,62 @@
+package pongo2
+
+import (
+	"testing"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+func TestPluralizeFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		count    interface{}
+		want     string
+	}{
+		{"English singular", `{{ n }} {{ n|pluralize:"item|items" }}`, 1, "1 item"},
+		{"English plural", `{{ n }} {{ n|pluralize:"item|items" }}`, 3, "3 items"},
+		{"English zero", `{{ n }} {{ n|pluralize:"item|items" }}`, 0, "0 items"},
+		{"Explicit locale", `{{ n|pluralize:"de-DE:Artikel|Artikel" }}`, 2, "Artikel"},
+		{"French zero is singular", `{{ n|pluralize:"fr:article|articles" }}`, 0, "article"},
+		{"French fraction below 2 is singular", `{{ n|pluralize:"fr:article|articles" }}`, 1.5, "article"},
+		{"French fraction below 1 is singular", `{{ n|pluralize:"fr:article|articles" }}`, 0.5, "article"},
+		{"French fraction from 2 is plural", `{{ n|pluralize:"fr:article|articles" }}`, 2.5, "articles"},
+		{"French negative fraction", `{{ n|pluralize:"fr:article|articles" }}`, -1.5, "article"},
+		{"Brazilian Portuguese fraction", `{{ n|pluralize:"pt-BR:item|itens" }}`, 1.25, "item"},
+		{"Russian one", `{{ n|pluralize:"ru:файл|файла|файлов" }}`, 21, "файл"},
+		{"Russian few", `{{ n|pluralize:"ru:файл|файла|файлов" }}`, 23, "файла"},
+		{"Russian many", `{{ n|pluralize:"ru:файл|файла|файлов" }}`, 12, "файлов"},
+		{"Polish many", `{{ n|pluralize:"pl:plik|pliki|plików" }}`, 25, "plików"},
+		{"Japanese single form", `{{ n|pluralize:"ja:個" }}`, 5, "個"},
+		{"Fraction uses other", `{{ n|pluralize:"item|items" }}`, 1.5, "items"},
+		{"Negative", `{{ n|pluralize:"item|items" }}`, -1, "item"},
+		{"Built-in default suffix", `item{{ n|pluralize }}`, 2, "items"},
+		{"Built-in two suffixes", `cherr{{ n|pluralize:"y,ies" }}`, 1, "cherry"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tpl, err := pongo2.FromString(tt.template)
+			if err != nil {
+				t.Fatalf("Failed to parse template: %v", err)
+			}
+			got, err := tpl.Execute(pongo2.Context{"n": tt.count})
+			if err != nil {
+				t.Fatalf("Failed to execute template: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPluralizeFilterWrongFormCount(t *testing.T) {
+	tpl, err := pongo2.FromString(`{{ n|pluralize:"ru:файл|файлов" }}`)
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	if _, err := tpl.Execute(pongo2.Context{"n": 2}); err == nil {
+		t.Error("Expected an error for a wrong number of plural forms")
+	}
+}
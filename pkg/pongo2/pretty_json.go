@@ -0,0 +1,68 @@
+package pongo2
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// filterToPrettyJSON encodes a value as indented JSON. The parameter is the
+// number of spaces per indentation level (default 2):
+//
+//	{{ config|to_pretty_json:4 }}
+//
+// Like to_json, the result is marked safe.
+func filterToPrettyJSON(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	width := 2
+	if !param.IsNil() {
+		width = param.Integer()
+	}
+	if width < 0 || width > 16 {
+		return nil, &pongo2.Error{
+			Sender:    "filter:to_pretty_json",
+			OrigError: fmt.Errorf("indent width must be between 0 and 16, got %d", width),
+		}
+	}
+
+	b, err := json.MarshalIndent(in.Interface(), "", strings.Repeat(" ", width))
+	if err != nil {
+		return nil, &pongo2.Error{Sender: "filter:to_pretty_json", OrigError: err}
+	}
+	return pongo2.AsSafeValue(string(b)), nil
+}
+
+// filterIndent indents every line but the first, so a multi-line fragment can be
+// placed at the template's current nesting level:
+//
+//	"config": {{ config|to_pretty_json:2|indent:2 }}
+//
+// The parameter is a number of spaces (default 4) or a literal prefix string.
+// Blank lines are left empty. The result is marked safe since the filter is
+// meant for structured fragments such as to_json output; apply escape first
+// when indenting untrusted text.
+func filterIndent(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	prefix := strings.Repeat(" ", 4)
+	switch {
+	case param.IsNil():
+	case param.IsNumber():
+		if param.Integer() < 0 {
+			return nil, &pongo2.Error{
+				Sender:    "filter:indent",
+				OrigError: fmt.Errorf("indent width must not be negative, got %d", param.Integer()),
+			}
+		}
+		prefix = strings.Repeat(" ", param.Integer())
+	default:
+		prefix = param.String()
+	}
+
+	lines := strings.Split(in.String(), "\n")
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != "" {
+			lines[i] = prefix + lines[i]
+		}
+	}
+	return pongo2.AsSafeValue(strings.Join(lines, "\n")), nil
+}
@@ -0,0 +1,75 @@
+package pongo2
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+func TestToPrettyJSONFilter(t *testing.T) {
+	tpl, err := pongo2.FromString(`{{ config|to_pretty_json:2 }}`)
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	output, err := tpl.Execute(pongo2.Context{
+		"config": map[string]interface{}{"name": `a "quoted" name`, "ports": []int{80, 443}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to execute template: %v", err)
+	}
+
+	want := "{\n  \"name\": \"a \\\"quoted\\\" name\",\n  \"ports\": [\n    80,\n    443\n  ]\n}"
+	if output != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, output)
+	}
+}
+
+func TestToPrettyJSONWithIndent(t *testing.T) {
+	templateString := `{
+  "service": {
+    "config": {{ config|to_pretty_json:2|indent:4 }}
+  }
+}`
+	tpl, err := pongo2.FromString(templateString)
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	output, err := tpl.Execute(pongo2.Context{
+		"config": map[string]interface{}{"debug": true, "tags": []string{"a"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to execute template: %v", err)
+	}
+
+	want := `{
+  "service": {
+    "config": {
+      "debug": true,
+      "tags": [
+        "a"
+      ]
+    }
+  }
+}`
+	if output != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, output)
+	}
+	if !json.Valid([]byte(output)) {
+		t.Errorf("Rendered output should be valid JSON:\n%s", output)
+	}
+}
+
+func TestIndentFilterPrefix(t *testing.T) {
+	tpl, err := pongo2.FromString(`{{ text|indent:"> " }}`)
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	output, err := tpl.Execute(pongo2.Context{"text": "a\n\nb"})
+	if err != nil {
+		t.Fatalf("Failed to execute template: %v", err)
+	}
+	if output != "a\n\n> b" {
+		t.Errorf("expected %q, got %q", "a\n\n> b", output)
+	}
+}
@@ -0,0 +1,28 @@
+package pongo2
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// RegisterFilters registers a set of filters with pongo2. It fails without
+// registering anything if one of the names is already taken, so a filter pack
+// can't silently shadow a built-in or another pack.
+func RegisterFilters(filters map[string]pongo2.FilterFunction) error {
+	names := make([]string, 0, len(filters))
+	for name := range filters {
+		if pongo2.FilterExists(name) {
+			return fmt.Errorf("filter %q is already registered", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := pongo2.RegisterFilter(name, filters[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
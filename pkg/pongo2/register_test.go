@@ -0,0 +1,37 @@
+package pongo2
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// registerFiltersRuns counts the runs of TestRegisterFilters. pongo2 can't
+// unregister filters, so each run registers a new name for go test -count.
+var registerFiltersRuns int
+
+func TestRegisterFilters(t *testing.T) {
+	identity := func(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+		return in, nil
+	}
+	registerFiltersRuns++
+	name := fmt.Sprintf("test_pack_a%d", registerFiltersRuns)
+
+	if err := RegisterFilters(map[string]pongo2.FilterFunction{
+		name:    identity,
+		"upper": identity,
+	}); err == nil {
+		t.Fatal("Registering an existing filter name should fail")
+	}
+	if pongo2.FilterExists(name) {
+		t.Error("A failed registration should not register any filter")
+	}
+
+	if err := RegisterFilters(map[string]pongo2.FilterFunction{name: identity}); err != nil {
+		t.Fatalf("RegisterFilters failed: %v", err)
+	}
+	if output := executeString(t, `{{ "x"|`+name+` }}`, nil); output != "x" {
+		t.Errorf("expected %q, got %q", "x", output)
+	}
+}
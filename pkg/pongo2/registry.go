@@ -0,0 +1,118 @@
+package pongo2
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// ErrTemplateNotFound is returned (wrapped in a *RenderError) when a Registry
+// has no template of the requested name.
+var ErrTemplateNotFound = errors.New("template not found")
+
+// Registry holds every template of a directory, compiled up front, so syntax
+// errors surface at startup rather than on first use. It is safe for
+// concurrent use.
+type Registry struct {
+	renderer  *Renderer
+	templates map[string]*pongo2.Template
+	versions  map[string][]templateVersion // by family name, see versions.go
+}
+
+// LoadDir compiles all templates below dir. See LoadFS.
+func LoadDir(dir string, opts Options) (*Registry, error) {
+	return LoadFS(os.DirFS(dir), opts)
+}
+
+// MustLoadDir is like LoadDir but panics if any template fails to compile.
+func MustLoadDir(dir string, opts Options) *Registry {
+	reg, err := LoadDir(dir, opts)
+	if err != nil {
+		panic(err)
+	}
+	return reg
+}
+
+// LoadFS compiles every file in fsys, skipping hidden files and directories.
+// Templates are named by their slash-separated path ("emails/welcome.txt"),
+// and {% extends %} / {% include %} resolve within fsys (opts.TemplateDirs
+// is ignored). All compile errors are reported together.
+func LoadFS(fsys fs.FS, opts Options) (*Registry, error) {
+	reg := &Registry{
+		renderer:  newRenderer(opts, &layeredLoader{layers: []fs.FS{fsys}, names: []string{"registry"}}),
+		templates: make(map[string]*pongo2.Template),
+	}
+
+	var errs []error
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name != "." && strings.HasPrefix(path.Base(name), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		t, err := reg.renderer.FromFile(name)
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		reg.templates[name] = t
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("compile templates: %w", errors.Join(errs...))
+	}
+	reg.indexVersions()
+	return reg, nil
+}
+
+// Names returns the names of all templates in the registry, sorted.
+func (reg *Registry) Names() []string {
+	names := make([]string, 0, len(reg.templates))
+	for name := range reg.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Template returns the named template, compiled, to render it without looking
+// it up again. Versioned templates can be selected with a version reference
+// (see Resolve).
+func (reg *Registry) Template(name string) (*Template, error) {
+	name, err := reg.Resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{renderer: reg.renderer, template: reg.templates[name], name: name}, nil
+}
+
+// Render executes the named template. Versioned templates can be selected
+// with a version reference (see Resolve).
+func (reg *Registry) Render(name string, ctx pongo2.Context, opts ...RenderOption) (string, error) {
+	name, err := reg.Resolve(name)
+	if err != nil {
+		return "", err
+	}
+	t := reg.templates[name]
+	output, err := reg.renderer.execute(name, t, ctx, opts)
+	if err != nil {
+		return "", reg.renderer.wrapError(err, name, reg.renderer.source(name))
+	}
+	return output, nil
+}
@@ -0,0 +1,74 @@
+package pongo2
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+func TestRegistry(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layout.txt":         {Data: []byte(`<{% block body %}{% endblock %}>`)},
+		"emails/welcome.txt": {Data: []byte(`{% extends "layout.txt" %}{% block body %}Hi {{ name }}{% endblock %}`)},
+		".hidden/draft.txt":  {Data: []byte(`{% if %}`)},
+	}
+
+	reg, err := LoadFS(fsys, Options{})
+	if err != nil {
+		t.Fatalf("LoadFS failed: %v", err)
+	}
+	if got := strings.Join(reg.Names(), ","); got != "emails/welcome.txt,layout.txt" {
+		t.Errorf("unexpected template names %q", got)
+	}
+
+	output, err := reg.Render("emails/welcome.txt", pongo2.Context{"name": "Alice"})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if output != "<Hi Alice>" {
+		t.Errorf("expected %q, got %q", "<Hi Alice>", output)
+	}
+
+	if _, err := reg.Render("missing.txt", nil); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("expected ErrTemplateNotFound, got %v", err)
+	}
+
+	tpl, err := reg.Template("emails/welcome.txt")
+	if err != nil {
+		t.Fatalf("Template failed: %v", err)
+	}
+	if output, err := tpl.Render(pongo2.Context{"name": "Bob"}); err != nil || output != "<Hi Bob>" {
+		t.Errorf("expected %q, got %q (%v)", "<Hi Bob>", output, err)
+	}
+	if _, err := reg.Template("missing.txt"); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("expected ErrTemplateNotFound, got %v", err)
+	}
+}
+
+func TestRegistryFailsFast(t *testing.T) {
+	fsys := fstest.MapFS{
+		"good.txt": {Data: []byte(`ok`)},
+		"bad1.txt": {Data: []byte("line\n{% if %}")},
+		"bad2.txt": {Data: []byte(`{{ value|no_such_filter }}`)},
+	}
+
+	_, err := LoadFS(fsys, Options{})
+	if err == nil {
+		t.Fatal("Expected LoadFS to fail on syntax errors")
+	}
+	for _, want := range []string{"bad1.txt:2", "bad2.txt"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Error should mention %q, got: %v", want, err)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("MustLoadDir should panic for a missing directory")
+		}
+	}()
+	MustLoadDir(t.TempDir()+"/missing", Options{})
+}
@@ -0,0 +1,69 @@
+package pongo2
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// settingsKey is the context key under which a render's options are
+// available to tags (see settingsFrom).
+const settingsKey = "_render"
+
+// RenderOption adjusts a single render.
+type RenderOption func(*renderSettings)
+
+type renderSettings struct {
+	mode OutputMode
+	now  func() time.Time
+	rand *rand.Rand
+	ctx  context.Context
+}
+
+// WithOutputMode overrides the Renderer's output mode for one render. It
+// requires a Renderer created with an Options.OutputMode.
+func WithOutputMode(mode OutputMode) RenderOption {
+	return func(s *renderSettings) {
+		s.mode = mode
+	}
+}
+
+// WithClock sets the clock read by the now tag.
+func WithClock(now func() time.Time) RenderOption {
+	return func(s *renderSettings) {
+		s.now = now
+	}
+}
+
+// WithSeed makes random_int and random_choice draw from a generator seeded
+// with seed, so renders with the same seed produce the same output.
+func WithSeed(seed int64) RenderOption {
+	return WithRand(rand.New(rand.NewSource(seed)))
+}
+
+// WithRand sets the generator used by random_int and random_choice. A
+// *rand.Rand is not safe for concurrent use, so don't share one across
+// concurrent renders.
+func WithRand(r *rand.Rand) RenderOption {
+	return func(s *renderSettings) {
+		s.rand = r
+	}
+}
+
+// WithContext stops the render with ctx's error once ctx is done, e.g. when
+// its request times out. The template stops at the next output it writes,
+// so a long loop can't keep running; a single tag or function call that
+// takes long without writing isn't interrupted.
+func WithContext(ctx context.Context) RenderOption {
+	return func(s *renderSettings) {
+		s.ctx = ctx
+	}
+}
+
+// settingsFrom returns the options of the current render, or nil if none were given.
+func settingsFrom(ctx *pongo2.ExecutionContext) *renderSettings {
+	s, _ := ctx.Public[settingsKey].(*renderSettings)
+	return s
+}
@@ -0,0 +1,300 @@
+package pongo2
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/flosch/pongo2/v6"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"go-demo/pkg/metrics"
+)
+
+// rendererKey is the global under which a Renderer makes itself available to
+// its own tags (see rendererFrom).
+const rendererKey = "_renderer"
+
+// Options configures how a Renderer parses and executes templates.
+//
+// Whitespace can also be controlled per tag with the "-" modifier:
+// "{%-" trims whitespace before a tag and "-%}" trims whitespace after it.
+type Options struct {
+	// TrimBlocks removes the first newline after a block tag ({% ... %}),
+	// so loop and conditional lines don't leave blank lines in the output.
+	TrimBlocks bool
+
+	// LStripBlocks strips spaces and tabs from the start of a line up to a
+	// block tag, so indented {% for %} / {% endfor %} lines leave no trace.
+	LStripBlocks bool
+
+	// TemplateDirs lists template directories from highest to lowest priority,
+	// e.g. a tenant's override directory followed by the base theme. Template
+	// names, including those in {% extends %} and {% include %}, are resolved
+	// in the first directory that has them. When empty, names are paths
+	// relative to the working directory (or to the including template).
+	TemplateDirs []string
+
+	// OutputMode escapes every interpolated variable for the output format
+	// (see OutputMode). When empty, pongo2's HTML autoescaping applies and
+	// the mode can't be changed per render.
+	OutputMode OutputMode
+
+	// EnvAllowlist names the environment variables the env tag may read.
+	EnvAllowlist []string
+
+	// Sandbox disables tags that reach outside the template context, such as
+	// env and ssi, and confines {% include %}, {% extends %} and {% import %}
+	// to TemplateDirs: without any, they fail. Use it for templates from
+	// untrusted sources.
+	Sandbox bool
+
+	// Schema describes the expected template context. The set_default tag
+	// reads defaults from it.
+	Schema *jsonschema.Schema
+
+	// Logger receives compile and render events: successes at debug level
+	// with their durations, failures at warn level. Nil disables logging.
+	Logger *slog.Logger
+
+	// Metrics receives the duration, output size and error of every render.
+	// Nil disables it.
+	Metrics metrics.Recorder
+}
+
+// Renderer renders templates that share one pongo2 template set and its options.
+// Errors returned by its methods are *RenderError values.
+type Renderer struct {
+	set     *pongo2.TemplateSet
+	loaders []pongo2.TemplateLoader
+	opts    Options
+
+	// plain compiles templates without the output mode rewrite, to report
+	// syntax errors at the positions the author wrote them. Nil without an
+	// output mode.
+	plain *pongo2.TemplateSet
+}
+
+// NewRenderer creates a Renderer that loads template files from
+// opts.TemplateDirs, or relative to the current working directory if none are
+// given outside sandbox mode. Templates can use the dict and list
+// constructors.
+func NewRenderer(opts Options) *Renderer {
+	var loader pongo2.TemplateLoader = pongo2.MustNewLocalFileSystemLoader("")
+	if len(opts.TemplateDirs) > 0 || opts.Sandbox {
+		loader = newDirLoader(opts.TemplateDirs)
+	}
+	return newRenderer(opts, loader)
+}
+
+// newRenderer creates a Renderer whose template set loads files through loader.
+func newRenderer(opts Options, loader pongo2.TemplateLoader) *Renderer {
+	r := &Renderer{loaders: []pongo2.TemplateLoader{loader}, opts: opts}
+	newSet := func(loader pongo2.TemplateLoader) *pongo2.TemplateSet {
+		set := pongo2.NewSet("go-demo", loader)
+		set.Globals.Update(constructors)
+		set.Globals[rendererKey] = r
+		set.Options.TrimBlocks = opts.TrimBlocks
+		set.Options.LStripBlocks = opts.LStripBlocks
+		if opts.Sandbox {
+			// ssi reads files past the loader.
+			_ = set.BanTag("ssi")
+		}
+		return set
+	}
+
+	if opts.OutputMode == "" {
+		r.set = newSet(loader)
+		return r
+	}
+	r.set = newSet(escapingLoader{loader})
+	r.plain = newSet(loader)
+	if escape, err := escaperFor(opts.OutputMode); err == nil {
+		r.set.Globals[escapeKey] = escape
+	}
+	return r
+}
+
+// FromString compiles a template from a string.
+func (r *Renderer) FromString(tpl string) (t *pongo2.Template, err error) {
+	defer r.logCompile(stringTemplateName, time.Now(), &err)
+	if err := r.checkOutputMode(r.opts.OutputMode); err != nil {
+		return nil, r.wrapError(err, stringTemplateName, tpl)
+	}
+	src := tpl
+	if r.plain != nil {
+		src = rewriteOutputs(tpl)
+	}
+	t, err = r.set.FromString(src)
+	if err != nil {
+		if r.plain != nil {
+			if _, plainErr := r.plain.FromString(tpl); plainErr != nil {
+				err = plainErr
+			}
+		}
+		return nil, r.wrapError(err, stringTemplateName, tpl)
+	}
+	r.applyWhitespaceOptions(t)
+	return t, nil
+}
+
+// FromFile compiles a template from a file.
+func (r *Renderer) FromFile(name string) (t *pongo2.Template, err error) {
+	defer r.logCompile(name, time.Now(), &err)
+	if err := r.checkOutputMode(r.opts.OutputMode); err != nil {
+		return nil, r.wrapError(err, name, "")
+	}
+	t, err = r.set.FromFile(name)
+	if err != nil {
+		if r.plain != nil {
+			if _, plainErr := r.plain.FromFile(name); plainErr != nil {
+				err = plainErr
+			}
+		}
+		return nil, r.wrapError(err, name, r.source(name))
+	}
+	r.applyWhitespaceOptions(t)
+	return t, nil
+}
+
+// RenderString compiles and executes a template string.
+func (r *Renderer) RenderString(tpl string, ctx pongo2.Context, opts ...RenderOption) (string, error) {
+	t, err := r.FromString(tpl)
+	if err != nil {
+		return "", err
+	}
+	output, err := r.execute(stringTemplateName, t, ctx, opts)
+	if err != nil {
+		return "", r.wrapError(err, stringTemplateName, tpl)
+	}
+	return output, nil
+}
+
+// RenderFile compiles and executes a template file.
+func (r *Renderer) RenderFile(name string, ctx pongo2.Context, opts ...RenderOption) (string, error) {
+	t, err := r.FromFile(name)
+	if err != nil {
+		return "", err
+	}
+	output, err := r.execute(name, t, ctx, opts)
+	if err != nil {
+		return "", r.wrapError(err, name, r.source(name))
+	}
+	return output, nil
+}
+
+// execute runs the compiled template name with the per-render options applied.
+func (r *Renderer) execute(name string, t *pongo2.Template, ctx pongo2.Context, opts []RenderOption) (output string, err error) {
+	defer r.logRender(name, time.Now(), &err)
+	defer r.observeRender(time.Now(), &output, &err)
+	if len(opts) == 0 {
+		return t.Execute(ctx)
+	}
+	settings := &renderSettings{}
+	for _, opt := range opts {
+		opt(settings)
+	}
+	ctx = withContextValue(ctx, settingsKey, settings)
+
+	if settings.mode != "" {
+		if r.plain == nil {
+			return "", fmt.Errorf("output mode %q requires a Renderer with Options.OutputMode", settings.mode)
+		}
+		escape, err := escaperFor(settings.mode)
+		if err != nil {
+			return "", err
+		}
+		ctx[escapeKey] = escape
+	}
+	if settings.ctx != nil {
+		return executeContext(settings.ctx, t, ctx)
+	}
+	return t.Execute(ctx)
+}
+
+// renderCanceled is what a cancelWriter panics with to stop a render.
+type renderCanceled struct{}
+
+// cancelWriter collects the output of a render until done is closed, and
+// then stops the render at its next write. pongo2 ignores the errors of
+// writes, so it panics, for executeContext to recover.
+type cancelWriter struct {
+	buf  bytes.Buffer
+	done <-chan struct{}
+}
+
+func (w *cancelWriter) Write(p []byte) (int, error) {
+	select {
+	case <-w.done:
+		panic(renderCanceled{})
+	default:
+	}
+	return w.buf.Write(p)
+}
+
+// executeContext executes t, failing with ctx's error if ctx is done before
+// t finishes. The render stops at the first output it writes after that, so
+// it doesn't run on in the background.
+func executeContext(ctx context.Context, t *pongo2.Template, data pongo2.Context) (output string, err error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(renderCanceled); !ok {
+				panic(r)
+			}
+			output, err = "", ctx.Err()
+		}
+	}()
+	w := &cancelWriter{done: ctx.Done()}
+	if err := t.ExecuteWriterUnbuffered(data, w); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", err
+	}
+	return w.buf.String(), nil
+}
+
+// checkOutputMode reports an unknown output mode.
+func (r *Renderer) checkOutputMode(mode OutputMode) error {
+	if mode == "" {
+		return nil
+	}
+	_, err := escaperFor(mode)
+	return err
+}
+
+// withContextValue returns a copy of ctx with key set, leaving ctx untouched.
+func withContextValue(ctx pongo2.Context, key string, value interface{}) pongo2.Context {
+	c := make(pongo2.Context, len(ctx)+1)
+	c.Update(ctx)
+	c[key] = value
+	return c
+}
+
+// applyWhitespaceOptions bakes the trim options into a freshly compiled template.
+// pongo2 applies TrimBlocks/LStripBlocks by rewriting the template's tokens at the
+// start of every execution, so a template that is executed repeatedly loses one
+// more newline each time (and concurrent executions race on the tokens).
+// Executing with an invalid context key performs that rewrite and then fails
+// before anything is rendered; afterwards the options are switched off so the
+// rewrite is never repeated.
+func (r *Renderer) applyWhitespaceOptions(t *pongo2.Template) {
+	if !r.opts.TrimBlocks && !r.opts.LStripBlocks {
+		return
+	}
+	_, _ = t.Execute(pongo2.Context{"-": nil})
+	t.Options.TrimBlocks = false
+	t.Options.LStripBlocks = false
+}
+
+// rendererFrom returns the Renderer executing a template, or nil if the template
+// was not created through a Renderer.
+func rendererFrom(ctx *pongo2.ExecutionContext) *Renderer {
+	r, _ := ctx.Public[rendererKey].(*Renderer)
+	return r
+}
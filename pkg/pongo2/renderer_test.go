@@ -0,0 +1,108 @@
+package pongo2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+func TestRendererWhitespaceOptions(t *testing.T) {
+	templateString := `{
+  "tags": [
+    {% for tag in tags %}
+    {{ tag|to_json }}{% if not forloop.Last %},{% endif %}
+    {% endfor %}
+  ]
+}`
+
+	renderer := NewRenderer(Options{TrimBlocks: true, LStripBlocks: true})
+	output, err := renderer.RenderString(templateString, pongo2.Context{
+		"tags": []string{"golang", "testing"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to execute template: %v", err)
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			t.Fatalf("Output should not contain blank lines:\n%s", output)
+		}
+	}
+
+	var decoded struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+		t.Fatalf("Rendered output should be valid JSON: %v\nOutput: %s", err, output)
+	}
+	if len(decoded.Tags) != 2 {
+		t.Errorf("expected 2 tags, got %d", len(decoded.Tags))
+	}
+}
+
+// TestRendererWhitespaceOptionsRepeated ensures trimming is applied once per
+// template and not again on every execution.
+func TestRendererWhitespaceOptionsRepeated(t *testing.T) {
+	renderer := NewRenderer(Options{TrimBlocks: true})
+	tpl, err := renderer.FromString("{% if true %}\n\n\nA{% endif %}")
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		output, err := tpl.Execute(nil)
+		if err != nil {
+			t.Fatalf("Failed to execute template: %v", err)
+		}
+		if output != "\n\nA" {
+			t.Errorf("execution %d: expected %q, got %q", i, "\n\nA", output)
+		}
+	}
+}
+
+func TestRendererTrimModifier(t *testing.T) {
+	renderer := NewRenderer(Options{})
+	output, err := renderer.RenderString("[\n  {%- for n in items %}{{ n }}{% if not forloop.Last %},{% endif %}{% endfor -%}\n]", pongo2.Context{
+		"items": []int{1, 2, 3},
+	})
+	if err != nil {
+		t.Fatalf("Failed to execute template: %v", err)
+	}
+	if output != "[1,2,3]" {
+		t.Errorf("expected %q, got %q", "[1,2,3]", output)
+	}
+}
+
+func TestRenderWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	data := pongo2.Context{
+		"items": make([]int, 1000),
+		"tick": func() int {
+			if calls++; calls == 3 {
+				cancel()
+			}
+			return calls
+		},
+	}
+	renderer := NewRenderer(Options{})
+	source := "{% for i in items %}{{ tick() }}{% endfor %}"
+	if _, err := renderer.RenderString(source, data, WithContext(ctx)); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected the render to stop after 3 calls, got %d", calls)
+	}
+	if _, err := renderer.RenderString("x", nil, WithContext(ctx)); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled before rendering, got %v", err)
+	}
+	output, err := renderer.RenderString("{{ 1 }}", nil, WithContext(context.Background()))
+	if err != nil || output != "1" {
+		t.Errorf("expected %q, got %q (%v)", "1", output, err)
+	}
+}
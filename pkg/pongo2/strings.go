@@ -0,0 +1,165 @@
+package pongo2
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// stringFilters is the string utility filter pack:
+//
+//	{{ "Héllo, World!"|slugify }}          -> hello-world
+//	{{ "user_id"|camelcase }}              -> userId
+//	{{ "user_id"|camelcase:"upper" }}      -> UserId
+//	{{ "HTTPServer"|snakecase }}           -> http_server
+//	{{ text|truncate_words:"3" }}          -> first three words...
+//	{{ text|truncate_words:"3, [more]" }}  -> custom suffix
+//	{{ sku|pad:"8" }} / {{ n|pad:"-6,0" }} -> right / left padding
+var stringFilters = map[string]pongo2.FilterFunction{
+	"slugify":        filterSlugify,
+	"camelcase":      filterCamelCase,
+	"snakecase":      filterSnakeCase,
+	"truncate_words": filterTruncateWords,
+	"pad":            filterPad,
+}
+
+// asciiFold maps common accented Latin letters to ASCII for slugify.
+var asciiFold = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ã", "a", "ä", "a", "å", "a", "æ", "ae",
+	"ç", "c", "è", "e", "é", "e", "ê", "e", "ë", "e",
+	"ì", "i", "í", "i", "î", "i", "ï", "i", "ñ", "n",
+	"ò", "o", "ó", "o", "ô", "o", "õ", "o", "ö", "o", "ø", "o", "œ", "oe",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u", "ý", "y", "ÿ", "y", "ß", "ss",
+	"ą", "a", "ć", "c", "č", "c", "ď", "d", "ę", "e", "ě", "e", "ł", "l",
+	"ń", "n", "ň", "n", "ř", "r", "ś", "s", "š", "s", "ť", "t", "ů", "u",
+	"ź", "z", "ż", "z", "ž", "z",
+)
+
+// filterSlugify lowercases a string, folds common accents to ASCII and joins
+// the remaining runs of letters and digits with "-".
+func filterSlugify(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	s := asciiFold.Replace(strings.ToLower(in.String()))
+	parts := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return pongo2.AsValue(strings.Join(parts, "-")), nil
+}
+
+// filterCamelCase joins the words of a string as camelCase, or PascalCase
+// with the parameter "upper".
+func filterCamelCase(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	upper := false
+	switch param.String() {
+	case "":
+	case "upper":
+		upper = true
+	default:
+		return nil, &pongo2.Error{Sender: "filter:camelcase", OrigError: fmt.Errorf("unknown parameter %q (want \"upper\")", param.String())}
+	}
+
+	var b strings.Builder
+	for i, word := range splitWords(in.String()) {
+		word = strings.ToLower(word)
+		if i > 0 || upper {
+			r, size := utf8.DecodeRuneInString(word)
+			word = string(unicode.ToUpper(r)) + word[size:]
+		}
+		b.WriteString(word)
+	}
+	return pongo2.AsValue(b.String()), nil
+}
+
+// filterSnakeCase joins the lowercased words of a string with "_".
+func filterSnakeCase(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	words := splitWords(in.String())
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	return pongo2.AsValue(strings.Join(words, "_")), nil
+}
+
+// splitWords splits identifiers and phrases into words at separators and case
+// changes: "HTTPServer_id" -> HTTP, Server, id.
+func splitWords(s string) []string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && len(word) > 0 {
+			prev := word[len(word)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
+
+// filterTruncateWords keeps the first n words of a string and appends a
+// suffix ("..." unless given after a comma) if anything was cut.
+func filterTruncateWords(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	count, suffix, hasSuffix := strings.Cut(param.String(), ",")
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || n < 0 {
+		return nil, &pongo2.Error{Sender: "filter:truncate_words", OrigError: fmt.Errorf("invalid word count %q", count)}
+	}
+	if !hasSuffix {
+		suffix = "..."
+	}
+
+	words := strings.Fields(in.String())
+	if len(words) <= n {
+		return pongo2.AsValue(strings.Join(words, " ")), nil
+	}
+	return pongo2.AsValue(strings.Join(words[:n], " ") + suffix), nil
+}
+
+// filterPad pads a string to a width in runes: on the right for a positive
+// width, on the left for a negative one. The pad character defaults to a
+// space and is given after a comma. Longer strings are left unchanged.
+func filterPad(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	spec, char, hasChar := strings.Cut(param.String(), ",")
+	width, err := strconv.Atoi(strings.TrimSpace(spec))
+	if err != nil {
+		return nil, &pongo2.Error{Sender: "filter:pad", OrigError: fmt.Errorf("invalid width %q", spec)}
+	}
+	if !hasChar {
+		char = " "
+	}
+	if utf8.RuneCountInString(char) != 1 {
+		return nil, &pongo2.Error{Sender: "filter:pad", OrigError: fmt.Errorf("pad character must be a single character, got %q", char)}
+	}
+
+	s := in.String()
+	left := width < 0
+	if left {
+		width = -width
+	}
+	missing := width - utf8.RuneCountInString(s)
+	if missing <= 0 {
+		return pongo2.AsValue(s), nil
+	}
+	padding := strings.Repeat(char, missing)
+	if left {
+		return pongo2.AsValue(padding + s), nil
+	}
+	return pongo2.AsValue(s + padding), nil
+}
@@ -0,0 +1,54 @@
+package pongo2
+
+import (
+	"testing"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+func TestStringFilters(t *testing.T) {
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{`{{ "Héllo, Wörld! Straße 42"|slugify }}`, "hello-world-strasse-42"},
+		{`{{ "  --Already-a-slug--  "|slugify }}`, "already-a-slug"},
+		{`{{ "user_id"|camelcase }}`, "userId"},
+		{`{{ "HTTP server error"|camelcase:"upper" }}`, "HttpServerError"},
+		{`{{ "HTTPServer"|snakecase }}`, "http_server"},
+		{`{{ "userID2FA token"|snakecase }}`, "user_id2_fa_token"},
+		{`{{ "one two  three four"|truncate_words:"2" }}`, "one two..."},
+		{`{{ "one two three"|truncate_words:"2, [more]" }}`, "one two [more]"},
+		{`{{ "one two"|truncate_words:"5" }}`, "one two"},
+		{`[{{ "ab"|pad:"5" }}]`, "[ab   ]"},
+		{`[{{ 42|pad:"-6,0" }}]`, "[000042]"},
+		{`[{{ "ünï"|pad:"-5,·" }}]`, "[··ünï]"},
+		{`[{{ "toolong"|pad:"3" }}]`, "[toolong]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			output := executeString(t, tt.template, nil)
+			if output != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, output)
+			}
+		})
+	}
+}
+
+func TestStringFilterErrors(t *testing.T) {
+	for _, tpl := range []string{
+		`{{ "a"|camelcase:"lower" }}`,
+		`{{ "a"|truncate_words:"x" }}`,
+		`{{ "a"|pad:"x" }}`,
+		`{{ "a"|pad:"5,ab" }}`,
+	} {
+		parsed, err := pongo2.FromString(tpl)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", tpl, err)
+		}
+		if _, err := parsed.Execute(nil); err == nil {
+			t.Errorf("%s: expected an error", tpl)
+		}
+	}
+}
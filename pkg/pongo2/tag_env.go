@@ -0,0 +1,81 @@
+package pongo2
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// tagEnvNode implements
+//
+//	{% env "REGION" %}
+//	{% env "REGION" as region %}
+//
+// which writes (or assigns) an environment variable. Only variables listed in
+// the Renderer's Options.EnvAllowlist can be read, and the tag fails when the
+// Renderer runs in sandbox mode. Unset variables read as "".
+type tagEnvNode struct {
+	start *pongo2.Token
+	name  pongo2.IEvaluator
+	as    string
+}
+
+func (node *tagEnvNode) Execute(ctx *pongo2.ExecutionContext, writer pongo2.TemplateWriter) *pongo2.Error {
+	r := rendererFrom(ctx)
+	if r == nil {
+		return ctx.Error("env requires a Renderer", node.start)
+	}
+	if r.opts.Sandbox {
+		return ctx.Error("env is not allowed in sandbox mode", node.start)
+	}
+
+	name, err := node.name.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+	if !r.envAllowed(name.String()) {
+		return ctx.Error(fmt.Sprintf("environment variable %q is not in the allowlist", name.String()), node.start)
+	}
+
+	value := pongo2.AsValue(os.Getenv(name.String()))
+	if node.as != "" {
+		ctx.Private[node.as] = value
+		return nil
+	}
+	writer.WriteString(escapeOutput(ctx, value).String())
+	return nil
+}
+
+// envAllowed reports whether Options.EnvAllowlist contains name.
+func (r *Renderer) envAllowed(name string) bool {
+	for _, allowed := range r.opts.EnvAllowlist {
+		if allowed == name {
+			return true
+		}
+	}
+	return false
+}
+
+func tagEnvParser(doc *pongo2.Parser, start *pongo2.Token, arguments *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+	node := &tagEnvNode{start: start}
+
+	name, err := arguments.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+	node.name = name
+
+	if arguments.Match(pongo2.TokenKeyword, "as") != nil {
+		asToken := arguments.MatchType(pongo2.TokenIdentifier)
+		if asToken == nil {
+			return nil, arguments.Error("Expected an identifier after 'as'.", nil)
+		}
+		node.as = asToken.Val
+	}
+
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("Malformed 'env'-tag arguments.", nil)
+	}
+	return node, nil
+}
@@ -0,0 +1,50 @@
+package pongo2
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEnvTag(t *testing.T) {
+	t.Setenv("GO_DEMO_REGION", `eu-west-1 "a"`)
+	t.Setenv("GO_DEMO_SECRET", "hunter2")
+
+	renderer := NewRenderer(Options{EnvAllowlist: []string{"GO_DEMO_REGION", "GO_DEMO_UNSET"}})
+	output, err := renderer.RenderString(`{% env "GO_DEMO_REGION" %}|{% env "GO_DEMO_REGION" as region %}{{ region|upper }}|{% env "GO_DEMO_UNSET" %}`, nil)
+	if err != nil {
+		t.Fatalf("Failed to render template: %v", err)
+	}
+	if want := `eu-west-1 "a"|EU-WEST-1 &quot;A&quot;|`; output != want {
+		t.Errorf("expected %q, got %q", want, output)
+	}
+
+	jsonRenderer := NewRenderer(Options{EnvAllowlist: []string{"GO_DEMO_REGION"}, OutputMode: OutputJSON})
+	output, err = jsonRenderer.RenderString(`{"region": "{% env "GO_DEMO_REGION" %}"}`, nil)
+	if err != nil {
+		t.Fatalf("Failed to render template: %v", err)
+	}
+	if want := `{"region": "eu-west-1 \"a\""}`; output != want {
+		t.Errorf("expected %q, got %q", want, output)
+	}
+}
+
+func TestEnvTagErrors(t *testing.T) {
+	t.Setenv("GO_DEMO_SECRET", "hunter2")
+
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr string
+	}{
+		{"not allowlisted", Options{EnvAllowlist: []string{"GO_DEMO_REGION"}}, "not in the allowlist"},
+		{"sandbox", Options{EnvAllowlist: []string{"GO_DEMO_SECRET"}, Sandbox: true}, "sandbox"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := NewRenderer(tt.opts).RenderString(`{% env "GO_DEMO_SECRET" %}`, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v (output %q)", tt.wantErr, err, output)
+			}
+		})
+	}
+}
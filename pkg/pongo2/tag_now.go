@@ -0,0 +1,66 @@
+package pongo2
+
+import (
+	"time"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// tagNowNode replaces pongo2's now tag:
+//
+//	{% now "2006-01-02T15:04:05Z07:00" %}
+//	{% now "2006-01-02" as today %}
+//
+// The time comes from the clock given with WithClock, so rendered timestamps
+// can be fixed in tests. As in pongo2, "fake" uses a constant date instead.
+type tagNowNode struct {
+	format string
+	fake   bool
+	as     string
+}
+
+func (node *tagNowNode) Execute(ctx *pongo2.ExecutionContext, writer pongo2.TemplateWriter) *pongo2.Error {
+	var t time.Time
+	switch settings := settingsFrom(ctx); {
+	case node.fake:
+		t = time.Date(2014, time.February, 5, 18, 31, 45, 0, time.UTC)
+	case settings != nil && settings.now != nil:
+		t = settings.now()
+	default:
+		t = time.Now()
+	}
+
+	formatted := t.Format(node.format)
+	if node.as != "" {
+		ctx.Private[node.as] = formatted
+		return nil
+	}
+	writer.WriteString(formatted)
+	return nil
+}
+
+func tagNowParser(doc *pongo2.Parser, start *pongo2.Token, arguments *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+	node := &tagNowNode{}
+
+	formatToken := arguments.MatchType(pongo2.TokenString)
+	if formatToken == nil {
+		return nil, arguments.Error("Expected a format string.", nil)
+	}
+	node.format = formatToken.Val
+
+	if arguments.Match(pongo2.TokenIdentifier, "fake") != nil {
+		node.fake = true
+	}
+	if arguments.Match(pongo2.TokenKeyword, "as") != nil {
+		asToken := arguments.MatchType(pongo2.TokenIdentifier)
+		if asToken == nil {
+			return nil, arguments.Error("Expected an identifier after 'as'.", nil)
+		}
+		node.as = asToken.Val
+	}
+
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("Malformed now-tag arguments.", nil)
+	}
+	return node, nil
+}
@@ -0,0 +1,39 @@
+package pongo2
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNowTag(t *testing.T) {
+	clock := func() time.Time {
+		return time.Date(2024, time.March, 9, 14, 30, 0, 0, time.FixedZone("CET", 3600))
+	}
+	renderer := NewRenderer(Options{})
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{`{% now "2006-01-02T15:04:05Z07:00" %}`, "2024-03-09T14:30:00+01:00"},
+		{`{% now "2006-01-02" as today %}generated {{ today }}`, "generated 2024-03-09"},
+		{`{% now "2006" fake %}`, "2014"},
+	}
+	for _, tt := range tests {
+		output, err := renderer.RenderString(tt.template, nil, WithClock(clock))
+		if err != nil {
+			t.Fatalf("Failed to render %s: %v", tt.template, err)
+		}
+		if output != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.template, tt.expected, output)
+		}
+	}
+
+	output, err := renderer.RenderString(`{% now "2006" %}`, nil)
+	if err != nil {
+		t.Fatalf("Failed to render template: %v", err)
+	}
+	if output != time.Now().Format("2006") {
+		t.Errorf("Without a clock the current time should be used, got %q", output)
+	}
+}
@@ -0,0 +1,112 @@
+package pongo2
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// tagRandomNode implements
+//
+//	{% random_int 1 100 %}
+//	{% random_choice "red" "green" "blue" %}
+//	{% random_choice colors as color %}
+//
+// random_int picks an integer between both bounds (inclusive); random_choice
+// picks one of its arguments, or one element of a single list argument.
+// Numbers come from the RNG given with WithSeed or WithRand, so the same seed
+// renders the same document.
+type tagRandomNode struct {
+	start  *pongo2.Token
+	choice bool
+	args   []pongo2.IEvaluator
+	as     string
+}
+
+func (node *tagRandomNode) Execute(ctx *pongo2.ExecutionContext, writer pongo2.TemplateWriter) *pongo2.Error {
+	args := make([]*pongo2.Value, len(node.args))
+	for i, arg := range node.args {
+		v, err := arg.Evaluate(ctx)
+		if err != nil {
+			return err
+		}
+		args[i] = v
+	}
+
+	intn, uint64n := rand.Int63n, rand.Uint64
+	if settings := settingsFrom(ctx); settings != nil && settings.rand != nil {
+		intn, uint64n = settings.rand.Int63n, settings.rand.Uint64
+	}
+
+	var value *pongo2.Value
+	if node.choice {
+		if len(args) == 1 && args[0].CanSlice() {
+			if args[0].Len() == 0 {
+				return ctx.Error("random_choice: empty list", node.start)
+			}
+			value = args[0].Index(int(intn(int64(args[0].Len()))))
+		} else {
+			value = args[intn(int64(len(args)))]
+		}
+	} else {
+		if !args[0].IsInteger() || !args[1].IsInteger() {
+			return ctx.Error("random_int: bounds must be integers", node.start)
+		}
+		lo, hi := int64(args[0].Integer()), int64(args[1].Integer())
+		if lo > hi {
+			return ctx.Error(fmt.Sprintf("random_int: lower bound %d exceeds upper bound %d", lo, hi), node.start)
+		}
+		// hi-lo can overflow int64; the bounds are at most MaxUint64 apart.
+		span := uint64(hi) - uint64(lo)
+		var offset uint64
+		if span < math.MaxInt64 {
+			offset = uint64(intn(int64(span) + 1))
+		} else {
+			// At least half of the numbers are in range.
+			for offset = uint64n(); offset > span; offset = uint64n() {
+			}
+		}
+		value = pongo2.AsValue(int64(uint64(lo) + offset))
+	}
+
+	if node.as != "" {
+		ctx.Private[node.as] = value
+		return nil
+	}
+	writer.WriteString(escapeOutput(ctx, value).String())
+	return nil
+}
+
+// tagRandomParser returns the parser of random_int (choice false) or random_choice.
+func tagRandomParser(choice bool) pongo2.TagParser {
+	return func(doc *pongo2.Parser, start *pongo2.Token, arguments *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+		node := &tagRandomNode{start: start, choice: choice}
+
+		for arguments.Remaining() > 0 && arguments.Peek(pongo2.TokenKeyword, "as") == nil {
+			arg, err := arguments.ParseExpression()
+			if err != nil {
+				return nil, err
+			}
+			node.args = append(node.args, arg)
+		}
+		if arguments.Match(pongo2.TokenKeyword, "as") != nil {
+			asToken := arguments.MatchType(pongo2.TokenIdentifier)
+			if asToken == nil {
+				return nil, arguments.Error("Expected an identifier after 'as'.", nil)
+			}
+			node.as = asToken.Val
+		}
+
+		switch {
+		case arguments.Remaining() > 0:
+			return nil, arguments.Error("Malformed random tag arguments.", nil)
+		case !choice && len(node.args) != 2:
+			return nil, arguments.Error("random_int expects a lower and an upper bound.", nil)
+		case choice && len(node.args) == 0:
+			return nil, arguments.Error("random_choice expects a list or at least one value.", nil)
+		}
+		return node, nil
+	}
+}
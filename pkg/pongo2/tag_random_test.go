@@ -0,0 +1,85 @@
+package pongo2
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+func TestRandomTags(t *testing.T) {
+	renderer := NewRenderer(Options{})
+	templateString := `{% for i in range %}{% random_int 1 6 %},{% random_choice "a" "b" "c" %},{% random_choice colors as c %}{{ c }};{% endfor %}`
+	ctx := pongo2.Context{"range": make([]int, 20), "colors": []string{"red", "green"}}
+
+	first, err := renderer.RenderString(templateString, ctx, WithSeed(42))
+	if err != nil {
+		t.Fatalf("Failed to render template: %v", err)
+	}
+	second, err := renderer.RenderString(templateString, ctx, WithSeed(42))
+	if err != nil {
+		t.Fatalf("Failed to render template: %v", err)
+	}
+	if first != second {
+		t.Errorf("The same seed should render the same output:\n%s\n%s", first, second)
+	}
+
+	other, err := renderer.RenderString(templateString, ctx, WithSeed(7))
+	if err != nil {
+		t.Fatalf("Failed to render template: %v", err)
+	}
+	if other == first {
+		t.Error("Different seeds should render different output")
+	}
+
+	for _, row := range strings.Split(strings.TrimSuffix(first, ";"), ";") {
+		fields := strings.Split(row, ",")
+		n, err := strconv.Atoi(fields[0])
+		if err != nil || n < 1 || n > 6 {
+			t.Errorf("random_int out of range: %q", fields[0])
+		}
+		if !strings.Contains("abc", fields[1]) || (fields[2] != "red" && fields[2] != "green") {
+			t.Errorf("unexpected choices in %q", row)
+		}
+	}
+}
+
+func TestRandomIntWideBounds(t *testing.T) {
+	renderer := NewRenderer(Options{})
+	for _, bounds := range [][2]int64{
+		{math.MinInt64, math.MaxInt64},
+		{-1, math.MaxInt64},
+		{0, math.MaxInt64},
+		{math.MinInt64, 0},
+		{math.MaxInt64, math.MaxInt64},
+	} {
+		ctx := pongo2.Context{"lo": bounds[0], "hi": bounds[1], "range": make([]int, 50)}
+		output, err := renderer.RenderString(`{% for i in range %}{% random_int lo hi %} {% endfor %}`, ctx, WithSeed(1))
+		if err != nil {
+			t.Errorf("%v: random_int failed: %v", bounds, err)
+			continue
+		}
+		for _, field := range strings.Fields(output) {
+			if n, err := strconv.ParseInt(field, 10, 64); err != nil || n < bounds[0] || n > bounds[1] {
+				t.Errorf("%v: random_int out of range: %q", bounds, field)
+			}
+		}
+	}
+}
+
+func TestRandomTagErrors(t *testing.T) {
+	renderer := NewRenderer(Options{})
+	for _, tpl := range []string{
+		`{% random_int 1 %}`,
+		`{% random_int 5 1 %}`,
+		`{% random_int "a" 2 %}`,
+		`{% random_choice %}`,
+		`{% random_choice empty %}`,
+	} {
+		if _, err := renderer.RenderString(tpl, pongo2.Context{"empty": []string{}}); err == nil {
+			t.Errorf("%s: expected an error", tpl)
+		}
+	}
+}
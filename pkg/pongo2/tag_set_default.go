@@ -0,0 +1,76 @@
+package pongo2
+
+import (
+	"fmt"
+
+	"github.com/flosch/pongo2/v6"
+
+	schemautil "go-demo/pkg/jsonschema"
+)
+
+// tagSetDefaultNode implements
+//
+//	{% set_default var "/json/pointer" %}
+//
+// which assigns the default declared in the Renderer's schema for the pointer
+// to var, unless the context already provides a non-nil value for it.
+type tagSetDefaultNode struct {
+	start   *pongo2.Token
+	name    string
+	pointer pongo2.IEvaluator
+}
+
+func (node *tagSetDefaultNode) Execute(ctx *pongo2.ExecutionContext, writer pongo2.TemplateWriter) *pongo2.Error {
+	if !isMissing(ctx.Private[node.name]) || !isMissing(ctx.Public[node.name]) {
+		return nil
+	}
+
+	r := rendererFrom(ctx)
+	if r == nil || r.opts.Schema == nil {
+		return ctx.Error("set_default requires a Renderer with a schema", node.start)
+	}
+
+	pointer, err := node.pointer.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+	value, ok := schemautil.DefaultAt(r.opts.Schema, pointer.String())
+	if !ok {
+		return ctx.Error(fmt.Sprintf("schema has no default for %q", pointer.String()), node.start)
+	}
+
+	ctx.Private[node.name] = value
+	return nil
+}
+
+func tagSetDefaultParser(doc *pongo2.Parser, start *pongo2.Token, arguments *pongo2.Parser) (pongo2.INodeTag, *pongo2.Error) {
+	node := &tagSetDefaultNode{start: start}
+
+	nameToken := arguments.MatchType(pongo2.TokenIdentifier)
+	if nameToken == nil {
+		return nil, arguments.Error("Expected an identifier.", nil)
+	}
+	node.name = nameToken.Val
+
+	pointer, err := arguments.ParseExpression()
+	if err != nil {
+		return nil, err
+	}
+	node.pointer = pointer
+
+	if arguments.Remaining() > 0 {
+		return nil, arguments.Error("Malformed 'set_default'-tag arguments.", nil)
+	}
+	return node, nil
+}
+
+// isMissing reports whether a context value is absent or nil.
+func isMissing(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	if value, ok := v.(*pongo2.Value); ok {
+		return value.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package pongo2
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/flosch/pongo2/v6"
+	jsonschemaLib "github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func compileSchema(t *testing.T, schemaStr string) *jsonschemaLib.Schema {
+	compiler := jsonschemaLib.NewCompiler()
+	compiler.ExtractAnnotations = true
+	if err := compiler.AddResource("schema.json", bytes.NewReader([]byte(schemaStr))); err != nil {
+		t.Fatalf("Failed to add schema resource: %v", err)
+	}
+	schema, err := compiler.Compile("schema.json")
+	if err != nil {
+		t.Fatalf("Failed to compile schema: %v", err)
+	}
+	return schema
+}
+
+func TestSetDefaultTag(t *testing.T) {
+	schema := compileSchema(t, `{
+		"type": "object",
+		"properties": {
+			"region": {"type": "string", "default": "eu-west-1"},
+			"address": {
+				"type": "object",
+				"properties": {"city": {"type": "string", "default": "Berlin"}}
+			}
+		}
+	}`)
+	renderer := NewRenderer(Options{Schema: schema})
+	templateString := `{% set_default region "/region" %}{% set_default city "/address/city" %}{{ region }} {{ city }}`
+
+	output, err := renderer.RenderString(templateString, nil)
+	if err != nil {
+		t.Fatalf("Failed to execute template: %v", err)
+	}
+	if output != "eu-west-1 Berlin" {
+		t.Errorf("Missing values should get schema defaults, got %q", output)
+	}
+
+	output, err = renderer.RenderString(templateString, pongo2.Context{"region": "us-east-1"})
+	if err != nil {
+		t.Fatalf("Failed to execute template: %v", err)
+	}
+	if output != "us-east-1 Berlin" {
+		t.Errorf("Provided values should be kept, got %q", output)
+	}
+}
+
+func TestSetDefaultTagErrors(t *testing.T) {
+	schema := compileSchema(t, `{"type": "object", "properties": {"name": {"type": "string"}}}`)
+
+	renderer := NewRenderer(Options{Schema: schema})
+	if _, err := renderer.RenderString(`{% set_default name "/name" %}`, nil); err == nil {
+		t.Error("Expected an error for a pointer without a default")
+	}
+
+	renderer = NewRenderer(Options{})
+	if _, err := renderer.RenderString(`{% set_default name "/name" %}`, nil); err == nil {
+		t.Error("Expected an error for a Renderer without a schema")
+	}
+}
@@ -27,4 +27,72 @@ func init() {
 		// Mark the JSON fragment as safe so it won't be HTML-escaped again.
 		return pongo2.AsSafeValue(string(b)), nil
 	})
+
+	// to_pretty_json encodes a value as indented JSON (default 2 spaces):
+	//   "config": {{ config|to_pretty_json:2|indent:2 }}
+	pongo2.RegisterFilter("to_pretty_json", filterToPrettyJSON)
+
+	// indent indents all lines but the first, to nest multi-line fragments.
+	pongo2.RegisterFilter("indent", filterIndent)
+
+	// sort_by_key sorts a list of maps by a key ("-key" for descending):
+	//   {% for user in users|sort_by_key:"-age" %}
+	pongo2.RegisterFilter("sort_by_key", filterSortByKey)
+
+	// group_by groups a list of maps into a map of lists by a field:
+	//   {% for role, members in users|group_by:"role" sorted %}
+	pongo2.RegisterFilter("group_by", filterGroupBy)
+
+	// map, where and sum extract, filter and total fields of a list of maps:
+	//   {{ users|map:"name"|join:", " }}
+	//   {% for order in orders|where:"status,active" %}
+	//   {{ lines|sum:"amount" }}
+	pongo2.RegisterFilter("map", filterMap)
+	pongo2.RegisterFilter("where", filterWhere)
+	pongo2.RegisterFilter("sum", filterSum)
+
+	// get resolves a JSON Pointer or dotted path, with an optional fallback:
+	//   {{ order|get:"/customer/address/city|unknown" }}
+	pongo2.RegisterFilter("get", filterGet)
+
+	// merge deep-merges a map into another, optionally with an array strategy:
+	//   {{ base|merge:list(overrides, "append")|to_json }}
+	pongo2.RegisterFilter("merge", filterMerge)
+
+	// String utilities: slugify, camelcase, snakecase, truncate_words, pad.
+	if err := RegisterFilters(stringFilters); err != nil {
+		panic(err)
+	}
+
+	// pluralize extends pongo2's built-in filter with locale-aware plural forms:
+	//   {{ count }} {{ count|pluralize:"item|items" }}
+	// The built-in suffix form ({{ count|pluralize:"y,ies" }}) keeps working.
+	pongo2.ReplaceFilter("pluralize", filterPluralize)
+
+	// currency formats monetary amounts for a currency and locale:
+	//   {{ total|currency:"EUR,de-DE" }}
+	// Integers are minor units (cents); floats and decimal strings are major units.
+	pongo2.RegisterFilter("currency", filterCurrency)
+}
+
+// Register tags used in templates.
+func init() {
+	// set_default assigns a schema default to a variable missing from the context:
+	//   {% set_default city "/address/city" %}
+	// It requires a Renderer created with Options.Schema.
+	pongo2.RegisterTag("set_default", tagSetDefaultParser)
+
+	// env reads an allowlisted environment variable:
+	//   {% env "REGION" %} or {% env "REGION" as region %}
+	// It requires a Renderer with Options.EnvAllowlist and fails in sandbox mode.
+	pongo2.RegisterTag("env", tagEnvParser)
+
+	// now replaces pongo2's now tag so the clock can be set per render:
+	//   {% now "2006-01-02T15:04:05Z07:00" %}
+	pongo2.ReplaceTag("now", tagNowParser)
+
+	// random_int and random_choice draw from the RNG given with WithSeed:
+	//   {% random_int 1 100 %} {% random_choice "red" "green" "blue" %}
+	pongo2.RegisterTag("random_int", tagRandomParser(false))
+	pongo2.RegisterTag("random_choice", tagRandomParser(true))
 }
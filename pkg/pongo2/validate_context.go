@@ -0,0 +1,113 @@
+package pongo2
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// ContextReport lists mismatches between a template and the context it is
+// going to be rendered with.
+type ContextReport struct {
+	// Missing holds paths of required values absent from the context,
+	// e.g. "user.name" or "tags[2].label".
+	Missing []string
+
+	// Unused holds top-level context keys the template never references.
+	Unused []string
+}
+
+// OK reports whether the context provides everything the template requires.
+// Unused keys don't make a context invalid.
+func (r *ContextReport) OK() bool {
+	return len(r.Missing) == 0
+}
+
+// ValidateContext checks ctx against the variables tpl references (see
+// Introspect) without rendering anything. An error is returned only if the
+// template itself cannot be parsed.
+func ValidateContext(tpl string, ctx pongo2.Context) (*ContextReport, error) {
+	shape, err := Introspect(tpl)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ContextReport{}
+	for _, name := range shape.Variables() {
+		prop := shape.Properties[name]
+		value, ok := ctx[name]
+		if !ok {
+			if prop.Required {
+				report.Missing = append(report.Missing, name)
+			}
+			continue
+		}
+		report.Missing = append(report.Missing, missingPaths(reflect.ValueOf(value), prop, name)...)
+	}
+
+	for key := range ctx {
+		if _, ok := shape.Properties[key]; !ok {
+			report.Unused = append(report.Unused, key)
+		}
+	}
+	sort.Strings(report.Unused)
+	return report, nil
+}
+
+// missingPaths returns the paths of required values below v that are absent.
+func missingPaths(v reflect.Value, shape *Shape, path string) []string {
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+
+	var missing []string
+	switch shape.Type {
+	case "object":
+		for _, name := range shape.Variables() {
+			prop := shape.Properties[name]
+			child, ok := attribute(v, name)
+			if !ok {
+				if prop.Required {
+					missing = append(missing, path+"."+name)
+				}
+				continue
+			}
+			missing = append(missing, missingPaths(child, prop, path+"."+name)...)
+		}
+	case "array":
+		if shape.Items != nil && (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) {
+			for i := 0; i < v.Len(); i++ {
+				missing = append(missing, missingPaths(v.Index(i), shape.Items, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return missing
+}
+
+// attribute resolves name on a map, struct or method set the way pongo2 does.
+func attribute(v reflect.Value, name string) (reflect.Value, bool) {
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return reflect.Value{}, false
+		}
+		child := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		return child, child.IsValid()
+	case reflect.Struct:
+		if field := v.FieldByName(name); field.IsValid() {
+			return field, true
+		}
+		if method := v.MethodByName(name); method.IsValid() {
+			return method, true
+		}
+	}
+	return reflect.Value{}, false
+}
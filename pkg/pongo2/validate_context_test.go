@@ -0,0 +1,55 @@
+package pongo2
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+func TestValidateContext(t *testing.T) {
+	templateString := `{{ user.name }}{% for tag in tags %}{{ tag.label }}{% endfor %}{% if bio %}{{ bio }}{% endif %}`
+
+	report, err := ValidateContext(templateString, pongo2.Context{
+		"user":  map[string]interface{}{"email": "john@example.com"},
+		"tags":  []map[string]string{{"label": "a"}, {"name": "b"}},
+		"extra": true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to validate context: %v", err)
+	}
+
+	wantMissing := []string{"tags[1].label", "user.name"}
+	if !reflect.DeepEqual(report.Missing, wantMissing) {
+		t.Errorf("expected missing %v, got %v", wantMissing, report.Missing)
+	}
+	if !reflect.DeepEqual(report.Unused, []string{"extra"}) {
+		t.Errorf("expected unused [extra], got %v", report.Unused)
+	}
+	if report.OK() {
+		t.Error("Report with missing values should not be OK")
+	}
+}
+
+func TestValidateContextComplete(t *testing.T) {
+	type User struct{ Name string }
+
+	report, err := ValidateContext(`{{ user.Name }} {{ count }}`, pongo2.Context{
+		"user":  User{Name: "John"},
+		"count": 3,
+	})
+	if err != nil {
+		t.Fatalf("Failed to validate context: %v", err)
+	}
+	if !report.OK() || len(report.Unused) != 0 {
+		t.Errorf("Complete context should be OK with nothing unused, got %+v", report)
+	}
+
+	report, err = ValidateContext(`{{ count }}`, nil)
+	if err != nil {
+		t.Fatalf("Failed to validate context: %v", err)
+	}
+	if !reflect.DeepEqual(report.Missing, []string{"count"}) {
+		t.Errorf("expected missing [count], got %v", report.Missing)
+	}
+}
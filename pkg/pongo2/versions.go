@@ -0,0 +1,153 @@
+package pongo2
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Versioned templates carry "@v<major>[.<minor>...]" at the end of their base
+// name, before the extension: "invoice@v2.txt", "emails/welcome@v1.3.html".
+// A Registry resolves references to them with these selectors:
+//
+//	invoice@v2.txt      exact version (v2 and v2.0 are equal)
+//	invoice@latest.txt  highest version
+//	invoice@^v2.txt     highest version with major version 2
+//	invoice@~v2.1.txt   highest version 2.1.x
+//	invoice.txt         the unversioned file if there is one, else the latest
+var (
+	reVersionedName = regexp.MustCompile(`^(.*)@v(\d+(?:\.\d+)*)(\.[^/@]*)?$`)
+	reVersionRef    = regexp.MustCompile(`^(.*)@(latest|[~^]?v\d+(?:\.\d+)*)(\.[^/@]*)?$`)
+)
+
+// templateVersion is one version of a versioned template.
+type templateVersion struct {
+	name    string // registry name, e.g. "invoice@v2.txt"
+	version []int
+}
+
+// parseVersionedName splits a template name into its family name (the name
+// without the version) and version.
+func parseVersionedName(name string) (family string, version []int, ok bool) {
+	m := reVersionedName.FindStringSubmatch(name)
+	if m == nil || strings.Contains(m[1], "@") {
+		return "", nil, false
+	}
+	version, err := parseVersion(m[2])
+	if err != nil {
+		return "", nil, false
+	}
+	return m[1] + m[3], version, true
+}
+
+func parseVersion(s string) ([]int, error) {
+	parts := strings.Split(strings.TrimPrefix(s, "v"), ".")
+	version := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q", s)
+		}
+		version[i] = n
+	}
+	return version, nil
+}
+
+// compareVersions compares versions segment by segment; missing segments count as 0.
+func compareVersions(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// hasPrefixVersion reports whether v starts with the segments of prefix.
+func hasPrefixVersion(v, prefix []int) bool {
+	for i, p := range prefix {
+		n := 0
+		if i < len(v) {
+			n = v[i]
+		}
+		if n != p {
+			return false
+		}
+	}
+	return true
+}
+
+// indexVersions groups the versioned templates of the registry by family,
+// each sorted from lowest to highest version.
+func (reg *Registry) indexVersions() {
+	reg.versions = make(map[string][]templateVersion)
+	for name := range reg.templates {
+		if family, version, ok := parseVersionedName(name); ok {
+			reg.versions[family] = append(reg.versions[family], templateVersion{name, version})
+		}
+	}
+	for _, versions := range reg.versions {
+		sort.Slice(versions, func(i, j int) bool {
+			return compareVersions(versions[i].version, versions[j].version) < 0
+		})
+	}
+}
+
+// Resolve returns the name of the template a reference selects, such as
+// "invoice@v2.1.txt" for "invoice@^v2.txt". Store the resolved name to render
+// the same version again later.
+func (reg *Registry) Resolve(ref string) (string, error) {
+	if _, ok := reg.templates[ref]; ok {
+		return ref, nil
+	}
+
+	family, selector := ref, "latest"
+	if m := reVersionRef.FindStringSubmatch(ref); m != nil {
+		family, selector = m[1]+m[3], m[2]
+	}
+	versions := reg.versions[family]
+
+	var match func(v []int) bool
+	switch {
+	case selector == "latest":
+		match = func([]int) bool { return true }
+	case strings.HasPrefix(selector, "^"), strings.HasPrefix(selector, "~"):
+		want, err := parseVersion(selector[1:])
+		if err != nil {
+			return "", &RenderError{Template: ref, Err: err}
+		}
+		n := 1
+		if selector[0] == '~' {
+			n = 2
+		}
+		if len(want) > n {
+			want = want[:n]
+		}
+		match = func(v []int) bool { return hasPrefixVersion(v, want) }
+	default:
+		want, err := parseVersion(selector)
+		if err != nil {
+			return "", &RenderError{Template: ref, Err: err}
+		}
+		match = func(v []int) bool { return compareVersions(v, want) == 0 }
+	}
+
+	for i := len(versions) - 1; i >= 0; i-- {
+		if match(versions[i].version) {
+			return versions[i].name, nil
+		}
+	}
+	return "", &RenderError{Template: ref, Err: ErrTemplateNotFound}
+}
@@ -0,0 +1,60 @@
+package pongo2
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRegistryVersions(t *testing.T) {
+	fsys := fstest.MapFS{
+		"invoice@v1.txt":   {Data: []byte(`v1`)},
+		"invoice@v2.txt":   {Data: []byte(`v2`)},
+		"invoice@v2.1.txt": {Data: []byte(`v2.1`)},
+		"invoice@v10.txt":  {Data: []byte(`v10`)},
+		"receipt.txt":      {Data: []byte(`receipt`)},
+		"receipt@v3.txt":   {Data: []byte(`receipt v3`)},
+	}
+	reg, err := LoadFS(fsys, Options{})
+	if err != nil {
+		t.Fatalf("LoadFS failed: %v", err)
+	}
+
+	tests := []struct {
+		ref      string
+		expected string
+	}{
+		{"invoice@v2.txt", "invoice@v2.txt"},
+		{"invoice@v2.0.txt", "invoice@v2.txt"},
+		{"invoice@latest.txt", "invoice@v10.txt"},
+		{"invoice.txt", "invoice@v10.txt"},
+		{"invoice@^v2.txt", "invoice@v2.1.txt"},
+		{"invoice@~v2.0.txt", "invoice@v2.txt"},
+		{"receipt.txt", "receipt.txt"},
+		{"receipt@latest.txt", "receipt@v3.txt"},
+	}
+	for _, tt := range tests {
+		got, err := reg.Resolve(tt.ref)
+		if err != nil {
+			t.Errorf("Resolve(%q) failed: %v", tt.ref, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("Resolve(%q): expected %q, got %q", tt.ref, tt.expected, got)
+		}
+	}
+
+	output, err := reg.Render("invoice@^v2.txt", nil)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if output != "v2.1" {
+		t.Errorf("expected %q, got %q", "v2.1", output)
+	}
+
+	for _, ref := range []string{"invoice@v3.txt", "invoice@^v3.txt", "missing@latest.txt"} {
+		if _, err := reg.Resolve(ref); !errors.Is(err, ErrTemplateNotFound) {
+			t.Errorf("Resolve(%q): expected ErrTemplateNotFound, got %v", ref, err)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Package report turns the validation results of a batch of documents into a
+// summary report: a pass/fail table, the constraints that fail most often and
+// the violations of each file. Reports are rendered in Markdown or HTML
+// through the pongo2 templates bundled with the package, or through custom
+// templates that receive the same context.
+package report
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/jsonutil"
+	"go-demo/pkg/pongo2"
+)
+
+// Result is the outcome of validating one document.
+type Result struct {
+	File string
+	// Violations are the document's violations; none if it is valid.
+	Violations []schemautil.Violation
+	// Err is set if the document couldn't be validated, e.g. because it is
+	// malformed.
+	Err error
+}
+
+// File statuses.
+const (
+	StatusPass  = "pass"
+	StatusFail  = "fail"
+	StatusError = "error"
+)
+
+// FileSummary is the entry of a file in a Summary.
+type FileSummary struct {
+	File       string                 `json:"file"`
+	Status     string                 `json:"status"`
+	Violations []schemautil.Violation `json:"violations"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// Constraint is a schema keyword that documents violated.
+type Constraint struct {
+	// KeywordLocation is the keyword's JSON Pointer in the schema.
+	KeywordLocation string `json:"keywordLocation"`
+	// Keyword is the keyword's name, the last token of KeywordLocation.
+	Keyword    string `json:"keyword"`
+	Violations int    `json:"violations"`
+	Files      int    `json:"files"`
+	// Message is the message of the first violation, as an example.
+	Message string `json:"message"`
+}
+
+// Summary is the content of a report. Its JSON encoding is the context of
+// the report templates.
+type Summary struct {
+	Title       string    `json:"title"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Total       int       `json:"total"`
+	Passed      int       `json:"passed"`
+	Failed      int       `json:"failed"`
+	Errors      int       `json:"errors"`
+	// PassRate is the percentage of files that passed, to one decimal.
+	PassRate float64 `json:"pass_rate"`
+	// TopConstraints are the most violated constraints, most violations
+	// first.
+	TopConstraints []Constraint  `json:"top_constraints"`
+	Files          []FileSummary `json:"files"`
+}
+
+// Options configures a report.
+type Options struct {
+	// Title is the report's title, "Validation report" if empty.
+	Title string
+
+	// Top is the number of constraints listed, 10 if 0.
+	Top int
+
+	// Now returns the generation time; time.Now if nil.
+	Now func() time.Time
+}
+
+// Summarize summarizes results, keeping their order.
+func Summarize(results []Result, opts Options) Summary {
+	if opts.Title == "" {
+		opts.Title = "Validation report"
+	}
+	if opts.Top <= 0 {
+		opts.Top = 10
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	s := Summary{Title: opts.Title, GeneratedAt: opts.Now().UTC(), Total: len(results), TopConstraints: []Constraint{}, Files: []FileSummary{}}
+
+	byLocation := map[string]*Constraint{}
+	for _, r := range results {
+		f := FileSummary{File: r.File, Violations: r.Violations}
+		if f.Violations == nil {
+			f.Violations = []schemautil.Violation{}
+		}
+		switch {
+		case r.Err != nil:
+			f.Status, f.Error = StatusError, r.Err.Error()
+			s.Errors++
+		case len(r.Violations) > 0:
+			f.Status = StatusFail
+			s.Failed++
+		default:
+			f.Status = StatusPass
+			s.Passed++
+		}
+		s.Files = append(s.Files, f)
+
+		counted := map[string]bool{}
+		for _, v := range r.Violations {
+			c := byLocation[v.KeywordLocation]
+			if c == nil {
+				c = &Constraint{KeywordLocation: v.KeywordLocation, Keyword: path.Base(v.KeywordLocation), Message: v.Message}
+				byLocation[v.KeywordLocation] = c
+			}
+			c.Violations++
+			if !counted[v.KeywordLocation] {
+				counted[v.KeywordLocation] = true
+				c.Files++
+			}
+		}
+	}
+	if s.Total > 0 {
+		s.PassRate = math.Round(float64(s.Passed)*1000/float64(s.Total)) / 10
+	}
+
+	for _, c := range byLocation {
+		s.TopConstraints = append(s.TopConstraints, *c)
+	}
+	sort.Slice(s.TopConstraints, func(i, j int) bool {
+		a, b := s.TopConstraints[i], s.TopConstraints[j]
+		if a.Violations != b.Violations {
+			return a.Violations > b.Violations
+		}
+		return a.KeywordLocation < b.KeywordLocation
+	})
+	if len(s.TopConstraints) > opts.Top {
+		s.TopConstraints = s.TopConstraints[:opts.Top]
+	}
+	return s
+}
+
+// Format is a report format.
+type Format string
+
+// Report formats.
+const (
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+)
+
+// FormatFromPath returns the format of a report file by its extension: .md
+// and .markdown for Markdown, .html and .htm for HTML.
+func FormatFromPath(p string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(p)) {
+	case ".md", ".markdown":
+		return FormatMarkdown, nil
+	case ".html", ".htm":
+		return FormatHTML, nil
+	}
+	return "", fmt.Errorf("%s: unknown report format (want .md or .html)", p)
+}
+
+//go:embed templates
+var bundled embed.FS
+
+// templates compiles the bundled templates once.
+var templates = sync.OnceValues(func() (*pongo2.Registry, error) {
+	fsys, err := fs.Sub(bundled, "templates")
+	if err != nil {
+		return nil, err
+	}
+	return pongo2.LoadFS(fsys, pongo2.Options{TrimBlocks: true, LStripBlocks: true, OutputMode: pongo2.OutputText})
+})
+
+// Write renders the report of results in format to w with the bundled
+// templates.
+func Write(w io.Writer, format Format, results []Result, opts Options) error {
+	var name string
+	var mode pongo2.OutputMode
+	switch format {
+	case FormatMarkdown:
+		name, mode = "report.md", pongo2.OutputMarkdown
+	case FormatHTML:
+		name, mode = "report.html", pongo2.OutputHTML
+	default:
+		return fmt.Errorf("unknown report format %q", format)
+	}
+	reg, err := templates()
+	if err != nil {
+		return err
+	}
+	ctx, err := Summarize(results, opts).Context()
+	if err != nil {
+		return err
+	}
+	out, err := reg.Render(name, ctx, pongo2.WithOutputMode(mode))
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, out)
+	return err
+}
+
+// Context returns the summary as a template context, for custom report
+// templates: the JSON encoding of s, with numbers as json.Number.
+func (s Summary) Context() (map[string]interface{}, error) {
+	b, err := jsonutil.Marshal(jsonutil.FormatJSON, s, 0)
+	if err != nil {
+		return nil, err
+	}
+	v, err := jsonutil.DecodeBytes(b)
+	if err != nil {
+		return nil, err
+	}
+	return v.(map[string]interface{}), nil
+}
@@ -0,0 +1,113 @@
+package report
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	schemautil "go-demo/pkg/jsonschema"
+)
+
+var results = []Result{
+	{File: "alice.json"},
+	{File: "bob.json", Violations: []schemautil.Violation{
+		{InstanceLocation: "", KeywordLocation: "/required", Message: "missing properties: 'name'"},
+		{InstanceLocation: "/age", KeywordLocation: "/properties/age/minimum", Message: "must be >= 0 but found -1"},
+	}},
+	{File: "<carol>.json", Violations: []schemautil.Violation{
+		{InstanceLocation: "", KeywordLocation: "/required", Message: "missing properties: 'name'"},
+	}},
+	{File: "broken.json", Err: errors.New("unexpected EOF")},
+}
+
+var now = func() time.Time { return time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC) }
+
+func TestSummarize(t *testing.T) {
+	s := Summarize(results, Options{Now: now})
+	if s.Title != "Validation report" || !s.GeneratedAt.Equal(now()) {
+		t.Errorf("unexpected title or time: %q %v", s.Title, s.GeneratedAt)
+	}
+	if s.Total != 4 || s.Passed != 1 || s.Failed != 2 || s.Errors != 1 || s.PassRate != 25 {
+		t.Errorf("unexpected counts %+v", s)
+	}
+	want := []Constraint{
+		{KeywordLocation: "/required", Keyword: "required", Violations: 2, Files: 2, Message: "missing properties: 'name'"},
+		{KeywordLocation: "/properties/age/minimum", Keyword: "minimum", Violations: 1, Files: 1, Message: "must be >= 0 but found -1"},
+	}
+	if !reflect.DeepEqual(s.TopConstraints, want) {
+		t.Errorf("expected constraints %+v, got %+v", want, s.TopConstraints)
+	}
+	var statuses []string
+	for _, f := range s.Files {
+		statuses = append(statuses, f.Status)
+	}
+	if !reflect.DeepEqual(statuses, []string{StatusPass, StatusFail, StatusFail, StatusError}) || s.Files[3].Error != "unexpected EOF" {
+		t.Errorf("unexpected files %+v", s.Files)
+	}
+
+	if s := Summarize(results, Options{Top: 1}); len(s.TopConstraints) != 1 || s.TopConstraints[0].Keyword != "required" {
+		t.Errorf("expected the top constraint only, got %+v", s.TopConstraints)
+	}
+	if s := Summarize(nil, Options{}); s.Total != 0 || s.PassRate != 0 || s.Files == nil || s.TopConstraints == nil {
+		t.Errorf("unexpected empty summary %+v", s)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		format Format
+		want   []string
+	}{
+		{FormatMarkdown, []string{
+			"# Nightly\n\nGenerated 2024-05-06T07:08:09Z.\n",
+			"| 4 | 1 | 2 | 1 | 25% |\n",
+			"| /required | 2 | 2 | missing properties: 'name' |\n",
+			"| /properties/age/minimum | 1 | 1 | must be \\>= 0 but found -1 |\n",
+			"| alice.json | ✅ pass | 0 |\n",
+			"| \\<carol\\>.json | ❌ fail | 1 |\n",
+			"| broken.json | ⚠️ error | 0 |\n",
+			"### bob.json\n\n- /: missing properties: 'name' (at #/required)\n- /age: must be \\>= 0 but found -1 (at #/properties/age/minimum)\n",
+			"### broken.json\n\nError: unexpected EOF\n",
+		}},
+		{FormatHTML, []string{
+			"<title>Nightly</title>",
+			`<td class="num">25%</td>`,
+			"<td><code>/required</code></td><td class=\"num\">2</td>",
+			"must be &gt;= 0 but found -1",
+			"<tr><td>&lt;carol&gt;.json</td><td class=\"fail\">fail</td>",
+			`<p class="error">unexpected EOF</p>`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var b strings.Builder
+			if err := Write(&b, tt.format, results, Options{Title: "Nightly", Now: now}); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(b.String(), want) {
+					t.Errorf("expected %q in:\n%s", want, b.String())
+				}
+			}
+			if strings.Contains(b.String(), "### alice.json") || strings.Contains(b.String(), "<strong>alice.json") {
+				t.Errorf("expected no details for passing files:\n%s", b.String())
+			}
+		})
+	}
+	if err := Write(&strings.Builder{}, "pdf", results, Options{}); err == nil {
+		t.Error("expected an unknown format error")
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	for p, want := range map[string]Format{"r.md": FormatMarkdown, "r.MARKDOWN": FormatMarkdown, "out/r.html": FormatHTML, "r.htm": FormatHTML} {
+		if got, err := FormatFromPath(p); err != nil || got != want {
+			t.Errorf("FormatFromPath(%q) = %q, %v; want %q", p, got, err, want)
+		}
+	}
+	if _, err := FormatFromPath("r.txt"); err == nil {
+		t.Error("expected an error for .txt")
+	}
+}
@@ -0,0 +1,113 @@
+package schemastore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Dir is a Store in a directory tree, one file per version:
+// <dir>/<name>/<version>.json, e.g. schemas/billing/invoice/2.1.0.json.
+type Dir struct {
+	dir string
+}
+
+// NewDir returns a store in dir, which is created by the first Put if
+// needed.
+func NewDir(dir string) *Dir {
+	return &Dir{dir: dir}
+}
+
+func (d *Dir) path(name string, version Version) string {
+	return filepath.Join(d.dir, filepath.FromSlash(name), version.String()+".json")
+}
+
+// Get implements Store.
+func (d *Dir) Get(ctx context.Context, name string, version Version) ([]byte, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(d.path(name, version))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s@%s: %w", name, version, ErrNotFound)
+	}
+	return b, err
+}
+
+// Put implements Store. The file is written under a temporary name and
+// renamed, so readers never see part of it.
+func (d *Dir) Put(ctx context.Context, name string, version Version, schema []byte) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	path := d.path(name, version)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(schema); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// A hard link fails if the version exists, unlike a rename.
+	if err := os.Link(tmp.Name(), path); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("%s@%s: %w", name, version, ErrExists)
+		}
+		return err
+	}
+	return nil
+}
+
+// List implements Store. Files that aren't named after a version are
+// ignored.
+func (d *Dir) List(ctx context.Context) ([]Entry, error) {
+	var entries []Entry
+	err := filepath.WalkDir(d.dir, func(path string, de fs.DirEntry, err error) error {
+		if err != nil {
+			if path == d.dir && errors.Is(err, fs.ErrNotExist) {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		if de.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		version, err := ParseVersion(strings.TrimSuffix(de.Name(), ".json"))
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(d.dir, filepath.Dir(path))
+		if err != nil || rel == "." {
+			return nil
+		}
+		entries = append(entries, Entry{Name: filepath.ToSlash(rel), Version: version})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortEntries(entries)
+	return entries, nil
+}
+
+func sortEntries(entries []Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Name != entries[j].Name {
+			return entries[i].Name < entries[j].Name
+		}
+		return entries[i].Version.Compare(entries[j].Version) < 0
+	})
+}
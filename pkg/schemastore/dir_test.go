@@ -0,0 +1,28 @@
+package schemastore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "schemas")
+	s := NewDir(dir)
+	if entries, err := s.List(context.Background()); err != nil || len(entries) != 0 {
+		t.Fatalf("expected an empty store before the first Put, got %v, %v", entries, err)
+	}
+	testStore(t, s)
+
+	if _, err := os.Stat(filepath.Join(dir, "billing", "invoice", "0.1.0.json")); err != nil {
+		t.Errorf("expected a file per version: %v", err)
+	}
+	// Other files are ignored.
+	if err := os.WriteFile(filepath.Join(dir, "billing", "README.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := s.List(context.Background()); len(entries) != 5 {
+		t.Errorf("expected 5 entries, got %v", entries)
+	}
+}
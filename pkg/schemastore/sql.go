@@ -0,0 +1,99 @@
+package schemastore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go-demo/internal/sqlutil"
+)
+
+// SQLConfig configures a SQL store.
+type SQLConfig struct {
+	// Table is the name of the table, "schemas" if empty. It is created if
+	// it doesn't exist.
+	Table string
+
+	// Placeholder is the parameter style of the driver: "?" (the default,
+	// e.g. SQLite and MySQL) or "$" for numbered parameters ($1, $2, ...,
+	// e.g. PostgreSQL).
+	Placeholder string
+}
+
+// SQL is a Store in a database table with one row per version.
+type SQL struct {
+	db    *sql.DB
+	table sqlutil.Table
+}
+
+// NewSQL returns a store in a table of db, creating the table if needed.
+func NewSQL(ctx context.Context, db *sql.DB, cfg SQLConfig) (*SQL, error) {
+	table, err := sqlutil.NewTable(cfg.Table, "schemas", cfg.Placeholder)
+	if err != nil {
+		return nil, err
+	}
+	err = table.Create(ctx, db, `
+		name VARCHAR(255) NOT NULL,
+		major INTEGER NOT NULL,
+		minor INTEGER NOT NULL,
+		patch INTEGER NOT NULL,
+		document TEXT NOT NULL,
+		PRIMARY KEY (name, major, minor, patch)
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return &SQL{db: db, table: table}, nil
+}
+
+// Get implements Store.
+func (s *SQL) Get(ctx context.Context, name string, version Version) ([]byte, error) {
+	var doc string
+	err := s.db.QueryRowContext(ctx,
+		s.table.Query(`SELECT document FROM `+s.table.Name+` WHERE name = ? AND major = ? AND minor = ? AND patch = ?`),
+		name, version.Major, version.Minor, version.Patch).Scan(&doc)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s@%s: %w", name, version, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(doc), nil
+}
+
+// Put implements Store.
+func (s *SQL) Put(ctx context.Context, name string, version Version, schema []byte) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx,
+		s.table.Query(`INSERT INTO `+s.table.Name+` (name, major, minor, patch, document) VALUES (?, ?, ?, ?, ?)`),
+		name, version.Major, version.Minor, version.Patch, string(schema))
+	if err != nil {
+		// Drivers report duplicate keys differently; look the row up instead.
+		if _, getErr := s.Get(ctx, name, version); getErr == nil {
+			return fmt.Errorf("%s@%s: %w", name, version, ErrExists)
+		}
+		return err
+	}
+	return nil
+}
+
+// List implements Store.
+func (s *SQL) List(ctx context.Context) ([]Entry, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT name, major, minor, patch FROM `+s.table.Name+` ORDER BY name, major, minor, patch`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []Entry
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(&e.Name, &e.Version.Major, &e.Version.Minor, &e.Version.Patch); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
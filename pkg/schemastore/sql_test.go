@@ -0,0 +1,47 @@
+package schemastore
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestSQL(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "schemas.db"))
+	if err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	defer db.Close()
+
+	s, err := NewSQL(context.Background(), db, SQLConfig{Table: "json_schemas"})
+	if err != nil {
+		t.Fatalf("NewSQL failed: %v", err)
+	}
+	testStore(t, s)
+
+	// The table survives a new store.
+	s, err = NewSQL(context.Background(), db, SQLConfig{Table: "json_schemas"})
+	if err != nil {
+		t.Fatalf("NewSQL failed: %v", err)
+	}
+	if entries, _ := s.List(context.Background()); len(entries) != 5 {
+		t.Errorf("expected 5 entries, got %v", entries)
+	}
+
+	if _, err := NewSQL(context.Background(), db, SQLConfig{Table: "x; DROP TABLE json_schemas"}); err == nil {
+		t.Error("NewSQL should reject invalid table names")
+	}
+	if _, err := NewSQL(context.Background(), db, SQLConfig{Placeholder: ":"}); err == nil {
+		t.Error("NewSQL should reject unknown placeholder styles")
+	}
+	s, err = NewSQL(context.Background(), db, SQLConfig{Placeholder: "$"})
+	if err != nil {
+		t.Fatalf("NewSQL failed: %v", err)
+	}
+	if q := s.table.Query("a = ? AND b = ?"); q != "a = $1 AND b = $2" {
+		t.Errorf("expected numbered parameters, got %q", q)
+	}
+}
@@ -0,0 +1,105 @@
+// Package schemastore keeps JSON Schemas by name and semantic version, so that
+// programs resolve references such as "user-profile@^2" at run time instead
+// of hard-coding file paths. Dir stores them in a directory tree, SQL in a
+// database table.
+package schemastore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	schemautil "go-demo/pkg/jsonschema"
+)
+
+var (
+	// ErrNotFound is matched by the errors for missing schemas and references
+	// no stored version satisfies.
+	ErrNotFound = errors.New("schema not found")
+
+	// ErrExists is returned by Put for a version that is already stored;
+	// stored versions never change.
+	ErrExists = errors.New("schema version already exists")
+)
+
+// Store keeps schema documents by name and version. Implementations must be
+// safe for concurrent use.
+type Store interface {
+	// Get returns the document of name at exactly version.
+	Get(ctx context.Context, name string, version Version) ([]byte, error)
+
+	// Put stores a new version of name.
+	Put(ctx context.Context, name string, version Version, schema []byte) error
+
+	// List returns the stored versions, sorted by name and then version.
+	List(ctx context.Context) ([]Entry, error)
+}
+
+// Entry identifies a stored schema version.
+type Entry struct {
+	Name    string  `json:"name"`
+	Version Version `json:"version"`
+}
+
+func (e Entry) String() string {
+	return e.Name + "@" + e.Version.String()
+}
+
+// ParseRef splits a reference of the form name[@constraint] (see
+// Constraint); without a constraint, it selects the latest version.
+func ParseRef(ref string) (name string, c Constraint, err error) {
+	name, constraint, _ := strings.Cut(ref, "@")
+	if err := checkName(name); err != nil {
+		return "", Constraint{}, err
+	}
+	c, err = ParseConstraint(constraint)
+	return name, c, err
+}
+
+// Resolve returns the highest stored version a reference selects.
+func Resolve(ctx context.Context, s Store, ref string) (Entry, error) {
+	name, c, err := ParseRef(ref)
+	if err != nil {
+		return Entry{}, err
+	}
+	entries, err := s.List(ctx)
+	if err != nil {
+		return Entry{}, err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if e := entries[i]; e.Name == name && c.Match(e.Version) {
+			return e, nil
+		}
+	}
+	return Entry{}, fmt.Errorf("%s: %w", ref, ErrNotFound)
+}
+
+// Load resolves a reference and compiles the schema it selects, returning
+// the version it took.
+func Load(ctx context.Context, s Store, ref string) (*jsonschema.Schema, Entry, error) {
+	e, err := Resolve(ctx, s, ref)
+	if err != nil {
+		return nil, Entry{}, err
+	}
+	doc, err := s.Get(ctx, e.Name, e.Version)
+	if err != nil {
+		return nil, Entry{}, err
+	}
+	schema, err := schemautil.CompileString(string(doc))
+	if err != nil {
+		return nil, Entry{}, fmt.Errorf("%s: %w", e, err)
+	}
+	return schema, e, nil
+}
+
+// checkName accepts slash-separated relative names such as "billing/invoice".
+func checkName(name string) error {
+	if name == "" || strings.ContainsAny(name, "@\\") || path.IsAbs(name) || path.Clean(name) != name || name == ".." || strings.HasPrefix(name, "../") {
+		return fmt.Errorf("invalid schema name %q", name)
+	}
+	return nil
+}
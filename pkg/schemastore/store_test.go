@@ -0,0 +1,96 @@
+package schemastore
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// testStore runs the Store contract against s.
+func testStore(t *testing.T, s Store) {
+	t.Helper()
+	ctx := context.Background()
+	docs := map[string]string{
+		"user-profile@1.0.0":    `{"type": "object"}`,
+		"user-profile@2.0.0":    `{"type": "object", "required": ["id"]}`,
+		"user-profile@2.3.1":    `{"type": "object", "required": ["id", "email"]}`,
+		"user-profile@3.0.0":    `{"type": "array"}`,
+		"billing/invoice@0.1.0": `{"type": "object", "properties": {"total": {"type": "number"}}}`,
+	}
+	for ref, doc := range docs {
+		name, c, _ := ParseRef(ref)
+		v := Version{c.parts[0], c.parts[1], c.parts[2]}
+		if err := s.Put(ctx, name, v, []byte(doc)); err != nil {
+			t.Fatalf("Put(%s) failed: %v", ref, err)
+		}
+	}
+	if err := s.Put(ctx, "user-profile", Version{2, 0, 0}, []byte(`{}`)); !errors.Is(err, ErrExists) {
+		t.Errorf("expected ErrExists for an existing version, got %v", err)
+	}
+	if err := s.Put(ctx, "../escape", Version{1, 0, 0}, []byte(`{}`)); err == nil {
+		t.Error("Put should reject names outside the store")
+	}
+
+	entries, err := s.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.String())
+	}
+	want := []string{"billing/invoice@0.1.0", "user-profile@1.0.0", "user-profile@2.0.0", "user-profile@2.3.1", "user-profile@3.0.0"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, got)
+			break
+		}
+	}
+
+	doc, err := s.Get(ctx, "user-profile", Version{2, 3, 1})
+	if err != nil || string(doc) != docs["user-profile@2.3.1"] {
+		t.Errorf("Get returned %q, %v", doc, err)
+	}
+	if _, err := s.Get(ctx, "user-profile", Version{2, 3, 2}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	resolved := map[string]string{
+		"user-profile":         "user-profile@3.0.0",
+		"user-profile@^2":      "user-profile@2.3.1",
+		"user-profile@~2.0":    "user-profile@2.0.0",
+		"user-profile@1":       "user-profile@1.0.0",
+		"billing/invoice@^0.1": "billing/invoice@0.1.0",
+	}
+	for ref, want := range resolved {
+		if e, err := Resolve(ctx, s, ref); err != nil || e.String() != want {
+			t.Errorf("Resolve(%s): expected %s, got %v, %v", ref, want, e, err)
+		}
+	}
+	if _, err := Resolve(ctx, s, "user-profile@^4"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	schema, e, err := Load(ctx, s, "user-profile@^2")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if e.Version != (Version{2, 3, 1}) || schema.Validate(map[string]interface{}{"id": 1}) == nil {
+		t.Errorf("expected user-profile 2.3.1 requiring an email, got %s", e)
+	}
+}
+
+func TestParseRef(t *testing.T) {
+	name, c, err := ParseRef("billing/invoice@^2.1")
+	if err != nil || name != "billing/invoice" || c.op != '^' || len(c.parts) != 2 {
+		t.Errorf("unexpected parse %q, %+v, %v", name, c, err)
+	}
+	for _, ref := range []string{"", "@1", "/abs@1", "a/../b", "user@>1", "user@1@2"} {
+		if _, _, err := ParseRef(ref); err == nil {
+			t.Errorf("ParseRef(%q) should fail", ref)
+		}
+	}
+}
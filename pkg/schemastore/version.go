@@ -0,0 +1,129 @@
+package schemastore
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version without pre-release or build metadata.
+type Version struct {
+	Major, Minor, Patch int
+}
+
+// ParseVersion parses "MAJOR.MINOR.PATCH", optionally prefixed with "v".
+func ParseVersion(s string) (Version, error) {
+	parts, err := parseParts(s)
+	if err != nil || len(parts) != 3 {
+		return Version{}, fmt.Errorf("invalid version %q (want MAJOR.MINOR.PATCH)", s)
+	}
+	return Version{parts[0], parts[1], parts[2]}, nil
+}
+
+// parseParts parses one to three dot-separated non-negative integers.
+func parseParts(s string) ([]int, error) {
+	fields := strings.Split(strings.TrimPrefix(s, "v"), ".")
+	if len(fields) > 3 {
+		return nil, fmt.Errorf("invalid version %q", s)
+	}
+	parts := make([]int, len(fields))
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 || f != strconv.Itoa(n) {
+			return nil, fmt.Errorf("invalid version %q", s)
+		}
+		parts[i] = n
+	}
+	return parts, nil
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare returns -1, 0 or 1 as v is lower than, equal to or higher than w.
+func (v Version) Compare(w Version) int {
+	for _, d := range [...]int{v.Major - w.Major, v.Minor - w.Minor, v.Patch - w.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Constraint selects versions. The syntax follows npm:
+//
+//	latest, *    any version
+//	2, 2.1       any 2.x.x, any 2.1.x
+//	2.1.3        exactly 2.1.3
+//	^2.1         2.1.0 up to, not including, 3.0.0 (^0.2 stays below 0.3.0)
+//	~2.1         2.1.0 up to, not including, 2.2.0 (~2 is any 2.x.x)
+type Constraint struct {
+	op    byte // 0, '^' or '~'
+	parts []int
+}
+
+// ParseConstraint parses a version constraint.
+func ParseConstraint(s string) (Constraint, error) {
+	if s == "latest" || s == "*" || s == "" {
+		return Constraint{}, nil
+	}
+	var c Constraint
+	if s[0] == '^' || s[0] == '~' {
+		c.op, s = s[0], s[1:]
+	}
+	parts, err := parseParts(s)
+	if err != nil {
+		return Constraint{}, fmt.Errorf("invalid version constraint %q", string(c.op)+s)
+	}
+	c.parts = parts
+	return c, nil
+}
+
+// Match reports whether v satisfies the constraint.
+func (c Constraint) Match(v Version) bool {
+	got := []int{v.Major, v.Minor, v.Patch}
+	// fixed is the number of leading parts that must equal the constraint's.
+	fixed := len(c.parts)
+	switch c.op {
+	case '^':
+		// The first non-zero part, and those before it, are fixed.
+		fixed = 1
+		for fixed < len(c.parts) && c.parts[fixed-1] == 0 {
+			fixed++
+		}
+	case '~':
+		if fixed > 2 {
+			fixed = 2
+		}
+	}
+	for i := 0; i < fixed; i++ {
+		if got[i] != c.parts[i] {
+			return false
+		}
+	}
+	if c.op == 0 {
+		return true
+	}
+	lower := make([]int, 3)
+	copy(lower, c.parts)
+	return v.Compare(Version{lower[0], lower[1], lower[2]}) >= 0
+}
+
+// MarshalText encodes the version as "MAJOR.MINOR.PATCH".
+func (v Version) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText parses a version with ParseVersion.
+func (v *Version) UnmarshalText(b []byte) error {
+	parsed, err := ParseVersion(string(b))
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
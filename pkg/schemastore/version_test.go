@@ -0,0 +1,66 @@
+package schemastore
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	if v, err := ParseVersion("v2.10.3"); err != nil || v != (Version{2, 10, 3}) {
+		t.Errorf("expected 2.10.3, got %v, %v", v, err)
+	}
+	for _, s := range []string{"", "2", "2.1", "2.1.x", "2.1.3.4", "-1.0.0", "01.0.0"} {
+		if _, err := ParseVersion(s); err == nil {
+			t.Errorf("ParseVersion(%q) should fail", s)
+		}
+	}
+	b, _ := json.Marshal(Entry{Name: "user", Version: Version{1, 2, 3}})
+	if want := `{"name":"user","version":"1.2.3"}`; string(b) != want {
+		t.Errorf("expected %s, got %s", want, b)
+	}
+	var e Entry
+	if err := json.Unmarshal(b, &e); err != nil || e.Version != (Version{1, 2, 3}) {
+		t.Errorf("expected the entry to round-trip, got %+v, %v", e, err)
+	}
+}
+
+func TestConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		match      []string
+		noMatch    []string
+	}{
+		{"latest", []string{"0.0.1", "9.9.9"}, nil},
+		{"2", []string{"2.0.0", "2.9.1"}, []string{"1.9.9", "3.0.0"}},
+		{"2.1", []string{"2.1.0", "2.1.7"}, []string{"2.0.9", "2.2.0"}},
+		{"2.1.3", []string{"2.1.3"}, []string{"2.1.4"}},
+		{"^2.1", []string{"2.1.0", "2.9.0"}, []string{"2.0.9", "3.0.0"}},
+		{"^2.1.3", []string{"2.1.3", "2.2.0"}, []string{"2.1.2"}},
+		{"^0.2", []string{"0.2.0", "0.2.5"}, []string{"0.3.0", "0.1.9"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"~2.1", []string{"2.1.0", "2.1.9"}, []string{"2.2.0"}},
+		{"~2.1.3", []string{"2.1.3", "2.1.4"}, []string{"2.1.2", "2.2.0"}},
+		{"~2", []string{"2.0.0", "2.5.0"}, []string{"3.0.0"}},
+	}
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Fatalf("ParseConstraint(%q) failed: %v", tt.constraint, err)
+		}
+		for _, s := range tt.match {
+			if v, _ := ParseVersion(s); !c.Match(v) {
+				t.Errorf("%s should match %s", tt.constraint, s)
+			}
+		}
+		for _, s := range tt.noMatch {
+			if v, _ := ParseVersion(s); c.Match(v) {
+				t.Errorf("%s shouldn't match %s", tt.constraint, s)
+			}
+		}
+	}
+	for _, s := range []string{"^", ">2", "^2.x", "1.2.3.4"} {
+		if _, err := ParseConstraint(s); err == nil {
+			t.Errorf("ParseConstraint(%q) should fail", s)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package stream
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go-demo/pkg/jsonutil"
+	"go-demo/pkg/pipeline"
+)
+
+// Config is the file format of LoadConfig: the broker to connect to and the
+// document type each topic is processed as.
+type Config struct {
+	// Broker is kafka or nats.
+	Broker string `json:"broker"`
+	// Brokers are the Kafka bootstrap brokers.
+	Brokers []string `json:"brokers"`
+	// URL is the NATS server URL; nats.DefaultURL if empty.
+	URL string `json:"url"`
+	// Group is the Kafka consumer group or the NATS queue group.
+	Group string `json:"group"`
+
+	// Types is the document types file (see pipeline.LoadRegistry).
+	Types string `json:"types"`
+	// Errors is the default error topic.
+	Errors string        `json:"errors"`
+	Routes []RouteConfig `json:"routes"`
+}
+
+// RouteConfig configures the route of a topic.
+type RouteConfig struct {
+	Topic  string `json:"topic"`
+	Type   string `json:"type"`
+	Output string `json:"output"`
+	Errors string `json:"errors"`
+}
+
+// LoadConfig reads a transformer configuration from a JSON, YAML or TOML
+// file (by extension). The types path is relative to the file:
+//
+//	broker: kafka
+//	brokers: [localhost:9092]
+//	group: go-demo
+//	types: types.yaml
+//	errors: documents.errors
+//	routes:
+//	  - topic: orders
+//	    type: order
+//	    output: orders.rendered
+func LoadConfig(path string) (*Config, error) {
+	format, err := jsonutil.FormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := jsonutil.Unmarshal(format, b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	// Round-trip through JSON to decode into the typed structure.
+	js, err := jsonutil.Marshal(jsonutil.FormatJSON, doc, 0)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	switch cfg.Broker {
+	case "kafka", "nats":
+	default:
+		return nil, fmt.Errorf("%s: unknown broker %q (want kafka or nats)", path, cfg.Broker)
+	}
+	if cfg.Types == "" || len(cfg.Routes) == 0 {
+		return nil, fmt.Errorf("%s: types and routes are required", path)
+	}
+	seen := map[string]bool{}
+	for i, r := range cfg.Routes {
+		if r.Topic == "" || r.Type == "" {
+			return nil, fmt.Errorf("%s: route %d: topic and type are required", path, i+1)
+		}
+		if seen[r.Topic] {
+			return nil, fmt.Errorf("%s: topic %s is routed twice", path, r.Topic)
+		}
+		seen[r.Topic] = true
+	}
+	if !filepath.IsAbs(cfg.Types) {
+		cfg.Types = filepath.Join(filepath.Dir(path), cfg.Types)
+	}
+	return &cfg, nil
+}
+
+// Topics returns the input topics of the routes.
+func (c *Config) Topics() []string {
+	topics := make([]string, len(c.Routes))
+	for i, r := range c.Routes {
+		topics[i] = r.Topic
+	}
+	return topics
+}
+
+// Transformer loads the document types and returns a transformer with the
+// configured routes; the caller sets its Consumer and Publisher. The
+// pipelines start from base, as in pipeline.LoadRegistry.
+func (c *Config) Transformer(base pipeline.Pipeline) (*Transformer, error) {
+	registry, err := pipeline.LoadRegistry(c.Types, base)
+	if err != nil {
+		return nil, err
+	}
+	t := &Transformer{Routes: map[string]Route{}, Errors: c.Errors}
+	for _, r := range c.Routes {
+		p, ok := registry.Lookup(r.Type)
+		if !ok {
+			return nil, fmt.Errorf("topic %s: %w %q", r.Topic, pipeline.ErrUnknownType, r.Type)
+		}
+		t.Routes[r.Topic] = Route{Pipeline: p, Output: r.Output, Errors: r.Errors}
+	}
+	return t, nil
+}
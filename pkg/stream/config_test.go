@@ -0,0 +1,61 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-demo/pkg/pipeline"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"types.yaml":   "types:\n  greeting:\n    template: greeting.txt\n",
+		"greeting.txt": "Hi {{ name }}",
+		"stream.yaml": "broker: nats\ngroup: g\ntypes: types.yaml\nerrors: errs\nroutes:\n" +
+			"  - {topic: in, type: greeting, output: out}\n  - {topic: other, type: missing}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg, err := LoadConfig(filepath.Join(dir, "stream.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Types != filepath.Join(dir, "types.yaml") || !reflect.DeepEqual(cfg.Topics(), []string{"in", "other"}) {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+	if _, err := cfg.Transformer(pipeline.Pipeline{}); !errors.Is(err, pipeline.ErrUnknownType) {
+		t.Fatalf("expected ErrUnknownType, got %v", err)
+	}
+	cfg.Routes = cfg.Routes[:1]
+	tr, err := cfg.Transformer(pipeline.Pipeline{})
+	if err != nil {
+		t.Fatalf("Transformer failed: %v", err)
+	}
+	out, _, err := tr.Routes["in"].Pipeline.Process(context.Background(), []byte(`{"name": "Ada"}`))
+	if err != nil || string(out) != "Hi Ada" || tr.Routes["in"].Output != "out" || tr.Errors != "errs" {
+		t.Errorf("unexpected route %+v: %q (%v)", tr.Routes["in"], out, err)
+	}
+
+	for name, tt := range map[string]struct{ content, err string }{
+		"broker":    {"broker: rabbitmq\ntypes: t.yaml\nroutes: [{topic: a, type: b}]\n", `unknown broker "rabbitmq"`},
+		"routes":    {"broker: kafka\ntypes: t.yaml\n", "types and routes are required"},
+		"route":     {"broker: kafka\ntypes: t.yaml\nroutes: [{topic: a}]\n", "route 1: topic and type are required"},
+		"duplicate": {"broker: kafka\ntypes: t.yaml\nroutes: [{topic: a, type: b}, {topic: a, type: c}]\n", "topic a is routed twice"},
+		"unknown":   {"broker: kafka\ntopics: [a]\n", `unknown field "topics"`},
+	} {
+		path := filepath.Join(dir, name+".yaml")
+		os.WriteFile(path, []byte(tt.content), 0o644)
+		if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: expected %q, got %v", name, tt.err, err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package stream
+
+import (
+	"context"
+	"errors"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaConfig configures the Kafka adapters.
+type KafkaConfig struct {
+	// Brokers are the addresses of the bootstrap brokers, host:port.
+	Brokers []string
+
+	// GroupID is the consumer group; its members share the partitions of
+	// the topics, and its committed offsets survive restarts.
+	GroupID string
+
+	// Topics are the topics to consume.
+	Topics []string
+}
+
+// Kafka consumes and publishes Kafka messages with at-least-once delivery:
+// offsets are committed after Transformer has published the result.
+type Kafka struct {
+	reader *kafka.Reader
+	writer *kafka.Writer
+}
+
+// NewKafka returns a Kafka adapter. Close it to leave the consumer group.
+func NewKafka(cfg KafkaConfig) (*Kafka, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, errors.New("kafka: no brokers")
+	}
+	k := &Kafka{
+		writer: &kafka.Writer{
+			Addr: kafka.TCP(cfg.Brokers...),
+			// Messages with the same key go to the same partition, in order.
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+		},
+	}
+	if len(cfg.Topics) > 0 {
+		if cfg.GroupID == "" {
+			return nil, errors.New("kafka: consuming requires a group ID")
+		}
+		k.reader = kafka.NewReader(kafka.ReaderConfig{
+			Brokers:     cfg.Brokers,
+			GroupID:     cfg.GroupID,
+			GroupTopics: cfg.Topics,
+		})
+	}
+	return k, nil
+}
+
+// Fetch implements Consumer.
+func (k *Kafka) Fetch(ctx context.Context) (Message, error) {
+	if k.reader == nil {
+		return Message{}, errors.New("kafka: no topics to consume")
+	}
+	km, err := k.reader.FetchMessage(ctx)
+	if err != nil {
+		return Message{}, err
+	}
+	m := Message{Topic: km.Topic, Key: km.Key, Value: km.Value, raw: km}
+	if len(km.Headers) > 0 {
+		m.Headers = make(map[string]string, len(km.Headers))
+		for _, h := range km.Headers {
+			m.Headers[h.Key] = string(h.Value)
+		}
+	}
+	return m, nil
+}
+
+// Commit implements Consumer.
+func (k *Kafka) Commit(ctx context.Context, m Message) error {
+	km, ok := m.raw.(kafka.Message)
+	if !ok || k.reader == nil {
+		return errors.New("kafka: message wasn't fetched from Kafka")
+	}
+	return k.reader.CommitMessages(ctx, km)
+}
+
+// Publish implements Publisher.
+func (k *Kafka) Publish(ctx context.Context, m Message) error {
+	km := kafka.Message{Topic: m.Topic, Key: m.Key, Value: m.Value}
+	for key, value := range m.Headers {
+		km.Headers = append(km.Headers, kafka.Header{Key: key, Value: []byte(value)})
+	}
+	return k.writer.WriteMessages(ctx, km)
+}
+
+// Close closes the connections.
+func (k *Kafka) Close() error {
+	var err error
+	if k.reader != nil {
+		err = k.reader.Close()
+	}
+	return errors.Join(err, k.writer.Close())
+}
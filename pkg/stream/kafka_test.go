@@ -0,0 +1,72 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// TestKafka runs against the brokers in GODEMO_KAFKA_BROKERS (comma
+// separated), which must allow topic auto-creation, and is skipped without
+// them.
+func TestKafka(t *testing.T) {
+	brokers := os.Getenv("GODEMO_KAFKA_BROKERS")
+	if brokers == "" {
+		t.Skip("GODEMO_KAFKA_BROKERS is not set")
+	}
+	topic := fmt.Sprintf("godemo-test-%d", time.Now().UnixNano())
+	conn, err := kafka.Dial("tcp", strings.Split(brokers, ",")[0])
+	if err != nil {
+		t.Fatalf("Failed to connect to Kafka: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.CreateTopics(kafka.TopicConfig{Topic: topic, NumPartitions: 1, ReplicationFactor: 1}); err != nil {
+		t.Fatalf("Failed to create topic: %v", err)
+	}
+
+	k, err := NewKafka(KafkaConfig{Brokers: strings.Split(brokers, ","), GroupID: topic, Topics: []string{topic}})
+	if err != nil {
+		t.Fatalf("NewKafka failed: %v", err)
+	}
+	defer k.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := k.Publish(ctx, Message{Topic: topic, Key: []byte("k"), Value: []byte("v"), Headers: map[string]string{"h": "1"}}); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	m, err := k.Fetch(ctx)
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if m.Topic != topic || string(m.Key) != "k" || string(m.Value) != "v" || m.Headers["h"] != "1" {
+		t.Errorf("unexpected message %+v", m)
+	}
+	if err := k.Commit(ctx, m); err != nil {
+		t.Errorf("Commit failed: %v", err)
+	}
+}
+
+func TestNewKafka(t *testing.T) {
+	if _, err := NewKafka(KafkaConfig{}); err == nil {
+		t.Error("expected an error without brokers")
+	}
+	if _, err := NewKafka(KafkaConfig{Brokers: []string{"localhost:9092"}, Topics: []string{"t"}}); err == nil {
+		t.Error("expected an error consuming without a group")
+	}
+	k, err := NewKafka(KafkaConfig{Brokers: []string{"localhost:9092"}})
+	if err != nil {
+		t.Fatalf("NewKafka failed: %v", err)
+	}
+	defer k.Close()
+	if _, err := k.Fetch(context.Background()); err == nil {
+		t.Error("expected Fetch to fail without topics")
+	}
+	if err := k.Commit(context.Background(), Message{}); err == nil {
+		t.Error("expected Commit to fail for a message not from Kafka")
+	}
+}
@@ -0,0 +1,89 @@
+package stream
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// Memory is an in-memory broker, for tests and for running a Transformer
+// inside a program. It is both a Consumer of the topics it was created with
+// and a Publisher to any topic, and is safe for concurrent use.
+type Memory struct {
+	topics map[string]bool
+	queue  chan Message
+
+	mu        sync.Mutex
+	closed    bool
+	published map[string][]Message
+}
+
+// NewMemory returns a broker whose Fetch returns the messages published to
+// topics, in order, buffering up to size of them.
+func NewMemory(size int, topics ...string) *Memory {
+	m := &Memory{topics: map[string]bool{}, queue: make(chan Message, size), published: map[string][]Message{}}
+	for _, t := range topics {
+		m.topics[t] = true
+	}
+	return m
+}
+
+// Publish implements Publisher. Messages of consumed topics are queued for
+// Fetch, blocking while the queue is full, and fail once the broker is
+// closed; all messages are kept for Messages.
+func (m *Memory) Publish(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.topics[msg.Topic] {
+		m.published[msg.Topic] = append(m.published[msg.Topic], msg)
+		return nil
+	}
+	if m.closed {
+		return io.ErrClosedPipe
+	}
+	m.published[msg.Topic] = append(m.published[msg.Topic], msg)
+	select {
+	case m.queue <- msg:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Fetch implements Consumer. It returns io.EOF once the broker is closed and
+// its queue is drained.
+func (m *Memory) Fetch(ctx context.Context) (Message, error) {
+	select {
+	case msg, ok := <-m.queue:
+		if !ok {
+			return Message{}, io.EOF
+		}
+		return msg, nil
+	case <-ctx.Done():
+		return Message{}, ctx.Err()
+	}
+}
+
+// Commit implements Consumer; it does nothing.
+func (m *Memory) Commit(context.Context, Message) error {
+	return nil
+}
+
+// Messages returns the messages published to topic so far.
+func (m *Memory) Messages(topic string) []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.published[topic]...)
+}
+
+// Close ends the consumed topics: Fetch returns the queued messages, then
+// io.EOF. Other topics can still be published to.
+func (m *Memory) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.closed {
+		m.closed = true
+		close(m.queue)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(2, "in")
+	for _, msg := range []Message{{Topic: "in", Value: []byte("1")}, {Topic: "out", Value: []byte("2")}, {Topic: "in", Value: []byte("3")}} {
+		if err := m.Publish(ctx, msg); err != nil {
+			t.Fatalf("Publish failed: %v", err)
+		}
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := m.Publish(canceled, Message{Topic: "in"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a full queue to block until the context is done, got %v", err)
+	}
+	m.Close()
+	if err := m.Publish(ctx, Message{Topic: "in"}); err == nil {
+		t.Error("expected Publish to a consumed topic to fail once closed")
+	}
+	if err := m.Publish(ctx, Message{Topic: "out", Value: []byte("4")}); err != nil {
+		t.Errorf("expected Publish to other topics to work once closed, got %v", err)
+	}
+
+	var values []string
+	for {
+		msg, err := m.Fetch(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Fetch failed: %v", err)
+		}
+		values = append(values, string(msg.Value))
+	}
+	if len(values) != 2 || values[0] != "1" || values[1] != "3" {
+		t.Errorf("expected the consumed topic's messages in order, got %q", values)
+	}
+	if out := m.Messages("out"); len(out) != 2 || string(out[0].Value) != "2" {
+		t.Errorf("expected the published message to be kept, got %+v", out)
+	}
+}
@@ -0,0 +1,101 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATS consumes and publishes core NATS messages. Core NATS doesn't keep
+// messages, so delivery is at most once: messages published while no
+// subscriber runs are lost, and Commit does nothing.
+type NATS struct {
+	conn *nats.Conn
+	subs []*nats.Subscription
+	msgs chan *nats.Msg
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewNATS subscribes to subjects on conn. With a queue group, the members of
+// the group share the messages instead of each getting all of them. The
+// caller keeps ownership of conn.
+func NewNATS(conn *nats.Conn, queue string, subjects ...string) (*NATS, error) {
+	n := &NATS{conn: conn, msgs: make(chan *nats.Msg, 256), done: make(chan struct{})}
+	for _, subject := range subjects {
+		sub, err := conn.ChanQueueSubscribe(subject, queue, n.msgs)
+		if err != nil {
+			n.Close()
+			return nil, err
+		}
+		n.subs = append(n.subs, sub)
+	}
+	// Make sure the server has the subscriptions before anything is
+	// published.
+	if err := conn.Flush(); err != nil {
+		n.Close()
+		return nil, err
+	}
+	return n, nil
+}
+
+// natsKeyHeader carries message keys, which NATS lacks.
+const natsKeyHeader = "Godemo-Key"
+
+// Fetch implements Consumer. It returns io.EOF once the adapter is closed.
+func (n *NATS) Fetch(ctx context.Context) (Message, error) {
+	select {
+	case nm := <-n.msgs:
+		m := Message{Topic: nm.Subject, Value: nm.Data, raw: nm}
+		if len(nm.Header) > 0 {
+			m.Headers = make(map[string]string, len(nm.Header))
+			for k := range nm.Header {
+				m.Headers[k] = nm.Header.Get(k)
+			}
+		}
+		if key := nm.Header.Get(natsKeyHeader); key != "" {
+			m.Key = []byte(key)
+			delete(m.Headers, natsKeyHeader)
+		}
+		return m, nil
+	case <-ctx.Done():
+		return Message{}, ctx.Err()
+	case <-n.done:
+		return Message{}, io.EOF
+	}
+}
+
+// Commit implements Consumer; it does nothing.
+func (n *NATS) Commit(context.Context, Message) error {
+	return nil
+}
+
+// Publish implements Publisher.
+func (n *NATS) Publish(ctx context.Context, m Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	nm := nats.NewMsg(m.Topic)
+	nm.Data = m.Value
+	for k, v := range m.Headers {
+		nm.Header.Set(k, v)
+	}
+	if len(m.Key) > 0 {
+		nm.Header.Set(natsKeyHeader, string(m.Key))
+	}
+	return n.conn.PublishMsg(nm)
+}
+
+// Close unsubscribes and ends pending and later Fetch calls.
+func (n *NATS) Close() error {
+	n.closeOnce.Do(func() { close(n.done) })
+	var errs []error
+	for _, sub := range n.subs {
+		errs = append(errs, sub.Unsubscribe())
+	}
+	return errors.Join(errs...)
+}
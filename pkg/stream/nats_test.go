@@ -0,0 +1,85 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+// startNATS starts an in-process NATS server and connects to it.
+func startNATS(t *testing.T) *nats.Conn {
+	t.Helper()
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatalf("Failed to create NATS server: %v", err)
+	}
+	go srv.Start()
+	t.Cleanup(srv.Shutdown)
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS server didn't start")
+	}
+	conn, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("Failed to connect to NATS: %v", err)
+	}
+	t.Cleanup(conn.Close)
+	return conn
+}
+
+func TestNATS(t *testing.T) {
+	conn := startNATS(t)
+	n, err := NewNATS(conn, "workers", "orders.>")
+	if err != nil {
+		t.Fatalf("NewNATS failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sent := Message{Topic: "orders.eu", Key: []byte("k1"), Value: []byte(`{"id": 1}`), Headers: map[string]string{"trace": "abc"}}
+	if err := n.Publish(ctx, sent); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	got, err := n.Fetch(ctx)
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if got.Topic != sent.Topic || string(got.Key) != "k1" || string(got.Value) != `{"id": 1}` || len(got.Headers) != 1 || got.Headers["trace"] != "abc" {
+		t.Errorf("expected %+v, got %+v", sent, got)
+	}
+	if err := n.Commit(ctx, got); err != nil {
+		t.Errorf("Commit failed: %v", err)
+	}
+
+	go n.Close()
+	if _, err := n.Fetch(ctx); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF after Close, got %v", err)
+	}
+}
+
+func TestNATSTransformer(t *testing.T) {
+	conn := startNATS(t)
+	in, err := NewNATS(conn, "", "orders")
+	if err != nil {
+		t.Fatalf("NewNATS failed: %v", err)
+	}
+	results, err := NewNATS(conn, "", "orders.out", "errors")
+	if err != nil {
+		t.Fatalf("NewNATS failed: %v", err)
+	}
+	tr := newTransformer(t, nil)
+	tr.Consumer, tr.Publisher = in, in
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go tr.Run(ctx)
+
+	in.Publish(ctx, Message{Topic: "orders", Value: []byte(`{"id": 7}`)})
+	got, err := results.Fetch(ctx)
+	if err != nil || got.Topic != "orders.out" || string(got.Value) != "7 EUR" {
+		t.Fatalf("expected the rendered order, got %+v (%v)", got, err)
+	}
+}
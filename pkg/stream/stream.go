@@ -0,0 +1,182 @@
+// Package stream turns the pipeline into a stream transformer: a Transformer
+// reads JSON messages from a message queue, processes each with the pipeline
+// configured for its topic and publishes the output to another topic, or the
+// failure to an error topic. Kafka, NATS and an in-memory broker implement
+// the Consumer and Publisher interfaces it is built on.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"go-demo/pkg/errcode"
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/pipeline"
+)
+
+// HeaderErrorCode is the header of error messages holding the error code of
+// the failure (see errcode).
+const HeaderErrorCode = "error-code"
+
+// Message is a message of a topic (a Kafka topic or a NATS subject).
+type Message struct {
+	Topic   string
+	Key     []byte
+	Value   []byte
+	Headers map[string]string
+
+	// raw is the message of the underlying client, for Commit.
+	raw interface{}
+}
+
+// Consumer reads messages. Implementations need not be safe for concurrent
+// use.
+type Consumer interface {
+	// Fetch blocks until the next message is available and returns it. It
+	// returns io.EOF when there will be no more messages.
+	Fetch(ctx context.Context) (Message, error)
+
+	// Commit marks a fetched message as processed, so it isn't delivered
+	// again after a restart.
+	Commit(ctx context.Context, m Message) error
+}
+
+// Publisher writes messages to their topic.
+type Publisher interface {
+	Publish(ctx context.Context, m Message) error
+}
+
+// Route is how a Transformer processes the messages of a topic.
+type Route struct {
+	// Pipeline processes the message values.
+	Pipeline pipeline.Pipeline
+
+	// Output is the topic the pipeline output is published to. Empty drops
+	// the output, e.g. for topics that are only validated.
+	Output string
+
+	// Errors is the topic failures are published to; the Transformer's
+	// Errors topic if empty.
+	Errors string
+}
+
+// Transformer processes the messages of a Consumer by topic and publishes
+// the results.
+//
+// A message is committed once its output or its error message has been
+// published, so a crash can publish a result again but never loses one;
+// consumers of the output topics should be idempotent.
+type Transformer struct {
+	// Routes are the routes by input topic.
+	Routes map[string]Route
+
+	// Errors is the error topic of routes without one and of messages from
+	// topics without a route. Empty drops their failures after logging them.
+	Errors string
+
+	Consumer  Consumer
+	Publisher Publisher
+
+	// Logger, if set, receives a record per failed message.
+	Logger *slog.Logger
+}
+
+// ErrorMessage is the value of the messages published to error topics.
+type ErrorMessage struct {
+	Topic      string                 `json:"topic"`
+	Key        string                 `json:"key,omitempty"`
+	Code       errcode.Code           `json:"code,omitempty"`
+	Error      string                 `json:"error"`
+	Violations []schemautil.Violation `json:"violations,omitempty"`
+	// Value is the value of the failed message.
+	Value string `json:"value"`
+}
+
+// Run processes messages until the consumer runs out of them or ctx is done,
+// which end it with a nil error. Errors fetching, publishing or committing
+// end it too, leaving the current message uncommitted.
+func (t *Transformer) Run(ctx context.Context) error {
+	for {
+		m, err := t.Consumer.Fetch(ctx)
+		if errors.Is(err, io.EOF) || ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("fetch: %w", err)
+		}
+		if err := t.Handle(ctx, m); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err := t.Consumer.Commit(ctx, m); err != nil {
+			return fmt.Errorf("commit: %w", err)
+		}
+	}
+}
+
+// Handle processes one message and publishes its result. It only fails if
+// the result can't be published or ctx is done.
+func (t *Transformer) Handle(ctx context.Context, m Message) error {
+	route, ok := t.Routes[m.Topic]
+	if !ok {
+		return t.fail(ctx, m, t.Errors, fmt.Errorf("no route for topic %q", m.Topic), nil)
+	}
+	out, report, err := route.Pipeline.Process(ctx, m.Value)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		errTopic := route.Errors
+		if errTopic == "" {
+			errTopic = t.Errors
+		}
+		return t.fail(ctx, m, errTopic, err, report.Violations)
+	}
+	if route.Output == "" {
+		return nil
+	}
+	result := Message{Topic: route.Output, Key: m.Key, Value: out, Headers: m.Headers}
+	if err := t.Publisher.Publish(ctx, result); err != nil {
+		return fmt.Errorf("publish to %s: %w", route.Output, err)
+	}
+	return nil
+}
+
+// fail publishes the failure of m to topic, or only logs it if topic is empty.
+func (t *Transformer) fail(ctx context.Context, m Message, topic string, cause error, violations []schemautil.Violation) error {
+	code := errcode.Of(cause)
+	if t.Logger != nil {
+		t.Logger.WarnContext(ctx, "message failed", "topic", m.Topic, "key", string(m.Key), "code", code, "error", cause, "errors", topic)
+	}
+	if topic == "" {
+		return nil
+	}
+	value, err := json.Marshal(ErrorMessage{
+		Topic:      m.Topic,
+		Key:        string(m.Key),
+		Code:       code,
+		Error:      cause.Error(),
+		Violations: violations,
+		Value:      string(m.Value),
+	})
+	if err != nil {
+		return err
+	}
+	headers := make(map[string]string, len(m.Headers)+1)
+	for k, v := range m.Headers {
+		headers[k] = v
+	}
+	if code != "" {
+		headers[HeaderErrorCode] = string(code)
+	}
+	if err := t.Publisher.Publish(ctx, Message{Topic: topic, Key: m.Key, Value: value, Headers: headers}); err != nil {
+		return fmt.Errorf("publish to %s: %w", topic, err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go-demo/pkg/errcode"
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/pipeline"
+)
+
+func newTransformer(t *testing.T, broker *Memory) *Transformer {
+	t.Helper()
+	schema, err := schemautil.CompileString(`{
+		"type": "object",
+		"properties": {"id": {"type": "integer"}, "currency": {"type": "string", "default": "EUR"}},
+		"required": ["id"]
+	}`)
+	if err != nil {
+		t.Fatalf("Failed to compile schema: %v", err)
+	}
+	return &Transformer{
+		Routes: map[string]Route{
+			"orders":  {Pipeline: pipeline.Pipeline{Schema: schema, Template: "{{ id }} {{ currency }}"}, Output: "orders.out", Errors: "orders.errors"},
+			"audit":   {Pipeline: pipeline.Pipeline{Schema: schema}},
+			"refunds": {Pipeline: pipeline.Pipeline{Template: "{% if %}"}, Output: "refunds.out"},
+		},
+		Errors:    "errors",
+		Consumer:  broker,
+		Publisher: broker,
+	}
+}
+
+func TestTransformer(t *testing.T) {
+	broker := NewMemory(10, "orders", "audit", "refunds", "unknown")
+	tr := newTransformer(t, broker)
+	ctx := context.Background()
+	for _, m := range []Message{
+		{Topic: "orders", Key: []byte("a"), Value: []byte(`{"id": 9007199254740993}`), Headers: map[string]string{"trace": "1"}},
+		{Topic: "orders", Key: []byte("b"), Value: []byte(`{"currency": "USD"}`)},
+		{Topic: "orders", Key: []byte("c"), Value: []byte(`{"id": `)},
+		{Topic: "audit", Value: []byte(`{"id": 1}`)},
+		{Topic: "refunds", Value: []byte(`{}`)},
+		{Topic: "unknown", Value: []byte(`{}`)},
+	} {
+		if err := broker.Publish(ctx, m); err != nil {
+			t.Fatalf("Publish failed: %v", err)
+		}
+	}
+	broker.Close()
+	if err := tr.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	out := broker.Messages("orders.out")
+	want := Message{Topic: "orders.out", Key: []byte("a"), Value: []byte("9007199254740993 EUR"), Headers: map[string]string{"trace": "1"}}
+	if len(out) != 1 || !reflect.DeepEqual(out[0], want) {
+		t.Errorf("expected %+v, got %+v", want, out)
+	}
+
+	failures := broker.Messages("orders.errors")
+	if len(failures) != 2 {
+		t.Fatalf("expected 2 error messages, got %d", len(failures))
+	}
+	var invalid, malformed ErrorMessage
+	if err := json.Unmarshal(failures[0].Value, &invalid); err != nil {
+		t.Fatalf("Failed to decode error message: %v", err)
+	}
+	if invalid.Topic != "orders" || invalid.Key != "b" || invalid.Code != errcode.Validation || len(invalid.Violations) != 1 || invalid.Value != `{"currency": "USD"}` {
+		t.Errorf("unexpected error message %+v", invalid)
+	}
+	if failures[0].Headers[HeaderErrorCode] != "validation_error" || string(failures[0].Key) != "b" {
+		t.Errorf("expected the error code header and the key, got %+v", failures[0])
+	}
+	if err := json.Unmarshal(failures[1].Value, &malformed); err != nil || malformed.Code != errcode.Decode {
+		t.Errorf("expected a decode error, got %+v (%v)", malformed, err)
+	}
+
+	failures = broker.Messages("errors")
+	if len(failures) != 2 || failures[0].Headers[HeaderErrorCode] != "template_compile_error" {
+		t.Fatalf("expected the template error and the unrouted message in the default error topic, got %+v", failures)
+	}
+	var unrouted ErrorMessage
+	if err := json.Unmarshal(failures[1].Value, &unrouted); err != nil || unrouted.Topic != "unknown" || unrouted.Error != `no route for topic "unknown"` {
+		t.Errorf("unexpected error message %+v (%v)", unrouted, err)
+	}
+}
+
+// failingPublisher fails every publish.
+type failingPublisher struct{}
+
+func (failingPublisher) Publish(context.Context, Message) error {
+	return errors.New("broker down")
+}
+
+// countingConsumer counts commits.
+type countingConsumer struct {
+	*Memory
+	commits int
+}
+
+func (c *countingConsumer) Commit(context.Context, Message) error {
+	c.commits++
+	return nil
+}
+
+func TestTransformerErrors(t *testing.T) {
+	broker := NewMemory(10, "orders")
+	consumer := &countingConsumer{Memory: broker}
+	tr := newTransformer(t, broker)
+	tr.Consumer, tr.Publisher = consumer, failingPublisher{}
+	broker.Publish(context.Background(), Message{Topic: "orders", Value: []byte(`{"id": 1}`)})
+	broker.Publish(context.Background(), Message{Topic: "orders", Value: []byte(`{"id": 2}`)})
+
+	if err := tr.Run(context.Background()); err == nil || err.Error() != "publish to orders.out: broker down" {
+		t.Fatalf("expected a publish error, got %v", err)
+	}
+	if consumer.commits != 0 {
+		t.Errorf("expected the message not to be committed, got %d commits", consumer.commits)
+	}
+
+	tr.Publisher = broker
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := tr.Run(ctx); err != nil {
+		t.Fatalf("expected Run to end with its context, got %v", err)
+	}
+	if consumer.commits != 1 || len(broker.Messages("orders.out")) != 1 {
+		t.Errorf("expected the remaining message to be processed and committed, got %d commits", consumer.commits)
+	}
+}
@@ -0,0 +1,283 @@
+// Package tenant gives the teams sharing one deployment of the servers in
+// pkg/httpapi and pkg/grpcapi their own namespaces: each tenant has its own
+// named schemas and templates, its own cache of inline ones and its own
+// limits, and can't reach those of the others: the references of its
+// schemas and the includes of its templates, inline ones too, only load
+// files from its own directories, and absolute paths and paths leading out
+// of them with ".." are rejected.
+//
+// A request's tenant is the tenant of its API key (see auth.Principal). Keys
+// that aren't bound to a tenant, and requests to servers without keys,
+// choose one with the X-Tenant header (x-tenant metadata in gRPC); without
+// one they use the server's own schemas and templates.
+package tenant
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"go-demo/pkg/auth"
+	"go-demo/pkg/cache"
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/jsonutil"
+	"go-demo/pkg/limits"
+	tpl "go-demo/pkg/pongo2"
+)
+
+// Header is the HTTP header that chooses the tenant of a request. gRPC
+// clients send it as metadata, lower-cased.
+const Header = "X-Tenant"
+
+// ErrUnknownTenant is matched by the error of Resolve for a tenant that
+// isn't configured.
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+// Config configures a tenant's namespace.
+type Config struct {
+	// SchemaDir holds the tenant's named schemas (*.json). Optional.
+	SchemaDir string
+
+	// TemplateDir holds the tenant's named templates. Optional.
+	TemplateDir string
+
+	// Limits are the tenant's limits. MaxBodyBytes and RenderTimeout
+	// replace the server's when set; RatePerSecond and Burst limit the
+	// requests of the tenant as a whole, on top of the server's limit per
+	// client.
+	Limits limits.Config
+
+	// Cache configures the tenant's cache of inline schemas and templates.
+	Cache cache.Config
+}
+
+// Namespace is what the requests of a tenant can reach.
+type Namespace struct {
+	Name      string
+	Schemas   map[string]*jsonschema.Schema
+	Templates *tpl.Registry // nil without a template directory
+	Cache     *cache.Cache
+	Limits    limits.Config
+
+	// TemplateDirs are the directories inline templates load files from:
+	// the template directory, if any.
+	TemplateDirs []string
+
+	limiter *limits.RateLimiter
+}
+
+// New loads the schemas and templates of a tenant. Templates are compiled
+// with opts. It fails if any of them doesn't compile. The tenant's cache is
+// sandboxed, whatever cfg.Cache says, so that inline schemas only reference
+// the tenant's schemas.
+func New(name string, cfg Config, opts tpl.Options) (*Namespace, error) {
+	if name == "" {
+		return nil, fmt.Errorf("tenant without a name")
+	}
+	cfg.Cache.Sandbox, cfg.Cache.SchemaFS = true, nil
+	if cfg.SchemaDir != "" {
+		cfg.Cache.SchemaFS = os.DirFS(cfg.SchemaDir)
+	}
+	n := &Namespace{
+		Name:    name,
+		Schemas: map[string]*jsonschema.Schema{},
+		Cache:   cache.New(cfg.Cache),
+		Limits:  cfg.Limits,
+		limiter: cfg.Limits.NewRateLimiter(),
+	}
+	var err error
+	if cfg.SchemaDir != "" {
+		if n.Schemas, err = schemautil.CompileFS(cfg.Cache.SchemaFS); err != nil {
+			return nil, fmt.Errorf("tenant %s: load schemas: %w", name, err)
+		}
+	}
+	if cfg.TemplateDir != "" {
+		if n.Templates, err = tpl.LoadDir(cfg.TemplateDir, opts); err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", name, err)
+		}
+		n.TemplateDirs = []string{cfg.TemplateDir}
+	}
+	return n, nil
+}
+
+// Allow takes a token from the tenant's rate limit. If there is none left,
+// it returns false and how long to wait for the next one.
+func (n *Namespace) Allow() (bool, time.Duration) {
+	return n.limiter.Allow(n.Name)
+}
+
+// LimitsFor returns the server's limits with the tenant's body size limit
+// and render timeout, where set.
+func (n *Namespace) LimitsFor(server limits.Config) limits.Config {
+	if n.Limits.MaxBodyBytes > 0 {
+		server.MaxBodyBytes = n.Limits.MaxBodyBytes
+	}
+	if n.Limits.RenderTimeout > 0 {
+		server.RenderTimeout = n.Limits.RenderTimeout
+	}
+	return server
+}
+
+// Set is the tenants of a server. A nil *Set has no tenants.
+type Set struct {
+	tenants map[string]*Namespace
+}
+
+// NewSet returns a set of namespaces. Names must be unique.
+func NewSet(namespaces ...*Namespace) (*Set, error) {
+	s := &Set{tenants: make(map[string]*Namespace, len(namespaces))}
+	for _, n := range namespaces {
+		if _, ok := s.tenants[n.Name]; ok {
+			return nil, fmt.Errorf("tenant %s is defined twice", n.Name)
+		}
+		s.tenants[n.Name] = n
+	}
+	return s, nil
+}
+
+// Lookup returns the namespace of a tenant.
+func (s *Set) Lookup(name string) (*Namespace, bool) {
+	if s == nil {
+		return nil, false
+	}
+	n, ok := s.tenants[name]
+	return n, ok
+}
+
+// Names returns the tenants, sorted.
+func (s *Set) Names() []string {
+	if s == nil {
+		return nil
+	}
+	names := make([]string, 0, len(s.tenants))
+	for name := range s.tenants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve returns the namespace of a request by principal p (nil without
+// API keys) that asked for the tenant requested (the X-Tenant header, or
+// ""). The tenant of p's key wins; asking for another one fails with an
+// error matching auth.ErrForbidden. It returns nil if the request has no
+// tenant, and an error matching ErrUnknownTenant if its tenant isn't in s.
+func (s *Set) Resolve(p *auth.Principal, requested string) (*Namespace, error) {
+	name := requested
+	if p != nil && p.Tenant != "" {
+		if requested != "" && requested != p.Tenant {
+			return nil, fmt.Errorf("%w: %s may not use tenant %s", auth.ErrForbidden, p.Name, requested)
+		}
+		name = p.Tenant
+	}
+	if name == "" {
+		return nil, nil
+	}
+	n, ok := s.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownTenant, name)
+	}
+	return n, nil
+}
+
+// tenantFile is the file format of Load.
+type tenantFile struct {
+	Tenants map[string]struct {
+		Schemas       string  `json:"schemas"`
+		Templates     string  `json:"templates"`
+		MaxBodyBytes  int64   `json:"max-body-bytes"`
+		Rate          float64 `json:"rate"`
+		Burst         int     `json:"burst"`
+		RenderTimeout string  `json:"render-timeout"`
+		CacheSize     int     `json:"cache-size"`
+		CacheTTL      string  `json:"cache-ttl"`
+	} `json:"tenants"`
+}
+
+// Load reads tenants from a JSON, YAML or TOML file (by extension). Paths
+// are relative to the file; durations are Go durations such as "2s":
+//
+//	tenants:
+//	  billing:
+//	    schemas: billing/schemas
+//	    templates: billing/templates
+//	    max-body-bytes: 1048576
+//	    rate: 50            # requests per second of the whole tenant
+//	    burst: 100
+//	    render-timeout: 2s
+//	    cache-size: 200     # inline schemas and templates kept compiled
+//	    cache-ttl: 1h
+//
+// Templates are compiled with opts. The tenants' caches share backend, which
+// may be nil: their keys are hashes of the sources, not names.
+func Load(path string, opts tpl.Options, backend cache.Backend) (*Set, error) {
+	format, err := jsonutil.FormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := jsonutil.Unmarshal(format, b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	// Round-trip through JSON to decode into the typed structure.
+	js, err := jsonutil.Marshal(jsonutil.FormatJSON, doc, 0)
+	if err != nil {
+		return nil, err
+	}
+	var file tenantFile
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+	duration := func(name, field, s string) (time.Duration, error) {
+		if s == "" {
+			return 0, nil
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("%s: tenant %s: %s: %w", path, name, field, err)
+		}
+		return d, nil
+	}
+	var namespaces []*Namespace
+	for name, t := range file.Tenants {
+		cfg := Config{
+			SchemaDir:   resolve(t.Schemas),
+			TemplateDir: resolve(t.Templates),
+			Limits:      limits.Config{MaxBodyBytes: t.MaxBodyBytes, RatePerSecond: t.Rate, Burst: t.Burst},
+			Cache:       cache.Config{MaxEntries: t.CacheSize, Backend: backend},
+		}
+		if cfg.Limits.RenderTimeout, err = duration(name, "render-timeout", t.RenderTimeout); err != nil {
+			return nil, err
+		}
+		if cfg.Cache.TTL, err = duration(name, "cache-ttl", t.CacheTTL); err != nil {
+			return nil, err
+		}
+		n, err := New(name, cfg, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		namespaces = append(namespaces, n)
+	}
+	return NewSet(namespaces...)
+}
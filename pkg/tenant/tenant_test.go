@@ -0,0 +1,207 @@
+package tenant
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-demo/pkg/auth"
+	"go-demo/pkg/limits"
+	tpl "go-demo/pkg/pongo2"
+)
+
+// writeFiles writes files, by slash-separated path, below a new directory.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"tenants.yaml": `tenants:
+  acme:
+    schemas: acme/schemas
+    templates: acme/templates
+    max-body-bytes: 1024
+    rate: 5
+    burst: 2
+    render-timeout: 2s
+    cache-size: 10
+    cache-ttl: 1h
+  globex: {}
+`,
+		"acme/schemas/order.json":    `{"type": "object", "properties": {"status": {"default": "new"}}}`,
+		"acme/templates/receipt.txt": "Receipt for {{ name }}",
+	})
+
+	set, err := Load(filepath.Join(dir, "tenants.yaml"), tpl.Options{}, nil)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if names := set.Names(); len(names) != 2 || names[0] != "acme" || names[1] != "globex" {
+		t.Fatalf("Expected tenants [acme globex], got %v", names)
+	}
+
+	acme, _ := set.Lookup("acme")
+	if _, ok := acme.Schemas["order"]; !ok {
+		t.Errorf("Expected schema order, got %v", acme.Schemas)
+	}
+	out, err := acme.Templates.Render("receipt.txt", map[string]interface{}{"name": "Ada"})
+	if err != nil || out != "Receipt for Ada" {
+		t.Errorf("Expected the tenant's template, got %q (%v)", out, err)
+	}
+	want := limits.Config{MaxBodyBytes: 1024, RatePerSecond: 5, Burst: 2, RenderTimeout: 2 * time.Second}
+	if acme.Limits != want {
+		t.Errorf("Expected limits %+v, got %+v", want, acme.Limits)
+	}
+
+	globex, _ := set.Lookup("globex")
+	if len(globex.Schemas) != 0 || globex.Templates != nil {
+		t.Errorf("Expected an empty namespace, got %+v", globex)
+	}
+
+	for name, content := range map[string]string{
+		"unknown field":    "tenants:\n  acme:\n    schema: x\n",
+		"bad duration":     "tenants:\n  acme:\n    render-timeout: soon\n",
+		"missing schemas":  "tenants:\n  acme:\n    schemas: nowhere\n",
+		"broken templates": "tenants:\n  acme:\n    templates: broken\n",
+	} {
+		dir := writeFiles(t, map[string]string{"tenants.yaml": content, "broken/x.txt": "{% if %}"})
+		if _, err := Load(filepath.Join(dir, "tenants.yaml"), tpl.Options{}, nil); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	acme, err := New("acme", Config{}, tpl.Options{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	globex, _ := New("globex", Config{}, tpl.Options{})
+	set, err := NewSet(acme, globex)
+	if err != nil {
+		t.Fatalf("NewSet failed: %v", err)
+	}
+	if _, err := NewSet(acme, acme); err == nil {
+		t.Error("Expected an error for a duplicate tenant")
+	}
+
+	bound := &auth.Principal{Name: "k1", Tenant: "acme"}
+	free := &auth.Principal{Name: "k2"}
+	tests := []struct {
+		name      string
+		set       *Set
+		p         *auth.Principal
+		requested string
+		want      *Namespace
+		err       error
+	}{
+		{"no tenant", set, nil, "", nil, nil},
+		{"header", set, nil, "globex", globex, nil},
+		{"unbound key with header", set, free, "globex", globex, nil},
+		{"bound key", set, bound, "", acme, nil},
+		{"bound key with its tenant", set, bound, "acme", acme, nil},
+		{"bound key with another tenant", set, bound, "globex", nil, auth.ErrForbidden},
+		{"unknown tenant", set, nil, "initech", nil, ErrUnknownTenant},
+		{"no tenants", nil, bound, "", nil, ErrUnknownTenant},
+	}
+	for _, tt := range tests {
+		got, err := tt.set.Resolve(tt.p, tt.requested)
+		if !errors.Is(err, tt.err) || tt.err == nil && err != nil {
+			t.Errorf("%s: expected error %v, got %v", tt.name, tt.err, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestNamespaceLimits(t *testing.T) {
+	n, err := New("acme", Config{Limits: limits.Config{MaxBodyBytes: 10, RatePerSecond: 0.001, Burst: 1}}, tpl.Options{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if ok, _ := n.Allow(); !ok {
+		t.Error("Expected the first request to be allowed")
+	}
+	if ok, wait := n.Allow(); ok || wait <= 0 {
+		t.Errorf("Expected the second request to wait, got %v %v", ok, wait)
+	}
+
+	server := limits.Config{MaxBodyBytes: 100, RenderTimeout: time.Second, RatePerSecond: 3}
+	got := n.LimitsFor(server)
+	want := limits.Config{MaxBodyBytes: 10, RenderTimeout: time.Second, RatePerSecond: 3}
+	if got != want {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+
+	if _, err := New("", Config{}, tpl.Options{}); err == nil {
+		t.Error("Expected an error for a tenant without a name")
+	}
+}
+
+func TestNamespaceIsolation(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"acme/schemas/order.json":     `{"properties": {"status": {"default": "new"}}}`,
+		"acme/templates/receipt.txt":  "Receipt",
+		"globex/schemas/secret.json":  `{"properties": {"plan": {"default": "secret"}}}`,
+		"globex/templates/secret.txt": "globex secret",
+	})
+	acme, err := New("acme", Config{SchemaDir: filepath.Join(dir, "acme/schemas"), TemplateDir: filepath.Join(dir, "acme/templates")}, tpl.Options{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	opts := tpl.Options{TemplateDirs: acme.TemplateDirs}
+	templates := map[string]bool{
+		`{% include "receipt.txt" %}`: true,
+		`{% include "` + filepath.Join(dir, "globex/templates/secret.txt") + `" %}`: false,
+		`{% include "../../globex/templates/secret.txt" %}`:                         false,
+		`{% ssi "` + filepath.Join(dir, "globex/templates/secret.txt") + `" %}`:     false,
+	}
+	for source, allowed := range templates {
+		output, err := acme.Cache.InlineTemplate(opts, source)
+		if err == nil {
+			var out string
+			out, err = output.Render(nil)
+			if strings.Contains(out, "secret") {
+				t.Errorf("%s: read another tenant's template: %q", source, out)
+			}
+		}
+		if (err == nil) != allowed {
+			t.Errorf("%s: expected allowed %v, got %v", source, allowed, err)
+		}
+	}
+
+	schemas := map[string]bool{
+		`{"$ref": "order.json"}`:                       true,
+		`{"$ref": "../../globex/schemas/secret.json"}`: false,
+		`{"$ref": "file://` + filepath.ToSlash(filepath.Join(dir, "globex/schemas/secret.json")) + `"}`: false,
+	}
+	for source, allowed := range schemas {
+		if _, err := acme.Cache.Schema([]byte(source)); (err == nil) != allowed {
+			t.Errorf("%s: expected allowed %v, got %v", source, allowed, err)
+		}
+	}
+
+	for _, ref := range []string{"../../globex/schemas/secret.json", "file://" + filepath.ToSlash(filepath.Join(dir, "globex/schemas/secret.json"))} {
+		escape := writeFiles(t, map[string]string{"schemas/escape.json": `{"$ref": "` + ref + `"}`})
+		if _, err := New("escape", Config{SchemaDir: filepath.Join(escape, "schemas")}, tpl.Options{}); err == nil {
+			t.Errorf("%s: expected an error for a schema referencing another tenant's", ref)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+// Package watch is a directory-watching ETL tool: a Watcher polls an input
+// directory for JSON, YAML and TOML files, processes each new one with a
+// pipeline, writes the output to a target directory and moves files that
+// fail to a quarantine directory next to a report of the failure.
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go-demo/pkg/audit"
+	"go-demo/pkg/bufpool"
+	"go-demo/pkg/errcode"
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/jsonutil"
+	"go-demo/pkg/pipeline"
+)
+
+// Config configures a Watcher.
+type Config struct {
+	// Input is the directory watched for documents. Hidden files, whose
+	// names start with ".", and subdirectories are ignored, so writers can
+	// create ".name.tmp" and rename it when done.
+	Input string
+
+	// Output is the directory outputs are written to, named after their
+	// input with Ext in place of its extension.
+	Output string
+
+	// Quarantine is the directory failed inputs are moved to, each with a
+	// <name>.error.json Report.
+	Quarantine string
+
+	// Archive is the directory processed inputs are moved to. Empty deletes
+	// them.
+	Archive string
+
+	// Pipeline processes the documents.
+	Pipeline pipeline.Pipeline
+
+	// Ext is the extension of outputs, with the dot: ".json" by default if
+	// the pipeline has no template, ".txt" otherwise.
+	Ext string
+
+	// Interval is how often Input is scanned (default 1s).
+	Interval time.Duration
+
+	// Logger, if set, receives a record per processed or failed file.
+	Logger *slog.Logger
+}
+
+// Report is the content of the report written next to a quarantined file.
+type Report struct {
+	File       string                 `json:"file"`
+	Code       errcode.Code           `json:"code,omitempty"`
+	Error      string                 `json:"error"`
+	Violations []schemautil.Violation `json:"violations,omitempty"`
+	FailedAt   time.Time              `json:"failed_at"`
+}
+
+// Stats counts the files a scan handled.
+type Stats struct {
+	Processed int `json:"processed"`
+	Failed    int `json:"failed"`
+}
+
+// Watcher processes the documents dropped into a directory. Its methods
+// must not be called concurrently.
+type Watcher struct {
+	cfg Config
+	now func() time.Time
+
+	// seen holds the size and modification time files had at the last
+	// scan; files are processed once they no longer change.
+	seen map[string]string
+}
+
+// New returns a Watcher for cfg, creating the output, quarantine and archive
+// directories.
+func New(cfg Config) (*Watcher, error) {
+	if cfg.Input == "" || cfg.Output == "" || cfg.Quarantine == "" {
+		return nil, errors.New("watch: input, output and quarantine directories are required")
+	}
+	if info, err := os.Stat(cfg.Input); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("watch: %s is not a directory", cfg.Input)
+	}
+	for _, dir := range []string{cfg.Output, cfg.Quarantine, cfg.Archive} {
+		if dir == "" {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Ext == "" {
+		cfg.Ext = ".json"
+		if cfg.Pipeline.Template != "" {
+			cfg.Ext = ".txt"
+		}
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	return &Watcher{cfg: cfg, now: time.Now, seen: map[string]string{}}, nil
+}
+
+// Run scans the input directory every Interval until ctx is done. Errors
+// that leave a file in place, such as an unwritable output directory, are
+// logged and the file is tried again on the next scan.
+func (w *Watcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := w.Scan(ctx); err != nil && ctx.Err() == nil && w.cfg.Logger != nil {
+			w.cfg.Logger.ErrorContext(ctx, "scan failed", "dir", w.cfg.Input, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan processes the files of the input directory that haven't changed since
+// the previous scan, so files still being written are left for later; a
+// file is therefore processed by the second scan that sees it. Files are
+// processed in name order. Scan stops at the first error that leaves a file
+// in place.
+func (w *Watcher) Scan(ctx context.Context) (Stats, error) {
+	var stats Stats
+	entries, err := os.ReadDir(w.cfg.Input)
+	if err != nil {
+		return stats, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	seen := map[string]string{}
+	var ready []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue // removed since ReadDir
+		}
+		stamp := fmt.Sprintf("%d %d", info.Size(), info.ModTime().UnixNano())
+		if w.seen[name] == stamp {
+			ready = append(ready, name)
+		}
+		seen[name] = stamp
+	}
+	w.seen = seen
+
+	for _, name := range ready {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		ok, err := w.process(ctx, name)
+		if err != nil {
+			return stats, err
+		}
+		delete(w.seen, name)
+		if ok {
+			stats.Processed++
+		} else {
+			stats.Failed++
+		}
+	}
+	return stats, nil
+}
+
+// process processes one input file. It reports whether the file was
+// processed rather than quarantined, and returns an error if it was neither.
+func (w *Watcher) process(ctx context.Context, name string) (bool, error) {
+	path := filepath.Join(w.cfg.Input, name)
+	out, report, err := w.transform(audit.WithDocument(ctx, name), path)
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		return false, w.quarantine(ctx, name, err, report.Violations)
+	}
+
+	target := filepath.Join(w.cfg.Output, strings.TrimSuffix(name, filepath.Ext(name))+w.cfg.Ext)
+	if err := writeFile(target, out); err != nil {
+		return false, err
+	}
+	if w.cfg.Archive != "" {
+		err = move(path, filepath.Join(w.cfg.Archive, name))
+	} else {
+		err = os.Remove(path)
+	}
+	if err != nil {
+		return false, err
+	}
+	if w.cfg.Logger != nil {
+		w.cfg.Logger.InfoContext(ctx, "file processed", "file", name, "output", target)
+	}
+	return true, nil
+}
+
+// transform reads a document and runs the pipeline on it.
+func (w *Watcher) transform(ctx context.Context, path string) ([]byte, pipeline.Report, error) {
+	format, err := jsonutil.FormatFromPath(path)
+	if err != nil {
+		return nil, pipeline.Report{}, err
+	}
+	// The pipeline doesn't keep the input, so it is read into a pooled buffer.
+	buf := bufpool.Get()
+	defer bufpool.Put(buf)
+	if err := readFile(buf, path); err != nil {
+		return nil, pipeline.Report{}, err
+	}
+	b := buf.Bytes()
+	if format != jsonutil.FormatJSON {
+		doc, err := jsonutil.Unmarshal(format, b)
+		if err != nil {
+			return nil, pipeline.Report{}, err
+		}
+		if b, err = jsonutil.Marshal(jsonutil.FormatJSON, doc, 0); err != nil {
+			return nil, pipeline.Report{}, err
+		}
+	}
+	return w.cfg.Pipeline.Process(ctx, b)
+}
+
+// readFile appends the contents of the file path to buf.
+func readFile(buf *bytes.Buffer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = buf.ReadFrom(f)
+	return err
+}
+
+// quarantine moves a failed input to the quarantine directory and writes its
+// report.
+func (w *Watcher) quarantine(ctx context.Context, name string, cause error, violations []schemautil.Violation) error {
+	report, err := json.MarshalIndent(Report{
+		File:       name,
+		Code:       errcode.Of(cause),
+		Error:      cause.Error(),
+		Violations: violations,
+		FailedAt:   w.now().UTC(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(w.cfg.Quarantine, name+".error.json"), append(report, '\n')); err != nil {
+		return err
+	}
+	if err := move(filepath.Join(w.cfg.Input, name), filepath.Join(w.cfg.Quarantine, name)); err != nil {
+		return err
+	}
+	if w.cfg.Logger != nil {
+		w.cfg.Logger.WarnContext(ctx, "file quarantined", "file", name, "code", errcode.Of(cause), "error", cause)
+	}
+	return nil
+}
+
+// writeFile writes a file through a temporary file in the same directory, so
+// readers of the directory never see it half written.
+func writeFile(path string, b []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(b)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// move moves a file, copying it if it can't be renamed, e.g. across file
+// systems.
+func move(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
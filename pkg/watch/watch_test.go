@@ -0,0 +1,212 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"go-demo/pkg/errcode"
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/pipeline"
+)
+
+func newWatcher(t *testing.T, cfg Config, files map[string]string) (*Watcher, Config) {
+	t.Helper()
+	root := t.TempDir()
+	cfg.Input = filepath.Join(root, "in")
+	cfg.Output = filepath.Join(root, "out")
+	cfg.Quarantine = filepath.Join(root, "quarantine")
+	if cfg.Archive != "" {
+		cfg.Archive = filepath.Join(root, cfg.Archive)
+	}
+	if err := os.MkdirAll(filepath.Join(cfg.Input, "subdir"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(cfg.Input, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cfg.Pipeline.Schema == nil {
+		schema, err := schemautil.CompileString(`{"type": "object", "properties": {"role": {"default": "member"}}, "required": ["name"]}`)
+		if err != nil {
+			t.Fatalf("Failed to compile schema: %v", err)
+		}
+		cfg.Pipeline.Schema = schema
+	}
+	w, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	w.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+	return w, w.cfg
+}
+
+// list returns the names of the files in dir.
+func list(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestScan(t *testing.T) {
+	w, cfg := newWatcher(t, Config{Pipeline: pipeline.Pipeline{Template: "{{ name }} ({{ role }})"}}, map[string]string{
+		"alice.json":     `{"name": "Alice"}`,
+		"bob.yaml":       "name: Bob\nrole: admin\n",
+		"nameless.json":  `{"age": 3}`,
+		"malformed.json": `{"name": `,
+		"notes.txt":      "hello",
+		".partial.json":  `{"name": "Eve"}`,
+	})
+	ctx := context.Background()
+	if stats, err := w.Scan(ctx); err != nil || stats != (Stats{}) {
+		t.Fatalf("expected the first scan to only record the files, got %+v, %v", stats, err)
+	}
+	stats, err := w.Scan(ctx)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if stats != (Stats{Processed: 2, Failed: 3}) {
+		t.Errorf("expected 2 processed and 3 failed files, got %+v", stats)
+	}
+
+	if got := list(t, cfg.Input); !reflect.DeepEqual(got, []string{".partial.json", "subdir"}) {
+		t.Errorf("expected only the hidden file and the directory to be left, got %v", got)
+	}
+	if got := list(t, cfg.Output); !reflect.DeepEqual(got, []string{"alice.txt", "bob.txt"}) {
+		t.Fatalf("unexpected outputs %v", got)
+	}
+	if b, _ := os.ReadFile(filepath.Join(cfg.Output, "alice.txt")); string(b) != "Alice (member)" {
+		t.Errorf("expected the rendered document, got %q", b)
+	}
+	wantQuarantine := []string{"malformed.json", "malformed.json.error.json", "nameless.json", "nameless.json.error.json", "notes.txt", "notes.txt.error.json"}
+	if got := list(t, cfg.Quarantine); !reflect.DeepEqual(got, wantQuarantine) {
+		t.Fatalf("expected %v in quarantine, got %v", wantQuarantine, got)
+	}
+
+	reports := map[string]Report{}
+	for _, name := range []string{"malformed.json", "nameless.json", "notes.txt"} {
+		var r Report
+		b, _ := os.ReadFile(filepath.Join(cfg.Quarantine, name+".error.json"))
+		if err := json.Unmarshal(b, &r); err != nil {
+			t.Fatalf("Failed to decode report of %s: %v", name, err)
+		}
+		reports[name] = r
+	}
+	if r := reports["nameless.json"]; r.File != "nameless.json" || r.Code != errcode.Validation || len(r.Violations) != 1 || !r.FailedAt.Equal(w.now()) {
+		t.Errorf("unexpected report %+v", r)
+	}
+	if r := reports["malformed.json"]; r.Code != errcode.Decode {
+		t.Errorf("expected a decode error, got %+v", r)
+	}
+	if r := reports["notes.txt"]; r.Code != "" || r.Error == "" {
+		t.Errorf("expected an unsupported format error, got %+v", r)
+	}
+}
+
+func TestScanArchive(t *testing.T) {
+	w, cfg := newWatcher(t, Config{Archive: "done"}, map[string]string{"alice.toml": `name = "Alice"`})
+	w.Scan(context.Background())
+	if stats, err := w.Scan(context.Background()); err != nil || stats.Processed != 1 {
+		t.Fatalf("expected a processed file, got %+v, %v", stats, err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(cfg.Output, "alice.json")); string(b) != "{\n  \"name\": \"Alice\",\n  \"role\": \"member\"\n}\n" {
+		t.Errorf("expected the document with defaults as JSON, got %q", b)
+	}
+	if got := list(t, cfg.Archive); !reflect.DeepEqual(got, []string{"alice.toml"}) {
+		t.Errorf("expected the input in the archive, got %v", got)
+	}
+}
+
+func TestScanChangingFile(t *testing.T) {
+	w, cfg := newWatcher(t, Config{}, map[string]string{"alice.json": `{"name": "Al`})
+	ctx := context.Background()
+	w.Scan(ctx)
+	if err := os.WriteFile(filepath.Join(cfg.Input, "alice.json"), []byte(`{"name": "Alice"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if stats, _ := w.Scan(ctx); stats != (Stats{}) {
+		t.Fatalf("expected a file still being written to be skipped, got %+v", stats)
+	}
+	if stats, _ := w.Scan(ctx); stats.Processed != 1 {
+		t.Errorf("expected the file to be processed once unchanged, got %+v", stats)
+	}
+}
+
+func TestScanOutputError(t *testing.T) {
+	w, cfg := newWatcher(t, Config{}, map[string]string{"alice.json": `{"name": "Alice"}`})
+	if err := os.Remove(cfg.Output); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	w.Scan(ctx)
+	if _, err := w.Scan(ctx); err == nil {
+		t.Fatal("expected an error writing the output")
+	}
+	if got := list(t, cfg.Input); !reflect.DeepEqual(got, []string{"alice.json", "subdir"}) {
+		t.Errorf("expected the input to stay, got %v", got)
+	}
+	if err := os.Mkdir(cfg.Output, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if stats, err := w.Scan(ctx); err != nil || stats.Processed != 1 {
+		t.Errorf("expected the file to be processed on the next scan, got %+v, %v", stats, err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	_, cfg := newWatcher(t, Config{Interval: 10 * time.Millisecond}, nil)
+	w, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+
+	if err := os.WriteFile(filepath.Join(cfg.Input, "alice.json"), []byte(`{"name": "Alice"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(cfg.Output, "alice.json")
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if _, err := os.Stat(output); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the file wasn't processed")
+		}
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("expected Run to end with its context, got %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	os.WriteFile(file, nil, 0o644)
+	for _, cfg := range []Config{
+		{Input: dir, Output: dir},
+		{Input: filepath.Join(dir, "missing"), Output: dir, Quarantine: dir},
+		{Input: file, Output: dir, Quarantine: dir},
+		{Input: dir, Output: filepath.Join(file, "out"), Quarantine: dir},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("%+v: expected an error", cfg)
+		}
+	}
+}
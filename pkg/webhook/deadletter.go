@@ -0,0 +1,61 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Failure is a delivery that failed for good.
+type Failure struct {
+	ID       string    `json:"id"`
+	URL      string    `json:"url"`
+	Attempts int       `json:"attempts"`
+	Status   int       `json:"status,omitempty"`
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+	// Body is the document that wasn't delivered; it is encoded as base64.
+	Body []byte `json:"body"`
+}
+
+// DeadLetter keeps failed deliveries so they can be inspected or replayed.
+// Implementations must be safe for concurrent use.
+type DeadLetter interface {
+	Store(ctx context.Context, f *Failure) error
+}
+
+// DeadLetterDir is a DeadLetter that writes each failure to
+// <dir>/<id>.json, creating the directory if needed.
+type DeadLetterDir string
+
+// Store implements DeadLetter.
+func (d DeadLetterDir) Store(ctx context.Context, f *Failure) error {
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(string(d), 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(string(d), f.ID+".json")
+	if err := os.WriteFile(path, append(b, '\n'), 0o644); err != nil {
+		return fmt.Errorf("can't write %s: %w", path, err)
+	}
+	return nil
+}
+
+// ReadFailure reads a failure written by DeadLetterDir.
+func ReadFailure(path string) (*Failure, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f Failure
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &f, nil
+}
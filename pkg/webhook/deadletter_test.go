@@ -0,0 +1,24 @@
+package webhook
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDeadLetterDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "dead")
+	want := &Failure{ID: "abc", URL: "http://example.com", Attempts: 2, Status: 500, Error: "500 Internal Server Error", FailedAt: time.Unix(1, 0).UTC(), Body: []byte("\x00binary")}
+	if err := DeadLetterDir(dir).Store(context.Background(), want); err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	got, err := ReadFailure(filepath.Join(dir, "abc.json"))
+	if err != nil {
+		t.Fatalf("ReadFailure failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
@@ -0,0 +1,29 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Sign returns the signature of a request with the given timestamp and body:
+// "sha256=" followed by the hex HMAC-SHA256, keyed by secret, of
+// timestamp + "." + body.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature, the X-Godemo-Signature header of a
+// request, matches its timestamp and body. Receivers should also reject
+// timestamps too far from their own clock to stop replays.
+func Verify(secret []byte, timestamp string, body []byte, signature string) bool {
+	if !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body)))
+}
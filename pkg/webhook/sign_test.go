@@ -0,0 +1,34 @@
+package webhook
+
+import "testing"
+
+func TestSign(t *testing.T) {
+	secret := []byte("key")
+	sig := Sign(secret, "1", []byte("body"))
+	if len(sig) != len("sha256=")+64 {
+		t.Fatalf("unexpected signature %q", sig)
+	}
+	tests := []struct {
+		name      string
+		timestamp string
+		body      string
+		signature string
+		ok        bool
+	}{
+		{"valid", "1", "body", sig, true},
+		{"body changed", "1", "bodY", sig, false},
+		{"timestamp changed", "2", "body", sig, false},
+		{"no prefix", "1", "body", sig[len("sha256="):], false},
+		{"empty", "1", "body", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(secret, tt.timestamp, []byte(tt.body), tt.signature); got != tt.ok {
+				t.Errorf("expected %v, got %v", tt.ok, got)
+			}
+		})
+	}
+	if Verify([]byte("other"), "1", []byte("body"), sig) {
+		t.Error("expected a signature from another secret to fail")
+	}
+}
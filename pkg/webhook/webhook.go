@@ -0,0 +1,259 @@
+// Package webhook delivers generated documents to an HTTP endpoint: it POSTs
+// them with an HMAC signature, retries failed attempts with exponential
+// backoff, and hands deliveries that never succeed to a dead letter.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	mathrand "math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go-demo/pkg/pipeline"
+)
+
+// Request headers.
+const (
+	// HeaderDelivery identifies a delivery; retries of it keep the same
+	// value, so receivers can drop duplicates.
+	HeaderDelivery = "X-Godemo-Delivery"
+	// HeaderTimestamp is the Unix time of the attempt, in seconds.
+	HeaderTimestamp = "X-Godemo-Timestamp"
+	// HeaderSignature is "sha256=" followed by the hex HMAC of the timestamp
+	// and body (see Sign).
+	HeaderSignature = "X-Godemo-Signature"
+)
+
+// Config configures a Deliverer.
+type Config struct {
+	// URL is the endpoint documents are POSTed to.
+	URL string
+
+	// Secret signs the requests. Empty leaves them unsigned.
+	Secret []byte
+
+	// ContentType is the Content-Type of the requests; by default it is
+	// detected from the body.
+	ContentType string
+
+	// MaxAttempts bounds the attempts per delivery, 5 if 0.
+	MaxAttempts int
+
+	// Backoff is the delay before the first retry, 500ms if 0. It doubles
+	// with every retry, up to MaxBackoff (30s if 0), and is randomized to
+	// between half and all of that.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// Timeout bounds each attempt, 10s if 0.
+	Timeout time.Duration
+
+	// Client sends the requests; http.DefaultClient if nil.
+	Client *http.Client
+
+	// DeadLetter keeps the deliveries that failed for good. Nil drops them.
+	DeadLetter DeadLetter
+}
+
+// Deliverer POSTs documents to a webhook. It is safe for concurrent use.
+type Deliverer struct {
+	cfg Config
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// New returns a Deliverer for cfg.
+func New(cfg Config) (*Deliverer, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook URL %q", cfg.URL)
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = 500 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 30 * time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	return &Deliverer{cfg: cfg, now: time.Now, sleep: sleep}, nil
+}
+
+// Result describes a successful delivery.
+type Result struct {
+	ID       string `json:"id"`
+	Attempts int    `json:"attempts"`
+	Status   int    `json:"status"`
+}
+
+// Error is returned for a delivery that failed for good, after it has been
+// handed to the dead letter.
+type Error struct {
+	ID       string
+	Attempts int
+	// Status is the HTTP status of the last attempt, 0 if there was no
+	// response.
+	Status int
+	// Err is the cause of the last failed attempt.
+	Err error
+
+	retryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("webhook delivery %s failed after %d attempt(s): %v", e.ID, e.Attempts, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Deliver POSTs body to the webhook. Network errors, timeouts, 429 and 5xx
+// responses are retried, honoring Retry-After; other responses outside 2xx
+// fail at once. A delivery that fails for good goes to the dead letter and
+// is returned as an *Error. Deliver gives up with ctx's error, without a
+// dead letter, if ctx is done.
+func (d *Deliverer) Deliver(ctx context.Context, body []byte) (*Result, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	var last *Error
+	for attempt := 1; attempt <= d.cfg.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			if err := d.sleep(ctx, d.delay(attempt-1, last)); err != nil {
+				return nil, err
+			}
+		}
+		status, retryAfter, err := d.attempt(ctx, id, body)
+		if err == nil {
+			return &Result{ID: id, Attempts: attempt, Status: status}, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		last = &Error{ID: id, Attempts: attempt, Status: status, Err: err, retryAfter: retryAfter}
+		if !retryable(status) {
+			break
+		}
+	}
+	if d.cfg.DeadLetter != nil {
+		failure := &Failure{ID: id, URL: d.cfg.URL, Attempts: last.Attempts, Status: last.Status, Error: last.Err.Error(), Body: body, FailedAt: d.now().UTC()}
+		if err := d.cfg.DeadLetter.Store(ctx, failure); err != nil {
+			return nil, fmt.Errorf("%v; dead letter: %w", last, err)
+		}
+	}
+	return nil, last
+}
+
+// Generate processes data with p and delivers the output. Documents that fail
+// p, such as invalid ones, aren't delivered.
+func (d *Deliverer) Generate(ctx context.Context, p pipeline.Pipeline, data []byte) (*Result, pipeline.Report, error) {
+	out, report, err := p.Process(ctx, data)
+	if err != nil {
+		return nil, report, err
+	}
+	result, err := d.Deliver(ctx, out)
+	return result, report, err
+}
+
+// attempt sends one request and returns the response status, the delay the
+// server asked for with Retry-After, and an error unless the status is 2xx.
+func (d *Deliverer) attempt(ctx context.Context, id string, body []byte) (int, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, 0, err
+	}
+	contentType := d.cfg.ContentType
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+	}
+	timestamp := strconv.FormatInt(d.now().Unix(), 10)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set(HeaderDelivery, id)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if len(d.cfg.Secret) > 0 {
+		req.Header.Set(HeaderSignature, Sign(d.cfg.Secret, timestamp, body))
+	}
+
+	resp, err := d.cfg.Client.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, 0, nil
+	}
+	var retryAfter time.Duration
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+	err = fmt.Errorf("%s", resp.Status)
+	if len(snippet) > 0 {
+		err = fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(snippet))
+	}
+	return resp.StatusCode, retryAfter, err
+}
+
+// retryable reports whether an attempt that ended with status (0 for no
+// response) may succeed if repeated.
+func retryable(status int) bool {
+	return status == 0 || status == http.StatusTooManyRequests || status >= 500
+}
+
+// delay returns the wait before the retry following failed attempt n.
+func (d *Deliverer) delay(n int, last *Error) time.Duration {
+	delay := d.cfg.Backoff
+	for i := 1; i < n && delay < d.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.cfg.MaxBackoff {
+		delay = d.cfg.MaxBackoff
+	}
+	delay = delay/2 + time.Duration(mathrand.Int63n(int64(delay/2)+1))
+	if last != nil && last.retryAfter > delay {
+		delay = last.retryAfter
+		if delay > d.cfg.MaxBackoff {
+			delay = d.cfg.MaxBackoff
+		}
+	}
+	return delay
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.New("webhook: can't generate a delivery ID")
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,191 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	schemautil "go-demo/pkg/jsonschema"
+	"go-demo/pkg/pipeline"
+)
+
+// receiver is a webhook endpoint that answers with statuses in turn and
+// records the requests it gets.
+type receiver struct {
+	statuses []int
+
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   []string
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := http.StatusOK
+	if n := len(r.requests); n < len(r.statuses) {
+		status = r.statuses[n]
+	}
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, string(body))
+	if status == http.StatusTooManyRequests {
+		w.Header().Set("Retry-After", "7")
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, http.StatusText(status))
+}
+
+// newDeliverer returns a Deliverer for srv that records its waits instead of
+// sleeping.
+func newDeliverer(t *testing.T, cfg Config, srv *httptest.Server) (*Deliverer, *[]time.Duration) {
+	t.Helper()
+	cfg.URL = srv.URL
+	d, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	var waits []time.Duration
+	d.sleep = func(ctx context.Context, wait time.Duration) error {
+		waits = append(waits, wait)
+		return ctx.Err()
+	}
+	d.now = func() time.Time { return time.Unix(1700000000, 0) }
+	return d, &waits
+}
+
+func TestDeliver(t *testing.T) {
+	rcv := &receiver{statuses: []int{http.StatusBadGateway, http.StatusTooManyRequests, http.StatusAccepted}}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+	secret := []byte("s3cret")
+	d, waits := newDeliverer(t, Config{Secret: secret, Backoff: time.Second}, srv)
+
+	result, err := d.Deliver(context.Background(), []byte(`{"id": 1}`))
+	if err != nil {
+		t.Fatalf("Deliver failed: %v", err)
+	}
+	if result.Attempts != 3 || result.Status != http.StatusAccepted {
+		t.Errorf("expected 3 attempts ending in 202, got %+v", result)
+	}
+	if len(*waits) != 2 || (*waits)[0] < 500*time.Millisecond || (*waits)[0] > time.Second || (*waits)[1] != 7*time.Second {
+		t.Errorf("expected a backoff of 0.5-1s, then the 7s Retry-After, got %v", *waits)
+	}
+	for i, req := range rcv.requests {
+		if got := req.Header.Get(HeaderDelivery); got != result.ID {
+			t.Errorf("request %d: expected delivery ID %q, got %q", i, result.ID, got)
+		}
+		if got := req.Header.Get("Content-Type"); got != "text/plain; charset=utf-8" {
+			t.Errorf("request %d: expected a detected content type, got %q", i, got)
+		}
+		if got := req.Header.Get(HeaderTimestamp); got != "1700000000" {
+			t.Errorf("request %d: expected timestamp 1700000000, got %q", i, got)
+		}
+		if !Verify(secret, req.Header.Get(HeaderTimestamp), []byte(rcv.bodies[i]), req.Header.Get(HeaderSignature)) {
+			t.Errorf("request %d: signature %q doesn't verify", i, req.Header.Get(HeaderSignature))
+		}
+	}
+}
+
+func TestDeliverFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		attempts int
+		status   int
+	}{
+		{"permanent", []int{http.StatusBadRequest}, 1, http.StatusBadRequest},
+		{"exhausted", []int{500, 500, 503}, 3, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(&receiver{statuses: tt.statuses})
+			defer srv.Close()
+			dir := t.TempDir()
+			d, _ := newDeliverer(t, Config{MaxAttempts: 3, DeadLetter: DeadLetterDir(dir)}, srv)
+
+			_, err := d.Deliver(context.Background(), []byte("hello"))
+			var derr *Error
+			if !errors.As(err, &derr) {
+				t.Fatalf("expected an *Error, got %v", err)
+			}
+			if derr.Attempts != tt.attempts || derr.Status != tt.status {
+				t.Errorf("expected %d attempts ending in %d, got %+v", tt.attempts, tt.status, derr)
+			}
+			f, err := ReadFailure(filepath.Join(dir, derr.ID+".json"))
+			if err != nil {
+				t.Fatalf("expected a dead letter: %v", err)
+			}
+			if f.URL != srv.URL || f.Attempts != tt.attempts || f.Status != tt.status || string(f.Body) != "hello" || !strings.Contains(f.Error, http.StatusText(tt.status)) {
+				t.Errorf("unexpected dead letter %+v", f)
+			}
+		})
+	}
+}
+
+func TestDeliverUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	d, waits := newDeliverer(t, Config{MaxAttempts: 2}, srv)
+	_, err := d.Deliver(context.Background(), []byte("x"))
+	var derr *Error
+	if !errors.As(err, &derr) || derr.Attempts != 2 || derr.Status != 0 || len(*waits) != 1 {
+		t.Errorf("expected two failed attempts without a response, got %v, waits %v", err, *waits)
+	}
+}
+
+func TestDeliverCanceled(t *testing.T) {
+	srv := httptest.NewServer(&receiver{statuses: []int{500}})
+	defer srv.Close()
+	dir := t.TempDir()
+	d, _ := newDeliverer(t, Config{DeadLetter: DeadLetterDir(dir)}, srv)
+	ctx, cancel := context.WithCancel(context.Background())
+	d.sleep = func(context.Context, time.Duration) error {
+		cancel()
+		return ctx.Err()
+	}
+	if _, err := d.Deliver(ctx, []byte("x")); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected no dead letter, got %d", len(entries))
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, u := range []string{"", "example.com/hook", "ftp://example.com", "http://"} {
+		if _, err := New(Config{URL: u}); err == nil {
+			t.Errorf("%q: expected an error", u)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	rcv := &receiver{}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+	d, _ := newDeliverer(t, Config{ContentType: "text/plain"}, srv)
+	schema, err := schemautil.CompileString(`{"type": "object", "required": ["name"]}`)
+	if err != nil {
+		t.Fatalf("Failed to compile schema: %v", err)
+	}
+	p := pipeline.Pipeline{Schema: schema, Template: "Hi {{ name }}"}
+
+	if _, _, err := d.Generate(context.Background(), p, []byte(`{"name": "Ada"}`)); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if _, report, err := d.Generate(context.Background(), p, []byte(`{}`)); !errors.Is(err, pipeline.ErrInvalid) || report.Valid() {
+		t.Fatalf("expected ErrInvalid, got %v", err)
+	}
+	if len(rcv.bodies) != 1 || rcv.bodies[0] != "Hi Ada" || rcv.requests[0].Header.Get("Content-Type") != "text/plain" {
+		t.Errorf("expected only the valid document to be delivered, got %q", rcv.bodies)
+	}
+}